@@ -0,0 +1,113 @@
+// cmd/numio-cli/convert.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// convertFlags are the flags of the convert command.
+var convertFlags = []flag{
+	{name: "rate-source", arg: "provider", usage: "Fetch rates from a provider first"},
+	{name: "date", arg: "YYYY-MM-DD", usage: "Use the fiat rates of a past day"},
+}
+
+// runConvert handles "convert <amount> <from> <to>" and
+// "convert <value> <to>" ("convert 100 USD EUR", "convert 5km mi"), with
+// [--rate-source <provider>] [--date YYYY-MM-DD].
+func runConvert(opts options, words []string) {
+	provider := opts.values["rate-source"]
+	var date time.Time
+	if s := opts.values["date"]; s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: --date must be YYYY-MM-DD")
+			os.Exit(1)
+		}
+		date = d
+	}
+
+	if len(words) < 2 {
+		fmt.Fprintln(os.Stderr, "Usage: numio convert <amount> <from> <to> [--rate-source <provider>] [--date YYYY-MM-DD] [--json]")
+		os.Exit(1)
+	}
+
+	// "100 USD to EUR" reads the same as "100 USD EUR"
+	target := words[len(words)-1]
+	value := words[:len(words)-1]
+	if n := len(value); n > 1 && (strings.EqualFold(value[n-1], "to") || strings.EqualFold(value[n-1], "in")) {
+		value = value[:n-1]
+	}
+
+	eng := opts.newEngine()
+	if provider != "" || !date.IsZero() {
+		opts.requireNetwork("--rate-source or --date")
+		ctx, cancel := context.WithTimeout(context.Background(), cache.DefaultRefreshTimeout)
+		defer cancel()
+		if _, err := eng.RefreshRatesFrom(ctx, provider, date); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	result := eng.Eval(strings.Join(value, " ") + " in " + target)
+	if result.IsError() {
+		if opts.json {
+			printJSON(valueJSON(eng, result))
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", result.ErrorMessage())
+		}
+		os.Exit(1)
+	}
+
+	conv, ok := eng.LastConversion()
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: cannot convert %s to %s\n", strings.Join(value, " "), target)
+		os.Exit(1)
+	}
+
+	if opts.json {
+		printJSON(conversionJSON(eng, conv, provider, date))
+		return
+	}
+
+	var source []string
+	if provider != "" {
+		source = append(source, provider)
+	}
+	if !date.IsZero() {
+		source = append(source, date.Format("2006-01-02"))
+	}
+	printConversion(eng, conv, strings.Join(source, " "))
+}
+
+// conversionJSON returns the JSON form of a conversion, with the rate
+// details when an exchange rate was used.
+func conversionJSON(eng *engine.Engine, c engine.Conversion, provider string, date time.Time) map[string]any {
+	m := map[string]any{
+		"from": valueJSON(eng, c.From),
+		"to":   valueJSON(eng, c.To),
+	}
+	if !c.HasRate() {
+		return m
+	}
+
+	m["rate"] = c.Rate
+	m["path"] = c.Path
+	if provider != "" {
+		m["rate_source"] = provider
+	}
+	if !date.IsZero() {
+		m["date"] = date.Format("2006-01-02")
+	} else if c.Age > 0 {
+		m["rate_age_seconds"] = int(c.Age.Seconds())
+	}
+	return m
+}
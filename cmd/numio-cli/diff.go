@@ -0,0 +1,151 @@
+// cmd/numio-cli/diff.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// runDiff handles "diff <old> <new>": which results changed between two
+// versions of a document, and by how much.
+func runDiff(opts options, args []string) {
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff <old.calc> <new.calc>\n", appName)
+		os.Exit(1)
+	}
+
+	eng := opts.newEngine()
+	before := snapshotFile(eng, args[0])
+	after := snapshotFile(eng, args[1])
+	d := engine.Diff(before, after)
+
+	if opts.json {
+		printJSON(diffJSON(eng, d))
+		return
+	}
+	printDiff(eng, d)
+}
+
+// snapshotFile evaluates a file, with imports resolved against its
+// directory, exiting if it can't be read.
+func snapshotFile(eng *engine.Engine, path string) engine.Snapshot {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	eng.SetImportDir(filepath.Dir(path))
+	return eng.Snapshot(string(data))
+}
+
+// printDiff prints each changed line, marked ~, + or -, with its results
+// before and after, then the change in the total:
+//
+//	~ 3: rent = $1300
+//	    $1,200.00 → $1,300.00  (+$100.00, +8.33%)
+func printDiff(eng *engine.Engine, d engine.Difference) {
+	if len(d.Changes) == 0 && d.Total.Kind == engine.LineUnchanged {
+		fmt.Println("No results changed.")
+		return
+	}
+
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case engine.LineChanged:
+			fmt.Printf("~ %d: %s\n", c.After.Line, strings.TrimSpace(c.After.Input))
+			fmt.Printf("    %s\n", changeText(eng, c))
+		case engine.LineAdded:
+			fmt.Printf("+ %d: %s\n", c.After.Line, strings.TrimSpace(c.After.Input))
+			fmt.Printf("    %s\n", diffValue(eng, c.After.Value))
+		case engine.LineRemoved:
+			fmt.Printf("- %d: %s\n", c.Before.Line, strings.TrimSpace(c.Before.Input))
+			fmt.Printf("    %s\n", diffValue(eng, c.Before.Value))
+		}
+	}
+	if d.Total.Kind == engine.LineChanged {
+		fmt.Printf("total: %s\n", changeText(eng, d.Total))
+	}
+}
+
+// changeText describes a changed result: "$1,200.00 → $1,300.00
+// (+$100.00, +8.33%)".
+func changeText(eng *engine.Engine, c engine.Change) string {
+	text := diffValue(eng, c.Before.Value) + " → " + diffValue(eng, c.After.Value)
+	delta, ok := c.Delta()
+	if !ok {
+		return text
+	}
+	by := signed(eng, delta)
+	if percent, ok := c.Percent(); ok {
+		by += ", " + signed(eng, percent)
+	}
+	return text + "  (" + by + ")"
+}
+
+// diffValue formats a result, or an error's message.
+func diffValue(eng *engine.Engine, v types.Value) string {
+	if v.IsError() {
+		return "error: " + v.ErrorMessage()
+	}
+	if v.IsEmpty() {
+		return "nothing"
+	}
+	return display(eng, v)
+}
+
+// signed formats an amount with its sign, + included.
+func signed(eng *engine.Engine, v types.Value) string {
+	if v.Num > 0 {
+		return "+" + eng.Format(v)
+	}
+	return eng.Format(v)
+}
+
+// changeJSON is the JSON form of a changed line.
+type changeJSON struct {
+	Change     string         `json:"change"`
+	BeforeLine int            `json:"before_line,omitempty"`
+	AfterLine  int            `json:"after_line,omitempty"`
+	Input      string         `json:"input,omitempty"`
+	Before     map[string]any `json:"before,omitempty"`
+	After      map[string]any `json:"after,omitempty"`
+	Delta      map[string]any `json:"delta,omitempty"`
+	Percent    map[string]any `json:"percent,omitempty"`
+}
+
+// diffJSON returns the JSON form of a difference.
+func diffJSON(eng *engine.Engine, d engine.Difference) map[string]any {
+	changes := make([]changeJSON, 0, len(d.Changes))
+	for _, c := range d.Changes {
+		changes = append(changes, toChangeJSON(eng, c))
+	}
+	return map[string]any{
+		"changes": changes,
+		"total":   toChangeJSON(eng, d.Total),
+	}
+}
+
+func toChangeJSON(eng *engine.Engine, c engine.Change) changeJSON {
+	out := changeJSON{Change: c.Kind.String(), BeforeLine: c.Before.Line, AfterLine: c.After.Line}
+	if c.Kind != engine.LineAdded {
+		out.Input = strings.TrimSpace(c.Before.Input)
+		out.Before = valueJSON(eng, c.Before.Value)
+	}
+	if c.Kind != engine.LineRemoved {
+		out.Input = strings.TrimSpace(c.After.Input)
+		out.After = valueJSON(eng, c.After.Value)
+	}
+	if delta, ok := c.Delta(); ok && c.Kind == engine.LineChanged {
+		out.Delta = valueJSON(eng, delta)
+		if percent, ok := c.Percent(); ok {
+			out.Percent = valueJSON(eng, percent)
+		}
+	}
+	return out
+}
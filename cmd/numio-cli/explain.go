@@ -0,0 +1,108 @@
+// cmd/numio-cli/explain.go
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// explainExpr prints how an expression evaluates (--explain): its parse
+// tree, the value of each part, the rates and variables it used, and the
+// result. With --json it prints them as JSON.
+func explainExpr(eng *engine.Engine, input string, opts options) {
+	x := eng.Explain(input)
+	if opts.json {
+		printJSON(explanationJSON(eng, x))
+		return
+	}
+
+	if x.Tree != "" {
+		fmt.Println("Parse tree:")
+		printIndented(x.Tree)
+	}
+	if len(x.Steps) > 0 {
+		fmt.Println("Steps:")
+		for _, s := range x.Steps {
+			fmt.Printf("  %s%s = %s\n", strings.Repeat("  ", s.Depth), s.Expr, display(eng, s.Value))
+		}
+	}
+	if len(x.Rates) > 0 {
+		fmt.Println("Rates:")
+		for _, r := range x.Rates {
+			fmt.Printf("  1 %s = %s %s (%s)\n", r.From, strconv.FormatFloat(r.Rate, 'g', 6, 64), r.To, rateAge(r))
+		}
+	}
+	if len(x.Reads) > 0 {
+		fmt.Println("Variables read:")
+		for _, name := range x.Reads {
+			if d := x.Descriptions[name]; d != "" {
+				fmt.Printf("  %s  # %s\n", name, d)
+			} else {
+				fmt.Printf("  %s\n", name)
+			}
+		}
+	}
+	printResult(eng, x.Result)
+}
+
+// printStep prints a step of a traced evaluation (set trace on), as
+// "20% of 150 → 30". Literals are left out: they are what was written.
+func printStep(eng *engine.Engine, s engine.Step) {
+	if s.Literal {
+		return
+	}
+	value := display(eng, s.Value)
+	if s.Value.IsError() {
+		value = "error: " + s.Value.ErrorMessage()
+	}
+	fmt.Printf("  %s → %s\n", s.Expr, value)
+}
+
+// printIndented prints each line of s indented by two spaces.
+func printIndented(s string) {
+	for _, line := range strings.Split(s, "\n") {
+		fmt.Println("  " + line)
+	}
+}
+
+// rateAge describes where a rate came from.
+func rateAge(r engine.RateUse) string {
+	if r.Fetched.IsZero() {
+		return "built-in"
+	}
+	return "fetched " + r.Fetched.Local().Format("2006-01-02 15:04")
+}
+
+// explanationJSON is the JSON form of an explanation.
+func explanationJSON(eng *engine.Engine, x engine.Explanation) map[string]any {
+	steps := make([]map[string]any, len(x.Steps))
+	for i, s := range x.Steps {
+		steps[i] = map[string]any{"expr": s.Expr, "depth": s.Depth, "value": valueJSON(eng, s.Value)}
+	}
+	rates := make([]map[string]any, len(x.Rates))
+	for i, r := range x.Rates {
+		rate := map[string]any{"from": r.From, "to": r.To, "rate": r.Rate}
+		if !r.Fetched.IsZero() {
+			rate["fetched"] = r.Fetched.Unix()
+		}
+		rates[i] = rate
+	}
+
+	var tree []string
+	if x.Tree != "" {
+		tree = strings.Split(x.Tree, "\n")
+	}
+	return map[string]any{
+		"input":        x.Input,
+		"tree":         tree,
+		"steps":        steps,
+		"rates":        rates,
+		"reads":        append([]string{}, x.Reads...),
+		"descriptions": x.Descriptions,
+		"result":       valueJSON(eng, x.Result),
+	}
+}
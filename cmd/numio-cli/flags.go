@@ -0,0 +1,414 @@
+// cmd/numio-cli/flags.go
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/0xsj/numio/internal/config"
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/errors"
+)
+
+// options holds the flags given on the command line and in the
+// environment.
+type options struct {
+	json     bool                 // --json
+	export   *engine.ExportFormat // --export csv|tsv
+	markdown bool                 // --export md
+	watch    bool                 // --watch
+	explain  bool                 // --explain
+
+	config    string    // --config: config file, instead of the default
+	settings  []setting // Engine settings, applied in order
+	noNetwork bool      // --no-network: never fetch rates
+	logLevel  string    // --log-level: log to stderr at this level; "" = don't log
+	logJSON   bool      // --log-format json
+	dir       string    // Where imports resolve: the file's directory, or the working directory
+	inputs    []setting // Values of a template's inputs: --input name=value
+
+	// Values of command flags (e.g. "rate-source" for convert), by name
+	values map[string]string
+}
+
+// setting is an engine setting from a flag or environment variable.
+type setting struct {
+	source string // Where it was given, for errors: "--precision"
+	name   string
+	value  string
+}
+
+// flag is a command line flag, given as --name, --name=value or
+// "--name value" (or -short). Switches take an optional "=on/off".
+type flag struct {
+	name  string // Long name, without "--"
+	short string // Short name, without "-"
+	arg   string // Value name for help; "" for switches
+	env   string // Environment variable setting the flag, if any
+	usage string
+
+	// set applies the flag; nil stores the value in options.values
+	set func(o *options, value string) error
+}
+
+// globalFlags apply to every command and the REPL.
+var globalFlags = []flag{
+	{name: "precision", arg: "n", env: "NUMIO_PRECISION", usage: "Decimal places shown (0-15, auto)",
+		set: engineSetting("precision")},
+	{name: "sigfig", arg: "n", env: "NUMIO_SIGFIG", usage: "Significant figures shown (1-15, off)",
+		set: engineSetting("sigfig")},
+	{name: "rounding", arg: "mode", env: "NUMIO_ROUNDING", usage: "Rounding: half-up, half-even, floor, ceiling",
+		set: engineSetting("rounding")},
+	{name: "locale", arg: "name", env: "NUMIO_LOCALE", usage: "Number input: en (1,234.56) or eu (1.234,56, args split by ;)",
+		set: engineSetting("locale")},
+	{name: "currencies", arg: "style", env: "NUMIO_CURRENCIES", usage: "Show currencies as symbol ($100.00) or code (100.00 USD)",
+		set: engineSetting("currencies")},
+	{name: "seed", arg: "n", env: "NUMIO_SEED", usage: "Seed for rand(), randint(), sample() and dice",
+		set: engineSetting("seed")},
+	{name: "strict", env: "NUMIO_STRICT", usage: "Error on undefined variables",
+		set: engineSetting("strict")},
+	{name: "as-of", env: "NUMIO_AS_OF", usage: "Show when the rates behind converted amounts were fetched",
+		set: engineSetting("as of")},
+	{name: "no-network", env: "NUMIO_NO_NETWORK", usage: "Never fetch rates from the network",
+		set: func(o *options, value string) error {
+			on, err := parseOnOff(value)
+			o.noNetwork = on
+			return err
+		}},
+	{name: "log-level", arg: "level", env: "NUMIO_LOG_LEVEL", usage: "Log rate refreshes and failures to stderr: debug, info, warn, error",
+		set: func(o *options, value string) error {
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("unknown log level: %s", value)
+			}
+			o.logLevel = value
+			return nil
+		}},
+	{name: "log-format", arg: "fmt", env: "NUMIO_LOG_FORMAT", usage: "Log as text (default) or json",
+		set: func(o *options, value string) error {
+			switch strings.ToLower(value) {
+			case "text":
+				o.logJSON = false
+			case "json":
+				o.logJSON = true
+			default:
+				return fmt.Errorf("unknown log format: %s", value)
+			}
+			return nil
+		}},
+	{name: "config", arg: "path", env: "NUMIO_CONFIG", usage: "Config file (default ~/.config/numio/config.toml)",
+		set: func(o *options, value string) error {
+			o.config = value
+			return nil
+		}},
+	{name: "json", usage: "Print results as JSON",
+		set: func(o *options, value string) error {
+			on, err := parseOnOff(value)
+			o.json = on
+			return err
+		}},
+	{name: "export", arg: "fmt", usage: "Export results (csv, tsv, md)",
+		set: func(o *options, value string) error {
+			if isMarkdownFormat(value) {
+				o.markdown = true
+				return nil
+			}
+			format, ok := engine.ParseExportFormat(value)
+			if !ok {
+				return fmt.Errorf("unknown export format: %s", value)
+			}
+			o.export = &format
+			return nil
+		}},
+	{name: "explain", usage: "Show how an expression evaluates: parse tree, steps, rates, variables",
+		set: func(o *options, value string) error {
+			on, err := parseOnOff(value)
+			o.explain = on
+			return err
+		}},
+	{name: "input", arg: "name=value", usage: "Give a template's input a value, instead of being asked (repeatable)",
+		set: func(o *options, value string) error {
+			name, text, ok := strings.Cut(value, "=")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				return fmt.Errorf("want name=value, got %q", value)
+			}
+			o.inputs = append(o.inputs, setting{source: "--input " + name, name: name, value: text})
+			return nil
+		}},
+	{name: "watch", short: "w", usage: "Re-evaluate a file (-f) whenever it changes",
+		set: func(o *options, value string) error {
+			on, err := parseOnOff(value)
+			o.watch = on
+			return err
+		}},
+}
+
+// engineSetting returns a flag setter that records an engine setting.
+func engineSetting(name string) func(o *options, value string) error {
+	return func(o *options, value string) error {
+		o.settings = append(o.settings, setting{name: name, value: value})
+		return nil
+	}
+}
+
+// parseOnOff parses a switch value.
+func parseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off, got %q", s)
+}
+
+// ════════════════════════════════════════════════════════════════
+// COMMANDS
+// ════════════════════════════════════════════════════════════════
+
+// command is a subcommand ("serve") or a mode selected by a flag ("-f").
+type command struct {
+	names []string
+	flags []flag // Flags the command takes besides the global ones
+	run   func(opts options, args []string)
+}
+
+// lookupCommand returns the command named name, or nil.
+func lookupCommand(name string) *command {
+	for i := range commands {
+		for _, n := range commands[i].names {
+			if n == name {
+				return &commands[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// PARSING
+// ════════════════════════════════════════════════════════════════
+
+// parseArgs parses the command line. Environment variables apply first,
+// so flags override them. The command is the first non-flag argument
+// naming one; nil means the REPL (no arguments) or an expression. Other
+// arguments are returned in order; "--" ends flag parsing.
+func parseArgs(args []string) (options, *command, []string, error) {
+	opts := options{values: make(map[string]string)}
+
+	for _, f := range globalFlags {
+		if value := os.Getenv(f.env); f.env != "" && value != "" {
+			n := len(opts.settings)
+			if err := f.set(&opts, value); err != nil {
+				return opts, nil, nil, fmt.Errorf("%s: %v", f.env, err)
+			}
+			for i := n; i < len(opts.settings); i++ {
+				opts.settings[i].source = f.env
+			}
+		}
+	}
+
+	var cmd *command
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+
+		if cmd == nil && len(rest) == 0 {
+			if c := lookupCommand(arg); c != nil {
+				cmd = c
+				continue
+			}
+		}
+
+		f, value, hasValue := lookupFlag(arg, cmd)
+		if f == nil {
+			if strings.HasPrefix(arg, "--") {
+				return opts, nil, nil, fmt.Errorf("unknown flag: %s", arg)
+			}
+			// Single-dash arguments may be negative numbers: "-5 + 3"
+			rest = append(rest, arg)
+			continue
+		}
+
+		switch {
+		case f.arg == "" && !hasValue:
+			value = "on"
+		case f.arg != "" && !hasValue:
+			if i+1 >= len(args) {
+				return opts, nil, nil, fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+
+		if f.set == nil {
+			opts.values[f.name] = value
+			continue
+		}
+		n := len(opts.settings)
+		if err := f.set(&opts, value); err != nil {
+			return opts, nil, nil, fmt.Errorf("--%s: %v", f.name, err)
+		}
+		for i := n; i < len(opts.settings); i++ {
+			opts.settings[i].source = "--" + f.name
+		}
+	}
+
+	return opts, cmd, rest, nil
+}
+
+// lookupFlag finds the global or command flag arg names, splitting off a
+// "=value".
+func lookupFlag(arg string, cmd *command) (*flag, string, bool) {
+	var name string
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		name = arg[2:]
+	case strings.HasPrefix(arg, "-") && len(arg) > 1:
+		name = arg[1:]
+	default:
+		return nil, "", false
+	}
+	name, value, hasValue := strings.Cut(name, "=")
+	long := strings.HasPrefix(arg, "--")
+
+	flags := globalFlags
+	if cmd != nil {
+		flags = append(append([]flag(nil), cmd.flags...), globalFlags...)
+	}
+	for i := range flags {
+		f := &flags[i]
+		if (long && f.name == name) || (!long && f.short != "" && f.short == name) {
+			return f, value, hasValue
+		}
+	}
+	return nil, "", false
+}
+
+// ════════════════════════════════════════════════════════════════
+// ENGINES
+// ════════════════════════════════════════════════════════════════
+
+// newEngine creates an engine with the config file and then the flag and
+// environment settings applied, exiting on invalid settings.
+func (o options) newEngine() *engine.Engine {
+	cfg := o.loadConfig()
+	eng, err := cfg.NewEngine()
+	if err == nil {
+		err = cfg.ApplyProviders()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, s := range o.settings {
+		if err := eng.ApplySetting(s.name, s.value); err != nil {
+			msg := err.Error()
+			if ee, ok := err.(*errors.Error); ok {
+				msg = ee.Message
+			}
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", s.source, msg)
+			os.Exit(1)
+		}
+	}
+
+	if o.noNetwork {
+		eng.RateCache().SetOffline(true)
+	}
+	if logger := o.logger(); logger != nil {
+		eng.SetLogger(logger)
+	}
+
+	for _, in := range o.inputs {
+		if v := eng.SetInputText(in.name, in.value); v.IsError() {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", in.source, v.ErrorMessage())
+			os.Exit(1)
+		}
+	}
+	return eng
+}
+
+// localEngine creates an engine as newEngine does, for evaluating the
+// user's own file or REPL input, with imports resolved against the
+// file's directory or the working directory.
+func (o options) localEngine() *engine.Engine {
+	eng := o.newEngine()
+	dir := o.dir
+	if dir == "" {
+		dir = "."
+	}
+	eng.SetImportDir(dir)
+	return eng
+}
+
+// logger returns the logger --log-level and --log-format ask for, or nil
+// if logging is off.
+func (o options) logger() *slog.Logger {
+	if o.logLevel == "" {
+		return nil
+	}
+	var level slog.Level
+	level.UnmarshalText([]byte(o.logLevel))
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if o.logJSON {
+		return slog.New(slog.NewJSONHandler(os.Stderr, handlerOpts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, handlerOpts))
+}
+
+// loadConfig loads the --config file, which must exist, or else the
+// default config file if there is one.
+func (o options) loadConfig() *config.Config {
+	path := o.config
+	if path == "" {
+		path = config.Path()
+	} else if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// requireNetwork exits when network access is disabled.
+func (o options) requireNetwork(what string) {
+	if o.noNetwork {
+		fmt.Fprintf(os.Stderr, "Error: %s needs the network, which --no-network disables\n", what)
+		os.Exit(1)
+	}
+}
+
+// flagUsage formats flags for help, one per line.
+func flagUsage(flags []flag) string {
+	var b strings.Builder
+	for _, f := range flags {
+		names := "    "
+		if f.short != "" {
+			names = "-" + f.short + ", "
+		}
+		names += "--" + f.name
+		if f.arg != "" {
+			names += " <" + f.arg + ">"
+		}
+		usage := f.usage
+		if f.env != "" {
+			usage += " [$" + f.env + "]"
+		}
+		fmt.Fprintf(&b, "  %-26s %s\n", names, usage)
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+// cmd/numio-cli/input.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/0xsj/numio/internal/readline"
+)
+
+// askInputs asks for the inputs a template declares that weren't given
+// with --input, and returns the answers as further --input values. It
+// asks only at a terminal; elsewhere the input lines are errors.
+func askInputs(content string, opts options) []setting {
+	if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+		return nil
+	}
+	eng := opts.newEngine()
+	missing := eng.MissingInputs(strings.Split(content, "\n"))
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var answers []setting
+	for _, in := range missing {
+		prompt := in.Name
+		if in.Prompt != "" {
+			prompt = in.Prompt + " (" + in.Name + ")"
+		}
+		editor := readline.New(prompt+": ", nil)
+
+		for {
+			text, err := editor.ReadLine()
+			if err == readline.ErrInterrupt {
+				os.Exit(1)
+			}
+			if err != nil {
+				// EOF leaves the rest unanswered
+				return answers
+			}
+			if v := eng.SetInputText(in.Name, text); v.IsError() {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", v.ErrorMessage())
+				continue
+			}
+			answers = append(answers, setting{source: "input " + in.Name, name: in.Name, value: text})
+			break
+		}
+	}
+	return answers
+}
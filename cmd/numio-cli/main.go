@@ -4,10 +4,20 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/0xsj/numio/internal/readline"
+	"github.com/0xsj/numio/internal/server"
 	"github.com/0xsj/numio/pkg/engine"
 	"github.com/0xsj/numio/pkg/types"
 )
@@ -17,86 +27,233 @@ const (
 	appVersion = "0.1.0"
 )
 
+// verbose makes the REPL show the path conversions took (set verbose on).
+var verbose bool
+
 func main() {
-	// Check for command line arguments
-	if len(os.Args) > 1 {
-		handleArgs(os.Args[1:])
-		return
+	opts, cmd, args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Start REPL
-	runREPL()
+	switch {
+	case cmd != nil:
+		cmd.run(opts, args)
+	case len(args) > 0:
+		// Treat as expression
+		evalArgs(args, opts)
+	default:
+		runREPL(opts)
+	}
 }
 
-// handleArgs processes command line arguments.
-func handleArgs(args []string) {
-	switch args[0] {
-	case "-h", "--help", "help":
-		printHelp()
-
-	case "-v", "--version", "version":
+// commands are the subcommands and the modes selected by flags.
+var commands = []command{
+	{names: []string{"-h", "--help", "help"}, run: func(options, []string) { printHelp() }},
+	{names: []string{"-v", "--version", "version"}, run: func(options, []string) {
 		fmt.Printf("%s v%s\n", appName, appVersion)
-
-	case "-e", "--eval":
-		if len(args) < 2 {
+	}},
+	{names: []string{"-e", "--eval"}, run: func(opts options, args []string) {
+		if len(args) == 0 {
 			fmt.Fprintln(os.Stderr, "Error: -e requires an expression")
 			os.Exit(1)
 		}
-		// Evaluate expression and print result
-		result := engine.QuickEval(strings.Join(args[1:], " "))
-		printResult(result)
-
-	case "-f", "--file":
-		if len(args) < 2 {
+		evalArgs(args, opts)
+	}},
+	{names: []string{"-f", "--file"}, run: func(opts options, args []string) {
+		if len(args) == 0 {
 			fmt.Fprintln(os.Stderr, "Error: -f requires a filename")
 			os.Exit(1)
 		}
-		runFile(args[1])
+		runFile(args[0], opts)
+	}},
+	{names: []string{"--stdin", "-"}, run: func(opts options, _ []string) { runStdin(opts) }},
+	{names: []string{"--serve-stdio"}, run: func(opts options, _ []string) { serveStdio(opts, server.DefaultEvalTimeout) }},
+	{names: []string{"serve"}, flags: serveFlags, run: runServe},
+	{names: []string{"convert"}, flags: convertFlags, run: runConvert},
+	{names: []string{"rates"}, run: runRates},
+	{names: []string{"diff"}, run: runDiff},
+	{names: []string{"constants"}, run: func(opts options, _ []string) { printConstants(opts) }},
+}
 
+// isMarkdownFormat reports whether an export format names Markdown.
+func isMarkdownFormat(s string) bool {
+	switch strings.ToLower(s) {
+	case "md", "markdown":
+		return true
+	}
+	return false
+}
+
+// evalArgs evaluates an expression given as arguments and prints the result.
+func evalArgs(args []string, opts options) {
+	eng := opts.newEngine()
+	if opts.markdown {
+		exportMarkdown(eng, strings.Join(args, " "))
+		return
+	}
+	if opts.explain {
+		explainExpr(eng, strings.Join(args, " "), opts)
+		return
+	}
+
+	result := eng.Eval(strings.Join(args, " "))
+
+	switch {
+	case opts.export != nil:
+		exportTo(os.Stdout, eng, *opts.export)
+	case opts.json:
+		printJSON(valueJSON(eng, result))
 	default:
-		// Treat as expression
-		result := engine.QuickEval(strings.Join(args, " "))
-		printResult(result)
+		printResult(eng, result)
 	}
 }
 
+// lineJSON is the JSON form of a file line result.
+type lineJSON struct {
+	Line   int            `json:"line"`
+	Input  string         `json:"input"`
+	Result map[string]any `json:"result"`
+}
+
 // runFile evaluates a file.
-func runFile(filename string) {
+func runFile(filename string, opts options) {
+	opts.dir = filepath.Dir(filename)
+	if opts.watch {
+		watchFile(filename, opts)
+		return
+	}
+
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 		os.Exit(1)
 	}
+	opts.inputs = append(opts.inputs, askInputs(string(data), opts)...)
+	printFile(string(data), opts)
+}
 
-	eng := engine.New()
-	lines := strings.Split(string(data), "\n")
+// printFile evaluates file contents with a fresh engine and prints the results.
+func printFile(content string, opts options) {
+	eng := opts.localEngine()
+	if opts.markdown {
+		exportMarkdown(eng, content)
+		return
+	}
 
-	for i, line := range lines {
-		result := eng.Eval(line)
+	lines := strings.Split(content, "\n")
+	results := eng.EvalFile(content)
+
+	if opts.export != nil {
+		exportTo(os.Stdout, eng, *opts.export)
+		return
+	}
+
+	if opts.json {
+		out := make([]lineJSON, 0, len(results))
+		for i, result := range results {
+			if result.IsEmpty() {
+				continue
+			}
+			out = append(out, lineJSON{
+				Line:   i + 1,
+				Input:  lines[i],
+				Result: valueJSON(eng, result),
+			})
+		}
+		printJSON(out)
+		return
+	}
+
+	for i, result := range results {
 		if !result.IsEmpty() {
 			if result.IsError() {
 				fmt.Fprintf(os.Stderr, "Line %d: %s\n", i+1, result.ErrorMessage())
 			} else {
-				fmt.Println(result.String())
+				fmt.Println(display(eng, result))
 			}
 		}
 	}
 }
 
+// runStdin evaluates lines from standard input as they arrive, keeping
+// state across lines. Results are printed one per line (or one JSON
+// object per line with --json); empty results print nothing.
+func runStdin(opts options) {
+	eng := opts.newEngine()
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		result := eng.Eval(line)
+		if result.IsEmpty() {
+			continue
+		}
+
+		switch {
+		case opts.json:
+			printJSONLine(lineJSON{Line: n, Input: line, Result: valueJSON(eng, result)})
+		case result.IsError():
+			fmt.Fprintf(os.Stderr, "Line %d: %s\n", n, result.ErrorMessage())
+		default:
+			fmt.Println(display(eng, result))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.export != nil {
+		exportTo(os.Stdout, eng, *opts.export)
+	}
+}
+
+// exportMarkdown evaluates content and prints it as a Markdown report.
+func exportMarkdown(eng *engine.Engine, content string) {
+	if err := eng.ExportMarkdown(os.Stdout, content); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportTo writes the engine's line history to w, exiting on failure.
+func exportTo(w io.Writer, eng *engine.Engine, format engine.ExportFormat) {
+	if err := eng.Export(w, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 // runREPL starts the interactive REPL.
-func runREPL() {
+func runREPL(opts options) {
 	printBanner()
 
-	eng := engine.New()
-	reader := bufio.NewReader(os.Stdin)
+	eng := opts.localEngine()
 
-	for {
-		fmt.Print("> ")
+	history, err := readline.LoadHistory(historyPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: history: %v\n", err)
+	}
+	editor := readline.New("> ", history)
+	editor.Complete = func(before string) (string, []string) {
+		var labels []string
+		for _, c := range eng.Complete(before) {
+			labels = append(labels, c.Label)
+		}
+		return engine.CompletionPrefix(before), labels
+	}
 
-		line, err := reader.ReadString('\n')
+	for {
+		line, err := editor.ReadLine()
+		if err == readline.ErrInterrupt {
+			continue
+		}
 		if err != nil {
 			// EOF or error
-			fmt.Println()
 			break
 		}
 
@@ -104,6 +261,9 @@ func runREPL() {
 		if line == "" {
 			continue
 		}
+		if err := history.Add(line); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: history: %v\n", err)
+		}
 
 		// Check for commands
 		if handleCommand(line, eng) {
@@ -112,10 +272,23 @@ func runREPL() {
 
 		// Evaluate expression
 		result := eng.Eval(line)
-		printResult(result)
+		if conv, ok := eng.LastConversion(); ok {
+			printConversion(eng, conv, "")
+			continue
+		}
+		printResult(eng, result)
 	}
 }
 
+// historyPath returns the REPL history file, ~/.numio/history.
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".numio", "history")
+}
+
 // handleCommand processes REPL commands. Returns true if it was a command.
 func handleCommand(input string, eng *engine.Engine) bool {
 	lower := strings.ToLower(input)
@@ -131,6 +304,7 @@ func handleCommand(input string, eng *engine.Engine) bool {
 
 	case lower == "clear" || lower == "cls":
 		eng.Clear()
+		eng.ClearPins()
 		fmt.Println("Cleared.")
 		return true
 
@@ -138,9 +312,14 @@ func handleCommand(input string, eng *engine.Engine) bool {
 		printVariables(eng)
 		return true
 
+	case lower == "vars --all" || lower == "variables --all":
+		printVariables(eng)
+		printBlocks(eng)
+		return true
+
 	case lower == "total":
 		result := eng.Total()
-		fmt.Printf("Total: %s\n", result.String())
+		fmt.Printf("Total: %s\n", eng.Format(result))
 		return true
 
 	case lower == "totals":
@@ -155,10 +334,26 @@ func handleCommand(input string, eng *engine.Engine) bool {
 		printRateInfo(eng)
 		return true
 
+	case lower == "constants":
+		printConstants(options{})
+		return true
+
 	case strings.HasPrefix(lower, "set "):
 		handleSet(input[4:], eng)
 		return true
 
+	case lower == "export" || strings.HasPrefix(lower, "export "):
+		exportSession(strings.Fields(input)[1:], eng)
+		return true
+
+	case strings.HasPrefix(lower, "save "):
+		saveSession(strings.TrimSpace(input[5:]), eng)
+		return true
+
+	case strings.HasPrefix(lower, "load "):
+		loadSession(strings.TrimSpace(input[5:]), eng)
+		return true
+
 	case strings.HasPrefix(lower, "del ") || strings.HasPrefix(lower, "delete "):
 		name := strings.TrimPrefix(lower, "del ")
 		name = strings.TrimPrefix(name, "delete ")
@@ -171,12 +366,79 @@ func handleCommand(input string, eng *engine.Engine) bool {
 	return false
 }
 
+// exportSession handles "export [csv|tsv] [file]".
+// Without a file, the table is printed.
+func exportSession(args []string, eng *engine.Engine) {
+	format := engine.ExportCSV
+	if len(args) > 0 {
+		f, ok := engine.ParseExportFormat(args[0])
+		if !ok {
+			fmt.Println("Usage: export [csv|tsv] [file]")
+			return
+		}
+		format = f
+	}
+
+	if len(args) < 2 {
+		if err := eng.Export(os.Stdout, format); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		return
+	}
+
+	f, err := os.Create(args[1])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := eng.Export(f, format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Exported %s to %s\n", format, args[1])
+}
+
+// saveSession writes the session state to a file.
+func saveSession(path string, eng *engine.Engine) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := eng.SaveState(f); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Session saved to %s\n", path)
+}
+
+// loadSession restores the session state from a file.
+func loadSession(path string, eng *engine.Engine) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := eng.LoadState(f); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Session loaded from %s (%d lines, %d variables)\n",
+		path, len(eng.Lines()), len(eng.Variables()))
+}
+
 // handleSet handles "set" commands.
 func handleSet(args string, eng *engine.Engine) {
 	parts := strings.SplitN(args, " ", 2)
 	if len(parts) < 2 {
 		fmt.Println("Usage: set <option> <value>")
-		fmt.Println("Options: precision, strict")
+		fmt.Println("Options: precision, sigfig, rounding, fractions, locale, currencies, angle, seed, strict, asof, currency, region, length, data, weekend, holidays, verbose, trace")
 		return
 	}
 
@@ -185,14 +447,74 @@ func handleSet(args string, eng *engine.Engine) {
 
 	switch option {
 	case "precision":
-		var p int
-		_, err := fmt.Sscanf(value, "%d", &p)
-		if err != nil || p < 0 || p > 15 {
-			fmt.Println("Precision must be 0-15")
+		if err := eng.ApplySetting("precision", value); err != nil {
+			fmt.Println("Precision must be 0-15 or auto")
+			return
+		}
+		fmt.Printf("Precision set to %s\n", strings.ToLower(value))
+
+	case "sigfig", "sigfigs":
+		if err := eng.ApplySetting("sigfig", value); err != nil {
+			fmt.Println("Usage: set sigfig 1-15|off")
+			return
+		}
+		if n := eng.SigFigs(); n > 0 {
+			fmt.Printf("Showing %d significant figures\n", n)
+		} else {
+			fmt.Println("Significant figures off")
+		}
+
+	case "fractions":
+		d, ok := types.ParseFractionDisplay(value)
+		if !ok {
+			fmt.Println("Usage: set fractions off|imperial|on")
+			return
+		}
+		eng.SetFractions(d)
+		fmt.Printf("Fractions set to %s\n", d)
+
+	case "locale":
+		l, ok := types.ParseInputLocale(value)
+		if !ok {
+			fmt.Println("Usage: set locale en|eu")
 			return
 		}
-		eng.SetPrecision(p)
-		fmt.Printf("Precision set to %d\n", p)
+		eng.SetInputLocale(l)
+		fmt.Printf("Input locale set to %s\n", l)
+
+	case "currencies":
+		d, ok := types.ParseCurrencyDisplay(value)
+		if !ok {
+			fmt.Println("Usage: set currencies symbol|code")
+			return
+		}
+		eng.SetCurrencyDisplay(d)
+		fmt.Printf("Currencies shown as %s\n", d)
+
+	case "angle", "angles":
+		m, ok := types.ParseAngleMode(value)
+		if !ok {
+			fmt.Println("Usage: set angle deg|rad")
+			return
+		}
+		eng.SetAngleMode(m)
+		fmt.Printf("Angle mode set to %s\n", m)
+
+	case "seed":
+		if err := eng.ApplySetting("seed", value); err != nil {
+			fmt.Println("Usage: set seed <n>")
+			return
+		}
+		fmt.Printf("Seed set to %d\n", eng.Seed())
+
+	case "rounding":
+		m, ok := types.ParseRoundingMode(value)
+		if !ok {
+			fmt.Println("Usage: set rounding half-up|half-even|floor|ceiling")
+			return
+		}
+		eng.SetRounding(m)
+		fmt.Printf("Rounding set to %s\n", m)
 
 	case "strict":
 		switch strings.ToLower(value) {
@@ -206,13 +528,109 @@ func handleSet(args string, eng *engine.Engine) {
 			fmt.Println("Usage: set strict on|off")
 		}
 
+	case "asof", "as-of":
+		switch strings.ToLower(value) {
+		case "on", "true", "1":
+			eng.SetShowAsOf(true)
+			fmt.Println("Rate times shown")
+		case "off", "false", "0":
+			eng.SetShowAsOf(false)
+			fmt.Println("Rate times hidden")
+		default:
+			fmt.Println("Usage: set asof on|off")
+		}
+
+	case "currency", "base":
+		if err := eng.SetBaseCurrency(value); err != nil {
+			fmt.Println("Usage: set currency <code>|none")
+			return
+		}
+		if curr := eng.BaseCurrency(); curr != nil {
+			fmt.Printf("Base currency set to %s\n", curr.Code)
+		} else {
+			fmt.Println("Totals follow the last currency used")
+		}
+
+	case "verbose":
+		switch strings.ToLower(value) {
+		case "on", "true", "1":
+			verbose = true
+			fmt.Println("Verbose conversions enabled")
+		case "off", "false", "0":
+			verbose = false
+			fmt.Println("Verbose conversions disabled")
+		default:
+			fmt.Println("Usage: set verbose on|off")
+		}
+
+	case "trace":
+		switch strings.ToLower(value) {
+		case "on", "true", "1":
+			eng.SetTrace(func(s engine.Step) { printStep(eng, s) })
+			fmt.Println("Trace enabled")
+		case "off", "false", "0":
+			eng.SetTrace(nil)
+			fmt.Println("Trace disabled")
+		default:
+			fmt.Println("Usage: set trace on|off")
+		}
+
+	case "region":
+		r, ok := types.ParseRegion(value)
+		if !ok {
+			fmt.Println("Usage: set region us|uk")
+			return
+		}
+		eng.SetRegion(r)
+		fmt.Printf("Region set to %s\n", r)
+
+	case "data":
+		d, ok := types.ParseDataUnits(value)
+		if !ok {
+			fmt.Println("Usage: set data binary|decimal")
+			return
+		}
+		eng.SetDataUnits(d)
+		fmt.Printf("Data units set to %s\n", d)
+
+	case "weekend":
+		days, ok := types.ParseWeekdays(value)
+		if !ok {
+			fmt.Println("Usage: set weekend sat,sun")
+			return
+		}
+		eng.SetWeekend(days...)
+		fmt.Printf("Weekend set to %s\n", value)
+
+	case "holidays":
+		f, err := os.Open(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		defer f.Close()
+		if err := eng.LoadHolidays(f); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Holidays loaded from %s\n", value)
+
+	case "length", "lengths":
+		d, ok := types.ParseLengthDisplay(value)
+		if !ok {
+			fmt.Println("Usage: set length auto|metric|imperial")
+			return
+		}
+		eng.SetLengthDisplay(d)
+		fmt.Printf("Length display set to %s\n", d)
+
 	default:
 		fmt.Printf("Unknown option: %s\n", option)
 	}
 }
 
 // printResult prints a value result.
-func printResult(result types.Value) {
+func printResult(eng *engine.Engine, result types.Value) {
 	if result.IsEmpty() {
 		return
 	}
@@ -222,7 +640,101 @@ func printResult(result types.Value) {
 		return
 	}
 
-	fmt.Printf("= %s\n", result.String())
+	if result.IsPlot() {
+		fmt.Println(display(eng, result))
+		return
+	}
+	fmt.Printf("= %s\n", eng.Format(result))
+}
+
+// display formats a result for the terminal, drawing plots as braille
+// charts.
+func display(eng *engine.Engine, v types.Value) string {
+	if v.IsPlot() {
+		return strings.Join(v.Plot.Braille(types.PlotWidth, types.PlotHeight), "\n")
+	}
+	return eng.Format(v)
+}
+
+// printConversion prints a conversion with the value converted and, for
+// exchange rates, the rate used and where it came from: "$100.00 = €92.00
+// (rate 0.92, 2h old)". A non-empty source replaces the rate's age.
+// In verbose mode it also prints the path the rate was found through.
+func printConversion(eng *engine.Engine, c engine.Conversion, source string) {
+	out := fmt.Sprintf("%s = %s", eng.Format(c.From), eng.Format(c.To))
+	if !c.HasRate() {
+		fmt.Println(out)
+		return
+	}
+
+	switch {
+	case source != "":
+	case c.Pinned:
+		source = "pinned"
+	case c.Age == 0:
+		source = "built-in"
+	default:
+		source = formatAge(c.Age) + " old"
+	}
+	fmt.Printf("%s (rate %s, %s)\n", out, formatRate(c.Rate), source)
+
+	if verbose {
+		fmt.Printf("  path: %s\n", strings.Join(c.Path, " → "))
+	}
+}
+
+// formatRate formats an exchange rate to at least four decimals, with
+// more for small rates so they keep significant digits.
+func formatRate(rate float64) string {
+	decimals := 4
+	for r := math.Abs(rate); r > 0 && r < 0.01 && decimals < 12; r *= 10 {
+		decimals++
+	}
+	s := strconv.FormatFloat(rate, 'f', decimals, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// formatAge formats a duration in its largest whole unit: "5m", "2h", "3d".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+}
+
+// valueJSON returns the JSON form of a value, displayed with the
+// engine's settings.
+func valueJSON(eng *engine.Engine, v types.Value) map[string]any {
+	m := v.ToMap()
+	if !v.IsError() {
+		m["display"] = eng.Format(v)
+	}
+	return m
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printJSONLine prints v as a single line of JSON.
+func printJSONLine(v any) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 // printVariables prints all variables.
@@ -235,7 +747,59 @@ func printVariables(eng *engine.Engine) {
 
 	fmt.Println("Variables:")
 	for name, value := range vars {
-		fmt.Printf("  %s = %s\n", name, value.String())
+		if d := eng.Description(name); d != "" {
+			fmt.Printf("  %s = %s  # %s\n", name, eng.Format(value), d)
+			continue
+		}
+		fmt.Printf("  %s = %s\n", name, eng.Format(value))
+	}
+}
+
+// printBlocks prints the variables set in each begin ... end block, which
+// vars no longer shows once the block has ended.
+func printBlocks(eng *engine.Engine) {
+	for _, b := range eng.Blocks() {
+		indent := strings.Repeat("  ", b.Depth)
+		if b.End == 0 {
+			fmt.Printf("%sBlock from line %d (open):\n", indent, b.Start)
+		} else {
+			fmt.Printf("%sBlock, lines %d-%d:\n", indent, b.Start, b.End)
+		}
+		if len(b.Vars) == 0 {
+			fmt.Printf("%s  No variables set.\n", indent)
+		}
+		for _, name := range slices.Sorted(maps.Keys(b.Vars)) {
+			fmt.Printf("%s  %s = %s\n", indent, name, eng.Format(b.Vars[name]))
+		}
+	}
+}
+
+// printConstants lists the built-in constants, as JSON with --json.
+func printConstants(opts options) {
+	all := types.AllConstants()
+	if opts.json {
+		out := make([]map[string]any, len(all))
+		for i, c := range all {
+			out[i] = map[string]any{
+				"name":        c.Name,
+				"aliases":     c.Aliases,
+				"value":       c.Value,
+				"unit":        c.Unit,
+				"description": c.Desc,
+			}
+		}
+		printJSON(out)
+		return
+	}
+
+	fmt.Println("Constants:")
+	for _, c := range all {
+		names := c.Name
+		if len(c.Aliases) > 0 {
+			names += ", " + strings.Join(c.Aliases, ", ")
+		}
+		value := c.ToValue().String()
+		fmt.Printf("  %-28s %-24s %s\n", names, value, c.Desc)
 	}
 }
 
@@ -249,7 +813,7 @@ func printGroupedTotals(eng *engine.Engine) {
 
 	fmt.Println("Totals:")
 	for _, t := range totals {
-		fmt.Printf("  %s\n", t.String())
+		fmt.Printf("  %s\n", eng.Format(t))
 	}
 }
 
@@ -275,7 +839,7 @@ func printHistory(eng *engine.Engine) {
 		}
 
 		if !lr.Value.IsEmpty() {
-			fmt.Printf("  %d: %s = %s%s\n", i+1, lr.Input, lr.Value.String(), status)
+			fmt.Printf("  %d: %s = %s%s\n", i+1, lr.Input, eng.Format(lr.Value), status)
 		}
 	}
 }
@@ -290,6 +854,13 @@ func printRateInfo(eng *engine.Engine) {
 	fmt.Printf("  Cache file: %s\n", stats.CacheFile)
 	fmt.Printf("  Has file cache: %v\n", stats.HasFileCache)
 	fmt.Printf("  Is expired: %v\n", stats.IsExpired)
+	if stats.IsExpired {
+		names := make([]string, len(stats.Expired))
+		for i, class := range stats.Expired {
+			names[i] = class.String()
+		}
+		fmt.Printf("  Expired: %s\n", strings.Join(names, ", "))
+	}
 
 	if !stats.LastUpdate.IsZero() {
 		fmt.Printf("  Last update: %s\n", stats.LastUpdate.Format("2006-01-02 15:04:05"))
@@ -320,46 +891,118 @@ Usage:
   %s <expression>       Evaluate expression
   %s -e <expression>    Evaluate expression
   %s -f <file>          Evaluate file
-
-Options:
+  %s --stdin            Evaluate lines from stdin
+  %s serve --http :8080 Serve the HTTP API (--api-key, --pool, --timeout)
+  %s serve --grpc :9090 Serve the gRPC API (same options; with --http too)
+  %s convert <amount> <from> <to>
+                           Convert a value (--rate-source, --date)
+  %s rates <command>    Manage exchange rates (refresh, show [CODE],
+                           providers, clear, set <from> <to> <rate>)
+  %s constants          List built-in constants (pi, e, c, ...)
+  %s diff <old> <new>   Show which results changed between two files
+
+Modes:
   -h, --help      Show this help
   -v, --version   Show version
   -e, --eval      Evaluate expression
   -f, --file      Evaluate file
+      --stdin     Evaluate lines from stdin as they arrive
+      --serve-stdio  Serve JSON-RPC 2.0 requests on stdin/stdout
 
-Examples:
+`, appName, appVersion, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName)
+
+	fmt.Printf("Options:\n%s\n", flagUsage(globalFlags))
+	fmt.Printf("Convert options:\n%s\n", flagUsage(convertFlags))
+	fmt.Printf("Serve options:\n%s\n", flagUsage(serveFlags))
+
+	fmt.Printf(`Examples:
   %s "100 + 50"
   %s "$100 in EUR"
   %s "20%% of 150"
+  %s --precision 4 "1 / 3"
   %s -f calculations.txt
-
-`, appName, appVersion, appName, appName, appName, appName, appName, appName, appName, appName)
+  %s -e "5 km in miles" --json
+  %s --explain "$120 + 8%% in EUR"
+  %s -f budget.calc --export csv
+  %s -f budget.calc --export md
+  cat data.txt | %s --stdin
+  %s -f budget.calc --watch
+  %s -f quote.calc --input hours=12
+  %s diff budget-v1.calc budget-v2.calc
+  %s convert 100 USD EUR
+  %s convert 5km mi --json
+  %s rates set USD EUR 0.93
+  NUMIO_PRECISION=4 %s
+  NUMIO_DISABLED_PROVIDERS=coingecko %s rates refresh
+
+`, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName, appName)
 }
 
 // printREPLHelp prints REPL help.
 func printREPLHelp() {
-	fmt.Println(`
+	fmt.Print(`
 Commands:
   help, ?          Show this help
   quit, exit, q    Exit the program
   clear, cls       Clear all state
   vars             Show all variables
+  vars --all       Also show those set in begin ... end blocks
   total            Show running total
   totals           Show grouped totals
   history          Show line history
   rates            Show rate cache info
-  set <opt> <val>  Set option (precision, strict)
+  constants        List built-in constants
+  set <opt> <val>  Set option (type "set" for the list)
+  set verbose on   Show the rate path conversions take
+  set trace on     Show each step of an evaluation
   del <name>       Delete a variable
+  export [fmt] [f] Export results as csv or tsv
+  save <file>      Save the session to a file
+  load <file>      Restore a saved session
+
+Editing:
+  Up/Down          Previous/next history entry (~/.numio/history)
+  Ctrl+R           Search history
+  Tab              Complete variables, units and currencies
+  Ctrl+A/Ctrl+E    Start/end of line
+  Ctrl+W/Ctrl+U    Delete word/to start of line
+  Ctrl+D           Exit on an empty line
 
 Expressions:
   100 + 50                 Basic math
+  17 mod 5, 17 // 5        Modulo and integer division
+  paid = €90 in USD !pin   Freeze a result, whatever rates do later
+  rate USD/EUR last 7 days Recorded rates, or avg/min/max (rate_history = true)
+  5!, nCr(10, 3)           Factorial and combinatorics (gcd, lcm, nPr)
   20% of 150               Percentage
+  $250 rent % of total     The line's share of the total
+  twenty percent of 300    Numbers in words (two dozen, three hundred and five)
   $100 + 15%               Price with tax
+  $1.2M + $300k            Compact amounts (5k, 2.5M, 1.2bn, 3 million)
   $100 in EUR              Currency conversion
+  $100 as code             Show currency codes (set currencies code)
+  €1.234,56 + 10%          European number input (set locale eu)
   5 km to miles            Unit conversion
+  2 uk gallons in liters   Regional units
+  5'11" in cm              Feet and inches
+  unit sprint = 2 weeks    Define a custom unit
+  today + 10 business days Business day arithmetic
   tax = 15%                Variable assignment
+  begin ... end            Variables set between stay inside
+  import "rates.calc"      Variables and functions from another file
+  input salary "Salary"    Template input, asked for when a file opens
   _ * 2                    Use previous result
+  _2 + _3, ans(2)          Results further back
   sum(1, 2, 3)             Functions
+  2 * pi * 6371 km         Constants (pi, e, c, g, avogadro, ...)
+  sin(90 deg)              Trig with angle units (set angle deg)
+  dice(3d6)                Random rolls (rand, randint, sample, --seed)
+  (10 ± 1) * (20 ± 2)      Uncertainty carried through (also +/-)
+  det([[1, 2], [3, 4]])    Vectors and matrices (dot, cross, inverse, ...)
+  f(x) = x^2 + 1           Define a function
+  derive(f, at 3)          Numeric derivative (integrate(f, 0, 10))
+  plot(f, 0..10)           Chart a function (or plot lines 1..20)
+  spark([3, 5, 9, 4])      Inline sparkline
 
 Supported:
   Currencies: USD, EUR, GBP, JPY, TRY, BTC, ETH, ...
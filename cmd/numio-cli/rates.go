@@ -0,0 +1,248 @@
+// cmd/numio-cli/rates.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// ratesBase is the currency "rates show" lists rates against.
+const ratesBase = "USD"
+
+// runRates handles "rates refresh", "rates show [CODE]", "rates
+// providers", "rates clear" and "rates set <from> <to> <rate>".
+func runRates(opts options, args []string) {
+	if len(args) == 0 {
+		printRatesUsage()
+		os.Exit(1)
+	}
+
+	eng := opts.newEngine()
+	rc := eng.RateCache()
+
+	switch args[0] {
+	case "refresh":
+		opts.requireNetwork("rates refresh")
+		ctx, cancel := context.WithTimeout(context.Background(), cache.DefaultRefreshTimeout)
+		defer cancel()
+		r := eng.RefreshRatesSummary(ctx)
+		if opts.json {
+			printRefresh(r)
+		} else {
+			for _, cr := range r.Classes {
+				if cr.Err != nil {
+					fmt.Printf("  %-6s failed: %v (using %s rates)\n", cr.Class, cr.Err, cr.Fallback)
+				} else {
+					fmt.Printf("  %-6s %d rates from %s (%s)\n", cr.Class, cr.Count, cr.Provider, cr.Duration.Round(time.Millisecond))
+				}
+			}
+		}
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", r.Err)
+			os.Exit(1)
+		}
+		if !opts.json {
+			fmt.Printf("Fetched %d rates (%d of %d asset classes)\n", r.Count, r.Fetched(), len(r.Classes))
+		}
+
+	case "show":
+		switch len(args) {
+		case 1:
+			showRates(eng, opts)
+		case 2:
+			showRate(rc, strings.ToUpper(args[1]), opts)
+		default:
+			printRatesUsage()
+			os.Exit(1)
+		}
+
+	case "providers":
+		showProviders(eng, opts)
+
+	case "clear":
+		if err := rc.ClearFiles(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Rate cache cleared.")
+
+	case "set":
+		if len(args) != 4 {
+			printRatesUsage()
+			os.Exit(1)
+		}
+		from, to := strings.ToUpper(args[1]), strings.ToUpper(args[2])
+		rate, err := strconv.ParseFloat(args[3], 64)
+		if err != nil || rate <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: rate must be a positive number")
+			os.Exit(1)
+		}
+		rc.SetRate(from, to, rate)
+		if err := rc.SavePinned(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Set 1 %s = %s %s\n", from, formatRate(rate), to)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown rates command: %s\n", args[0])
+		printRatesUsage()
+		os.Exit(1)
+	}
+}
+
+// printRatesUsage prints the rates subcommands.
+func printRatesUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: numio rates refresh [--json]")
+	fmt.Fprintln(os.Stderr, "       numio rates show [CODE] [--json]")
+	fmt.Fprintln(os.Stderr, "       numio rates providers [--json]")
+	fmt.Fprintln(os.Stderr, "       numio rates clear")
+	fmt.Fprintln(os.Stderr, "       numio rates set <from> <to> <rate>")
+}
+
+// showRates prints the cache info, every rate against the base currency
+// with where it came from, and the pinned rates.
+func showRates(eng *engine.Engine, opts options) {
+	rc := eng.RateCache()
+	rates := rc.DirectRates(ratesBase)
+	pinned := rc.PinnedRates()
+	sources := make(map[string]string, len(rates))
+	for code := range rates {
+		sources[code] = rc.RateSource(code)
+	}
+
+	if opts.json {
+		stats := rc.Stats()
+		printJSON(map[string]any{
+			"base":        ratesBase,
+			"rates":       rates,
+			"sources":     sources,
+			"pinned":      pinned,
+			"last_update": stats.LastUpdate,
+			"expired":     stats.IsExpired,
+		})
+		return
+	}
+
+	printRateInfo(eng)
+
+	codes := make([]string, 0, len(rates))
+	for code := range rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	fmt.Printf("\nRates (1 %s =):\n", ratesBase)
+	for _, code := range codes {
+		fmt.Printf("  %-6s %-20s %s\n", code, formatRate(rates[code]), sources[code])
+	}
+
+	if len(pinned) > 0 {
+		fmt.Println("\nPinned:")
+		for _, p := range pinned {
+			fmt.Printf("  1 %s = %s %s\n", p.From, formatRate(p.Rate), p.To)
+		}
+	}
+}
+
+// showRate prints the rate of one code against the base currency, both
+// ways round, and the pinned rates involving it.
+func showRate(rc *cache.RateCache, code string, opts options) {
+	path, rate, ok := rc.RatePath(code, ratesBase)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: no rate for %s\n", code)
+		os.Exit(1)
+	}
+
+	var pinned []cache.PinnedRate
+	for _, p := range rc.PinnedRates() {
+		if p.From == code || p.To == code {
+			pinned = append(pinned, p)
+		}
+	}
+
+	if opts.json {
+		printJSON(map[string]any{
+			"code":   code,
+			"base":   ratesBase,
+			"rate":   rate,
+			"path":   path,
+			"source": rc.RateSource(code),
+			"pinned": pinned,
+		})
+		return
+	}
+
+	fmt.Printf("1 %s = %s %s\n", code, formatRate(rate), ratesBase)
+	if rate != 0 {
+		fmt.Printf("1 %s = %s %s\n", ratesBase, formatRate(1/rate), code)
+	}
+	fmt.Printf("  source: %s\n", rc.RateSource(code))
+	if len(path) > 2 {
+		fmt.Printf("  path: %s\n", strings.Join(path, " → "))
+	}
+	for _, p := range pinned {
+		fmt.Printf("  pinned: 1 %s = %s %s\n", p.From, formatRate(p.Rate), p.To)
+	}
+}
+
+// showProviders prints the rate providers in the order they are tried
+// within each type, and whether each is in use.
+func showProviders(eng *engine.Engine, opts options) {
+	statuses := eng.ProviderStatus()
+	if opts.json {
+		printJSON(statuses)
+		return
+	}
+
+	for _, s := range statuses {
+		state := "ok"
+		switch {
+		case s.Disabled:
+			state = "disabled"
+		case !s.Available:
+			state = "no API key"
+		case s.State == "open":
+			state = "skipped until " + s.RetryAt.Local().Format("15:04:05")
+		case s.LastError != "":
+			state = "failing: " + s.LastError
+		case s.Source:
+			state = "ok (only " + s.Type + " source)"
+		}
+		fmt.Printf("  %-6s %-16s %s\n", s.Type, s.Name, state)
+	}
+}
+
+// printRefresh prints the outcome of a refresh as JSON.
+func printRefresh(r cache.RefreshResult) {
+	classes := make([]map[string]any, len(r.Classes))
+	for i, cr := range r.Classes {
+		c := map[string]any{
+			"class":       cr.Class.String(),
+			"count":       cr.Count,
+			"duration_ms": cr.Duration.Milliseconds(),
+		}
+		if cr.Err != nil {
+			c["error"] = cr.Err.Error()
+			c["fallback"] = cr.Fallback
+		} else {
+			c["provider"] = cr.Provider
+		}
+		classes[i] = c
+	}
+	printJSON(map[string]any{
+		"count":   r.Count,
+		"fetched": r.Fetched(),
+		"failed":  r.Failed(),
+		"classes": classes,
+	})
+}
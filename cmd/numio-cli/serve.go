@@ -0,0 +1,143 @@
+// cmd/numio-cli/serve.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/0xsj/numio/internal/server"
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// apiKeyEnv names the environment variable holding the HTTP and gRPC API key.
+const apiKeyEnv = "NUMIO_API_KEY"
+
+// serveFlags are the flags of the serve command.
+var serveFlags = []flag{
+	{name: "http", arg: "addr", usage: "Serve the HTTP API on addr"},
+	{name: "grpc", arg: "addr", usage: "Serve the gRPC API on addr"},
+	{name: "api-key", arg: "key", usage: "Require an API key (default $" + apiKeyEnv + ")"},
+	{name: "pool", arg: "n", usage: "Number of engines serving requests"},
+	{name: "metrics", arg: "addr", usage: "Also serve Prometheus metrics on addr, without an API key"},
+	{name: "timeout", arg: "duration", usage: "Stop evaluating a line after duration (default 5s, 0 = never)"},
+	{name: "stdio", usage: "Serve JSON-RPC on stdin/stdout"},
+}
+
+// runServe handles "serve --http <addr>" and "serve --grpc <addr>", both
+// with [--api-key <key>] [--pool <n>], and "serve --stdio", any of them
+// with [--timeout <duration>]. --http and --grpc may be given together.
+func runServe(opts options, args []string) {
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: unknown serve option: %s\n", args[0])
+		os.Exit(1)
+	}
+
+	httpOpts := server.HTTPOptions{
+		Addr:    opts.values["http"],
+		APIKey:  os.Getenv(apiKeyEnv),
+		Offline: opts.noNetwork,
+	}
+	if key, ok := opts.values["api-key"]; ok {
+		httpOpts.APIKey = key
+	}
+	if pool, ok := opts.values["pool"]; ok {
+		n, err := strconv.Atoi(pool)
+		if err != nil || n < 1 {
+			fmt.Fprintln(os.Stderr, "Error: --pool must be a positive number")
+			os.Exit(1)
+		}
+		httpOpts.PoolSize = n
+	}
+	timeout := server.DefaultEvalTimeout
+	if s, ok := opts.values["timeout"]; ok {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			fmt.Fprintln(os.Stderr, "Error: --timeout must be a duration such as 5s or 500ms")
+			os.Exit(1)
+		}
+		timeout = d
+	}
+
+	switch {
+	case opts.values["stdio"] != "":
+		serveStdio(opts, timeout)
+	case httpOpts.Addr != "" || opts.values["grpc"] != "":
+		serveNetwork(opts, httpOpts, timeout)
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: numio serve --http <addr> [--api-key <key>] [--pool <n>]")
+		fmt.Fprintln(os.Stderr, "       numio serve --grpc <addr> [--api-key <key>] [--pool <n>]")
+		fmt.Fprintln(os.Stderr, "       numio serve --stdio")
+		os.Exit(1)
+	}
+}
+
+// serveStdio serves JSON-RPC on stdin/stdout.
+func serveStdio(opts options, timeout time.Duration) {
+	rpc := server.NewJSONRPC(servedEngine(opts, timeout))
+	if err := rpc.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// serveNetwork serves the REST API, the gRPC API or both until
+// interrupted. Both share one engine and so one rate cache, and record
+// the same metrics, which the REST API serves at /metrics and --metrics
+// serves on a port of its own.
+func serveNetwork(opts options, httpOpts server.HTTPOptions, timeout time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	eng := servedEngine(opts, timeout)
+	httpOpts.Metrics = server.NewMetrics(eng)
+	errs := make(chan error, 3)
+	running := 0
+
+	if httpOpts.Addr != "" {
+		srv := server.NewHTTP(eng, httpOpts)
+		fmt.Fprintf(os.Stderr, "Serving HTTP on %s\n", httpOpts.Addr)
+		running++
+		go func() { errs <- srv.ListenAndServe(ctx) }()
+	}
+	if addr := opts.values["grpc"]; addr != "" {
+		srv := server.NewGRPC(eng, server.GRPCOptions{
+			Addr:     addr,
+			APIKey:   httpOpts.APIKey,
+			PoolSize: httpOpts.PoolSize,
+			Offline:  httpOpts.Offline,
+			Metrics:  httpOpts.Metrics,
+		})
+		fmt.Fprintf(os.Stderr, "Serving gRPC on %s\n", addr)
+		running++
+		go func() { errs <- srv.ListenAndServe(ctx) }()
+	}
+	if addr := opts.values["metrics"]; addr != "" {
+		fmt.Fprintf(os.Stderr, "Serving metrics on %s/metrics\n", addr)
+		running++
+		go func() { errs <- httpOpts.Metrics.ListenAndServe(ctx, addr) }()
+	}
+	if httpOpts.APIKey == "" {
+		fmt.Fprintln(os.Stderr, "Warning: no API key set; the API is unauthenticated")
+	}
+
+	for ; running > 0; running-- {
+		if err := <-errs; err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// servedEngine creates the engine a server evaluates with, stopping lines
+// that run longer than timeout.
+func servedEngine(opts options, timeout time.Duration) *engine.Engine {
+	eng := opts.newEngine()
+	eng.SetEvalTimeout(timeout)
+	return eng
+}
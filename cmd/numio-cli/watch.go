@@ -0,0 +1,82 @@
+// cmd/numio-cli/watch.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the bursts of events editors emit on save.
+const watchDebounce = 100 * time.Millisecond
+
+// watchFile evaluates a file and re-evaluates it whenever it changes.
+// The parent directory is watched rather than the file itself, so editors
+// that save by renaming a temporary file over the original keep working.
+func watchFile(filename string, opts options) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	reload := func() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return
+		}
+		// Inputs the file gains are asked for when it does
+		opts.inputs = append(opts.inputs, askInputs(string(data), opts)...)
+		if !opts.json {
+			fmt.Printf("── %s (%s) ──\n", filename, time.Now().Format("15:04:05"))
+		}
+		printFile(string(data), opts)
+	}
+
+	reload()
+
+	// Timer fires once events have settled
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != path {
+				continue
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				timer.Reset(watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Watch error: %v\n", err)
+
+		case <-timer.C:
+			reload()
+		}
+	}
+}
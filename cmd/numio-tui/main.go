@@ -31,37 +31,19 @@ func main() {
 		}
 	}
 
-	// Check if a file was provided
-	var filename string
-	var content string
-
-	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
-		filename = args[0]
-
-		// Try to read the file
-		data, err := os.ReadFile(filename)
-		if err != nil {
-			if os.IsNotExist(err) {
-				// New file, start with empty content
-				content = ""
-			} else {
-				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-				os.Exit(1)
-			}
-		} else {
-			content = string(data)
+	// Files open in a buffer each; flags start with "-"
+	opts := tui.Options{Restore: true}
+	for _, arg := range args {
+		switch {
+		case arg == "--no-restore":
+			opts.Restore = false
+		case !strings.HasPrefix(arg, "-"):
+			opts.Files = append(opts.Files, arg)
 		}
 	}
 
 	// Run the TUI
-	var err error
-	if filename != "" {
-		err = tui.RunWithFile(filename, content)
-	} else {
-		err = tui.Run()
-	}
-
-	if err != nil {
+	if err := tui.RunWithOptions(opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -71,8 +53,10 @@ func printHelp() {
 	fmt.Printf(`%s v%s - Natural Math Calculator (TUI)
 
 Usage:
-  %s                    Start with empty buffer
+  %s                    Start where the last session left off
   %s <file>             Open file for editing
+  %s <file>...          Open files in buffers
+  %s --no-restore       Don't restore or save the session
   %s -h, --help         Show this help
   %s -v, --version      Show version
 
@@ -81,32 +65,64 @@ Keyboard Shortcuts:
     h/j/k/l or arrows   Move cursor
     0 / $               Start / End of line
     gg / G              Top / Bottom of file
+    Ctrl+e / Ctrl+y     Scroll down / up
+    zz                  Center cursor line
     w / b               Next / Previous word
     PgUp / PgDn         Page up / Page down
 
   Editing:
     i                   Insert mode
+    Tab                 Accept completion (insert mode, ↑/↓ to choose)
     a                   Append mode
     o / O               New line below / above
     dd                  Delete line
     x                   Delete character
     yy / p              Yank / Paste line
+    yr / yR             Copy line result / all results to clipboard
+    v / V               Visual / Visual line selection
+    d / y / c / p       Delete / Yank / Change / Replace selection (visual)
     u / Ctrl+r          Undo / Redo
 
   General:
     Esc                 Normal mode
     ? / F1              Toggle help
-    Ctrl+s              Save file
+    T                   Toggle totals and variables panel
+    K                   Explain the line's result
+    Ctrl+s / :w         Save file
+    :w <file>           Save as
     Ctrl+r              Refresh rates
     W                   Toggle wrap
     N                   Toggle line numbers
     H                   Toggle header
-    q                   Quit
+    q / :q              Quit (asks again if unsaved)
+    :wq / :q!           Save and quit / Quit without saving
+    :e <file>           Edit file in a new buffer (:e! reloads, discarding changes)
+    gt / gT             Next / Previous buffer (:bn / :bp, :b N)
+    :ls / :bd[!]        List buffers / Close buffer
+    :sp / :vs [<file>]  Split window (same buffer, or file)
+    :new / :vnew        Split onto a scratch pad that sees the document's variables
+    Ctrl+w              Next window (:close / :only, q closes a split)
+    :set <name> <value> Apply setting (:set precision 4, :set strict on)
+    :set undofile       Keep the file's undo history across sessions
+    :scenario x = <v>   What-if: recompute as if x were v, leaving the file alone
+                        (:scenario lists, :scenario x = drops one, :scenario off)
+    :%%s/old/new/g       Replace text in all lines (:s for current line)
+    :fmt                Format every line (spacing, unit codes, currency symbols)
+    :input [<name>]     Ask again for the values of the document's input lines
+
+Themes:
+  Built-in: default (dark), light, solarized, dracula, monokai, gruvbox.
+  Switch with :set theme <name>. Customize in ~/.config/numio/theme.toml:
+    base = "solarized"
+    [syntax]
+    number = "#d33682"
+    [ui]
+    result = "#2aa198"
 
 Examples:
-  %s                        Start fresh
+  %s --no-restore           Start fresh
   %s budget.calc            Open budget.calc
   %s ~/finances/taxes.calc  Open with path
 
-`, appName, appVersion, appName, appName, appName, appName, appName, appName, appName)
+`, appName, appVersion, appName, appName, appName, appName, appName, appName, appName, appName, appName)
 }
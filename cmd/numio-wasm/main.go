@@ -0,0 +1,119 @@
+// cmd/numio-wasm/main.go
+
+//go:build js && wasm
+
+// Command numio-wasm runs numio in a web page. It sets a global numio
+// object whose methods evaluate with one sandboxed engine, so variables
+// persist between calls:
+//
+//	numio.eval("5 km in mi")            → value
+//	numio.evalDocument("a = 5\na * 2")  → [value]
+//	numio.convert("5 km", "mi")         → value
+//	numio.complete("5 k")               → {prefix, items}
+//	numio.variables()                   → {name: value}
+//	numio.clear()                       → true
+//	numio.setRate("USD", "EUR", 0.92)   → true
+//	numio.setRates({"EUR": 0.92, ...})  → true
+//
+// Values are objects as the JSON-RPC server returns them: {kind, display,
+// ...}, with kind "error" and an error message for evaluation errors.
+// The engine never fetches rates: the page fetches them and passes them
+// to setRates, in the form exchange rate APIs give (units per US dollar
+// for fiat, dollar prices for crypto, metals and commodities).
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/0xsj/numio/internal/server"
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+func main() {
+	eng := engine.NewSandboxed()
+	eng.SetEvalTimeout(server.DefaultEvalTimeout)
+	rpc := server.NewJSONRPC(eng)
+
+	numio := map[string]any{
+		"eval": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return call(rpc, "eval", map[string]any{"input": arg(args, 0)})
+		}),
+		"evalDocument": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return call(rpc, "eval_lines", map[string]any{"content": arg(args, 0)})
+		}),
+		"convert": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return call(rpc, "convert", map[string]any{"input": arg(args, 0), "to": arg(args, 1)})
+		}),
+		"complete": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return call(rpc, "complete", map[string]any{"input": arg(args, 0)})
+		}),
+		"variables": js.FuncOf(func(_ js.Value, _ []js.Value) any {
+			return call(rpc, "variables", nil)
+		}),
+		"clear": js.FuncOf(func(_ js.Value, _ []js.Value) any {
+			return call(rpc, "clear", nil)
+		}),
+		"setRate": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 3 || args[2].Type() != js.TypeNumber {
+				return jsError("setRate requires from, to and a rate")
+			}
+			eng.SetRate(arg(args, 0), arg(args, 1), args[2].Float())
+			return true
+		}),
+		"setRates": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 1 || args[0].Type() != js.TypeObject {
+				return jsError("setRates requires an object of rates")
+			}
+			var rates map[string]float64
+			data := js.Global().Get("JSON").Call("stringify", args[0]).String()
+			if err := json.Unmarshal([]byte(data), &rates); err != nil {
+				return jsError("setRates: " + err.Error())
+			}
+			eng.ApplyRawRates(rates)
+			return true
+		}),
+	}
+	js.Global().Set("numio", numio)
+
+	// The functions are called from JavaScript for as long as the page
+	// lives
+	select {}
+}
+
+// call calls a JSON-RPC method and returns its result as a JavaScript
+// value, or an Error object if the call failed.
+func call(rpc *server.JSONRPC, method string, params map[string]any) any {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return jsError(err.Error())
+	}
+	resp := rpc.Handle(&server.Request{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage("1"),
+		Method:  method,
+		Params:  raw,
+	})
+	if resp.Error != nil {
+		return jsError(resp.Error.Message)
+	}
+
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return jsError(err.Error())
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
+
+// arg returns the i'th argument as a string, or "" if there isn't one.
+func arg(args []js.Value, i int) string {
+	if i >= len(args) || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+// jsError returns a JavaScript Error with the given message.
+func jsError(message string) js.Value {
+	return js.Global().Get("Error").New(message)
+}
@@ -4,7 +4,9 @@
 package ast
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/0xsj/numio/pkg/types"
 )
@@ -35,6 +37,9 @@ type Stmt interface {
 // It can be empty, a comment, an assignment, or an expression.
 type Line struct {
 	Stmt    Stmt   // The statement (nil if empty)
+	Pinned  bool   // Value frozen with !pin
+	Share   bool   // Shows its share of the document total: % of total
+	Label   string // Words after the statement, which are ignored ($250 rent)
 	Comment string // Trailing comment (if any)
 	Raw     string // Original raw input
 }
@@ -49,10 +54,20 @@ func (l *Line) String() string {
 		}
 		return ""
 	}
+	s := l.Stmt.String()
+	if l.Pinned {
+		s += " !pin"
+	}
+	if l.Label != "" {
+		s += " " + l.Label
+	}
+	if l.Share {
+		s += " % of total"
+	}
 	if l.Comment != "" {
-		return l.Stmt.String() + " " + l.Comment
+		return s + " " + l.Comment
 	}
-	return l.Stmt.String()
+	return s
 }
 
 // EmptyStmt represents an empty line.
@@ -105,14 +120,65 @@ func (a *AssignStmt) String() string {
 	return a.Name + " = " + a.Expr.String()
 }
 
+// FuncDefStmt defines a function (e.g., f(x) = x^2 + 1).
+type FuncDefStmt struct {
+	Name   string   // Function name
+	Params []string // Parameter names
+	Body   Expr     // Result, in terms of the parameters
+}
+
+func (f *FuncDefStmt) node() {}
+func (f *FuncDefStmt) stmt() {}
+
+func (f *FuncDefStmt) String() string {
+	return f.Name + "(" + strings.Join(f.Params, ", ") + ") = " + f.Body.String()
+}
+
+// UnitDefStmt defines a custom unit (e.g., unit sprint = 2 weeks).
+// A nil Expr defines a new base unit for its own dimension (e.g., unit point).
+type UnitDefStmt struct {
+	Name    string   // Unit code
+	Aliases []string // Extra names (e.g., plural)
+	Expr    Expr     // Size of one unit, or nil for a base unit
+}
+
+func (u *UnitDefStmt) node() {}
+func (u *UnitDefStmt) stmt() {}
+
+func (u *UnitDefStmt) String() string {
+	s := "unit " + strings.Join(append([]string{u.Name}, u.Aliases...), ", ")
+	if u.Expr != nil {
+		s += " = " + u.Expr.String()
+	}
+	return s
+}
+
+// ScopeStmt opens a scope ("begin") or closes the innermost one ("end").
+// Variables set inside a scope go back to what they were when it closes.
+type ScopeStmt struct {
+	End bool // Closes a scope
+}
+
+func (s *ScopeStmt) node() {}
+func (s *ScopeStmt) stmt() {}
+
+func (s *ScopeStmt) String() string {
+	if s.End {
+		return "end"
+	}
+	return "begin"
+}
+
 // ════════════════════════════════════════════════════════════════
 // EXPRESSIONS - LITERALS
 // ════════════════════════════════════════════════════════════════
 
 // NumberLit represents a numeric literal.
 type NumberLit struct {
-	Value float64
-	Raw   string // Original text (for display)
+	Value   float64
+	Raw     string // Original text (for display)
+	Compact bool   // Written with a scale: 5k, 3 million
+	Counts  string // What it counts, written after it: "gas"
 }
 
 func (n *NumberLit) node() {}
@@ -146,6 +212,7 @@ type CurrencyLit struct {
 	Amount   float64
 	Currency *types.Currency
 	Raw      string
+	Compact  bool // Written with a scale: $1.2M, $3 million
 }
 
 func (c *CurrencyLit) node() {}
@@ -156,6 +223,9 @@ func (c *CurrencyLit) String() string {
 		return c.Raw
 	}
 	if c.Currency != nil {
+		if !c.Currency.ShowsSymbol() {
+			return formatFloat(c.Amount) + " " + c.Currency.Code
+		}
 		if c.Currency.SymbolAfter {
 			return formatFloat(c.Amount) + c.Currency.Symbol
 		}
@@ -165,10 +235,12 @@ func (c *CurrencyLit) String() string {
 }
 
 // UnitLit represents a value with a unit (e.g., 5 km, 2 hours).
+// Ingredient is set for cooking measures (e.g., 2 cups flour).
 type UnitLit struct {
-	Amount float64
-	Unit   *types.Unit
-	Raw    string
+	Amount     float64
+	Unit       *types.Unit
+	Ingredient *types.Ingredient
+	Raw        string
 }
 
 func (u *UnitLit) node() {}
@@ -178,15 +250,43 @@ func (u *UnitLit) String() string {
 	if u.Raw != "" {
 		return u.Raw
 	}
+	if u.Unit != nil && u.Ingredient != nil {
+		return formatFloat(u.Amount) + " " + u.Unit.Code + " " + u.Ingredient.Name
+	}
 	if u.Unit != nil {
 		return formatFloat(u.Amount) + " " + u.Unit.Code
 	}
 	return formatFloat(u.Amount)
 }
 
+// DateLit represents a calendar date (e.g., 2026-03-01, Mar 1).
+// Year is 0 when omitted, meaning the current year.
+type DateLit struct {
+	Year  int
+	Month time.Month
+	Day   int
+	Raw   string
+}
+
+func (d *DateLit) node() {}
+func (d *DateLit) expr() {}
+
+func (d *DateLit) String() string {
+	if d.Raw != "" {
+		return d.Raw
+	}
+	s := d.Month.String()[:3] + " " + formatFloat(float64(d.Day))
+	if d.Year != 0 {
+		s += " " + formatFloat(float64(d.Year))
+	}
+	return s
+}
+
 // MetalLit represents a precious metal literal (e.g., 1 oz gold).
 type MetalLit struct {
 	Amount float64
+	Unit   *types.Unit // Unit of Amount, as written: "10 g gold"; nil for the metal's own
+	Karat  int         // Purity of gold, as written: "18k gold"; 0 if not given
 	Metal  *types.Metal
 	Raw    string
 }
@@ -198,16 +298,24 @@ func (m *MetalLit) String() string {
 	if m.Raw != "" {
 		return m.Raw
 	}
-	if m.Metal != nil {
-		return formatFloat(m.Amount) + " " + m.Metal.Code
+	if m.Metal == nil {
+		return formatFloat(m.Amount)
+	}
+	s := formatFloat(m.Amount) + " "
+	if m.Unit != nil {
+		s += m.Unit.Code + " "
 	}
-	return formatFloat(m.Amount)
+	if m.Karat > 0 {
+		s += strconv.Itoa(m.Karat) + "k "
+	}
+	return s + m.Metal.Code
 }
 
-// CryptoLit represents a cryptocurrency literal (e.g., 0.5 BTC).
+// CryptoLit represents a cryptocurrency literal (e.g., 0.5 BTC, 50000 sats).
 type CryptoLit struct {
 	Amount float64
 	Crypto *types.Crypto
+	Unit   *types.CryptoUnit // Sub-unit Amount is in, or nil for whole coins
 	Raw    string
 }
 
@@ -218,6 +326,9 @@ func (c *CryptoLit) String() string {
 	if c.Raw != "" {
 		return c.Raw
 	}
+	if c.Unit != nil {
+		return formatFloat(c.Amount) + " " + c.Unit.Name(c.Amount)
+	}
 	if c.Crypto != nil {
 		if c.Crypto.HasSymbol() {
 			return c.Crypto.Symbol + formatFloat(c.Amount)
@@ -227,6 +338,20 @@ func (c *CryptoLit) String() string {
 	return formatFloat(c.Amount)
 }
 
+// DiceLit represents a dice roll in dice notation (e.g., 3d6), rolled
+// each time it is evaluated.
+type DiceLit struct {
+	Count int // Number of dice
+	Sides int // Sides per die
+}
+
+func (d *DiceLit) node() {}
+func (d *DiceLit) expr() {}
+
+func (d *DiceLit) String() string {
+	return itoa(int64(d.Count)) + "d" + itoa(int64(d.Sides))
+}
+
 // ════════════════════════════════════════════════════════════════
 // EXPRESSIONS - REFERENCES
 // ════════════════════════════════════════════════════════════════
@@ -256,7 +381,9 @@ const (
 	OpMul
 	OpDiv
 	OpPow
-	OpMod
+	OpMod       // Floored modulo: 17 mod 5
+	OpIntDiv    // Floored integer division: 17 // 5
+	OpPlusMinus // Uncertainty: 100 ± 5
 )
 
 // String returns the operator symbol.
@@ -273,7 +400,11 @@ func (op BinaryOp) String() string {
 	case OpPow:
 		return "^"
 	case OpMod:
-		return "%"
+		return "mod"
+	case OpIntDiv:
+		return "//"
+	case OpPlusMinus:
+		return "±"
 	default:
 		return "?"
 	}
@@ -284,10 +415,12 @@ func (op BinaryOp) Precedence() int {
 	switch op {
 	case OpAdd, OpSub:
 		return 1
-	case OpMul, OpDiv, OpMod:
+	case OpMul, OpDiv, OpMod, OpIntDiv:
 		return 2
 	case OpPow:
 		return 3
+	case OpPlusMinus:
+		return 4 // Binds to the adjacent number: 2 * 100 ± 5
 	default:
 		return 0
 	}
@@ -304,7 +437,12 @@ func (b *BinaryExpr) node() {}
 func (b *BinaryExpr) expr() {}
 
 func (b *BinaryExpr) String() string {
-	return "(" + b.Left.String() + " " + b.Op.String() + " " + b.Right.String() + ")"
+	right := b.Right.String()
+	if b.Op == OpIntDiv && !strings.HasPrefix(right, "(") {
+		// "//" before a name would start a comment
+		right = "(" + right + ")"
+	}
+	return "(" + b.Left.String() + " " + b.Op.String() + " " + right + ")"
 }
 
 // UnaryOp represents the unary operator type.
@@ -357,19 +495,38 @@ func (p *PercentOfExpr) String() string {
 	return p.Percent.String() + " of " + p.Value.String()
 }
 
-// ConversionExpr represents a unit/currency conversion (e.g., $100 in EUR, 5 km to miles).
+// ConversionExpr represents a unit/currency conversion (e.g., $100 in EUR, 5 km to miles),
+// optionally at a past date's rates (e.g., 0.5 BTC on 2021-11-10 in USD).
 type ConversionExpr struct {
-	Value  Expr   // The value to convert
-	Target string // Target unit/currency (raw string, resolved at eval time)
+	Value  Expr     // The value to convert
+	Target string   // Target unit/currency (raw string, resolved at eval time)
+	Date   *DateLit // Date of the rates to convert at (nil = current rates)
 }
 
 func (c *ConversionExpr) node() {}
 func (c *ConversionExpr) expr() {}
 
 func (c *ConversionExpr) String() string {
+	if c.Date != nil {
+		return c.Value.String() + " on " + c.Date.String() + " in " + c.Target
+	}
 	return c.Value.String() + " in " + c.Target
 }
 
+// DisplayExpr represents a value with its currency display chosen for
+// the line (e.g., $100 as code).
+type DisplayExpr struct {
+	Value    Expr   // The value to display
+	Currency string // "code" or "symbol" (raw string, resolved at eval time)
+}
+
+func (d *DisplayExpr) node() {}
+func (d *DisplayExpr) expr() {}
+
+func (d *DisplayExpr) String() string {
+	return d.Value.String() + " as " + d.Currency
+}
+
 // CallExpr represents a function call (e.g., sum(1, 2, 3), sqrt(16)).
 type CallExpr struct {
 	Name string
@@ -387,6 +544,23 @@ func (c *CallExpr) String() string {
 	return c.Name + "(" + strings.Join(args, ", ") + ")"
 }
 
+// ListLit represents a list of values (e.g., [1, 2, 3]), passed to
+// functions as separate arguments.
+type ListLit struct {
+	Elems []Expr
+}
+
+func (l *ListLit) node() {}
+func (l *ListLit) expr() {}
+
+func (l *ListLit) String() string {
+	var elems []string
+	for _, elem := range l.Elems {
+		elems = append(elems, elem.String())
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
 // GroupExpr represents a parenthesized expression.
 type GroupExpr struct {
 	Expr Expr
@@ -430,6 +604,19 @@ func (c *ConversionContinuation) String() string {
 	return "in " + c.Target
 }
 
+// BusinessDaysExpr represents "business days between A and B".
+type BusinessDaysExpr struct {
+	From Expr
+	To   Expr
+}
+
+func (b *BusinessDaysExpr) node() {}
+func (b *BusinessDaysExpr) expr() {}
+
+func (b *BusinessDaysExpr) String() string {
+	return "business days between " + b.From.String() + " and " + b.To.String()
+}
+
 // ════════════════════════════════════════════════════════════════
 // FUTURE: CONDITIONALS (placeholder for later)
 // ════════════════════════════════════════════════════════════════
@@ -450,7 +637,7 @@ func (c *CondExpr) String() string {
 }
 
 // ════════════════════════════════════════════════════════════════
-// FUTURE: RANGES (placeholder for later)
+// RANGES
 // ════════════════════════════════════════════════════════════════
 
 // RangeExpr represents a range expression (e.g., 1..10).
@@ -471,6 +658,41 @@ func (r *RangeExpr) String() string {
 	return s
 }
 
+// LinesExpr represents the values of a range of lines (e.g., lines 1..20).
+type LinesExpr struct {
+	Range *RangeExpr
+}
+
+func (l *LinesExpr) node() {}
+func (l *LinesExpr) expr() {}
+
+func (l *LinesExpr) String() string {
+	return "lines " + l.Range.String()
+}
+
+// RateExpr represents an exchange rate (e.g., rate USD/EUR), or a
+// statistic of its recorded history (e.g., avg rate USD/EUR last 30 days).
+type RateExpr struct {
+	Stat   string // "avg", "min" or "max"; "" for the rates themselves
+	From   string // Currency, crypto or metal code
+	To     string
+	Period Expr // Time after "last" (nil for the current rate)
+}
+
+func (r *RateExpr) node() {}
+func (r *RateExpr) expr() {}
+
+func (r *RateExpr) String() string {
+	s := "rate " + r.From + "/" + r.To
+	if r.Stat != "" {
+		s = r.Stat + " " + s
+	}
+	if r.Period != nil {
+		s += " last " + r.Period.String()
+	}
+	return s
+}
+
 // ════════════════════════════════════════════════════════════════
 // HELPER FUNCTIONS
 // ════════════════════════════════════════════════════════════════
@@ -574,6 +796,14 @@ func Walk(v Visitor, node Node) {
 	case *AssignStmt:
 		Walk(v, n.Expr)
 
+	case *FuncDefStmt:
+		Walk(v, n.Body)
+
+	case *UnitDefStmt:
+		if n.Expr != nil {
+			Walk(v, n.Expr)
+		}
+
 	case *BinaryExpr:
 		Walk(v, n.Left)
 		Walk(v, n.Right)
@@ -585,14 +815,29 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Percent)
 		Walk(v, n.Value)
 
+	case *BusinessDaysExpr:
+		Walk(v, n.From)
+		Walk(v, n.To)
+
 	case *ConversionExpr:
 		Walk(v, n.Value)
+		if n.Date != nil {
+			Walk(v, n.Date)
+		}
+
+	case *DisplayExpr:
+		Walk(v, n.Value)
 
 	case *CallExpr:
 		for _, arg := range n.Args {
 			Walk(v, arg)
 		}
 
+	case *ListLit:
+		for _, elem := range n.Elems {
+			Walk(v, elem)
+		}
+
 	case *GroupExpr:
 		Walk(v, n.Expr)
 
@@ -610,7 +855,113 @@ func Walk(v Visitor, node Node) {
 		if n.Step != nil {
 			Walk(v, n.Step)
 		}
+
+	case *LinesExpr:
+		Walk(v, n.Range)
+
+	case *RateExpr:
+		if n.Period != nil {
+			Walk(v, n.Period)
+		}
+	}
+}
+
+// Tree writes the tree under node, one node per line, each indented
+// below its parent:
+//
+//	ExprStmt
+//	  BinaryExpr +
+//	    NumberLit 2
+//	    NumberLit 3
+func Tree(node Node) string {
+	var b strings.Builder
+	Walk(&treeVisitor{b: &b}, node)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// treeVisitor writes nodes for Tree at its depth.
+type treeVisitor struct {
+	b     *strings.Builder
+	depth int
+}
+
+func (v *treeVisitor) Visit(node Node) Visitor {
+	v.b.WriteString(strings.Repeat("  ", v.depth))
+	v.b.WriteString(nodeLabel(node))
+	v.b.WriteByte('\n')
+	return &treeVisitor{b: v.b, depth: v.depth + 1}
+}
+
+// nodeLabel names a node's type, with what sets it apart from others of
+// the type: its operator, name or target, or the text of a literal.
+func nodeLabel(node Node) string {
+	switch n := node.(type) {
+	case *Line:
+		return "Line"
+	case *EmptyStmt:
+		return "EmptyStmt"
+	case *CommentStmt:
+		return "CommentStmt"
+	case *ExprStmt:
+		return "ExprStmt"
+	case *AssignStmt:
+		return "AssignStmt " + n.Name
+	case *FuncDefStmt:
+		return "FuncDefStmt " + n.Name + "(" + strings.Join(n.Params, ", ") + ")"
+	case *UnitDefStmt:
+		return "UnitDefStmt " + n.Name
+	case *ScopeStmt:
+		return "ScopeStmt " + n.String()
+	case *NumberLit:
+		return "NumberLit " + n.String()
+	case *PercentLit:
+		return "PercentLit " + n.String()
+	case *CurrencyLit:
+		return "CurrencyLit " + n.String()
+	case *UnitLit:
+		return "UnitLit " + n.String()
+	case *DateLit:
+		return "DateLit " + n.String()
+	case *MetalLit:
+		return "MetalLit " + n.String()
+	case *CryptoLit:
+		return "CryptoLit " + n.String()
+	case *DiceLit:
+		return "DiceLit " + n.String()
+	case *Identifier:
+		return "Identifier " + n.Name
+	case *BinaryExpr:
+		return "BinaryExpr " + n.Op.String()
+	case *UnaryExpr:
+		return "UnaryExpr " + n.Op.String()
+	case *PercentOfExpr:
+		return "PercentOfExpr"
+	case *ConversionExpr:
+		return "ConversionExpr in " + n.Target
+	case *DisplayExpr:
+		return "DisplayExpr as " + n.Currency
+	case *CallExpr:
+		return "CallExpr " + n.Name
+	case *ListLit:
+		return "ListLit"
+	case *GroupExpr:
+		return "GroupExpr"
+	case *ContinuationExpr:
+		return "ContinuationExpr " + n.Op.String()
+	case *ConversionContinuation:
+		return "ConversionContinuation in " + n.Target
+	case *BusinessDaysExpr:
+		return "BusinessDaysExpr"
+	case *CondExpr:
+		return "CondExpr"
+	case *RangeExpr:
+		return "RangeExpr"
+	case *LinesExpr:
+		return "LinesExpr"
+	case *RateExpr:
+		return "RateExpr " + n.String()
 	}
+	return node.String()
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -620,7 +971,7 @@ func Walk(v Visitor, node Node) {
 // IsLiteral returns true if the expression is a literal value.
 func IsLiteral(e Expr) bool {
 	switch e.(type) {
-	case *NumberLit, *PercentLit, *CurrencyLit, *UnitLit, *MetalLit, *CryptoLit:
+	case *NumberLit, *PercentLit, *CurrencyLit, *UnitLit, *MetalLit, *CryptoLit, *DateLit:
 		return true
 	default:
 		return false
@@ -654,6 +1005,9 @@ func GetIdentifiers(e Expr) []string {
 		case *PercentOfExpr:
 			collect(n.Percent)
 			collect(n.Value)
+		case *BusinessDaysExpr:
+			collect(n.From)
+			collect(n.To)
 		case *ConversionExpr:
 			collect(n.Value)
 		case *CallExpr:
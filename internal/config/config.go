@@ -0,0 +1,369 @@
+// internal/config/config.go
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/0xsj/numio/internal/fetch"
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/errors"
+)
+
+// The config file holds defaults shared by the CLI and the TUI. Every key
+// is optional; environment variables and flags override it.
+//
+//	precision = 4
+//	sigfig = 4
+//	rounding = "half-even"
+//	fractions = "imperial"
+//	currencies = "code"
+//	locale = "eu"
+//	angle = "deg"
+//	seed = 42
+//	strict = true
+//	as_of = true
+//	base_currency = "EUR"
+//	theme = "nord"
+//	rate_ttl = "6h"
+//	rate_history = true
+//	providers = ["frankfurter", "coingecko"]
+//	disabled_providers = ["coincap"]
+//	fiat_source = "ecb"
+//
+//	[units]
+//	region = "uk"
+//	length = "imperial"
+//	data = "binary"
+//
+//	[rate_ttls]
+//	crypto = "2m"
+//	metal = "30m"
+//	coingecko = "1m"
+//
+//	[[custom_providers]]
+//	name = "mybank"
+//	type = "fiat"
+//	url = "https://rates.example.com/v1/latest"
+//	refresh = "1h"
+//	base = "EUR"
+//	rates = "$.data.rates"
+//	[custom_providers.headers]
+//	Authorization = "Bearer ${MYBANK_TOKEN}"
+
+// Config is the decoded config file.
+type Config struct {
+	Precision    *int          `toml:"precision"`
+	SigFigs      int           `toml:"sigfig"`
+	Rounding     string        `toml:"rounding"`
+	Fractions    string        `toml:"fractions"`
+	Currencies   string        `toml:"currencies"`
+	Locale       string        `toml:"locale"`
+	Angle        string        `toml:"angle"`
+	Seed         *uint64       `toml:"seed"`
+	Strict       *bool         `toml:"strict"`
+	AsOf         *bool         `toml:"as_of"` // Show when converted amounts' rates were fetched
+	BaseCurrency string        `toml:"base_currency"`
+	Theme        string        `toml:"theme"`
+	RateTTL      time.Duration `toml:"rate_ttl"`
+	RateHistory  bool          `toml:"rate_history"` // Keep every fetch in a SQLite database
+	Providers    []string      `toml:"providers"`    // Tried first, in order
+	Units        Units         `toml:"units"`
+
+	// Providers never to call
+	DisabledProviders []string `toml:"disabled_providers"`
+
+	// The only fiat provider used, e.g. a central bank's official rates
+	// ("ecb", "boe" or "cbrt"), instead of falling back through them all
+	FiatSource string `toml:"fiat_source"`
+
+	// Providers of the user's own, tried before the built-in ones
+	CustomProviders []CustomProvider `toml:"custom_providers"`
+
+	// TTLs per asset class (fiat, crypto, metal, commodity) or provider
+	RateTTLs map[string]time.Duration `toml:"rate_ttls"`
+}
+
+// Units holds the preferred unit settings.
+type Units struct {
+	Region string `toml:"region"`
+	Length string `toml:"length"`
+	Data   string `toml:"data"`
+}
+
+// CustomProvider describes a JSON API to fetch rates from (see
+// fetch.CustomConfig for how rates are found in its responses).
+type CustomProvider struct {
+	Name    string            `toml:"name"`
+	Type    string            `toml:"type"` // fiat (default), crypto, metal or commodity
+	URL     string            `toml:"url"`
+	Headers map[string]string `toml:"headers"` // e.g. Authorization; $VARs are expanded
+	Refresh time.Duration     `toml:"refresh"` // How long its rates stay fresh
+	Base    string            `toml:"base"`    // Currency fiat rates are quoted against (default USD)
+	Rates   string            `toml:"rates"`   // Path to an object or array of rates
+	CodeKey string            `toml:"code_key"`
+	RateKey string            `toml:"rate_key"`
+	Fields  map[string]string `toml:"fields"` // Paths to single rates, by code
+	Time    string            `toml:"time"`   // Path to when the rates were published
+}
+
+// provider returns the fetch provider p describes.
+func (p CustomProvider) provider() (*fetch.CustomProvider, error) {
+	typ := fetch.ProviderTypeFiat
+	if p.Type != "" {
+		t, ok := fetch.ParseProviderType(p.Type)
+		if !ok || t == fetch.ProviderTypeStock {
+			return nil, fmt.Errorf("%s: unknown type: %s", p.Name, p.Type)
+		}
+		typ = t
+	}
+	if p.Refresh < 0 {
+		return nil, fmt.Errorf("%s: refresh must not be negative", p.Name)
+	}
+	return fetch.NewCustomProvider(fetch.CustomConfig{
+		Name:    strings.ToLower(p.Name),
+		Type:    typ,
+		URL:     p.URL,
+		Header:  p.Headers,
+		Base:    p.Base,
+		Rates:   p.Rates,
+		CodeKey: p.CodeKey,
+		RateKey: p.RateKey,
+		Fields:  p.Fields,
+		Time:    p.Time,
+	})
+}
+
+// Path returns the path of the user's config file.
+func Path() string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "numio", "config.toml")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "config.toml"
+	}
+
+	return filepath.Join(home, ".config", "numio", "config.toml")
+}
+
+// Load reads the config file at path. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	md, err := toml.DecodeFile(path, cfg)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("%s: unknown key: %s", path, undecoded[0])
+	}
+	if cfg.RateTTL < 0 {
+		return cfg, fmt.Errorf("%s: rate_ttl must not be negative", path)
+	}
+	custom := make(map[string]bool)
+	for _, p := range cfg.CustomProviders {
+		if _, err := p.provider(); err != nil {
+			return cfg, fmt.Errorf("%s: custom_providers: %w", path, err)
+		}
+		name := strings.ToLower(p.Name)
+		if custom[name] || fetch.Default().HasProvider(name) {
+			return cfg, fmt.Errorf("%s: custom_providers: %s: name already in use", path, p.Name)
+		}
+		custom[name] = true
+	}
+	for name, ttl := range cfg.RateTTLs {
+		if _, ok := cache.ParseAssetClass(name); !ok && !fetch.Default().HasProvider(name) && !custom[name] {
+			return cfg, fmt.Errorf("%s: rate_ttls: unknown asset class or provider: %s", path, name)
+		}
+		if ttl < 0 {
+			return cfg, fmt.Errorf("%s: rate_ttls: %s must not be negative", path, name)
+		}
+	}
+	return cfg, nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// APPLYING
+// ════════════════════════════════════════════════════════════════
+
+// NewEngine creates an engine with the config's rate TTLs and settings.
+// rate_ttl replaces the default TTL of every asset class; rate_ttls then
+// sets those of single classes or providers.
+func (c *Config) NewEngine() (*engine.Engine, error) {
+	ttl, classTTLs := cache.DefaultTTL, cache.DefaultClassTTLs()
+	if c.RateTTL > 0 {
+		ttl, classTTLs = c.RateTTL, map[cache.AssetClass]time.Duration{}
+	}
+	for name, classTTL := range c.RateTTLs {
+		if class, ok := cache.ParseAssetClass(name); ok {
+			classTTLs[class] = classTTL
+		}
+	}
+
+	rc := cache.NewWithClassTTLs(ttl, classTTLs)
+	for _, p := range c.CustomProviders {
+		rc.SetProviderTTL(p.Name, p.Refresh)
+	}
+	for name, providerTTL := range c.RateTTLs {
+		if _, ok := cache.ParseAssetClass(name); !ok {
+			rc.SetProviderTTL(name, providerTTL)
+		}
+	}
+	eng := engine.NewWithCache(rc)
+	if path := cache.DefaultHistoryPath(); c.RateHistory && path != "" {
+		if err := rc.OpenHistory(path); err != nil {
+			return eng, fmt.Errorf("config: rate_history: %w", err)
+		}
+	}
+	return eng, c.Apply(eng)
+}
+
+// Apply applies the config's engine settings to eng.
+func (c *Config) Apply(eng *engine.Engine) error {
+	var settings [][2]string
+	if c.Precision != nil {
+		settings = append(settings, [2]string{"precision", strconv.Itoa(*c.Precision)})
+	}
+	if c.SigFigs != 0 {
+		settings = append(settings, [2]string{"sigfig", strconv.Itoa(c.SigFigs)})
+	}
+	if c.Rounding != "" {
+		settings = append(settings, [2]string{"rounding", c.Rounding})
+	}
+	if c.Fractions != "" {
+		settings = append(settings, [2]string{"fractions", c.Fractions})
+	}
+	if c.Locale != "" {
+		settings = append(settings, [2]string{"locale", c.Locale})
+	}
+	if c.Currencies != "" {
+		settings = append(settings, [2]string{"currencies", c.Currencies})
+	}
+	if c.Angle != "" {
+		settings = append(settings, [2]string{"angle", c.Angle})
+	}
+	if c.Seed != nil {
+		settings = append(settings, [2]string{"seed", strconv.FormatUint(*c.Seed, 10)})
+	}
+	if c.Strict != nil {
+		strict := "off"
+		if *c.Strict {
+			strict = "on"
+		}
+		settings = append(settings, [2]string{"strict", strict})
+	}
+	if c.AsOf != nil {
+		asOf := "off"
+		if *c.AsOf {
+			asOf = "on"
+		}
+		settings = append(settings, [2]string{"as of", asOf})
+	}
+	if c.BaseCurrency != "" {
+		settings = append(settings, [2]string{"base currency", c.BaseCurrency})
+	}
+	if c.Units.Region != "" {
+		settings = append(settings, [2]string{"region", c.Units.Region})
+	}
+	if c.Units.Length != "" {
+		settings = append(settings, [2]string{"length", c.Units.Length})
+	}
+	if c.Units.Data != "" {
+		settings = append(settings, [2]string{"data", c.Units.Data})
+	}
+
+	for _, s := range settings {
+		if err := eng.ApplySetting(s[0], s[1]); err != nil {
+			msg := err.Error()
+			if ee, ok := err.(*errors.Error); ok {
+				msg = ee.Message
+			}
+			return fmt.Errorf("config: %s", msg)
+		}
+	}
+	return nil
+}
+
+// Environment variables overriding providers and disabled_providers, as
+// comma-separated provider names, and fiat_source.
+const (
+	ProvidersEnv         = "NUMIO_PROVIDERS"
+	DisabledProvidersEnv = "NUMIO_DISABLED_PROVIDERS"
+	FiatSourceEnv        = "NUMIO_FIAT_SOURCE"
+)
+
+// ApplyProviders adds the config's custom providers to the front of the
+// default fetch registry, then moves its providers to the front, disables
+// its disabled providers and sets its fiat source. NUMIO_PROVIDERS,
+// NUMIO_DISABLED_PROVIDERS and NUMIO_FIAT_SOURCE replace the config's
+// settings when set.
+func (c *Config) ApplyProviders() error {
+	// In reverse, so the first listed ends up first
+	for i := len(c.CustomProviders) - 1; i >= 0; i-- {
+		p, err := c.CustomProviders[i].provider()
+		if err != nil {
+			return fmt.Errorf("config: custom_providers: %w", err)
+		}
+		if fetch.Default().HasProvider(p.Name()) {
+			return fmt.Errorf("config: custom_providers: %s: name already in use", p.Name())
+		}
+		fetch.Default().RegisterFirst(p)
+	}
+
+	providers, source := c.Providers, "config: providers"
+	if names, ok := envList(ProvidersEnv); ok {
+		providers, source = names, ProvidersEnv
+	}
+	if len(providers) > 0 {
+		if err := fetch.Default().Prioritize(providers...); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	disabled, source := c.DisabledProviders, "config: disabled_providers"
+	if names, ok := envList(DisabledProvidersEnv); ok {
+		disabled, source = names, DisabledProvidersEnv
+	}
+	if len(disabled) > 0 {
+		if err := fetch.Default().Disable(disabled...); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	fiat, source := c.FiatSource, "config: fiat_source"
+	if name, ok := os.LookupEnv(FiatSourceEnv); ok {
+		fiat, source = strings.ToLower(strings.TrimSpace(name)), FiatSourceEnv
+	}
+	if err := fetch.Default().SetSource(fetch.ProviderTypeFiat, fiat); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	return nil
+}
+
+// envList returns the comma-separated names in the environment variable
+// key, and whether it is set.
+func envList(key string) ([]string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, false
+	}
+	var names []string
+	for name := range strings.SplitSeq(value, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, true
+}
@@ -0,0 +1,71 @@
+// internal/eval/budget.go
+
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Budget limits the evaluation of each line, so that a pathological
+// input, such as a function calling itself twice over, can't run on.
+type Budget struct {
+	Ctx      context.Context // Evaluation stops once it is done; nil = never
+	MaxSteps int             // Expressions a line may evaluate; 0 = no limit
+}
+
+// budgetCheckEvery is how many steps pass between looks at the budget's
+// context, which is dearer to check than the step count.
+const budgetCheckEvery = 64
+
+// SetBudget sets the limits on each line evaluated from now on. The zero
+// Budget removes them.
+func (e *Evaluator) SetBudget(b Budget) {
+	e.budget = b
+}
+
+// Budget returns the limits on each line evaluated.
+func (e *Evaluator) Budget() Budget {
+	return e.budget
+}
+
+// startBudget starts counting a line's steps afresh.
+func (e *Evaluator) startBudget() {
+	e.steps = 0
+	e.halted = types.Value{}
+}
+
+// spend counts a step, returning false, and the error that every
+// expression then evaluates to, once the budget has run out.
+func (e *Evaluator) spend() (types.Value, bool) {
+	if e.halted.IsError() {
+		return e.halted, false
+	}
+
+	e.steps++
+	if limit := e.budget.MaxSteps; limit > 0 && e.steps > limit {
+		e.halted = types.LimitErrorf("evaluation took more than %d steps", limit)
+		return e.halted, false
+	}
+	if ctx := e.budget.Ctx; ctx != nil && e.steps%budgetCheckEvery == 1 {
+		if err := ctx.Err(); err != nil {
+			e.halted = types.LimitError(budgetError(err))
+			return e.halted, false
+		}
+	}
+	return types.Value{}, true
+}
+
+// budgetError describes why a context stopped evaluation.
+func budgetError(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "evaluation timed out"
+	case errors.Is(err, context.Canceled):
+		return "evaluation cancelled"
+	}
+	return fmt.Sprintf("evaluation stopped: %v", err)
+}
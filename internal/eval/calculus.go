@@ -0,0 +1,233 @@
+// internal/eval/calculus.go
+
+package eval
+
+import (
+	"math"
+	"strings"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// realFunc is a function of one real number. ok is false where it is
+// undefined.
+type realFunc func(x float64) (y float64, ok bool)
+
+// evalDerive evaluates derive(f, at x): the derivative of the
+// one-argument function f at x.
+func (e *Evaluator) evalDerive(args []ast.Expr) types.Value {
+	if len(args) != 2 {
+		return types.Error("derive requires a function and a point: derive(f, at 3)")
+	}
+	f, errVal := e.realFunction(args[0])
+	if f == nil {
+		return errVal
+	}
+	x := e.evalExpr(args[1])
+	if x.IsError() {
+		return x
+	}
+	if !x.IsNumber() {
+		return types.Error("derive requires a plain number to differentiate at")
+	}
+
+	d, ok := derivative(f, x.Num)
+	if !ok {
+		return types.Errorf("%s is not differentiable at %s", args[0].String(), x.String())
+	}
+	return types.Number(d)
+}
+
+// evalIntegrate evaluates integrate(f, a, b): the integral of the
+// one-argument function f from a to b.
+func (e *Evaluator) evalIntegrate(args []ast.Expr) types.Value {
+	if len(args) != 3 {
+		return types.Error("integrate requires a function and two bounds: integrate(f, 0, 10)")
+	}
+	f, errVal := e.realFunction(args[0])
+	if f == nil {
+		return errVal
+	}
+
+	var bounds [2]float64
+	for i, arg := range args[1:] {
+		v := e.evalExpr(arg)
+		if v.IsError() {
+			return v
+		}
+		if !v.IsNumber() || math.IsInf(v.Num, 0) {
+			return types.Error("integrate requires finite plain numbers as bounds")
+		}
+		bounds[i] = v.Num
+	}
+
+	area, ok := integral(f, bounds[0], bounds[1])
+	if !ok {
+		return types.Errorf("integral of %s did not converge", args[0].String())
+	}
+	return types.Number(area)
+}
+
+// realFunction returns the function named by expr, which must be a
+// user-defined function of one argument or a one-argument built-in
+// (sin, sqrt, ...). On failure it returns nil and an error value.
+func (e *Evaluator) realFunction(expr ast.Expr) (realFunc, types.Value) {
+	id, ok := expr.(*ast.Identifier)
+	if !ok {
+		return nil, types.Error("expected a function name, e.g. f after f(x) = x^2")
+	}
+
+	call := func(x float64) types.Value {
+		return e.callFunction(strings.ToLower(id.Name), []types.Value{types.Number(x)})
+	}
+	if fn, ok := e.ctx.Function(id.Name); ok {
+		if len(fn.Params) != 1 {
+			return nil, types.Errorf("%s must take one argument", id.Name)
+		}
+		call = func(x float64) types.Value {
+			return e.callFunctionDef(fn, []types.Value{types.Number(x)})
+		}
+	} else if !isBuiltinFunction(strings.ToLower(id.Name)) {
+		return nil, types.Errorf("unknown function: %s", id.Name)
+	}
+
+	return func(x float64) (float64, bool) {
+		v := call(x)
+		if !v.IsNumeric() || math.IsNaN(v.Num) || math.IsInf(v.Num, 0) {
+			return 0, false
+		}
+		return v.AsFloat(), true
+	}, types.Empty()
+}
+
+// derivative returns f'(x) by Ridders' method: central differences with
+// shrinking steps, extrapolated to a zero step. f must be defined at x,
+// and the differences must settle: at a pole or a jump they grow as the
+// steps shrink, and the error estimate stays large.
+func derivative(f realFunc, x float64) (float64, bool) {
+	if _, ok := f(x); !ok {
+		return 0, false
+	}
+
+	// Start from a step as large as x allows, then from smaller ones where
+	// f changes too fast for it (sin at 1000) or isn't defined that far
+	// out (1/x at 1e-9)
+	minStep := math.Max(1e-10*math.Abs(x), 1e-12)
+	for h := 0.1 * math.Max(1, math.Abs(x)); h >= minStep; h /= 100 {
+		if d, ok := ridders(f, x, h); ok {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// ridders returns f'(x) by Ridders' method from a first step h, or false
+// if its error estimate doesn't settle.
+func ridders(f realFunc, x, h float64) (float64, bool) {
+	const (
+		shrink    = 1.4 // Step size ratio between rounds
+		rounds    = 10
+		safe      = 2.0  // Stop once the error grows by this much
+		tolerance = 1e-6 // Largest error accepted, relative to the result's size
+	)
+
+	central := func(h float64) (float64, bool) {
+		hi, ok1 := f(x + h)
+		lo, ok2 := f(x - h)
+		return (hi - lo) / (2 * h), ok1 && ok2
+	}
+
+	var table [rounds][rounds]float64
+	d, ok := central(h)
+	if !ok {
+		return 0, false
+	}
+	table[0][0] = d
+
+	best, bestErr := d, math.Inf(1)
+	for i := 1; i < rounds; i++ {
+		h /= shrink
+		if table[0][i], ok = central(h); !ok {
+			return 0, false
+		}
+
+		// Extrapolate to higher orders
+		fac := shrink * shrink
+		for j := 1; j <= i; j++ {
+			table[j][i] = (table[j-1][i]*fac - table[j-1][i-1]) / (fac - 1)
+			fac *= shrink * shrink
+			err := math.Max(math.Abs(table[j][i]-table[j-1][i]), math.Abs(table[j][i]-table[j-1][i-1]))
+			if err <= bestErr {
+				best, bestErr = table[j][i], err
+			}
+		}
+		if math.Abs(table[i][i]-table[i-1][i-1]) >= safe*bestErr {
+			break
+		}
+	}
+	if math.IsNaN(best) || math.IsInf(best, 0) || !(bestErr <= tolerance*math.Max(1, math.Abs(best))) {
+		return 0, false
+	}
+	return best, true
+}
+
+// Limits for adaptive integration.
+const (
+	integralTolerance = 1e-10  // Target error, relative to the result's size
+	integralDepth     = 50     // Deepest interval splitting
+	integralEvals     = 200000 // Most evaluations of f
+)
+
+// integral returns the integral of f from a to b by adaptive Simpson's
+// rule, splitting intervals until each is within tolerance.
+func integral(f realFunc, a, b float64) (float64, bool) {
+	if a == b {
+		return 0, true
+	}
+
+	s := simpsonState{f: f, ok: true}
+	fa, fm, fb := s.eval(a), s.eval((a+b)/2), s.eval(b)
+	whole := (b - a) / 6 * (fa + 4*fm + fb)
+	tol := integralTolerance * math.Max(1, math.Abs(whole))
+	area := s.adapt(a, b, fa, fm, fb, whole, tol, integralDepth)
+	return area, s.ok
+}
+
+// simpsonState tracks an adaptive Simpson integration.
+type simpsonState struct {
+	f     realFunc
+	evals int
+	ok    bool // False once f is undefined or the budget runs out
+}
+
+// eval evaluates f, recording failures.
+func (s *simpsonState) eval(x float64) float64 {
+	s.evals++
+	y, ok := s.f(x)
+	if !ok || s.evals > integralEvals {
+		s.ok = false
+	}
+	return y
+}
+
+// adapt integrates [a, b], whose Simpson estimate is whole, to within
+// tol.
+func (s *simpsonState) adapt(a, b, fa, fm, fb, whole, tol float64, depth int) float64 {
+	m := (a + b) / 2
+	lm, rm := (a+m)/2, (m+b)/2
+	flm, frm := s.eval(lm), s.eval(rm)
+	left := (m - a) / 6 * (fa + 4*flm + fm)
+	right := (b - m) / 6 * (fm + 4*frm + fb)
+	delta := left + right - whole
+
+	if !s.ok || math.Abs(delta) <= 15*tol {
+		return left + right + delta/15
+	}
+	if depth <= 0 {
+		s.ok = false
+		return left + right
+	}
+	return s.adapt(a, m, fa, flm, fm, left, tol/2, depth-1) +
+		s.adapt(m, b, fm, frm, fb, right, tol/2, depth-1)
+}
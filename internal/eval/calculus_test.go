@@ -0,0 +1,55 @@
+// internal/eval/calculus_test.go
+
+package eval
+
+import (
+	"math"
+	"testing"
+)
+
+// finite wraps a float function as a realFunc, undefined where it isn't
+// finite.
+func finite(f func(float64) float64) realFunc {
+	return func(x float64) (float64, bool) {
+		y := f(x)
+		return y, !math.IsNaN(y) && !math.IsInf(y, 0)
+	}
+}
+
+func TestDerivativeRejectsPoles(t *testing.T) {
+	tests := []struct {
+		name string
+		f    realFunc
+		x    float64
+	}{
+		{"1/x at 0", finite(func(x float64) float64 { return 1 / x }), 0},
+		{"1/x^2 at 0", finite(func(x float64) float64 { return 1 / (x * x) }), 0},
+		{"floor at 0", finite(math.Floor), 0},
+		{"sqrt at 0", finite(math.Sqrt), 0},
+	}
+	for _, tt := range tests {
+		if d, ok := derivative(tt.f, tt.x); ok {
+			t.Errorf("%s = %g, want not differentiable", tt.name, d)
+		}
+	}
+}
+
+func TestDerivative(t *testing.T) {
+	tests := []struct {
+		name string
+		f    realFunc
+		x    float64
+		want float64
+	}{
+		{"sin at 1", finite(math.Sin), 1, math.Cos(1)},
+		{"sin at 1000", finite(math.Sin), 1000, math.Cos(1000)},
+		{"1/x at 1e-9", finite(func(x float64) float64 { return 1 / x }), 1e-9, -1e18},
+		{"x^3 at 100", finite(func(x float64) float64 { return x * x * x }), 100, 30000},
+	}
+	for _, tt := range tests {
+		d, ok := derivative(tt.f, tt.x)
+		if !ok || math.Abs(d-tt.want) > 1e-6*math.Max(1, math.Abs(tt.want)) {
+			t.Errorf("%s = %g (%v), want %g", tt.name, d, ok, tt.want)
+		}
+	}
+}
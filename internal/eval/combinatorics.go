@@ -0,0 +1,178 @@
+// internal/eval/combinatorics.go
+
+package eval
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+const (
+	// maxFactorial is the largest n whose factorial is calculated, and
+	// the largest number of factors in nCr and nPr.
+	maxFactorial = 10000
+
+	// maxExactInteger is the largest whole number a float64 holds exactly.
+	maxExactInteger = 1 << 53
+)
+
+// wholeArg returns args[i] as a non-negative whole number, or an error
+// value naming the function.
+func wholeArg(name string, args []types.Value, i int) (int64, types.Value) {
+	v := args[i]
+	if !v.IsNumber() || !isInteger(v.Num) || v.Num < 0 || v.Num > maxExactInteger {
+		return 0, types.Errorf("%s requires whole numbers from 0, got %s", name, v.String())
+	}
+	return int64(v.Num), types.Empty()
+}
+
+// fnFactorial returns n!: exactly for whole numbers, however large, and
+// by the gamma function otherwise (0.5! = √π/2).
+func (e *Evaluator) fnFactorial(args []types.Value) types.Value {
+	if len(args) != 1 || !args[0].IsNumber() || math.IsInf(args[0].Num, 0) || math.IsNaN(args[0].Num) {
+		return types.Error("factorial requires a finite plain number")
+	}
+	x := args[0].Num
+	if !isInteger(x) {
+		return types.Number(math.Gamma(x + 1))
+	}
+	if x < 0 {
+		return types.Error("factorial is undefined for negative whole numbers")
+	}
+	if x > maxFactorial {
+		return types.Errorf("factorial is limited to %d!", maxFactorial)
+	}
+	return types.BigInteger(new(big.Int).MulRange(1, int64(x)))
+}
+
+// fnChoose returns the number of ways to choose k of n items: nCr(10, 3)
+// for combinations, nPr(10, 3) for ordered permutations.
+func (e *Evaluator) fnChoose(args []types.Value, ordered bool) types.Value {
+	name := "nCr"
+	if ordered {
+		name = "nPr"
+	}
+	if len(args) != 2 {
+		return types.Errorf("%s requires two arguments: %s(10, 3)", name, name)
+	}
+	n, errVal := wholeArg(name, args, 0)
+	if errVal.IsError() {
+		return errVal
+	}
+	k, errVal := wholeArg(name, args, 1)
+	if errVal.IsError() {
+		return errVal
+	}
+	if k > n {
+		return types.Number(0)
+	}
+
+	if ordered {
+		if k > maxFactorial {
+			return types.Errorf("%s is limited to %d factors", name, maxFactorial)
+		}
+		return types.BigInteger(new(big.Int).MulRange(n-k+1, n))
+	}
+	if min(k, n-k) > maxFactorial {
+		return types.Errorf("%s is limited to %d factors", name, maxFactorial)
+	}
+	return types.BigInteger(new(big.Int).Binomial(n, k))
+}
+
+// fnGCD returns the greatest common divisor of whole numbers.
+func (e *Evaluator) fnGCD(args []types.Value) types.Value {
+	nums, errVal := bigArgs("gcd", args)
+	if nums == nil {
+		return errVal
+	}
+	gcd := nums[0]
+	for _, n := range nums[1:] {
+		gcd = new(big.Int).GCD(nil, nil, gcd, n)
+	}
+	return types.BigInteger(gcd)
+}
+
+// fnLCM returns the least common multiple of whole numbers.
+func (e *Evaluator) fnLCM(args []types.Value) types.Value {
+	nums, errVal := bigArgs("lcm", args)
+	if nums == nil {
+		return errVal
+	}
+	lcm := nums[0]
+	for _, n := range nums[1:] {
+		if lcm.Sign() == 0 || n.Sign() == 0 {
+			lcm = new(big.Int)
+			continue
+		}
+		gcd := new(big.Int).GCD(nil, nil, lcm, n)
+		lcm = new(big.Int).Mul(new(big.Int).Quo(lcm, gcd), n)
+	}
+	return types.BigInteger(lcm)
+}
+
+// bigArgs returns the absolute values of whole-number arguments. On
+// failure it returns nil and an error value.
+func bigArgs(name string, args []types.Value) ([]*big.Int, types.Value) {
+	if len(args) == 0 {
+		return nil, types.Errorf("%s requires whole numbers: %s(12, 18)", name, name)
+	}
+	nums := make([]*big.Int, len(args))
+	for i, v := range args {
+		if v.Big != nil {
+			nums[i] = new(big.Int).Abs(v.Big)
+			continue
+		}
+		if !v.IsNumber() || !isInteger(v.Num) || math.Abs(v.Num) > maxExactInteger {
+			return nil, types.Errorf("%s requires whole numbers, got %s", name, v.String())
+		}
+		nums[i] = big.NewInt(int64(math.Abs(v.Num)))
+	}
+	return nums, types.Empty()
+}
+
+// applyBigOp adds, subtracts, multiplies or evenly divides whole plain
+// numbers exactly when one is too large for a float64 to hold exactly:
+// 25! + 1, 200! / 199!. It reports false for other operations and values.
+func applyBigOp(op ast.BinaryOp, left, right types.Value) (types.Value, bool) {
+	a, ok := exactInteger(left)
+	if !ok {
+		return types.Value{}, false
+	}
+	b, ok := exactInteger(right)
+	if !ok {
+		return types.Value{}, false
+	}
+	switch op {
+	case ast.OpAdd:
+		return types.BigInteger(a.Add(a, b)), true
+	case ast.OpSub:
+		return types.BigInteger(a.Sub(a, b)), true
+	case ast.OpMul:
+		return types.BigInteger(a.Mul(a, b)), true
+	case ast.OpDiv:
+		if b.Sign() == 0 {
+			return types.Value{}, false
+		}
+		if q, r := new(big.Int).QuoRem(a, b, new(big.Int)); r.Sign() == 0 {
+			return types.BigInteger(q), true
+		}
+	}
+	return types.Value{}, false
+}
+
+// exactInteger returns a plain number as a big.Int, if it is whole and
+// held exactly.
+func exactInteger(v types.Value) (*big.Int, bool) {
+	switch {
+	case !v.IsNumber() || v.Uncertainty != 0 || v.Compact:
+		return nil, false
+	case v.Big != nil:
+		return new(big.Int).Set(v.Big), true
+	case isInteger(v.Num) && math.Abs(v.Num) <= maxExactInteger:
+		return big.NewInt(int64(v.Num)), true
+	}
+	return nil, false
+}
@@ -0,0 +1,61 @@
+// internal/eval/combinatorics_test.go
+
+package eval
+
+import (
+	"testing"
+
+	"github.com/0xsj/numio/internal/parser"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// evalString evaluates one line on a new evaluator.
+func evalString(t *testing.T, input string) types.Value {
+	t.Helper()
+	line, errs := parser.ParseLine(input)
+	if len(errs) > 0 {
+		t.Fatalf("%s: %v", input, errs[0])
+	}
+	return NewWithContext(NewContext()).EvalLine(line)
+}
+
+func TestLargeIntegersStayExact(t *testing.T) {
+	tests := []struct {
+		input string
+		big   string // The exact result, if above 2^53
+		num   float64
+	}{
+		{"21!", "51090942171709440000", 0},
+		{"25! + 1", "15511210043330985984000001", 0},
+		{"20! * 21 - 21!", "", 0},
+		{"25! + 1 - 25!", "", 1},
+		{"200! / 199!", "", 200},
+		{"gcd(30!, 25!) - 25!", "", 0},
+	}
+	for _, tt := range tests {
+		v := evalString(t, tt.input)
+		if v.IsError() {
+			t.Errorf("%s: %s", tt.input, v.ErrorMessage())
+			continue
+		}
+		switch {
+		case tt.big != "" && (v.Big == nil || v.Big.String() != tt.big):
+			t.Errorf("%s = %v, want exactly %s", tt.input, v.Big, tt.big)
+		case tt.big == "" && (v.Big != nil || v.Num != tt.num):
+			t.Errorf("%s = %s, want %g", tt.input, v.String(), tt.num)
+		}
+	}
+}
+
+func TestLargeIntegersCalculate(t *testing.T) {
+	v := evalString(t, "25! / 29")
+	if v.IsError() || v.Big != nil {
+		t.Fatalf("25! / 29 = %s", v.String())
+	}
+	if want := 15511210043330985984000000.0 / 29; v.Num != want {
+		t.Errorf("25! / 29 = %g, want %g", v.Num, want)
+	}
+	if v := evalString(t, "2000! / 2003"); !v.IsError() {
+		t.Errorf("2000! / 2003 = %s, want too large", v.String())
+	}
+}
@@ -4,9 +4,17 @@
 package eval
 
 import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/0xsj/numio/internal/ast"
 	"github.com/0xsj/numio/pkg/types"
 )
 
@@ -16,6 +24,9 @@ type RateCacheAdapter interface {
 	GetRate(from, to string) (float64, bool)
 	Convert(amount float64, from, to string) (float64, bool)
 	ConvertValue(v types.Value, target string) (types.Value, bool)
+	RateTime(from, to string) time.Time
+	RateSeries(from, to string, since time.Time) ([]float64, error)
+	ConvertValueAt(v types.Value, target string, date time.Time) (types.Value, error)
 }
 
 // Context holds the evaluation state including variables and rate cache.
@@ -25,6 +36,22 @@ type Context struct {
 	// Variables map
 	variables map[string]types.Value
 
+	// Descriptions of variables, from the comments on the lines setting
+	// them: tax = 8.25%  # local sales tax
+	descriptions map[string]string
+
+	// Blocks between begin and end, and those still open, innermost last
+	blocks []Block
+	scopes []scope
+
+	// User-defined functions: f(x) = x^2
+	functions map[string]*ast.FuncDefStmt
+
+	// User-defined units: unit sprint = 2 weeks. Clear drops those the
+	// document defined, back to those kept with KeepUnit.
+	units     *types.UnitSet
+	keptUnits *types.UnitSet // Never changed, only replaced
+
 	// Rate cache adapter for currency/crypto conversions
 	rateCache RateCacheAdapter
 
@@ -32,11 +59,41 @@ type Context struct {
 	previous types.Value
 
 	// Line results (for continuation tracking)
-	lines []LineResult
+	lines  []LineResult
+	lineNo int // Lines seen, including blank lines and comments
 
 	// Settings
-	precision int  // Decimal precision for display
-	strict    bool // Strict mode (error on undefined variables)
+	precision int                   // Decimal places for display (types.AutoPrecision = by magnitude)
+	sigFigs   int                   // Significant figures for display (0 = use precision)
+	rounding  types.RoundingMode    // How displayed and round()ed values are rounded
+	fractions types.FractionDisplay // When values are displayed as fractions
+	currency  types.CurrencyDisplay // Whether currencies show symbols or codes
+	locale    types.InputLocale     // How numbers are written in input
+	angles    types.AngleMode       // How trig functions read plain numbers
+	strict    bool                  // Strict mode (error on undefined variables)
+	asOf      bool                  // Show when converted amounts' rates were fetched
+	region    types.Region          // Regional unit variants (US/UK)
+	lengths   types.LengthDisplay   // Display mode for lengths
+	dataUnits types.DataUnits       // Binary or decimal KB/MB/GB
+	calendar  *types.Calendar       // Weekend days and holidays
+	base      *types.Currency       // Display currency for totals (nil = last used)
+	limits    Limits                // Most a line may build
+
+	// Scenario values that replace variables: "what if rate were 7%"
+	overrides map[string]types.Value
+
+	// Values frozen with !pin, by statement. They outlive Clear, so a
+	// re-evaluated document keeps them.
+	pins map[string]types.Value
+
+	// Random numbers: rand(), randint(), dice rolls
+	seed uint64     // Seed the generator restarts from on Clear
+	pcg  *rand.PCG  // Generator state
+	rng  *rand.Rand // Generator over pcg
+
+	// Called when a line is recorded and when a variable is set (nil = off)
+	onLine func(LineResult)
+	onSet  func(name string, value types.Value)
 }
 
 // LineResult stores the result of evaluating a single line.
@@ -45,19 +102,42 @@ type LineResult struct {
 	Value          types.Value // Computed value
 	IsConsumed     bool        // True if consumed by continuation
 	IsContinuation bool        // True if this was a continuation
+	IsTotal        bool        // True if it uses the running total, so totals leave it out
 	AssignedVar    string      // Variable name if assignment
+	Converted      types.Value // Value converted from, if a conversion
+	Line           int         // Line number, counting lines without results
+	Share          bool        // Shows its share of the total: % of total
 }
 
 // NewContext creates a new evaluation context.
 func NewContext() *Context {
-	return &Context{
+	c := &Context{
 		variables: make(map[string]types.Value),
+		functions: make(map[string]*ast.FuncDefStmt),
+		units:     types.NewUnitSet(),
+		keptUnits: types.NewUnitSet(),
+		pins:      make(map[string]types.Value),
 		rateCache: nil,
 		previous:  types.Empty(),
 		lines:     nil,
-		precision: 2,
+		precision: types.AutoPrecision,
+		rounding:  types.RoundHalfUp,
 		strict:    false,
+		region:    types.RegionUS,
+		lengths:   types.LengthAsIs,
+		dataUnits: types.DataBinary,
+		calendar:  types.NewCalendar(),
+		limits:    DefaultLimits(),
+		seed:      rand.Uint64(),
 	}
+	c.reseed()
+	return c
+}
+
+// reseed restarts c's random number generator from its seed.
+func (c *Context) reseed() {
+	c.pcg = rand.NewPCG(c.seed, 0)
+	c.rng = rand.New(c.pcg)
 }
 
 // SetRateCacheAdapter sets the rate cache adapter.
@@ -67,6 +147,16 @@ func (c *Context) SetRateCacheAdapter(adapter RateCacheAdapter) {
 	c.rateCache = adapter
 }
 
+// SetHooks sets the functions called when a line is added to the history
+// and when a variable is set, after the change and outside c's lock, so
+// they may read c. nil turns one off. Clones don't keep them.
+func (c *Context) SetHooks(onLine func(LineResult), onSet func(name string, value types.Value)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onLine = onLine
+	c.onSet = onSet
+}
+
 // ════════════════════════════════════════════════════════════════
 // VARIABLE OPERATIONS
 // ════════════════════════════════════════════════════════════════
@@ -89,6 +179,14 @@ func (c *Context) GetVariable(name string) (types.Value, bool) {
 		return c.calculateTotal(), true
 	}
 
+	if back, ok := ResultIndex(lower); ok {
+		return c.result(back)
+	}
+
+	if v, ok := c.override(name); ok {
+		return v, true
+	}
+
 	// Regular variable lookup
 	v, ok := c.variables[name]
 	if !ok {
@@ -100,16 +198,24 @@ func (c *Context) GetVariable(name string) (types.Value, bool) {
 
 // SetVariable sets a variable value.
 func (c *Context) SetVariable(name string, value types.Value) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	// Don't store special variables
 	lower := strings.ToLower(name)
 	if lower == "_" || lower == "ans" || lower == "total" {
 		return
 	}
+	if _, ok := ResultIndex(lower); ok {
+		return
+	}
 
+	c.mu.Lock()
+	c.shadow(name)
 	c.variables[name] = value
+	onSet := c.onSet
+	c.mu.Unlock()
+
+	if onSet != nil {
+		onSet(name, value)
+	}
 }
 
 // DeleteVariable removes a variable.
@@ -117,6 +223,35 @@ func (c *Context) DeleteVariable(name string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.variables, name)
+	delete(c.descriptions, name)
+}
+
+// Description returns a variable's description, or "".
+func (c *Context) Description(name string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if d, ok := c.descriptions[name]; ok {
+		return d
+	}
+	return c.descriptions[strings.ToLower(name)]
+}
+
+// SetDescription sets a variable's description. Setting the variable
+// again keeps it.
+func (c *Context) SetDescription(name, description string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.descriptions == nil {
+		c.descriptions = make(map[string]string)
+	}
+	c.descriptions[name] = description
+}
+
+// Descriptions returns a copy of the variables' descriptions.
+func (c *Context) Descriptions() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.descriptions)
 }
 
 // HasVariable checks if a variable exists.
@@ -154,6 +289,141 @@ func (c *Context) ClearVariables() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.variables = make(map[string]types.Value)
+	c.descriptions = nil
+	c.resetScopes()
+}
+
+// ════════════════════════════════════════════════════════════════
+// PINNED VALUES
+// ════════════════════════════════════════════════════════════════
+
+// PinnedValue returns the value frozen for a statement, if any.
+func (c *Context) PinnedValue(stmt string) (types.Value, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.pins[stmt]
+	return v, ok
+}
+
+// HasPins reports whether any values are frozen.
+func (c *Context) HasPins() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.pins) > 0
+}
+
+// Pin freezes the value of a statement.
+func (c *Context) Pin(stmt string, value types.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pins[stmt] = value
+}
+
+// Unpin forgets the value frozen for a statement.
+func (c *Context) Unpin(stmt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pins, stmt)
+}
+
+// PinnedValues returns a copy of the frozen values, by statement.
+func (c *Context) PinnedValues() map[string]types.Value {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]types.Value, len(c.pins))
+	for k, v := range c.pins {
+		result[k] = v
+	}
+	return result
+}
+
+// ClearPins forgets all frozen values.
+func (c *Context) ClearPins() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pins = make(map[string]types.Value)
+}
+
+// ════════════════════════════════════════════════════════════════
+// SCENARIO OVERRIDES
+// ════════════════════════════════════════════════════════════════
+
+// SetOverrides sets the scenario values that replace variables. An
+// overridden variable reads as its override, and assigning to it keeps
+// the override, so lines that depend on it recompute without the
+// document changing. nil clears them.
+func (c *Context) SetOverrides(overrides map[string]types.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.overrides = nil
+	if len(overrides) > 0 {
+		c.overrides = make(map[string]types.Value, len(overrides))
+		for k, v := range overrides {
+			c.overrides[k] = v
+		}
+	}
+}
+
+// Overrides returns a copy of the scenario values.
+func (c *Context) Overrides() map[string]types.Value {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]types.Value, len(c.overrides))
+	for k, v := range c.overrides {
+		result[k] = v
+	}
+	return result
+}
+
+// Override returns the scenario value for a variable, if it has one.
+func (c *Context) Override(name string) (types.Value, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.override(name)
+}
+
+// override looks up a scenario value, falling back to the lowercase name
+// like variables do. Callers must hold the lock.
+func (c *Context) override(name string) (types.Value, bool) {
+	v, ok := c.overrides[name]
+	if !ok {
+		v, ok = c.overrides[strings.ToLower(name)]
+	}
+	return v, ok
+}
+
+// ════════════════════════════════════════════════════════════════
+// FUNCTION OPERATIONS
+// ════════════════════════════════════════════════════════════════
+
+// Function retrieves a user-defined function.
+func (c *Context) Function(name string) (*ast.FuncDefStmt, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fn, ok := c.functions[name]
+	return fn, ok
+}
+
+// DefineFunction adds or replaces a user-defined function.
+func (c *Context) DefineFunction(fn *ast.FuncDefStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.functions[fn.Name] = fn
+}
+
+// Functions returns a copy of all user-defined functions.
+func (c *Context) Functions() map[string]*ast.FuncDefStmt {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]*ast.FuncDefStmt, len(c.functions))
+	for k, fn := range c.functions {
+		result[k] = fn
+	}
+	return result
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -178,6 +448,45 @@ func (c *Context) SetPrevious(v types.Value) {
 	}
 }
 
+// Result returns the result back lines up the line history, counting
+// only lines with a value: 1 is the previous result, as _ is.
+func (c *Context) Result(back int) (types.Value, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.result(back)
+}
+
+// result returns the result back lines up. Callers must hold the lock.
+func (c *Context) result(back int) (types.Value, bool) {
+	if back < 1 {
+		return types.Empty(), false
+	}
+	for i := len(c.lines) - 1; i >= 0; i-- {
+		v := c.lines[i].Value
+		if v.IsEmpty() || v.IsError() {
+			continue
+		}
+		if back--; back == 0 {
+			return v, true
+		}
+	}
+	return types.Empty(), false
+}
+
+// ResultIndex reports whether name reaches back up the line history, as
+// _1, _2 and so on do, and how far.
+func ResultIndex(name string) (int, bool) {
+	digits, ok := strings.CutPrefix(name, "_")
+	if !ok || digits == "" || digits[0] < '1' || digits[0] > '9' {
+		return 0, false
+	}
+	back, err := strconv.Atoi(digits)
+	if err != nil || back < 1 {
+		return 0, false
+	}
+	return back, true
+}
+
 // HasPrevious returns true if there's a valid previous result.
 func (c *Context) HasPrevious() bool {
 	c.mu.RLock()
@@ -189,11 +498,35 @@ func (c *Context) HasPrevious() bool {
 // LINE TRACKING
 // ════════════════════════════════════════════════════════════════
 
-// AddLineResult adds a line result to the history.
+// AddLineResult adds a line result to the history, numbering it as the
+// next line.
 func (c *Context) AddLineResult(result LineResult) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.lineNo++
+	result.Line = c.lineNo
 	c.lines = append(c.lines, result)
+	onLine := c.onLine
+	c.mu.Unlock()
+
+	if onLine != nil {
+		onLine(result)
+	}
+}
+
+// LineNumber returns the number of lines seen, which is the line number
+// of the last.
+func (c *Context) LineNumber() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lineNo
+}
+
+// SkipLine counts a line that has no result (blank, comment, or
+// settings), so later line numbers match the document.
+func (c *Context) SkipLine() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lineNo++
 }
 
 // Lines returns all line results.
@@ -237,27 +570,126 @@ func (c *Context) MarkLastConsumed() {
 func (c *Context) ClearLines() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.lines = nil
+	c.resetLines()
+	c.lineNo = 0
+}
+
+// resetLines empties the line history, keeping its buffer for the next
+// document. Lines hands out copies, so nothing else refers to it. Callers
+// must hold the lock.
+func (c *Context) resetLines() {
+	clear(c.lines)
+	c.lines = c.lines[:0]
 }
 
 // ════════════════════════════════════════════════════════════════
 // TOTALS
 // ════════════════════════════════════════════════════════════════
 
-// calculateTotal calculates the sum of all non-consumed line values.
+// calculateTotal calculates the sum of all line values, leaving out lines
+// consumed by a continuation and lines that use the total.
+// Money is converted to the currency groupedTotals uses (see moneyTarget)
+// and the total is in it. Uncertainties add in quadrature.
 func (c *Context) calculateTotal() types.Value {
-	var total float64
+	var total, variance float64
+	var money bool
 
+	target := c.moneyTarget(c.lines)
 	for _, lr := range c.lines {
-		if lr.IsConsumed {
+		if lr.IsConsumed || lr.IsTotal {
 			continue
 		}
-		if lr.Value.IsNumeric() {
-			total += lr.Value.AsFloat()
+		if !lr.Value.IsNumeric() {
+			continue
 		}
+		if code := moneyCode(lr.Value); code != "" {
+			if amount, ok := c.convert(lr.Value.Num, code, target.Code); ok {
+				u, _ := c.convert(lr.Value.Uncertainty, code, target.Code)
+				total += amount
+				variance += u * u
+				money = true
+				continue
+			}
+		}
+		total += lr.Value.AsFloat()
+		variance += lr.Value.Uncertainty * lr.Value.Uncertainty
 	}
 
-	return types.Number(total)
+	if money {
+		return types.CurrencyValue(total, target).WithUncertainty(math.Sqrt(variance))
+	}
+	return types.Number(total).WithUncertainty(math.Sqrt(variance))
+}
+
+// Shares returns the share of the total that each line ending "% of
+// total", numbered from on, comes to, by line number. The total is of
+// every line so far, so shares are final once the document has been
+// evaluated.
+func (c *Context) Shares(from int) map[int]types.Value {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var shares map[int]types.Value
+	var total types.Value
+	for i := len(c.lines) - 1; i >= 0 && c.lines[i].Line >= from; i-- {
+		lr := c.lines[i]
+		if !lr.Share {
+			continue
+		}
+		if shares == nil {
+			shares = make(map[int]types.Value)
+			total = c.calculateTotal()
+		}
+		shares[lr.Line] = c.shareOf(lr.Value, total)
+	}
+	return shares
+}
+
+// shareOf returns v's share of total as a percentage, converting money
+// to the total's currency. Callers must hold the lock.
+func (c *Context) shareOf(v, total types.Value) types.Value {
+	if v.IsError() {
+		return v
+	}
+	if !v.IsNumeric() {
+		return types.Error("% of total needs a number")
+	}
+
+	amount := v.AsFloat()
+	if total.Kind == types.ValueCurrency && total.Curr != nil {
+		if code := moneyCode(v); code != "" {
+			if converted, ok := c.convert(v.Num, code, total.Curr.Code); ok {
+				amount = converted
+			}
+		}
+	}
+	if total.Num == 0 {
+		return types.Error("total is zero")
+	}
+	return types.Percentage(amount / total.Num)
+}
+
+// moneyCode returns the currency or crypto code of v, or "".
+func moneyCode(v types.Value) string {
+	switch {
+	case v.Kind == types.ValueCurrency && v.Curr != nil:
+		return v.Curr.Code
+	case v.Kind == types.ValueCrypto && v.Crypto != nil:
+		return v.Crypto.Code
+	}
+	return ""
+}
+
+// convert converts an amount between currencies, if there is a rate.
+// Caller must hold the read lock.
+func (c *Context) convert(amount float64, from, to string) (float64, bool) {
+	if from == to {
+		return amount, true
+	}
+	if c.rateCache == nil {
+		return 0, false
+	}
+	return c.rateCache.Convert(amount, from, to)
 }
 
 // Total returns the running total of all results.
@@ -271,45 +703,66 @@ func (c *Context) Total() types.Value {
 func (c *Context) GroupedTotals() []types.Value {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	return c.groupedTotals(c.lines)
+}
+
+// GroupedTotalsOf returns grouped totals for a subset of line results,
+// such as one section of a document.
+func (c *Context) GroupedTotalsOf(lines []LineResult) []types.Value {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.groupedTotals(lines)
+}
+
+// moneyTarget returns the currency money among lines is totalled in: the
+// base currency, or else the last currency used (USD if none).
+// Caller must hold the read lock.
+func (c *Context) moneyTarget(lines []LineResult) *types.Currency {
+	if c.base != nil {
+		return c.base
+	}
+	target := types.ParseCurrency("USD")
+	for _, lr := range lines {
+		if !lr.IsConsumed && !lr.IsTotal && lr.Value.Kind == types.ValueCurrency && lr.Value.Curr != nil {
+			target = lr.Value.Curr
+		}
+	}
+	return target
+}
 
+// groupedTotals groups and sums line results. Money is summed in the base
+// currency, or else the last currency used (USD if none); amounts without
+// a rate to it get totals of their own.
+// Caller must hold the read lock.
+func (c *Context) groupedTotals(lines []LineResult) []types.Value {
 	// Track totals by type
-	currencyTotals := make(map[string]float64)     // currency code -> amount
 	unitTotals := make(map[types.UnitType]float64) // unit type -> base amount
-	var lastCurrency *types.Currency
 	lastUnits := make(map[types.UnitType]*types.Unit)
 	var plainTotal float64
 
-	for _, lr := range c.lines {
-		if lr.IsConsumed || lr.Value.IsEmpty() || lr.Value.IsError() {
+	target := c.moneyTarget(lines)
+	var moneyTotal float64
+	var hasMoney bool
+	unconverted := make(map[string]types.Value) // code -> total without a rate
+
+	for _, lr := range lines {
+		if lr.IsConsumed || lr.IsTotal || lr.Value.IsEmpty() || lr.Value.IsError() {
 			continue
 		}
 
 		switch lr.Value.Kind {
-		case types.ValueCurrency:
-			if lr.Value.Curr != nil {
-				code := lr.Value.Curr.Code
-				// Convert to USD for summing
-				if c.rateCache != nil {
-					if usdAmount, ok := c.rateCache.Convert(lr.Value.Num, code, "USD"); ok {
-						currencyTotals["USD"] += usdAmount
-					} else {
-						currencyTotals[code] += lr.Value.Num
-					}
-				} else {
-					currencyTotals[code] += lr.Value.Num
-				}
-				lastCurrency = lr.Value.Curr
+		case types.ValueCurrency, types.ValueCrypto:
+			code := moneyCode(lr.Value)
+			if code == "" {
+				continue
 			}
-
-		case types.ValueCrypto:
-			if lr.Value.Crypto != nil {
-				code := lr.Value.Crypto.Code
-				// Convert to USD for summing
-				if c.rateCache != nil {
-					if usdAmount, ok := c.rateCache.Convert(lr.Value.Num, code, "USD"); ok {
-						currencyTotals["USD"] += usdAmount
-					}
-				}
+			if amount, ok := c.convert(lr.Value.Num, code, target.Code); ok {
+				moneyTotal += amount
+				hasMoney = true
+			} else if prev, ok := unconverted[code]; ok {
+				unconverted[code] = prev.WithAmount(prev.Num + lr.Value.Num)
+			} else {
+				unconverted[code] = lr.Value.Nominal()
 			}
 
 		case types.ValueWithUnit:
@@ -331,25 +784,17 @@ func (c *Context) GroupedTotals() []types.Value {
 
 	var results []types.Value
 
-	// Add currency total (converted back to last used currency)
-	if len(currencyTotals) > 0 {
-		usdTotal := currencyTotals["USD"]
-		if lastCurrency != nil && lastCurrency.Code != "USD" {
-			if c.rateCache != nil {
-				if converted, ok := c.rateCache.Convert(usdTotal, "USD", lastCurrency.Code); ok {
-					results = append(results, types.CurrencyValue(converted, lastCurrency))
-				} else {
-					usdCurr := types.ParseCurrency("USD")
-					results = append(results, types.CurrencyValue(usdTotal, usdCurr))
-				}
-			} else {
-				usdCurr := types.ParseCurrency("USD")
-				results = append(results, types.CurrencyValue(usdTotal, usdCurr))
-			}
-		} else {
-			usdCurr := types.ParseCurrency("USD")
-			results = append(results, types.CurrencyValue(usdTotal, usdCurr))
-		}
+	// Add the money total, then any currencies without a rate
+	if hasMoney {
+		results = append(results, types.CurrencyValue(moneyTotal, target))
+	}
+	codes := make([]string, 0, len(unconverted))
+	for code := range unconverted {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		results = append(results, unconverted[code])
 	}
 
 	// Add unit totals (converted back to last used unit of each type)
@@ -389,11 +834,29 @@ func (c *Context) Convert(amount float64, from, to string) (float64, bool) {
 	return c.rateCache.Convert(amount, from, to)
 }
 
+// RateTime returns when the rate between two currencies was fetched, or
+// zero for built-in rates.
+func (c *Context) RateTime(from, to string) time.Time {
+	if c.rateCache == nil || from == to {
+		return time.Time{}
+	}
+	return c.rateCache.RateTime(from, to)
+}
+
+// RateSeries returns the recorded rates between two currencies since the
+// given time, oldest first.
+func (c *Context) RateSeries(from, to string, since time.Time) ([]float64, error) {
+	if c.rateCache == nil {
+		return nil, nil
+	}
+	return c.rateCache.RateSeries(from, to, since)
+}
+
 // ConvertValue converts a value to a target currency/unit.
 func (c *Context) ConvertValue(v types.Value, target string) (types.Value, bool) {
 	// Handle unit conversion
 	if v.Kind == types.ValueWithUnit && v.Unit != nil {
-		targetUnit := types.ParseUnit(target)
+		targetUnit := c.Units().Parse(target)
 		if targetUnit != nil {
 			converted, ok := v.Unit.ConvertTo(v.Num, targetUnit)
 			if ok {
@@ -410,6 +873,15 @@ func (c *Context) ConvertValue(v types.Value, target string) (types.Value, bool)
 	return c.rateCache.ConvertValue(v, target)
 }
 
+// ConvertValueAt converts a currency, crypto or metal amount to target at
+// the rates of a date, fetching them if need be.
+func (c *Context) ConvertValueAt(v types.Value, target string, date time.Time) (types.Value, error) {
+	if c.rateCache == nil {
+		return v, fmt.Errorf("no rates available on %s", date.Format(time.DateOnly))
+	}
+	return c.rateCache.ConvertValueAt(v, target, date)
+}
+
 // ════════════════════════════════════════════════════════════════
 // SETTINGS
 // ════════════════════════════════════════════════════════════════
@@ -421,15 +893,104 @@ func (c *Context) Precision() int {
 	return c.precision
 }
 
-// SetPrecision sets the display precision.
+// SetPrecision sets the display precision: 0-15 decimal places, or
+// types.AutoPrecision.
 func (c *Context) SetPrecision(p int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if p >= 0 && p <= 15 {
+	if p >= types.AutoPrecision && p <= 15 {
 		c.precision = p
 	}
 }
 
+// SigFigs returns the significant figures shown, or 0 if precision is
+// used instead.
+func (c *Context) SigFigs() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sigFigs
+}
+
+// SetSigFigs sets the significant figures shown: 1-15, or 0 to use
+// precision.
+func (c *Context) SetSigFigs(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n >= 0 && n <= 15 {
+		c.sigFigs = n
+	}
+}
+
+// Fractions returns when values are displayed as fractions.
+func (c *Context) Fractions() types.FractionDisplay {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fractions
+}
+
+// SetFractions sets when values are displayed as fractions.
+func (c *Context) SetFractions(d types.FractionDisplay) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fractions = d
+}
+
+// InputLocale returns how numbers are written in input.
+func (c *Context) InputLocale() types.InputLocale {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.locale
+}
+
+// SetInputLocale sets how numbers are written in input.
+func (c *Context) SetInputLocale(l types.InputLocale) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locale = l
+}
+
+// CurrencyDisplay returns whether currencies show symbols or codes.
+func (c *Context) CurrencyDisplay() types.CurrencyDisplay {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.currency
+}
+
+// SetCurrencyDisplay sets whether currencies show symbols or codes.
+func (c *Context) SetCurrencyDisplay(d types.CurrencyDisplay) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.currency = d
+}
+
+// AngleMode returns how trig functions read plain numbers.
+func (c *Context) AngleMode() types.AngleMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.angles
+}
+
+// SetAngleMode sets how trig functions read plain numbers.
+func (c *Context) SetAngleMode(m types.AngleMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.angles = m
+}
+
+// Rounding returns the rounding mode.
+func (c *Context) Rounding() types.RoundingMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rounding
+}
+
+// SetRounding sets the rounding mode.
+func (c *Context) SetRounding(m types.RoundingMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rounding = m
+}
+
 // IsStrict returns whether strict mode is enabled.
 func (c *Context) IsStrict() bool {
 	c.mu.RLock()
@@ -444,18 +1005,160 @@ func (c *Context) SetStrict(strict bool) {
 	c.strict = strict
 }
 
+// ShowAsOf returns whether converted amounts show when their rates were
+// fetched.
+func (c *Context) ShowAsOf() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.asOf
+}
+
+// SetShowAsOf sets whether converted amounts show when their rates were
+// fetched.
+func (c *Context) SetShowAsOf(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.asOf = on
+}
+
+// Region returns the region used to resolve ambiguous units.
+func (c *Context) Region() types.Region {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.region
+}
+
+// SetRegion sets the region used to resolve ambiguous units.
+func (c *Context) SetRegion(r types.Region) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.region = r
+}
+
+// LengthDisplay returns the display mode for lengths.
+func (c *Context) LengthDisplay() types.LengthDisplay {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lengths
+}
+
+// SetLengthDisplay sets the display mode for lengths.
+func (c *Context) SetLengthDisplay(d types.LengthDisplay) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lengths = d
+}
+
+// DataUnits returns whether KB, MB, ... are binary or decimal.
+func (c *Context) DataUnits() types.DataUnits {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dataUnits
+}
+
+// SetDataUnits sets whether KB, MB, ... are binary or decimal.
+func (c *Context) SetDataUnits(d types.DataUnits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dataUnits = d
+}
+
+// BaseCurrency returns the currency totals are displayed in.
+// Returns nil if totals follow the last used currency.
+func (c *Context) BaseCurrency() *types.Currency {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.base
+}
+
+// SetBaseCurrency sets the currency totals are displayed in.
+// Pass nil to follow the last used currency.
+func (c *Context) SetBaseCurrency(curr *types.Currency) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = curr
+}
+
+// Calendar returns the calendar used for business day arithmetic.
+// The calendar is owned by the context; modify it only between evaluations.
+func (c *Context) Calendar() *types.Calendar {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.calendar
+}
+
+// Units returns the units the user defined.
+func (c *Context) Units() *types.UnitSet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.units
+}
+
+// KeepUnit makes a defined unit outlive Clear, as units defined by the
+// settings do rather than by a document.
+func (c *Context) KeepUnit(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.units.Lookup(code)
+	if u == nil {
+		return
+	}
+	kept := c.keptUnits.Clone()
+	if _, err := kept.Define(*u); err == nil {
+		c.keptUnits = kept
+	}
+}
+
+// Seed returns the seed of the random number generator.
+func (c *Context) Seed() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.seed
+}
+
+// SetSeed restarts the random number generator from seed, so the same
+// lines give the same random numbers.
+func (c *Context) SetSeed(seed uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seed = seed
+	c.reseed()
+}
+
+// Random returns a random number in [0, 1).
+func (c *Context) Random() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64()
+}
+
+// RandomInt returns a random integer in [0, n). n must be positive.
+func (c *Context) RandomInt(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Int64N(n)
+}
+
 // ════════════════════════════════════════════════════════════════
 // RESET / CLEAR
 // ════════════════════════════════════════════════════════════════
 
-// Clear resets the context to initial state.
+// Clear resets the context to initial state. The random number generator
+// restarts from its seed, so re-evaluating a document rolls the same
+// numbers.
 func (c *Context) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.variables = make(map[string]types.Value)
+	c.descriptions = nil
+	c.functions = make(map[string]*ast.FuncDefStmt)
+	c.units = c.keptUnits.Clone()
 	c.previous = types.Empty()
-	c.lines = nil
+	c.resetLines()
+	c.lineNo = 0
+	c.resetScopes()
+	c.reseed()
 }
 
 // Reset is an alias for Clear.
@@ -474,17 +1177,50 @@ func (c *Context) Clone() *Context {
 
 	clone := &Context{
 		variables: make(map[string]types.Value, len(c.variables)),
+		functions: make(map[string]*ast.FuncDefStmt, len(c.functions)),
+		units:     c.units.Clone(),
+		keptUnits: c.keptUnits,
+		pins:      make(map[string]types.Value, len(c.pins)),
 		rateCache: nil, // Will be set by engine
 		previous:  c.previous,
 		lines:     make([]LineResult, len(c.lines)),
+		lineNo:    c.lineNo,
 		precision: c.precision,
+		sigFigs:   c.sigFigs,
+		rounding:  c.rounding,
+		fractions: c.fractions,
+		currency:  c.currency,
+		locale:    c.locale,
+		angles:    c.angles,
 		strict:    c.strict,
+		asOf:      c.asOf,
+		region:    c.region,
+		lengths:   c.lengths,
+		dataUnits: c.dataUnits,
+		calendar:  c.calendar.Clone(),
+		base:      c.base,
+		limits:    c.limits,
+		overrides: c.overrides, // Replaced, never modified in place
+		seed:      c.seed,
 	}
 
+	// Continue from the same generator state
+	pcg := *c.pcg
+	clone.pcg = &pcg
+	clone.rng = rand.New(clone.pcg)
+
 	for k, v := range c.variables {
 		clone.variables[k] = v
 	}
+	for k, fn := range c.functions {
+		clone.functions[k] = fn
+	}
+	for k, v := range c.pins {
+		clone.pins[k] = v
+	}
 	copy(clone.lines, c.lines)
+	clone.descriptions = maps.Clone(c.descriptions)
+	c.cloneScopes(clone)
 
 	return clone
 }
@@ -0,0 +1,49 @@
+// internal/eval/conversion_test.go
+
+package eval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsj/numio/internal/parser"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// datedRates converts at one rate today and another on any past date.
+type datedRates struct {
+	today, past float64
+}
+
+func (r datedRates) GetRate(from, to string) (float64, bool) { return r.today, true }
+func (r datedRates) Convert(amount float64, from, to string) (float64, bool) {
+	return amount * r.today, true
+}
+func (r datedRates) ConvertValue(v types.Value, target string) (types.Value, bool) {
+	return types.CurrencyValue(v.Num*r.today, types.ParseCurrency(target)), true
+}
+func (r datedRates) RateTime(from, to string) time.Time { return time.Time{} }
+func (r datedRates) RateSeries(from, to string, since time.Time) ([]float64, error) {
+	return nil, nil
+}
+func (r datedRates) ConvertValueAt(v types.Value, target string, date time.Time) (types.Value, error) {
+	return types.CurrencyValue(v.Num*r.past, types.ParseCurrency(target)), nil
+}
+
+func TestConversionAtDateEitherSide(t *testing.T) {
+	for _, input := range []string{
+		"$100 on 2024-01-01 in EUR",
+		"$100 in EUR on 2024-01-01",
+	} {
+		ctx := NewContext()
+		ctx.SetRateCacheAdapter(datedRates{today: 0.92, past: 0.9})
+		line, errs := parser.ParseLine(input)
+		if len(errs) > 0 {
+			t.Fatalf("%s: %v", input, errs[0])
+		}
+		v := NewWithContext(ctx).EvalLine(line)
+		if v.IsError() || v.Num != 90 {
+			t.Errorf("%s = %s, want €90.00 at the date's rate", input, v)
+		}
+	}
+}
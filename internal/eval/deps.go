@@ -0,0 +1,96 @@
+// internal/eval/deps.go
+
+package eval
+
+import (
+	"strings"
+
+	"github.com/0xsj/numio/internal/ast"
+)
+
+// Deps is what a line needs from the lines around it, for evaluating
+// lines out of order.
+type Deps struct {
+	Reads   []string // Variables it uses, lower-cased
+	Assigns string   // Variable it sets, lower-cased, or ""
+
+	// Ordered lines must run after every line before them and before
+	// every line after: they use the line history (_, total, lines 1..5,
+	// "+ 10"), random numbers or user-defined functions, whose bodies may
+	// use any variable, define functions or units, or are pinned.
+	Ordered bool
+}
+
+// LineDeps returns what a parsed line needs from the lines around it.
+func (c *Context) LineDeps(line *ast.Line) Deps {
+	v := &depsVisitor{ctx: c, reads: make(map[string]bool)}
+	ast.Walk(v, line)
+
+	deps := Deps{Ordered: v.ordered || line.Pinned}
+	for name := range v.reads {
+		deps.Reads = append(deps.Reads, name)
+	}
+	if assign, ok := line.Stmt.(*ast.AssignStmt); ok {
+		deps.Assigns = strings.ToLower(assign.Name)
+	}
+	return deps
+}
+
+// depsVisitor collects a line's dependencies.
+type depsVisitor struct {
+	ctx     *Context
+	reads   map[string]bool
+	ordered bool
+}
+
+func (v *depsVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		name := strings.ToLower(n.Name)
+		if name == "_" || name == "ans" || name == "total" || v.isFunction(n.Name) {
+			v.ordered = true
+		}
+		if _, ok := ResultIndex(name); ok {
+			v.ordered = true
+		}
+		v.reads[name] = true
+
+	case *ast.CallExpr:
+		name := strings.ToLower(n.Name)
+		if randomFunctions[name] || name == "dice" || name == "ans" || v.isFunction(n.Name) {
+			v.ordered = true
+		}
+
+	case *ast.DiceLit, *ast.LinesExpr, *ast.ContinuationExpr, *ast.ConversionContinuation,
+		*ast.FuncDefStmt, *ast.UnitDefStmt, *ast.ScopeStmt:
+		v.ordered = true
+	}
+	return v
+}
+
+// readsTotal reports whether a parsed line reads the running total, as
+// LineDeps would find, without collecting the rest.
+func readsTotal(line *ast.Line) bool {
+	var v totalVisitor
+	ast.Walk(&v, line)
+	return bool(v)
+}
+
+// totalVisitor records whether a line reads total.
+type totalVisitor bool
+
+func (v *totalVisitor) Visit(node ast.Node) ast.Visitor {
+	if id, ok := node.(*ast.Identifier); ok && strings.EqualFold(id.Name, "total") {
+		*v = true
+	}
+	if *v {
+		return nil
+	}
+	return v
+}
+
+// isFunction reports whether name is a user-defined function.
+func (v *depsVisitor) isFunction(name string) bool {
+	_, ok := v.ctx.Function(name)
+	return ok
+}
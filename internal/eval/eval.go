@@ -4,7 +4,9 @@ package eval
 
 import (
 	"math"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/0xsj/numio/internal/ast"
 	"github.com/0xsj/numio/pkg/types"
@@ -13,6 +15,25 @@ import (
 // Evaluator evaluates AST nodes and produces values.
 type Evaluator struct {
 	ctx *Context
+
+	// converted is the value the last conversion converted from.
+	converted types.Value
+
+	// Parameters of the user-defined function being called, and the
+	// depth of nested calls
+	locals map[string]types.Value
+	depth  int
+
+	// Called with each expression's value, if set, and the nesting of
+	// the expression being evaluated
+	trace      func(Step)
+	traceDepth int
+
+	// Limits on each line, the steps the current line has taken, and the
+	// error it stopped with once it ran out
+	budget Budget
+	steps  int
+	halted types.Value
 }
 
 // New creates a new Evaluator with a fresh context.
@@ -38,46 +59,141 @@ func (e *Evaluator) Context() *Context {
 // PUBLIC EVALUATION METHODS
 // ════════════════════════════════════════════════════════════════
 
-// EvalLine evaluates a parsed line and returns the result.
+// EvalLine evaluates a parsed line, records it in the line history and
+// returns the result.
 func (e *Evaluator) EvalLine(line *ast.Line) types.Value {
 	if line == nil || line.Stmt == nil {
 		return types.Empty()
 	}
 
-	result := e.evalStmt(line.Stmt)
+	lr := e.EvalLineResult(line)
+	e.RecordLine(lr)
+	return lr.Value
+}
+
+// EvalLineResult evaluates a parsed line without recording it in the line
+// history. Variables it assigns are still set.
+func (e *Evaluator) EvalLineResult(line *ast.Line) LineResult {
+	e.converted = types.Empty()
+	e.startBudget()
+	result := e.evalPinned(line)
+	if e.halted.IsError() {
+		// Whatever the line made of the error, it didn't finish
+		result = e.halted
+	}
 
 	// Track result
 	lr := LineResult{
-		Input: line.Raw,
-		Value: result,
+		Input:   line.Raw,
+		Value:   result,
+		Share:   line.Share,
+		IsTotal: readsTotal(line),
+	}
+	if isConversion(line.Stmt) && !result.IsError() {
+		lr.Converted = e.converted
+		if d := result.CurrencyDisplay; d != nil {
+			lr.Converted = lr.Converted.WithCurrencyDisplay(*d)
+		}
 	}
 
 	// Check if this was a continuation
 	if stmt, ok := line.Stmt.(*ast.ExprStmt); ok {
-		if _, isCont := stmt.Expr.(*ast.ContinuationExpr); isCont {
-			lr.IsContinuation = true
-			e.ctx.MarkLastConsumed()
-		}
-		if _, isConvCont := stmt.Expr.(*ast.ConversionContinuation); isConvCont {
-			lr.IsContinuation = true
-			e.ctx.MarkLastConsumed()
+		expr := stmt.Expr
+		if d, ok := expr.(*ast.DisplayExpr); ok {
+			expr = d.Value
 		}
+		lr.IsContinuation = ast.IsContinuation(expr)
 	}
 
-	// Check if this was an assignment
+	// Check if this was an assignment; its comment describes the variable
 	if assign, ok := line.Stmt.(*ast.AssignStmt); ok {
 		lr.AssignedVar = assign.Name
+		if d := commentText(line.Comment); d != "" && !result.IsError() {
+			e.ctx.SetDescription(assign.Name, d)
+		}
 	}
 
-	// Update context
+	return lr
+}
+
+// commentText returns the text of a comment, without its # or //.
+func commentText(comment string) string {
+	if text, ok := strings.CutPrefix(comment, "//"); ok {
+		return strings.TrimSpace(text)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(comment, "#"))
+}
+
+// RecordLine adds a line evaluated with EvalLineResult to the line
+// history, making it the previous result.
+func (e *Evaluator) RecordLine(lr LineResult) {
+	if lr.IsContinuation {
+		e.ctx.MarkLastConsumed()
+	}
 	e.ctx.AddLineResult(lr)
-	e.ctx.SetPrevious(result)
+	e.ctx.SetPrevious(lr.Value)
+}
+
+// evalPinned evaluates a line's statement. A line marked !pin keeps the
+// value it first evaluated to, even when rates or the variables it uses
+// change; assigning it still sets the variable. Lines without !pin drop
+// any value frozen for the same statement, so removing and re-adding the
+// directive freezes afresh.
+func (e *Evaluator) evalPinned(line *ast.Line) types.Value {
+	if !line.Pinned {
+		// Pins are keyed by the statement's text, which is only worth
+		// building if something is pinned
+		if e.ctx.HasPins() {
+			e.ctx.Unpin(line.Stmt.String())
+		}
+		return e.evalStmt(line.Stmt)
+	}
+
+	key := line.Stmt.String()
+
+	if v, ok := e.ctx.PinnedValue(key); ok {
+		if assign, ok := line.Stmt.(*ast.AssignStmt); ok {
+			e.ctx.SetVariable(assign.Name, v)
+		}
+		return v
+	}
 
+	result := e.evalStmt(line.Stmt)
+	if !result.IsError() && !result.IsEmpty() {
+		e.ctx.Pin(key, result)
+	}
 	return result
 }
 
+// isConversion reports whether a statement's expression is a conversion,
+// as in "$100 in EUR", "in EUR" or "x = 5 km to mi".
+func isConversion(stmt ast.Stmt) bool {
+	var expr ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		expr = s.Expr
+	case *ast.AssignStmt:
+		expr = s.Expr
+	}
+	if d, ok := expr.(*ast.DisplayExpr); ok {
+		expr = d.Value
+	}
+	switch expr.(type) {
+	case *ast.ConversionExpr, *ast.ConversionContinuation:
+		return true
+	}
+	return false
+}
+
+// EvalStmt evaluates a statement without recording it in the line history.
+func (e *Evaluator) EvalStmt(stmt ast.Stmt) types.Value {
+	e.startBudget()
+	return e.evalStmt(stmt)
+}
+
 // EvalExpr evaluates an expression and returns the result.
 func (e *Evaluator) EvalExpr(expr ast.Expr) types.Value {
+	e.startBudget()
 	return e.evalExpr(expr)
 }
 
@@ -99,6 +215,20 @@ func (e *Evaluator) evalStmt(stmt ast.Stmt) types.Value {
 	case *ast.AssignStmt:
 		return e.evalAssign(s)
 
+	case *ast.UnitDefStmt:
+		return e.evalUnitDef(s)
+
+	case *ast.FuncDefStmt:
+		return e.evalFuncDef(s)
+
+	case *ast.ScopeStmt:
+		if !s.End {
+			e.ctx.BeginScope()
+		} else if !e.ctx.EndScope() {
+			return types.Error("end without begin")
+		}
+		return types.Empty()
+
 	default:
 		return types.Error("unknown statement type")
 	}
@@ -106,6 +236,9 @@ func (e *Evaluator) evalStmt(stmt ast.Stmt) types.Value {
 
 func (e *Evaluator) evalAssign(stmt *ast.AssignStmt) types.Value {
 	value := e.evalExpr(stmt.Expr)
+	if override, ok := e.ctx.Override(stmt.Name); ok {
+		value = override
+	}
 
 	if !value.IsError() {
 		e.ctx.SetVariable(stmt.Name, value)
@@ -114,6 +247,61 @@ func (e *Evaluator) evalAssign(stmt *ast.AssignStmt) types.Value {
 	return value
 }
 
+// evalFuncDef defines a function: "f(x) = x^2 + 1". Built-in functions
+// cannot be redefined.
+func (e *Evaluator) evalFuncDef(stmt *ast.FuncDefStmt) types.Value {
+	if isBuiltinFunction(strings.ToLower(stmt.Name)) {
+		return types.Errorf("cannot redefine built-in function %s", stmt.Name)
+	}
+	for i, param := range stmt.Params {
+		if slices.Contains(stmt.Params[:i], param) {
+			return types.Errorf("duplicate parameter %s in %s", param, stmt.Name)
+		}
+	}
+
+	e.ctx.DefineFunction(stmt)
+	return types.Empty()
+}
+
+// evalUnitDef registers a custom unit: "unit sprint = 2 weeks" or,
+// for a new dimension, "unit point".
+func (e *Evaluator) evalUnitDef(stmt *ast.UnitDefStmt) types.Value {
+	def := types.Unit{
+		Code:    stmt.Name,
+		Name:    stmt.Name,
+		Aliases: stmt.Aliases,
+	}
+	if !strings.HasSuffix(stmt.Name, "s") {
+		def.Plural = stmt.Name + "s"
+	}
+
+	size := types.Empty()
+	if stmt.Expr == nil {
+		def.Type = types.RegisterUnitType(stmt.Name)
+		def.ToBase = 1
+		def.IsBase = true
+	} else {
+		size = e.evalExpr(stmt.Expr)
+		if size.IsError() {
+			return size
+		}
+		if !size.IsUnit() || size.Unit == nil {
+			return types.Errorf("unit %s must be defined in terms of another unit", stmt.Name)
+		}
+		def.Type = size.Unit.Type
+		def.ToBase = size.Num * size.Unit.ToBase
+	}
+
+	u, err := e.ctx.Units().Define(def)
+	if err != nil {
+		return types.Error(err.Error())
+	}
+	if size.IsEmpty() {
+		return types.UnitValue(1, u)
+	}
+	return size
+}
+
 // ════════════════════════════════════════════════════════════════
 // EXPRESSION EVALUATION
 // ════════════════════════════════════════════════════════════════
@@ -122,27 +310,89 @@ func (e *Evaluator) evalExpr(expr ast.Expr) types.Value {
 	if expr == nil {
 		return types.Empty()
 	}
+	if e.budget.Ctx != nil || e.budget.MaxSteps > 0 {
+		if v, ok := e.spend(); !ok {
+			return v
+		}
+	}
+	// Parentheses are no step of their own: what they hold is traced
+	if _, group := expr.(*ast.GroupExpr); e.trace == nil || group {
+		return e.evalNode(expr)
+	}
+
+	e.traceDepth++
+	v := e.evalNode(expr)
+	e.traceDepth--
+	e.trace(Step{Expr: expr, Value: v, Depth: e.traceDepth})
+	return v
+}
 
+// evalNode evaluates an expression by its type.
+func (e *Evaluator) evalNode(expr ast.Expr) types.Value {
 	switch ex := expr.(type) {
 	// Literals
 	case *ast.NumberLit:
+		if ex.Compact {
+			return types.Number(ex.Value).WithCompact()
+		}
 		return types.Number(ex.Value)
 
 	case *ast.PercentLit:
 		return types.Percentage(ex.Value)
 
 	case *ast.CurrencyLit:
+		if ex.Compact {
+			return types.CurrencyValue(ex.Amount, ex.Currency).WithCompact()
+		}
 		return types.CurrencyValue(ex.Amount, ex.Currency)
 
 	case *ast.UnitLit:
-		return types.UnitValue(ex.Amount, ex.Unit)
+		v := types.UnitValue(ex.Amount, e.resolveUnit(ex.Unit))
+		if ex.Ingredient != nil {
+			v = v.WithIngredient(ex.Ingredient)
+		}
+		return v
 
 	case *ast.MetalLit:
-		return types.MetalValue(ex.Amount, ex.Metal)
+		amount := ex.Amount
+		if ex.Unit != nil {
+			// Counted in the unit the metal is priced per
+			converted, ok := ex.Unit.ConvertTo(amount, ex.Metal.Unit())
+			if !ok {
+				return types.Errorf("cannot measure %s in %s", ex.Metal.Name, ex.Unit.Code)
+			}
+			amount = converted
+		}
+		return types.MetalValue(amount, ex.Metal).WithKarat(ex.Karat)
 
 	case *ast.CryptoLit:
+		if ex.Unit != nil {
+			return types.CryptoValue(ex.Amount*ex.Unit.Factor, ex.Crypto).WithCryptoUnit(ex.Unit)
+		}
 		return types.CryptoValue(ex.Amount, ex.Crypto)
 
+	case *ast.DiceLit:
+		return e.rollDice(ex.Count, ex.Sides)
+
+	case *ast.ListLit:
+		return e.evalList(ex)
+
+	case *ast.RangeExpr:
+		return e.evalRange(ex)
+
+	case *ast.LinesExpr:
+		return e.evalLines(ex)
+
+	case *ast.RateExpr:
+		return e.evalRate(ex)
+
+	case *ast.DateLit:
+		year := ex.Year
+		if year == 0 {
+			year = time.Now().Year()
+		}
+		return types.DateValue(time.Date(year, ex.Month, ex.Day, 0, 0, 0, 0, time.Local))
+
 	// References
 	case *ast.Identifier:
 		return e.evalIdentifier(ex)
@@ -158,9 +408,15 @@ func (e *Evaluator) evalExpr(expr ast.Expr) types.Value {
 	case *ast.PercentOfExpr:
 		return e.evalPercentOf(ex)
 
+	case *ast.BusinessDaysExpr:
+		return e.evalBusinessDays(ex)
+
 	case *ast.ConversionExpr:
 		return e.evalConversion(ex)
 
+	case *ast.DisplayExpr:
+		return e.evalDisplay(ex)
+
 	case *ast.CallExpr:
 		return e.evalCall(ex)
 
@@ -179,13 +435,40 @@ func (e *Evaluator) evalExpr(expr ast.Expr) types.Value {
 	}
 }
 
+// resolveUnit applies the context's region and data unit settings
+// to a parsed unit (e.g., "gal" → imperial gallon, "KB" → 1000 B).
+func (e *Evaluator) resolveUnit(u *types.Unit) *types.Unit {
+	u = types.RegionalUnit(u, e.ctx.Region())
+	return types.DataUnit(u, e.ctx.DataUnits())
+}
+
 // ════════════════════════════════════════════════════════════════
 // IDENTIFIER EVALUATION
 // ════════════════════════════════════════════════════════════════
 
 func (e *Evaluator) evalIdentifier(id *ast.Identifier) types.Value {
+	if value, ok := e.locals[id.Name]; ok {
+		return value
+	}
+
 	value, ok := e.ctx.GetVariable(id.Name)
 	if !ok {
+		// Relative dates, unless shadowed by a variable
+		switch strings.ToLower(id.Name) {
+		case "today", "now":
+			return types.DateValue(time.Now())
+		case "tomorrow":
+			return types.DateValue(time.Now().AddDate(0, 0, 1))
+		case "yesterday":
+			return types.DateValue(time.Now().AddDate(0, 0, -1))
+		}
+		// Constants, unless shadowed by a variable
+		if c := types.LookupConstant(id.Name); c != nil {
+			return c.ToValue()
+		}
+		if back, ok := ResultIndex(strings.ToLower(id.Name)); ok {
+			return types.Errorf("no result %d back", back)
+		}
 		if e.ctx.IsStrict() {
 			return types.Errorf("undefined variable: %s", id.Name)
 		}
@@ -195,6 +478,27 @@ func (e *Evaluator) evalIdentifier(id *ast.Identifier) types.Value {
 	return value
 }
 
+// evalAns evaluates ans(n), the result n lines back, as _n is: ans(1) is
+// the previous result.
+func (e *Evaluator) evalAns(args []ast.Expr) types.Value {
+	if len(args) != 1 {
+		return types.Errorf("ans expects 1 argument(s), got %d", len(args))
+	}
+	n := e.evalExpr(args[0])
+	if n.IsError() {
+		return n
+	}
+	if !n.IsNumeric() || !isInteger(n.AsFloat()) || n.AsFloat() < 1 {
+		return types.Error("ans needs a whole number of results back, from 1")
+	}
+
+	back := int(min(n.AsFloat(), math.MaxInt32))
+	if v, ok := e.ctx.Result(back); ok {
+		return v
+	}
+	return types.Errorf("no result %d back", back)
+}
+
 // ════════════════════════════════════════════════════════════════
 // BINARY OPERATIONS
 // ════════════════════════════════════════════════════════════════
@@ -214,9 +518,43 @@ func (e *Evaluator) evalBinary(expr *ast.BinaryExpr) types.Value {
 }
 
 func (e *Evaluator) applyBinaryOp(op ast.BinaryOp, left, right types.Value) types.Value {
+	if op == ast.OpPlusMinus {
+		return e.applyPlusMinus(left, right)
+	}
+	if left.Uncertainty != 0 || right.Uncertainty != 0 {
+		return propagate([]types.Value{left, right}, func(args []types.Value) types.Value {
+			return e.applyBinaryOp(op, args[0], args[1])
+		})
+	}
+	if isChart(left) || isChart(right) {
+		return types.Errorf("cannot apply %s to a chart", op.String())
+	}
+	if left.Big != nil || right.Big != nil {
+		if v, ok := applyBigOp(op, left, right); ok {
+			return v
+		}
+		if math.IsInf(left.Num, 0) || math.IsInf(right.Num, 0) {
+			return types.Error("number is too large to calculate with")
+		}
+		left, right = left.WithAmount(left.Num), right.WithAmount(right.Num)
+	}
+	if isLinear(left) || isLinear(right) {
+		return e.applyMatrixOp(op, left, right)
+	}
+
+	// Calendar arithmetic
+	if left.IsDate() || right.IsDate() {
+		return e.applyDateOp(op, left, right)
+	}
+
+	// Integer division and modulo
+	if op == ast.OpIntDiv || op == ast.OpMod {
+		return e.applyFloorOp(op, left, right)
+	}
+
 	// Handle percentage operations specially
 	if right.IsPercentage() && (op == ast.OpAdd || op == ast.OpSub) {
-		return e.applyPercentageOp(op, left, right)
+		return keepAsOf(keepCompact(e.applyPercentageOp(op, left, right), left, right), left, right)
 	}
 
 	// Get numeric values
@@ -247,17 +585,69 @@ func (e *Evaluator) applyBinaryOp(op ast.BinaryOp, left, right types.Value) type
 		result = leftNum / rightNum
 	case ast.OpPow:
 		result = math.Pow(leftNum, rightNum)
-	case ast.OpMod:
-		if rightNum == 0 {
-			return types.Error("modulo by zero")
-		}
-		result = math.Mod(leftNum, rightNum)
 	default:
 		return types.Error("unknown operator")
 	}
 
 	// Determine result type based on operands
-	return e.coerceResult(result, left, right, op)
+	return keepAsOf(keepCompact(e.coerceResult(result, left, right, op), left, right), left, right)
+}
+
+// keepCompact shows a number or currency result with a scale suffix if
+// either operand was: $1.2M + $300k is $1.5M.
+func keepCompact(result, left, right types.Value) types.Value {
+	if (left.Compact || right.Compact) && (result.IsNumber() || result.IsCurrency()) {
+		return result.WithCompact()
+	}
+	return result
+}
+
+// keepAsOf stamps a result with the oldest rate behind its operands, so
+// arithmetic on converted amounts still says which rates it used.
+func keepAsOf(result, left, right types.Value) types.Value {
+	t := earliest(result.AsOf, earliest(left.AsOf, right.AsOf))
+	if t.IsZero() || !result.IsNumeric() {
+		return result
+	}
+	return result.WithAsOf(t)
+}
+
+// stampRate records on v, converted from one currency to another, when
+// the rate was fetched. A value already stamped keeps the older time.
+func (e *Evaluator) stampRate(v types.Value, from, to string) types.Value {
+	return v.WithAsOf(earliest(v.AsOf, e.ctx.RateTime(from, to)))
+}
+
+// earliest returns the earlier of two times, ignoring zero ones.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// applyFloorOp handles floored integer division and modulo, keeping
+// units and currencies as division and subtraction would:
+// 17 // 5 = 3, -7 mod 3 = 2, 100 min mod 1 h = 40 min, $17 mod $5 = $2.
+func (e *Evaluator) applyFloorOp(op ast.BinaryOp, left, right types.Value) types.Value {
+	q := e.applyBinaryOp(ast.OpDiv, left, right)
+	if q.IsError() {
+		if op == ast.OpMod && q.Err == "division by zero" {
+			return types.Error("modulo by zero")
+		}
+		return q
+	}
+	q = q.WithAmount(types.Round(q.Num, 0, types.RoundFloor))
+	if op == ast.OpIntDiv {
+		return q
+	}
+
+	// a mod b = a - b * (a // b), snapping float noise to zero
+	r := e.applyBinaryOp(ast.OpSub, left, e.applyBinaryOp(ast.OpMul, right, q))
+	if !r.IsError() && math.Abs(r.Num) < 1e-12*math.Max(1, math.Abs(left.Num)) {
+		r = r.WithAmount(0)
+	}
+	return r
 }
 
 // applyPercentageOp handles "value + percentage" and "value - percentage"
@@ -288,7 +678,14 @@ func (e *Evaluator) coerceResult(result float64, left, right types.Value, op ast
 		if right.IsNumber() && !left.IsNumber() {
 			return left.WithAmount(result)
 		}
-		// Both typed - return plain number (or could be unit algebra in future)
+		// Both units - data/time algebra and same-type ratios
+		if left.IsUnit() && right.IsUnit() {
+			if v, ok := e.combineUnits(op, left, right); ok {
+				return v
+			}
+		}
+
+		// Both typed - return plain number
 		if !left.IsNumber() && !right.IsNumber() {
 			return types.Number(result)
 		}
@@ -296,6 +693,64 @@ func (e *Evaluator) coerceResult(result float64, left, right types.Value, op ast
 
 	// For addition/subtraction, types must be compatible
 	if op == ast.OpAdd || op == ast.OpSub {
+		// Different currencies - convert both to the base currency if
+		// there is one, else right to left's currency
+		if left.IsCurrency() && right.IsCurrency() && left.Curr != nil && right.Curr != nil &&
+			left.Curr.Code != right.Curr.Code {
+			if base := e.ctx.BaseCurrency(); base != nil {
+				l, lok := e.ctx.Convert(left.Num, left.Curr.Code, base.Code)
+				r, rok := e.ctx.Convert(right.Num, right.Curr.Code, base.Code)
+				if left.Curr.Code == base.Code {
+					l, lok = left.Num, true
+				}
+				if right.Curr.Code == base.Code {
+					r, rok = right.Num, true
+				}
+				if lok && rok {
+					sum := types.CurrencyValue(l+r, base)
+					if op == ast.OpSub {
+						sum = types.CurrencyValue(l-r, base)
+					}
+					sum = e.stampRate(sum, left.Curr.Code, base.Code)
+					return e.stampRate(sum, right.Curr.Code, base.Code)
+				}
+			}
+			converted, ok := e.ctx.Convert(right.Num, right.Curr.Code, left.Curr.Code)
+			if ok {
+				sum := left.WithAmount(left.Num + converted)
+				if op == ast.OpSub {
+					sum = left.WithAmount(left.Num - converted)
+				}
+				return e.stampRate(sum, right.Curr.Code, left.Curr.Code)
+			}
+		}
+
+		// Different units - convert right to left's unit
+		if left.IsUnit() && right.IsUnit() && left.Unit != nil && right.Unit != nil &&
+			left.Unit.Code != right.Unit.Code {
+			converted, ok := right.Unit.ConvertTo(right.Num, left.Unit)
+			if !ok && right.Ingredient != nil {
+				converted, ok = right.Ingredient.Convert(right.Num, right.Unit, left.Unit)
+			}
+			if !ok {
+				return types.Error("incompatible units")
+			}
+			if op == ast.OpAdd {
+				return left.WithAmount(left.Num + converted)
+			}
+			return left.WithAmount(left.Num - converted)
+		}
+
+		// Gold of different purities - count right in left's purity, by
+		// the pure gold in it
+		if left.IsMetal() && right.IsMetal() && left.Metal == right.Metal && left.Karat != right.Karat {
+			converted := right.Num * float64(karatOf(right)) / float64(karatOf(left))
+			if op == ast.OpAdd {
+				return left.WithAmount(left.Num + converted)
+			}
+			return left.WithAmount(left.Num - converted)
+		}
+
 		// Same type - preserve it
 		if left.Kind == right.Kind {
 			return left.WithAmount(result)
@@ -308,37 +763,145 @@ func (e *Evaluator) coerceResult(result float64, left, right types.Value, op ast
 		if right.IsNumber() {
 			return left.WithAmount(result)
 		}
+	}
 
-		// Different typed values - need conversion
-		// For currencies, convert right to left's currency
-		if left.IsCurrency() && right.IsCurrency() {
-			if left.Curr != nil && right.Curr != nil {
-				converted, ok := e.ctx.Convert(right.Num, right.Curr.Code, left.Curr.Code)
-				if ok {
-					if op == ast.OpAdd {
-						return left.WithAmount(left.Num + converted)
-					}
-					return left.WithAmount(left.Num - converted)
+	return types.Number(result)
+}
+
+// karatOf returns the purity of a metal value in karats, pure if not
+// given.
+func karatOf(v types.Value) int {
+	if v.Karat > 0 {
+		return v.Karat
+	}
+	return types.PureKarat
+}
+
+// combineUnits multiplies or divides two unit values:
+//
+//	amount / rate  → duration   (700 GB / 50 Mbit/s, 100 km / 50 km/h)
+//	amount / time  → rate       (10 GB / 2 h, 100 km / 2 h, 10 m/s / 2 s)
+//	rate * time    → amount     (50 Mbit/s * 1 h, c * 2 s, g * 3 s)
+//	a / b          → ratio      (1 GB / 512 MB, same type)
+//	a * b, a / b   → SI units   (3 m * 2 m, 10 J / 2 K, G * 5 kg)
+func (e *Evaluator) combineUnits(op ast.BinaryOp, left, right types.Value) (types.Value, bool) {
+	l, r := left.Unit, right.Unit
+	if l == nil || r == nil {
+		return types.Value{}, false
+	}
+	lBase, rBase := left.Num*l.ToBase, right.Num*r.ToBase
+
+	if op == ast.OpDiv {
+		if _, per, ok := types.RateParts(r); ok {
+			if rate := types.RateUnit(l, per); rate != nil && rate.Type == r.Type {
+				if rBase == 0 {
+					return types.Error("division by zero"), true
 				}
+				return types.Duration(lBase / rBase), true
 			}
 		}
-
-		// For units, convert right to left's unit
-		if left.IsUnit() && right.IsUnit() {
-			if left.Unit != nil && right.Unit != nil {
-				converted, ok := right.Unit.ConvertTo(right.Num, left.Unit)
-				if ok {
-					if op == ast.OpAdd {
-						return left.WithAmount(left.Num + converted)
-					}
-					return left.WithAmount(left.Num - converted)
-				}
+		if rate := types.RateUnit(l, r); rate != nil {
+			if right.Num == 0 {
+				return types.Error("division by zero"), true
+			}
+			return types.UnitValue(left.Num/right.Num, rate), true
+		}
+		if l.Type == r.Type && l.Type != types.UnitTypeTemperature {
+			if rBase == 0 {
+				return types.Error("division by zero"), true
 			}
-			return types.Error("incompatible units")
+			return types.Number(lBase / rBase), true
 		}
 	}
 
-	return types.Number(result)
+	if op == ast.OpMul {
+		rate, other := left, right
+		if other.Unit.Type != types.UnitTypeTime {
+			rate, other = right, left
+		}
+		if amount, per, ok := types.RateParts(rate.Unit); ok && other.Unit.Type == types.UnitTypeTime {
+			seconds := other.Num * other.Unit.ToBase
+			return types.UnitValue(rate.Num*seconds/per.ToBase, amount), true
+		}
+	}
+
+	return types.MultiplyUnits(left, right, op == ast.OpDiv)
+}
+
+// ════════════════════════════════════════════════════════════════
+// DATE OPERATIONS
+// ════════════════════════════════════════════════════════════════
+
+// applyDateOp handles arithmetic involving dates:
+//
+//	date ± 3 days / 2 weeks / 1 month  → date
+//	date ± 10 business days            → date (skips weekends and holidays)
+//	date ± 5                           → date (days)
+//	date - date                        → days
+func (e *Evaluator) applyDateOp(op ast.BinaryOp, left, right types.Value) types.Value {
+	if op != ast.OpAdd && op != ast.OpSub {
+		return types.Error("dates only support + and -")
+	}
+
+	if left.IsDate() && right.IsDate() {
+		if op == ast.OpAdd {
+			return types.Error("cannot add two dates")
+		}
+		days := math.Round(left.Time.Sub(right.Time).Hours() / 24)
+		return types.UnitValue(days, types.ParseUnit("d"))
+	}
+
+	date, offset := left, right
+	if right.IsDate() {
+		if op == ast.OpSub {
+			return types.Error("cannot subtract a date from a value")
+		}
+		date, offset = right, left
+	}
+
+	amount := offset.Num
+	if op == ast.OpSub {
+		amount = -amount
+	}
+
+	t := date.Time
+	switch {
+	case offset.IsNumber():
+		return types.DateValue(t.AddDate(0, 0, int(math.Round(amount))))
+
+	case offset.IsUnit() && offset.Unit != nil && offset.Unit.Type == types.UnitTypeBusinessDay:
+		return types.DateValue(e.ctx.Calendar().AddBusinessDays(t, int(math.Round(amount))))
+
+	case offset.IsUnit() && offset.Unit != nil && offset.Unit.Type == types.UnitTypeTime:
+		switch offset.Unit.Code {
+		case "mo":
+			return types.DateValue(t.AddDate(0, int(math.Round(amount)), 0))
+		case "y":
+			return types.DateValue(t.AddDate(int(math.Round(amount)), 0, 0))
+		}
+		days := amount * offset.Unit.ToBase / 86400
+		return types.DateValue(t.AddDate(0, 0, int(math.Round(days))))
+	}
+
+	return types.Errorf("cannot add %s to a date", offset.Kind.String())
+}
+
+// evalBusinessDays handles "business days between A and B".
+func (e *Evaluator) evalBusinessDays(expr *ast.BusinessDaysExpr) types.Value {
+	from := e.evalExpr(expr.From)
+	if from.IsError() {
+		return from
+	}
+	to := e.evalExpr(expr.To)
+	if to.IsError() {
+		return to
+	}
+	if !from.IsDate() || !to.IsDate() {
+		return types.Error("business days between requires two dates")
+	}
+
+	n := e.ctx.Calendar().BusinessDaysBetween(from.Time, to.Time)
+	return types.UnitValue(float64(n), types.ParseUnit("bd"))
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -377,6 +940,13 @@ func (e *Evaluator) evalPercentOf(expr *ast.PercentOfExpr) types.Value {
 		return value
 	}
 
+	return propagate([]types.Value{percent, value}, func(args []types.Value) types.Value {
+		return percentOf(args[0], args[1])
+	})
+}
+
+// percentOf returns percent of value, keeping value's type.
+func percentOf(percent, value types.Value) types.Value {
 	// Get percentage as decimal
 	var pct float64
 	if percent.IsPercentage() {
@@ -392,23 +962,82 @@ func (e *Evaluator) evalPercentOf(expr *ast.PercentOfExpr) types.Value {
 }
 
 // evalConversion handles "value in target" expressions.
+// evalDisplay evaluates a value shown with the currency display chosen
+// for the line: "$100 as code".
+func (e *Evaluator) evalDisplay(expr *ast.DisplayExpr) types.Value {
+	value := e.evalExpr(expr.Value)
+	if value.IsError() {
+		return value
+	}
+
+	d, ok := types.ParseCurrencyDisplay(expr.Currency)
+	if !ok {
+		return types.Errorf("unknown currency display: %s", expr.Currency)
+	}
+	if !value.IsCurrency() && !value.IsCrypto() {
+		return types.Errorf("as %s applies to currencies, not a %s", expr.Currency, value.Kind.String())
+	}
+	return value.WithCurrencyDisplay(d)
+}
+
 func (e *Evaluator) evalConversion(expr *ast.ConversionExpr) types.Value {
 	value := e.evalExpr(expr.Value)
 	if value.IsError() {
 		return value
 	}
 
+	if expr.Date != nil {
+		date := e.evalExpr(expr.Date)
+		if date.IsError() {
+			return date
+		}
+		return e.convertValueAt(value, expr.Target, date.Time)
+	}
 	return e.convertValue(value, expr.Target)
 }
 
+// convertValueAt converts a value at the rates of a date. Units don't
+// change with time, so they convert as usual.
+func (e *Evaluator) convertValueAt(value types.Value, target string, date time.Time) types.Value {
+	if !value.IsCurrency() && !value.IsCrypto() && !value.IsMetal() {
+		return e.convertValue(value, target)
+	}
+	if value.Uncertainty != 0 {
+		result := propagate([]types.Value{value}, func(args []types.Value) types.Value {
+			return e.convertValueAt(args[0], target, date)
+		})
+		e.converted = value
+		return result
+	}
+	e.converted = value
+
+	converted, err := e.ctx.ConvertValueAt(value, target, date)
+	if err != nil {
+		return types.Error(err.Error())
+	}
+	return keepCompact(converted, value, value)
+}
+
 func (e *Evaluator) convertValue(value types.Value, target string) types.Value {
+	if value.Uncertainty != 0 {
+		result := propagate([]types.Value{value}, func(args []types.Value) types.Value {
+			return e.convertValue(args[0], target)
+		})
+		e.converted = value
+		return result
+	}
+	e.converted = value
+
 	// Try unit conversion first
 	if value.IsUnit() && value.Unit != nil {
-		targetUnit := types.ParseUnit(target)
+		targetUnit := e.resolveUnit(e.ctx.Units().Parse(target))
 		if targetUnit != nil {
 			converted, ok := value.Unit.ConvertTo(value.Num, targetUnit)
+			if !ok && value.Ingredient != nil {
+				converted, ok = value.Ingredient.Convert(value.Num, value.Unit, targetUnit)
+			}
 			if ok {
-				return types.UnitValue(converted, targetUnit)
+				return types.UnitValue(converted, targetUnit).WithIngredient(value.Ingredient).WithKeptUnit()
 			}
 			return types.Errorf("cannot convert %s to %s", value.Unit.Code, target)
 		}
@@ -417,14 +1046,15 @@ func (e *Evaluator) convertValue(value types.Value, target string) types.Value {
 	// Try currency/crypto conversion
 	converted, ok := e.ctx.ConvertValue(value, target)
 	if ok {
-		return converted
+		converted = e.stampRate(converted.WithAsOf(value.AsOf), rateCode(value), rateCode(converted))
+		return keepCompact(converted, value, value)
 	}
 
 	// Check if target is valid but conversion unavailable
-	if types.ParseCurrency(target) != nil || types.ParseCrypto(target) != nil {
+	if types.ParseCurrency(target) != nil || types.ParseCrypto(target) != nil || types.ParseCryptoUnit(target) != nil {
 		return types.Errorf("no rate available for conversion to %s", target)
 	}
-	if types.ParseUnit(target) != nil {
+	if e.ctx.Units().Parse(target) != nil {
 		return types.Errorf("cannot convert to %s (incompatible types)", target)
 	}
 
@@ -438,8 +1068,12 @@ func (e *Evaluator) convertValue(value types.Value, target string) types.Value {
 // evalContinuation handles "+ 10", "* 2" etc. continuing from previous.
 func (e *Evaluator) evalContinuation(expr *ast.ContinuationExpr) types.Value {
 	if !e.ctx.HasPrevious() {
-		// No previous - evaluate expression alone
-		return e.evalExpr(expr.Expr)
+		// No previous - evaluate expression alone, so "-$10" is negative
+		value := e.evalExpr(expr.Expr)
+		if expr.Op == ast.OpSub && !value.IsError() {
+			return value.Negate()
+		}
+		return value
 	}
 
 	prev := e.ctx.Previous()
@@ -466,19 +1100,181 @@ func (e *Evaluator) evalConversionContinuation(expr *ast.ConversionContinuation)
 // ════════════════════════════════════════════════════════════════
 
 func (e *Evaluator) evalCall(expr *ast.CallExpr) types.Value {
-	// Evaluate arguments
-	args := make([]types.Value, len(expr.Args))
-	for i, arg := range expr.Args {
-		val := e.evalExpr(arg)
-		if val.IsError() {
-			return val
+	name := strings.ToLower(expr.Name)
+	switch name {
+	case "round":
+		return e.evalRound(expr.Args)
+	case "dice":
+		return e.evalDice(expr.Args)
+	case "derive":
+		return e.evalDerive(expr.Args)
+	case "integrate":
+		return e.evalIntegrate(expr.Args)
+	case "plot":
+		return e.evalPlot(expr.Args)
+	case "ans":
+		return e.evalAns(expr.Args)
+	}
+	fn, userDefined := e.ctx.Function(expr.Name)
+
+	// Evaluate arguments; lists pass their elements to list functions:
+	// sum([1, 2, 3]), sum(v)
+	args := make([]types.Value, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		elems := []ast.Expr{arg}
+		if list, ok := arg.(*ast.ListLit); ok && listFunctions[name] {
+			elems = list.Elems
+		}
+		for _, elem := range elems {
+			val := e.evalExpr(elem)
+			switch {
+			case val.IsError():
+				return val
+			case val.IsVector() && listFunctions[name]:
+				for _, n := range val.Vec {
+					args = append(args, types.Number(n))
+				}
+			case (isLinear(val) && !matrixFunctions[name] && !userDefined) || isChart(val):
+				return types.Errorf("%s does not take a %s", name, val.Kind.String())
+			default:
+				args = append(args, val)
+			}
 		}
-		args[i] = val
 	}
 
 	// Look up and call function
-	name := strings.ToLower(expr.Name)
-	return e.callFunction(name, args)
+	if userDefined {
+		return e.callFunctionDef(fn, args)
+	}
+	if randomFunctions[name] {
+		return e.callFunction(name, args)
+	}
+	return propagate(args, func(args []types.Value) types.Value {
+		return e.callFunction(name, args)
+	})
+}
+
+// callFunctionDef calls a user-defined function, binding its parameters
+// to args.
+func (e *Evaluator) callFunctionDef(fn *ast.FuncDefStmt, args []types.Value) types.Value {
+	if len(args) != len(fn.Params) {
+		return types.Errorf("%s expects %d argument(s), got %d", fn.Name, len(fn.Params), len(args))
+	}
+	if limit := e.ctx.Limits().MaxDepth; limit > 0 && e.depth >= limit {
+		return types.LimitErrorf("%s: calls nested more than %d deep", fn.Name, limit)
+	}
+
+	locals := make(map[string]types.Value, len(args))
+	for i, param := range fn.Params {
+		locals[param] = args[i]
+	}
+
+	saved := e.locals
+	e.locals = locals
+	e.depth++
+	defer func() {
+		e.locals = saved
+		e.depth--
+	}()
+
+	return e.evalExpr(fn.Body)
+}
+
+// isBuiltinFunction reports whether name is a built-in function.
+func isBuiltinFunction(name string) bool {
+	return slices.Contains(functionNames, name)
+}
+
+// listFunctions are the functions that take a list's elements as their
+// arguments.
+var listFunctions = map[string]bool{
+	"sum": true, "avg": true, "average": true, "mean": true,
+	"min": true, "max": true, "count": true, "sample": true, "spark": true,
+	"gcd": true, "lcm": true,
+}
+
+// randomFunctions are the functions whose results are random, so their
+// arguments' uncertainties are not propagated.
+var randomFunctions = map[string]bool{"rand": true, "randint": true, "sample": true}
+
+// evalRound evaluates round(x), round(x, places) and round(x, places,
+// mode), keeping x's type. The mode is a name (half-up, half-even or
+// bankers, floor, ceiling) and defaults to the context's rounding mode.
+func (e *Evaluator) evalRound(args []ast.Expr) types.Value {
+	if len(args) < 1 || len(args) > 3 {
+		return types.Error("round requires one to three arguments")
+	}
+
+	mode := e.ctx.Rounding()
+	if len(args) == 3 {
+		name := roundingModeName(args[2])
+		m, ok := types.ParseRoundingMode(name)
+		if !ok || name == "" {
+			return types.Error("unknown rounding mode (use half-up, half-even, floor or ceiling)")
+		}
+		mode = m
+	}
+
+	x := e.evalExpr(args[0])
+	if x.IsError() {
+		return x
+	}
+	if !x.IsNumeric() {
+		return types.Errorf("cannot round %s", x.Kind)
+	}
+
+	places := 0
+	if len(args) > 1 {
+		p := e.evalExpr(args[1])
+		if p.IsError() {
+			return p
+		}
+		if !p.IsNumber() || p.Num != math.Trunc(p.Num) || math.Abs(p.Num) > 15 {
+			return types.Error("round places must be a whole number from -15 to 15")
+		}
+		places = int(p.Num)
+	}
+
+	// Percentages round as shown: round(12.345%, 1) = 12.3%
+	if x.IsPercentage() {
+		return x.WithAmount(types.Round(x.Num*100, places, mode) / 100)
+	}
+	return x.WithAmount(types.Round(x.Num, places, mode))
+}
+
+// roundingModeName returns the rounding mode written as round's third
+// argument: a name, or a hyphenated name that parses as subtraction
+// ("half-even").
+func roundingModeName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.Identifier:
+		return x.Name
+	case *ast.BinaryExpr:
+		l, lok := x.Left.(*ast.Identifier)
+		r, rok := x.Right.(*ast.Identifier)
+		if x.Op == ast.OpSub && lok && rok {
+			return l.Name + "-" + r.Name
+		}
+	}
+	return ""
+}
+
+// functionNames lists the built-in functions handled by callFunction.
+var functionNames = []string{
+	"sum", "avg", "average", "mean", "min", "max", "count",
+	"abs", "sqrt", "round", "floor", "ceil", "log", "log10", "ln", "exp",
+	"sin", "cos", "tan", "asin", "acos", "atan", "pow",
+	"rand", "randint", "dice", "sample", "ans",
+	"dot", "cross", "norm", "det", "inverse", "inv", "transpose", "identity",
+	"derive", "integrate", "plot", "spark",
+	"factorial", "gcd", "lcm", "ncr", "npr",
+}
+
+// FunctionNames returns the names of all built-in functions.
+func FunctionNames() []string {
+	names := make([]string, len(functionNames))
+	copy(names, functionNames)
+	return names
 }
 
 func (e *Evaluator) callFunction(name string, args []types.Value) types.Value {
@@ -500,8 +1296,6 @@ func (e *Evaluator) callFunction(name string, args []types.Value) types.Value {
 		return e.fnUnary(args, math.Abs)
 	case "sqrt":
 		return e.fnUnary(args, math.Sqrt)
-	case "round":
-		return e.fnUnary(args, math.Round)
 	case "floor":
 		return e.fnUnary(args, math.Floor)
 	case "ceil":
@@ -513,22 +1307,62 @@ func (e *Evaluator) callFunction(name string, args []types.Value) types.Value {
 	case "exp":
 		return e.fnUnary(args, math.Exp)
 	case "sin":
-		return e.fnUnary(args, math.Sin)
+		return e.fnTrig(args, math.Sin)
 	case "cos":
-		return e.fnUnary(args, math.Cos)
+		return e.fnTrig(args, math.Cos)
 	case "tan":
-		return e.fnUnary(args, math.Tan)
+		return e.fnTrig(args, math.Tan)
 	case "asin":
-		return e.fnUnary(args, math.Asin)
+		return e.fnInverseTrig(args, math.Asin)
 	case "acos":
-		return e.fnUnary(args, math.Acos)
+		return e.fnInverseTrig(args, math.Acos)
 	case "atan":
-		return e.fnUnary(args, math.Atan)
+		return e.fnInverseTrig(args, math.Atan)
 
 	// Power function (2 args)
 	case "pow":
 		return e.fnPow(args)
 
+	// Vectors and matrices
+	case "dot":
+		return e.fnDot(args)
+	case "cross":
+		return e.fnCross(args)
+	case "norm":
+		return e.fnNorm(args)
+	case "det":
+		return e.fnDet(args)
+	case "inverse", "inv":
+		return e.fnInverse(args)
+	case "transpose":
+		return e.fnTranspose(args)
+	case "identity":
+		return e.fnIdentity(args)
+
+	// Combinatorics
+	case "factorial":
+		return e.fnFactorial(args)
+	case "gcd":
+		return e.fnGCD(args)
+	case "lcm":
+		return e.fnLCM(args)
+	case "ncr":
+		return e.fnChoose(args, false)
+	case "npr":
+		return e.fnChoose(args, true)
+
+	// Random functions
+	case "rand":
+		return e.fnRand(args)
+	case "randint":
+		return e.fnRandInt(args)
+	case "sample":
+		return e.fnSample(args)
+
+	// Charts
+	case "spark":
+		return e.fnSpark(args)
+
 	default:
 		return types.Errorf("unknown function: %s", name)
 	}
@@ -543,17 +1377,16 @@ func (e *Evaluator) fnSum(args []types.Value) types.Value {
 		return types.Number(0)
 	}
 
-	var total float64
-	var resultType types.Value = args[0]
-
-	for _, arg := range args {
-		if arg.IsError() {
-			return arg
-		}
-		total += arg.AsFloat()
+	target, nums, errVal := e.aggregateAmounts("sum", args)
+	if nums == nil {
+		return errVal
 	}
 
-	return resultType.WithAmount(total)
+	var total float64
+	for _, n := range nums {
+		total += n
+	}
+	return target.WithAmount(total)
 }
 
 func (e *Evaluator) fnAvg(args []types.Value) types.Value {
@@ -574,20 +1407,11 @@ func (e *Evaluator) fnMin(args []types.Value) types.Value {
 		return types.Error("min requires at least one argument")
 	}
 
-	minVal := args[0]
-	minNum := minVal.AsFloat()
-
-	for _, arg := range args[1:] {
-		if arg.IsError() {
-			return arg
-		}
-		if arg.AsFloat() < minNum {
-			minNum = arg.AsFloat()
-			minVal = arg
-		}
+	target, nums, errVal := e.aggregateAmounts("min", args)
+	if nums == nil {
+		return errVal
 	}
-
-	return minVal.WithAmount(minNum)
+	return target.WithAmount(slices.Min(nums))
 }
 
 func (e *Evaluator) fnMax(args []types.Value) types.Value {
@@ -595,20 +1419,84 @@ func (e *Evaluator) fnMax(args []types.Value) types.Value {
 		return types.Error("max requires at least one argument")
 	}
 
-	maxVal := args[0]
-	maxNum := maxVal.AsFloat()
+	target, nums, errVal := e.aggregateAmounts("max", args)
+	if nums == nil {
+		return errVal
+	}
+	return target.WithAmount(slices.Max(nums))
+}
 
-	for _, arg := range args[1:] {
+// aggregateAmounts returns the amounts of an aggregate's arguments in the
+// currency or unit of the first that has one, which the result takes:
+// sum($10, €20) is in dollars, max(1 km, 800 m) in km. Plain numbers are
+// taken as they are. On failure it returns nil and an error value.
+func (e *Evaluator) aggregateAmounts(name string, args []types.Value) (types.Value, []float64, types.Value) {
+	target := args[0]
+	for _, arg := range args {
 		if arg.IsError() {
-			return arg
+			return arg, nil, arg
+		}
+		if !arg.IsNumber() && target.IsNumber() {
+			target = arg
+		}
+	}
+
+	nums := make([]float64, len(args))
+	var asOf time.Time
+	for i, arg := range args {
+		if !arg.IsNumeric() {
+			errVal := types.Errorf("%s requires numbers, got %s", name, arg.Kind.String())
+			return errVal, nil, errVal
+		}
+		n, ok := e.amountIn(arg, target)
+		if !ok {
+			errVal := types.Errorf("%s cannot combine %s and %s", name, target.String(), arg.String())
+			return errVal, nil, errVal
 		}
-		if arg.AsFloat() > maxNum {
-			maxNum = arg.AsFloat()
-			maxVal = arg
+		nums[i] = n
+		asOf = earliest(asOf, earliest(arg.AsOf, e.ctx.RateTime(moneyCode(arg), moneyCode(target))))
+	}
+	return target.Nominal().WithAsOf(asOf), nums, types.Empty()
+}
+
+// rateCode returns the code v is priced by in the rate cache: its
+// currency, crypto or metal code, or "".
+func rateCode(v types.Value) string {
+	if v.IsMetal() && v.Metal != nil {
+		return v.Metal.Code
+	}
+	return moneyCode(v)
+}
+
+// amountIn returns v's amount in the currency or unit of like, converting
+// currencies with the context's rates. A plain number is taken as is.
+func (e *Evaluator) amountIn(v, like types.Value) (float64, bool) {
+	switch {
+	case v.IsNumber():
+		return v.Num, true
+
+	case v.IsPercentage() && like.IsPercentage():
+		return v.Num, true
+
+	case v.IsUnit() && like.IsUnit() && v.Unit != nil && like.Unit != nil:
+		converted, ok := v.Unit.ConvertTo(v.Num, like.Unit)
+		if !ok && v.Ingredient != nil {
+			converted, ok = v.Ingredient.Convert(v.Num, v.Unit, like.Unit)
 		}
+		return converted, ok
+
+	case v.IsMetal() && like.IsMetal() && v.Metal != nil && like.Metal != nil:
+		return v.Num, v.Metal.Code == like.Metal.Code && v.Karat == like.Karat
 	}
 
-	return maxVal.WithAmount(maxNum)
+	from, code := moneyCode(v), moneyCode(like)
+	if from == "" || code == "" {
+		return 0, false
+	}
+	if from == code {
+		return v.Num, true
+	}
+	return e.ctx.Convert(v.Num, from, code)
 }
 
 func (e *Evaluator) fnUnary(args []types.Value, fn func(float64) float64) types.Value {
@@ -631,6 +1519,33 @@ func (e *Evaluator) fnUnary(args []types.Value, fn func(float64) float64) types.
 	return types.Number(result)
 }
 
+// fnTrig applies a trig function to an angle: "90 deg", "1 rad", or a
+// plain number read in the context's angle mode.
+func (e *Evaluator) fnTrig(args []types.Value, fn func(float64) float64) types.Value {
+	if len(args) != 1 {
+		return types.Error("function requires exactly one argument")
+	}
+	if args[0].IsError() {
+		return args[0]
+	}
+
+	rad, ok := types.Radians(args[0], e.ctx.AngleMode())
+	if !ok {
+		return types.Errorf("expected an angle, got %s", args[0].String())
+	}
+	return e.fnUnary([]types.Value{types.Number(rad)}, fn)
+}
+
+// fnInverseTrig applies an inverse trig function, returning the angle in
+// degrees in degree mode and a plain number of radians otherwise.
+func (e *Evaluator) fnInverseTrig(args []types.Value, fn func(float64) float64) types.Value {
+	result := e.fnUnary(args, fn)
+	if result.IsError() {
+		return result
+	}
+	return types.AngleValue(result.AsFloat(), e.ctx.AngleMode())
+}
+
 func (e *Evaluator) fnPow(args []types.Value) types.Value {
 	if len(args) != 2 {
 		return types.Error("pow requires exactly two arguments")
@@ -647,3 +1562,187 @@ func (e *Evaluator) fnPow(args []types.Value) types.Value {
 
 	return types.Number(result)
 }
+
+// ════════════════════════════════════════════════════════════════
+// RANDOM
+// ════════════════════════════════════════════════════════════════
+
+// maxDice is the most dice one roll may throw.
+const maxDice = 1000
+
+// evalDice evaluates dice(3d6), dice(2d20 + 3), dice(6) (one six-sided
+// die) and dice(3, 6).
+func (e *Evaluator) evalDice(args []ast.Expr) types.Value {
+	switch len(args) {
+	case 1:
+		if n, ok := args[0].(*ast.NumberLit); ok {
+			return e.rollDice(1, int(n.Value))
+		}
+		return e.evalExpr(args[0])
+	case 2:
+		count, sides := e.evalExpr(args[0]), e.evalExpr(args[1])
+		if count.IsError() {
+			return count
+		}
+		if sides.IsError() {
+			return sides
+		}
+		if !isInteger(count.AsFloat()) || !isInteger(sides.AsFloat()) {
+			return types.Error("dice count and sides must be whole numbers")
+		}
+		return e.rollDice(int(count.AsFloat()), int(sides.AsFloat()))
+	default:
+		return types.Error("dice requires dice notation (3d6) or a count and sides")
+	}
+}
+
+// rollDice rolls count dice with sides sides and returns their sum.
+func (e *Evaluator) rollDice(count, sides int) types.Value {
+	if count < 1 || count > maxDice {
+		return types.Errorf("dice count must be 1-%d", maxDice)
+	}
+	if sides < 1 {
+		return types.Error("dice must have at least one side")
+	}
+
+	var total int64
+	for range count {
+		total += e.ctx.RandomInt(int64(sides)) + 1
+	}
+	return types.Number(float64(total))
+}
+
+// fnRand returns a random number in [0, 1), or in [a, b) keeping a's
+// type: rand(10 USD, 20 USD).
+func (e *Evaluator) fnRand(args []types.Value) types.Value {
+	switch len(args) {
+	case 0:
+		return types.Number(e.ctx.Random())
+	case 2:
+		a, b := args[0].AsFloat(), args[1].AsFloat()
+		if a > b {
+			return types.Error("rand: lower bound is greater than upper bound")
+		}
+		return args[0].WithAmount(a + e.ctx.Random()*(b-a))
+	default:
+		return types.Error("rand takes no arguments or a lower and upper bound")
+	}
+}
+
+// fnRandInt returns a random integer in [a, b], keeping a's type.
+func (e *Evaluator) fnRandInt(args []types.Value) types.Value {
+	if len(args) != 2 {
+		return types.Error("randint requires a lower and upper bound")
+	}
+
+	a, b := args[0].AsFloat(), args[1].AsFloat()
+	if !isInteger(a) || !isInteger(b) {
+		return types.Error("randint bounds must be whole numbers")
+	}
+	if a > b {
+		return types.Error("randint: lower bound is greater than upper bound")
+	}
+	if b-a >= 1<<62 {
+		return types.Error("randint: range is too large")
+	}
+
+	n := e.ctx.RandomInt(int64(b-a) + 1)
+	return args[0].WithAmount(a + float64(n))
+}
+
+// fnSample returns one of its arguments at random: sample([1, 2, 3]).
+func (e *Evaluator) fnSample(args []types.Value) types.Value {
+	if len(args) == 0 {
+		return types.Error("sample requires at least one value")
+	}
+	return args[e.ctx.RandomInt(int64(len(args)))]
+}
+
+// isInteger reports whether n is a finite whole number.
+func isInteger(n float64) bool {
+	return n == math.Trunc(n) && !math.IsInf(n, 0)
+}
+
+// ════════════════════════════════════════════════════════════════
+// UNCERTAINTY
+// ════════════════════════════════════════════════════════════════
+
+// applyPlusMinus gives value the uncertainty tol: 100 ± 5, 100 ± 5 kg,
+// 100 kg ± 5, 100 kg ± 50 g, 100 kg ± 2%. An uncertainty value already
+// has adds in quadrature.
+func (e *Evaluator) applyPlusMinus(value, tol types.Value) types.Value {
+	if !value.IsNumeric() || !tol.IsNumeric() {
+		return types.Error("± requires numbers on both sides")
+	}
+
+	var u float64
+	switch {
+	case value.IsPercentage() && tol.IsPercentage():
+		u = tol.Num
+	case tol.IsPercentage():
+		// Relative: 100 kg ± 2%
+		u = value.Num * tol.Num
+	case value.IsNumber() && !tol.IsNumber():
+		// The value takes the tolerance's type: 100 ± 5 kg
+		value = tol.Nominal().WithAmount(value.Num).WithUncertainty(value.Uncertainty)
+		u = tol.Num
+	case tol.IsNumber():
+		u = tol.Num
+	default:
+		// Convert the tolerance to the value's type: 100 kg ± 50 g
+		sum := e.applyBinaryOp(ast.OpAdd, value.Nominal(), tol.Nominal())
+		if sum.IsError() {
+			return sum
+		}
+		if sum.Kind != value.Kind {
+			return types.Errorf("cannot use %s as the uncertainty of %s", tol.Nominal().String(), value.Nominal().String())
+		}
+		u = sum.Num - value.Num
+	}
+
+	return value.WithUncertainty(math.Hypot(value.Uncertainty, u))
+}
+
+// propagate returns f(args) with the uncertainty carried from args, to
+// first order: each argument is moved by its uncertainty in turn, and
+// the changes in the result add in quadrature. Arguments are taken as
+// independent, so x * x gives √2·x·u rather than 2·x·u.
+func propagate(args []types.Value, f func(args []types.Value) types.Value) types.Value {
+	nominal := make([]types.Value, len(args))
+	uncertain := false
+	for i, arg := range args {
+		nominal[i] = arg.Nominal()
+		uncertain = uncertain || arg.Uncertainty != 0
+	}
+
+	result := f(nominal)
+	if !uncertain || result.IsError() || !result.IsNumeric() {
+		return result
+	}
+
+	var variance float64
+	for i, arg := range args {
+		if arg.Uncertainty == 0 {
+			continue
+		}
+		shifted := slices.Clone(nominal)
+		shifted[i] = nominal[i].WithAmount(arg.Num + arg.Uncertainty)
+		hi := f(shifted)
+		shifted[i] = nominal[i].WithAmount(arg.Num - arg.Uncertainty)
+		lo := f(shifted)
+
+		// Use one side where the other is out of range: sqrt(1 ± 2)
+		var d float64
+		switch {
+		case !hi.IsError() && !lo.IsError():
+			d = (hi.Num - lo.Num) / 2
+		case !hi.IsError():
+			d = hi.Num - result.Num
+		case !lo.IsError():
+			d = result.Num - lo.Num
+		}
+		variance += d * d
+	}
+
+	return result.WithUncertainty(math.Sqrt(variance))
+}
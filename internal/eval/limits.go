@@ -0,0 +1,56 @@
+// internal/eval/limits.go
+
+package eval
+
+import "github.com/0xsj/numio/pkg/types"
+
+// Limits bound what evaluating a line may build, so that a hostile input
+// can't exhaust a server's memory. A zero field means no limit.
+type Limits struct {
+	MaxList  int // Elements of a list, vector or matrix
+	MaxRange int // Elements a range like 1..10 creates
+	MaxInput int // Bytes in a line of input
+	MaxDepth int // How deeply user-defined functions may call each other
+}
+
+// DefaultLimits returns the limits of a new context.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxList:  1_000_000,
+		MaxRange: 10_000,
+		MaxInput: 64 << 10,
+		MaxDepth: 100,
+	}
+}
+
+// Limits returns the limits on what a line may build.
+func (c *Context) Limits() Limits {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.limits
+}
+
+// SetLimits sets the limits on what a line may build.
+func (c *Context) SetLimits(l Limits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits = l
+}
+
+// CheckInput returns an error, and true, if input is longer than the
+// context's limits allow.
+func (c *Context) CheckInput(input string) (types.Value, bool) {
+	if limit := c.Limits().MaxInput; limit > 0 && len(input) > limit {
+		return types.LimitErrorf("input is longer than %d bytes", limit), true
+	}
+	return types.Value{}, false
+}
+
+// checkSize returns an error, and true, if a list, vector or matrix of n
+// elements would be larger than the context's limits allow.
+func (c *Context) checkSize(n int) (types.Value, bool) {
+	if limit := c.Limits().MaxList; limit > 0 && n > limit {
+		return types.LimitErrorf("list has more than %d elements", limit), true
+	}
+	return types.Value{}, false
+}
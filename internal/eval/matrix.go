@@ -0,0 +1,238 @@
+// internal/eval/matrix.go
+
+package eval
+
+import (
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// maxIdentity is the largest matrix identity(n) creates.
+const maxIdentity = 64
+
+// evalList evaluates a list literal: numbers make a vector ([1, 2, 3]),
+// vectors of the same length make a matrix ([[1, 2], [3, 4]]).
+func (e *Evaluator) evalList(list *ast.ListLit) types.Value {
+	if len(list.Elems) == 0 {
+		return types.Error("empty list")
+	}
+
+	var nums []float64
+	var rows [][]float64
+	for _, elem := range list.Elems {
+		v := e.evalExpr(elem)
+		switch {
+		case v.IsError():
+			return v
+		case v.IsNumber() && rows == nil:
+			nums = append(nums, v.Num)
+		case v.IsVector() && nums == nil:
+			rows = append(rows, v.Vec)
+		default:
+			return types.Error("list elements must be plain numbers, or lists of numbers for a matrix")
+		}
+	}
+
+	if rows == nil {
+		if errVal, ok := e.ctx.checkSize(len(nums)); ok {
+			return errVal
+		}
+		return types.VectorValue(nums)
+	}
+	if errVal, ok := e.ctx.checkSize(len(rows) * len(rows[0])); ok {
+		return errVal
+	}
+	m := types.NewMatrix(rows)
+	if m == nil {
+		return types.Error("matrix rows must be the same length")
+	}
+	return types.MatrixValue(m)
+}
+
+// isLinear reports whether v is a vector or matrix.
+func isLinear(v types.Value) bool {
+	return v.IsVector() || v.IsMatrix()
+}
+
+// applyMatrixOp applies op where either side is a vector or matrix.
+func (e *Evaluator) applyMatrixOp(op ast.BinaryOp, left, right types.Value) types.Value {
+	switch {
+	case left.IsVector() && right.IsVector():
+		if op != ast.OpAdd && op != ast.OpSub {
+			return types.Error("use dot(a, b) or cross(a, b) to multiply vectors")
+		}
+		sum, ok := types.RowMatrix(left.Vec).Add(types.RowMatrix(right.Vec), sign(op))
+		if !ok {
+			return types.Errorf("vector lengths differ: %d and %d", len(left.Vec), len(right.Vec))
+		}
+		return types.VectorValue(sum.Data)
+
+	case left.IsMatrix() && right.IsMatrix():
+		switch op {
+		case ast.OpAdd, ast.OpSub:
+			if sum, ok := left.Mat.Add(right.Mat, sign(op)); ok {
+				return types.MatrixValue(sum)
+			}
+			return types.Errorf("matrix shapes differ: %s and %s", left.Mat.Shape(), right.Mat.Shape())
+		case ast.OpMul:
+			// A column times a row is larger than either
+			if errVal, ok := e.ctx.checkSize(left.Mat.Rows * right.Mat.Cols); ok {
+				return errVal
+			}
+			if product, ok := left.Mat.Mul(right.Mat); ok {
+				return types.MatrixValue(product)
+			}
+			return types.Errorf("cannot multiply %s by %s matrix", left.Mat.Shape(), right.Mat.Shape())
+		}
+
+	case left.IsMatrix() && right.IsVector() && op == ast.OpMul:
+		if product, ok := left.Mat.MulVec(right.Vec); ok {
+			return types.VectorValue(product)
+		}
+		return types.Errorf("cannot multiply %s matrix by vector of length %d", left.Mat.Shape(), len(right.Vec))
+
+	case left.IsVector() && right.IsMatrix() && op == ast.OpMul:
+		// Row vector times matrix
+		if product, ok := right.Mat.Transpose().MulVec(left.Vec); ok {
+			return types.VectorValue(product)
+		}
+		return types.Errorf("cannot multiply vector of length %d by %s matrix", len(left.Vec), right.Mat.Shape())
+
+	case isLinear(left) && right.IsNumber():
+		switch op {
+		case ast.OpMul:
+			return scaleLinear(left, right.Num)
+		case ast.OpDiv:
+			if right.Num == 0 {
+				return types.Error("division by zero")
+			}
+			return scaleLinear(left, 1/right.Num)
+		case ast.OpPow:
+			if !left.IsMatrix() || !isInteger(right.Num) {
+				return types.Error("only square matrices can be raised to whole powers")
+			}
+			if power, ok := left.Mat.Pow(int(right.Num)); ok {
+				return types.MatrixValue(power)
+			}
+			if !left.Mat.IsSquare() {
+				return types.Errorf("cannot raise %s matrix to a power", left.Mat.Shape())
+			}
+			return types.Error("matrix is singular")
+		}
+
+	case left.IsNumber() && isLinear(right) && op == ast.OpMul:
+		return scaleLinear(right, left.Num)
+	}
+
+	return types.Errorf("cannot apply %s to %s and %s", op.String(), left.Kind.String(), right.Kind.String())
+}
+
+// sign returns 1 for addition and -1 for subtraction.
+func sign(op ast.BinaryOp) float64 {
+	if op == ast.OpSub {
+		return -1
+	}
+	return 1
+}
+
+// scaleLinear multiplies a vector or matrix by k.
+func scaleLinear(v types.Value, k float64) types.Value {
+	if v.IsVector() {
+		return types.VectorValue(types.ScaleVector(v.Vec, k))
+	}
+	return types.MatrixValue(v.Mat.Scale(k))
+}
+
+// ════════════════════════════════════════════════════════════════
+// FUNCTIONS
+// ════════════════════════════════════════════════════════════════
+
+// matrixFunctions are the functions that take vectors and matrices.
+var matrixFunctions = map[string]bool{
+	"dot": true, "cross": true, "norm": true, "det": true,
+	"inverse": true, "inv": true, "transpose": true, "identity": true,
+}
+
+// fnDot returns the dot product of two vectors.
+func (e *Evaluator) fnDot(args []types.Value) types.Value {
+	if len(args) != 2 || !args[0].IsVector() || !args[1].IsVector() {
+		return types.Error("dot requires two vectors")
+	}
+	dot, ok := types.Dot(args[0].Vec, args[1].Vec)
+	if !ok {
+		return types.Errorf("vector lengths differ: %d and %d", len(args[0].Vec), len(args[1].Vec))
+	}
+	return types.Number(dot)
+}
+
+// fnCross returns the cross product of two 3-vectors.
+func (e *Evaluator) fnCross(args []types.Value) types.Value {
+	if len(args) != 2 || !args[0].IsVector() || !args[1].IsVector() {
+		return types.Error("cross requires two vectors")
+	}
+	cross, ok := types.Cross(args[0].Vec, args[1].Vec)
+	if !ok {
+		return types.Error("cross requires vectors of length 3")
+	}
+	return types.VectorValue(cross)
+}
+
+// fnNorm returns the length of a vector, or the Frobenius norm of a
+// matrix.
+func (e *Evaluator) fnNorm(args []types.Value) types.Value {
+	if len(args) != 1 || !isLinear(args[0]) {
+		return types.Error("norm requires a vector or matrix")
+	}
+	if args[0].IsVector() {
+		return types.Number(types.Norm(args[0].Vec))
+	}
+	return types.Number(types.Norm(args[0].Mat.Data))
+}
+
+// fnDet returns the determinant of a square matrix.
+func (e *Evaluator) fnDet(args []types.Value) types.Value {
+	if len(args) != 1 || !args[0].IsMatrix() {
+		return types.Error("det requires a matrix")
+	}
+	det, ok := args[0].Mat.Det()
+	if !ok {
+		return types.Errorf("det requires a square matrix, got %s", args[0].Mat.Shape())
+	}
+	return types.Number(det)
+}
+
+// fnInverse returns the inverse of a square matrix.
+func (e *Evaluator) fnInverse(args []types.Value) types.Value {
+	if len(args) != 1 || !args[0].IsMatrix() {
+		return types.Error("inverse requires a matrix")
+	}
+	if !args[0].Mat.IsSquare() {
+		return types.Errorf("inverse requires a square matrix, got %s", args[0].Mat.Shape())
+	}
+	inv, ok := args[0].Mat.Inverse()
+	if !ok {
+		return types.Error("matrix is singular")
+	}
+	return types.MatrixValue(inv)
+}
+
+// fnTranspose returns the transpose of a matrix; a vector becomes a
+// one-column matrix.
+func (e *Evaluator) fnTranspose(args []types.Value) types.Value {
+	if len(args) != 1 || !isLinear(args[0]) {
+		return types.Error("transpose requires a vector or matrix")
+	}
+	if args[0].IsVector() {
+		return types.MatrixValue(types.RowMatrix(args[0].Vec).Transpose())
+	}
+	return types.MatrixValue(args[0].Mat.Transpose())
+}
+
+// fnIdentity returns the n×n identity matrix.
+func (e *Evaluator) fnIdentity(args []types.Value) types.Value {
+	if len(args) != 1 || !args[0].IsNumber() || !isInteger(args[0].Num) ||
+		args[0].Num < 1 || args[0].Num > maxIdentity {
+		return types.Errorf("identity requires a size from 1 to %d", maxIdentity)
+	}
+	return types.MatrixValue(types.Identity(int(args[0].Num)))
+}
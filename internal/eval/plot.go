@@ -0,0 +1,186 @@
+// internal/eval/plot.go
+
+package eval
+
+import (
+	"math"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// plotSamples is how many points plot(f, a..b) evaluates f at.
+const plotSamples = 200
+
+// evalRange evaluates a range to a vector: 1..5 is [1, 2, 3, 4, 5],
+// 0..1 step 0.25 is [0, 0.25, 0.5, 0.75, 1].
+func (e *Evaluator) evalRange(r *ast.RangeExpr) types.Value {
+	start, end, errVal := e.rangeBounds(r)
+	if errVal.IsError() {
+		return errVal
+	}
+
+	step := 1.0
+	if end < start {
+		step = -1
+	}
+	if r.Step != nil {
+		v := e.evalExpr(r.Step)
+		if v.IsError() {
+			return v
+		}
+		if !v.IsNumber() || v.Num == 0 || math.IsInf(v.Num, 0) {
+			return types.Error("range step must be a non-zero plain number")
+		}
+		step = v.Num
+	}
+
+	n := math.Floor((end-start)/step+1e-9) + 1
+	if n < 1 {
+		return types.Error("range step goes away from its end")
+	}
+	if limit := e.ctx.Limits().MaxRange; limit > 0 && n > float64(limit) {
+		return types.LimitErrorf("range has more than %d elements", limit)
+	}
+
+	vec := make([]float64, int(n))
+	for i := range vec {
+		vec[i] = start + float64(i)*step
+	}
+	return types.VectorValue(vec)
+}
+
+// rangeBounds evaluates the start and end of a range, which must be
+// finite plain numbers.
+func (e *Evaluator) rangeBounds(r *ast.RangeExpr) (start, end float64, errVal types.Value) {
+	var bounds [2]float64
+	for i, expr := range []ast.Expr{r.Start, r.End} {
+		v := e.evalExpr(expr)
+		if v.IsError() {
+			return 0, 0, v
+		}
+		if !v.IsNumber() || math.IsInf(v.Num, 0) || math.IsNaN(v.Num) {
+			return 0, 0, types.Error("range bounds must be plain numbers")
+		}
+		bounds[i] = v.Num
+	}
+	return bounds[0], bounds[1], types.Empty()
+}
+
+// evalLines evaluates "lines 1..20" to a vector of those lines' values.
+func (e *Evaluator) evalLines(l *ast.LinesExpr) types.Value {
+	_, ys, errVal := e.lineValues(l.Range)
+	if errVal.IsError() {
+		return errVal
+	}
+	return types.VectorValue(ys)
+}
+
+// lineValues returns the line numbers and values of the lines in r that
+// have a numeric result.
+func (e *Evaluator) lineValues(r *ast.RangeExpr) (xs, ys []float64, errVal types.Value) {
+	first, last, errVal := e.rangeBounds(r)
+	if errVal.IsError() {
+		return nil, nil, errVal
+	}
+	if first > last {
+		first, last = last, first
+	}
+
+	for _, lr := range e.ctx.Lines() {
+		n := float64(lr.Line)
+		if n < first || n > last || !lr.Value.IsNumeric() {
+			continue
+		}
+		xs = append(xs, n)
+		ys = append(ys, lr.Value.AsFloat())
+	}
+	if len(ys) == 0 {
+		return nil, nil, types.Errorf("no values on lines %s", r.String())
+	}
+	return xs, ys, types.Empty()
+}
+
+// isChart reports whether v is a plot or sparkline.
+func isChart(v types.Value) bool {
+	return v.IsPlot() || v.IsSparkline()
+}
+
+// evalPlot evaluates plot(f, 0..10), plot([1, 4, 9]) or plot lines 1..20
+// to a chart of the points.
+func (e *Evaluator) evalPlot(args []ast.Expr) types.Value {
+	const usage = "plot requires a function and a range, a list, or lines: plot(f, 0..10)"
+
+	switch len(args) {
+	case 1:
+		if lines, ok := args[0].(*ast.LinesExpr); ok {
+			xs, ys, errVal := e.lineValues(lines.Range)
+			if errVal.IsError() {
+				return errVal
+			}
+			return types.PlotValue(&types.Plot{X: xs, Y: ys})
+		}
+
+		v := e.evalExpr(args[0])
+		if v.IsError() {
+			return v
+		}
+		if !v.IsVector() {
+			return types.Error(usage)
+		}
+		xs := make([]float64, len(v.Vec))
+		for i := range xs {
+			xs[i] = float64(i + 1)
+		}
+		return types.PlotValue(&types.Plot{X: xs, Y: v.Vec})
+
+	case 2:
+		f, errVal := e.realFunction(args[0])
+		if f == nil {
+			return errVal
+		}
+		r, ok := args[1].(*ast.RangeExpr)
+		if !ok {
+			return types.Error(usage)
+		}
+		lo, hi, errVal := e.rangeBounds(r)
+		if errVal.IsError() {
+			return errVal
+		}
+		if lo >= hi {
+			return types.Error("plot range must go from low to high")
+		}
+
+		p := &types.Plot{}
+		for i := range plotSamples {
+			x := lo + (hi-lo)*float64(i)/(plotSamples-1)
+			if y, ok := f(x); ok {
+				p.X = append(p.X, x)
+				p.Y = append(p.Y, y)
+			}
+		}
+		if len(p.X) == 0 {
+			return types.Errorf("%s is undefined on %s", args[0].String(), r.String())
+		}
+		return types.PlotValue(p)
+	}
+
+	return types.Error(usage)
+}
+
+// fnSpark returns a sparkline of its arguments: spark([3, 5, 9, 4]).
+func (e *Evaluator) fnSpark(args []types.Value) types.Value {
+	if len(args) == 0 {
+		return types.Error("spark requires at least one value")
+	}
+
+	p := &types.Plot{X: make([]float64, len(args)), Y: make([]float64, len(args))}
+	for i, arg := range args {
+		if !arg.IsNumeric() {
+			return types.Errorf("spark requires numbers, got %s", arg.Kind.String())
+		}
+		p.X[i] = float64(i + 1)
+		p.Y[i] = arg.AsFloat()
+	}
+	return types.SparklineValue(p)
+}
@@ -0,0 +1,59 @@
+// internal/eval/rates.go
+
+package eval
+
+import (
+	"slices"
+	"time"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// evalRate evaluates "rate USD/EUR", the current exchange rate, or the
+// recorded rates over a period: "rate USD/EUR last 30 days" is a vector
+// of them, "avg rate USD/EUR last 30 days" their average.
+func (e *Evaluator) evalRate(r *ast.RateExpr) types.Value {
+	if r.Period == nil {
+		rate, ok := e.ctx.GetRate(r.From, r.To)
+		if !ok {
+			return types.Errorf("no rate available for %s/%s", r.From, r.To)
+		}
+		return types.Number(rate).WithAsOf(e.ctx.RateTime(r.From, r.To))
+	}
+
+	period := e.evalExpr(r.Period)
+	if period.IsError() {
+		return period
+	}
+	if !period.IsUnit() || period.Unit == nil || period.Unit.Type != types.UnitTypeTime || period.Num <= 0 {
+		return types.Error("rate history needs a period of time, e.g. last 30 days")
+	}
+	since := time.Now().Add(-time.Duration(period.Num * period.Unit.ToBase * float64(time.Second)))
+
+	rates, err := e.ctx.RateSeries(r.From, r.To, since)
+	if err != nil {
+		return types.Error(err.Error())
+	}
+	if len(rates) == 0 {
+		return types.Errorf("no %s/%s rates recorded in the last %s", r.From, r.To, period.String())
+	}
+
+	switch r.Stat {
+	case "avg":
+		var sum float64
+		for _, rate := range rates {
+			sum += rate
+		}
+		return types.Number(sum / float64(len(rates)))
+	case "min":
+		return types.Number(slices.Min(rates))
+	case "max":
+		return types.Number(slices.Max(rates))
+	default:
+		if errVal, ok := e.ctx.checkSize(len(rates)); ok {
+			return errVal
+		}
+		return types.VectorValue(rates)
+	}
+}
@@ -0,0 +1,132 @@
+// internal/eval/scope.go
+
+package eval
+
+import (
+	"maps"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Block is a run of lines between begin and end, and the variables set
+// in it, which went back to their earlier values at the end.
+type Block struct {
+	Start int                    // Line number of begin
+	End   int                    // Line number of end; 0 while open
+	Depth int                    // Blocks it is inside
+	Vars  map[string]types.Value // Variables set in it, as they were at the end
+}
+
+// scope is an open block: the values its variables had before it set
+// them, put back when it closes.
+type scope struct {
+	block int // Index in Context.blocks
+	saved map[string]savedVar
+}
+
+// savedVar is a variable's value and description before a scope set it,
+// or that it had none.
+type savedVar struct {
+	value       types.Value
+	ok          bool
+	description string
+}
+
+// BeginScope opens a scope at the next line. Variables set until
+// EndScope shadow those of the same name outside it.
+func (c *Context) BeginScope() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.blocks = append(c.blocks, Block{Start: c.lineNo + 1, Depth: len(c.scopes)})
+	c.scopes = append(c.scopes, scope{block: len(c.blocks) - 1, saved: make(map[string]savedVar)})
+}
+
+// EndScope closes the innermost scope at the next line, putting the
+// variables it set back as they were. It returns false if no scope is
+// open.
+func (c *Context) EndScope() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.scopes) == 0 {
+		return false
+	}
+	s := c.scopes[len(c.scopes)-1]
+	c.scopes = c.scopes[:len(c.scopes)-1]
+
+	block := &c.blocks[s.block]
+	block.End = c.lineNo + 1
+	block.Vars = c.scopeVars(s)
+	for name, old := range s.saved {
+		if old.ok {
+			c.variables[name] = old.value
+		} else {
+			delete(c.variables, name)
+		}
+		if old.description != "" {
+			c.descriptions[name] = old.description
+		} else {
+			delete(c.descriptions, name)
+		}
+	}
+	return true
+}
+
+// Blocks returns the blocks opened since the context was cleared, in the
+// order they began. Open blocks have the current values of the
+// variables set in them.
+func (c *Context) Blocks() []Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	blocks := make([]Block, len(c.blocks))
+	for i, b := range c.blocks {
+		b.Vars = maps.Clone(b.Vars)
+		blocks[i] = b
+	}
+	for _, s := range c.scopes {
+		blocks[s.block].Vars = c.scopeVars(s)
+	}
+	return blocks
+}
+
+// scopeVars returns the current values of the variables a scope set.
+// Callers must hold the lock.
+func (c *Context) scopeVars(s scope) map[string]types.Value {
+	vars := make(map[string]types.Value, len(s.saved))
+	for name := range s.saved {
+		if v, ok := c.variables[name]; ok {
+			vars[name] = v
+		}
+	}
+	return vars
+}
+
+// shadow notes a variable's value before the innermost scope sets it.
+// Callers must hold the lock.
+func (c *Context) shadow(name string) {
+	if len(c.scopes) == 0 {
+		return
+	}
+	s := c.scopes[len(c.scopes)-1]
+	if _, ok := s.saved[name]; !ok {
+		v, ok := c.variables[name]
+		s.saved[name] = savedVar{value: v, ok: ok, description: c.descriptions[name]}
+	}
+}
+
+// resetScopes forgets every block. Callers must hold the lock.
+func (c *Context) resetScopes() {
+	c.scopes = nil
+	c.blocks = nil
+}
+
+// cloneScopes copies the blocks and open scopes of c into clone.
+// Callers must hold c's lock.
+func (c *Context) cloneScopes(clone *Context) {
+	clone.blocks = append([]Block(nil), c.blocks...)
+	for _, s := range c.scopes {
+		clone.scopes = append(clone.scopes, scope{block: s.block, saved: maps.Clone(s.saved)})
+	}
+}
@@ -0,0 +1,24 @@
+// internal/eval/trace.go
+
+package eval
+
+import (
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Step is one expression an evaluator evaluated and its value.
+type Step struct {
+	Expr  ast.Expr
+	Value types.Value
+	Depth int // Expressions it is part of; 0 for a line's whole expression
+}
+
+// SetTrace sets a function called with every expression the evaluator
+// evaluates, after its parts, so steps come innermost first.
+// Parenthesized expressions are traced once, without the parentheses.
+// nil stops tracing.
+func (e *Evaluator) SetTrace(trace func(Step)) {
+	e.trace = trace
+	e.traceDepth = 0
+}
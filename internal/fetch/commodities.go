@@ -0,0 +1,224 @@
+// internal/fetch/commodities.go
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ════════════════════════════════════════════════════════════════
+// ALPHA VANTAGE PROVIDER (Free tier with API key)
+// ════════════════════════════════════════════════════════════════
+
+const (
+	alphaVantageName    = "alphavantage"
+	alphaVantageBaseURL = "https://www.alphavantage.co/query"
+	alphaVantageEnvKey  = "ALPHAVANTAGE_API_KEY"
+)
+
+// poundsPerTonne converts prices per metric ton to prices per pound.
+const poundsPerTonne = 2204.62262185
+
+// alphaVantageSeries describes the series a commodity's price is read
+// from: oil and gas are published daily, base metals monthly.
+type alphaVantageSeries struct {
+	Function string
+	Interval string
+	PerTonne bool // Priced per metric ton rather than per unit
+}
+
+// alphaVantageCommodities maps commodity codes to their series.
+var alphaVantageCommodities = map[string]alphaVantageSeries{
+	"WTI":    {Function: "WTI", Interval: "daily"},
+	"BRENT":  {Function: "BRENT", Interval: "daily"},
+	"NATGAS": {Function: "NATURAL_GAS", Interval: "daily"},
+	"XCU":    {Function: "COPPER", Interval: "monthly", PerTonne: true},
+	"XAL":    {Function: "ALUMINUM", Interval: "monthly", PerTonne: true},
+}
+
+// AlphaVantageProvider fetches commodity prices from Alpha Vantage: crude
+// oil and natural gas in USD per barrel and per MMBtu, copper and
+// aluminum per pound. Requires API key (free tier available with
+// registration); each commodity is a request, of the free tier's 25 a day.
+type AlphaVantageProvider struct {
+	*BaseProvider
+	baseURL string
+}
+
+// NewAlphaVantageProvider creates a new Alpha Vantage provider.
+func NewAlphaVantageProvider() *AlphaVantageProvider {
+	base := NewBaseProvider(alphaVantageName, ProviderTypeCommodity)
+	base.SetAPIKeyEnv(alphaVantageEnvKey)
+	base.SetRequireKey(true) // API key required
+
+	return &AlphaVantageProvider{
+		BaseProvider: base,
+		baseURL:      alphaVantageBaseURL,
+	}
+}
+
+// FetchRates fetches the latest price of each commodity.
+func (p *AlphaVantageProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
+	if p.APIKey() == "" {
+		return nil, p.WrapError(ErrUnauthorized)
+	}
+
+	// The URL is left out of the result: it holds the key
+	result := NewRatesResult(p.Name(), ProviderTypeCommodity).
+		SetBase("USD").
+		SetSource(p.baseURL)
+
+	// Alpha Vantage requires separate requests per commodity
+	var lastErr error
+	for _, code := range CommodityCodes {
+		points, err := p.fetchSeries(ctx, code)
+		if err != nil {
+			lastErr = err
+			if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnauthorized) {
+				break // The rest would fail the same way
+			}
+			continue
+		}
+		if len(points) > 0 {
+			result.AddRate(code, points[0].price)
+		}
+	}
+
+	if result.IsEmpty() {
+		if lastErr == nil {
+			lastErr = ErrInvalidResponse
+		}
+		return nil, p.WrapError(lastErr)
+	}
+
+	return result, nil
+}
+
+// FetchPriceAt fetches a commodity's price on date: that day's close for
+// oil and gas, or the month's average for metals. Days without trading
+// take the last price before them.
+func (p *AlphaVantageProvider) FetchPriceAt(ctx context.Context, symbol string, date time.Time) (*RatesResult, error) {
+	if p.APIKey() == "" {
+		return nil, p.WrapError(ErrUnauthorized)
+	}
+	symbol = strings.ToUpper(symbol)
+	if _, ok := alphaVantageCommodities[symbol]; !ok {
+		return nil, p.WrapError(ErrNotFound)
+	}
+
+	points, err := p.fetchSeries(ctx, symbol)
+	if err != nil {
+		return nil, p.WrapError(err)
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	for _, pt := range points {
+		if !pt.date.After(day) {
+			return NewRatesResult(p.Name(), ProviderTypeCommodity).
+				SetBase("USD").
+				SetSource(p.baseURL).
+				SetTimestamp(pt.date).
+				AddRate(symbol, pt.price), nil
+		}
+	}
+	// Before the series starts
+	return nil, p.WrapError(ErrNotFound)
+}
+
+// alphaVantagePoint is a price of a series, converted to its quoting unit.
+type alphaVantagePoint struct {
+	date  time.Time
+	price float64
+}
+
+// fetchSeries fetches a commodity's series, newest first.
+func (p *AlphaVantageProvider) fetchSeries(ctx context.Context, code string) ([]alphaVantagePoint, error) {
+	series := alphaVantageCommodities[code]
+	url := p.baseURL + "?function=" + series.Function + "&interval=" + series.Interval + "&apikey=" + p.APIKey()
+
+	var resp alphaVantageResponse
+	if err := p.Client().GetJSON(ctx, url, &resp); err != nil {
+		return nil, err
+	}
+
+	// Errors come back as 200s, with a message in place of the data
+	switch {
+	case resp.ErrorMessage != "":
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, resp.ErrorMessage)
+	case len(resp.Data) == 0 && strings.Contains(strings.ToLower(resp.Information+resp.Note), "api key"):
+		return nil, ErrUnauthorized
+	case len(resp.Data) == 0 && resp.Information+resp.Note != "":
+		return nil, ErrRateLimited
+	}
+
+	points := make([]alphaVantagePoint, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		// Days without a price hold "."
+		price, err := strconv.ParseFloat(d.Value, 64)
+		if err != nil || price <= 0 {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, d.Date)
+		if err != nil {
+			continue
+		}
+		if series.PerTonne {
+			price /= poundsPerTonne
+		}
+		points = append(points, alphaVantagePoint{date: date, price: price})
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: no %s prices", ErrInvalidResponse, code)
+	}
+	return points, nil
+}
+
+// alphaVantageResponse is the API response structure.
+type alphaVantageResponse struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval"`
+	Unit     string `json:"unit"`
+	Data     []struct {
+		Date  string `json:"date"`
+		Value string `json:"value"`
+	} `json:"data"`
+
+	ErrorMessage string `json:"Error Message"`
+	Information  string `json:"Information"`
+	Note         string `json:"Note"`
+}
+
+// ════════════════════════════════════════════════════════════════
+// COMMODITY PROVIDER FACTORY
+// ════════════════════════════════════════════════════════════════
+
+// NewCommodityProviders returns all available commodity providers in
+// priority order. None is free of a key: without one, commodities keep
+// their built-in prices.
+func NewCommodityProviders() []Provider {
+	var providers []Provider
+
+	if alphaVantage := NewAlphaVantageProvider(); alphaVantage.IsAvailable() {
+		providers = append(providers, alphaVantage)
+	}
+
+	return providers
+}
+
+// ════════════════════════════════════════════════════════════════
+// SUPPORTED COMMODITIES
+// ════════════════════════════════════════════════════════════════
+
+// CommodityCodes returns a list of supported commodity codes.
+var CommodityCodes = []string{
+	"WTI",    // WTI crude oil, per barrel
+	"BRENT",  // Brent crude oil, per barrel
+	"NATGAS", // Henry Hub natural gas, per MMBtu
+	"XCU",    // Copper, per pound
+	"XAL",    // Aluminum, per pound
+}
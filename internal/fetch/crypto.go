@@ -4,6 +4,7 @@ package fetch
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -20,12 +21,20 @@ const (
 	coingeckoEnvKey     = "COINGECKO_API_KEY"
 )
 
+// Free tier limits on past prices, kept to without an API key: each coin
+// and day is a call of its own, and only the past year is served.
+const (
+	coingeckoFreeHistoryPerMinute = 10
+	coingeckoFreeHistoryDays      = 365
+)
+
 // CoinGeckoProvider fetches crypto prices from CoinGecko.
 // Free tier: 10-30 calls/minute, no API key required.
 // Pro tier: Higher limits with API key.
 type CoinGeckoProvider struct {
 	*BaseProvider
 	baseURL string
+	history *rateLimiter // Spaces out past prices on the free tier
 }
 
 // NewCoinGeckoProvider creates a new CoinGecko provider.
@@ -42,6 +51,7 @@ func NewCoinGeckoProvider() *CoinGeckoProvider {
 	return &CoinGeckoProvider{
 		BaseProvider: base,
 		baseURL:      coingeckoBaseURL,
+		history:      newRateLimiterPer(coingeckoFreeHistoryPerMinute, time.Minute),
 	}
 }
 
@@ -70,6 +80,46 @@ func (p *CoinGeckoProvider) FetchRates(ctx context.Context) (*RatesResult, error
 	return result, nil
 }
 
+// FetchPriceAt fetches a coin's USD price on date, as CoinGecko recorded
+// it at 00:00 UTC. Without an API key, older dates than the free tier
+// serves fail at once with ErrNoHistory.
+func (p *CoinGeckoProvider) FetchPriceAt(ctx context.Context, symbol string, date time.Time) (*RatesResult, error) {
+	symbol = strings.ToUpper(symbol)
+	id, ok := coingeckoSymbolToID[symbol]
+	if !ok {
+		return nil, p.WrapError(ErrNotFound)
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if p.APIKey() == "" {
+		if time.Since(day) > coingeckoFreeHistoryDays*24*time.Hour {
+			return nil, p.WrapError(fmt.Errorf("%w: only the past %d days without %s",
+				ErrNoHistory, coingeckoFreeHistoryDays, coingeckoEnvKey))
+		}
+		if err := p.history.Wait(ctx); err != nil {
+			return nil, p.WrapError(err)
+		}
+	}
+
+	url := p.buildURL("/coins/" + id + "/history?date=" + day.Format("02-01-2006") + "&localization=false")
+
+	var resp coingeckoHistoryResponse
+	if err := p.Client().GetJSON(ctx, url, &resp); err != nil {
+		return nil, p.WrapError(err)
+	}
+	// No market data: the coin wasn't traded yet
+	price := resp.MarketData.CurrentPrice.USD
+	if price <= 0 {
+		return nil, p.WrapError(ErrNotFound)
+	}
+
+	return NewRatesResult(p.Name(), ProviderTypeCrypto).
+		SetBase("USD").
+		SetSource(url).
+		SetTimestamp(day).
+		AddRate(symbol, price), nil
+}
+
 // buildURL constructs the API URL with optional API key.
 func (p *CoinGeckoProvider) buildURL(path string) string {
 	apiKey := p.APIKey()
@@ -98,6 +148,13 @@ type coingeckoPriceData struct {
 	USD float64 `json:"usd"`
 }
 
+// coingeckoHistoryResponse is a coin's data on a past day.
+type coingeckoHistoryResponse struct {
+	MarketData struct {
+		CurrentPrice coingeckoPriceData `json:"current_price"`
+	} `json:"market_data"`
+}
+
 // ════════════════════════════════════════════════════════════════
 // COINCAP PROVIDER (Free, no API key required)
 // ════════════════════════════════════════════════════════════════
@@ -153,6 +210,34 @@ func (p *CoinCapProvider) FetchRates(ctx context.Context) (*RatesResult, error)
 	return result, nil
 }
 
+// FetchPriceAt fetches a coin's average USD price over date's day (UTC).
+func (p *CoinCapProvider) FetchPriceAt(ctx context.Context, symbol string, date time.Time) (*RatesResult, error) {
+	symbol = strings.ToUpper(symbol)
+	id, ok := coincapSymbolToID[symbol]
+	if !ok {
+		return nil, p.WrapError(ErrNotFound)
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	url := fmt.Sprintf("%s/assets/%s/history?interval=d1&start=%d&end=%d",
+		p.baseURL, id, day.UnixMilli(), day.AddDate(0, 0, 1).UnixMilli())
+
+	var resp coincapHistoryResponse
+	if err := p.Client().GetJSON(ctx, url, &resp); err != nil {
+		return nil, p.WrapError(err)
+	}
+	for _, point := range resp.Data {
+		if price := parseFloat(point.PriceUSD); price > 0 {
+			return NewRatesResult(p.Name(), ProviderTypeCrypto).
+				SetBase("USD").
+				SetSource(url).
+				SetTimestamp(day).
+				AddRate(symbol, price), nil
+		}
+	}
+	return nil, p.WrapError(ErrNotFound)
+}
+
 // coincapResponse is the API response structure.
 type coincapResponse struct {
 	Data      []coincapAsset   `json:"data"`
@@ -168,6 +253,14 @@ type coincapAsset struct {
 	PriceUSD string `json:"priceUsd"`
 }
 
+// coincapHistoryResponse is an asset's prices over time, a day apiece.
+type coincapHistoryResponse struct {
+	Data []struct {
+		PriceUSD string `json:"priceUsd"`
+		Time     int64  `json:"time"`
+	} `json:"data"`
+}
+
 // coincapTimestamp handles CoinCap's millisecond timestamp.
 type coincapTimestamp int64
 
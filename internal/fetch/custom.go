@@ -0,0 +1,250 @@
+// internal/fetch/custom.go
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ════════════════════════════════════════════════════════════════
+// CUSTOM PROVIDER (User-defined JSON API)
+// ════════════════════════════════════════════════════════════════
+
+// CustomConfig describes a provider of the user's own: a JSON API whose
+// rates are picked out by path.
+//
+// Paths name a value by its keys and array indexes, with an optional
+// leading "$": "$.data.rates", "quotes[0].mid". Rates may be numbers or
+// numeric strings.
+type CustomConfig struct {
+	Name   string
+	Type   ProviderType
+	URL    string            // Environment variables ($VAR, ${VAR}) are expanded when fetching
+	Header map[string]string // Extra request headers, e.g. Authorization; expanded like URL
+
+	// Base is the currency fiat rates are quoted against, as units of
+	// each code per unit of Base (default USD). Crypto, metal and
+	// commodity rates are always USD prices.
+	Base string
+
+	// Rates is the path to the rates: an object of rates by code, or an
+	// array of objects holding a code at CodeKey and a rate at RateKey
+	// (default "code" and "rate").
+	Rates   string
+	CodeKey string
+	RateKey string
+
+	// Fields maps codes to the paths of single rates, added to those at
+	// Rates.
+	Fields map[string]string
+
+	// Time is the path to when the rates were published, as RFC 3339, a
+	// date (YYYY-MM-DD) or Unix seconds. Optional.
+	Time string
+}
+
+// CustomProvider fetches rates from an API described by a CustomConfig.
+type CustomProvider struct {
+	*BaseProvider
+	cfg CustomConfig
+}
+
+// NewCustomProvider creates a provider from cfg, checking that it names
+// a URL and where to find rates.
+func NewCustomProvider(cfg CustomConfig) (*CustomProvider, error) {
+	switch {
+	case cfg.Name == "":
+		return nil, errors.New("custom provider: missing name")
+	case cfg.URL == "":
+		return nil, NewProviderError(cfg.Name, errors.New("missing url"))
+	case cfg.Rates == "" && len(cfg.Fields) == 0:
+		return nil, NewProviderError(cfg.Name, errors.New("missing rates or fields"))
+	}
+	if cfg.Base == "" {
+		cfg.Base = "USD"
+	}
+	cfg.Base = strings.ToUpper(cfg.Base)
+	if cfg.Type != ProviderTypeFiat && cfg.Base != "USD" {
+		return nil, NewProviderError(cfg.Name, fmt.Errorf("%s rates must be USD prices", cfg.Type))
+	}
+	if cfg.CodeKey == "" {
+		cfg.CodeKey = "code"
+	}
+	if cfg.RateKey == "" {
+		cfg.RateKey = "rate"
+	}
+
+	base := NewBaseProvider(cfg.Name, cfg.Type)
+	base.SetRequireKey(false)
+
+	return &CustomProvider{BaseProvider: base, cfg: cfg}, nil
+}
+
+// FetchRates fetches the configured URL and picks out its rates.
+func (p *CustomProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
+	header := make(http.Header)
+	for key, value := range p.cfg.Header {
+		header.Set(key, os.ExpandEnv(value))
+	}
+
+	var resp any
+	if err := p.Client().GetJSONWithHeader(ctx, os.ExpandEnv(p.cfg.URL), header, &resp); err != nil {
+		return nil, p.WrapError(err)
+	}
+
+	rates, err := p.rates(resp)
+	if err != nil {
+		return nil, p.WrapError(err)
+	}
+
+	day := time.Now()
+	if p.cfg.Time != "" {
+		if v, ok := lookupPath(resp, p.cfg.Time); ok {
+			if t, ok := parseTime(v); ok {
+				day = t
+			}
+		}
+	}
+
+	// The URL is left out of the result: expanded, it may hold a secret
+	if p.cfg.Type == ProviderTypeFiat {
+		rates[p.cfg.Base] = 1
+		return usdResult(p.Name(), p.Name(), day, p.cfg.Base, rates)
+	}
+	result := NewRatesResult(p.Name(), p.cfg.Type).
+		SetBase("USD").
+		SetSource(p.Name()).
+		SetTimestamp(day)
+	for code, rate := range rates {
+		result.AddRate(code, rate)
+	}
+	return result, nil
+}
+
+// rates picks the rates out of a response, by code.
+func (p *CustomProvider) rates(resp any) (map[string]float64, error) {
+	rates := make(map[string]float64)
+
+	if p.cfg.Rates != "" {
+		v, ok := lookupPath(resp, p.cfg.Rates)
+		if !ok {
+			return nil, fmt.Errorf("%w: nothing at %s", ErrInvalidResponse, p.cfg.Rates)
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			for code, rate := range v {
+				if n, ok := toFloat(rate); ok && n > 0 {
+					rates[strings.ToUpper(code)] = n
+				}
+			}
+		case []any:
+			for _, item := range v {
+				obj, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				code, _ := obj[p.cfg.CodeKey].(string)
+				if n, ok := toFloat(obj[p.cfg.RateKey]); ok && n > 0 && code != "" {
+					rates[strings.ToUpper(code)] = n
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s is not an object or array", ErrInvalidResponse, p.cfg.Rates)
+		}
+	}
+
+	for code, path := range p.cfg.Fields {
+		v, ok := lookupPath(resp, path)
+		if !ok {
+			continue
+		}
+		if n, ok := toFloat(v); ok && n > 0 {
+			rates[strings.ToUpper(code)] = n
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: no rates found", ErrInvalidResponse)
+	}
+	return rates, nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// PATHS
+// ════════════════════════════════════════════════════════════════
+
+// lookupPath returns the value at path in a decoded JSON document.
+func lookupPath(v any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	for path != "" {
+		// An index: [n]
+		if rest, ok := strings.CutPrefix(path, "["); ok {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, false
+			}
+			i, err := strconv.Atoi(rest[:end])
+			arr, ok := v.([]any)
+			if err != nil || !ok || i < 0 || i >= len(arr) {
+				return nil, false
+			}
+			v, path = arr[i], strings.TrimPrefix(rest[end+1:], ".")
+			continue
+		}
+
+		// A key, up to the next "." or "["
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			end = len(path)
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[path[:end]]; !ok {
+			return nil, false
+		}
+		path = strings.TrimPrefix(path[end:], ".")
+	}
+	return v, true
+}
+
+// toFloat returns a JSON number, or a string holding one, as a float.
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// parseTime returns a JSON time: RFC 3339, a date or Unix seconds.
+func parseTime(v any) (time.Time, bool) {
+	if n, ok := v.(float64); ok {
+		return time.Unix(int64(n), 0), true
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), true
+	}
+	return time.Time{}, false
+}
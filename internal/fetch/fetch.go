@@ -7,21 +7,29 @@ package fetch
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // Default client settings
 const (
-	DefaultTimeout     = 10 * time.Second
-	DefaultMaxRetries  = 3
-	DefaultRateLimit   = 10 // requests per second
-	DefaultUserAgent   = "numio/1.0"
-	DefaultBackoffBase = 500 * time.Millisecond
-	DefaultBackoffMax  = 5 * time.Second
+	DefaultTimeout       = 10 * time.Second
+	DefaultMaxRetries    = 3
+	DefaultRateLimit     = 10 // requests per second
+	DefaultUserAgent     = "numio/1.0"
+	DefaultBackoffBase   = 500 * time.Millisecond
+	DefaultBackoffMax    = 5 * time.Second
+	DefaultMaxRetryAfter = 30 * time.Second // Longest Retry-After waited for
 )
 
 // Common errors
@@ -32,6 +40,8 @@ var (
 	ErrInvalidResponse = errors.New("invalid response")
 	ErrNotFound        = errors.New("resource not found")
 	ErrUnauthorized    = errors.New("unauthorized (check API key)")
+	ErrNoHistory       = errors.New("historical rates not supported")
+	ErrDisabled        = errors.New("provider disabled")
 )
 
 // ════════════════════════════════════════════════════════════════
@@ -46,19 +56,32 @@ type Client struct {
 	rateLimiter *rateLimiter
 	backoffBase time.Duration
 	backoffMax  time.Duration
+	maxWait     time.Duration // Longest Retry-After waited for
+	logger      *slog.Logger
+	cache       *HTTPCache // nil: no conditional requests
+
+	// Why requests can't be made, as when $NUMIO_CA_BUNDLE can't be loaded
+	transportErr error
 }
 
 // NewClient creates a new Client with default settings.
 func NewClient() *Client {
+	transport, err := defaultTransport()
 	return &Client{
 		http: &http.Client{
-			Timeout: DefaultTimeout,
+			Timeout:   DefaultTimeout,
+			Transport: transport,
 		},
 		userAgent:   DefaultUserAgent,
 		maxRetries:  DefaultMaxRetries,
 		rateLimiter: newRateLimiter(DefaultRateLimit),
 		backoffBase: DefaultBackoffBase,
 		backoffMax:  DefaultBackoffMax,
+		maxWait:     DefaultMaxRetryAfter,
+		logger:      discardLogger,
+		cache:       DefaultHTTPCache(),
+
+		transportErr: err,
 	}
 }
 
@@ -120,6 +143,33 @@ func WithBackoff(base, max time.Duration) ClientOption {
 	}
 }
 
+// WithMaxRetryAfter sets the longest Retry-After a rate-limited or
+// unavailable server may ask for and still be retried; a longer one
+// fails the request at once.
+func WithMaxRetryAfter(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d >= 0 {
+			c.maxWait = d
+		}
+	}
+}
+
+// WithLogger logs retried requests to l at debug level.
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = orDiscard(l)
+	}
+}
+
+// WithHTTPCache keeps GET responses in hc and asks for them again
+// conditionally. Clients use the cache in DefaultHTTPCacheDir unless
+// given another; nil turns caching off.
+func WithHTTPCache(hc *HTTPCache) ClientOption {
+	return func(c *Client) {
+		c.cache = hc
+	}
+}
+
 // ════════════════════════════════════════════════════════════════
 // HTTP METHODS
 // ════════════════════════════════════════════════════════════════
@@ -140,9 +190,49 @@ func (c *Client) GetJSON(ctx context.Context, url string, v any) error {
 	return resp.JSON(v)
 }
 
+// GetJSONWithHeader performs a GET request with extra headers, such as
+// authentication, and decodes the JSON response.
+func (c *Client) GetJSONWithHeader(ctx context.Context, url string, header http.Header, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, url, nil, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	return resp.JSON(v)
+}
+
+// GetXML performs a GET request and decodes the XML response.
+func (c *Client) GetXML(ctx context.Context, url string, v any) error {
+	resp, err := c.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	return resp.XML(v)
+}
+
 // Do performs an HTTP request with retries and rate limiting.
 func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) (*Response, error) {
+	return c.do(ctx, method, url, body, nil)
+}
+
+// do performs a request like Do, adding header to it.
+func (c *Client) do(ctx context.Context, method, url string, body io.Reader, header http.Header) (*Response, error) {
+	if c.transportErr != nil {
+		return nil, c.transportErr
+	}
 	var lastErr error
+	var retryAfter time.Duration // Asked for by the last response, if any
+
+	// A GET whose response was kept is made conditional
+	var key string
+	var cached *httpCacheEntry
+	if c.cache != nil && method == http.MethodGet && body == nil {
+		key = cacheKey(url, header)
+		cached = c.cache.get(key)
+	}
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		// Wait for rate limiter
@@ -153,6 +243,14 @@ func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) (*R
 		// Apply backoff on retry
 		if attempt > 0 {
 			backoff := c.calculateBackoff(attempt)
+			if retryAfter > 0 {
+				backoff = retryAfter + jitter(c.backoffBase)
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+				return nil, lastErr
+			}
+			c.logger.Debug("retrying request", "host", hostOf(url),
+				"attempt", attempt+1, "backoff", backoff, "err", lastErr)
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
@@ -168,11 +266,23 @@ func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) (*R
 
 		req.Header.Set("User-Agent", c.userAgent)
 		req.Header.Set("Accept", "application/json")
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
 
 		// Execute request
 		resp, err := c.http.Do(req)
 		if err != nil {
 			lastErr = c.wrapError(err)
+			retryAfter = 0
 			// Retry on timeout or temporary errors
 			if isRetryable(err) {
 				continue
@@ -181,7 +291,16 @@ func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) (*R
 		}
 
 		// Check status code
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			c.cache.touch(key)
+			c.logger.Debug("response not modified", "host", hostOf(url))
+			return cached.response(), nil
+		}
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if key != "" {
+				return c.cache.store(key, resp), nil
+			}
 			return newResponse(resp), nil
 		}
 
@@ -189,8 +308,14 @@ func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) (*R
 		resp.Body.Close()
 		lastErr = c.statusError(resp.StatusCode)
 
-		// Retry on server errors (5xx) or rate limiting (429)
+		// Retry on server errors (5xx) or rate limiting (429), waiting as
+		// long as the server asks if it says
 		if isRetryableStatus(resp.StatusCode) {
+			wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if ok && wait > c.maxWait {
+				return nil, fmt.Errorf("%w (retry after %s)", lastErr, wait.Round(time.Second))
+			}
+			retryAfter = wait
 			continue
 		}
 
@@ -207,11 +332,16 @@ func (c *Client) Do(ctx context.Context, method, url string, body io.Reader) (*R
 // Use this when you need custom headers or request configuration.
 // Note: This does not apply rate limiting or retries.
 func (c *Client) DoRaw(req *http.Request) (*http.Response, error) {
+	if c.transportErr != nil {
+		return nil, c.transportErr
+	}
 	return c.http.Do(req)
 }
 
 // calculateBackoff returns the backoff duration for a retry attempt.
-// Uses exponential backoff with jitter.
+// Uses exponential backoff with jitter: a random wait between half and
+// all of the doubled delay, so clients failing together don't all retry
+// together.
 func (c *Client) calculateBackoff(attempt int) time.Duration {
 	backoff := c.backoffBase
 	for i := 1; i < attempt; i++ {
@@ -221,7 +351,30 @@ func (c *Client) calculateBackoff(attempt int) time.Duration {
 			break
 		}
 	}
-	return backoff
+	return backoff/2 + jitter(backoff/2)
+}
+
+// jitter returns a random duration in [0, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// parseRetryAfter returns the wait a Retry-After header asks for, in
+// seconds or as an HTTP date, at now.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
 }
 
 // wrapError wraps an HTTP error with a more descriptive error.
@@ -249,6 +402,15 @@ func (c *Client) statusError(status int) error {
 	}
 }
 
+// hostOf returns the host a URL names, for logging without the paths
+// and queries that may hold API keys.
+func hostOf(rawURL string) string {
+	if u, err := neturl.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return ""
+}
+
 // isRetryable returns true if the error is retryable.
 func isRetryable(err error) bool {
 	if errors.Is(err, context.DeadlineExceeded) {
@@ -274,6 +436,7 @@ func isRetryableStatus(status int) bool {
 type Response struct {
 	StatusCode int
 	Header     http.Header
+	Cached     bool // Body came from the HTTP cache, the server replying 304 Not Modified
 	body       io.ReadCloser
 }
 
@@ -320,6 +483,18 @@ func (r *Response) JSON(v any) error {
 	return nil
 }
 
+// XML decodes the response body as XML into v.
+func (r *Response) XML(v any) error {
+	if r.body == nil {
+		return ErrInvalidResponse
+	}
+
+	if err := xml.NewDecoder(r.body).Decode(v); err != nil {
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
 // ════════════════════════════════════════════════════════════════
 // RATE LIMITER (Token Bucket)
 // ════════════════════════════════════════════════════════════════
@@ -338,10 +513,16 @@ func newRateLimiter(rps int) *rateLimiter {
 	if rps <= 0 {
 		rps = DefaultRateLimit
 	}
+	return newRateLimiterPer(rps, time.Second)
+}
+
+// newRateLimiterPer creates a rate limiter allowing n requests per period,
+// in a burst or spread out.
+func newRateLimiterPer(n int, period time.Duration) *rateLimiter {
 	return &rateLimiter{
-		tokens:     float64(rps),
-		maxTokens:  float64(rps),
-		refillRate: float64(rps),
+		tokens:     float64(n),
+		maxTokens:  float64(n),
+		refillRate: float64(n) / period.Seconds(),
 		lastRefill: time.Now(),
 	}
 }
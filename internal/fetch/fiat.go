@@ -39,7 +39,18 @@ func NewFrankfurterProvider() *FrankfurterProvider {
 
 // FetchRates fetches current fiat rates from Frankfurter.
 func (p *FrankfurterProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
-	url := p.baseURL + "/latest?from=USD"
+	return p.fetch(ctx, "latest")
+}
+
+// FetchRatesAt fetches the fiat rates published on date, or on the last
+// working day before it.
+func (p *FrankfurterProvider) FetchRatesAt(ctx context.Context, date time.Time) (*RatesResult, error) {
+	return p.fetch(ctx, date.Format("2006-01-02"))
+}
+
+// fetch fetches the rates of a day ("latest" or YYYY-MM-DD).
+func (p *FrankfurterProvider) fetch(ctx context.Context, day string) (*RatesResult, error) {
+	url := p.baseURL + "/" + day + "?from=USD"
 
 	var resp frankfurterResponse
 	if err := p.Client().GetJSON(ctx, url, &resp); err != nil {
@@ -175,10 +186,14 @@ func NewFiatProvider() Provider {
 }
 
 // NewFiatProviders returns all available fiat providers in priority order.
+// The central banks' reference rates (see official.go) come last.
 func NewFiatProviders() []Provider {
 	return []Provider{
 		NewFrankfurterProvider(),
 		NewExchangeRateAPIProvider(),
+		NewECBProvider(),
+		NewBoEProvider(),
+		NewCBRTProvider(),
 	}
 }
 
@@ -200,5 +215,5 @@ var FiatCurrencies = []string{
 	"CNY", "HKD", "TWD", "KRW", "SGD", "MYR", "THB", "IDR",
 	"PHP", "VND", "INR", "PKR", "BDT",
 	// Middle East & Africa
-	"ILS", "AED", "SAR", "QAR", "KWD", "EGP", "ZAR", "NGN", "KES",
+	"ILS", "AED", "SAR", "QAR", "KWD", "BHD", "EGP", "ZAR", "NGN", "KES",
 }
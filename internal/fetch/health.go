@@ -0,0 +1,213 @@
+// internal/fetch/health.go
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Circuit breaker settings. A provider failing BreakerThreshold times in a
+// row is skipped for BreakerCooldown; the first fetch after that probes
+// it, and each failed probe doubles the wait, up to BreakerMaxCooldown.
+const (
+	BreakerThreshold   = 3
+	BreakerCooldown    = 1 * time.Minute
+	BreakerMaxCooldown = 30 * time.Minute
+)
+
+// ErrCircuitOpen is the error of fetching when every provider of a type
+// is being skipped after failing repeatedly.
+var ErrCircuitOpen = errors.New("provider skipped after repeated failures")
+
+// CircuitState is whether the registry is calling a provider.
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Called as usual
+	CircuitOpen                         // Skipped until its cooldown ends
+	CircuitHalfOpen                     // Cooldown over: one fetch at a time probes it
+)
+
+// String returns the state name.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// health tracks a provider's recent fetches.
+type health struct {
+	successes   int
+	failures    int
+	streak      int           // Failures in a row
+	cooldown    time.Duration // Wait after the latest trip; 0 = closed
+	openUntil   time.Time
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     error
+	probing     bool // A fetch is probing the half-open circuit
+}
+
+// state returns the circuit state at now.
+func (h *health) state(now time.Time) CircuitState {
+	switch {
+	case h.cooldown == 0:
+		return CircuitClosed
+	case now.Before(h.openUntil):
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
+
+// record notes the outcome of a fetch at now, tripping the breaker on
+// the BreakerThreshold'th failure in a row or on a failed probe.
+func (h *health) record(now time.Time, err error) {
+	if err == nil {
+		h.successes++
+		h.streak = 0
+		h.cooldown = 0
+		h.lastSuccess = now
+		return
+	}
+
+	h.failures++
+	h.streak++
+	h.lastFailure = now
+	h.lastErr = err
+	switch {
+	case h.cooldown > 0:
+		h.cooldown = min(2*h.cooldown, BreakerMaxCooldown)
+	case h.streak >= BreakerThreshold:
+		h.cooldown = BreakerCooldown
+	default:
+		return
+	}
+	h.openUntil = now.Add(h.cooldown)
+}
+
+// ProviderStatus is a provider's health, as Status reports it.
+type ProviderStatus struct {
+	Name        string
+	Type        ProviderType
+	Available   bool // Has the API key it needs, if any
+	Disabled    bool
+	Source      bool // The only provider used for its type (see SetSource)
+	State       CircuitState
+	Successes   int
+	Failures    int
+	Streak      int       // Failures in a row
+	LastSuccess time.Time // Zero if none
+	LastFailure time.Time // Zero if none
+	LastError   error     // Of the latest failure, nil if none
+	RetryAt     time.Time // When an open circuit is probed again
+}
+
+// Status returns the health of every registered provider, in priority
+// order within each type: fiat, crypto, then metal providers.
+func (r *Registry) Status() []ProviderStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var statuses []ProviderStatus
+	for _, typ := range []ProviderType{ProviderTypeFiat, ProviderTypeCrypto, ProviderTypeMetal, ProviderTypeStock, ProviderTypeCommodity} {
+		for _, p := range r.providers[typ] {
+			s := ProviderStatus{
+				Name:      p.Name(),
+				Type:      typ,
+				Available: p.IsAvailable(),
+				Disabled:  r.disabled[p.Name()],
+				Source:    r.sources[typ] == p.Name(),
+			}
+			if h := r.health[p.Name()]; h != nil {
+				s.State = h.state(now)
+				s.Successes, s.Failures, s.Streak = h.successes, h.failures, h.streak
+				s.LastSuccess, s.LastFailure, s.LastError = h.lastSuccess, h.lastFailure, h.lastErr
+				if s.State == CircuitOpen {
+					s.RetryAt = h.openUntil
+				}
+			}
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses
+}
+
+// ResetHealth forgets the named provider's failures, closing its circuit,
+// or every provider's if name is empty.
+func (r *Registry) ResetHealth(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == "" {
+		clear(r.health)
+		return
+	}
+	delete(r.health, name)
+}
+
+// skipping reports whether Fetch passes p by: its circuit is open, or
+// half-open with another fetch already probing it. Otherwise a half-open
+// circuit is left to this fetch to probe, until its outcome is recorded.
+func (r *Registry) skipping(p Provider) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.health[p.Name()]
+	if h == nil {
+		return false
+	}
+	switch h.state(time.Now()) {
+	case CircuitOpen:
+		return true
+	case CircuitHalfOpen:
+		if h.probing {
+			return true
+		}
+		h.probing = true
+	}
+	return false
+}
+
+// recordHealth notes the outcome of fetching from p, returning the
+// circuit's state before and after. A cancelled fetch, or one asking for
+// rates the provider doesn't have (a past date it doesn't keep, an asset
+// not yet traded), says nothing about the provider and isn't counted.
+func (r *Registry) recordHealth(p Provider, err error) (before, after CircuitState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.health[p.Name()]
+	if h == nil {
+		h = &health{}
+		r.health[p.Name()] = h
+	}
+	now := time.Now()
+	before = h.state(now)
+	h.probing = false
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, ErrNoHistory) && !errors.Is(err, ErrNotFound) {
+		h.record(now, err)
+	}
+	return before, h.state(now)
+}
+
+// retryAt returns when p's open circuit is probed again.
+func (r *Registry) retryAt(p Provider) time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if h := r.health[p.Name()]; h != nil {
+		return h.openUntil
+	}
+	return time.Time{}
+}
@@ -0,0 +1,246 @@
+// internal/fetch/httpcache.go
+
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HTTP cache settings. Responses carrying an ETag or Last-Modified header
+// are kept, and asked for again conditionally; a 304 Not Modified reply
+// is answered from the cache, costing the provider next to nothing.
+const (
+	DefaultHTTPCacheSize = 16 << 20 // Bytes kept on disk, oldest dropped first
+	MaxHTTPCacheEntry    = 4 << 20  // Larger responses aren't kept
+	HTTPCacheEnv         = "NUMIO_HTTP_CACHE"
+)
+
+// HTTPCache keeps responses on disk, one file per request, so clients
+// can make conditional requests (If-None-Match, If-Modified-Since).
+type HTTPCache struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex // Serializes pruning
+}
+
+// NewHTTPCache creates a cache in dir holding up to maxSize bytes
+// (DefaultHTTPCacheSize if not positive). The directory is created when
+// first written to.
+func NewHTTPCache(dir string, maxSize int64) *HTTPCache {
+	if maxSize <= 0 {
+		maxSize = DefaultHTTPCacheSize
+	}
+	return &HTTPCache{dir: dir, maxSize: maxSize}
+}
+
+// DefaultHTTPCacheDir returns where clients cache responses by default:
+// $NUMIO_HTTP_CACHE, or "http" in numio's cache directory. It returns ""
+// if NUMIO_HTTP_CACHE is "off" or there is no cache directory.
+func DefaultHTTPCacheDir() string {
+	switch dir := os.Getenv(HTTPCacheEnv); dir {
+	case "off", "0", "false":
+		return ""
+	case "":
+	default:
+		return dir
+	}
+
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "numio", "http")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".numio", "cache", "http")
+}
+
+var (
+	defaultHTTPCache     *HTTPCache
+	defaultHTTPCacheOnce sync.Once
+)
+
+// DefaultHTTPCache returns the cache new clients use, in
+// DefaultHTTPCacheDir, or nil if it is off.
+func DefaultHTTPCache() *HTTPCache {
+	defaultHTTPCacheOnce.Do(func() {
+		if dir := DefaultHTTPCacheDir(); dir != "" {
+			defaultHTTPCache = NewHTTPCache(dir, 0)
+		}
+	})
+	return defaultHTTPCache
+}
+
+// Clear removes every cached response.
+func (hc *HTTPCache) Clear() error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	err := os.RemoveAll(hc.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ════════════════════════════════════════════════════════════════
+// ENTRIES
+// ════════════════════════════════════════════════════════════════
+
+// httpCacheEntry is a cached response, as stored.
+type httpCacheEntry struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         []byte      `json:"body"`
+}
+
+// cacheKey returns the file name of a request's entry. Extra headers are
+// part of it, so requests with different credentials don't share one.
+func cacheKey(url string, header http.Header) string {
+	h := sha256.New()
+	io.WriteString(h, url)
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			io.WriteString(h, "\n"+key+": "+value)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)) + ".json"
+}
+
+// get returns the entry stored under key, or nil.
+func (hc *HTTPCache) get(key string) *httpCacheEntry {
+	data, err := os.ReadFile(filepath.Join(hc.dir, key))
+	if err != nil {
+		return nil
+	}
+	var entry httpCacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// touch marks key's entry as just used, so it is pruned last.
+func (hc *HTTPCache) touch(key string) {
+	now := time.Now()
+	_ = os.Chtimes(filepath.Join(hc.dir, key), now, now)
+}
+
+// store returns resp for reading, keeping its body under key if the
+// response can be asked for conditionally and isn't too large.
+func (hc *HTTPCache) store(key string, resp *http.Response) *Response {
+	etag, modified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && modified == "" {
+		return newResponse(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxHTTPCacheEntry+1))
+	if err != nil || len(body) > MaxHTTPCacheEntry {
+		// Too large (or broken): hand it on unread and uncached
+		r := newResponse(resp)
+		r.body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return r
+	}
+	resp.Body.Close()
+
+	entry := httpCacheEntry{
+		ETag:         etag,
+		LastModified: modified,
+		Header:       http.Header{"Content-Type": resp.Header.Values("Content-Type")},
+		Body:         body,
+	}
+	if err := hc.write(key, entry); err == nil {
+		hc.prune()
+	}
+
+	r := newResponse(resp)
+	r.body = io.NopCloser(bytes.NewReader(body))
+	return r
+}
+
+// write stores an entry, replacing the file whole so readers never see
+// half of one.
+func (hc *HTTPCache) write(key string, entry httpCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hc.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(hc.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(hc.dir, key))
+}
+
+// prune drops the least recently used entries while the cache is over
+// its size.
+func (hc *HTTPCache) prune() {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	dirEntries, err := os.ReadDir(hc.dir)
+	if err != nil {
+		return
+	}
+	var files []os.FileInfo
+	var total int64
+	for _, e := range dirEntries {
+		if info, err := e.Info(); err == nil && filepath.Ext(e.Name()) == ".json" {
+			files = append(files, info)
+			total += info.Size()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, f := range files {
+		if total <= hc.maxSize {
+			break
+		}
+		if os.Remove(filepath.Join(hc.dir, f.Name())) == nil {
+			total -= f.Size()
+		}
+	}
+}
+
+// response returns the entry as the response to a request.
+func (e *httpCacheEntry) response() *Response {
+	return &Response{
+		StatusCode: http.StatusOK,
+		Header:     e.Header,
+		Cached:     true,
+		body:       io.NopCloser(bytes.NewReader(e.Body)),
+	}
+}
+
+// readCloser reads from one reader and closes another.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,22 @@
+// internal/fetch/log.go
+
+package fetch
+
+import "log/slog"
+
+// discardLogger is the logger of clients and registries given none.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// orDiscard returns l, or a logger that drops everything if l is nil.
+func orDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	return l
+}
+
+// loggerSetter is implemented by providers that can log, as those
+// embedding BaseProvider do.
+type loggerSetter interface {
+	SetLogger(l *slog.Logger)
+}
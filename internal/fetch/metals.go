@@ -6,6 +6,7 @@ import (
 	"context"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -114,12 +115,17 @@ func (p *GoldAPIProvider) FetchRates(ctx context.Context) (*RatesResult, error)
 
 	// GoldAPI requires separate requests per metal
 	for _, metal := range []string{"XAU", "XAG", "XPT", "XPD"} {
-		price, err := p.fetchMetal(ctx, apiKey, metal)
+		data, err := p.fetchMetal(ctx, apiKey, metal)
 		if err != nil {
 			// Continue with other metals on error
 			continue
 		}
-		result.AddRate(metal, price)
+		result.AddRate(metal, data.Price)
+		if metal == "XAU" {
+			for karat, price := range data.karatPrices() {
+				result.AddRate(goldKaratCode(karat), price)
+			}
+		}
 	}
 
 	if result.IsEmpty() {
@@ -129,35 +135,35 @@ func (p *GoldAPIProvider) FetchRates(ctx context.Context) (*RatesResult, error)
 	return result, nil
 }
 
-// fetchMetal fetches a single metal price.
-func (p *GoldAPIProvider) fetchMetal(ctx context.Context, apiKey, metal string) (float64, error) {
+// fetchMetal fetches a single metal's prices.
+func (p *GoldAPIProvider) fetchMetal(ctx context.Context, apiKey, metal string) (*goldAPIResponse, error) {
 	url := p.baseURL + "/" + metal + "/USD"
 
 	// Create custom request with API key header
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	req.Header.Set("x-access-token", apiKey)
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := p.Client().DoRaw(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return 0, ErrRequestFailed
+		return nil, ErrRequestFailed
 	}
 
 	var data goldAPIResponse
 	response := newResponse(resp)
 	if err := response.JSON(&data); err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return data.Price, nil
+	return &data, nil
 }
 
 // APIKey returns the API key from environment.
@@ -192,6 +198,27 @@ type goldAPIResponse struct {
 	PriceGram10K   float64 `json:"price_gram_10k"`
 }
 
+// karatPrices returns the USD prices of a gram of gold by purity, of
+// those given.
+func (r *goldAPIResponse) karatPrices() map[int]float64 {
+	prices := make(map[int]float64)
+	for karat, price := range map[int]float64{
+		24: r.PriceGram24K, 22: r.PriceGram22K, 21: r.PriceGram21K, 20: r.PriceGram20K,
+		18: r.PriceGram18K, 16: r.PriceGram16K, 14: r.PriceGram14K, 10: r.PriceGram10K,
+	} {
+		if price > 0 {
+			prices[karat] = price
+		}
+	}
+	return prices
+}
+
+// goldKaratCode returns the rate code of a gram of gold of a purity, as
+// the rate cache knows it: "XAU18K".
+func goldKaratCode(karat int) string {
+	return "XAU" + strconv.Itoa(karat) + "K"
+}
+
 // ════════════════════════════════════════════════════════════════
 // METAL PRICE API PROVIDER (Free tier with API key)
 // ════════════════════════════════════════════════════════════════
@@ -0,0 +1,326 @@
+// internal/fetch/official.go
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Official providers publish central bank reference rates, once a working
+// day, against their own currency. They are rebased to USD like the
+// other fiat providers' rates.
+
+// officialLookback is how many days before a date are searched for the
+// reference rates in force on it, covering weekends and bank holidays.
+const officialLookback = 7
+
+// ════════════════════════════════════════════════════════════════
+// ECB PROVIDER (European Central Bank euro reference rates)
+// ════════════════════════════════════════════════════════════════
+
+const (
+	ecbName    = "ecb"
+	ecbBaseURL = "https://www.ecb.europa.eu/stats/eurofxref"
+)
+
+// ecbRecentDays is the span of the ECB's shorter history feed; older
+// dates need the full one.
+const ecbRecentDays = 90
+
+// ECBProvider fetches the euro foreign exchange reference rates the
+// European Central Bank publishes at around 16:00 CET each working day.
+type ECBProvider struct {
+	*BaseProvider
+	baseURL string
+}
+
+// NewECBProvider creates a new ECB provider.
+func NewECBProvider() *ECBProvider {
+	base := NewBaseProvider(ecbName, ProviderTypeFiat)
+	base.SetRequireKey(false)
+
+	return &ECBProvider{
+		BaseProvider: base,
+		baseURL:      ecbBaseURL,
+	}
+}
+
+// FetchRates fetches the latest reference rates.
+func (p *ECBProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
+	return p.fetch(ctx, p.baseURL+"/eurofxref-daily.xml", time.Time{})
+}
+
+// FetchRatesAt fetches the reference rates published on date, or on the
+// last working day before it.
+func (p *ECBProvider) FetchRatesAt(ctx context.Context, date time.Time) (*RatesResult, error) {
+	url := p.baseURL + "/eurofxref-hist-90d.xml"
+	if time.Since(date) > (ecbRecentDays-officialLookback)*24*time.Hour {
+		url = p.baseURL + "/eurofxref-hist.xml"
+	}
+	return p.fetch(ctx, url, date)
+}
+
+// fetch fetches a feed and returns its latest day's rates on or before
+// date, or its latest day's if date is zero.
+func (p *ECBProvider) fetch(ctx context.Context, url string, date time.Time) (*RatesResult, error) {
+	var resp ecbResponse
+	if err := p.Client().GetXML(ctx, url, &resp); err != nil {
+		return nil, p.WrapError(err)
+	}
+
+	var latest *ecbDay
+	var latestTime time.Time
+	for i, day := range resp.Days {
+		t, err := time.Parse("2006-01-02", day.Time)
+		if err != nil || (!date.IsZero() && t.After(date)) || !t.After(latestTime) {
+			continue
+		}
+		latest, latestTime = &resp.Days[i], t
+	}
+	if latest == nil {
+		return nil, p.WrapError(ErrNotFound)
+	}
+
+	perEUR := map[string]float64{"EUR": 1}
+	for _, r := range latest.Rates {
+		perEUR[strings.ToUpper(r.Currency)] = r.Rate
+	}
+	return usdResult(p.Name(), url, latestTime, "EUR", perEUR)
+}
+
+// ecbResponse is the eurofxref feed: a day, or many, of rates per euro.
+type ecbResponse struct {
+	Days []ecbDay `xml:"Cube>Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string  `xml:"currency,attr"`
+	Rate     float64 `xml:"rate,attr"`
+}
+
+// ════════════════════════════════════════════════════════════════
+// BOE PROVIDER (Bank of England spot rates)
+// ════════════════════════════════════════════════════════════════
+
+const (
+	boeName    = "boe"
+	boeBaseURL = "https://www.bankofengland.co.uk/boeapps/database/_iadb-fromshowcolumns.asp"
+)
+
+// boeSeries maps the Bank of England's daily spot rate series (units of a
+// currency per pound) to currency codes.
+var boeSeries = map[string]string{
+	"XUDLUSS": "USD",
+	"XUDLERS": "EUR",
+	"XUDLJYS": "JPY",
+	"XUDLSFS": "CHF",
+	"XUDLCDS": "CAD",
+	"XUDLADS": "AUD",
+	"XUDLNDS": "NZD",
+	"XUDLSKS": "SEK",
+	"XUDLNKS": "NOK",
+	"XUDLDKS": "DKK",
+	"XUDLHDS": "HKD",
+	"XUDLSGS": "SGD",
+	"XUDLSRS": "SAR",
+	"XUDLZRS": "ZAR",
+}
+
+// BoEProvider fetches the Bank of England's daily spot exchange rates
+// against sterling, recorded at 16:00 London time each working day.
+type BoEProvider struct {
+	*BaseProvider
+	baseURL string
+}
+
+// NewBoEProvider creates a new Bank of England provider.
+func NewBoEProvider() *BoEProvider {
+	base := NewBaseProvider(boeName, ProviderTypeFiat)
+	base.SetRequireKey(false)
+
+	return &BoEProvider{
+		BaseProvider: base,
+		baseURL:      boeBaseURL,
+	}
+}
+
+// FetchRates fetches the latest spot rates.
+func (p *BoEProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
+	return p.FetchRatesAt(ctx, time.Now())
+}
+
+// FetchRatesAt fetches the spot rates recorded on date, or on the last
+// working day before it.
+func (p *BoEProvider) FetchRatesAt(ctx context.Context, date time.Time) (*RatesResult, error) {
+	url := p.buildURL(date.AddDate(0, 0, -officialLookback), date)
+
+	resp, err := p.Client().Get(ctx, url)
+	if err != nil {
+		return nil, p.WrapError(err)
+	}
+	defer resp.Close()
+
+	body, err := resp.Bytes()
+	if err != nil {
+		return nil, p.WrapError(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil, p.WrapError(ErrInvalidResponse)
+	}
+
+	// Rows run oldest first; a series may miss a day, so each keeps its
+	// latest value
+	header := records[0]
+	perGBP := map[string]float64{"GBP": 1}
+	var latest time.Time
+	for _, row := range records[1:] {
+		day, err := time.Parse("02 Jan 2006", strings.TrimSpace(row[0]))
+		if err != nil {
+			continue
+		}
+		latest = day
+		for i := 1; i < len(row) && i < len(header); i++ {
+			code, ok := boeSeries[strings.TrimSpace(header[i])]
+			if !ok {
+				continue
+			}
+			if rate, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64); err == nil && rate > 0 {
+				perGBP[code] = rate
+			}
+		}
+	}
+	if latest.IsZero() {
+		return nil, p.WrapError(ErrNotFound)
+	}
+	return usdResult(p.Name(), url, latest, "GBP", perGBP)
+}
+
+// buildURL returns the URL of the spot rates CSV between two dates.
+func (p *BoEProvider) buildURL(from, to time.Time) string {
+	series := make([]string, 0, len(boeSeries))
+	for code := range boeSeries {
+		series = append(series, code)
+	}
+	return fmt.Sprintf("%s?csv.x=yes&Datefrom=%s&Dateto=%s&SeriesCodes=%s&CSVF=TN&UsingCodes=Y&VPD=Y&VFD=N",
+		p.baseURL, from.Format("02/Jan/2006"), to.Format("02/Jan/2006"), strings.Join(series, ","))
+}
+
+// ════════════════════════════════════════════════════════════════
+// CBRT PROVIDER (Central Bank of the Republic of Türkiye)
+// ════════════════════════════════════════════════════════════════
+
+const (
+	cbrtName    = "cbrt"
+	cbrtBaseURL = "https://www.tcmb.gov.tr/kurlar"
+)
+
+// CBRTProvider fetches the indicative exchange rates the Central Bank of
+// the Republic of Türkiye publishes at 15:30 Istanbul time each working
+// day, using their forex buying rates.
+type CBRTProvider struct {
+	*BaseProvider
+	baseURL string
+}
+
+// NewCBRTProvider creates a new CBRT provider.
+func NewCBRTProvider() *CBRTProvider {
+	base := NewBaseProvider(cbrtName, ProviderTypeFiat)
+	base.SetRequireKey(false)
+
+	return &CBRTProvider{
+		BaseProvider: base,
+		baseURL:      cbrtBaseURL,
+	}
+}
+
+// FetchRates fetches the latest indicative rates.
+func (p *CBRTProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
+	return p.fetch(ctx, p.baseURL+"/today.xml")
+}
+
+// FetchRatesAt fetches the indicative rates published on date, or on the
+// last working day before it. Each day is its own file, missing on days
+// without rates.
+func (p *CBRTProvider) FetchRatesAt(ctx context.Context, date time.Time) (*RatesResult, error) {
+	var lastErr error
+	for i := range officialLookback {
+		day := date.AddDate(0, 0, -i)
+		url := p.baseURL + "/" + day.Format("200601") + "/" + day.Format("02012006") + ".xml"
+		result, err := p.fetch(ctx, url)
+		if !errors.Is(err, ErrNotFound) {
+			return result, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// fetch fetches a day's rates.
+func (p *CBRTProvider) fetch(ctx context.Context, url string) (*RatesResult, error) {
+	var resp cbrtResponse
+	if err := p.Client().GetXML(ctx, url, &resp); err != nil {
+		return nil, p.WrapError(err)
+	}
+
+	perTRY := map[string]float64{"TRY": 1}
+	for _, c := range resp.Currencies {
+		if c.ForexBuying > 0 && c.Unit > 0 {
+			perTRY[strings.ToUpper(c.Code)] = c.Unit / c.ForexBuying
+		}
+	}
+
+	day, err := time.Parse("01/02/2006", resp.Date)
+	if err != nil {
+		day = time.Now()
+	}
+	return usdResult(p.Name(), url, day, "TRY", perTRY)
+}
+
+// cbrtResponse is a day's rates: TRY per Unit of each currency.
+type cbrtResponse struct {
+	Date       string         `xml:"Date,attr"` // MM/DD/YYYY
+	Currencies []cbrtCurrency `xml:"Currency"`
+}
+
+type cbrtCurrency struct {
+	Code        string  `xml:"CurrencyCode,attr"`
+	Unit        float64 `xml:"Unit"`
+	ForexBuying float64 `xml:"ForexBuying"`
+}
+
+// ════════════════════════════════════════════════════════════════
+// HELPERS
+// ════════════════════════════════════════════════════════════════
+
+// usdResult returns fiat rates published against base (units of each code
+// per base, base itself included), rebased to USD.
+func usdResult(provider, url string, day time.Time, base string, perBase map[string]float64) (*RatesResult, error) {
+	usd := perBase["USD"]
+	if usd <= 0 {
+		return nil, NewProviderError(provider, ErrInvalidResponse)
+	}
+
+	result := NewRatesResult(provider, ProviderTypeFiat).
+		SetBase("USD").
+		SetSource(url).
+		SetTimestamp(day)
+	for code, rate := range perBase {
+		if rate > 0 {
+			result.AddRate(code, rate/usd)
+		}
+	}
+	return result, nil
+}
@@ -4,6 +4,8 @@ package fetch
 
 import (
 	"context"
+	"log/slog"
+	"os"
 	"time"
 )
 
@@ -24,6 +26,7 @@ type Provider interface {
 	//   - Fiat: base currency to rates (e.g., {"EUR": 0.92, "GBP": 0.79})
 	//   - Crypto: symbol to USD price (e.g., {"BTC": 95000, "ETH": 3500})
 	//   - Metal: symbol to USD price per oz (e.g., {"XAU": 2650, "XAG": 31.5})
+	//   - Commodity: symbol to USD price per unit (e.g., {"WTI": 75, "NATGAS": 3.1})
 	FetchRates(ctx context.Context) (*RatesResult, error)
 
 	// IsAvailable checks if the provider is currently available.
@@ -31,6 +34,24 @@ type Provider interface {
 	IsAvailable() bool
 }
 
+// HistoricalProvider is a provider that can also fetch past rates.
+type HistoricalProvider interface {
+	Provider
+
+	// FetchRatesAt fetches rates as they were on the given date.
+	FetchRatesAt(ctx context.Context, date time.Time) (*RatesResult, error)
+}
+
+// PriceHistoryProvider is a provider that can fetch an asset's past
+// price, one asset at a time, for APIs that keep history per asset.
+type PriceHistoryProvider interface {
+	Provider
+
+	// FetchPriceAt fetches the USD price of symbol on the given date, as
+	// a result holding that one rate.
+	FetchPriceAt(ctx context.Context, symbol string, date time.Time) (*RatesResult, error)
+}
+
 // ProviderType identifies the category of a provider.
 type ProviderType int
 
@@ -38,8 +59,8 @@ const (
 	ProviderTypeFiat ProviderType = iota
 	ProviderTypeCrypto
 	ProviderTypeMetal
-	ProviderTypeStock     // Future
-	ProviderTypeCommodity // Future
+	ProviderTypeStock // Future
+	ProviderTypeCommodity
 )
 
 // String returns the provider type name.
@@ -60,6 +81,17 @@ func (t ProviderType) String() string {
 	}
 }
 
+// ParseProviderType returns the provider type named s ("fiat", "crypto",
+// "metal", ...).
+func ParseProviderType(s string) (ProviderType, bool) {
+	for _, t := range []ProviderType{ProviderTypeFiat, ProviderTypeCrypto, ProviderTypeMetal, ProviderTypeStock, ProviderTypeCommodity} {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
 // ════════════════════════════════════════════════════════════════
 // RATES RESULT
 // ════════════════════════════════════════════════════════════════
@@ -79,6 +111,8 @@ type RatesResult struct {
 	// For fiat: 1 BaseCurrency = X target (e.g., {"EUR": 0.92} means 1 USD = 0.92 EUR)
 	// For crypto: 1 token = X USD (e.g., {"BTC": 95000} means 1 BTC = 95000 USD)
 	// For metal: 1 oz = X USD (e.g., {"XAU": 2650} means 1 oz gold = 2650 USD)
+	//   and for gold by purity 1 g = X USD (e.g., {"XAU18K": 64} means 1 g of 18k gold = 64 USD)
+	// For commodity: 1 unit = X USD (e.g., {"WTI": 75} means 1 barrel = 75 USD)
 	Rates map[string]float64
 
 	// Timestamp when the rates were fetched/updated
@@ -224,6 +258,11 @@ func (p *BaseProvider) SetClient(c *Client) {
 	}
 }
 
+// SetLogger sets the logger of the provider's HTTP client.
+func (p *BaseProvider) SetLogger(l *slog.Logger) {
+	p.client.logger = orDiscard(l)
+}
+
 // SetAPIKey sets the API key directly.
 func (p *BaseProvider) SetAPIKey(key string) {
 	p.apiKey = key
@@ -269,17 +308,7 @@ func (p *BaseProvider) WrapError(err error) error {
 // ════════════════════════════════════════════════════════════════
 
 // getEnv gets an environment variable value.
-// Implemented without os package to keep imports minimal in this file.
-// The actual implementation will use os.Getenv.
-var getEnv = defaultGetEnv
-
-// defaultGetEnv is a placeholder that will be replaced.
-// We'll use os.Getenv in the actual providers.
-func defaultGetEnv(key string) string {
-	// This will be properly implemented when we import "os"
-	// For now, return empty (providers will import os themselves)
-	return ""
-}
+var getEnv = os.Getenv
 
 // SetEnvFunc allows setting a custom env lookup function (useful for testing).
 func SetEnvFunc(fn func(string) string) {
@@ -4,7 +4,11 @@ package fetch
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
+	"time"
 )
 
 // ════════════════════════════════════════════════════════════════
@@ -15,12 +19,21 @@ import (
 type Registry struct {
 	mu        sync.RWMutex
 	providers map[ProviderType][]Provider
+	onFetch   []func(FetchAttempt)
+	logger    *slog.Logger
+	health    map[string]*health      // By provider name (see health.go)
+	disabled  map[string]bool         // Providers never to call, by name
+	sources   map[ProviderType]string // The only provider Fetch uses for a type, if any
 }
 
 // NewRegistry creates a new empty registry.
 func NewRegistry() *Registry {
 	return &Registry{
 		providers: make(map[ProviderType][]Provider),
+		logger:    discardLogger,
+		health:    make(map[string]*health),
+		disabled:  make(map[string]bool),
+		sources:   make(map[ProviderType]string),
 	}
 }
 
@@ -43,6 +56,11 @@ func DefaultRegistry() *Registry {
 		r.Register(p)
 	}
 
+	// Register commodity providers
+	for _, p := range NewCommodityProviders() {
+		r.Register(p)
+	}
+
 	return r
 }
 
@@ -54,6 +72,7 @@ func (r *Registry) Register(p Provider) {
 
 	typ := p.Type()
 	r.providers[typ] = append(r.providers[typ], p)
+	r.giveLogger(p)
 }
 
 // RegisterFirst adds a provider at the beginning (highest priority).
@@ -63,6 +82,91 @@ func (r *Registry) RegisterFirst(p Provider) {
 
 	typ := p.Type()
 	r.providers[typ] = append([]Provider{p}, r.providers[typ]...)
+	r.giveLogger(p)
+}
+
+// Prioritize moves the named providers to the front of their type's list,
+// in the order given. Unknown names are an error.
+func (r *Registry) Prioritize(names ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Later names go to the front first, so the first name ends up first
+	for i := len(names) - 1; i >= 0; i-- {
+		if !r.moveToFront(names[i]) {
+			return NewProviderError(names[i], ErrNotFound)
+		}
+	}
+	return nil
+}
+
+// moveToFront moves the named provider to the front of its type's list.
+func (r *Registry) moveToFront(name string) bool {
+	for typ, providers := range r.providers {
+		for i, p := range providers {
+			if p.Name() == name {
+				rest := append(providers[:i:i], providers[i+1:]...)
+				r.providers[typ] = append([]Provider{p}, rest...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Disable stops the named providers being called, even when asked for by
+// name. Unknown names are an error, and disable none.
+func (r *Registry) Disable(names ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		if !r.has(name) {
+			return NewProviderError(name, ErrNotFound)
+		}
+	}
+	for _, name := range names {
+		r.disabled[name] = true
+	}
+	return nil
+}
+
+// SetSource makes the named provider the only one Fetch uses for typ,
+// without falling back to others when it fails, as when official rates
+// must be used. An empty name goes back to trying every provider.
+func (r *Registry) SetSource(typ ProviderType, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == "" {
+		delete(r.sources, typ)
+		return nil
+	}
+	for _, p := range r.providers[typ] {
+		if p.Name() == name {
+			r.sources[typ] = name
+			return nil
+		}
+	}
+	if r.has(name) {
+		return NewProviderError(name, fmt.Errorf("not a %s provider", typ))
+	}
+	return NewProviderError(name, ErrNotFound)
+}
+
+// Source returns the only provider Fetch uses for typ, or "" if it
+// tries them all.
+func (r *Registry) Source(typ ProviderType) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sources[typ]
+}
+
+// IsDisabled reports whether the named provider has been disabled.
+func (r *Registry) IsDisabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.disabled[name]
 }
 
 // Providers returns all providers of a given type.
@@ -76,14 +180,15 @@ func (r *Registry) Providers(typ ProviderType) []Provider {
 	return result
 }
 
-// AvailableProviders returns providers that are currently available.
+// AvailableProviders returns providers that are currently available and
+// not disabled.
 func (r *Registry) AvailableProviders(typ ProviderType) []Provider {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var available []Provider
 	for _, p := range r.providers[typ] {
-		if p.IsAvailable() {
+		if p.IsAvailable() && !r.disabled[p.Name()] {
 			available = append(available, p)
 		}
 	}
@@ -107,25 +212,46 @@ func (r *Registry) AllProviders() []Provider {
 // ════════════════════════════════════════════════════════════════
 
 // Fetch fetches rates from the first available provider of the given type.
-// Falls back to subsequent providers on failure.
+// Falls back to subsequent providers on failure. Providers that have
+// failed repeatedly are skipped until their cooldown ends (see health.go).
+// If the type has a source (see SetSource), only it is used.
 func (r *Registry) Fetch(ctx context.Context, typ ProviderType) (*RatesResult, error) {
+	if name := r.Source(typ); name != "" {
+		return r.FetchWithProvider(ctx, name)
+	}
+
 	providers := r.AvailableProviders(typ)
 	if len(providers) == 0 {
+		if len(r.Providers(typ)) > 0 && r.allDisabled(typ) {
+			return nil, NewProviderError("registry", ErrDisabled)
+		}
 		return nil, NewProviderError("registry", ErrNotFound)
 	}
 
 	var lastErr error
-	for _, p := range providers {
-		result, err := p.FetchRates(ctx)
+	var skipped []string
+	for i, p := range providers {
+		if r.skipping(p) {
+			skipped = append(skipped, p.Name())
+			continue
+		}
+		result, err := r.fetchFrom(ctx, p)
 		if err == nil && !result.IsEmpty() {
 			return result, nil
 		}
 		lastErr = err
+		if i+1 < len(providers) {
+			r.log().Info("falling back to next rate provider",
+				"type", typ.String(), "failed", p.Name(), "next", providers[i+1].Name())
+		}
 	}
 
 	if lastErr != nil {
 		return nil, lastErr
 	}
+	if len(skipped) > 0 {
+		return nil, NewProviderError(strings.Join(skipped, ", "), ErrCircuitOpen)
+	}
 	return nil, NewProviderError("registry", ErrRequestFailed)
 }
 
@@ -144,36 +270,39 @@ func (r *Registry) FetchMetal(ctx context.Context) (*RatesResult, error) {
 	return r.Fetch(ctx, ProviderTypeMetal)
 }
 
+// FetchCommodity fetches commodity prices: oil, gas and base metals.
+func (r *Registry) FetchCommodity(ctx context.Context) (*RatesResult, error) {
+	return r.Fetch(ctx, ProviderTypeCommodity)
+}
+
 // FetchAll fetches rates from all provider types and merges results.
 func (r *Registry) FetchAll(ctx context.Context) (*RatesResult, error) {
 	result := NewRatesResult("combined", ProviderTypeFiat).
 		SetBase("USD")
 
-	var lastErr error
-	var fetched int
-
-	// Fetch fiat
-	if fiat, err := r.FetchFiat(ctx); err == nil {
-		result.Merge(fiat)
-		fetched++
-	} else {
-		lastErr = err
-	}
-
-	// Fetch crypto
-	if crypto, err := r.FetchCrypto(ctx); err == nil {
-		result.Merge(crypto)
-		fetched++
-	} else {
-		lastErr = err
+	// Fetch fiat, crypto, metals and commodities at the same time
+	types := []ProviderType{ProviderTypeFiat, ProviderTypeCrypto, ProviderTypeMetal, ProviderTypeCommodity}
+	results := make([]*RatesResult, len(types))
+	errs := make([]error, len(types))
+	var wg sync.WaitGroup
+	for i, typ := range types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = r.Fetch(ctx, typ)
+		}()
 	}
+	wg.Wait()
 
-	// Fetch metals
-	if metals, err := r.FetchMetal(ctx); err == nil {
-		result.Merge(metals)
+	var lastErr error
+	var fetched int
+	for i, err := range errs {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		result.Merge(results[i])
 		fetched++
-	} else {
-		lastErr = err
 	}
 
 	// Return error only if nothing was fetched
@@ -186,21 +315,221 @@ func (r *Registry) FetchAll(ctx context.Context) (*RatesResult, error) {
 
 // FetchWithProvider fetches rates using a specific provider by name.
 func (r *Registry) FetchWithProvider(ctx context.Context, name string) (*RatesResult, error) {
+	for _, p := range r.AllProviders() {
+		if p.Name() == name {
+			if r.IsDisabled(name) {
+				return nil, NewProviderError(name, ErrDisabled)
+			}
+			if !p.IsAvailable() {
+				return nil, NewProviderError(name, ErrUnauthorized)
+			}
+			return r.fetchFrom(ctx, p)
+		}
+	}
+
+	return nil, NewProviderError(name, ErrNotFound)
+}
+
+// FetchAt fetches fiat rates as of date from the named provider, or when
+// name is empty from the first available provider that keeps history.
+func (r *Registry) FetchAt(ctx context.Context, name string, date time.Time) (*RatesResult, error) {
+	var lastErr error = NewProviderError("registry", ErrNoHistory)
+	for _, p := range r.AllProviders() {
+		if name != "" && p.Name() != name {
+			continue
+		}
+		if r.IsDisabled(p.Name()) {
+			if name != "" {
+				return nil, NewProviderError(name, ErrDisabled)
+			}
+			continue
+		}
+		hp, ok := p.(HistoricalProvider)
+		if !ok {
+			if name != "" {
+				return nil, NewProviderError(name, ErrNoHistory)
+			}
+			continue
+		}
+		if !hp.IsAvailable() {
+			lastErr = NewProviderError(p.Name(), ErrUnauthorized)
+			continue
+		}
+
+		start := r.starting(ctx, p)
+		result, err := hp.FetchRatesAt(ctx, date)
+		r.fetched(ctx, p, start, result, err)
+		if err == nil && !result.IsEmpty() {
+			return result, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	if name != "" && !r.HasProvider(name) {
+		return nil, NewProviderError(name, ErrNotFound)
+	}
+	return nil, lastErr
+}
+
+// FetchPriceAt fetches the USD price of symbol as of date from the first
+// available provider of the given type that keeps prices by asset (see
+// PriceHistoryProvider), falling back to the next on failure.
+func (r *Registry) FetchPriceAt(ctx context.Context, typ ProviderType, symbol string, date time.Time) (*RatesResult, error) {
+	var lastErr error = NewProviderError("registry", ErrNoHistory)
+	for _, p := range r.AvailableProviders(typ) {
+		hp, ok := p.(PriceHistoryProvider)
+		if !ok || r.skipping(p) {
+			continue
+		}
+
+		start := r.starting(ctx, p)
+		result, err := hp.FetchPriceAt(ctx, symbol, date)
+		r.fetched(ctx, p, start, result, err)
+		if err == nil && !result.IsEmpty() {
+			return result, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+// ════════════════════════════════════════════════════════════════
+// FETCH REPORTING
+// ════════════════════════════════════════════════════════════════
+
+// FetchAttempt is the outcome of asking one provider for rates.
+type FetchAttempt struct {
+	Provider string
+	Type     ProviderType
+	Duration time.Duration
+	Err      error // Why no rates were fetched, or nil
+}
+
+// OnFetch registers a function called after each provider is asked for
+// rates, whether or not it had any, from the goroutine fetching.
+func (r *Registry) OnFetch(fn func(FetchAttempt)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onFetch = append(r.onFetch, fn)
+}
+
+// Progress is told about each provider a fetch asks for rates, as it
+// goes. Either function may be nil.
+type Progress struct {
+	Start func(provider string, typ ProviderType) // Before the provider is asked
+	Done  func(FetchAttempt)                      // Once it has answered
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context under which fetches report each
+// provider they ask to p, from the goroutine fetching. Unlike OnFetch,
+// only fetches made with the context are reported.
+func WithProgress(ctx context.Context, p Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// progressOf returns the Progress carried by ctx, if any.
+func progressOf(ctx context.Context) (Progress, bool) {
+	p, ok := ctx.Value(progressKey{}).(Progress)
+	return p, ok
+}
+
+// fetchFrom fetches current rates from p, reporting the attempt.
+func (r *Registry) fetchFrom(ctx context.Context, p Provider) (*RatesResult, error) {
+	start := r.starting(ctx, p)
+	result, err := p.FetchRates(ctx)
+	r.fetched(ctx, p, start, result, err)
+	return result, err
+}
+
+// starting reports that p is about to be asked for rates to ctx's
+// Progress, and returns the time.
+func (r *Registry) starting(ctx context.Context, p Provider) time.Time {
+	if progress, ok := progressOf(ctx); ok && progress.Start != nil {
+		progress.Start(p.Name(), p.Type())
+	}
+	return time.Now()
+}
+
+// fetched logs an attempt started at start and reports it to the OnFetch
+// functions and ctx's Progress. An attempt returning no rates and no
+// error counts as failed.
+func (r *Registry) fetched(ctx context.Context, p Provider, start time.Time, result *RatesResult, err error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	hooks, logger := r.onFetch, r.logger
+	r.mu.RUnlock()
+
+	if err == nil && (result == nil || result.IsEmpty()) {
+		err = NewProviderError(p.Name(), ErrInvalidResponse)
+	}
+	attempt := FetchAttempt{
+		Provider: p.Name(),
+		Type:     p.Type(),
+		Duration: time.Since(start),
+		Err:      err,
+	}
+
+	before, after := r.recordHealth(p, err)
+	if err != nil {
+		logger.Warn("rate provider failed", "provider", attempt.Provider,
+			"type", attempt.Type.String(), "duration", attempt.Duration, "err", err)
+	} else {
+		logger.Debug("fetched rates", "provider", attempt.Provider,
+			"type", attempt.Type.String(), "duration", attempt.Duration, "count", result.Count())
+	}
+	switch {
+	case after == CircuitOpen && before != CircuitOpen:
+		logger.Warn("skipping rate provider after repeated failures", "provider", attempt.Provider,
+			"until", r.retryAt(p))
+	case after == CircuitClosed && before == CircuitHalfOpen:
+		logger.Info("rate provider recovered", "provider", attempt.Provider)
+	}
+	for _, fn := range hooks {
+		fn(attempt)
+	}
+	if progress, ok := progressOf(ctx); ok && progress.Done != nil {
+		progress.Done(attempt)
+	}
+}
+
+// ════════════════════════════════════════════════════════════════
+// LOGGING
+// ════════════════════════════════════════════════════════════════
 
+// SetLogger logs failed and successful fetches, and falling back from one
+// provider to the next, to l, and gives l to the registered providers
+// (and those registered later) for logging their requests. nil turns
+// logging off.
+func (r *Registry) SetLogger(l *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logger = orDiscard(l)
 	for _, providers := range r.providers {
 		for _, p := range providers {
-			if p.Name() == name {
-				if !p.IsAvailable() {
-					return nil, NewProviderError(name, ErrUnauthorized)
-				}
-				return p.FetchRates(ctx)
-			}
+			r.giveLogger(p)
 		}
 	}
+}
 
-	return nil, NewProviderError(name, ErrNotFound)
+// log returns the registry's logger.
+func (r *Registry) log() *slog.Logger {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.logger
+}
+
+// giveLogger gives p the registry's logger, if it can log.
+// Callers must hold mu.
+func (r *Registry) giveLogger(p Provider) {
+	if ls, ok := p.(loggerSetter); ok {
+		ls.SetLogger(r.logger.With("provider", p.Name()))
+	}
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -212,6 +541,7 @@ type ProviderInfo struct {
 	Name      string
 	Type      ProviderType
 	Available bool
+	Disabled  bool
 }
 
 // ListProviders returns information about all registered providers.
@@ -226,6 +556,7 @@ func (r *Registry) ListProviders() []ProviderInfo {
 				Name:      p.Name(),
 				Type:      p.Type(),
 				Available: p.IsAvailable(),
+				Disabled:  r.disabled[p.Name()],
 			})
 		}
 	}
@@ -236,7 +567,12 @@ func (r *Registry) ListProviders() []ProviderInfo {
 func (r *Registry) HasProvider(name string) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	return r.has(name)
+}
 
+// has reports whether the named provider is registered.
+// Callers must hold mu.
+func (r *Registry) has(name string) bool {
 	for _, providers := range r.providers {
 		for _, p := range providers {
 			if p.Name() == name {
@@ -247,6 +583,19 @@ func (r *Registry) HasProvider(name string) bool {
 	return false
 }
 
+// allDisabled reports whether every provider of typ is disabled.
+func (r *Registry) allDisabled(typ ProviderType) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.providers[typ] {
+		if !r.disabled[p.Name()] {
+			return false
+		}
+	}
+	return true
+}
+
 // ════════════════════════════════════════════════════════════════
 // DEFAULT REGISTRY
 // ════════════════════════════════════════════════════════════════
@@ -284,6 +633,11 @@ func FetchMetalRates(ctx context.Context) (*RatesResult, error) {
 	return Default().FetchMetal(ctx)
 }
 
+// FetchCommodityRates fetches commodity prices using the default registry.
+func FetchCommodityRates(ctx context.Context) (*RatesResult, error) {
+	return Default().FetchCommodity(ctx)
+}
+
 // FetchAllRates fetches all rates using the default registry.
 func FetchAllRates(ctx context.Context) (*RatesResult, error) {
 	return Default().FetchAll(ctx)
@@ -0,0 +1,127 @@
+// internal/fetch/registry_test.go
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider answers with rate after delay, or with err.
+type fakeProvider struct {
+	name  string
+	typ   ProviderType
+	delay time.Duration
+	rate  float64
+	err   error
+	calls atomic.Int32
+}
+
+func (p *fakeProvider) Name() string       { return p.name }
+func (p *fakeProvider) Type() ProviderType { return p.typ }
+func (p *fakeProvider) IsAvailable() bool  { return true }
+
+func (p *fakeProvider) FetchRates(ctx context.Context) (*RatesResult, error) {
+	p.calls.Add(1)
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return NewRatesResult(p.name, p.typ).AddRate("EUR", p.rate), nil
+}
+
+func newFake(name string, delay time.Duration, err error) *fakeProvider {
+	return &fakeProvider{name: name, typ: ProviderTypeFiat, delay: delay, rate: 0.92, err: err}
+}
+
+func TestFetchKeepsPriorityOrder(t *testing.T) {
+	r := NewRegistry()
+	r.Register(newFake("fast", time.Millisecond, nil))
+	r.Register(newFake("official", 50*time.Millisecond, nil))
+	if err := r.Prioritize("official"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := r.Fetch(context.Background(), ProviderTypeFiat)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if result.Provider != "official" {
+		t.Errorf("rates from %q, want the prioritized official", result.Provider)
+	}
+}
+
+func TestFetchFallsBackOnFailure(t *testing.T) {
+	r := NewRegistry()
+	r.Register(newFake("failing", time.Millisecond, errors.New("down")))
+	r.Register(newFake("backup", time.Millisecond, nil))
+
+	result, err := r.Fetch(context.Background(), ProviderTypeFiat)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if result.Provider != "backup" {
+		t.Errorf("rates from %q, want backup", result.Provider)
+	}
+}
+
+func TestFetchAllFailing(t *testing.T) {
+	r := NewRegistry()
+	r.Register(newFake("a", time.Millisecond, errors.New("down")))
+	r.Register(newFake("b", time.Millisecond, errors.New("down")))
+
+	if _, err := r.Fetch(context.Background(), ProviderTypeFiat); err == nil {
+		t.Error("Fetch succeeded with every provider failing")
+	}
+}
+
+func TestFetchAllAsksClassesAtOnce(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	r := NewRegistry()
+	for _, typ := range []ProviderType{ProviderTypeFiat, ProviderTypeCrypto, ProviderTypeMetal, ProviderTypeCommodity} {
+		p := newFake(typ.String(), delay, nil)
+		p.typ = typ
+		r.Register(p)
+	}
+
+	start := time.Now()
+	if _, err := r.FetchAll(context.Background()); err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 3*delay {
+		t.Errorf("FetchAll took %v for four classes of %v each", elapsed, delay)
+	}
+}
+
+func TestHalfOpenCircuitLetsOneProbeThrough(t *testing.T) {
+	r := NewRegistry()
+	p := newFake("flaky", 50*time.Millisecond, errors.New("still down"))
+	r.Register(p)
+	// Tripped, with the cooldown just over
+	r.health[p.name] = &health{streak: BreakerThreshold, cooldown: BreakerCooldown, openUntil: time.Now().Add(-time.Second)}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Fetch(context.Background(), ProviderTypeFiat)
+		}()
+	}
+	wg.Wait()
+
+	if n := p.calls.Load(); n != 1 {
+		t.Errorf("provider called %d times while half-open, want 1 probe", n)
+	}
+	if s := r.Status()[0]; s.State != CircuitOpen {
+		t.Errorf("state after the failed probe = %v, want open", s.State)
+	}
+}
@@ -0,0 +1,134 @@
+// internal/fetch/transport.go
+
+package fetch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Environment variables configuring every client's connections. Proxies
+// come from HTTP_PROXY, HTTPS_PROXY and NO_PROXY, as usual.
+const (
+	CABundleEnv    = "NUMIO_CA_BUNDLE"    // PEM file of CAs trusted besides the system's
+	TLSInsecureEnv = "NUMIO_TLS_INSECURE" // "1" or "true": don't verify certificates
+)
+
+// ════════════════════════════════════════════════════════════════
+// CLIENT OPTIONS
+// ════════════════════════════════════════════════════════════════
+
+// WithProxy sends requests through the proxy at u; nil connects
+// directly. Clients use HTTP_PROXY, HTTPS_PROXY and NO_PROXY unless
+// given a proxy.
+func WithProxy(u *url.URL) ClientOption {
+	return func(c *Client) {
+		if u == nil {
+			c.transport().Proxy = nil
+			return
+		}
+		c.transport().Proxy = http.ProxyURL(u)
+	}
+}
+
+// WithRootCAs trusts only the CAs in pool. Clients trust the system's
+// CAs and those in $NUMIO_CA_BUNDLE unless given a pool; see LoadCABundle.
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(c *Client) {
+		c.tlsConfig().RootCAs = pool
+		c.transportErr = nil
+	}
+}
+
+// WithInsecureSkipVerify turns certificate verification off, or back on.
+// Only for internal endpoints with certificates that can't be verified:
+// anyone in between can then read and change the rates.
+func WithInsecureSkipVerify(skip bool) ClientOption {
+	return func(c *Client) {
+		c.tlsConfig().InsecureSkipVerify = skip
+	}
+}
+
+// LoadCABundle returns the system's CAs with those in the PEM file at
+// path added.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates found", path)
+	}
+	return pool, nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// DEFAULT TRANSPORT
+// ════════════════════════════════════════════════════════════════
+
+var (
+	envTransport     *http.Transport
+	envTransportErr  error
+	envTransportOnce sync.Once
+)
+
+// defaultTransport returns the transport clients share unless configured
+// otherwise, set up from the environment, and the error of a CA bundle
+// that couldn't be loaded.
+func defaultTransport() (*http.Transport, error) {
+	envTransportOnce.Do(func() {
+		envTransport = http.DefaultTransport.(*http.Transport).Clone()
+		envTransport.Proxy = http.ProxyFromEnvironment
+
+		if path := os.Getenv(CABundleEnv); path != "" {
+			pool, err := LoadCABundle(path)
+			if err != nil {
+				envTransportErr = fmt.Errorf("%s: %w", CABundleEnv, err)
+			} else {
+				envTLSConfig().RootCAs = pool
+			}
+		}
+		switch os.Getenv(TLSInsecureEnv) {
+		case "1", "true":
+			envTLSConfig().InsecureSkipVerify = true
+		}
+	})
+	return envTransport, envTransportErr
+}
+
+// envTLSConfig returns the shared transport's TLS config, creating it.
+func envTLSConfig() *tls.Config {
+	if envTransport.TLSClientConfig == nil {
+		envTransport.TLSClientConfig = &tls.Config{}
+	}
+	return envTransport.TLSClientConfig
+}
+
+// transport returns the client's own transport for an option to change,
+// copying the shared one first.
+func (c *Client) transport() *http.Transport {
+	t := c.http.Transport.(*http.Transport)
+	if t == envTransport {
+		t = t.Clone()
+		c.http.Transport = t
+	}
+	return t
+}
+
+// tlsConfig returns the client's own TLS config for an option to change.
+func (c *Client) tlsConfig() *tls.Config {
+	t := c.transport()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	return t.TLSClientConfig
+}
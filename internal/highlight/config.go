@@ -0,0 +1,155 @@
+// internal/highlight/config.go
+
+package highlight
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// A theme file customizes a built-in theme:
+//
+//	name = "mine"
+//	base = "solarized"
+//
+//	[syntax]
+//	number = "#d33682"
+//	comment = "#586e75"
+//
+//	[ui]
+//	result = "#2aa198"
+//	status_bar = "#073642"
+
+// themeFile is the TOML layout of a theme file.
+type themeFile struct {
+	Name   string            `toml:"name"`
+	Base   string            `toml:"base"`
+	Syntax map[string]string `toml:"syntax"`
+	UI     map[string]string `toml:"ui"`
+}
+
+// ThemeConfigPath returns the path of the user's theme file.
+func ThemeConfigPath() string {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "numio", "theme.toml")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "theme.toml"
+	}
+
+	return filepath.Join(home, ".config", "numio", "theme.toml")
+}
+
+// LoadThemeFile loads a theme file. Colors it doesn't set come from its
+// base theme (default if unset).
+func LoadThemeFile(path string) (*Theme, error) {
+	var file themeFile
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		return nil, err
+	}
+
+	base := DefaultTheme()
+	if file.Base != "" {
+		t, ok := LookupTheme(file.Base)
+		if !ok {
+			return nil, fmt.Errorf("unknown base theme: %s", file.Base)
+		}
+		base = t
+	}
+
+	theme := base.Clone()
+	theme.Name = file.Name
+	if theme.Name == "" {
+		theme.Name = "custom"
+	}
+
+	for name, hex := range file.Syntax {
+		class, ok := ParseTokenClass(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown syntax class: %s", name)
+		}
+		theme.Colors[class] = NewColor(hex)
+	}
+
+	for name, hex := range file.UI {
+		c := theme.UI.field(name)
+		if c == nil {
+			return nil, fmt.Errorf("unknown ui color: %s", name)
+		}
+		*c = NewColor(hex)
+	}
+
+	return theme, nil
+}
+
+// LoadUserTheme loads and registers the user's theme file, if it exists.
+func LoadUserTheme() (*Theme, error) {
+	path := ThemeConfigPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	theme, err := LoadThemeFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	RegisterTheme(theme)
+	return theme, nil
+}
+
+// ParseTokenClass parses a token class name as returned by TokenClass.String.
+func ParseTokenClass(name string) (TokenClass, bool) {
+	for c := ClassNone; c <= ClassAssign; c++ {
+		if c.String() == name {
+			return c, true
+		}
+	}
+	return ClassNone, false
+}
+
+// field returns the UI color with the given theme file key.
+func (u *UIColors) field(name string) *Color {
+	switch name {
+	case "result":
+		return &u.Result
+	case "error":
+		return &u.Error
+	case "line_number":
+		return &u.LineNumber
+	case "non_text":
+		return &u.NonText
+	case "muted":
+		return &u.Muted
+	case "pending":
+		return &u.Pending
+	case "heading":
+		return &u.Heading
+	case "accent":
+		return &u.Accent
+	case "selection":
+		return &u.Selection
+	case "status_bar":
+		return &u.StatusBar
+	case "popup_text":
+		return &u.PopupText
+	case "popup_bg":
+		return &u.PopupBg
+	case "mode_text":
+		return &u.ModeText
+	case "mode_normal":
+		return &u.ModeNormal
+	case "mode_insert":
+		return &u.ModeInsert
+	case "mode_visual":
+		return &u.ModeVisual
+	case "mode_operator":
+		return &u.ModeOperator
+	default:
+		return nil
+	}
+}
@@ -5,14 +5,17 @@ package highlight
 import (
 	"strings"
 
-	"github.com/0xsj/numio/internal/lexer"
-	"github.com/0xsj/numio/internal/token"
+	"github.com/0xsj/numio/internal/parser"
 	"github.com/0xsj/numio/pkg/types"
 )
 
-// Highlighter applies syntax highlighting to numio expressions.
+// Highlighter applies syntax highlighting to numio expressions. Lines are
+// highlighted as the parser reads them, so a word is colored as a unit
+// only where it is one.
 type Highlighter struct {
-	theme *Theme
+	theme  *Theme
+	locale types.InputLocale
+	units  *types.UnitSet
 }
 
 // New creates a new Highlighter with the given theme.
@@ -45,6 +48,18 @@ func (h *Highlighter) SetTheme(theme *Theme) {
 	}
 }
 
+// SetLocale sets how numbers are written, as the engine reads them:
+// 1,234.56 or 1.234,56.
+func (h *Highlighter) SetLocale(locale types.InputLocale) {
+	h.locale = locale
+}
+
+// SetUnits sets the user-defined units read as units besides the built-in
+// ones.
+func (h *Highlighter) SetUnits(units *types.UnitSet) {
+	h.units = units
+}
+
 // ════════════════════════════════════════════════════════════════
 // HIGHLIGHTING
 // ════════════════════════════════════════════════════════════════
@@ -55,43 +70,7 @@ func (h *Highlighter) Highlight(input string) string {
 	if input == "" {
 		return ""
 	}
-
-	// Check for comment-only lines first
-	trimmed := strings.TrimSpace(input)
-	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
-		return h.theme.Render(ClassComment, input)
-	}
-
-	// Tokenize the input
-	tokens := lexer.Tokenize(input)
-
-	// Build highlighted output
-	var result strings.Builder
-	lastEnd := 0
-
-	for _, tok := range tokens {
-		if tok.Type == token.EOF {
-			break
-		}
-
-		// Preserve whitespace/characters between tokens
-		if tok.Pos > lastEnd {
-			result.WriteString(input[lastEnd:tok.Pos])
-		}
-
-		// Get token class and apply highlighting
-		class := h.classifyToken(tok)
-		result.WriteString(h.theme.Render(class, tok.Literal))
-
-		lastEnd = tok.Pos + len(tok.Literal)
-	}
-
-	// Append any remaining content
-	if lastEnd < len(input) {
-		result.WriteString(input[lastEnd:])
-	}
-
-	return result.String()
+	return h.RenderSpans(h.HighlightSpans(input))
 }
 
 // HighlightLine highlights a line, handling the cursor position.
@@ -114,134 +93,46 @@ func (h *Highlighter) HighlightLine(input string, cursorPos int) (before, cursor
 		return h.Highlight(input), "", ""
 	}
 
-	// Split at cursor and highlight each part
-	beforeText := input[:cursorPos]
-	cursorChar := string(input[cursorPos])
-	afterText := ""
-	if cursorPos+1 < len(input) {
-		afterText = input[cursorPos+1:]
+	// Split the highlighted line at the cursor
+	var b, a strings.Builder
+	for _, span := range h.HighlightSpans(input) {
+		switch {
+		case span.End <= cursorPos:
+			b.WriteString(h.theme.Render(span.Class, span.Text))
+		case span.Start > cursorPos:
+			a.WriteString(h.theme.Render(span.Class, span.Text))
+		default:
+			rel := cursorPos - span.Start
+			b.WriteString(h.theme.Render(span.Class, span.Text[:rel]))
+			cursor = span.Text[rel : rel+1]
+			a.WriteString(h.theme.Render(span.Class, span.Text[rel+1:]))
+		}
 	}
-
-	return h.Highlight(beforeText), cursorChar, h.Highlight(afterText)
+	return b.String(), cursor, a.String()
 }
 
 // ════════════════════════════════════════════════════════════════
 // TOKEN CLASSIFICATION
 // ════════════════════════════════════════════════════════════════
 
-// classifyToken determines the TokenClass for a given token.
-func (h *Highlighter) classifyToken(tok token.Token) TokenClass {
-	switch tok.Type {
-	// Numbers and percentages
-	case token.NUMBER:
-		return ClassNumber
-	case token.PERCENT:
-		return ClassPercent
-
-	// Operators
-	case token.PLUS, token.MINUS, token.STAR, token.SLASH, token.CARET, token.POWER:
-		return ClassOperator
-
-	// Parentheses
-	case token.LPAREN, token.RPAREN:
-		return ClassParen
-
-	// Assignment
-	case token.EQUALS:
-		return ClassAssign
-
-	// Keywords
-	case token.IN, token.OF:
-		return ClassKeyword
-
-	// Currency symbols
-	case token.DOLLAR, token.EURO, token.POUND, token.YEN, token.BITCOIN, token.CURRENCY:
-		return ClassCurrency
-
-	// Comments
-	case token.COMMENT:
-		return ClassComment
-
-	// Identifiers - need further classification
-	case token.IDENTIFIER:
-		return h.classifyIdentifier(tok.Literal)
-
-	// Comma
-	case token.COMMA:
-		return ClassOperator
-
-	default:
-		return ClassNone
-	}
-}
-
-// classifyIdentifier determines if an identifier is a function, currency, unit, etc.
-func (h *Highlighter) classifyIdentifier(name string) TokenClass {
-	lower := strings.ToLower(name)
-
-	// Check if it's a known function
-	if isFunction(lower) {
-		return ClassFunction
-	}
-
-	// Check if it's a currency code or name
-	if types.ParseCurrency(name) != nil {
-		return ClassCurrency
-	}
-
-	// Check if it's a crypto code or name
-	if types.ParseCrypto(name) != nil {
-		return ClassCrypto
-	}
-
-	// Check if it's a metal code or name
-	if types.ParseMetal(name) != nil {
-		return ClassMetal
-	}
-
-	// Check if it's a unit code or name
-	if types.ParseUnit(name) != nil {
-		return ClassUnit
-	}
-
-	// Default to identifier (variable)
-	return ClassIdentifier
-}
-
-// isFunction checks if a name is a known built-in function.
-func isFunction(name string) bool {
-	functions := map[string]bool{
-		// Aggregation
-		"sum":     true,
-		"avg":     true,
-		"average": true,
-		"mean":    true,
-		"min":     true,
-		"max":     true,
-		"count":   true,
-
-		// Math
-		"abs":   true,
-		"sqrt":  true,
-		"round": true,
-		"floor": true,
-		"ceil":  true,
-		"log":   true,
-		"log10": true,
-		"ln":    true,
-		"exp":   true,
-		"pow":   true,
-
-		// Trigonometry
-		"sin":  true,
-		"cos":  true,
-		"tan":  true,
-		"asin": true,
-		"acos": true,
-		"atan": true,
-	}
-
-	return functions[name]
+// roleClasses maps the role the parser gave a token to its class.
+var roleClasses = map[parser.Role]TokenClass{
+	parser.RoleNone:     ClassNone,
+	parser.RoleNumber:   ClassNumber,
+	parser.RolePercent:  ClassPercent,
+	parser.RoleOperator: ClassOperator,
+	parser.RoleParen:    ClassParen,
+	parser.RoleAssign:   ClassAssign,
+	parser.RoleKeyword:  ClassKeyword,
+	parser.RoleFunction: ClassFunction,
+	parser.RoleVariable: ClassIdentifier,
+	parser.RoleCurrency: ClassCurrency,
+	parser.RoleCrypto:   ClassCrypto,
+	parser.RoleMetal:    ClassMetal,
+	parser.RoleUnit:     ClassUnit,
+	parser.RoleLabel:    ClassComment, // Ignored, like a comment
+	parser.RoleComment:  ClassComment,
+	parser.RoleError:    ClassError,
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -256,14 +147,15 @@ type Span struct {
 	Class TokenClass // The token class for coloring
 }
 
-// HighlightSpans returns highlighting information as spans.
-// Useful for custom rendering or editors that need position info.
+// HighlightSpans returns highlighting information as spans, covering the
+// whole input. Useful for custom rendering or editors that need position
+// info.
 func (h *Highlighter) HighlightSpans(input string) []Span {
 	if input == "" {
 		return nil
 	}
 
-	// Check for comment-only lines
+	// Comment-only lines are skipped before parsing, as the engine does
 	trimmed := strings.TrimSpace(input)
 	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
 		return []Span{{
@@ -274,13 +166,13 @@ func (h *Highlighter) HighlightSpans(input string) []Span {
 		}}
 	}
 
-	tokens := lexer.Tokenize(input)
-	spans := make([]Span, 0, len(tokens))
+	tokens := parser.SpansWithUnits(input, h.locale, h.units)
+	spans := make([]Span, 0, 2*len(tokens)+1)
 	lastEnd := 0
 
 	for _, tok := range tokens {
-		if tok.Type == token.EOF {
-			break
+		if tok.Pos < lastEnd || tok.End > len(input) {
+			continue
 		}
 
 		// Add span for whitespace/gaps (as ClassNone)
@@ -293,17 +185,15 @@ func (h *Highlighter) HighlightSpans(input string) []Span {
 			})
 		}
 
-		// Add span for this token
-		class := h.classifyToken(tok)
-		end := tok.Pos + len(tok.Literal)
+		// Add span for this token, as written
 		spans = append(spans, Span{
 			Start: tok.Pos,
-			End:   end,
-			Text:  tok.Literal,
-			Class: class,
+			End:   tok.End,
+			Text:  tok.Text(input),
+			Class: roleClasses[tok.Role],
 		})
 
-		lastEnd = end
+		lastEnd = tok.End
 	}
 
 	// Add any remaining content
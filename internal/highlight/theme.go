@@ -2,12 +2,64 @@
 
 package highlight
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"sort"
+	"strings"
 
-// Theme defines colors for each token class.
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme defines colors for each token class and for the editor UI.
 type Theme struct {
 	Name   string
 	Colors map[TokenClass]Color
+	UI     UIColors
+}
+
+// UIColors are the colors of the editor around the highlighted text.
+type UIColors struct {
+	Result     Color // Evaluated results
+	Error      Color // Error markers and messages
+	LineNumber Color // Line numbers, file name
+	NonText    Color // "~" past the end of the document
+	Muted      Color // Hints, help descriptions
+	Pending    Color // Pending keys in the status bar
+	Heading    Color // Help sections, panel titles
+	Accent     Color // Help border and title, selected completion
+	Selection  Color // Visual selection background
+	StatusBar  Color // Status bar background
+	PopupText  Color // Completion popup text
+	PopupBg    Color // Completion popup background
+
+	// Mode badges in the status bar
+	ModeText     Color
+	ModeNormal   Color
+	ModeInsert   Color
+	ModeVisual   Color
+	ModeOperator Color
+}
+
+// DefaultUI returns the UI colors for dark themes.
+func DefaultUI() UIColors {
+	return UIColors{
+		Result:       Palette.Green,
+		Error:        Palette.Error,
+		LineNumber:   NewColor("#666"),
+		NonText:      NewColor("#444"),
+		Muted:        NewColor("#888"),
+		Pending:      Palette.Orange,
+		Heading:      Palette.Orange,
+		Accent:       Palette.Blue,
+		Selection:    NewColor("#3b3f5c"),
+		StatusBar:    NewColor("#1a1a2e"),
+		PopupText:    NewColor("#c9d1d9"),
+		PopupBg:      NewColor("#21262d"),
+		ModeText:     Palette.Black,
+		ModeNormal:   Palette.Blue,
+		ModeInsert:   Palette.Green,
+		ModeVisual:   Palette.Purple,
+		ModeOperator: Palette.Orange,
+	}
 }
 
 // Get returns the color for a token class, or a default if not found.
@@ -53,6 +105,7 @@ func DefaultTheme() *Theme {
 			ClassError:      Palette.Error,
 			ClassAssign:     Palette.Cyan,
 		},
+		UI: DefaultUI(),
 	}
 }
 
@@ -77,6 +130,25 @@ func DraculaTheme() *Theme {
 			ClassError:      NewColor("#ff5555"), // Red
 			ClassAssign:     NewColor("#ff79c6"), // Pink
 		},
+		UI: UIColors{
+			Result:       NewColor("#50fa7b"), // Green
+			Error:        NewColor("#ff5555"), // Red
+			LineNumber:   NewColor("#6272a4"), // Comment
+			NonText:      NewColor("#44475a"), // Current line
+			Muted:        NewColor("#6272a4"), // Comment
+			Pending:      NewColor("#ffb86c"), // Orange
+			Heading:      NewColor("#ffb86c"), // Orange
+			Accent:       NewColor("#bd93f9"), // Purple
+			Selection:    NewColor("#44475a"), // Selection
+			StatusBar:    NewColor("#21222c"), // Darker background
+			PopupText:    NewColor("#f8f8f2"), // Foreground
+			PopupBg:      NewColor("#343746"), // Lighter background
+			ModeText:     NewColor("#282a36"), // Background
+			ModeNormal:   NewColor("#bd93f9"), // Purple
+			ModeInsert:   NewColor("#50fa7b"), // Green
+			ModeVisual:   NewColor("#ff79c6"), // Pink
+			ModeOperator: NewColor("#ffb86c"), // Orange
+		},
 	}
 }
 
@@ -101,6 +173,7 @@ func MonokaiTheme() *Theme {
 			ClassError:      NewColor("#f92672"), // Pink/Red
 			ClassAssign:     NewColor("#f92672"), // Pink
 		},
+		UI: DefaultUI(),
 	}
 }
 
@@ -125,6 +198,7 @@ func GruvboxTheme() *Theme {
 			ClassError:      NewColor("#fb4934"), // Red
 			ClassAssign:     NewColor("#8ec07c"), // Aqua
 		},
+		UI: DefaultUI(),
 	}
 }
 
@@ -149,6 +223,68 @@ func LightTheme() *Theme {
 			ClassError:      NewColor("#cb2431"), // Red
 			ClassAssign:     NewColor("#d73a49"), // Red
 		},
+		UI: UIColors{
+			Result:       NewColor("#22863a"), // Green
+			Error:        NewColor("#cb2431"), // Red
+			LineNumber:   NewColor("#959da5"), // Gray
+			NonText:      NewColor("#d1d5da"), // Light gray
+			Muted:        NewColor("#6a737d"), // Gray
+			Pending:      NewColor("#e36209"), // Orange
+			Heading:      NewColor("#e36209"), // Orange
+			Accent:       NewColor("#005cc5"), // Blue
+			Selection:    NewColor("#c8e1ff"), // Light blue
+			StatusBar:    NewColor("#f6f8fa"), // Off-white
+			PopupText:    NewColor("#24292e"), // Dark gray
+			PopupBg:      NewColor("#e1e4e8"), // Light gray
+			ModeText:     NewColor("#ffffff"), // White
+			ModeNormal:   NewColor("#005cc5"), // Blue
+			ModeInsert:   NewColor("#22863a"), // Green
+			ModeVisual:   NewColor("#6f42c1"), // Purple
+			ModeOperator: NewColor("#e36209"), // Orange
+		},
+	}
+}
+
+// SolarizedTheme returns a Solarized dark theme.
+func SolarizedTheme() *Theme {
+	return &Theme{
+		Name: "solarized",
+		Colors: map[TokenClass]Color{
+			ClassNone:       NewColor("#839496"), // base0
+			ClassNumber:     NewColor("#d33682"), // Magenta
+			ClassPercent:    NewColor("#d33682"), // Magenta
+			ClassOperator:   NewColor("#859900"), // Green
+			ClassParen:      NewColor("#93a1a1"), // base1
+			ClassIdentifier: NewColor("#839496"), // base0
+			ClassKeyword:    NewColor("#859900"), // Green
+			ClassFunction:   NewColor("#268bd2"), // Blue
+			ClassCurrency:   NewColor("#2aa198"), // Cyan
+			ClassUnit:       NewColor("#b58900"), // Yellow
+			ClassCrypto:     NewColor("#cb4b16"), // Orange
+			ClassMetal:      NewColor("#b58900"), // Yellow
+			ClassComment:    NewColor("#586e75"), // base01
+			ClassError:      NewColor("#dc322f"), // Red
+			ClassAssign:     NewColor("#859900"), // Green
+		},
+		UI: UIColors{
+			Result:       NewColor("#2aa198"), // Cyan
+			Error:        NewColor("#dc322f"), // Red
+			LineNumber:   NewColor("#586e75"), // base01
+			NonText:      NewColor("#073642"), // base02
+			Muted:        NewColor("#657b83"), // base00
+			Pending:      NewColor("#cb4b16"), // Orange
+			Heading:      NewColor("#cb4b16"), // Orange
+			Accent:       NewColor("#268bd2"), // Blue
+			Selection:    NewColor("#073642"), // base02
+			StatusBar:    NewColor("#073642"), // base02
+			PopupText:    NewColor("#93a1a1"), // base1
+			PopupBg:      NewColor("#073642"), // base02
+			ModeText:     NewColor("#002b36"), // base03
+			ModeNormal:   NewColor("#268bd2"), // Blue
+			ModeInsert:   NewColor("#859900"), // Green
+			ModeVisual:   NewColor("#6c71c4"), // Violet
+			ModeOperator: NewColor("#cb4b16"), // Orange
+		},
 	}
 }
 
@@ -158,30 +294,56 @@ func LightTheme() *Theme {
 
 // builtinThemes holds all registered themes.
 var builtinThemes = map[string]func() *Theme{
-	"default": DefaultTheme,
-	"dracula": DraculaTheme,
-	"monokai": MonokaiTheme,
-	"gruvbox": GruvboxTheme,
-	"light":   LightTheme,
+	"default":   DefaultTheme,
+	"dark":      DefaultTheme,
+	"dracula":   DraculaTheme,
+	"monokai":   MonokaiTheme,
+	"gruvbox":   GruvboxTheme,
+	"light":     LightTheme,
+	"solarized": SolarizedTheme,
 }
 
 // GetTheme returns a theme by name, or the default theme if not found.
 func GetTheme(name string) *Theme {
-	if fn, ok := builtinThemes[name]; ok {
-		return fn()
+	if t, ok := LookupTheme(name); ok {
+		return t
 	}
 	return DefaultTheme()
 }
 
-// ThemeNames returns a list of all available theme names.
+// LookupTheme returns a theme by name.
+func LookupTheme(name string) (*Theme, bool) {
+	fn, ok := builtinThemes[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return fn(), true
+}
+
+// RegisterTheme makes a theme available by its name.
+func RegisterTheme(t *Theme) {
+	builtinThemes[strings.ToLower(t.Name)] = func() *Theme { return t.Clone() }
+}
+
+// ThemeNames returns a sorted list of all available theme names.
 func ThemeNames() []string {
 	names := make([]string, 0, len(builtinThemes))
 	for name := range builtinThemes {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
+// Clone returns a copy of the theme.
+func (t *Theme) Clone() *Theme {
+	colors := make(map[TokenClass]Color, len(t.Colors))
+	for k, v := range t.Colors {
+		colors[k] = v
+	}
+	return &Theme{Name: t.Name, Colors: colors, UI: t.UI}
+}
+
 // ════════════════════════════════════════════════════════════════
 // CUSTOM THEME BUILDER
 // ════════════════════════════════════════════════════════════════
@@ -198,6 +360,7 @@ func NewThemeBuilder(name string) *ThemeBuilder {
 		theme: &Theme{
 			Name:   name,
 			Colors: base.Colors,
+			UI:     base.UI,
 		},
 	}
 }
@@ -210,6 +373,7 @@ func (b *ThemeBuilder) From(base *Theme) *ThemeBuilder {
 		colors[k] = v
 	}
 	b.theme.Colors = colors
+	b.theme.UI = base.UI
 	return b
 }
 
@@ -0,0 +1,62 @@
+// internal/lexer/fuzz_test.go
+
+package lexer
+
+import (
+	"testing"
+
+	"github.com/0xsj/numio/internal/token"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// FuzzLexer checks that, for any input in either locale, tokenizing
+// ends with an EOF token, every token before it consumes input, tokens
+// span the input in order without overlapping, and a lexer reset onto
+// the input returns the same tokens again.
+func FuzzLexer(f *testing.F) {
+	for _, seed := range []string{
+		"", "1 + 2", "$100 in EUR", "1.234,56 €", "3 ft 2 in", "17 // 5",
+		"# note", "x = 5 // comment", "2026-03-01", "¥1000", "100₽",
+		"Rate = 5", "10 g 18k gold", "\"quoted\" 5", "1e400", "3d6",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		l := &Lexer{}
+		for _, locale := range []types.InputLocale{types.LocaleDecimalPoint, types.LocaleDecimalComma} {
+			l.Reset(input, locale)
+			tokens := fuzzTokens(t, l, input)
+
+			l.Reset(input, locale)
+			again := fuzzTokens(t, l, input)
+			if len(again) != len(tokens) {
+				t.Fatalf("reset lexer returned %d tokens, want %d", len(again), len(tokens))
+			}
+			for i := range tokens {
+				if again[i] != tokens[i] {
+					t.Fatalf("reset lexer returned %v, want %v", again[i], tokens[i])
+				}
+			}
+		}
+	})
+}
+
+// fuzzTokens reads every token from l, checking them as it goes.
+func fuzzTokens(t *testing.T, l *Lexer, input string) []token.Token {
+	var tokens []token.Token
+	last := 0
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+		if len(tokens) > len(input) {
+			t.Fatalf("more tokens than input bytes")
+		}
+		if tok.Pos < last || tok.End < tok.Pos || tok.End > len(input) {
+			t.Fatalf("token %v out of order", tok)
+		}
+		last = tok.End
+	}
+}
@@ -4,6 +4,8 @@
 package lexer
 
 import (
+	"math"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -19,34 +21,54 @@ type Lexer struct {
 	ch      rune // Current character under examination
 	line    int  // Current line number (for error reporting)
 	col     int  // Current column number
+
+	brackets int // Depth inside [...], where commas separate elements
+
+	decimalComma bool // Numbers are written 1.234,56 and ";" separates arguments
+
+	num []byte // Digits of the number being read, reused between numbers
 }
 
 // New creates a new Lexer for the given input.
 func New(input string) *Lexer {
-	l := &Lexer{
-		input: input,
-		line:  1,
-		col:   0,
+	return NewWithLocale(input, types.LocaleDecimalPoint)
+}
+
+// NewWithLocale creates a new Lexer reading numbers as written in locale.
+func NewWithLocale(input string, locale types.InputLocale) *Lexer {
+	l := &Lexer{}
+	l.Reset(input, locale)
+	return l
+}
+
+// Reset starts the lexer over on new input, keeping its buffers, so one
+// lexer can tokenize many lines without allocating.
+func (l *Lexer) Reset(input string, locale types.InputLocale) {
+	*l = Lexer{
+		input:        input,
+		line:         1,
+		col:          0,
+		decimalComma: locale == types.LocaleDecimalComma,
+		num:          l.num[:0],
 	}
 	l.readChar()
-	return l
 }
 
 // readChar reads the next character and advances position.
 func (l *Lexer) readChar() {
+	l.pos = l.readPos
+	size := 1
 	if l.readPos >= len(l.input) {
 		l.ch = 0 // EOF
 	} else {
 		l.ch = rune(l.input[l.readPos])
-		// Handle multi-byte UTF-8 characters
+		// Handle multi-byte UTF-8 characters, keeping pos at their first
+		// byte
 		if l.ch >= 0x80 {
-			r, size := decodeRune(l.input[l.readPos:])
-			l.ch = r
-			l.readPos += size - 1 // -1 because we'll add 1 below
+			l.ch, size = decodeRune(l.input[l.readPos:])
 		}
 	}
-	l.pos = l.readPos
-	l.readPos++
+	l.readPos += size
 	l.col++
 
 	// Track newlines
@@ -117,6 +139,19 @@ func (l *Lexer) peekChar() rune {
 	return ch
 }
 
+// directiveLen returns the length of "!name" at the current position, or
+// 0 if the ! starts something else, like a factorial.
+func (l *Lexer) directiveLen(name string) int {
+	end := l.pos + 1 + len(name)
+	if end > len(l.input) || !strings.EqualFold(l.input[l.pos+1:end], name) {
+		return 0
+	}
+	if end < len(l.input) && (isLetter(rune(l.input[end])) || isDigit(rune(l.input[end]))) {
+		return 0
+	}
+	return end - l.pos
+}
+
 // peekCharN returns the character N positions ahead.
 func (l *Lexer) peekCharN(n int) rune {
 	pos := l.readPos + n - 1
@@ -131,8 +166,20 @@ func (l *Lexer) peekCharN(n int) rune {
 	return ch
 }
 
-// NextToken returns the next token from the input.
+// NextToken returns the next token from the input, spanning the text it
+// was read from.
 func (l *Lexer) NextToken() token.Token {
+	tok := l.next()
+	end := min(l.pos, len(l.input))
+	for end > tok.Pos && (l.input[end-1] == ' ' || l.input[end-1] == '\t') {
+		end-- // Words like "three hundred" may read on past a space
+	}
+	tok.End = max(end, tok.Pos)
+	return tok
+}
+
+// next reads the next token.
+func (l *Lexer) next() token.Token {
 	l.skipWhitespace()
 
 	startPos := l.pos
@@ -142,16 +189,22 @@ func (l *Lexer) NextToken() token.Token {
 		return token.New(token.EOF, "", startPos)
 	}
 
-	// Check for comments
-	if l.ch == '#' || (l.ch == '/' && l.peekChar() == '/') {
+	// Check for comments ("//" after an operand and before a number is
+	// integer division: 17 // 5)
+	if l.ch == '#' || (l.ch == '/' && l.peekChar() == '/' && !l.isIntDivision()) {
 		return l.readComment(startPos)
 	}
 
 	// Check for currency symbols (must be before operators)
-	if types.IsCurrencySymbolRune(l.ch) || types.IsCryptoSymbolRune(l.ch) {
+	if isSymbolRune(l.ch) {
 		return l.readCurrencySymbol(startPos)
 	}
 
+	// Check for ISO dates (2026-03-01) before numbers
+	if l.isISODate() {
+		return l.readISODate(startPos)
+	}
+
 	// Check for numbers (including negative and decimals starting with .)
 	if isDigit(l.ch) || (l.ch == '.' && isDigit(l.peekChar())) {
 		return l.readNumber(startPos)
@@ -160,9 +213,19 @@ func (l *Lexer) NextToken() token.Token {
 	// Check for operators and punctuation
 	switch l.ch {
 	case '+':
+		if l.peekChar() == '/' && l.peekCharN(2) == '-' {
+			l.readChar()
+			l.readChar()
+			l.readChar()
+			return token.New(token.PLUSMINUS, "+/-", startPos)
+		}
 		l.readChar()
 		return token.New(token.PLUS, "+", startPos)
 
+	case '±':
+		l.readChar()
+		return token.New(token.PLUSMINUS, "±", startPos)
+
 	case '-':
 		// Could be minus or negative number
 		// If followed by digit and previous was operator or start, treat as number
@@ -182,6 +245,11 @@ func (l *Lexer) NextToken() token.Token {
 		return token.New(token.STAR, "*", startPos)
 
 	case '/':
+		if l.peekChar() == '/' {
+			l.readChar()
+			l.readChar()
+			return token.New(token.DSLASH, "//", startPos)
+		}
 		l.readChar()
 		return token.New(token.SLASH, "/", startPos)
 
@@ -189,6 +257,16 @@ func (l *Lexer) NextToken() token.Token {
 		l.readChar()
 		return token.New(token.CARET, "^", startPos)
 
+	case '!':
+		if n := l.directiveLen("pin"); n > 0 {
+			for range n {
+				l.readChar()
+			}
+			return token.New(token.PIN, l.input[startPos:l.pos], startPos)
+		}
+		l.readChar()
+		return token.New(token.BANG, "!", startPos)
+
 	case '(':
 		l.readChar()
 		return token.New(token.LPAREN, "(", startPos)
@@ -197,6 +275,16 @@ func (l *Lexer) NextToken() token.Token {
 		l.readChar()
 		return token.New(token.RPAREN, ")", startPos)
 
+	case '[':
+		l.brackets++
+		l.readChar()
+		return token.New(token.LBRACKET, "[", startPos)
+
+	case ']':
+		l.brackets = max(0, l.brackets-1)
+		l.readChar()
+		return token.New(token.RBRACKET, "]", startPos)
+
 	case '=':
 		l.readChar()
 		return token.New(token.EQUALS, "=", startPos)
@@ -205,6 +293,20 @@ func (l *Lexer) NextToken() token.Token {
 		l.readChar()
 		return token.New(token.COMMA, ",", startPos)
 
+	case ';':
+		// Argument separator where commas are decimal points: sum(1,5; 2)
+		if l.decimalComma {
+			l.readChar()
+			return token.New(token.COMMA, ";", startPos)
+		}
+
+	case '.':
+		if l.peekChar() == '.' {
+			l.readChar()
+			l.readChar()
+			return token.New(token.DOTDOT, "..", startPos)
+		}
+
 	case '%':
 		l.readChar()
 		return token.New(token.PERCENT, "%", startPos)
@@ -214,6 +316,13 @@ func (l *Lexer) NextToken() token.Token {
 		return token.New(token.NEWLINE, "\n", startPos)
 	}
 
+	// Check for foot/inch marks directly after a number (5'11")
+	if isFootInchMark(l.ch) && l.followsDigit() {
+		ch := l.ch
+		l.readChar()
+		return token.New(token.IDENTIFIER, string(ch), startPos)
+	}
+
 	// Check for identifiers and keywords
 	if isLetter(l.ch) || l.ch == '_' {
 		return l.readIdentifier(startPos)
@@ -227,17 +336,21 @@ func (l *Lexer) NextToken() token.Token {
 
 // Tokenize returns all tokens from the input.
 func (l *Lexer) Tokenize() []token.Token {
-	var tokens []token.Token
+	return l.AppendTokens(nil)
+}
 
+// AppendTokens appends all tokens from the input to dst and returns the
+// extended slice.
+func (l *Lexer) AppendTokens(dst []token.Token) []token.Token {
 	for {
 		tok := l.NextToken()
-		tokens = append(tokens, tok)
+		dst = append(dst, tok)
 		if tok.Type == token.EOF {
 			break
 		}
 	}
 
-	return tokens
+	return dst
 }
 
 // skipWhitespace skips spaces and tabs (but not newlines).
@@ -263,100 +376,242 @@ func (l *Lexer) isStartOfExpression() bool {
 		}
 		// After operator or open paren, it's start of expression
 		return ch == '+' || ch == '-' || ch == '*' || ch == '/' ||
-			ch == '^' || ch == '(' || ch == '=' || ch == ','
+			ch == '^' || ch == '(' || ch == '=' || ch == ',' || ch == ';'
 	}
 
 	return true
 }
 
+// isIntDivision reports whether the "//" at the current position is
+// integer division rather than a comment: it follows an operand and is
+// followed by a number or parenthesis, as in 17 // 5 or (a + b) // (c).
+func (l *Lexer) isIntDivision() bool {
+	before := strings.TrimRight(l.input[:l.pos], " \t")
+	if before == "" {
+		return false
+	}
+	i := len(before) - 1
+	for i > 0 && before[i]&0xC0 == 0x80 {
+		i-- // Back to the first byte of a multi-byte character
+	}
+	prev, _ := decodeRune(before[i:])
+	if !isLetter(prev) && !isDigit(prev) && prev != ')' && prev != ']' && prev != '%' {
+		return false
+	}
+
+	after := strings.TrimLeft(l.input[l.pos+2:], " \t")
+	if after == "" {
+		return false
+	}
+	next, _ := decodeRune(after)
+	return isDigit(next) || next == '.' || next == '(' ||
+		isSymbolRune(next)
+}
+
+// followsDigit returns true if the character before the current one is a digit.
+func (l *Lexer) followsDigit() bool {
+	return l.pos > 0 && isDigit(rune(l.input[l.pos-1]))
+}
+
+// isISODate returns true if the input at the current position is a
+// YYYY-MM-DD date not followed by another digit.
+func (l *Lexer) isISODate() bool {
+	const layout = "dddd-dd-dd"
+	rest := l.input[l.pos:]
+	if len(rest) < len(layout) {
+		return false
+	}
+	for i := 0; i < len(layout); i++ {
+		if layout[i] == 'd' && !isDigit(rune(rest[i])) {
+			return false
+		}
+		if layout[i] == '-' && rest[i] != '-' {
+			return false
+		}
+	}
+	return len(rest) == len(layout) || !isDigit(rune(rest[len(layout)]))
+}
+
+// readISODate reads a YYYY-MM-DD date token.
+func (l *Lexer) readISODate(startPos int) token.Token {
+	literal := l.input[l.pos : l.pos+10]
+	for range literal {
+		l.readChar()
+	}
+	return token.New(token.DATE, literal, startPos)
+}
+
 // readNumber reads a number token (integer, decimal, or with thousands separators).
 func (l *Lexer) readNumber(startPos int) token.Token {
-	var sb strings.Builder
+	l.num = l.num[:0]
 
 	// Handle leading negative sign
 	if l.ch == '-' {
-		sb.WriteRune(l.ch)
+		l.keep(l.ch)
 		l.readChar()
 	}
 
+	if l.decimalComma {
+		return l.readCommaNumber(startPos)
+	}
+
 	// Read integer part (with possible comma separators)
 	hasDigits := false
 	for isDigit(l.ch) || l.ch == ',' {
 		if l.ch == ',' {
-			// Validate comma placement (should have digits after)
-			if !isDigit(l.peekChar()) {
+			// Validate comma placement (should have three digits after,
+			// else it separates arguments: gcd(12,18)); in a list, commas
+			// separate elements: [1,2,3]
+			if !l.isThousandsGroup() || l.brackets > 0 {
 				break
 			}
 			// Skip comma in output (or keep for parsing later)
 			l.readChar()
 			continue
 		}
-		sb.WriteRune(l.ch)
+		l.keep(l.ch)
 		hasDigits = true
 		l.readChar()
 	}
 
 	// Read decimal part
 	if l.ch == '.' && isDigit(l.peekChar()) {
-		sb.WriteRune(l.ch)
+		l.keep(l.ch)
 		l.readChar()
 
 		for isDigit(l.ch) {
-			sb.WriteRune(l.ch)
+			l.keep(l.ch)
 			l.readChar()
 		}
 	} else if l.ch == '.' && !hasDigits {
 		// Leading decimal: .5
-		sb.WriteRune('0')
-		sb.WriteRune(l.ch)
+		l.keep('0')
+		l.keep(l.ch)
 		l.readChar()
 
 		for isDigit(l.ch) {
-			sb.WriteRune(l.ch)
+			l.keep(l.ch)
 			l.readChar()
 		}
 	}
 
+	return l.finishNumber(startPos)
+}
+
+// readCommaNumber reads the rest of a number written with a decimal
+// comma: 1.234,56. A point followed by three digits groups thousands;
+// any other point is a decimal point, so 1.5 still reads as 1.5.
+func (l *Lexer) readCommaNumber(startPos int) token.Token {
+	for isDigit(l.ch) || (l.ch == '.' && l.isThousandsGroup()) {
+		if l.ch != '.' {
+			l.keep(l.ch)
+		}
+		l.readChar()
+	}
+
+	if (l.ch == ',' || l.ch == '.') && isDigit(l.peekChar()) {
+		l.keep('.')
+		l.readChar()
+		for isDigit(l.ch) {
+			l.keep(l.ch)
+			l.readChar()
+		}
+	}
+
+	return l.finishNumber(startPos)
+}
+
+// isThousandsGroup reports whether the current character is followed by
+// exactly three digits.
+func (l *Lexer) isThousandsGroup() bool {
+	return isDigit(l.peekCharN(1)) && isDigit(l.peekCharN(2)) && isDigit(l.peekCharN(3)) &&
+		!isDigit(l.peekCharN(4))
+}
+
+// finishNumber reads a number's exponent and percent sign, if any.
+func (l *Lexer) finishNumber(startPos int) token.Token {
 	// Read exponent (scientific notation)
 	if l.ch == 'e' || l.ch == 'E' {
-		sb.WriteRune(l.ch)
+		l.keep(l.ch)
 		l.readChar()
 
 		// Optional sign
 		if l.ch == '+' || l.ch == '-' {
-			sb.WriteRune(l.ch)
+			l.keep(l.ch)
 			l.readChar()
 		}
 
 		// Exponent digits
 		for isDigit(l.ch) {
-			sb.WriteRune(l.ch)
+			l.keep(l.ch)
 			l.readChar()
 		}
 	}
 
+	// Scale suffix: 5k, 2.5M, 1.2bn
+	for range l.scaleSuffixLen() {
+		l.keep(l.ch)
+		l.readChar()
+	}
+
 	// Check for immediate percent sign (20% as single token)
 	if l.ch == '%' {
-		literal := sb.String()
+		l.keep(l.ch)
 		l.readChar()
-		return token.New(token.PERCENT, literal+"%", startPos)
+		return token.New(token.PERCENT, l.numLiteral(startPos), startPos)
+	}
+
+	return token.New(token.NUMBER, l.numLiteral(startPos), startPos)
+}
+
+// keep adds a character to the number being read. Numbers are ASCII.
+func (l *Lexer) keep(ch rune) {
+	l.num = append(l.num, byte(ch))
+}
+
+// numLiteral returns the number read since startPos. Most numbers are
+// written just as they are kept, and share the input's memory; only
+// those with separators dropped or a zero added are copied.
+func (l *Lexer) numLiteral(startPos int) string {
+	if written := l.input[startPos:min(l.pos, len(l.input))]; string(l.num) == written {
+		return written
 	}
+	return string(l.num)
+}
 
-	return token.New(token.NUMBER, sb.String(), startPos)
+// scaleSuffixLen returns the length of the scale suffix at the current
+// position (k, K, M, bn, tn), or 0 if there is none. The suffix must end
+// the word, so 5km and 5kg stay units.
+func (l *Lexer) scaleSuffixLen() int {
+	n := 1
+	switch {
+	case (l.ch == 'b' || l.ch == 't') && l.peekChar() == 'n':
+		n = 2
+	case l.ch == 'k' || l.ch == 'K' || l.ch == 'M':
+	default:
+		return 0
+	}
+	if next := l.peekCharN(n); isLetter(next) || isDigit(next) {
+		return 0
+	}
+	return n
 }
 
 // readIdentifier reads an identifier or keyword.
 func (l *Lexer) readIdentifier(startPos int) token.Token {
-	var sb strings.Builder
-
-	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
-		sb.WriteRune(l.ch)
+	// Powers are part of a unit: m², s²
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' || l.ch == '²' || l.ch == '³' {
 		l.readChar()
 	}
 
-	literal := sb.String()
+	literal := l.input[startPos:min(l.pos, len(l.input))]
 	lower := strings.ToLower(literal)
 
+	// Check for numbers in words: "three hundred", "twenty-one"
+	if n, ok := l.readNumberWords(lower); ok {
+		return token.New(token.NUMBER, strconv.FormatFloat(n, 'f', -1, 64), startPos)
+	}
+
 	// Check for keywords
 	if tokType := token.LookupIdentifier(lower); tokType != token.IDENTIFIER {
 		return token.New(tokType, literal, startPos)
@@ -371,54 +626,81 @@ func (l *Lexer) readIdentifier(startPos int) token.Token {
 	return token.New(token.IDENTIFIER, literal, startPos)
 }
 
+// multiWordPrefixes maps the first word of a multi-word identifier to the
+// words that may follow it (e.g., "turkish lira", "square feet").
+var multiWordPrefixes = map[string][]string{
+	"turkish":      {"lira"},
+	"hong":         {"kong", "dollar"},
+	"new":          {"zealand", "dollar"},
+	"south":        {"african", "rand", "korean", "won"},
+	"saudi":        {"riyal"},
+	"swiss":        {"franc", "francs"},
+	"british":      {"pound", "pounds"},
+	"us":           {"dollar", "dollars", "gallon", "gallons", "gal", "quart", "quarts", "qt", "pint", "pints", "pt", "fluid", "ounce", "ounces", "fl", "oz"},
+	"uk":           {"gallon", "gallons", "gal", "quart", "quarts", "qt", "pint", "pints", "pt", "fluid", "ounce", "ounces", "fl", "oz"},
+	"imperial":     {"gallon", "gallons", "gal", "quart", "quarts", "qt", "pint", "pints", "pt", "fluid", "ounce", "ounces", "fl", "oz"},
+	"mexican":      {"peso"},
+	"brazilian":    {"real"},
+	"indian":       {"rupee", "rupees"},
+	"square":       {"meter", "meters", "foot", "feet", "mile", "miles", "kilometer", "kilometers"},
+	"cubic":        {"meter", "meters"},
+	"fluid":        {"ounce", "ounces"},
+	"fl":           {"oz"},
+	"troy":         {"ounce", "ounces"},
+	"nautical":     {"mile", "miles"},
+	"light":        {"year", "years"},
+	"astronomical": {"unit", "units"},
+
+	// Ingredients (see types.ParseIngredient)
+	"plain":      {"flour"},
+	"bread":      {"flour"},
+	"whole":      {"wheat", "flour"},
+	"white":      {"sugar"},
+	"granulated": {"sugar"},
+	"brown":      {"sugar"},
+	"powdered":   {"sugar"},
+	"icing":      {"sugar"},
+	"baking":     {"soda", "powder"},
+	"maple":      {"syrup"},
+	"heavy":      {"cream"},
+	"vegetable":  {"oil"},
+	"olive":      {"oil"},
+	"cocoa":      {"powder"},
+	"corn":       {"starch"},
+	"rolled":     {"oats"},
+	"table":      {"salt"},
+
+	// Commodities (see types.ParseMetal)
+	"crude": {"oil"},
+
+	// Calendar
+	"business": {"day", "days"},
+}
+
 // tryReadMultiWordIdentifier tries to read a multi-word identifier.
-// Returns the full identifier if found, empty string otherwise.
+// Following words are consumed as long as they appear in the prefix's
+// word list. Returns the full identifier if found, empty string otherwise.
 func (l *Lexer) tryReadMultiWordIdentifier(first string) string {
-	lower := strings.ToLower(first)
-
-	// Save state in case we need to backtrack
-	savedPos := l.pos
-	savedReadPos := l.readPos
-	savedCh := l.ch
-	savedCol := l.col
-
-	// Check for known multi-word patterns
-	multiWordPrefixes := map[string][]string{
-		"turkish":   {"lira"},
-		"hong":      {"kong", "dollar"},
-		"new":       {"zealand", "dollar"},
-		"south":     {"african", "rand", "korean", "won"},
-		"saudi":     {"riyal"},
-		"swiss":     {"franc", "francs"},
-		"british":   {"pound", "pounds"},
-		"us":        {"dollar", "dollars"},
-		"mexican":   {"peso"},
-		"brazilian": {"real"},
-		"indian":    {"rupee", "rupees"},
-		"square":    {"meter", "meters", "foot", "feet", "mile", "miles", "kilometer", "kilometers"},
-		"cubic":     {"meter", "meters"},
-		"fluid":     {"ounce", "ounces"},
-		"troy":      {"ounce", "ounces"},
-		"nautical":  {"mile", "miles"},
-	}
-
-	expectedWords, ok := multiWordPrefixes[lower]
+	expectedWords, ok := multiWordPrefixes[strings.ToLower(first)]
 	if !ok {
 		return ""
 	}
 
-	// Try to read the expected words
-	var words []string
-	words = append(words, first)
+	words := []string{first}
 
-	for _, expected := range expectedWords {
-		l.skipWhitespace()
+	for {
+		// Save state in case this word doesn't belong to the identifier
+		savedPos := l.pos
+		savedReadPos := l.readPos
+		savedCh := l.ch
+		savedCol := l.col
 
+		l.skipWhitespace()
 		if !isLetter(l.ch) {
+			l.pos, l.readPos, l.ch, l.col = savedPos, savedReadPos, savedCh, savedCol
 			break
 		}
 
-		wordStart := l.pos
 		var sb strings.Builder
 		for isLetter(l.ch) || l.ch == '_' {
 			sb.WriteRune(l.ch)
@@ -426,33 +708,128 @@ func (l *Lexer) tryReadMultiWordIdentifier(first string) string {
 		}
 		word := sb.String()
 
-		if strings.ToLower(word) == expected {
-			words = append(words, word)
-		} else {
+		if !containsFold(expectedWords, word) {
 			// Backtrack this word
-			l.pos = wordStart
-			l.readPos = wordStart + 1
-			if wordStart < len(l.input) {
-				l.ch = rune(l.input[wordStart])
-			} else {
-				l.ch = 0
-			}
+			l.pos, l.readPos, l.ch, l.col = savedPos, savedReadPos, savedCh, savedCol
 			break
 		}
+		words = append(words, word)
 	}
 
-	// If we only got the first word, backtrack completely
 	if len(words) == 1 {
-		l.pos = savedPos
-		l.readPos = savedReadPos
-		l.ch = savedCh
-		l.col = savedCol
 		return ""
 	}
 
 	return strings.Join(words, " ")
 }
 
+// containsFold reports whether words contains s (case-insensitive).
+func containsFold(words []string, s string) bool {
+	for _, w := range words {
+		if strings.EqualFold(w, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ════════════════════════════════════════════════════════════════
+// NUMBER WORDS
+// ════════════════════════════════════════════════════════════════
+
+// numberWords are the words that start a number written in words.
+var numberWords = map[string]float64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+	"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+// readNumberWords reads the rest of a number written in words starting
+// with first: "three hundred and five", "twenty-one", "two dozen". The
+// following words are consumed as long as they continue the number.
+// Returns false if first is not a number word.
+func (l *Lexer) readNumberWords(first string) (float64, bool) {
+	current, ok := numberWords[first]
+	if !ok {
+		return 0, false
+	}
+	total := 0.0
+
+	for {
+		// Save state in case this word doesn't continue the number
+		savedPos := l.pos
+		savedReadPos := l.readPos
+		savedCh := l.ch
+		savedCol := l.col
+
+		hyphen := l.ch == '-'
+		if hyphen {
+			l.readChar()
+		} else {
+			l.skipWhitespace()
+		}
+		word := l.readWord()
+		if word == "and" && !hyphen && current+total > 0 {
+			l.skipWhitespace()
+			word = l.readWord()
+		}
+
+		if !l.continueNumber(word, hyphen, &total, &current) {
+			l.pos, l.readPos, l.ch, l.col = savedPos, savedReadPos, savedCh, savedCol
+			break
+		}
+	}
+
+	return total + current, true
+}
+
+// continueNumber adds word to the number being read in words, returning
+// false if it does not continue it. Ones follow only tens ("twenty one",
+// "twenty-one") or a whole hundred or scale ("one hundred five").
+func (l *Lexer) continueNumber(word string, hyphen bool, total, current *float64) bool {
+	if n, ok := numberWords[word]; ok {
+		last := math.Mod(*current, 100)
+		switch {
+		case last == 0 && !hyphen:
+		case last >= 20 && math.Mod(last, 10) == 0 && n < 10:
+		default:
+			return false
+		}
+		*current += n
+		return true
+	}
+
+	scale, ok := types.NumberScale(word)
+	if hyphen || len(word) < 2 || !ok {
+		return false
+	}
+	if *current == 0 {
+		*current = 1
+	}
+	if scale < 1000 {
+		// "three hundred", "two dozen"
+		*current *= scale
+	} else {
+		// "five thousand", "two million"
+		*total += *current * scale
+		*current = 0
+	}
+	return true
+}
+
+// readWord reads a lowercase run of letters.
+func (l *Lexer) readWord() string {
+	var sb strings.Builder
+	for isLetter(l.ch) {
+		sb.WriteRune(unicode.ToLower(l.ch))
+		l.readChar()
+	}
+	return sb.String()
+}
+
 // readComment reads a comment until end of line.
 func (l *Lexer) readComment(startPos int) token.Token {
 	var sb strings.Builder
@@ -481,13 +858,17 @@ func (l *Lexer) readComment(startPos int) token.Token {
 
 // readCurrencySymbol reads a currency symbol token.
 func (l *Lexer) readCurrencySymbol(startPos int) token.Token {
-	symbol := string(l.ch)
+	symbol := l.ch
 	l.readChar()
 
-	// Determine specific token type
-	tokType := token.LookupCurrencySymbol(rune(symbol[0]))
+	// Determine specific token type; other registered symbols (₺, ₦, ฿)
+	// are plain currency tokens
+	tokType := token.LookupCurrencySymbol(symbol)
+	if tokType == token.ILLEGAL {
+		tokType = token.CURRENCY
+	}
 
-	return token.New(tokType, symbol, startPos)
+	return token.New(tokType, string(symbol), startPos)
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -499,6 +880,21 @@ func isDigit(ch rune) bool {
 	return ch >= '0' && ch <= '9'
 }
 
+// isFootInchMark returns true if the rune is a foot or inch mark.
+func isFootInchMark(ch rune) bool {
+	return ch == '\'' || ch == '"' || ch == '′' || ch == '″'
+}
+
+// isSymbolRune reports whether ch starts a currency or crypto symbol. A
+// letter only does when the token table names it (Ξ, Ł), so ZAR's "R"
+// doesn't swallow the start of "Rate".
+func isSymbolRune(ch rune) bool {
+	if token.IsCurrencyRune(ch) {
+		return true
+	}
+	return !isLetter(ch) && (types.IsCurrencySymbolRune(ch) || types.IsCryptoSymbolRune(ch))
+}
+
 // isLetter returns true if the rune is a letter.
 func isLetter(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') ||
@@ -0,0 +1,34 @@
+// internal/parser/conversion_test.go
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/0xsj/numio/internal/ast"
+)
+
+func TestConversionDateEitherSide(t *testing.T) {
+	for _, input := range []string{
+		"0.5 BTC on 2021-11-10 in USD",
+		"0.5 BTC in USD on 2021-11-10",
+	} {
+		expr, errs := ParseExpr(input)
+		if len(errs) > 0 {
+			t.Fatalf("%s: %v", input, errs[0])
+		}
+		conv, ok := expr.(*ast.ConversionExpr)
+		if !ok {
+			t.Fatalf("%s: got %T, want a conversion", input, expr)
+		}
+		if conv.Target != "USD" || conv.Date == nil || conv.Date.Year != 2021 || conv.Date.Month != 11 || conv.Date.Day != 10 {
+			t.Errorf("%s: got %s", input, conv)
+		}
+	}
+}
+
+func TestConversionContinuationWithDate(t *testing.T) {
+	if _, errs := ParseLine("in EUR on 2024-01-01"); len(errs) == 0 {
+		t.Error("continuation with a date parsed; it would convert at today's rates")
+	}
+}
@@ -0,0 +1,318 @@
+// internal/parser/format.go
+
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/internal/token"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// FormatOptions controls how Format writes a line.
+type FormatOptions struct {
+	Locale     types.InputLocale     // How numbers are written
+	Currencies types.CurrencyDisplay // Currency amounts as $100 or 100 USD
+}
+
+// Format writes a parsed line canonically: one space around operators
+// and after commas, numbers in digits, units by their codes, and currency
+// amounts by symbol or code. A literal that would read back differently,
+// like 5'11", keeps its text; the line's label and comment are kept as
+// written.
+func Format(line *ast.Line, opts FormatOptions) string {
+	f := formatter{opts: opts}
+	if c, ok := line.Stmt.(*ast.CommentStmt); ok {
+		return c.Text
+	}
+
+	var parts []string
+	if line.Stmt != nil {
+		if s := f.stmt(line.Stmt); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if line.Pinned {
+		parts = append(parts, "!pin")
+	}
+	if line.Label != "" {
+		parts = append(parts, line.Label)
+	}
+	if line.Share {
+		parts = append(parts, "% of total")
+	}
+	if line.Comment != "" {
+		parts = append(parts, line.Comment)
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatExpr writes an expression canonically, as Format writes lines.
+func FormatExpr(expr ast.Expr, opts FormatOptions) string {
+	return formatter{opts: opts}.expr(expr)
+}
+
+// formatter writes the nodes of a line for Format.
+type formatter struct {
+	opts FormatOptions
+}
+
+func (f formatter) stmt(stmt ast.Stmt) string {
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		if s.Expr == nil {
+			return ""
+		}
+		return f.expr(s.Expr)
+	case *ast.AssignStmt:
+		return s.Name + " = " + f.expr(s.Expr)
+	case *ast.FuncDefStmt:
+		return s.Name + "(" + strings.Join(s.Params, ", ") + ") = " + f.expr(s.Body)
+	case *ast.UnitDefStmt:
+		out := "unit " + strings.Join(append([]string{s.Name}, s.Aliases...), ", ")
+		if s.Expr != nil {
+			out += " = " + f.expr(s.Expr)
+		}
+		return out
+	default:
+		return stmt.String()
+	}
+}
+
+func (f formatter) expr(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.NumberLit:
+		if e.Counts != "" {
+			return f.literal(e, e.Raw, f.number(e.Value, e.Compact)+" "+e.Counts)
+		}
+		return f.literal(e, e.Raw, f.number(e.Value, e.Compact))
+	case *ast.PercentLit:
+		return f.literal(e, e.Raw, f.number(e.Value*100, false)+"%")
+	case *ast.CurrencyLit:
+		return f.literal(e, e.Raw, f.currency(e)...)
+	case *ast.CryptoLit:
+		return f.literal(e, e.Raw, f.crypto(e)...)
+	case *ast.MetalLit:
+		text := f.number(e.Amount, false) + " "
+		if e.Unit != nil {
+			text += e.Unit.Code + " "
+		}
+		if e.Karat > 0 {
+			text += strconv.Itoa(e.Karat) + "k "
+		}
+		return f.literal(e, e.Raw, text+e.Metal.Code)
+	case *ast.UnitLit:
+		text := f.number(e.Amount, false) + " " + e.Unit.Code
+		if e.Ingredient != nil {
+			text += " " + e.Ingredient.Name
+		}
+		return f.literal(e, e.Raw, text)
+
+	case *ast.BinaryExpr:
+		right := f.expr(e.Right)
+		if e.Op == ast.OpIntDiv && !startsOperand(right) {
+			// "//" before a name would start a comment
+			right = "(" + right + ")"
+		}
+		if isPerUnit(e) {
+			return f.expr(e.Left) + "/" + right // 5 km/h
+		}
+		return f.expr(e.Left) + " " + e.Op.String() + " " + right
+	case *ast.UnaryExpr:
+		return e.Op.String() + f.expr(e.Expr)
+	case *ast.GroupExpr:
+		return "(" + f.expr(e.Expr) + ")"
+	case *ast.PercentOfExpr:
+		return f.expr(e.Percent) + " of " + f.expr(e.Value)
+	case *ast.ConversionExpr:
+		if e.Date != nil {
+			return f.expr(e.Value) + " on " + f.expr(e.Date) + " in " + target(e.Target)
+		}
+		return f.expr(e.Value) + " in " + target(e.Target)
+	case *ast.DisplayExpr:
+		return f.expr(e.Value) + " as " + strings.ToLower(e.Currency)
+	case *ast.CallExpr:
+		if e.Name == "factorial" && len(e.Args) == 1 && isPrimary(e.Args[0]) {
+			return f.expr(e.Args[0]) + "!" // 5!
+		}
+		return e.Name + "(" + f.list(e.Args) + ")"
+	case *ast.ListLit:
+		return "[" + f.list(e.Elems) + "]"
+	case *ast.ContinuationExpr:
+		return e.Op.String() + " " + f.expr(e.Expr)
+	case *ast.ConversionContinuation:
+		return "in " + target(e.Target)
+	case *ast.BusinessDaysExpr:
+		return "business days between " + f.expr(e.From) + " and " + f.expr(e.To)
+	case *ast.RangeExpr:
+		out := f.expr(e.Start) + ".." + f.expr(e.End)
+		if e.Step != nil {
+			out += " step " + f.expr(e.Step)
+		}
+		return out
+	case *ast.LinesExpr:
+		return "lines " + f.expr(e.Range)
+	case *ast.RateExpr:
+		out := "rate " + strings.ToUpper(e.From) + "/" + strings.ToUpper(e.To)
+		if e.Stat != "" {
+			out = strings.ToLower(e.Stat) + " " + out
+		}
+		if e.Period != nil {
+			out += " last " + f.expr(e.Period)
+		}
+		return out
+	default:
+		return expr.String()
+	}
+}
+
+// list writes function arguments or list elements.
+func (f formatter) list(exprs []ast.Expr) string {
+	sep := ", "
+	if f.opts.Locale == types.LocaleDecimalComma {
+		sep = "; "
+	}
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = f.expr(expr)
+	}
+	return strings.Join(parts, sep)
+}
+
+// currency returns the ways to write a currency amount, best first.
+func (f formatter) currency(c *ast.CurrencyLit) []string {
+	amount := f.number(c.Amount, c.Compact)
+	code := amount + " " + c.Currency.Code
+	// A leading "-$" would continue the previous line
+	if f.opts.Currencies == types.ShowCurrencyCodes || c.Amount < 0 || c.Currency.SymbolAfter ||
+		!c.Currency.ShowsSymbol() {
+		return []string{code}
+	}
+	return []string{c.Currency.Symbol + amount, code}
+}
+
+// crypto returns the ways to write a crypto amount, best first.
+func (f formatter) crypto(c *ast.CryptoLit) []string {
+	amount := f.number(c.Amount, false)
+	if c.Unit != nil {
+		return []string{amount + " " + c.Unit.Name(c.Amount)}
+	}
+	code := amount + " " + c.Crypto.Code
+	if f.opts.Currencies == types.ShowCurrencyCodes || c.Amount < 0 || !c.Crypto.HasSymbol() {
+		return []string{code}
+	}
+	return []string{c.Crypto.Symbol + amount, code}
+}
+
+// number writes a number in digits, with a scale suffix if compact. It
+// keeps 12 significant digits; literals check the rest weren't needed.
+func (f formatter) number(n float64, compact bool) string {
+	suffix := ""
+	if compact {
+		for _, scale := range []string{"tn", "bn", "M", "k"} {
+			factor, _ := types.NumberScale(scale)
+			if math.Abs(n) >= factor {
+				n /= factor
+				suffix = scale
+				break
+			}
+		}
+	}
+
+	s := strconv.FormatFloat(n, 'g', 12, 64)
+	if f.opts.Locale == types.LocaleDecimalComma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s + suffix
+}
+
+// literal returns the first of texts that reads back as the literal lit,
+// or its raw text if none does.
+func (f formatter) literal(lit ast.Expr, raw string, texts ...string) string {
+	for _, text := range texts {
+		if parsed, errs := ParseExprWithLocale(text, f.opts.Locale); len(errs) == 0 && sameLiteral(parsed, lit) {
+			return text
+		}
+	}
+	if raw == "" {
+		return lit.String()
+	}
+	// Raw foot and inch marks are spaced out: 5 ' 11 "
+	return strings.NewReplacer(" ' ", "'", " '", "'", ` "`, `"`).Replace(raw)
+}
+
+// sameLiteral reports whether a and b are literals of the same value.
+func sameLiteral(a, b ast.Expr) bool {
+	switch a := a.(type) {
+	case *ast.NumberLit:
+		b, ok := b.(*ast.NumberLit)
+		return ok && a.Value == b.Value && a.Compact == b.Compact && a.Counts == b.Counts
+	case *ast.PercentLit:
+		b, ok := b.(*ast.PercentLit)
+		return ok && a.Value == b.Value
+	case *ast.CurrencyLit:
+		b, ok := b.(*ast.CurrencyLit)
+		return ok && a.Amount == b.Amount && a.Currency == b.Currency && a.Compact == b.Compact
+	case *ast.CryptoLit:
+		b, ok := b.(*ast.CryptoLit)
+		return ok && a.Amount == b.Amount && a.Crypto == b.Crypto && a.Unit == b.Unit
+	case *ast.MetalLit:
+		b, ok := b.(*ast.MetalLit)
+		return ok && a.Amount == b.Amount && a.Unit == b.Unit && a.Karat == b.Karat && a.Metal == b.Metal
+	case *ast.UnitLit:
+		b, ok := b.(*ast.UnitLit)
+		return ok && a.Amount == b.Amount && a.Unit == b.Unit && a.Ingredient == b.Ingredient
+	}
+	return false
+}
+
+// target writes a conversion target by its code, if it names a currency
+// or a unit unambiguously.
+func target(name string) string {
+	if c := types.ParseCurrency(name); c != nil {
+		return c.Code
+	}
+	if c := types.ParseCrypto(name); c != nil {
+		return c.Code
+	}
+	if m := types.ParseMetal(name); m != nil {
+		return m.Code
+	}
+	u := types.ParseUnit(name)
+	if u == nil || types.ParseUnit(u.Code) != u || types.ParseCurrency(u.Code) != nil ||
+		types.ParseCrypto(u.Code) != nil || types.ParseMetal(u.Code) != nil ||
+		token.LookupIdentifier(strings.ToLower(u.Code)) != token.IDENTIFIER {
+		return name
+	}
+	return u.Code
+}
+
+// isPerUnit reports whether b divides a unit amount by a unit name, as in
+// 5 km/h.
+func isPerUnit(b *ast.BinaryExpr) bool {
+	if b.Op != ast.OpDiv {
+		return false
+	}
+	_, amount := b.Left.(*ast.UnitLit)
+	name, ok := b.Right.(*ast.Identifier)
+	return amount && ok && types.ParseUnit(name.Name) != nil
+}
+
+// isPrimary reports whether expr needs no parentheses before a postfix
+// operator.
+func isPrimary(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.NumberLit, *ast.Identifier, *ast.GroupExpr, *ast.CallExpr, *ast.ListLit:
+		return true
+	}
+	return false
+}
+
+// startsOperand reports whether s starts with a number or parenthesis,
+// which "//" must be followed by to divide.
+func startsOperand(s string) bool {
+	return s != "" && (s[0] == '(' || s[0] >= '0' && s[0] <= '9')
+}
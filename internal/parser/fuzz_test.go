@@ -0,0 +1,54 @@
+// internal/parser/fuzz_test.go
+
+package parser
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/internal/lexer"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// FuzzParseLine checks that any line, in either locale, parses without
+// panicking, and that reading valid UTF-8 tokens as the parser reaches
+// them gives the same line and errors as tokenizing it up front.
+func FuzzParseLine(f *testing.F) {
+	for _, seed := range []string{
+		"", "1 + 2 * 3", "x = 5", "$100 in EUR", "0.5 BTC in USD on 2021-11-10",
+		"1.234,56 € in USD", "3 ft 2 in to cm", "20% of 300", "unit sprint = 2 weeks",
+		"sum", "10 g 18k gold", "rate USD/EUR", "f(x) = x^2", "lines 1..20", "-$10",
+		"5 from 1,000 runs", "\f",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, locale := range []types.InputLocale{types.LocaleDecimalPoint, types.LocaleDecimalComma} {
+			line, errs := ParseLineWithLocale(input, locale)
+
+			p := NewFromTokens(lexer.NewWithLocale(input, locale).Tokenize())
+			whole := p.ParseLine()
+			if !utf8.ValidString(input) {
+				continue // tokens hold U+FFFD where the input has the bad byte
+			}
+			if len(p.Errors()) != len(errs) {
+				t.Fatalf("streamed input gave %d errors, pre-tokenized %d", len(errs), len(p.Errors()))
+			}
+			if unlabeled(line) != unlabeled(whole) {
+				t.Fatalf("streamed input gave %q, pre-tokenized %q", line.String(), whole.String())
+			}
+		}
+	})
+}
+
+// unlabeled returns a line's text with any label as "label": without the
+// input, labels are rebuilt from the tokens' literals, which lose what
+// the lexer normalized ("0,000" reads as 0000).
+func unlabeled(l *ast.Line) string {
+	c := *l
+	if c.Label != "" {
+		c.Label = "label"
+	}
+	return c.String()
+}
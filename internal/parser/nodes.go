@@ -0,0 +1,68 @@
+// internal/parser/nodes.go
+
+package parser
+
+import "github.com/0xsj/numio/internal/ast"
+
+// The parser allocates its commonest nodes in chunks rather than one at a
+// time. A chunk is never reused: it is freed once no AST refers to any
+// node in it, so an AST that is kept, like a function's body, keeps the
+// rest of its chunk alive too. As parsers are pooled, one chunk serves
+// many lines.
+
+// nodeChunk is the number of nodes allocated together.
+const nodeChunk = 32
+
+// slab hands out zeroed nodes of one type from chunks.
+type slab[T any] struct {
+	free []T
+}
+
+// alloc returns a new zeroed node.
+func (s *slab[T]) alloc() *T {
+	if len(s.free) == 0 {
+		s.free = make([]T, nodeChunk)
+	}
+	n := &s.free[0]
+	s.free = s.free[1:]
+	return n
+}
+
+// nodes holds a parser's slabs.
+type nodes struct {
+	lines   slab[ast.Line]
+	stmts   slab[ast.ExprStmt]
+	numbers slab[ast.NumberLit]
+	binary  slab[ast.BinaryExpr]
+	idents  slab[ast.Identifier]
+}
+
+func (p *Parser) newLine(stmt ast.Stmt, pinned bool, comment string) *ast.Line {
+	n := p.nodes.lines.alloc()
+	n.Stmt, n.Pinned, n.Comment = stmt, pinned, comment
+	return n
+}
+
+func (p *Parser) newExprStmt(expr ast.Expr) *ast.ExprStmt {
+	n := p.nodes.stmts.alloc()
+	n.Expr = expr
+	return n
+}
+
+func (p *Parser) newNumber(value float64, raw string, compact bool) *ast.NumberLit {
+	n := p.nodes.numbers.alloc()
+	n.Value, n.Raw, n.Compact = value, raw, compact
+	return n
+}
+
+func (p *Parser) newBinary(left ast.Expr, op ast.BinaryOp, right ast.Expr) *ast.BinaryExpr {
+	n := p.nodes.binary.alloc()
+	n.Left, n.Op, n.Right = left, op, right
+	return n
+}
+
+func (p *Parser) newIdentifier(name string) *ast.Identifier {
+	n := p.nodes.idents.alloc()
+	n.Name = name
+	return n
+}
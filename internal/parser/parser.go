@@ -6,6 +6,8 @@ package parser
 import (
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/0xsj/numio/internal/ast"
 	"github.com/0xsj/numio/internal/lexer"
@@ -14,28 +16,51 @@ import (
 	"github.com/0xsj/numio/pkg/types"
 )
 
-// Parser parses tokens into an AST.
+// Parser parses tokens into an AST. Tokens are read from the lexer as the
+// parser reaches them, so tokens holds only those it has looked ahead at,
+// not the whole input.
 type Parser struct {
 	lexer  *lexer.Lexer
+	input  string
+	lexed  bool // The lexer has returned EOF, or there is none
 	tokens []token.Token
 	pos    int
 	errors []*errors.Error
+	nodes  nodes
+	spans  []Span         // Tokens read and their roles, kept for Spans
+	units  *types.UnitSet // User-defined units, besides the built-in ones
 }
 
 // New creates a new Parser for the given input.
 func New(input string) *Parser {
-	l := lexer.New(input)
+	return NewWithLocale(input, types.LocaleDecimalPoint)
+}
+
+// NewWithLocale creates a new Parser reading numbers as written in locale.
+func NewWithLocale(input string, locale types.InputLocale) *Parser {
 	return &Parser{
-		lexer:  l,
-		tokens: l.Tokenize(),
+		lexer:  lexer.NewWithLocale(input, locale),
+		input:  input,
 		pos:    0,
 		errors: nil,
 	}
 }
 
+// SetUnits has the parser read the units of set besides the built-in
+// ones.
+func (p *Parser) SetUnits(set *types.UnitSet) {
+	p.units = set
+}
+
+// unit returns the unit name names, user-defined or built in, or nil.
+func (p *Parser) unit(name string) *types.Unit {
+	return p.units.Parse(name)
+}
+
 // NewFromTokens creates a parser from pre-tokenized input.
 func NewFromTokens(tokens []token.Token) *Parser {
 	return &Parser{
+		lexed:  true,
 		tokens: tokens,
 		pos:    0,
 		errors: nil,
@@ -56,27 +81,47 @@ func (p *Parser) HasErrors() bool {
 // TOKEN NAVIGATION
 // ════════════════════════════════════════════════════════════════
 
+// fill reads tokens from the lexer until the token n positions ahead is
+// in the buffer, or the input ends.
+func (p *Parser) fill(n int) {
+	for !p.lexed && p.pos+n >= len(p.tokens) {
+		tok := p.lexer.NextToken()
+		p.tokens = append(p.tokens, tok)
+		p.lexed = tok.Type == token.EOF
+	}
+}
+
 // current returns the current token.
 func (p *Parser) current() token.Token {
-	if p.pos >= len(p.tokens) {
-		return token.New(token.EOF, "", -1)
-	}
-	return p.tokens[p.pos]
+	return p.peekN(0)
 }
 
 // peek returns the next token without advancing.
 func (p *Parser) peek() token.Token {
-	if p.pos+1 >= len(p.tokens) {
+	return p.peekN(1)
+}
+
+// peekN returns the token n positions ahead without advancing.
+func (p *Parser) peekN(n int) token.Token {
+	p.fill(n)
+	if p.pos+n >= len(p.tokens) {
 		return token.New(token.EOF, "", -1)
 	}
-	return p.tokens[p.pos+1]
+	return p.tokens[p.pos+n]
 }
 
-// advance moves to the next token and returns the previous one.
+// advance moves to the next token and returns the previous one. The
+// parser never goes back, so once every token read has been passed the
+// buffer starts over.
 func (p *Parser) advance() token.Token {
 	tok := p.current()
 	if p.pos < len(p.tokens) {
 		p.pos++
+		p.record(tok)
+	}
+	if p.pos == len(p.tokens) && !p.lexed {
+		p.tokens = p.tokens[:0]
+		p.pos = 0
 	}
 	return tok
 }
@@ -139,31 +184,92 @@ func (p *Parser) ParseLine() *ast.Line {
 
 	// Check for EOF
 	if p.check(token.EOF) {
-		return &ast.Line{Stmt: &ast.EmptyStmt{}}
+		return p.newLine(&ast.EmptyStmt{}, false, "")
 	}
 
 	// Check for comment-only line
 	if p.check(token.COMMENT) {
 		comment := p.advance()
-		return &ast.Line{
-			Stmt:    &ast.CommentStmt{Text: comment.Literal},
-			Comment: comment.Literal,
-		}
+		return p.newLine(&ast.CommentStmt{Text: comment.Literal}, false, comment.Literal)
 	}
 
 	// Try to parse a statement
 	stmt := p.parseStatement()
 
+	// Check for a !pin directive, which must end the statement
+	pinned := p.match(token.PIN)
+	if pinned && !p.checkAny(token.COMMENT, token.NEWLINE, token.EOF) && !p.checkShare() {
+		p.addError("!pin must come at the end of the line")
+	}
+
+	// Words the statement doesn't take are a label: "$250 rent"
+	label := p.skipLabel()
+
+	// "% of total" shows the line's share of the document total
+	share := p.matchShare()
+	if share && !p.checkAny(token.COMMENT, token.NEWLINE, token.EOF) {
+		p.addError("% of total must come at the end of the line")
+	}
+
 	// Check for trailing comment
 	var comment string
 	if p.check(token.COMMENT) {
 		comment = p.advance().Literal
 	}
 
-	return &ast.Line{
-		Stmt:    stmt,
-		Comment: comment,
+	line := p.newLine(stmt, pinned, comment)
+	line.Label, line.Share = label, share
+	return line
+}
+
+// checkShare reports whether the next tokens are "% of total".
+func (p *Parser) checkShare() bool {
+	return p.check(token.PERCENT) && p.current().Literal == "%" && p.peek().Type == token.OF &&
+		p.peekN(2).Type == token.IDENTIFIER && strings.EqualFold(p.peekN(2).Literal, "total")
+}
+
+// matchShare consumes "% of total", returning true if it was there.
+func (p *Parser) matchShare() bool {
+	if !p.checkShare() {
+		return false
+	}
+	for range 3 {
+		p.mark(p.advance(), RoleKeyword)
+	}
+	return true
+}
+
+// skipLabel skips the tokens before a comment, "% of total" or the end of
+// the line, returning their text.
+func (p *Parser) skipLabel() string {
+	start := p.current().Pos
+	var words strings.Builder
+	last := -1
+	for !p.checkAny(token.COMMENT, token.NEWLINE, token.EOF) && !p.checkShare() {
+		tok := p.advance()
+		if tok.Type != token.ILLEGAL {
+			p.mark(tok, RoleLabel)
+		}
+		// Tokens written apart are joined by a space: ",56" stays one word
+		if words.Len() > 0 && tok.Pos != last {
+			words.WriteByte(' ')
+		}
+		words.WriteString(tok.Literal)
+		last = tok.End
+	}
+	if words.Len() == 0 {
+		return ""
+	}
+
+	// Take the text as written, if the parser has it
+	end := p.current().Pos
+	if end < 0 {
+		end = len(p.input)
 	}
+	if p.input != "" && 0 <= start && start <= end && end <= len(p.input) {
+		return strings.TrimSpace(p.input[start:end])
+	}
+	return strings.TrimSpace(words.String())
 }
 
 // Parse parses the entire input and returns the first line.
@@ -193,13 +299,34 @@ func (p *Parser) ParseLines() []*ast.Line {
 
 // parseStatement parses a statement (assignment or expression).
 func (p *Parser) parseStatement() ast.Stmt {
+	// Check for unit definition: unit sprint = 2 weeks
+	if p.check(token.IDENTIFIER) && strings.EqualFold(p.current().Literal, "unit") &&
+		p.peek().Type == token.IDENTIFIER {
+		return p.parseUnitDef()
+	}
+
+	// Check for a scope: begin ... end, each on a line of its own
+	if p.check(token.IDENTIFIER) && (p.peek().Type == token.COMMENT || p.peek().Type == token.NEWLINE ||
+		p.peek().Type == token.EOF) {
+		switch word := strings.ToLower(p.current().Literal); word {
+		case "begin", "end":
+			p.mark(p.advance(), RoleKeyword)
+			return &ast.ScopeStmt{End: word == "end"}
+		}
+	}
+
+	// Check for function definition: f(x) = expr
+	if p.isFuncDef() {
+		return p.parseFuncDef()
+	}
+
 	// Check for assignment: identifier = expr
 	if p.check(token.IDENTIFIER) && p.peek().Type == token.EQUALS {
 		return p.parseAssignment()
 	}
 
 	// Check for continuation (line starting with operator)
-	if p.checkAny(token.PLUS, token.MINUS, token.STAR, token.SLASH, token.CARET, token.POWER) {
+	if p.checkAny(token.PLUS, token.MINUS, token.STAR, token.SLASH, token.MOD, token.CARET, token.POWER) {
 		return p.parseContinuation()
 	}
 
@@ -214,12 +341,12 @@ func (p *Parser) parseStatement() ast.Stmt {
 		return &ast.EmptyStmt{}
 	}
 
-	return &ast.ExprStmt{Expr: expr}
+	return p.newExprStmt(expr)
 }
 
 // parseAssignment parses a variable assignment.
 func (p *Parser) parseAssignment() *ast.AssignStmt {
-	name := p.advance().Literal // identifier
+	name := p.advance().Literal // identifier, a variable by default
 	p.advance()                 // =
 
 	expr := p.parseExpression()
@@ -231,6 +358,80 @@ func (p *Parser) parseAssignment() *ast.AssignStmt {
 	return &ast.AssignStmt{Name: name, Expr: expr}
 }
 
+// isFuncDef reports whether the statement is a function definition:
+// name(param, ...) = expr.
+func (p *Parser) isFuncDef() bool {
+	if !p.check(token.IDENTIFIER) || p.peek().Type != token.LPAREN {
+		return false
+	}
+
+	n := 2
+	if p.peekN(n).Type == token.RPAREN {
+		return p.peekN(n+1).Type == token.EQUALS
+	}
+	for p.peekN(n).Type == token.IDENTIFIER {
+		switch p.peekN(n + 1).Type {
+		case token.COMMA:
+			n += 2
+		case token.RPAREN:
+			return p.peekN(n+2).Type == token.EQUALS
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// parseFuncDef parses a function definition: f(x, y) = expr.
+func (p *Parser) parseFuncDef() *ast.FuncDefStmt {
+	name := p.advance()
+	p.mark(name, RoleFunction)
+	stmt := &ast.FuncDefStmt{Name: name.Literal}
+	p.advance() // (
+
+	for p.check(token.IDENTIFIER) {
+		stmt.Params = append(stmt.Params, p.advance().Literal)
+		p.match(token.COMMA)
+	}
+	p.advance() // )
+	p.advance() // =
+
+	stmt.Body = p.parseExpression()
+	if stmt.Body == nil {
+		p.addError("expected expression after '='")
+		stmt.Body = &ast.NumberLit{Value: 0}
+	}
+
+	return stmt
+}
+
+// parseUnitDef parses a unit definition: unit <name>[, <alias>...] [= expr].
+func (p *Parser) parseUnitDef() *ast.UnitDefStmt {
+	p.mark(p.advance(), RoleKeyword) // unit
+	name := p.advance()
+	p.mark(name, RoleUnit)
+	stmt := &ast.UnitDefStmt{Name: name.Literal}
+
+	for p.match(token.COMMA) {
+		if !p.check(token.IDENTIFIER) {
+			p.addError("expected unit alias after ','")
+			return stmt
+		}
+		alias := p.advance()
+		p.mark(alias, RoleUnit)
+		stmt.Aliases = append(stmt.Aliases, alias.Literal)
+	}
+
+	if p.match(token.EQUALS) {
+		stmt.Expr = p.parseExpression()
+		if stmt.Expr == nil {
+			p.addError("expected expression after '='")
+		}
+	}
+
+	return stmt
+}
+
 // parseContinuation parses a continuation expression (e.g., "+ 10").
 func (p *Parser) parseContinuation() ast.Stmt {
 	op := p.parseBinaryOp()
@@ -255,13 +456,37 @@ func (p *Parser) parseConversionContinuation() ast.Stmt {
 		return &ast.EmptyStmt{}
 	}
 
-	target := p.advance().Literal
+	target := p.parseConversionTarget()
+
+	// Past rates need the amount on the same line
+	if p.checkWord("on") && p.isDateNext() {
+		p.addError("converting at a date's rates needs the amount: 0.5 BTC in USD on 2021-11-10")
+		return &ast.EmptyStmt{}
+	}
 
 	return &ast.ExprStmt{
-		Expr: &ast.ConversionContinuation{Target: target},
+		Expr: p.parseDisplaySuffix(&ast.ConversionContinuation{Target: target}),
 	}
 }
 
+// parseConversionTarget consumes a conversion target identifier,
+// including a compound rate such as "MB/s".
+func (p *Parser) parseConversionTarget() string {
+	tok := p.advance()
+	target := tok.Literal
+	if unit := p.units.Lookup(target); unit != nil {
+		p.mark(tok, RoleUnit)
+	} else {
+		p.mark(tok, nameRole(target))
+	}
+	if unit := p.unit(target); unit != nil {
+		if per := p.parseUnitPer(unit); per != "" {
+			target += "/" + per
+		}
+	}
+	return target
+}
+
 // ════════════════════════════════════════════════════════════════
 // EXPRESSION PARSING (Pratt parser / precedence climbing)
 // ════════════════════════════════════════════════════════════════
@@ -307,21 +532,80 @@ func (p *Parser) parseBinaryExpr(minPrec int) ast.Expr {
 			return left
 		}
 
-		left = &ast.BinaryExpr{Left: left, Op: op, Right: right}
+		left = p.newBinary(left, op, right)
 	}
 
-	// Check for conversion suffix: "in EUR", "to miles"
-	if p.check(token.IN) {
+	// Check for a range: 1..10, 0..1 step 0.1
+	if minPrec == 0 && p.check(token.DOTDOT) {
+		left = p.parseRange(left)
+	}
+
+	// Check for the rates of a past date: "0.5 BTC on 2021-11-10 in USD"
+	var date *ast.DateLit
+	if minPrec == 0 && p.checkWord("on") && p.isDateNext() {
+		p.mark(p.advance(), RoleKeyword)
+		date = p.parseDate()
+		if !p.check(token.IN) {
+			p.addError("expected 'in' after date")
+		}
+	}
+
+	// Check for conversion suffix: "in EUR", "to miles".
+	// Only at the outermost level so it applies to the whole expression.
+	if minPrec == 0 && p.check(token.IN) {
 		p.advance()
 		if p.check(token.IDENTIFIER) {
-			target := p.advance().Literal
-			left = &ast.ConversionExpr{Value: left, Target: target}
+			target := p.parseConversionTarget()
+			// The date may follow the target: "$100 in EUR on 2024-01-01"
+			if date == nil && p.checkWord("on") && p.isDateNext() {
+				p.mark(p.advance(), RoleKeyword)
+				date = p.parseDate()
+			}
+			left = &ast.ConversionExpr{Value: left, Target: target, Date: date}
 		}
 	}
 
+	if minPrec == 0 {
+		left = p.parseDisplaySuffix(left)
+	}
+
 	return left
 }
 
+// parseDisplaySuffix wraps expr in a display choice if it is followed by
+// "as code" or "as symbol".
+func (p *Parser) parseDisplaySuffix(expr ast.Expr) ast.Expr {
+	if !p.checkWord("as") || p.peek().Type != token.IDENTIFIER {
+		return expr
+	}
+	if _, ok := types.ParseCurrencyDisplay(p.peek().Literal); !ok {
+		return expr
+	}
+	p.mark(p.advance(), RoleKeyword) // as
+	display := p.advance()
+	p.mark(display, RoleKeyword)
+	return &ast.DisplayExpr{Value: expr, Currency: display.Literal}
+}
+
+// parseRange parses the rest of a range after its start.
+func (p *Parser) parseRange(start ast.Expr) ast.Expr {
+	p.advance() // consume ..
+
+	r := &ast.RangeExpr{Start: start, End: p.parseBinaryExpr(1)}
+	if r.End == nil {
+		p.addError("expected end of range after '..'")
+		return start
+	}
+	if p.checkWord("step") {
+		p.mark(p.advance(), RoleKeyword)
+		if r.Step = p.parseBinaryExpr(1); r.Step == nil {
+			p.addError("expected step after 'step'")
+			return start
+		}
+	}
+	return r
+}
+
 // parseUnaryExpr parses unary expressions.
 func (p *Parser) parseUnaryExpr() ast.Expr {
 	// Unary minus or plus
@@ -352,6 +636,12 @@ func (p *Parser) parsePostfixExpr() ast.Expr {
 		return nil
 	}
 
+	// Factorial: 5!
+	for p.check(token.BANG) {
+		p.advance()
+		expr = &ast.CallExpr{Name: "factorial", Args: []ast.Expr{expr}}
+	}
+
 	// Check for "of" (percent of): 20% of 150
 	if p.check(token.OF) {
 		// Only valid if expr is a percentage
@@ -386,15 +676,21 @@ func (p *Parser) parsePrimaryExpr() ast.Expr {
 	case token.IDENTIFIER:
 		return p.parseIdentifierOrValue()
 
+	case token.DATE:
+		return p.parseISODate()
+
 	case token.LPAREN:
 		return p.parseGroupExpr()
 
+	case token.LBRACKET:
+		return p.parseList()
+
 	case token.EOF, token.NEWLINE, token.COMMENT:
 		return nil
 
 	default:
 		// Don't error on valid statement terminators
-		if tok.Type != token.RPAREN && tok.Type != token.COMMA {
+		if tok.Type != token.RPAREN && tok.Type != token.RBRACKET && tok.Type != token.COMMA {
 			p.addErrorf("unexpected token: %s", tok.Literal)
 		}
 		return nil
@@ -414,36 +710,340 @@ func (p *Parser) parseNumber() ast.Expr {
 		return &ast.NumberLit{Value: 0, Raw: tok.Literal}
 	}
 
+	// Mixed number: "3 1/2 cups"
+	if frac, fracRaw, ok := p.parseMixedFraction(tok.Literal); ok {
+		value += frac
+		tok.Literal += " " + fracRaw
+	}
+
+	// Scale: "5k", "3 million dollars", "2 dozen"
+	compact := hasScale(tok.Literal)
+	if scale, word, ok := p.parseScaleWord(); ok {
+		value *= scale
+		tok.Literal += " " + word
+		compact = compact || scale >= 1000
+	}
+
+	// "20 percent", "twenty percent of 300"
+	if p.checkWord("percent") {
+		p.mark(tok, RolePercent)
+		p.mark(p.advance(), RolePercent)
+		return &ast.PercentLit{Value: value / 100, Raw: tok.Literal + " percent"}
+	}
+
+	// Gas is counted, as plain numbers: "21 gwei * 150000 gas"
+	if p.checkWord("gas") {
+		p.mark(p.advance(), RoleUnit)
+		n := p.newNumber(value, tok.Literal+" gas", compact)
+		n.Counts = "gas"
+		return n
+	}
+
+	// "in" as an inch suffix: "3 in", "3 in to cm"
+	if p.check(token.IN) {
+		if unit := p.mixedUnitAt(0); unit != nil {
+			suffixTok := p.advance()
+			p.mark(suffixTok, RoleUnit)
+			lit := &ast.UnitLit{Amount: value, Unit: unit, Raw: tok.Literal + " " + suffixTok.Literal}
+			p.parseMixedUnit(lit)
+			return lit
+		}
+	}
+
+	// Gold of a purity, in troy ounces like "3 XAU": "0.5 18k gold"
+	if karat, width := p.karatAt(0); karat > 0 {
+		if metal := types.ParseMetal(p.peekN(width).Literal); metal != nil && metal.Code == "XAU" {
+			if lit := p.parseMetalAmount(value, metal.Unit(), tok.Literal); lit != nil {
+				return lit
+			}
+		}
+	}
+
+	// Trailing symbol: "100₽", "50 ₺"
+	if p.check(token.CURRENCY) && p.peek().Type != token.NUMBER {
+		if curr := types.LookupCurrencyBySymbol(p.current().Literal); curr != nil && curr.SymbolAfter {
+			p.mark(p.advance(), RoleCurrency)
+			return &ast.CurrencyLit{Amount: value, Currency: curr, Raw: tok.Literal + curr.Symbol, Compact: compact}
+		}
+	}
+
 	// Check for unit or currency suffix
 	if p.check(token.IDENTIFIER) {
 		suffix := p.current().Literal
 
 		// Try currency
 		if curr := types.ParseCurrency(suffix); curr != nil {
-			p.advance()
-			return &ast.CurrencyLit{Amount: value, Currency: curr, Raw: tok.Literal + " " + suffix}
+			p.mark(p.advance(), RoleCurrency)
+			return &ast.CurrencyLit{Amount: value, Currency: curr, Raw: tok.Literal + " " + suffix, Compact: compact}
 		}
 
 		// Try crypto
 		if crypto := types.ParseCrypto(suffix); crypto != nil {
-			p.advance()
+			p.mark(p.advance(), RoleCrypto)
 			return &ast.CryptoLit{Amount: value, Crypto: crypto, Raw: tok.Literal + " " + suffix}
 		}
 
+		// Try crypto sub-unit: "50000 sats", "21 gwei"
+		if unit := types.ParseCryptoUnit(suffix); unit != nil {
+			p.mark(p.advance(), RoleCrypto)
+			return &ast.CryptoLit{Amount: value, Crypto: unit.Of(), Unit: unit, Raw: tok.Literal + " " + suffix}
+		}
+
 		// Try metal
 		if metal := types.ParseMetal(suffix); metal != nil {
-			p.advance()
+			p.mark(p.advance(), RoleMetal)
 			return &ast.MetalLit{Amount: value, Metal: metal, Raw: tok.Literal + " " + suffix}
 		}
 
 		// Try unit
-		if unit := types.ParseUnit(suffix); unit != nil {
-			p.advance()
-			return &ast.UnitLit{Amount: value, Unit: unit, Raw: tok.Literal + " " + suffix}
+		if unit := p.unit(suffix); unit != nil {
+			p.mark(p.advance(), RoleUnit)
+			if lit := p.parseMetalAmount(value, unit, tok.Literal+" "+suffix); lit != nil {
+				return lit
+			}
+			if per := p.parseUnitPer(unit); per != "" {
+				suffix += "/" + per
+				unit = p.unit(suffix)
+			}
+			lit := &ast.UnitLit{Amount: value, Unit: unit, Raw: tok.Literal + " " + suffix}
+			p.parseMixedUnit(lit)
+			p.parseIngredient(lit)
+			return lit
+		}
+
+		// Try dice notation: "3d6"
+		if sides, ok := diceSides(suffix); ok && isWholeNumber(tok.Literal) {
+			p.mark(p.advance(), RoleNumber)
+			count, _ := strconv.Atoi(tok.Literal)
+			return &ast.DiceLit{Count: count, Sides: sides}
 		}
 	}
 
-	return &ast.NumberLit{Value: value, Raw: tok.Literal}
+	return p.newNumber(value, tok.Literal, compact)
+}
+
+// parseMixedFraction consumes the "1/2" of a mixed number after the whole
+// part whole, returning its value and text. Every part must be a whole
+// number.
+func (p *Parser) parseMixedFraction(whole string) (float64, string, bool) {
+	num, slash, den := p.current(), p.peek(), p.peekN(2)
+	if num.Type != token.NUMBER || slash.Type != token.SLASH || den.Type != token.NUMBER ||
+		!isWholeNumber(whole) || !isWholeNumber(num.Literal) || !isWholeNumber(den.Literal) {
+		return 0, "", false
+	}
+	n, _ := parseFloat(num.Literal)
+	d, _ := parseFloat(den.Literal)
+	if d == 0 || n >= d {
+		return 0, "", false
+	}
+
+	p.advance()
+	p.mark(p.advance(), RoleNumber) // /
+	p.advance()
+	return n / d, num.Literal + "/" + den.Literal, true
+}
+
+// isWholeNumber reports whether a number literal has only digits.
+func isWholeNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// diceSides parses the "d6" of dice notation, returning the number of
+// sides.
+func diceSides(s string) (int, bool) {
+	if len(s) < 2 || (s[0] != 'd' && s[0] != 'D') || !isWholeNumber(s[1:]) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[1:])
+	return n, err == nil
+}
+
+// parseMixedUnit folds trailing "<number> <unit>" pairs of the same unit
+// type into lit, e.g. "6 ft 2 in", 5'11", "1 h 30 min".
+// The combined amount is expressed in lit's unit.
+func (p *Parser) parseMixedUnit(lit *ast.UnitLit) {
+	for p.check(token.NUMBER) {
+		unit := p.mixedUnitAt(1)
+		if unit == nil || unit.Type != lit.Unit.Type {
+			return
+		}
+
+		numTok := p.advance()
+		unitTok := p.advance()
+		p.mark(unitTok, RoleUnit)
+		value, err := parseFloat(numTok.Literal)
+		if err != nil {
+			p.addErrorf("invalid number: %s", numTok.Literal)
+			return
+		}
+
+		converted, _ := unit.ConvertTo(value, lit.Unit)
+		if lit.Amount < 0 {
+			converted = -converted
+		}
+		lit.Amount += converted
+		lit.Raw += " " + numTok.Literal + " " + unitTok.Literal
+	}
+}
+
+// parseUnitPer consumes "/ <time unit>" after a unit with a rate (e.g.,
+// "Mbit/s", "km/h"), and again or "^2" for an acceleration ("m/s/s",
+// "m/s^2"), returning what follows the unit's "/", or "" if nothing does.
+func (p *Parser) parseUnitPer(unit *types.Unit) string {
+	per := ""
+	for p.check(token.SLASH) && p.peek().Type == token.IDENTIFIER {
+		next := p.peek().Literal
+		if p.unit(unit.Code+"/"+next) == nil {
+			break
+		}
+		unit = p.unit(unit.Code + "/" + next)
+		p.mark(p.advance(), RoleUnit) // /
+		p.mark(p.advance(), RoleUnit) // time unit
+		if per != "" {
+			per += "/"
+		}
+		per += next
+
+		// "m/s^2"
+		if p.check(token.CARET) && p.peek().Literal == "2" {
+			if squared := p.unit(unit.Code + "²"); squared != nil {
+				p.mark(p.advance(), RoleUnit)
+				p.mark(p.advance(), RoleUnit)
+				return per + "²"
+			}
+		}
+	}
+	return per
+}
+
+// parseIngredient attaches a trailing ingredient to a volume or weight
+// literal: "2 cups flour", "200 g of sugar".
+func (p *Parser) parseIngredient(lit *ast.UnitLit) {
+	if lit.Unit.Type != types.UnitTypeVolume && lit.Unit.Type != types.UnitTypeWeight {
+		return
+	}
+
+	n := 0
+	if p.check(token.OF) {
+		n = 1
+	}
+	nameTok := p.peekN(n)
+	if nameTok.Type != token.IDENTIFIER {
+		return
+	}
+	ing := types.ParseIngredient(nameTok.Literal)
+	if ing == nil {
+		return
+	}
+
+	for i := 0; i <= n; i++ {
+		p.advance()
+	}
+	p.mark(nameTok, RoleUnit)
+	lit.Ingredient = ing
+	lit.Raw += " " + nameTok.Literal
+}
+
+// karatWords name the purity of gold after a number: "18 karat".
+var karatWords = map[string]bool{
+	"k": true, "kt": true, "karat": true, "karats": true, "carat": true, "carats": true,
+}
+
+// parseMetalAmount consumes the metal after an amount of it in a unit,
+// as in "10 g gold", "2 oz of 18k gold" or "100 bbl brent", returning nil
+// if no metal priced in a unit of the same kind follows. Gold may be
+// given a purity in karats: "18k", "18 kt", "18 karat".
+func (p *Parser) parseMetalAmount(value float64, unit *types.Unit, raw string) *ast.MetalLit {
+	n := 0
+	if p.check(token.OF) {
+		n = 1
+	}
+	karat, width := p.karatAt(n)
+	metalTok := p.peekN(n + width)
+	if metalTok.Type != token.IDENTIFIER {
+		return nil
+	}
+	metal := types.ParseMetal(metalTok.Literal)
+	if metal == nil || metal.Unit() == nil || metal.Unit().Type != unit.Type {
+		return nil
+	}
+	if karat > 0 && metal.Code != "XAU" {
+		return nil
+	}
+	// Oil is cooked with in cups, and traded by the barrel
+	if unit.Code != metal.UnitName && types.ParseIngredient(metalTok.Literal) != nil {
+		return nil
+	}
+
+	// Precious metals are weighed in troy ounces: "1 oz gold" is one
+	if unit.Code == "oz" && metal.UnitName == "ozt" {
+		unit = metal.Unit()
+	}
+
+	for i := 0; i < n+width+1; i++ {
+		t := p.advance()
+		switch {
+		case i < n:
+			p.mark(t, RoleKeyword)
+		case i < n+width:
+			p.mark(t, RoleNumber)
+		default:
+			p.mark(t, RoleMetal)
+		}
+		raw += " " + t.Literal
+	}
+	return &ast.MetalLit{Amount: value, Unit: unit, Karat: karat, Metal: metal, Raw: raw}
+}
+
+// karatAt returns the purity in karats written at the token n positions
+// ahead, and how many tokens it takes, or 0, 0 if there is none.
+func (p *Parser) karatAt(n int) (int, int) {
+	tok := p.peekN(n)
+	if tok.Type != token.NUMBER {
+		return 0, 0
+	}
+	digits, suffixed := strings.CutSuffix(strings.ToLower(tok.Literal), "k")
+	karat, err := strconv.Atoi(digits)
+	if err != nil || karat < 1 || karat > types.PureKarat {
+		return 0, 0
+	}
+	if suffixed {
+		return karat, 1
+	}
+	if word := p.peekN(n + 1); word.Type == token.IDENTIFIER && karatWords[strings.ToLower(word.Literal)] {
+		return karat, 2
+	}
+	return 0, 0
+}
+
+// mixedUnitAt returns the unit named by the token n positions ahead,
+// or nil if it does not name a unit. The keyword "in" is read as inches
+// only when it ends the value ("6 ft 2 in", "6 ft 2 in to cm").
+func (p *Parser) mixedUnitAt(n int) *types.Unit {
+	tok := p.peekN(n)
+	switch tok.Type {
+	case token.IDENTIFIER:
+		return p.unit(tok.Literal)
+	case token.IN:
+		if !strings.EqualFold(tok.Literal, "in") {
+			return nil
+		}
+		switch p.peekN(n + 1).Type {
+		case token.IDENTIFIER, token.NUMBER, token.LPAREN,
+			token.DOLLAR, token.EURO, token.POUND, token.YEN, token.BITCOIN, token.CURRENCY:
+			return nil
+		}
+		return types.ParseUnit("in")
+	}
+	return nil
 }
 
 // parsePercent parses a percentage literal (e.g., "20%").
@@ -476,6 +1076,9 @@ func (p *Parser) parseCurrencyWithSymbol() ast.Expr {
 	if curr == nil {
 		crypto = types.LookupCrypto(symbol)
 	}
+	if crypto != nil {
+		p.mark(symbolTok, RoleCrypto)
+	}
 
 	// Expect a number to follow
 	if !p.check(token.NUMBER) {
@@ -498,8 +1101,16 @@ func (p *Parser) parseCurrencyWithSymbol() ast.Expr {
 
 	raw := symbol + numTok.Literal
 
+	// Scale: "$1.2M", "$3 million"
+	compact := hasScale(numTok.Literal)
+	if scale, word, ok := p.parseScaleWord(); ok {
+		amount *= scale
+		raw += " " + word
+		compact = compact || scale >= 1000
+	}
+
 	if curr != nil {
-		return &ast.CurrencyLit{Amount: amount, Currency: curr, Raw: raw}
+		return &ast.CurrencyLit{Amount: amount, Currency: curr, Raw: raw, Compact: compact}
 	}
 	if crypto != nil {
 		return &ast.CryptoLit{Amount: amount, Crypto: crypto, Raw: raw}
@@ -519,6 +1130,7 @@ func (p *Parser) parseIdentifierOrValue() ast.Expr {
 
 	// Check for function call: name(args)
 	if p.check(token.LPAREN) {
+		p.mark(tok, RoleFunction)
 		return p.parseFunctionCall(name)
 	}
 
@@ -529,13 +1141,215 @@ func (p *Parser) parseIdentifierOrValue() ast.Expr {
 		// The conversion will be handled at a higher level
 	}
 
+	// Check for a date: "Mar 1", "Mar 1 2027", "Mar 1, 2027"
+	if month, ok := types.ParseMonth(name); ok && p.check(token.NUMBER) {
+		if date := p.parseMonthDay(name, month); date != nil {
+			p.mark(tok, RoleNumber)
+			return date
+		}
+	}
+
 	// Check for special identifiers
 	lower := strings.ToLower(name)
+
+	// Check for "business days between A and B"
+	if (lower == "business days" || lower == "workdays") && p.checkWord("between") {
+		p.mark(tok, RoleKeyword)
+		return p.parseBusinessDays()
+	}
+
 	if lower == "_" || lower == "ans" {
-		return &ast.Identifier{Name: "_"} // Normalize to _
+		return p.newIdentifier("_") // Normalize to _
+	}
+
+	// Check for line values: "lines 1..20", "plot lines 1..20"
+	if lower == "lines" && p.check(token.NUMBER) {
+		p.mark(tok, RoleKeyword)
+		return p.parseLines()
+	}
+	if lower == "plot" && p.checkWord("lines") {
+		p.mark(tok, RoleFunction)
+		return &ast.CallExpr{Name: name, Args: []ast.Expr{p.parsePrimaryExpr()}}
 	}
 
-	return &ast.Identifier{Name: name}
+	// Check for exchange rates: "rate USD/EUR", "avg rate USD/EUR last 30 days"
+	if lower == "rate" && p.isRatePair(0) {
+		p.mark(tok, RoleKeyword)
+		return p.parseRate("")
+	}
+	if stat, ok := rateStats[lower]; ok && p.checkWord("rate") && p.isRatePair(1) {
+		p.mark(tok, RoleKeyword)
+		p.mark(p.advance(), RoleKeyword) // rate
+		return p.parseRate(stat)
+	}
+
+	return p.newIdentifier(name)
+}
+
+// rateStats are the statistics of a rate's history, by the word that
+// asks for them.
+var rateStats = map[string]string{
+	"avg": "avg", "average": "avg", "mean": "avg",
+	"min": "min", "low": "min", "lowest": "min",
+	"max": "max", "high": "max", "highest": "max",
+}
+
+// isRatePair reports whether the tokens n ahead are a currency pair:
+// USD/EUR.
+func (p *Parser) isRatePair(n int) bool {
+	from, slash, to := p.peekN(n), p.peekN(n+1), p.peekN(n+2)
+	return from.Type == token.IDENTIFIER && slash.Type == token.SLASH && to.Type == token.IDENTIFIER &&
+		rateCode(from.Literal) != "" && rateCode(to.Literal) != ""
+}
+
+// rateCode returns the code of a currency, crypto or metal, or "".
+func rateCode(s string) string {
+	if c := types.ParseCurrency(s); c != nil {
+		return c.Code
+	}
+	if c := types.ParseCrypto(s); c != nil {
+		return c.Code
+	}
+	if m := types.ParseMetal(s); m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+// parseRate parses the pair and period after "rate": USD/EUR last 30 days.
+// A statistic needs a period.
+func (p *Parser) parseRate(stat string) ast.Expr {
+	fromTok := p.advance()
+	p.advance() // /
+	toTok := p.advance()
+	p.mark(fromTok, nameRole(fromTok.Literal))
+	p.mark(toTok, nameRole(toTok.Literal))
+	from, to := rateCode(fromTok.Literal), rateCode(toTok.Literal)
+	r := &ast.RateExpr{Stat: stat, From: from, To: to}
+
+	if p.checkWord("last") {
+		p.mark(p.advance(), RoleKeyword)
+		if unit := p.unit(p.current().Literal); p.check(token.IDENTIFIER) && unit != nil &&
+			unit.Type == types.UnitTypeTime {
+			// "last week" is the last 1 week
+			period := p.advance()
+			p.mark(period, RoleUnit)
+			r.Period = &ast.UnitLit{Amount: 1, Unit: unit, Raw: period.Literal}
+		} else {
+			r.Period = p.parsePrimaryExpr()
+		}
+	}
+	if r.Period == nil && stat != "" {
+		p.addError("expected a period, e.g. " + stat + " rate " + from + "/" + to + " last 30 days")
+	}
+	return r
+}
+
+// parseLines parses the range after "lines": lines 1..20.
+func (p *Parser) parseLines() ast.Expr {
+	r, ok := p.parseExpression().(*ast.RangeExpr)
+	if !ok {
+		p.addError("expected a range of lines, e.g. lines 1..20")
+		return &ast.NumberLit{Value: 0}
+	}
+	return &ast.LinesExpr{Range: r}
+}
+
+// parseISODate parses a YYYY-MM-DD date token.
+func (p *Parser) parseISODate() ast.Expr {
+	tok := p.advance()
+	t, ok := types.ParseDate(tok.Literal)
+	if !ok {
+		p.addErrorf("invalid date: %s", tok.Literal)
+		return &ast.NumberLit{Value: 0, Raw: tok.Literal}
+	}
+	return &ast.DateLit{Year: t.Year(), Month: t.Month(), Day: t.Day(), Raw: tok.Literal}
+}
+
+// isDateNext reports whether the next token starts a date: 2021-11-10,
+// Nov 10.
+func (p *Parser) isDateNext() bool {
+	next := p.peek()
+	if next.Type == token.DATE {
+		return true
+	}
+	_, ok := types.ParseMonth(next.Literal)
+	return ok && next.Type == token.IDENTIFIER && p.peekN(2).Type == token.NUMBER
+}
+
+// parseDate parses a date: an ISO date, or a month and day. Returns nil
+// if it is invalid, with the error added.
+func (p *Parser) parseDate() *ast.DateLit {
+	if p.check(token.DATE) {
+		date, _ := p.parseISODate().(*ast.DateLit)
+		return date
+	}
+
+	tok := p.advance()
+	month, _ := types.ParseMonth(tok.Literal)
+	date, ok := p.parseMonthDay(tok.Literal, month).(*ast.DateLit)
+	if !ok {
+		p.addErrorf("invalid date: %s %s", tok.Literal, p.current().Literal)
+		return nil
+	}
+	p.mark(tok, RoleNumber)
+	return date
+}
+
+// parseMonthDay parses the day (and optional year) after a month name.
+// Returns nil without consuming anything if the number is not a valid day.
+func (p *Parser) parseMonthDay(name string, month time.Month) ast.Expr {
+	dayTok := p.current()
+	day, err := strconv.Atoi(dayTok.Literal)
+	if err != nil || day < 1 || day > 31 {
+		return nil
+	}
+	p.advance()
+
+	date := &ast.DateLit{Month: month, Day: day, Raw: name + " " + dayTok.Literal}
+
+	// "Mar 1 2027", or as dates are shown: "Mar 1, 2027"
+	yearAt := 0
+	if p.check(token.COMMA) {
+		yearAt = 1
+	}
+	yearTok := p.peekN(yearAt)
+	if yearTok.Type == token.NUMBER && len(yearTok.Literal) == 4 {
+		if year, err := strconv.Atoi(yearTok.Literal); err == nil {
+			if yearAt == 1 {
+				p.advance() // ,
+				date.Raw += ","
+			}
+			date.Year = year
+			date.Raw += " " + p.advance().Literal
+		}
+	}
+	return date
+}
+
+// parseBusinessDays parses "between A and B" after "business days".
+func (p *Parser) parseBusinessDays() ast.Expr {
+	p.mark(p.advance(), RoleKeyword) // between
+
+	from := p.parseBinaryExpr(1)
+	if from == nil || !p.checkWord("and") {
+		p.addError("expected 'business days between <date> and <date>'")
+		return &ast.NumberLit{Value: 0}
+	}
+	p.mark(p.advance(), RoleKeyword) // and
+
+	to := p.parseBinaryExpr(1)
+	if to == nil {
+		p.addError("expected date after 'and'")
+		return &ast.NumberLit{Value: 0}
+	}
+
+	return &ast.BusinessDaysExpr{From: from, To: to}
+}
+
+// checkWord returns true if the current token is the given word (case-insensitive).
+func (p *Parser) checkWord(word string) bool {
+	return p.check(token.IDENTIFIER) && strings.EqualFold(p.current().Literal, word)
 }
 
 // parseFunctionCall parses a function call.
@@ -547,6 +1361,11 @@ func (p *Parser) parseFunctionCall(name string) ast.Expr {
 	// Parse arguments
 	if !p.check(token.RPAREN) {
 		for {
+			// The point to differentiate at: derive(f, at 3)
+			if strings.EqualFold(name, "derive") && p.checkWord("at") && len(args) == 1 {
+				p.mark(p.advance(), RoleKeyword)
+			}
+
 			arg := p.parseExpression()
 			if arg != nil {
 				args = append(args, arg)
@@ -560,9 +1379,41 @@ func (p *Parser) parseFunctionCall(name string) ast.Expr {
 
 	p.expect(token.RPAREN, "expected ')' after function arguments")
 
+	// One die without a count: dice(d6)
+	if strings.EqualFold(name, "dice") && len(args) == 1 {
+		if ident, ok := args[0].(*ast.Identifier); ok {
+			if sides, ok := diceSides(ident.Name); ok {
+				args[0] = &ast.DiceLit{Count: 1, Sides: sides}
+			}
+		}
+	}
+
 	return &ast.CallExpr{Name: name, Args: args}
 }
 
+// parseList parses a list literal: [1, 2, 3].
+func (p *Parser) parseList() ast.Expr {
+	p.advance() // consume [
+
+	var elems []ast.Expr
+	if !p.check(token.RBRACKET) {
+		for {
+			elem := p.parseExpression()
+			if elem != nil {
+				elems = append(elems, elem)
+			}
+
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	p.expect(token.RBRACKET, "expected ']' after list")
+
+	return &ast.ListLit{Elems: elems}
+}
+
 // parseGroupExpr parses a parenthesized expression.
 func (p *Parser) parseGroupExpr() ast.Expr {
 	p.advance() // consume (
@@ -584,7 +1435,8 @@ func (p *Parser) parseGroupExpr() ast.Expr {
 
 // isBinaryOp returns true if current token is a binary operator.
 func (p *Parser) isBinaryOp() bool {
-	return p.checkAny(token.PLUS, token.MINUS, token.STAR, token.SLASH, token.CARET, token.POWER)
+	return p.checkAny(token.PLUS, token.MINUS, token.STAR, token.SLASH, token.DSLASH, token.MOD, token.CARET, token.POWER,
+		token.PLUSMINUS)
 }
 
 // currentBinaryOp returns the current token as a BinaryOp.
@@ -598,8 +1450,14 @@ func (p *Parser) currentBinaryOp() ast.BinaryOp {
 		return ast.OpMul
 	case token.SLASH:
 		return ast.OpDiv
+	case token.DSLASH:
+		return ast.OpIntDiv
+	case token.MOD:
+		return ast.OpMod
 	case token.CARET, token.POWER:
 		return ast.OpPow
+	case token.PLUSMINUS:
+		return ast.OpPlusMinus
 	default:
 		return ast.OpAdd
 	}
@@ -620,23 +1478,116 @@ func (p *Parser) parseBinaryOp() ast.BinaryOp {
 func parseFloat(s string) (float64, error) {
 	// Remove thousands separators
 	s = strings.ReplaceAll(s, ",", "")
-	return strconv.ParseFloat(s, 64)
+	s, scale := splitScale(s)
+	n, err := strconv.ParseFloat(s, 64)
+	return n * scale, err
+}
+
+// splitScale splits the scale suffix off a number ("5k", "1.2bn"),
+// returning the digits and the suffix's multiplier, or 1 if it has none.
+func splitScale(s string) (string, float64) {
+	i := strings.LastIndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' }) + 1
+	if i == 0 || i == len(s) {
+		return s, 1
+	}
+	if scale, ok := types.NumberScale(s[i:]); ok {
+		return s[:i], scale
+	}
+	return s, 1
+}
+
+// hasScale reports whether a number literal has a scale suffix.
+func hasScale(s string) bool {
+	_, scale := splitScale(s)
+	return scale != 1
+}
+
+// parseScaleWord consumes a scale word after a number ("3 million"),
+// returning its multiplier and text. Single letters are units here:
+// "5 K" is kelvin, "5 M" is meters.
+func (p *Parser) parseScaleWord() (float64, string, bool) {
+	tok := p.current()
+	if tok.Type != token.IDENTIFIER || len(tok.Literal) < 2 {
+		return 0, "", false
+	}
+	scale, ok := types.NumberScale(tok.Literal)
+	if !ok {
+		return 0, "", false
+	}
+	p.mark(p.advance(), RoleNumber)
+	return scale, tok.Literal, true
 }
 
 // ════════════════════════════════════════════════════════════════
 // CONVENIENCE FUNCTIONS
 // ════════════════════════════════════════════════════════════════
 
+// parserPool keeps parsers, with their lexer and token buffer, between
+// calls to the parse functions, which are called for every line.
+var parserPool = sync.Pool{
+	New: func() any { return &Parser{lexer: &lexer.Lexer{}} },
+}
+
+// getParser takes a parser from the pool and starts it on input.
+func getParser(input string, locale types.InputLocale, units *types.UnitSet) *Parser {
+	p := parserPool.Get().(*Parser)
+	p.lexer.Reset(input, locale)
+	p.input = input
+	p.lexed = false
+	p.units = units
+	return p
+}
+
+// putParser returns a parser to the pool. The errors were handed to the
+// caller, so they aren't kept; tokens are cleared so the pool doesn't
+// keep the input alive.
+func putParser(p *Parser) {
+	clear(p.tokens)
+	p.tokens = p.tokens[:0]
+	p.pos = 0
+	p.errors = nil
+	p.input = ""
+	p.units = nil
+	p.lexer.Reset("", types.LocaleDecimalPoint)
+	parserPool.Put(p)
+}
+
 // ParseLine parses a single line of input.
 func ParseLine(input string) (*ast.Line, []*errors.Error) {
-	p := New(input)
+	return ParseLineWithLocale(input, types.LocaleDecimalPoint)
+}
+
+// ParseLineWithLocale parses a single line of input, reading numbers as
+// written in locale.
+func ParseLineWithLocale(input string, locale types.InputLocale) (*ast.Line, []*errors.Error) {
+	return ParseLineWithUnits(input, locale, nil)
+}
+
+// ParseLineWithUnits parses a single line of input, reading numbers as
+// written in locale and the units of set besides the built-in ones.
+func ParseLineWithUnits(input string, locale types.InputLocale, set *types.UnitSet) (*ast.Line, []*errors.Error) {
+	p := getParser(input, locale, set)
+	defer putParser(p)
 	line := p.ParseLine()
 	return line, p.Errors()
 }
 
 // ParseExpr parses a single expression.
 func ParseExpr(input string) (ast.Expr, []*errors.Error) {
-	p := New(input)
+	return ParseExprWithLocale(input, types.LocaleDecimalPoint)
+}
+
+// ParseExprWithLocale parses a single expression, reading numbers as
+// written in locale.
+func ParseExprWithLocale(input string, locale types.InputLocale) (ast.Expr, []*errors.Error) {
+	return ParseExprWithUnits(input, locale, nil)
+}
+
+// ParseExprWithUnits parses a single expression, reading numbers as
+// written in locale and the units of set besides the built-in ones.
+func ParseExprWithUnits(input string, locale types.InputLocale, set *types.UnitSet) (ast.Expr, []*errors.Error) {
+	p := getParser(input, locale, set)
+	defer putParser(p)
 	expr := p.parseExpression()
 	return expr, p.Errors()
 }
@@ -0,0 +1,154 @@
+// internal/parser/spans.go
+
+package parser
+
+import (
+	"github.com/0xsj/numio/internal/token"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Role is what a token means to the parser, for highlighting.
+type Role int
+
+const (
+	RoleNone     Role = iota // Tokens with no meaning of their own
+	RoleNumber               // 42, three hundred, 3 1/2, 2026-03-01, Mar 1
+	RolePercent              // 20%, 20 percent
+	RoleOperator             // + - * / ^ ! , ..
+	RoleParen                // ( ) [ ]
+	RoleAssign               // =
+	RoleKeyword              // in, on, of, mod, !pin, lines, rate, step, as, begin, end, % of total
+	RoleFunction             // sqrt(...), f(x) = ...
+	RoleVariable             // Names of variables and parameters
+	RoleCurrency             // $, USD, turkish lira
+	RoleCrypto               // ₿, BTC
+	RoleMetal                // gold, XAU
+	RoleUnit                 // km, h, cups, and ingredients: flour
+	RoleLabel                // Words after the statement: $250 rent
+	RoleComment              // # note
+	RoleError                // Characters the lexer doesn't know
+)
+
+var roleNames = map[Role]string{
+	RoleNone:     "none",
+	RoleNumber:   "number",
+	RolePercent:  "percent",
+	RoleOperator: "operator",
+	RoleParen:    "paren",
+	RoleAssign:   "assign",
+	RoleKeyword:  "keyword",
+	RoleFunction: "function",
+	RoleVariable: "variable",
+	RoleCurrency: "currency",
+	RoleCrypto:   "crypto",
+	RoleMetal:    "metal",
+	RoleUnit:     "unit",
+	RoleLabel:    "label",
+	RoleComment:  "comment",
+	RoleError:    "error",
+}
+
+// String returns the role's name.
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Span is a token of the input and the role the parser gave it. The
+// token's Text is what was written, which its literal may not be.
+type Span struct {
+	token.Token
+	Role Role
+}
+
+// Spans parses input, as lines, and returns its tokens with the role each
+// played: a word is a currency or unit where the parser read it as one, a
+// function where it was called, and so on. Tokens after an error that the
+// parser skipped keep the role their type suggests.
+func Spans(input string, locale types.InputLocale) []Span {
+	return SpansWithUnits(input, locale, nil)
+}
+
+// SpansWithUnits returns the spans of input as Spans does, reading the
+// units of set besides the built-in ones.
+func SpansWithUnits(input string, locale types.InputLocale, set *types.UnitSet) []Span {
+	p := getParser(input, locale, set)
+	defer putParser(p)
+	p.spans = []Span{}
+
+	for !p.check(token.EOF) {
+		p.ParseLine()
+		p.match(token.NEWLINE)
+	}
+	spans := p.spans
+	p.spans = nil
+	return spans
+}
+
+// record notes a token the parser consumed, with the role its type
+// suggests, if the parser is keeping spans.
+func (p *Parser) record(tok token.Token) {
+	if p.spans != nil && tok.Type != token.EOF {
+		p.spans = append(p.spans, Span{Token: tok, Role: typeRole(tok)})
+	}
+}
+
+// mark sets the role of a token the parser consumed.
+func (p *Parser) mark(tok token.Token, role Role) {
+	for i := len(p.spans) - 1; i >= 0; i-- {
+		if p.spans[i].Pos == tok.Pos {
+			p.spans[i].Role = role
+			return
+		}
+	}
+}
+
+// typeRole returns the role a token has unless the parser reads it
+// otherwise.
+func typeRole(tok token.Token) Role {
+	switch tok.Type {
+	case token.NUMBER, token.DATE:
+		return RoleNumber
+	case token.PERCENT:
+		return RolePercent
+	case token.PLUS, token.MINUS, token.STAR, token.SLASH, token.DSLASH, token.CARET, token.POWER,
+		token.PLUSMINUS, token.BANG, token.COMMA, token.DOTDOT:
+		return RoleOperator
+	case token.LPAREN, token.RPAREN, token.LBRACKET, token.RBRACKET:
+		return RoleParen
+	case token.EQUALS:
+		return RoleAssign
+	case token.IN, token.OF, token.MOD, token.PIN:
+		return RoleKeyword
+	case token.DOLLAR, token.EURO, token.POUND, token.YEN, token.CURRENCY:
+		return RoleCurrency
+	case token.BITCOIN:
+		return RoleCrypto
+	case token.IDENTIFIER:
+		return RoleVariable
+	case token.COMMENT:
+		return RoleComment
+	case token.ILLEGAL:
+		return RoleError
+	}
+	return RoleNone
+}
+
+// nameRole returns the role of a name that stands for a currency, crypto,
+// metal or unit where one is expected, as in a conversion target, or
+// RoleVariable.
+func nameRole(name string) Role {
+	switch {
+	case types.ParseCurrency(name) != nil:
+		return RoleCurrency
+	case types.ParseCrypto(name) != nil, types.ParseCryptoUnit(name) != nil:
+		return RoleCrypto
+	case types.ParseMetal(name) != nil:
+		return RoleMetal
+	case types.ParseUnit(name) != nil:
+		return RoleUnit
+	}
+	return RoleVariable
+}
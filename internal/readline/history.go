@@ -0,0 +1,112 @@
+// internal/readline/history.go
+
+package readline
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHistorySize is the number of entries a history keeps.
+const DefaultHistorySize = 1000
+
+// History is a list of entered lines, oldest first, optionally backed by
+// a file that each new entry is appended to.
+type History struct {
+	entries []string
+	path    string
+	size    int
+}
+
+// NewHistory creates an empty in-memory history.
+func NewHistory() *History {
+	return &History{size: DefaultHistorySize}
+}
+
+// LoadHistory creates a history backed by the file at path, loading its
+// most recent entries. A missing file starts an empty history.
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path, size: DefaultHistorySize}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	return h, scanner.Err()
+}
+
+// Add appends a line, skipping blank lines and repeats of the last entry.
+// With a backing file, the line is appended to it.
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.Contains(line, "\n") {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Len returns the number of entries.
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// At returns entry i, oldest first.
+func (h *History) At(i int) string {
+	return h.entries[i]
+}
+
+// Entries returns a copy of the entries, oldest first.
+func (h *History) Entries() []string {
+	return append([]string(nil), h.entries...)
+}
+
+// Search returns the index of the newest entry before from that contains
+// query, or -1.
+func (h *History) Search(query string, from int) int {
+	for i := min(from, len(h.entries)) - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
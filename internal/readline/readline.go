@@ -0,0 +1,589 @@
+// internal/readline/readline.go
+
+// Package readline reads lines from a terminal with line editing, history
+// navigation, reverse search, and tab completion. When input isn't a
+// terminal it reads plain lines.
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// ErrInterrupt is returned by ReadLine when the line is cancelled with
+// Ctrl+C.
+var ErrInterrupt = errors.New("interrupt")
+
+// Completer returns the word before the cursor that is being completed,
+// and the candidates to replace it with.
+type Completer func(before string) (word string, candidates []string)
+
+// maxListed is the most completion candidates listed at once.
+const maxListed = 100
+
+// Editor reads lines from standard input.
+type Editor struct {
+	Prompt   string
+	History  *History
+	Complete Completer
+
+	in  *bufio.Reader
+	out *bufio.Writer
+	fd  uintptr
+}
+
+// New creates an editor reading from standard input with the given
+// prompt. A nil history keeps one in memory.
+func New(prompt string, history *History) *Editor {
+	if history == nil {
+		history = NewHistory()
+	}
+	return &Editor{
+		Prompt:  prompt,
+		History: history,
+		in:      bufio.NewReader(os.Stdin),
+		out:     bufio.NewWriter(os.Stdout),
+		fd:      os.Stdin.Fd(),
+	}
+}
+
+// ReadLine reads a line. It returns io.EOF at the end of input (Ctrl+D
+// on an empty line) and ErrInterrupt when the line is cancelled. Lines
+// aren't added to the history; callers add the ones they accept.
+func (e *Editor) ReadLine() (string, error) {
+	if !term.IsTerminal(e.fd) {
+		return e.readPlain()
+	}
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return e.readPlain()
+	}
+	defer term.Restore(e.fd, state)
+
+	s := &lineState{e: e, histIdx: e.History.Len()}
+	return s.run()
+}
+
+// readPlain prints the prompt and reads a line without editing.
+func (e *Editor) readPlain() (string, error) {
+	fmt.Fprint(e.out, e.Prompt)
+	e.out.Flush()
+
+	line, err := e.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Fprintln(e.out)
+		e.out.Flush()
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// KEYS
+// ════════════════════════════════════════════════════════════════
+
+// key is a typed rune, or one of the special keys below.
+type key rune
+
+const (
+	keyNone key = -(iota + 1)
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyWordLeft
+	keyWordRight
+	keyDeleteWord
+	keyEsc
+)
+
+// Control characters.
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	ctrlH     = 8
+	tab       = 9
+	ctrlJ     = 10
+	ctrlK     = 11
+	ctrlL     = 12
+	ctrlM     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	ctrlW     = 23
+	esc       = 27
+	backspace = 127
+)
+
+// readKey reads a key, decoding escape sequences.
+func (e *Editor) readKey() (key, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return keyNone, err
+	}
+	if r != esc {
+		return key(r), nil
+	}
+
+	// A lone Esc has nothing following it
+	if e.in.Buffered() == 0 {
+		return keyEsc, nil
+	}
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return keyNone, err
+	}
+
+	switch next {
+	case 'b':
+		return keyWordLeft, nil
+	case 'f':
+		return keyWordRight, nil
+	case backspace, ctrlH:
+		return keyDeleteWord, nil
+	case '[', 'O':
+	default:
+		return keyNone, nil
+	}
+
+	// CSI: parameters, then a final byte
+	var params strings.Builder
+	for {
+		c, _, err := e.in.ReadRune()
+		if err != nil {
+			return keyNone, err
+		}
+		if c >= 0x40 && c <= 0x7e {
+			return csiKey(params.String(), c), nil
+		}
+		params.WriteRune(c)
+	}
+}
+
+// csiKey maps a CSI sequence to a key.
+func csiKey(params string, final rune) key {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		if strings.HasSuffix(params, ";5") || strings.HasSuffix(params, ";3") {
+			return keyWordRight
+		}
+		return keyRight
+	case 'D':
+		if strings.HasSuffix(params, ";5") || strings.HasSuffix(params, ";3") {
+			return keyWordLeft
+		}
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		}
+	}
+	return keyNone
+}
+
+// ════════════════════════════════════════════════════════════════
+// LINE EDITING
+// ════════════════════════════════════════════════════════════════
+
+// lineState is the state of the line being read.
+type lineState struct {
+	e    *Editor
+	line []rune
+	pos  int
+
+	// History browsing: histIdx is History.Len() for the edited line,
+	// which is kept in saved while browsing
+	histIdx int
+	saved   []rune
+
+	lastTab bool
+
+	// Reverse search
+	searching bool
+	query     []rune
+	match     int // History index, or -1
+	failed    bool
+}
+
+// run reads keys until the line is entered or cancelled.
+func (s *lineState) run() (string, error) {
+	s.refresh()
+	for {
+		k, err := s.e.readKey()
+		if err != nil {
+			s.e.out.WriteString("\r\n")
+			s.e.out.Flush()
+			return "", err
+		}
+
+		if s.searching && !s.searchKey(k) {
+			s.refresh()
+			continue
+		}
+
+		tabbed := k == tab
+		done, line, err := s.key(k)
+		s.lastTab = tabbed
+		if done {
+			s.e.out.Flush()
+			return line, err
+		}
+		s.refresh()
+	}
+}
+
+// key applies a key. It returns done when the line is finished.
+func (s *lineState) key(k key) (done bool, line string, err error) {
+	switch k {
+	case ctrlM, ctrlJ:
+		s.e.out.WriteString("\r\n")
+		return true, string(s.line), nil
+
+	case ctrlC:
+		s.e.out.WriteString("^C\r\n")
+		return true, "", ErrInterrupt
+
+	case ctrlD:
+		if len(s.line) == 0 {
+			s.e.out.WriteString("\r\n")
+			return true, "", io.EOF
+		}
+		s.deleteAt(s.pos)
+
+	case backspace, ctrlH:
+		if s.pos > 0 {
+			s.pos--
+			s.deleteAt(s.pos)
+		}
+
+	case keyDelete:
+		s.deleteAt(s.pos)
+
+	case ctrlA, keyHome:
+		s.pos = 0
+
+	case ctrlE, keyEnd:
+		s.pos = len(s.line)
+
+	case ctrlB, keyLeft:
+		s.pos = max(0, s.pos-1)
+
+	case ctrlF, keyRight:
+		s.pos = min(len(s.line), s.pos+1)
+
+	case keyWordLeft:
+		s.pos = s.wordStart()
+
+	case keyWordRight:
+		s.pos = s.wordEnd()
+
+	case ctrlK:
+		s.line = s.line[:s.pos]
+
+	case ctrlU:
+		s.line = s.line[s.pos:]
+		s.pos = 0
+
+	case ctrlW, keyDeleteWord:
+		start := s.wordStart()
+		s.line = append(s.line[:start], s.line[s.pos:]...)
+		s.pos = start
+
+	case ctrlL:
+		s.e.out.WriteString("\x1b[H\x1b[2J")
+
+	case ctrlP, keyUp:
+		s.browse(-1)
+
+	case ctrlN, keyDown:
+		s.browse(1)
+
+	case ctrlR:
+		s.searching, s.query, s.match, s.failed = true, nil, -1, false
+
+	case tab:
+		s.complete()
+
+	default:
+		if k >= 0 && unicode.IsPrint(rune(k)) {
+			s.insert([]rune{rune(k)})
+		}
+	}
+	return false, "", nil
+}
+
+// insert inserts text at the cursor.
+func (s *lineState) insert(text []rune) {
+	line := make([]rune, 0, len(s.line)+len(text))
+	line = append(line, s.line[:s.pos]...)
+	line = append(line, text...)
+	s.line = append(line, s.line[s.pos:]...)
+	s.pos += len(text)
+}
+
+// deleteAt deletes the rune at i, if any.
+func (s *lineState) deleteAt(i int) {
+	if i < len(s.line) {
+		s.line = append(s.line[:i], s.line[i+1:]...)
+	}
+}
+
+// wordStart returns the start of the word before the cursor.
+func (s *lineState) wordStart() int {
+	i := s.pos
+	for i > 0 && !isWordRune(s.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(s.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns the end of the word after the cursor.
+func (s *lineState) wordEnd() int {
+	i := s.pos
+	for i < len(s.line) && !isWordRune(s.line[i]) {
+		i++
+	}
+	for i < len(s.line) && isWordRune(s.line[i]) {
+		i++
+	}
+	return i
+}
+
+// isWordRune reports whether r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// ════════════════════════════════════════════════════════════════
+// HISTORY
+// ════════════════════════════════════════════════════════════════
+
+// browse moves n entries through the history (negative is older),
+// keeping the edited line to come back to.
+func (s *lineState) browse(n int) {
+	h := s.e.History
+	idx := s.histIdx + n
+	if idx < 0 || idx > h.Len() {
+		return
+	}
+	if s.histIdx == h.Len() {
+		s.saved = s.line
+	}
+
+	s.histIdx = idx
+	if idx == h.Len() {
+		s.line = s.saved
+	} else {
+		s.line = []rune(h.At(idx))
+	}
+	s.pos = len(s.line)
+}
+
+// searchKey handles a key during reverse search. It returns true when the
+// search ended and the key should still be applied to the line.
+func (s *lineState) searchKey(k key) bool {
+	h := s.e.History
+	switch k {
+	case ctrlR:
+		from := s.match
+		if from < 0 {
+			from = h.Len()
+		}
+		s.search(from)
+		return false
+
+	case backspace, ctrlH:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+		}
+		s.search(h.Len())
+		return false
+
+	case ctrlG, ctrlC, keyEsc:
+		s.searching = false
+		return false
+	}
+
+	if k >= 0 && unicode.IsPrint(rune(k)) {
+		s.query = append(s.query, rune(k))
+		from := h.Len()
+		if s.match >= 0 {
+			from = s.match + 1
+		}
+		s.search(from)
+		return false
+	}
+
+	// Any other key takes the match into the line
+	s.searching = false
+	if s.match >= 0 {
+		s.line = []rune(h.At(s.match))
+		s.pos = len(s.line)
+		s.histIdx = h.Len()
+	}
+	return true
+}
+
+// search finds the newest entry before from matching the query.
+func (s *lineState) search(from int) {
+	if len(s.query) == 0 {
+		s.match, s.failed = -1, false
+		return
+	}
+	if i := s.e.History.Search(string(s.query), from); i >= 0 {
+		s.match, s.failed = i, false
+	} else {
+		s.failed = true
+	}
+}
+
+// ════════════════════════════════════════════════════════════════
+// COMPLETION
+// ════════════════════════════════════════════════════════════════
+
+// complete completes the word before the cursor: a single candidate
+// replaces it, several extend it to their common prefix, and a second Tab
+// lists them.
+func (s *lineState) complete() {
+	if s.e.Complete == nil {
+		return
+	}
+	word, candidates := s.e.Complete(string(s.line[:s.pos]))
+	wordLen := len([]rune(word))
+
+	switch {
+	case len(candidates) == 0:
+		s.e.out.WriteString("\a")
+	case len(candidates) == 1:
+		s.replaceWord(wordLen, candidates[0])
+	default:
+		if prefix := commonPrefix(candidates); len([]rune(prefix)) > wordLen {
+			s.replaceWord(wordLen, prefix)
+		} else if s.lastTab {
+			s.list(candidates)
+		} else {
+			s.e.out.WriteString("\a")
+		}
+	}
+}
+
+// replaceWord replaces the n runes before the cursor with text.
+func (s *lineState) replaceWord(n int, text string) {
+	start := max(0, s.pos-n)
+	s.line = append(s.line[:start:start], s.line[s.pos:]...)
+	s.pos = start
+	s.insert([]rune(text))
+}
+
+// list prints completion candidates below the line.
+func (s *lineState) list(candidates []string) {
+	width := s.width()
+	more := len(candidates) - maxListed
+	if more > 0 {
+		candidates = candidates[:maxListed]
+	}
+
+	s.e.out.WriteString("\r\n")
+	col := 0
+	for _, c := range candidates {
+		if col > 0 && col+len(c)+2 > width {
+			s.e.out.WriteString("\r\n")
+			col = 0
+		}
+		s.e.out.WriteString(c + "  ")
+		col += len(c) + 2
+	}
+	if more > 0 {
+		fmt.Fprintf(s.e.out, "\r\n(%d more)", more)
+	}
+	s.e.out.WriteString("\r\n")
+}
+
+// commonPrefix returns the longest prefix shared by all of ss.
+func commonPrefix(ss []string) string {
+	prefix := []rune(ss[0])
+	for _, s := range ss[1:] {
+		r := []rune(s)
+		n := 0
+		for n < len(prefix) && n < len(r) && prefix[n] == r[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
+
+// ════════════════════════════════════════════════════════════════
+// RENDERING
+// ════════════════════════════════════════════════════════════════
+
+// width returns the terminal width.
+func (s *lineState) width() int {
+	if w, _, err := term.GetSize(s.e.fd); err == nil && w > 0 {
+		return w
+	}
+	return 80
+}
+
+// refresh redraws the prompt and line, scrolling a long line so the cursor
+// stays visible.
+func (s *lineState) refresh() {
+	prompt, text, cursor := s.e.Prompt, s.line, s.pos
+	if s.searching {
+		prompt = fmt.Sprintf("(reverse-i-search)`%s': ", string(s.query))
+		if s.failed {
+			prompt = "(failed " + prompt[1:]
+		}
+		text, cursor = nil, 0
+		if s.match >= 0 {
+			text = []rune(s.e.History.At(s.match))
+			if i := strings.Index(string(text), string(s.query)); i >= 0 {
+				cursor = len([]rune(string(text)[:i]))
+			}
+		}
+	}
+
+	promptLen := len([]rune(prompt))
+	avail := max(1, s.width()-promptLen-1)
+	offset := max(0, cursor-avail)
+	visible := text[offset:min(len(text), offset+avail)]
+
+	out := s.e.out
+	out.WriteString("\r" + prompt + string(visible) + "\x1b[K\r")
+	if col := promptLen + cursor - offset; col > 0 {
+		fmt.Fprintf(out, "\x1b[%dC", col)
+	}
+	out.Flush()
+}
@@ -0,0 +1,337 @@
+// internal/server/grpc.go
+
+//go:build !(js && wasm)
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/numiopb"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// GRPCOptions configures the gRPC server.
+type GRPCOptions struct {
+	Addr     string   // Listen address, e.g. ":9090"
+	APIKey   string   // If set, required as "authorization: Bearer <key>" or "x-api-key" metadata
+	PoolSize int      // Number of engines (default DefaultPoolSize)
+	Offline  bool     // Never fetch rates
+	Metrics  *Metrics // If set, recorded
+}
+
+// GRPC serves the numio.v1.Numio service (see pkg/numiopb/numio.proto)
+// backed by a pool of engines. Like HTTP, each call gets a clean engine
+// and all engines share one rate cache.
+type GRPC struct {
+	numiopb.UnimplementedNumioServer
+
+	base    *engine.Engine
+	pool    *enginePool
+	apiKey  string
+	addr    string
+	offline bool
+	metrics *Metrics
+
+	// StreamRates calls waiting for the next refresh
+	mu       sync.Mutex
+	watchers map[chan struct{}]struct{}
+}
+
+// NewGRPC creates a gRPC server whose engines are clones of base
+// (sharing its settings and rate cache).
+func NewGRPC(base *engine.Engine, opts GRPCOptions) *GRPC {
+	size := opts.PoolSize
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+
+	g := &GRPC{
+		base:     base,
+		pool:     newEnginePool(base, size),
+		apiKey:   opts.APIKey,
+		addr:     opts.Addr,
+		offline:  opts.Offline,
+		metrics:  opts.Metrics,
+		watchers: make(map[chan struct{}]struct{}),
+	}
+	base.RateCache().OnRefresh(func(r cache.RefreshResult) {
+		if r.Err == nil {
+			g.ratesChanged()
+		}
+	})
+	return g
+}
+
+// Server returns a gRPC server with the service registered, including
+// authentication.
+func (g *GRPC) Server() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(g.authUnary),
+		grpc.StreamInterceptor(g.authStream),
+	)
+	numiopb.RegisterNumioServer(srv, g)
+	return srv
+}
+
+// ListenAndServe serves until ctx is cancelled.
+// Expired rates are refreshed in the background unless offline.
+func (g *GRPC) ListenAndServe(ctx context.Context) error {
+	lis, err := net.Listen("tcp", g.addr)
+	if err != nil {
+		return err
+	}
+	srv := g.Server()
+
+	if !g.offline {
+		go refreshRates(ctx, g.base, g.ratesChanged)
+	}
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	return srv.Serve(lis)
+}
+
+// ════════════════════════════════════════════════════════════════
+// METHODS
+// ════════════════════════════════════════════════════════════════
+
+// Eval evaluates a single line.
+func (g *GRPC) Eval(ctx context.Context, req *numiopb.EvalRequest) (*numiopb.Value, error) {
+	eng, ok := g.pool.acquire(ctx)
+	if !ok {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	defer g.pool.release(eng)
+
+	start := time.Now()
+	v := eng.EvalContext(ctx, req.GetInput())
+	g.metrics.observe("grpc", "Eval", start, eng, []string{req.GetInput()}, v)
+	return valueProto(eng, v), nil
+}
+
+// EvalDocument evaluates a document, returning a result per line.
+func (g *GRPC) EvalDocument(ctx context.Context, req *numiopb.EvalDocumentRequest) (*numiopb.EvalDocumentResponse, error) {
+	eng, ok := g.pool.acquire(ctx)
+	if !ok {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	defer g.pool.release(eng)
+
+	start := time.Now()
+	lines := req.GetLines()
+	var results []types.Value
+	if req.GetContent() != "" {
+		lines = strings.Split(req.GetContent(), "\n")
+		results = eng.EvalFileContext(ctx, req.GetContent())
+	} else {
+		results = eng.EvalMultipleContext(ctx, lines)
+	}
+	g.metrics.observe("grpc", "EvalDocument", start, eng, lines, results...)
+
+	resp := &numiopb.EvalDocumentResponse{Results: make([]*numiopb.Value, len(results))}
+	for i, v := range results {
+		resp.Results[i] = valueProto(eng, v)
+	}
+	return resp, nil
+}
+
+// Convert converts an amount without evaluating anything else.
+func (g *GRPC) Convert(ctx context.Context, req *numiopb.ConvertRequest) (*numiopb.Value, error) {
+	eng, ok := g.pool.acquire(ctx)
+	if !ok {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	defer g.pool.release(eng)
+
+	start := time.Now()
+	v, ok := convert(eng, convertRequest{
+		Input:  req.GetInput(),
+		Amount: req.Amount,
+		From:   req.GetFrom(),
+		To:     req.GetTo(),
+	})
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, errConvertParams)
+	}
+	g.metrics.observe("grpc", "Convert", start, eng, nil, v)
+	return valueProto(eng, v), nil
+}
+
+// StreamRates sends the current rates, then again after every refresh,
+// until the call is cancelled.
+func (g *GRPC) StreamRates(req *numiopb.StreamRatesRequest, stream grpc.ServerStreamingServer[numiopb.RatesUpdate]) error {
+	base := strings.ToUpper(req.GetBase())
+	if base == "" {
+		base = "USD"
+	}
+	if _, ok := g.base.GetRate(base, "USD"); !ok {
+		return status.Errorf(codes.InvalidArgument, "no rates for %s", base)
+	}
+
+	changed := make(chan struct{}, 1)
+	g.mu.Lock()
+	g.watchers[changed] = struct{}{}
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		delete(g.watchers, changed)
+		g.mu.Unlock()
+	}()
+
+	for {
+		if err := stream.Send(g.ratesUpdate(base, req.GetCodes())); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// ratesChanged wakes the StreamRates calls. A call still sending the
+// previous update sends once more when it is done.
+func (g *GRPC) ratesChanged() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for ch := range g.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ratesUpdate returns the rates of the wanted codes (all if none) against base.
+func (g *GRPC) ratesUpdate(base string, want []string) *numiopb.RatesUpdate {
+	if len(want) == 0 {
+		for code := range g.base.RateCache().RawRates() {
+			want = append(want, code)
+		}
+	}
+
+	update := &numiopb.RatesUpdate{Base: base, Rates: make(map[string]float64, len(want))}
+	for _, code := range want {
+		code = strings.ToUpper(code)
+		if rate, ok := g.base.GetRate(base, code); ok {
+			update.Rates[code] = rate
+		}
+	}
+	if last := g.base.RateCacheStats().LastUpdate; !last.IsZero() {
+		update.UpdatedUnixMs = last.UnixMilli()
+	}
+	return update
+}
+
+// ════════════════════════════════════════════════════════════════
+// AUTHENTICATION / HELPERS
+// ════════════════════════════════════════════════════════════════
+
+func (g *GRPC) authUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := g.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *GRPC) authStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize rejects calls without the API key, if one is configured.
+func (g *GRPC) authorize(ctx context.Context) error {
+	if g.apiKey == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		key = v[0]
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		if bearer, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+			key = bearer
+		}
+	}
+	if subtle.ConstantTimeCompare([]byte(key), []byte(g.apiKey)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid or missing API key")
+	}
+	return nil
+}
+
+// valueProto returns the protobuf form of a value, displayed with the
+// engine's settings.
+func valueProto(eng *engine.Engine, v types.Value) *numiopb.Value {
+	pb := &numiopb.Value{Kind: v.Kind.String()}
+	if v.IsError() {
+		pb.Error = v.Err
+		pb.Limit = v.Limited
+		return pb
+	}
+	pb.Display = eng.Format(v)
+
+	switch v.Kind {
+	case types.ValueNumber:
+		if v.Big != nil {
+			pb.Big = v.Big.String()
+		} else {
+			pb.Amount = v.Num
+		}
+	case types.ValuePercentage:
+		pb.Amount = v.Num
+	case types.ValueCurrency:
+		pb.Amount = v.Num
+		if v.Curr != nil {
+			pb.Code = v.Curr.Code
+		}
+	case types.ValueWithUnit:
+		pb.Amount = v.Num
+		if v.Unit != nil {
+			pb.Code = v.Unit.Code
+		}
+	case types.ValueMetal:
+		pb.Amount = v.Num
+		if v.Metal != nil {
+			pb.Code = v.Metal.Code
+		}
+	case types.ValueCrypto:
+		pb.Amount = v.Num
+		if v.Crypto != nil {
+			pb.Code = v.Crypto.Code
+		}
+	case types.ValueDate:
+		pb.Date = v.Time.Format("2006-01-02")
+	case types.ValueVector:
+		pb.Values = v.Vec
+	case types.ValueMatrix:
+		if v.Mat != nil {
+			pb.Values = v.Mat.Data
+			pb.Columns = int32(v.Mat.Cols)
+		}
+	case types.ValuePlot, types.ValueSparkline:
+		if v.Plot != nil {
+			pb.X = v.Plot.X
+			pb.Y = v.Plot.Y
+		}
+	}
+	return pb
+}
@@ -0,0 +1,246 @@
+// internal/server/http.go
+
+//go:build !(js && wasm)
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// DefaultPoolSize is the number of engines serving HTTP requests.
+const DefaultPoolSize = 8
+
+// maxBodySize limits request bodies.
+const maxBodySize = 1 << 20
+
+// HTTPOptions configures the HTTP server.
+type HTTPOptions struct {
+	Addr     string   // Listen address, e.g. ":8080"
+	APIKey   string   // If set, required as "Authorization: Bearer <key>" or "X-API-Key"
+	PoolSize int      // Number of engines (default DefaultPoolSize)
+	Offline  bool     // Never fetch rates
+	Metrics  *Metrics // If set, recorded and served at GET /metrics
+}
+
+// HTTP serves a REST API backed by a pool of engines:
+//
+//	POST /eval     {"input": "..."} or {"lines": [...]} / {"content": "..."}
+//	POST /convert  {"input": "5 km", "to": "mi"} or {"amount", "from", "to"}
+//	GET  /rates    all rates, or ?from=USD&to=EUR for one rate
+//
+// Each request gets a clean engine; all engines share one rate cache.
+type HTTP struct {
+	base    *engine.Engine
+	pool    *enginePool
+	apiKey  string
+	addr    string
+	offline bool
+	metrics *Metrics
+}
+
+// NewHTTP creates an HTTP server whose engines are clones of base
+// (sharing its settings and rate cache).
+func NewHTTP(base *engine.Engine, opts HTTPOptions) *HTTP {
+	size := opts.PoolSize
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+
+	return &HTTP{
+		base:    base,
+		pool:    newEnginePool(base, size),
+		apiKey:  opts.APIKey,
+		addr:    opts.Addr,
+		offline: opts.Offline,
+		metrics: opts.Metrics,
+	}
+}
+
+// Handler returns the HTTP handler, including authentication.
+func (h *HTTP) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /eval", h.handleEval)
+	mux.HandleFunc("POST /convert", h.handleConvert)
+	mux.HandleFunc("GET /rates", h.handleRates)
+	if h.metrics != nil {
+		mux.Handle("GET /metrics", h.metrics.Handler())
+	}
+	return h.auth(mux)
+}
+
+// ListenAndServe serves until ctx is cancelled.
+// Expired rates are refreshed in the background unless offline.
+func (h *HTTP) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              h.addr,
+		Handler:           h.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if !h.offline {
+		go refreshRates(ctx, h.base, nil)
+	}
+	return serveHTTP(ctx, srv)
+}
+
+// serveHTTP runs srv until ctx is cancelled, then shuts it down.
+func serveHTTP(ctx context.Context, srv *http.Server) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// HANDLERS
+// ════════════════════════════════════════════════════════════════
+
+func (h *HTTP) handleEval(w http.ResponseWriter, r *http.Request) {
+	var req evalRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	eng, ok := h.pool.acquire(r.Context())
+	if !ok {
+		return
+	}
+	defer h.pool.release(eng)
+
+	start := time.Now()
+	switch {
+	case req.Content != "" || req.Lines != nil:
+		lines := req.Lines
+		var results []types.Value
+		if req.Content != "" {
+			lines = strings.Split(req.Content, "\n")
+			results = eng.EvalFileContext(r.Context(), req.Content)
+		} else {
+			results = eng.EvalMultipleContext(r.Context(), lines)
+		}
+		h.metrics.observe("http", "eval", start, eng, lines, results...)
+		out := make([]map[string]any, len(results))
+		for i, v := range results {
+			out[i] = valueJSON(eng, v)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"results": out})
+
+	default:
+		v := eng.EvalContext(r.Context(), req.Input)
+		h.metrics.observe("http", "eval", start, eng, []string{req.Input}, v)
+		writeJSON(w, http.StatusOK, valueJSON(eng, v))
+	}
+}
+
+func (h *HTTP) handleConvert(w http.ResponseWriter, r *http.Request) {
+	var req convertRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	eng, ok := h.pool.acquire(r.Context())
+	if !ok {
+		return
+	}
+	defer h.pool.release(eng)
+
+	start := time.Now()
+	v, ok := convert(eng, req)
+	if !ok {
+		writeError(w, http.StatusBadRequest, errConvertParams)
+		return
+	}
+	h.metrics.observe("http", "convert", start, eng, nil, v)
+	writeJSON(w, http.StatusOK, valueJSON(eng, v))
+}
+
+func (h *HTTP) handleRates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to := strings.ToUpper(q.Get("from")), strings.ToUpper(q.Get("to"))
+
+	if from != "" || to != "" {
+		if from == "" || to == "" {
+			writeError(w, http.StatusBadRequest, "rates requires both from and to")
+			return
+		}
+		rate, ok := h.base.GetRate(from, to)
+		if !ok {
+			writeError(w, http.StatusNotFound, "no rate for "+from+" to "+to)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"from": from, "to": to, "rate": rate})
+		return
+	}
+
+	rc := h.base.RateCache()
+	stats := rc.Stats()
+	resp := map[string]any{
+		"base":    "USD",
+		"rates":   rc.RawRates(),
+		"expired": stats.IsExpired,
+	}
+	if !stats.LastUpdate.IsZero() {
+		resp["last_update"] = stats.LastUpdate.UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ════════════════════════════════════════════════════════════════
+// MIDDLEWARE / HELPERS
+// ════════════════════════════════════════════════════════════════
+
+// auth rejects requests without the API key, if one is configured.
+func (h *HTTP) auth(next http.Handler) http.Handler {
+	if h.apiKey == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(h.apiKey)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid or missing API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeBody decodes a JSON request body, writing an error on failure.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,245 @@
+// internal/server/jsonrpc.go
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+)
+
+// Request is a JSON-RPC 2.0 request.
+// A request without an ID is a notification and gets no response.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error object.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSONRPC serves JSON-RPC 2.0 requests against a single engine, so state
+// (variables, previous result) persists across calls.
+//
+// Methods:
+//
+//	eval        {"input": "5 km in mi"}            → value
+//	eval_lines  {"lines": [...]} or {"content": s} → [value]
+//	parse       {"input": "..."}                   → {valid, ast, errors}
+//	complete    {"input": "5 k"}                   → {prefix, items}
+//	convert     {"input": "5 km", "to": "mi"}      → value
+//	            {"amount": 5, "from": "km", "to": "mi"}
+//	clear       {}                                 → true
+//	variables   {}                                 → {name: value}
+//
+// Evaluation errors are results with kind "error", not RPC errors.
+type JSONRPC struct {
+	mu  sync.Mutex
+	eng *engine.Engine
+}
+
+// NewJSONRPC creates a JSON-RPC handler for an engine, turning its
+// imports off so clients can't read the server's files.
+func NewJSONRPC(eng *engine.Engine) *JSONRPC {
+	eng.SetImportDir("")
+	return &JSONRPC{eng: eng}
+}
+
+// Serve reads newline-delimited requests from r and writes one response
+// per line to w until r is exhausted.
+func (s *JSONRPC) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		resp := s.HandleMessage([]byte(line))
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// HandleMessage decodes and handles a single request.
+// Returns nil for notifications.
+func (s *JSONRPC) HandleMessage(data []byte) *Response {
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(nil, CodeParseError, "parse error: "+err.Error())
+	}
+	return s.Handle(&req)
+}
+
+// Handle handles a decoded request.
+// Returns nil for notifications.
+func (s *JSONRPC) Handle(req *Request) *Response {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, CodeInvalidRequest, "invalid request")
+	}
+
+	s.mu.Lock()
+	result, rpcErr := s.call(req.Method, req.Params)
+	s.mu.Unlock()
+
+	if len(req.ID) == 0 {
+		return nil
+	}
+	if rpcErr != nil {
+		return &Response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// errorResponse builds an error response.
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &RPCError{Code: code, Message: message},
+	}
+}
+
+// ════════════════════════════════════════════════════════════════
+// METHODS
+// ════════════════════════════════════════════════════════════════
+
+// params holds the union of all method parameters.
+type params struct {
+	Input   string   `json:"input"`
+	Lines   []string `json:"lines"`
+	Content string   `json:"content"`
+	Amount  *float64 `json:"amount"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+}
+
+// call dispatches a method. Caller must hold s.mu.
+func (s *JSONRPC) call(method string, raw json.RawMessage) (any, *RPCError) {
+	var p params
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+	}
+
+	switch method {
+	case "eval":
+		return s.valueResult(s.eng.Eval(p.Input)), nil
+
+	case "eval_lines":
+		var results []types.Value
+		if p.Content != "" {
+			results = s.eng.EvalFile(p.Content)
+		} else {
+			results = s.eng.EvalMultiple(p.Lines)
+		}
+		out := make([]map[string]any, len(results))
+		for i, v := range results {
+			out[i] = s.valueResult(v)
+		}
+		return out, nil
+
+	case "parse":
+		return s.parse(p.Input), nil
+
+	case "complete":
+		items := s.eng.Complete(p.Input)
+		if items == nil {
+			items = []engine.Completion{}
+		}
+		return map[string]any{
+			"prefix": engine.CompletionPrefix(p.Input),
+			"items":  items,
+		}, nil
+
+	case "convert":
+		v, ok := convert(s.eng, convertRequest{
+			Input: p.Input, Amount: p.Amount, From: p.From, To: p.To,
+		})
+		if !ok {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: errConvertParams}
+		}
+		return s.valueResult(v), nil
+
+	case "clear":
+		s.eng.Clear()
+		return true, nil
+
+	case "variables":
+		vars := make(map[string]map[string]any)
+		for name, v := range s.eng.Variables() {
+			vars[name] = s.valueResult(v)
+		}
+		return vars, nil
+
+	default:
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// valueResult returns the JSON form of a value.
+func (s *JSONRPC) valueResult(v types.Value) map[string]any {
+	return valueJSON(s.eng, v)
+}
+
+// parse parses input without evaluating it.
+func (s *JSONRPC) parse(input string) map[string]any {
+	line, errs := s.eng.Parse(input)
+
+	type parseError struct {
+		Message string `json:"message"`
+		Pos     int    `json:"pos"`
+	}
+	out := make([]parseError, 0, len(errs))
+	for _, err := range errs {
+		out = append(out, parseError{Message: err.Message, Pos: err.Pos})
+	}
+
+	result := map[string]any{
+		"valid":  len(errs) == 0,
+		"errors": out,
+	}
+	if line != nil && len(errs) == 0 {
+		result["ast"] = line.String()
+	}
+	return result
+}
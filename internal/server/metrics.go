@@ -0,0 +1,152 @@
+// internal/server/metrics.go
+
+//go:build !(js && wasm)
+
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/0xsj/numio/internal/fetch"
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Line results, as the result label of numio_lines_evaluated_total.
+const (
+	lineOK         = "ok"
+	lineError      = "error"
+	lineParseError = "parse_error"
+	lineLimit      = "limit"
+)
+
+// Metrics records what the HTTP and gRPC servers do, in Prometheus form:
+//
+//	numio_eval_duration_seconds{api, method}    time spent evaluating a request
+//	numio_lines_evaluated_total{api, result}    lines by result: ok, error, parse_error or limit
+//	numio_rate_cache_age_seconds                time since rates were refreshed (+Inf if never)
+//	numio_rate_provider_fetches_total{provider, result}
+//	                                            rate fetches by result: success or failure
+//
+// The parse error rate is the parse_error share of lines evaluated. A
+// nil *Metrics records nothing.
+type Metrics struct {
+	registry    *prometheus.Registry
+	evalSeconds *prometheus.HistogramVec
+	lines       *prometheus.CounterVec
+	fetches     *prometheus.CounterVec
+}
+
+// NewMetrics creates metrics for servers evaluating with clones of base.
+// Provider fetches are counted for the whole process, so a process
+// should create them once.
+func NewMetrics(base *engine.Engine) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		evalSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "numio_eval_duration_seconds",
+			Help:    "Time spent evaluating a request.",
+			Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"api", "method"}),
+		lines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "numio_lines_evaluated_total",
+			Help: "Lines evaluated, by result: ok, error, parse_error or limit.",
+		}, []string{"api", "result"}),
+		fetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "numio_rate_provider_fetches_total",
+			Help: "Rate fetches from each provider, by result: success or failure.",
+		}, []string{"provider", "result"}),
+	}
+	age := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "numio_rate_cache_age_seconds",
+		Help: "Time since rates were last refreshed, +Inf if they never were.",
+	}, func() float64 {
+		last := base.RateCacheStats().LastUpdate
+		if last.IsZero() {
+			return math.Inf(1)
+		}
+		return time.Since(last).Seconds()
+	})
+
+	m.registry.MustRegister(
+		m.evalSeconds, m.lines, m.fetches, age,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	fetch.Default().OnFetch(func(a fetch.FetchAttempt) {
+		result := "success"
+		if a.Err != nil {
+			result = "failure"
+		}
+		m.fetches.WithLabelValues(a.Provider, result).Inc()
+	})
+	return m
+}
+
+// Handler returns the handler Prometheus scrapes.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ListenAndServe serves the metrics alone on addr, at /metrics, until
+// ctx is cancelled.
+func (m *Metrics) ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	return serveHTTP(ctx, &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	})
+}
+
+// observe records a request to api's method that began at start and
+// evaluated inputs to results; blank and comment lines aren't counted.
+// inputs may be nil when the results don't come from lines the caller
+// wrote, as with a conversion.
+func (m *Metrics) observe(api, method string, start time.Time, eng *engine.Engine, inputs []string, results ...types.Value) {
+	if m == nil {
+		return
+	}
+	m.evalSeconds.WithLabelValues(api, method).Observe(time.Since(start).Seconds())
+
+	for i, v := range results {
+		if v.Kind == types.ValueEmpty {
+			continue
+		}
+		result := lineOK
+		switch {
+		case v.Limited:
+			result = lineLimit
+		case v.IsError():
+			result = lineError
+			if i < len(inputs) && parseFailed(eng, inputs[i]) {
+				result = lineParseError
+			}
+		}
+		m.lines.WithLabelValues(api, result).Inc()
+	}
+}
+
+// parseFailed reports whether input fails to parse, as opposed to
+// parsing and failing to evaluate.
+func parseFailed(eng *engine.Engine, input string) bool {
+	trimmed := strings.TrimSpace(input)
+	if _, ok := engine.ImportPath(trimmed); ok {
+		return false
+	}
+	if _, ok := engine.ParseInput(trimmed); ok {
+		return false
+	}
+	_, errs := eng.Parse(input)
+	return len(errs) > 0
+}
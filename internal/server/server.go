@@ -0,0 +1,130 @@
+// internal/server/server.go
+
+// Package server exposes numio engines to other processes over
+// JSON-RPC (stdio), HTTP and gRPC.
+package server
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// rateRefreshInterval is how often a server checks for expired rates.
+const rateRefreshInterval = 10 * time.Minute
+
+// DefaultEvalTimeout is the longest a served engine should spend on one
+// line, so a pathological input can't tie it up.
+const DefaultEvalTimeout = 5 * time.Second
+
+// evalRequest holds the input of an evaluation.
+// Input is a single line; Lines or Content evaluate a document.
+type evalRequest struct {
+	Input   string   `json:"input"`
+	Lines   []string `json:"lines"`
+	Content string   `json:"content"`
+}
+
+// convertRequest holds the input of a conversion: either an expression
+// ("5 km") or an amount and a source code, plus the target.
+type convertRequest struct {
+	Input  string   `json:"input"`
+	Amount *float64 `json:"amount"`
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+}
+
+// errConvertParams describes the required conversion parameters.
+const errConvertParams = "convert requires input (or amount and from) and to"
+
+// convert evaluates a conversion without affecting engine state.
+// Returns false if the request is incomplete.
+func convert(eng *engine.Engine, req convertRequest) (types.Value, bool) {
+	input := req.Input
+	if input == "" && req.Amount != nil {
+		input = strconv.FormatFloat(*req.Amount, 'f', -1, 64) + " " + req.From
+	}
+	if strings.TrimSpace(input) == "" || strings.TrimSpace(req.To) == "" {
+		return types.Value{}, false
+	}
+	return eng.EvalPreview(input + " in " + req.To), true
+}
+
+// valueJSON returns the JSON form of a value, displayed with the
+// engine's settings.
+func valueJSON(eng *engine.Engine, v types.Value) map[string]any {
+	m := v.ToMap()
+	if !v.IsError() {
+		m["display"] = eng.Format(v)
+	}
+	return m
+}
+
+// refreshRates refreshes expired rates until ctx is cancelled, first
+// picking up any that other processes have saved to the cache file.
+// reloaded, if set, is called after rates are picked up that way.
+func refreshRates(ctx context.Context, base *engine.Engine, reloaded func()) {
+	ticker := time.NewTicker(rateRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if base.ReloadRatesIfChanged() && reloaded != nil {
+			reloaded()
+		}
+		base.RefreshRatesIfExpired(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ════════════════════════════════════════════════════════════════
+// ENGINE POOL
+// ════════════════════════════════════════════════════════════════
+
+// enginePool holds clean clones of a base engine (sharing its settings
+// and rate cache), each serving one request at a time.
+type enginePool struct {
+	base    *engine.Engine
+	engines chan *engine.Engine
+}
+
+// newEnginePool creates a pool of size engines cloned from base.
+func newEnginePool(base *engine.Engine, size int) *enginePool {
+	p := &enginePool{base: base, engines: make(chan *engine.Engine, size)}
+	for i := 0; i < size; i++ {
+		p.engines <- p.clone()
+	}
+	return p
+}
+
+// clone returns a clean clone of the base engine, with imports off so
+// clients can't read the server's files.
+func (p *enginePool) clone() *engine.Engine {
+	eng := p.base.Clone()
+	eng.Clear()
+	eng.SetImportDir("")
+	return eng
+}
+
+// acquire takes an engine from the pool, waiting until one is free.
+func (p *enginePool) acquire(ctx context.Context) (*engine.Engine, bool) {
+	select {
+	case eng := <-p.engines:
+		return eng, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// release puts a fresh clone in the pool in place of a used engine, whose
+// settings and pins a request's front matter may have changed.
+func (p *enginePool) release(eng *engine.Engine) {
+	p.engines <- p.clone()
+}
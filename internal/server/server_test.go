@@ -0,0 +1,97 @@
+// internal/server/server_test.go
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// importingEngine returns an engine that imports from a directory
+// holding secret.calc, as the CLI's file mode sets one up, and the path
+// of the file.
+func importingEngine(t *testing.T) (*engine.Engine, string) {
+	t.Helper()
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.calc")
+	if err := os.WriteFile(secret, []byte("secret = 42\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	eng := engine.NewWithCache(cache.New())
+	eng.RateCache().SetOffline(true)
+	eng.SetImportDir(dir)
+	if v := eng.Clone().Eval(`import "secret.calc"`); v.IsError() {
+		t.Fatalf("engine can't import: %s", v.ErrorMessage())
+	}
+	return eng, secret
+}
+
+// postEval sends a document to POST /eval and returns its results.
+func postEval(t *testing.T, h http.Handler, content string) []map[string]any {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"content": content})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/eval", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /eval: %d %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Results []map[string]any `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Results
+}
+
+func TestHTTPRefusesImports(t *testing.T) {
+	eng, secret := importingEngine(t)
+	h := NewHTTP(eng, HTTPOptions{Offline: true}).Handler()
+
+	for _, line := range []string{`import "secret.calc"`, `include "` + secret + `"`} {
+		results := postEval(t, h, line)
+		if len(results) != 1 || results[0]["kind"] != "error" {
+			t.Errorf("%s gave %v, want an error", line, results)
+		}
+	}
+}
+
+func TestJSONRPCRefusesImports(t *testing.T) {
+	eng, _ := importingEngine(t)
+	rpc := NewJSONRPC(eng)
+
+	resp := rpc.HandleMessage([]byte(`{"jsonrpc":"2.0","id":1,"method":"eval","params":{"input":"import \"secret.calc\""}}`))
+	if resp.Error != nil {
+		t.Fatalf("eval: %s", resp.Error.Message)
+	}
+	if m, _ := resp.Result.(map[string]any); m["kind"] != "error" {
+		t.Errorf("import gave %v, want an error", resp.Result)
+	}
+}
+
+func TestRequestSettingsDontCarryOver(t *testing.T) {
+	base := engine.NewSandboxed()
+	h := NewHTTP(base, HTTPOptions{Offline: true, PoolSize: 1}).Handler()
+
+	first := postEval(t, h, "strict: on\nprecision: 1\n---\nfoo + 1\n1/3")
+	if got := first[4]["display"]; got != "0.3" || first[3]["kind"] != "error" {
+		t.Fatalf("first request gave %v", first)
+	}
+
+	// The same engine, as the pool holds one
+	second := postEval(t, h, "foo + 1\n1/3")
+	if second[0]["kind"] == "error" {
+		t.Errorf("strict mode carried over: %v", second[0])
+	}
+	if got := second[1]["display"]; got != "0.3333" {
+		t.Errorf("1/3 = %v, want 0.3333", got)
+	}
+}
@@ -17,22 +17,30 @@ const (
 	NUMBER     // 42, 3.14, 1,234.56, 1.5e6
 	PERCENT    // 20%
 	IDENTIFIER // variable names, unit names, currency codes
+	DATE       // 2026-03-01
 
 	// Operators
-	PLUS   // +
-	MINUS  // -
-	STAR   // *
-	SLASH  // /
-	CARET  // ^
-	POWER  // **
-	LPAREN // (
-	RPAREN // )
-	EQUALS // =
-	COMMA  // ,
+	PLUS      // +
+	MINUS     // -
+	STAR      // *
+	SLASH     // /
+	DSLASH    // // (integer division)
+	CARET     // ^
+	POWER     // **
+	PLUSMINUS // ±, +/-
+	BANG      // ! (factorial)
+	LPAREN    // (
+	RPAREN    // )
+	LBRACKET  // [
+	RBRACKET  // ]
+	EQUALS    // =
+	COMMA     // ,
+	DOTDOT    // .. (ranges)
 
 	// Keywords
-	IN // in, to (for conversions)
-	OF // of (for "20% of 150")
+	IN  // in, to (for conversions)
+	OF  // of (for "20% of 150")
+	MOD // mod (modulo: 17 mod 5)
 
 	// Currency symbols
 	DOLLAR   // $
@@ -45,6 +53,9 @@ const (
 	// Comments
 	COMMENT // # or // to end of line
 
+	// Directives
+	PIN // !pin (freeze a line's value)
+
 	// Whitespace (usually skipped, but tracked for position)
 	WHITESPACE
 	NEWLINE
@@ -56,18 +67,26 @@ var typeNames = map[Type]string{
 	NUMBER:     "NUMBER",
 	PERCENT:    "PERCENT",
 	IDENTIFIER: "IDENTIFIER",
+	DATE:       "DATE",
 	PLUS:       "PLUS",
 	MINUS:      "MINUS",
 	STAR:       "STAR",
 	SLASH:      "SLASH",
+	DSLASH:     "DSLASH",
 	CARET:      "CARET",
 	POWER:      "POWER",
+	PLUSMINUS:  "PLUSMINUS",
+	BANG:       "BANG",
 	LPAREN:     "LPAREN",
 	RPAREN:     "RPAREN",
+	LBRACKET:   "LBRACKET",
+	RBRACKET:   "RBRACKET",
 	EQUALS:     "EQUALS",
 	COMMA:      "COMMA",
+	DOTDOT:     "DOTDOT",
 	IN:         "IN",
 	OF:         "OF",
+	MOD:        "MOD",
 	DOLLAR:     "DOLLAR",
 	EURO:       "EURO",
 	POUND:      "POUND",
@@ -75,6 +94,7 @@ var typeNames = map[Type]string{
 	BITCOIN:    "BITCOIN",
 	CURRENCY:   "CURRENCY",
 	COMMENT:    "COMMENT",
+	PIN:        "PIN",
 	WHITESPACE: "WHITESPACE",
 	NEWLINE:    "NEWLINE",
 }
@@ -91,6 +111,7 @@ type Token struct {
 	Type    Type   // Token type
 	Literal string // Raw text of the token
 	Pos     int    // Start position in input (byte offset)
+	End     int    // End position in input (byte offset, exclusive)
 }
 
 // New creates a new token.
@@ -102,6 +123,15 @@ func New(typ Type, literal string, pos int) Token {
 	}
 }
 
+// Text returns the token as written in input, which may differ from its
+// literal: "three hundred" is the number 300, and "1,234" is 1234.
+func (t Token) Text(input string) string {
+	if t.Pos < 0 || t.End > len(input) || t.Pos > t.End {
+		return t.Literal
+	}
+	return input[t.Pos:t.End]
+}
+
 // Is checks if the token is of a specific type.
 func (t Token) Is(typ Type) bool {
 	return t.Type == typ
@@ -114,7 +144,7 @@ func (t Token) IsOneOf(types ...Type) bool {
 
 // IsOperator checks if the token is a binary operator.
 func (t Token) IsOperator() bool {
-	return t.IsOneOf(PLUS, MINUS, STAR, SLASH, CARET, POWER)
+	return t.IsOneOf(PLUS, MINUS, STAR, SLASH, DSLASH, MOD, CARET, POWER)
 }
 
 // IsCurrencySymbol checks if the token is a currency symbol.
@@ -124,14 +154,15 @@ func (t Token) IsCurrencySymbol() bool {
 
 // IsKeyword checks if the token is a keyword.
 func (t Token) IsKeyword() bool {
-	return t.IsOneOf(IN, OF)
+	return t.IsOneOf(IN, OF, MOD)
 }
 
 // Keywords maps keyword strings to token types.
 var Keywords = map[string]Type{
-	"in": IN,
-	"to": IN, // "to" is an alias for "in"
-	"of": OF,
+	"in":  IN,
+	"to":  IN, // "to" is an alias for "in"
+	"of":  OF,
+	"mod": MOD,
 }
 
 // LookupIdentifier checks if an identifier is a keyword.
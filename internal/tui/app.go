@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/0xsj/numio/internal/config"
 	"github.com/0xsj/numio/internal/highlight"
 	"github.com/0xsj/numio/internal/tui/keymap"
 	"github.com/0xsj/numio/pkg/engine"
@@ -13,46 +14,59 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Styles
-var (
-	lineNumStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#666"))
-	resultStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#7ee787"))
-	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#f85149"))
-	cursorStyle  = lipgloss.NewStyle().Reverse(true)
-	tildeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#444"))
-	pendingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffa657"))
-
-	// Help styles
-	helpBorderStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#79c0ff")).Padding(1, 2)
-	helpTitleStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#79c0ff"))
-	helpSectionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ffa657")).MarginTop(1)
-	helpKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#79c0ff")).Width(14)
-	helpDescStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#888"))
-	helpFooterStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#666")).Italic(true).MarginTop(1)
-)
-
 // App is the main model
 type App struct {
-	lines  []string
-	row    int
-	col    int
+	// Current buffer, and all open buffers
+	*buffer
+	buffers []*buffer
+	current int
+
+	// Windows onto buffers, and the focused one
+	windows  []*window
+	win      int
+	vertical bool // Windows are side by side
+
 	width  int
 	height int
-	engine *engine.Engine
 
-	// Syntax highlighting
+	// Syntax highlighting and UI styles (from the same theme)
 	highlighter *highlight.Highlighter
+	st          *styles
+
+	// Settings from the config file, applied to every new buffer's engine
+	config *config.Config
 
 	// Keymap
-	keymap   *keymap.KeyMap
-	showHelp bool
+	keymap      *keymap.KeyMap
+	showHelp    bool
+	showPanel   bool // Totals and variables sidebar
+	showRunning bool // Running total column beside the results
+
+	// Explanation of a line's result (K), shown until a key is pressed
+	explain *engine.Explanation
 
 	// Yank buffer
 	yankBuffer string
 
-	// Undo/Redo
-	undoStack []editorState
-	redoStack []editorState
+	// Insert mode completion popup
+	completions      []engine.Completion
+	completionIdx    int
+	completionPrefix string
+
+	// Quit was requested with unsaved changes
+	confirmQuit bool
+
+	// Command line (:) and status message
+	cmdline        bool
+	cmdInput       string
+	message        string
+	messageIsError bool
+
+	// Template inputs still to ask for, the one being asked first
+	inputs []pendingInput
+
+	// Rates being fetched, shown in the status bar (see refresh.go)
+	refresh *rateRefresh
 }
 
 // editorState for undo/redo
@@ -67,32 +81,80 @@ func NewApp() *App {
 	// Load keymap (with user config if exists)
 	km, _ := keymap.LoadOrCreate(keymap.DefaultConfigPath())
 
-	return &App{
-		lines:       []string{""},
-		row:         0,
-		col:         0,
+	// Load the config file; a broken one still leaves defaults
+	cfg, cfgErr := config.Load(config.Path())
+	if cfgErr != nil {
+		cfg = &config.Config{}
+	}
+	if err := cfg.ApplyProviders(); err != nil && cfgErr == nil {
+		cfgErr = err
+	}
+
+	// Use the user's theme file if there is one, unless the config names
+	// another theme
+	theme, themeErr := highlight.LoadUserTheme()
+	if cfg.Theme != "" {
+		if t, ok := highlight.LookupTheme(cfg.Theme); ok {
+			theme = t
+		} else if themeErr == nil {
+			themeErr = fmt.Errorf("config: unknown theme: %s", cfg.Theme)
+		}
+	}
+	if theme == nil {
+		theme = highlight.DefaultTheme()
+	}
+
+	eng, engErr := cfg.NewEngine()
+	if cfgErr == nil {
+		cfgErr = engErr
+	}
+
+	buf := newBuffer(eng)
+	app := &App{
+		buffer:      buf,
+		buffers:     []*buffer{buf},
+		windows:     []*window{{buf: buf}},
 		width:       80,
 		height:      24,
-		engine:      engine.New(),
-		highlighter: highlight.Default(),
+		highlighter: highlight.New(theme),
+		st:          newStyles(theme),
+		config:      cfg,
 		keymap:      km,
 		showHelp:    false,
 		yankBuffer:  "",
-		undoStack:   nil,
-		redoStack:   nil,
 	}
+	if themeErr != nil {
+		app.setError(themeErr.Error())
+	}
+	if cfgErr != nil {
+		app.setError(cfgErr.Error())
+	}
+	return app
+}
+
+// newEngine creates an engine with the config file's settings. Errors in
+// them were shown when the app started.
+func (a *App) newEngine() *engine.Engine {
+	eng, _ := a.config.NewEngine()
+	return eng
 }
 
 // NewAppWithTheme creates a new app with a specific theme
 func NewAppWithTheme(themeName string) *App {
 	app := NewApp()
-	app.highlighter = highlight.NewWithThemeName(themeName)
+	app.SetTheme(themeName)
 	return app
 }
 
-// SetTheme changes the syntax highlighting theme
+// SetTheme changes the syntax highlighting and UI theme
 func (a *App) SetTheme(themeName string) {
-	a.highlighter.SetTheme(highlight.GetTheme(themeName))
+	a.setTheme(highlight.GetTheme(themeName))
+}
+
+// setTheme applies a theme to the highlighter and UI styles.
+func (a *App) setTheme(theme *highlight.Theme) {
+	a.highlighter.SetTheme(theme)
+	a.st = newStyles(theme)
 }
 
 // Init implements tea.Model
@@ -106,9 +168,18 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
 		a.height = msg.Height
+		a.scrollToCursor()
 
 	case tea.KeyMsg:
-		return a.handleKey(msg)
+		model, cmd := a.handleKey(msg)
+		a.scrollToCursor()
+		return model, cmd
+
+	case refreshEventMsg:
+		return a, a.handleRefreshEvent(msg)
+
+	case refreshTickMsg:
+		return a, a.handleRefreshTick()
 	}
 
 	return a, nil
@@ -117,11 +188,23 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (a *App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 
+	// Input prompts and the command line handle their own keys
+	// (including Ctrl+C to cancel)
+	if len(a.inputs) > 0 {
+		return a.handleInputKey(msg)
+	}
+	if a.cmdline {
+		return a.handleCommandLineKey(msg)
+	}
+
 	// Always handle Ctrl+C as force quit
 	if key == "ctrl+c" {
 		return a, tea.Quit
 	}
 
+	// Any key dismisses the status message
+	a.message = ""
+
 	// In insert mode, handle text input specially
 	if a.keymap.CurrentMode == keymap.ModeInsert {
 		return a.handleInsertKey(msg)
@@ -132,6 +215,10 @@ func (a *App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.showHelp = false
 		return a, nil
 	}
+	if a.explain != nil {
+		a.explain = nil
+		return a, nil
+	}
 
 	// Process key through keymap
 	cmd, ok := a.keymap.ProcessKey(key)
@@ -147,19 +234,34 @@ func (a *App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) handleInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 
+	// The completion popup takes Tab and the arrow keys while open
+	if a.handleCompletionKey(key) {
+		return a, nil
+	}
+	a.clearCompletions()
+
 	// Check for bound keys in insert mode
 	result := a.keymap.Insert.Lookup(key)
 	if result.Status == keymap.LookupFound {
+		// Moving the cursor starts a new undo step
+		if result.Action.IsMotion() {
+			a.undoJoin = false
+		}
 		cmd := keymap.NewCommand(result.Action, 1)
-		return a.executeCommand(cmd)
+		model, teaCmd := a.executeCommand(cmd)
+		if result.Action == keymap.ActionBackspace {
+			a.updateCompletions()
+		}
+		return model, teaCmd
 	}
 
 	// Handle regular character input
 	if len(msg.Runes) > 0 {
-		a.saveUndo()
+		a.saveInsertUndo()
 		for _, r := range msg.Runes {
 			a.insertChar(r)
 		}
+		a.updateCompletions()
 	}
 
 	return a, nil
@@ -168,25 +270,36 @@ func (a *App) handleInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) executeCommand(cmd keymap.Command) (tea.Model, tea.Cmd) {
 	count := cmd.TotalCount()
 
+	// A quit warning only holds until the next command
+	confirmQuit := a.confirmQuit
+	a.confirmQuit = false
+
 	switch cmd.Action {
 	// Mode switching
 	case keymap.ActionNormalMode:
+		a.clearCompletions()
+		wasInsert := a.keymap.CurrentMode == keymap.ModeInsert
 		a.keymap.SetMode(keymap.ModeNormal)
-		if a.col > 0 {
+		a.undoJoin = false
+		if wasInsert && a.col > 0 {
 			a.col--
 		}
+		a.clampCol()
 
 	case keymap.ActionInsertMode:
-		a.keymap.SetMode(keymap.ModeInsert)
+		a.startInsert(false)
 
 	case keymap.ActionAppendMode:
-		a.keymap.SetMode(keymap.ModeInsert)
+		a.startInsert(false)
 		if a.col < len(a.lines[a.row]) {
 			a.col++
 		}
 
 	case keymap.ActionVisualMode:
-		a.keymap.SetMode(keymap.ModeVisual)
+		a.enterVisual(keymap.ModeVisual)
+
+	case keymap.ActionVisualLine:
+		a.enterVisual(keymap.ModeVisualLine)
 
 	// Cursor movement
 	case keymap.ActionMoveUp:
@@ -251,6 +364,16 @@ func (a *App) executeCommand(cmd keymap.Command) (tea.Model, tea.Cmd) {
 			a.cursorDown()
 		}
 
+	// Scrolling
+	case keymap.ActionScrollDown:
+		a.scrollLines(count)
+
+	case keymap.ActionScrollUp:
+		a.scrollLines(-count)
+
+	case keymap.ActionScrollCenter:
+		a.scrollCenter()
+
 	// Editing
 	case keymap.ActionDeleteChar:
 		a.saveUndo()
@@ -277,9 +400,19 @@ func (a *App) executeCommand(cmd keymap.Command) (tea.Model, tea.Cmd) {
 	case keymap.ActionYankLine:
 		a.yankLine()
 
+	case keymap.ActionYankResult:
+		return a, a.yankResult()
+
+	case keymap.ActionYankResults:
+		return a, a.yankResults()
+
 	case keymap.ActionPaste:
 		a.saveUndo()
-		a.paste()
+		if a.keymap.CurrentMode.IsVisual() {
+			a.pasteSelection()
+		} else {
+			a.paste()
+		}
 
 	case keymap.ActionPasteAbove:
 		a.saveUndo()
@@ -298,77 +431,126 @@ func (a *App) executeCommand(cmd keymap.Command) (tea.Model, tea.Cmd) {
 	case keymap.ActionOpenBelow:
 		a.saveUndo()
 		a.newLineBelow()
-		a.keymap.SetMode(keymap.ModeInsert)
+		a.startInsert(true)
 
 	case keymap.ActionOpenAbove:
 		a.saveUndo()
 		a.newLineAbove()
-		a.keymap.SetMode(keymap.ModeInsert)
+		a.startInsert(true)
 
 	// Insert mode actions
 	case keymap.ActionBackspace:
-		a.saveUndo()
+		a.saveInsertUndo()
 		a.backspace()
 
 	case keymap.ActionDelete:
-		a.saveUndo()
+		a.saveInsertUndo()
 		a.deleteChar()
 
 	case keymap.ActionInsertNewline:
-		a.saveUndo()
+		a.saveInsertUndo()
 		a.newLine()
 
 	case keymap.ActionInsertTab:
-		a.saveUndo()
+		a.saveInsertUndo()
 		a.insertChar(' ')
 		a.insertChar(' ')
 
-	// Operators with motions
-	case keymap.ActionOperatorDelete:
-		if cmd.Motion != keymap.ActionNone {
-			a.saveUndo()
-			a.deleteWithMotion(cmd.Motion, count)
-		}
-
-	case keymap.ActionOperatorYank:
-		if cmd.Motion != keymap.ActionNone {
-			a.yankWithMotion(cmd.Motion, count)
-		}
-
-	case keymap.ActionOperatorChange:
-		if cmd.Motion != keymap.ActionNone {
-			a.saveUndo()
-			a.deleteWithMotion(cmd.Motion, count)
-			a.keymap.SetMode(keymap.ModeInsert)
+	// Operators, on a motion or the visual selection
+	case keymap.ActionOperatorDelete, keymap.ActionOperatorYank, keymap.ActionOperatorChange:
+		if a.keymap.CurrentMode.IsVisual() {
+			a.executeVisualOperator(cmd.Action)
+		} else if cmd.Motion != keymap.ActionNone {
+			a.executeOperator(cmd.Action, cmd.Motion, count)
 		}
 
 	// General
 	case keymap.ActionQuit:
-		return a, tea.Quit
+		a.confirmQuit = confirmQuit
+		return a, a.quit()
 
 	case keymap.ActionForceQuit:
 		return a, tea.Quit
 
 	case keymap.ActionSave:
-		// TODO: Implement save
+		a.writeCommand("")
 
 	case keymap.ActionSaveQuit:
-		// TODO: Implement save
-		return a, tea.Quit
+		if a.writeCommand("") {
+			return a, tea.Quit
+		}
+
+	case keymap.ActionCommandLine:
+		a.cmdline = true
+		a.cmdInput = ""
+
+	case keymap.ActionNextBuffer:
+		a.cycleBuffer(count)
+
+	case keymap.ActionPrevBuffer:
+		a.cycleBuffer(-count)
+
+	case keymap.ActionNextWindow:
+		a.cycleWindow(count)
 
 	case keymap.ActionToggleHelp:
 		a.showHelp = !a.showHelp
 
+	case keymap.ActionExplain:
+		a.explainLine()
+
+	case keymap.ActionRefreshRate:
+		return a, a.refreshRates()
+
 	case keymap.ActionToggleLineNumbers:
 		// TODO: Implement
 
 	case keymap.ActionToggleWrap:
 		// TODO: Implement
+
+	case keymap.ActionTogglePanel:
+		a.showPanel = !a.showPanel
+
+	case keymap.ActionToggleRunning:
+		a.showRunning = !a.showRunning
 	}
 
 	return a, nil
 }
 
+// executeOperator applies an operator over a motion.
+func (a *App) executeOperator(op, motion keymap.Action, count int) {
+	switch op {
+	case keymap.ActionOperatorDelete:
+		a.saveUndo()
+		a.deleteWithMotion(motion, count)
+
+	case keymap.ActionOperatorYank:
+		a.yankWithMotion(motion, count)
+
+	case keymap.ActionOperatorChange:
+		a.saveUndo()
+		a.deleteWithMotion(motion, count)
+		a.startInsert(true)
+	}
+}
+
+// executeVisualOperator applies an operator to the visual selection.
+func (a *App) executeVisualOperator(op keymap.Action) {
+	switch op {
+	case keymap.ActionOperatorDelete:
+		a.saveUndo()
+		a.deleteSelection()
+
+	case keymap.ActionOperatorYank:
+		a.yankSelection()
+
+	case keymap.ActionOperatorChange:
+		a.saveUndo()
+		a.changeSelection()
+	}
+}
+
 // ════════════════════════════════════════════════════════════════
 // CURSOR MOVEMENT
 // ════════════════════════════════════════════════════════════════
@@ -395,7 +577,7 @@ func (a *App) cursorLeft() {
 
 func (a *App) cursorRight() {
 	maxCol := len(a.lines[a.row])
-	if a.keymap.CurrentMode == keymap.ModeNormal && maxCol > 0 {
+	if a.keymap.CurrentMode != keymap.ModeInsert && maxCol > 0 {
 		maxCol--
 	}
 	if a.col < maxCol {
@@ -405,7 +587,7 @@ func (a *App) cursorRight() {
 
 func (a *App) clampCol() {
 	maxCol := len(a.lines[a.row])
-	if a.keymap.CurrentMode == keymap.ModeNormal && maxCol > 0 {
+	if a.keymap.CurrentMode != keymap.ModeInsert && maxCol > 0 {
 		maxCol--
 	}
 	if a.col > maxCol {
@@ -589,22 +771,16 @@ func (a *App) paste() {
 	}
 
 	if strings.HasSuffix(a.yankBuffer, "\n") {
-		// Paste line below
-		content := strings.TrimSuffix(a.yankBuffer, "\n")
-		newLines := make([]string, 0, len(a.lines)+1)
-		newLines = append(newLines, a.lines[:a.row+1]...)
-		newLines = append(newLines, content)
-		if a.row+1 < len(a.lines) {
-			newLines = append(newLines, a.lines[a.row+1:]...)
-		}
-		a.lines = newLines
+		// Paste lines below
+		a.insertLines(a.row+1, yankedLines(a.yankBuffer))
 		a.row++
 		a.col = 0
 	} else {
-		// Paste inline
-		line := a.lines[a.row]
-		a.lines[a.row] = line[:a.col+1] + a.yankBuffer + line[a.col+1:]
-		a.col += len(a.yankBuffer)
+		// Paste inline after cursor
+		if a.col < len(a.lines[a.row]) {
+			a.col++
+		}
+		a.insertText(a.yankBuffer)
 	}
 }
 
@@ -614,21 +790,20 @@ func (a *App) pasteAbove() {
 	}
 
 	if strings.HasSuffix(a.yankBuffer, "\n") {
-		// Paste line above
-		content := strings.TrimSuffix(a.yankBuffer, "\n")
-		newLines := make([]string, 0, len(a.lines)+1)
-		newLines = append(newLines, a.lines[:a.row]...)
-		newLines = append(newLines, content)
-		newLines = append(newLines, a.lines[a.row:]...)
-		a.lines = newLines
+		// Paste lines above
+		a.insertLines(a.row, yankedLines(a.yankBuffer))
 		a.col = 0
 	} else {
 		// Paste inline before cursor
-		line := a.lines[a.row]
-		a.lines[a.row] = line[:a.col] + a.yankBuffer + line[a.col:]
+		a.insertText(a.yankBuffer)
 	}
 }
 
+// yankedLines splits a line-wise yank buffer into lines.
+func yankedLines(buf string) []string {
+	return strings.Split(strings.TrimSuffix(buf, "\n"), "\n")
+}
+
 // ════════════════════════════════════════════════════════════════
 // OPERATOR + MOTION
 // ════════════════════════════════════════════════════════════════
@@ -771,6 +946,7 @@ func (a *App) saveUndo() {
 	}
 	copy(state.lines, a.lines)
 	a.undoStack = append(a.undoStack, state)
+	a.modified = true
 
 	// Limit undo stack
 	if len(a.undoStack) > 100 {
@@ -802,6 +978,7 @@ func (a *App) undo() {
 	a.lines = state.lines
 	a.row = state.row
 	a.col = state.col
+	a.modified = true
 }
 
 func (a *App) redo() {
@@ -825,6 +1002,7 @@ func (a *App) redo() {
 	a.lines = state.lines
 	a.row = state.row
 	a.col = state.col
+	a.modified = true
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -839,51 +1017,96 @@ func (a *App) View() string {
 	if a.showHelp {
 		return a.renderHelp()
 	}
+	if a.explain != nil {
+		return a.renderExplain()
+	}
 
 	var b strings.Builder
 
-	contentHeight := a.height - 2
-	if contentHeight < 1 {
-		contentHeight = 20
+	// The panel reads the state left by this render's evaluation
+	rows := a.renderWindows()
+	var panel []string
+	if panelWidth := a.panelWidth(); panelWidth > 0 {
+		panel = a.renderPanel(panelWidth, len(rows))
 	}
 
-	lineNumWidth := 5
-	resultWidth := 20
-	editorWidth := a.width - lineNumWidth - resultWidth - 4
+	if a.tablineHeight() > 0 {
+		b.WriteString(a.renderTabline() + "\n")
+	}
+	for n, row := range rows {
+		b.WriteString(row)
+		if panel != nil {
+			b.WriteString("│" + panel[n])
+		}
+		b.WriteString("\n")
+	}
 
-	if editorWidth < 20 {
-		editorWidth = 20
+	b.WriteString(a.renderStatusBar())
+
+	return b.String()
+}
+
+// renderWindow renders the current buffer into a window of size sz. Only
+// the focused window shows the cursor, selection and completions.
+func (a *App) renderWindow(sz size, focused bool) []string {
+	contentHeight := sz.height
+
+	// The whole document is evaluated: lines above the view define
+	// variables, and lines below it count towards the total
+	results := a.evaluate()
+
+	// Line numbers, separators, and a space either side of the results
+	lineNumWidth := 5
+	available := sz.width - lineNumWidth - 3
+	column := layoutResults(results, max(available/2, available-editorMinWidth))
+	if a.showRunning {
+		column = column.withRunning(results, available-editorMinWidth-column.width-runningGap)
 	}
+	editorWidth := max(editorMinWidth, available-column.width-column.runningSpace())
 
-	a.engine.Clear()
+	a.fitCursor(column, contentHeight)
 
-	for i := 0; i < contentHeight; i++ {
-		if i < len(a.lines) {
-			b.WriteString(lineNumStyle.Render(fmt.Sprintf("%3d ", i+1)))
-		} else {
-			b.WriteString(lineNumStyle.Render("    "))
-		}
+	var popup map[int]string
+	if focused {
+		popup = a.completionPopup(contentHeight)
+	}
 
-		b.WriteString("│")
+	rows := make([]string, contentHeight)
+	i, wrapped := a.scroll, 0
+	for n := range rows {
+		var b strings.Builder
 
 		var editorContent string
-		var resultContent string
+		switch {
+		case i >= len(a.lines):
+			b.WriteString(a.st.lineNum.Render("    "))
+			editorContent = a.st.nonText.Render("~")
+		case wrapped > 0:
+			// A result wrapped onto this row
+			b.WriteString(a.st.lineNum.Render("    "))
+		default:
+			b.WriteString(a.st.lineNum.Render(fmt.Sprintf("%3d ", i+1)))
 
-		if i < len(a.lines) {
 			line := a.lines[i]
-
-			if i == a.row {
+			if start, end, ok := a.selectedCols(i); ok && focused {
+				editorContent = a.renderSelectedLine(i, line, start, end)
+			} else if i == a.row && focused {
 				editorContent = a.renderLineWithCursor(line)
 			} else {
 				editorContent = a.highlighter.Highlight(line)
 			}
+		}
 
-			resultContent = a.evaluateLine(line)
-		} else {
-			editorContent = tildeStyle.Render("~")
-			resultContent = ""
+		if item, ok := popup[i]; ok && wrapped == 0 {
+			line := ""
+			if i < len(a.lines) {
+				line = a.lines[i]
+			}
+			editorContent = a.overlayCompletion(line, item)
 		}
 
+		b.WriteString("│")
+
 		editorLen := lipgloss.Width(editorContent)
 		if editorLen < editorWidth {
 			editorContent += strings.Repeat(" ", editorWidth-editorLen)
@@ -891,64 +1114,96 @@ func (a *App) View() string {
 			editorContent = editorContent[:editorWidth]
 		}
 
-		resultContent = fmt.Sprintf("%*s", resultWidth, resultContent)
-
 		b.WriteString(editorContent)
-		b.WriteString("│")
-		b.WriteString(resultContent)
-		b.WriteString("\n")
-	}
+		b.WriteString("│ ")
 
-	b.WriteString(a.renderStatusBar())
-
-	return b.String()
+		line, row := i, wrapped
+		if i < len(a.lines) {
+			b.WriteString(a.renderResult(column, results[i], i, wrapped))
+			wrapped++
+			if wrapped >= column.height(i) {
+				i, wrapped = i+1, 0
+			}
+		} else {
+			b.WriteString(strings.Repeat(" ", column.width))
+			i++
+		}
+		b.WriteString(" ")
+		if column.running != nil {
+			b.WriteString(a.renderRunning(column, line, row))
+		}
+		rows[n] = b.String()
+	}
+	return rows
 }
 
 func (a *App) renderHelp() string {
 	var content strings.Builder
 
-	content.WriteString(helpTitleStyle.Render("Help"))
+	content.WriteString(a.st.helpTitle.Render("Help"))
 	content.WriteString("\n\n")
 
-	content.WriteString(helpSectionStyle.Render("Navigation"))
+	content.WriteString(a.st.helpSection.Render("Navigation"))
 	content.WriteString("\n")
-	content.WriteString(helpKeyStyle.Render("[count]k/↑") + helpDescStyle.Render("Move up") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]j/↓") + helpDescStyle.Render("Move down") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]h/←") + helpDescStyle.Render("Move left") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]l/→") + helpDescStyle.Render("Move right") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]w") + helpDescStyle.Render("Next word") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]b") + helpDescStyle.Render("Previous word") + "\n")
-	content.WriteString(helpKeyStyle.Render("0 / $") + helpDescStyle.Render("Start / End of line") + "\n")
-	content.WriteString(helpKeyStyle.Render("gg / G") + helpDescStyle.Render("Top / Bottom of file") + "\n")
-
-	content.WriteString(helpSectionStyle.Render("Editing"))
+	content.WriteString(a.st.helpKey.Render("[count]k/↑") + a.st.helpDesc.Render("Move up") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]j/↓") + a.st.helpDesc.Render("Move down") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]h/←") + a.st.helpDesc.Render("Move left") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]l/→") + a.st.helpDesc.Render("Move right") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]w") + a.st.helpDesc.Render("Next word") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]b") + a.st.helpDesc.Render("Previous word") + "\n")
+	content.WriteString(a.st.helpKey.Render("0 / $") + a.st.helpDesc.Render("Start / End of line") + "\n")
+	content.WriteString(a.st.helpKey.Render("gg / G") + a.st.helpDesc.Render("Top / Bottom of file") + "\n")
+	content.WriteString(a.st.helpKey.Render("Ctrl+e / y") + a.st.helpDesc.Render("Scroll down / up") + "\n")
+	content.WriteString(a.st.helpKey.Render("zz") + a.st.helpDesc.Render("Center cursor line") + "\n")
+
+	content.WriteString(a.st.helpSection.Render("Editing"))
 	content.WriteString("\n")
-	content.WriteString(helpKeyStyle.Render("i / a") + helpDescStyle.Render("Insert / Append mode") + "\n")
-	content.WriteString(helpKeyStyle.Render("o / O") + helpDescStyle.Render("Open line below/above") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]x") + helpDescStyle.Render("Delete character") + "\n")
-	content.WriteString(helpKeyStyle.Render("[count]dd") + helpDescStyle.Render("Delete line") + "\n")
-	content.WriteString(helpKeyStyle.Render("d{motion}") + helpDescStyle.Render("Delete with motion") + "\n")
-	content.WriteString(helpKeyStyle.Render("yy / y{motion}") + helpDescStyle.Render("Yank line/motion") + "\n")
-	content.WriteString(helpKeyStyle.Render("p / P") + helpDescStyle.Render("Paste after/before") + "\n")
-	content.WriteString(helpKeyStyle.Render("u / Ctrl+r") + helpDescStyle.Render("Undo / Redo") + "\n")
-
-	content.WriteString(helpSectionStyle.Render("General"))
+	content.WriteString(a.st.helpKey.Render("i / a") + a.st.helpDesc.Render("Insert / Append mode") + "\n")
+	content.WriteString(a.st.helpKey.Render("Tab") + a.st.helpDesc.Render("Accept completion (insert)") + "\n")
+	content.WriteString(a.st.helpKey.Render("o / O") + a.st.helpDesc.Render("Open line below/above") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]x") + a.st.helpDesc.Render("Delete character") + "\n")
+	content.WriteString(a.st.helpKey.Render("[count]dd") + a.st.helpDesc.Render("Delete line") + "\n")
+	content.WriteString(a.st.helpKey.Render("d{motion}") + a.st.helpDesc.Render("Delete with motion") + "\n")
+	content.WriteString(a.st.helpKey.Render("yy / y{motion}") + a.st.helpDesc.Render("Yank line/motion") + "\n")
+	content.WriteString(a.st.helpKey.Render("yr / yR") + a.st.helpDesc.Render("Copy line result / all results") + "\n")
+	content.WriteString(a.st.helpKey.Render("p / P") + a.st.helpDesc.Render("Paste after/before") + "\n")
+	content.WriteString(a.st.helpKey.Render("v / V") + a.st.helpDesc.Render("Visual / Visual line") + "\n")
+	content.WriteString(a.st.helpKey.Render("d y c p") + a.st.helpDesc.Render("Delete/yank/change/paste selection") + "\n")
+	content.WriteString(a.st.helpKey.Render("u / Ctrl+r") + a.st.helpDesc.Render("Undo / Redo (:set undofile to keep)") + "\n")
+
+	content.WriteString(a.st.helpSection.Render("General"))
 	content.WriteString("\n")
-	content.WriteString(helpKeyStyle.Render("Esc") + helpDescStyle.Render("Normal mode") + "\n")
-	content.WriteString(helpKeyStyle.Render("?") + helpDescStyle.Render("Toggle help") + "\n")
-	content.WriteString(helpKeyStyle.Render("q") + helpDescStyle.Render("Quit") + "\n")
-	content.WriteString(helpKeyStyle.Render("Ctrl+C") + helpDescStyle.Render("Force quit") + "\n")
-
-	content.WriteString(helpSectionStyle.Render("Examples"))
+	content.WriteString(a.st.helpKey.Render("Esc") + a.st.helpDesc.Render("Normal mode") + "\n")
+	content.WriteString(a.st.helpKey.Render("?") + a.st.helpDesc.Render("Toggle help") + "\n")
+	content.WriteString(a.st.helpKey.Render("T") + a.st.helpDesc.Render("Toggle totals panel") + "\n")
+	content.WriteString(a.st.helpKey.Render("R") + a.st.helpDesc.Render("Toggle running total column") + "\n")
+	content.WriteString(a.st.helpKey.Render("K") + a.st.helpDesc.Render("Explain the line's result") + "\n")
+	content.WriteString(a.st.helpKey.Render("gr / :refresh") + a.st.helpDesc.Render("Fetch fresh rates") + "\n")
+	content.WriteString(a.st.helpKey.Render("Ctrl+s / :w") + a.st.helpDesc.Render("Save (:w file to save as)") + "\n")
+	content.WriteString(a.st.helpKey.Render("q / :q") + a.st.helpDesc.Render("Quit (confirms if unsaved)") + "\n")
+	content.WriteString(a.st.helpKey.Render("ZZ / :wq") + a.st.helpDesc.Render("Save and quit") + "\n")
+	content.WriteString(a.st.helpKey.Render(":e file") + a.st.helpDesc.Render("Edit file in a new buffer") + "\n")
+	content.WriteString(a.st.helpKey.Render("gt / gT") + a.st.helpDesc.Render("Next / Previous buffer (:bn, :bp)") + "\n")
+	content.WriteString(a.st.helpKey.Render(":ls / :bd") + a.st.helpDesc.Render("List / Close buffers") + "\n")
+	content.WriteString(a.st.helpKey.Render(":sp / :vs") + a.st.helpDesc.Render("Split window (:new, :vnew scratch)") + "\n")
+	content.WriteString(a.st.helpKey.Render("Ctrl+w") + a.st.helpDesc.Render("Next window (:close, :only)") + "\n")
+	content.WriteString(a.st.helpKey.Render(":set name val") + a.st.helpDesc.Render("Apply setting") + "\n")
+	content.WriteString(a.st.helpKey.Render(":scenario x=v") + a.st.helpDesc.Render("What-if: override x (:scenario off)") + "\n")
+	content.WriteString(a.st.helpKey.Render(":%s/old/new/g") + a.st.helpDesc.Render("Replace in all lines") + "\n")
+	content.WriteString(a.st.helpKey.Render(":fmt") + a.st.helpDesc.Render("Format every line") + "\n")
+	content.WriteString(a.st.helpKey.Render(":input [x]") + a.st.helpDesc.Render("Ask again for the template's inputs") + "\n")
+	content.WriteString(a.st.helpKey.Render("Ctrl+C") + a.st.helpDesc.Render("Force quit") + "\n")
+
+	content.WriteString(a.st.helpSection.Render("Examples"))
 	content.WriteString("\n")
-	content.WriteString(helpDescStyle.Render("5j      → Move down 5 lines") + "\n")
-	content.WriteString(helpDescStyle.Render("3dd     → Delete 3 lines") + "\n")
-	content.WriteString(helpDescStyle.Render("d3w     → Delete 3 words") + "\n")
-	content.WriteString(helpDescStyle.Render("y$      → Yank to end of line") + "\n")
+	content.WriteString(a.st.helpDesc.Render("5j      → Move down 5 lines") + "\n")
+	content.WriteString(a.st.helpDesc.Render("3dd     → Delete 3 lines") + "\n")
+	content.WriteString(a.st.helpDesc.Render("d3w     → Delete 3 words") + "\n")
+	content.WriteString(a.st.helpDesc.Render("y$      → Yank to end of line") + "\n")
 
-	content.WriteString(helpFooterStyle.Render("\nPress any key to close"))
+	content.WriteString(a.st.helpFooter.Render("\nPress any key to close"))
 
-	helpBox := helpBorderStyle.Render(content.String())
+	helpBox := a.st.helpBorder.Render(content.String())
 
 	return lipgloss.Place(a.width, a.height, lipgloss.Center, lipgloss.Center, helpBox)
 }
@@ -961,7 +1216,7 @@ func (a *App) renderLineWithCursor(line string) string {
 
 	// Cursor at end of line
 	if col == len(line) {
-		return a.highlighter.Highlight(line) + cursorStyle.Render(" ")
+		return a.highlighter.Highlight(line) + a.st.cursor.Render(" ")
 	}
 
 	// Get highlighted spans for precise cursor placement
@@ -984,7 +1239,7 @@ func (a *App) renderLineWithCursor(line string) string {
 			if beforeCursor != "" {
 				result.WriteString(style.Render(beforeCursor))
 			}
-			result.WriteString(cursorStyle.Render(cursorChar))
+			result.WriteString(a.st.cursor.Render(cursorChar))
 			if afterCursor != "" {
 				result.WriteString(style.Render(afterCursor))
 			}
@@ -998,43 +1253,31 @@ func (a *App) renderLineWithCursor(line string) string {
 	return result.String()
 }
 
-func (a *App) evaluateLine(line string) string {
-	trimmed := strings.TrimSpace(line)
-
-	if trimmed == "" {
-		return ""
-	}
-
-	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
-		return ""
-	}
-
-	result := a.engine.Eval(line)
-
-	if result.IsEmpty() {
-		return ""
-	}
-
-	if result.IsError() {
-		return errorStyle.Render("err")
+func (a *App) renderStatusBar() string {
+	statusBg := a.st.statusBar
+
+	// Input prompts and the command line replace the status bar while
+	// open
+	if len(a.inputs) > 0 || a.cmdline {
+		line := ":" + a.cmdInput + a.st.cursor.Render(" ")
+		if len(a.inputs) > 0 {
+			line = a.inputPrompt() + a.cmdInput + a.st.cursor.Render(" ")
+			if a.message != "" && a.messageIsError {
+				line += "  " + a.st.error.Render(a.message)
+			}
+		}
+		spaces := a.width - lipgloss.Width(line)
+		if spaces < 0 {
+			spaces = 0
+		}
+		return statusBg.Render(line + strings.Repeat(" ", spaces))
 	}
 
-	return resultStyle.Render(result.String())
-}
-
-func (a *App) renderStatusBar() string {
 	mode := a.keymap.GetMode()
 
-	var modeStyle lipgloss.Style
-	switch mode {
-	case keymap.ModeInsert:
-		modeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#000")).Background(lipgloss.Color("#7ee787")).Padding(0, 1)
-	case keymap.ModeVisual:
-		modeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#000")).Background(lipgloss.Color("#d2a8ff")).Padding(0, 1)
-	case keymap.ModeOperatorPending:
-		modeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#000")).Background(lipgloss.Color("#ffa657")).Padding(0, 1)
-	default:
-		modeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#000")).Background(lipgloss.Color("#79c0ff")).Padding(0, 1)
+	modeStyle, ok := a.st.modes[mode]
+	if !ok {
+		modeStyle = a.st.modes[keymap.ModeNormal]
 	}
 
 	modeStr := modeStyle.Render(mode.String())
@@ -1042,17 +1285,36 @@ func (a *App) renderStatusBar() string {
 	// Show pending keys
 	pending := a.keymap.State.PendingDisplay()
 	if pending != "" {
-		modeStr += " " + pendingStyle.Render(pending)
+		modeStr += " " + a.st.pending.Render(pending)
 	}
 
-	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("#666")).Render("  ? help  ^s save")
+	// Overridden variables make every result hypothetical
+	if n := len(a.engine.Overrides()); n > 0 {
+		modeStr += " " + a.st.pending.Render(fmt.Sprintf("SCENARIO(%d)", n))
+	}
+
+	hint := a.st.hint.Render("  ? help  ^s save")
+	switch {
+	case a.refresh != nil:
+		hint = "  " + a.refresh.status()
+	case a.message != "" && a.messageIsError:
+		hint = "  " + a.st.error.Render(a.message)
+	case a.message != "":
+		hint = "  " + a.message
+	}
 
 	pos := fmt.Sprintf("%d:%d", a.row+1, a.col+1)
 
+	name := a.name()
+	if a.modified {
+		name += " [+]"
+	}
+	pos = a.st.lineNum.Render(name) + "  " + pos
+
 	total := a.engine.Total()
 	totalStr := ""
 	if !total.IsEmpty() && total.AsFloat() != 0 {
-		totalStr = resultStyle.Render(fmt.Sprintf("total: %s", total.String())) + "  "
+		totalStr = a.st.result.Render(fmt.Sprintf("total: %s", a.engine.Format(total))) + "  "
 	}
 
 	left := modeStr + hint
@@ -1063,7 +1325,6 @@ func (a *App) renderStatusBar() string {
 		spaces = 1
 	}
 
-	statusBg := lipgloss.NewStyle().Background(lipgloss.Color("#1a1a2e"))
 	return statusBg.Render(left + strings.Repeat(" ", spaces) + right)
 }
 
@@ -1081,14 +1342,63 @@ func Run() error {
 // RunWithFile starts with file content
 func RunWithFile(filename, content string) error {
 	app := NewApp()
+	app.filename = filename
 	if content != "" {
 		app.lines = strings.Split(content, "\n")
+		app.askInputs(true)
 	}
 	p := tea.NewProgram(app, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }
 
+// RunWithFiles starts with a buffer per file. Missing files open empty.
+func RunWithFiles(filenames []string) error {
+	return RunWithOptions(Options{Files: filenames})
+}
+
+// Options configures RunWithOptions.
+type Options struct {
+	Files   []string // Files to open, a buffer each
+	Restore bool     // Restore the last session, and save this one on quit
+}
+
+// RunWithOptions starts with the given files. With Restore set, the files
+// of the last session reopen when none are given, cursors return to where
+// they were, and the session is saved on quit.
+func RunWithOptions(opts Options) error {
+	app := NewApp()
+	for _, filename := range opts.Files {
+		if !app.isScratch() {
+			app.addBuffer(newBuffer(app.newEngine()))
+		}
+		if err := app.open(filename); err != nil {
+			return err
+		}
+	}
+	if len(app.buffers) > 1 {
+		app.switchBuffer(0)
+		app.setMessage(app.bufferInfo())
+	}
+
+	if opts.Restore {
+		if s := loadSession(); len(opts.Files) == 0 {
+			app.restoreSession(s)
+		} else {
+			app.restorePositions(s)
+		}
+	}
+
+	p := tea.NewProgram(app, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return err
+	}
+	if opts.Restore {
+		return app.saveSession()
+	}
+	return nil
+}
+
 // RunWithTheme starts the TUI with a specific theme
 func RunWithTheme(themeName string) error {
 	app := NewAppWithTheme(themeName)
@@ -0,0 +1,244 @@
+// internal/tui/buffer.go
+
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0xsj/numio/internal/tui/keymap"
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// buffer is an open document. Each buffer has its own engine, so variables
+// and settings in one file don't leak into another.
+type buffer struct {
+	lines  []string
+	row    int
+	col    int
+	scroll int // First visible line
+	engine *engine.Engine
+
+	// Visual mode anchor
+	visualRow int
+	visualCol int
+
+	// Undo/Redo
+	undoStack []editorState
+	redoStack []editorState
+	undoJoin  bool // Insert mode edits join the last undo step
+	undoFile  bool // Undo history is saved with the file
+
+	// File
+	filename string
+	modified bool
+
+	// A scratch buffer is evaluated on top of the document it links to
+	link *buffer
+}
+
+// newBuffer creates an empty, unnamed buffer evaluated by eng.
+func newBuffer(eng *engine.Engine) *buffer {
+	return &buffer{
+		lines:  []string{""},
+		engine: eng,
+	}
+}
+
+// newScratch creates an unnamed buffer linked to doc. Its engine starts as
+// a clone of doc's, so it shares its settings and sees its variables.
+func newScratch(doc *buffer) *buffer {
+	return &buffer{
+		lines:  []string{""},
+		engine: doc.engine.Clone(),
+		link:   doc,
+	}
+}
+
+// name returns the buffer's display name.
+func (b *buffer) name() string {
+	switch {
+	case b.filename != "":
+		return b.filename
+	case b.link != nil:
+		return "[Scratch]"
+	default:
+		return "[No Name]"
+	}
+}
+
+// unsaved reports whether the buffer has changes that would be lost.
+// Unnamed scratch buffers are throwaway.
+func (b *buffer) unsaved() bool {
+	return b.modified && (b.filename != "" || b.link == nil)
+}
+
+// isScratch reports whether the buffer is unnamed, unmodified and empty,
+// so opening a file can reuse it.
+func (b *buffer) isScratch() bool {
+	return b.filename == "" && !b.modified && len(b.lines) == 1 && b.lines[0] == ""
+}
+
+// ════════════════════════════════════════════════════════════════
+// BUFFER LIST
+// ════════════════════════════════════════════════════════════════
+
+// addBuffer adds a buffer after the current one and switches to it.
+func (a *App) addBuffer(b *buffer) {
+	at := a.current + 1
+	a.buffers = append(a.buffers[:at], append([]*buffer{b}, a.buffers[at:]...)...)
+	a.switchBuffer(at)
+}
+
+// switchBuffer makes buffer i current in the focused window.
+func (a *App) switchBuffer(i int) {
+	a.current = i
+	a.buffer = a.buffers[i]
+	a.windows[a.win].buf = a.buffer
+	a.keymap.SetMode(keymap.ModeNormal)
+	a.clearCompletions()
+	a.confirmQuit = false
+}
+
+// cycleBuffer moves n buffers forward (negative moves back), wrapping
+// around the list.
+func (a *App) cycleBuffer(n int) {
+	count := len(a.buffers)
+	a.switchBuffer(((a.current+n)%count + count) % count)
+	a.setMessage(a.bufferInfo())
+}
+
+// findBuffer returns the index of the buffer editing path, or -1.
+func (a *App) findBuffer(path string) int {
+	for i, b := range a.buffers {
+		if b.filename != "" && samePath(b.filename, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// closeBuffer closes the current buffer, discarding any changes, along
+// with other windows onto it. Closing the last buffer leaves an empty one.
+func (a *App) closeBuffer() {
+	closed := a.buffer
+	a.closeWindowsOnto(closed)
+	for _, b := range a.buffers {
+		if b.link == closed {
+			b.link = nil
+		}
+	}
+
+	if len(a.buffers) == 1 {
+		a.buffers[0] = newBuffer(a.newEngine())
+		a.switchBuffer(0)
+		return
+	}
+
+	a.buffers = append(a.buffers[:a.current], a.buffers[a.current+1:]...)
+	a.switchBuffer(min(a.current, len(a.buffers)-1))
+}
+
+// modifiedBuffers returns the number of buffers with unsaved changes.
+func (a *App) modifiedBuffers() int {
+	n := 0
+	for _, b := range a.buffers {
+		if b.unsaved() {
+			n++
+		}
+	}
+	return n
+}
+
+// bufferInfo describes the current buffer, as in "2/3 budget.calc [+]".
+func (a *App) bufferInfo() string {
+	info := fmt.Sprintf("%d/%d %s", a.current+1, len(a.buffers), a.name())
+	if a.modified {
+		info += " [+]"
+	}
+	return info
+}
+
+// bufferList lists the open buffers, marking the current one with %.
+func (a *App) bufferList() string {
+	items := make([]string, len(a.buffers))
+	for i, b := range a.buffers {
+		mark := " "
+		if i == a.current {
+			mark = "%"
+		}
+		items[i] = fmt.Sprintf("%d%s %s", i+1, mark, b.name())
+		if b.modified {
+			items[i] += " [+]"
+		}
+	}
+	return strings.Join(items, "  ")
+}
+
+// samePath reports whether two paths name the same file.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// ════════════════════════════════════════════════════════════════
+// TABLINE
+// ════════════════════════════════════════════════════════════════
+
+// tablineHeight returns the number of rows the tabline takes up. It is
+// only shown with more than one buffer.
+func (a *App) tablineHeight() int {
+	if len(a.buffers) > 1 {
+		return 1
+	}
+	return 0
+}
+
+// renderTabline renders a tab per buffer, scrolled so the current one is
+// visible.
+func (a *App) renderTabline() string {
+	tabs := make([]string, len(a.buffers))
+	for i, b := range a.buffers {
+		name := b.name()
+		if b.filename != "" {
+			name = filepath.Base(name)
+		}
+		if b.modified {
+			name += " [+]"
+		}
+		label := fmt.Sprintf(" %d %s ", i+1, name)
+
+		style := a.st.tab
+		if i == a.current {
+			style = a.st.tabActive
+		}
+		tabs[i] = style.Render(label)
+	}
+
+	first, width := 0, 0
+	for i := a.current; i >= 0; i-- {
+		width += lipgloss.Width(tabs[i])
+		if width > a.width && i < a.current {
+			break
+		}
+		first = i
+	}
+
+	var b strings.Builder
+	width = 0
+	for _, tab := range tabs[first:] {
+		if width+lipgloss.Width(tab) > a.width {
+			break
+		}
+		b.WriteString(tab)
+		width += lipgloss.Width(tab)
+	}
+	return b.String() + a.st.statusBar.Render(strings.Repeat(" ", max(0, a.width-width)))
+}
@@ -0,0 +1,83 @@
+// internal/tui/clipboard.go
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ════════════════════════════════════════════════════════════════
+// CLIPBOARD
+// ════════════════════════════════════════════════════════════════
+
+// copyToClipboard returns a command that copies text to the system
+// clipboard with an OSC 52 escape sequence, which works over SSH and
+// inside tmux or screen.
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		seq := osc52.New(text)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		seq.WriteTo(os.Stderr)
+		return nil
+	}
+}
+
+// results evaluates the document in a copy of the engine and returns the
+// formatted result of each line ("" for lines without a result).
+func (a *App) results() []string {
+	eng := a.engine.Clone()
+	eng.Clear()
+
+	values := eng.EvalFile(a.content())
+	out := make([]string, len(values))
+	for i, v := range values {
+		if !v.IsEmpty() && !v.IsError() {
+			out[i] = eng.Format(v)
+		}
+	}
+	return out
+}
+
+// yankResult copies the current line's result to the clipboard and the
+// yank buffer.
+func (a *App) yankResult() tea.Cmd {
+	result := a.results()[a.row]
+	if result == "" {
+		a.setError("No result on this line")
+		return nil
+	}
+
+	a.yankBuffer = result
+	a.setMessage("Copied " + result)
+	return copyToClipboard(result)
+}
+
+// yankResults copies every line's result, one per line, to the clipboard
+// and the yank buffer.
+func (a *App) yankResults() tea.Cmd {
+	var results []string
+	for _, r := range a.results() {
+		if r != "" {
+			results = append(results, r)
+		}
+	}
+	if len(results) == 0 {
+		a.setError("No results")
+		return nil
+	}
+
+	text := strings.Join(results, "\n") + "\n"
+	a.yankBuffer = text
+	a.setMessage(fmt.Sprintf("Copied %d results", len(results)))
+	return copyToClipboard(text)
+}
@@ -0,0 +1,561 @@
+// internal/tui/cmdline.go
+
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0xsj/numio/internal/highlight"
+	"github.com/0xsj/numio/pkg/errors"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// ════════════════════════════════════════════════════════════════
+// COMMAND LINE
+// ════════════════════════════════════════════════════════════════
+
+// handleCommandLineKey handles a key while the : prompt is open.
+func (a *App) handleCommandLineKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+[":
+		a.cmdline = false
+
+	case "enter":
+		a.cmdline = false
+		return a, a.runCommand(a.cmdInput)
+
+	case "backspace":
+		if a.cmdInput == "" {
+			a.cmdline = false
+		} else {
+			a.cmdInput = a.cmdInput[:len(a.cmdInput)-1]
+		}
+
+	default:
+		if len(msg.Runes) > 0 {
+			a.cmdInput += string(msg.Runes)
+		} else if msg.String() == "space" || msg.String() == " " {
+			a.cmdInput += " "
+		}
+	}
+
+	return a, nil
+}
+
+// runCommand runs a : command:
+//
+//	:w [file]          save (as file)
+//	:wq, :x            save and quit
+//	:q, :q!            quit, discarding changes with !
+//	:e[!] [file]       edit file in a new buffer, or reload, discarding changes with !
+//	:bn, :bp, :b N     next, previous, or Nth buffer
+//	:bd[!], :ls        close the buffer, list buffers
+//	:sp, :vs [file]    split the window, onto file if given
+//	:new, :vnew        split onto a scratch buffer that sees this one's variables
+//	:clo, :on          close the window, close all other windows
+//	:set name value    apply a setting (precision, strict, region, theme, undofile, ...)
+//	:scenario [x = v]  override a variable without editing the document; off ends it
+//	:[%]s/old/new/[g]  replace text on the current line or all lines
+//	:fmt               format every line canonically
+//	:input [name]      ask again for the document's inputs, or one of them
+func (a *App) runCommand(input string) tea.Cmd {
+	input = strings.TrimSpace(input)
+	if isSubstitute(input) {
+		a.substitute(input)
+		return nil
+	}
+
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "":
+		return nil
+
+	case "w", "write":
+		a.writeCommand(arg)
+
+	case "wq", "x":
+		if a.writeCommand(arg) {
+			return tea.Quit
+		}
+
+	case "q", "quit":
+		return a.quit()
+
+	case "q!", "quit!":
+		return tea.Quit
+
+	case "e", "edit":
+		a.editCommand(arg, false)
+
+	case "e!", "edit!":
+		a.editCommand(arg, true)
+
+	case "bn", "bnext":
+		a.cycleBuffer(1)
+
+	case "bp", "bprevious", "bN", "bNext":
+		a.cycleBuffer(-1)
+
+	case "b", "buffer":
+		a.bufferCommand(arg)
+
+	case "bd", "bdelete":
+		a.bdeleteCommand(false)
+
+	case "bd!", "bdelete!":
+		a.bdeleteCommand(true)
+
+	case "ls", "buffers":
+		a.setMessage(a.bufferList())
+
+	case "sp", "split":
+		a.splitCommand(false, arg)
+
+	case "vs", "vsplit":
+		a.splitCommand(true, arg)
+
+	case "new":
+		a.scratchCommand(false)
+
+	case "vne", "vnew":
+		a.scratchCommand(true)
+
+	case "clo", "close":
+		a.closeWindow()
+
+	case "on", "only":
+		a.onlyWindow()
+
+	case "set", "se":
+		a.setCommand(arg)
+
+	case "scenario", "whatif":
+		a.scenarioCommand(arg)
+
+	case "fmt", "format":
+		a.formatCommand()
+
+	case "input":
+		a.inputCommand(arg)
+
+	case "refresh":
+		return a.refreshRates()
+
+	default:
+		a.setError("Not an editor command: " + name)
+	}
+
+	return nil
+}
+
+// writeCommand saves the buffer, to path if given.
+// Returns true on success.
+func (a *App) writeCommand(path string) bool {
+	var err error
+	if path != "" {
+		err = a.saveAs(path)
+	} else {
+		err = a.save()
+	}
+	if err != nil {
+		a.setError(err.Error())
+		return false
+	}
+	return true
+}
+
+// editCommand opens path in a buffer of its own, switching to it if it is
+// already open, or reloads the current file if path is empty. Reloading
+// only discards unsaved changes when force is set.
+func (a *App) editCommand(path string, force bool) {
+	if path == "" {
+		path = a.filename
+	}
+	if path == "" {
+		a.setError("No file name")
+		return
+	}
+
+	if a.filename != "" && samePath(path, a.filename) {
+		if a.modified && !force {
+			a.setError("No write since last change (add ! to override)")
+			return
+		}
+		if err := a.open(path); err != nil {
+			a.setError(err.Error())
+		}
+		return
+	}
+	a.editFile(path, a.isScratch())
+}
+
+// editFile switches to the buffer editing path, opening it in a new buffer
+// if needed, or in the current one if reuse is set.
+func (a *App) editFile(path string, reuse bool) {
+	if i := a.findBuffer(path); i >= 0 {
+		a.switchBuffer(i)
+		a.setMessage(a.bufferInfo())
+		return
+	}
+
+	prev := a.current
+	if !reuse {
+		a.addBuffer(newBuffer(a.newEngine()))
+	}
+	if err := a.open(path); err != nil {
+		if !reuse {
+			a.closeBuffer()
+			a.switchBuffer(prev)
+		}
+		a.setError(err.Error())
+	}
+}
+
+// splitCommand splits the window, onto path if given.
+func (a *App) splitCommand(vertical bool, path string) {
+	if a.split(vertical) && path != "" {
+		a.editFile(path, false)
+	}
+}
+
+// scratchCommand splits the window onto a new scratch buffer linked to the
+// current one.
+func (a *App) scratchCommand(vertical bool) {
+	doc := a.buffer
+	if a.split(vertical) {
+		a.addBuffer(newScratch(doc))
+	}
+}
+
+// bufferCommand switches to buffer number arg (1-based), or names the
+// current buffer if arg is empty.
+func (a *App) bufferCommand(arg string) {
+	if arg == "" {
+		a.setMessage(a.bufferInfo())
+		return
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(a.buffers) {
+		a.setError("Buffer " + arg + " does not exist")
+		return
+	}
+	a.switchBuffer(n - 1)
+	a.setMessage(a.bufferInfo())
+}
+
+// bdeleteCommand closes the current buffer. Unsaved changes are only
+// discarded when force is set.
+func (a *App) bdeleteCommand(force bool) {
+	if a.unsaved() && !force {
+		a.setError("No write since last change (add ! to override)")
+		return
+	}
+	a.closeBuffer()
+	a.setMessage(a.bufferInfo())
+}
+
+// setCommand applies "name value", "name=value", "name" (on) or
+// "noname" (off). With no argument it shows the current settings.
+func (a *App) setCommand(arg string) {
+	if arg == "" {
+		strict := "off"
+		if a.engine.IsStrict() {
+			strict = "on"
+		}
+		undofile := "off"
+		if a.undoFile {
+			undofile = "on"
+		}
+		precision := "auto"
+		if p := a.engine.Precision(); p != types.AutoPrecision {
+			precision = strconv.Itoa(p)
+		}
+		if n := a.engine.SigFigs(); n > 0 {
+			precision += " sigfig=" + strconv.Itoa(n)
+		}
+		asOf := "off"
+		if a.engine.ShowAsOf() {
+			asOf = "on"
+		}
+		a.setMessage(fmt.Sprintf("precision=%s rounding=%s locale=%s currencies=%s angle=%s strict=%s asof=%s region=%s theme=%s undofile=%s",
+			precision, a.engine.Rounding(), a.engine.InputLocale(), a.engine.CurrencyDisplay(), a.engine.AngleMode(), strict, asOf, a.engine.Region(),
+			a.highlighter.Theme().Name, undofile))
+		return
+	}
+
+	var name, value string
+	if n, v, ok := strings.Cut(arg, "="); ok {
+		name, value = n, v
+	} else if fields := strings.Fields(arg); len(fields) > 1 {
+		name = strings.Join(fields[:len(fields)-1], " ")
+		value = fields[len(fields)-1]
+	} else if strings.HasPrefix(arg, "no") {
+		name, value = arg[2:], "off"
+	} else {
+		name, value = arg, "on"
+	}
+
+	switch strings.TrimSpace(name) {
+	case "theme":
+		a.themeCommand(strings.TrimSpace(value))
+		return
+	case "undofile", "udf":
+		a.undofileCommand(strings.TrimSpace(value))
+		return
+	}
+
+	if err := a.engine.ApplySetting(name, value); err != nil {
+		if ee, ok := err.(*errors.Error); ok {
+			a.setError(ee.Message)
+		} else {
+			a.setError(err.Error())
+		}
+		return
+	}
+	a.setMessage(strings.TrimSpace(name) + "=" + strings.TrimSpace(value))
+}
+
+// scenarioCommand overrides a variable for a what-if: "rate = 7%" makes
+// every line that uses rate recompute as if the document said 7%, without
+// changing it. "rate =" drops one override, "off" all of them, and with no
+// argument it shows the current ones.
+func (a *App) scenarioCommand(arg string) {
+	overrides := a.engine.Overrides()
+
+	if arg == "" {
+		a.setMessage(a.scenarioInfo(overrides))
+		return
+	}
+	if arg == "off" {
+		a.engine.SetOverrides(nil)
+		a.setMessage("scenario off")
+		return
+	}
+
+	name, expr, ok := strings.Cut(arg, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || !isVariableName(name) {
+		a.setError("Usage: :scenario name = value, :scenario off")
+		return
+	}
+
+	if expr == "" {
+		delete(overrides, name)
+	} else {
+		value := a.engine.EvalPreview(expr)
+		if value.IsError() {
+			a.setError(value.ErrorMessage())
+			return
+		}
+		if value.IsEmpty() {
+			a.setError("No value: " + expr)
+			return
+		}
+		overrides[name] = value
+	}
+	a.engine.SetOverrides(overrides)
+	a.setMessage(a.scenarioInfo(overrides))
+}
+
+// scenarioInfo describes the overridden variables.
+func (a *App) scenarioInfo(overrides map[string]types.Value) string {
+	if len(overrides) == 0 {
+		return "No scenario (:scenario name = value)"
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + a.engine.Format(overrides[name])
+	}
+	return "scenario: " + strings.Join(parts, " ")
+}
+
+// isVariableName reports whether s can name a variable: a letter or
+// underscore, then letters, digits and underscores.
+func isVariableName(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// themeCommand switches to a named theme.
+func (a *App) themeCommand(name string) {
+	theme, ok := highlight.LookupTheme(name)
+	if !ok {
+		a.setError("Unknown theme: " + name + " (" + strings.Join(highlight.ThemeNames(), ", ") + ")")
+		return
+	}
+	a.setTheme(theme)
+	a.setMessage("theme=" + theme.Name)
+}
+
+// undofileCommand turns saving undo history with the current file on or
+// off.
+func (a *App) undofileCommand(value string) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1":
+		a.undoFile = true
+	case "off", "false", "no", "0":
+		a.undoFile = false
+	default:
+		a.setError("Invalid value for undofile: " + value)
+		return
+	}
+
+	if a.undoFile {
+		a.setMessage("undofile=on (history is saved with the file)")
+	} else {
+		a.setMessage("undofile=off")
+	}
+}
+
+// ════════════════════════════════════════════════════════════════
+// SUBSTITUTE
+// ════════════════════════════════════════════════════════════════
+
+// isSubstitute reports whether input is an :s or :%s command.
+func isSubstitute(input string) bool {
+	input = strings.TrimPrefix(input, "%")
+	return len(input) > 1 && input[0] == 's' && strings.ContainsRune("/#|,", rune(input[1]))
+}
+
+// substitute runs ":[%]s/old/new/[g]". Patterns are matched literally, since
+// "$" and "." are common in calculations; a backslash escapes the delimiter.
+func (a *App) substitute(input string) {
+	all := strings.HasPrefix(input, "%")
+	input = strings.TrimPrefix(input, "%")
+
+	parts := splitEscaped(input[2:], input[1])
+	if len(parts) < 2 || parts[0] == "" {
+		a.setError("Invalid substitute: " + input)
+		return
+	}
+	old, repl := parts[0], parts[1]
+	n := 1
+	if len(parts) > 2 && strings.Contains(parts[2], "g") {
+		n = -1
+	}
+
+	first, last := a.row, a.row
+	if all {
+		first, last = 0, len(a.lines)-1
+	}
+
+	count, changed := 0, 0
+	lines := make([]string, len(a.lines))
+	copy(lines, a.lines)
+	for i := first; i <= last; i++ {
+		matches := strings.Count(lines[i], old)
+		if matches == 0 {
+			continue
+		}
+		if n == 1 {
+			matches = 1
+		}
+		lines[i] = strings.Replace(lines[i], old, repl, n)
+		count += matches
+		changed++
+	}
+
+	if count == 0 {
+		a.setError("Pattern not found: " + old)
+		return
+	}
+
+	a.saveUndo()
+	a.lines = lines
+	a.clampCol()
+	a.setMessage(fmt.Sprintf("%d substitutions on %d lines", count, changed))
+}
+
+// formatCommand rewrites every line canonically, as the engine formats
+// them: spaced operators, unit codes and consistent currency amounts.
+func (a *App) formatCommand() {
+	lines := strings.Split(a.engine.FormatDocument(strings.Join(a.lines, "\n")), "\n")
+	changed := 0
+	for i := range lines {
+		if lines[i] != a.lines[i] {
+			changed++
+		}
+	}
+	if changed == 0 {
+		a.setMessage("Already formatted")
+		return
+	}
+
+	a.saveUndo()
+	a.lines = lines
+	a.clampCol()
+	a.setMessage(fmt.Sprintf("Formatted %d lines", changed))
+}
+
+// splitEscaped splits s on sep, treating a backslash before sep as a
+// literal sep.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == sep:
+			cur.WriteByte(sep)
+			i++
+		case s[i] == sep:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	return append(parts, cur.String())
+}
+
+// quit closes the focused window if there are several, and otherwise
+// quits unless any buffer has unsaved changes, in which case the first
+// request only warns and a second one quits.
+func (a *App) quit() tea.Cmd {
+	if len(a.windows) > 1 {
+		a.closeWindow()
+		return nil
+	}
+	if n := a.modifiedBuffers(); n > 0 && !a.confirmQuit {
+		a.confirmQuit = true
+		switch {
+		case a.unsaved() && n == 1:
+			a.setError("Unsaved changes: q again to quit, ctrl+s to save")
+		case n == 1:
+			a.setError("Unsaved changes in another buffer: q again to quit, :ls to list")
+		default:
+			a.setError(fmt.Sprintf("Unsaved changes in %d buffers: q again to quit, :ls to list", n))
+		}
+		return nil
+	}
+	return tea.Quit
+}
+
+// setMessage shows an informational message in the status bar.
+func (a *App) setMessage(msg string) {
+	a.message = msg
+	a.messageIsError = false
+}
+
+// setError shows an error message in the status bar.
+func (a *App) setError(msg string) {
+	a.message = msg
+	a.messageIsError = true
+}
@@ -0,0 +1,140 @@
+// internal/tui/completion.go
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+const (
+	completionMinPrefix = 2  // Characters typed before the popup appears
+	completionMaxItems  = 8  // Candidates shown at once
+	completionMaxDetail = 30 // Characters of a variable's description shown
+)
+
+// completionKindLabels are the short kind names shown in the popup.
+var completionKindLabels = map[engine.CompletionKind]string{
+	engine.CompleteVariable: "var",
+	engine.CompleteFunction: "fn",
+	engine.CompleteCurrency: "currency",
+	engine.CompleteCrypto:   "crypto",
+	engine.CompleteMetal:    "metal",
+	engine.CompleteUnit:     "unit",
+}
+
+// ════════════════════════════════════════════════════════════════
+// COMPLETION
+// ════════════════════════════════════════════════════════════════
+
+// updateCompletions refreshes the popup for the word before the cursor.
+func (a *App) updateCompletions() {
+	a.clearCompletions()
+
+	input := a.lines[a.row][:min(a.col, len(a.lines[a.row]))]
+	prefix := engine.CompletionPrefix(input)
+	if len(prefix) < completionMinPrefix {
+		return
+	}
+
+	items := a.engine.Complete(input)
+	if len(items) == 1 && items[0].Label == prefix {
+		return // Already complete
+	}
+	if len(items) > completionMaxItems {
+		items = items[:completionMaxItems]
+	}
+
+	a.completions = items
+	a.completionPrefix = prefix
+}
+
+// clearCompletions closes the popup.
+func (a *App) clearCompletions() {
+	a.completions = nil
+	a.completionIdx = 0
+	a.completionPrefix = ""
+}
+
+// handleCompletionKey handles a key while the popup is open: Tab accepts,
+// Up/Down (or Ctrl+P/Ctrl+N) select, Esc closes. Returns false for keys
+// the popup doesn't use.
+func (a *App) handleCompletionKey(key string) bool {
+	if len(a.completions) == 0 {
+		return false
+	}
+
+	switch key {
+	case "tab":
+		a.acceptCompletion()
+	case "down", "ctrl+n":
+		a.completionIdx = (a.completionIdx + 1) % len(a.completions)
+	case "up", "ctrl+p":
+		a.completionIdx = (a.completionIdx - 1 + len(a.completions)) % len(a.completions)
+	case "esc":
+		a.clearCompletions()
+	default:
+		return false
+	}
+	return true
+}
+
+// acceptCompletion replaces the word before the cursor with the selected
+// candidate.
+func (a *App) acceptCompletion() {
+	label := a.completions[a.completionIdx].Label
+	start := a.col - len(a.completionPrefix)
+
+	a.saveInsertUndo()
+	line := a.lines[a.row]
+	a.lines[a.row] = line[:start] + label + line[a.col:]
+	a.col = start + len(label)
+	a.clearCompletions()
+}
+
+// completionPopup returns the popup rows keyed by document line, placed
+// below the cursor line, or above it if there is no room in the view.
+func (a *App) completionPopup(contentHeight int) map[int]string {
+	if len(a.completions) == 0 {
+		return nil
+	}
+
+	labelWidth, kindWidth, detailWidth := 0, 0, 0
+	for _, c := range a.completions {
+		labelWidth = max(labelWidth, len(c.Label))
+		kindWidth = max(kindWidth, len(completionKindLabels[c.Kind]))
+		detailWidth = max(detailWidth, min(lipgloss.Width(c.Detail), completionMaxDetail))
+	}
+
+	first := a.row + 1
+	if first+len(a.completions) > a.scroll+contentHeight {
+		first = a.row - len(a.completions)
+	}
+
+	popup := make(map[int]string, len(a.completions))
+	for i, c := range a.completions {
+		kind := completionKindLabels[c.Kind]
+		text := " " + c.Label + strings.Repeat(" ", labelWidth-len(c.Label)) +
+			"  " + kind + strings.Repeat(" ", kindWidth-len(kind)) + " "
+		if detailWidth > 0 {
+			text += " " + fitWidth(c.Detail, detailWidth) + " "
+		}
+		style := a.st.completion
+		if i == a.completionIdx {
+			style = a.st.completionSelected
+		}
+		popup[first+i] = style.Render(text)
+	}
+	return popup
+}
+
+// overlayCompletion draws a popup row over a line, aligned with the start
+// of the word being completed.
+func (a *App) overlayCompletion(line, item string) string {
+	col := max(a.col-len(a.completionPrefix), 0)
+	left := line[:min(col, len(line))]
+	return left + strings.Repeat(" ", col-len(left)) + item
+}
@@ -0,0 +1,89 @@
+// internal/tui/explain.go
+
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// explainLine explains the current line's result (K), as the engine
+// stands after the lines above it.
+func (a *App) explainLine() {
+	settingsLines, _ := a.resetEngine()
+	if a.row < settingsLines {
+		a.setError("Front matter has no result to explain")
+		return
+	}
+	for _, line := range a.lines[settingsLines:a.row] {
+		a.engine.Eval(line)
+	}
+
+	x := a.engine.Explain(a.lines[a.row])
+	if x.Result.IsEmpty() {
+		a.setError("Nothing to explain on this line")
+		return
+	}
+	a.explain = &x
+}
+
+// renderExplain renders the explanation popup over the editor.
+func (a *App) renderExplain() string {
+	x := a.explain
+
+	var body []string
+	if x.Tree != "" {
+		body = append(body, a.st.helpSection.Render("Parse tree"))
+		body = append(body, strings.Split(x.Tree, "\n")...)
+	}
+	if len(x.Steps) > 0 {
+		body = append(body, a.st.helpSection.Render("Steps"))
+		for _, s := range x.Steps {
+			body = append(body, strings.Repeat("  ", s.Depth)+s.Expr+a.st.helpDesc.Render(" = "+a.engine.Format(s.Value)))
+		}
+	}
+	if len(x.Rates) > 0 {
+		body = append(body, a.st.helpSection.Render("Rates"))
+		for _, r := range x.Rates {
+			source := "built-in"
+			if !r.Fetched.IsZero() {
+				source = "fetched " + r.Fetched.Local().Format("2006-01-02 15:04")
+			}
+			rate := fmt.Sprintf("1 %s = %s %s", r.From, strconv.FormatFloat(r.Rate, 'g', 6, 64), r.To)
+			body = append(body, rate+a.st.helpDesc.Render(" ("+source+")"))
+		}
+	}
+	if len(x.Reads) > 0 {
+		body = append(body, a.st.helpSection.Render("Variables read"))
+		for _, name := range x.Reads {
+			if d := x.Descriptions[name]; d != "" {
+				name += a.st.helpDesc.Render("  " + d)
+			}
+			body = append(body, name)
+		}
+	}
+
+	// Leave room for the title, result, footer and border
+	if limit := max(a.height-12, 4); len(body) > limit {
+		body = append(body[:limit-1], a.st.helpDesc.Render("…"))
+	}
+
+	result := a.engine.Format(x.Result)
+	if x.Result.IsError() {
+		result = x.Result.ErrorMessage()
+	}
+
+	var content strings.Builder
+	content.WriteString(a.st.helpTitle.Render("Explain line "+strconv.Itoa(a.row+1)) + "\n")
+	content.WriteString(a.st.helpDesc.Render(strings.TrimSpace(x.Input)) + "\n")
+	content.WriteString(strings.Join(body, "\n") + "\n")
+	content.WriteString(a.st.helpSection.Render("Result") + "\n")
+	content.WriteString(result)
+	content.WriteString(a.st.helpFooter.Render("\nPress any key to close"))
+
+	box := a.st.helpBorder.Render(content.String())
+	return lipgloss.Place(a.width, a.height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,115 @@
+// internal/tui/file.go
+
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/0xsj/numio/internal/atomicfile"
+)
+
+// backupSuffix is appended to a file's name for the copy kept on save.
+const backupSuffix = "~"
+
+// ════════════════════════════════════════════════════════════════
+// OPENING
+// ════════════════════════════════════════════════════════════════
+
+// open replaces the current buffer with the contents of path and makes it
+// the buffer's file, and asks for the inputs it declares that have no
+// value. A missing file opens an empty buffer.
+func (a *App) open(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	a.lines = strings.Split(string(data), "\n")
+	a.row, a.col, a.scroll = 0, 0, 0
+	a.undoStack = nil
+	a.redoStack = nil
+	a.undoFile = false
+	a.filename = path
+	a.modified = false
+	a.readUndo(string(data))
+
+	if os.IsNotExist(err) {
+		a.setMessage(fmt.Sprintf("%q [New]", path))
+	} else {
+		a.setMessage(fmt.Sprintf("%q %dL", path, len(a.lines)))
+	}
+	a.askInputs(true)
+	return nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// SAVING
+// ════════════════════════════════════════════════════════════════
+
+// content returns the buffer as file contents.
+func (a *App) content() string {
+	return strings.Join(a.lines, "\n")
+}
+
+// save writes the buffer to the current file.
+func (a *App) save() error {
+	if a.filename == "" {
+		return fmt.Errorf("no file name (use :w <file>)")
+	}
+	return a.saveAs(a.filename)
+}
+
+// saveAs writes the buffer to path and makes it the current file, along
+// with its undo history if that is kept.
+func (a *App) saveAs(path string) error {
+	content := a.content()
+	if err := writeWithBackup(path, []byte(content)); err != nil {
+		return err
+	}
+
+	a.filename = path
+	a.modified = false
+	a.setMessage(fmt.Sprintf("%q %dL written", path, len(a.lines)))
+
+	if a.undoFile {
+		if err := a.writeUndo(content); err != nil {
+			a.setError("Undo history not saved: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// writeWithBackup writes data to path atomically, keeping the file's
+// permissions. The previous version, if any, is kept as path + "~".
+func writeWithBackup(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+		if err := copyFile(path, path+backupSuffix, perm); err != nil {
+			return fmt.Errorf("backup failed: %w", err)
+		}
+	}
+	return atomicfile.Write(path, data, perm)
+}
+
+// copyFile copies src to dst, replacing dst.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,98 @@
+// internal/tui/input.go
+
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0xsj/numio/pkg/engine"
+)
+
+// pendingInput is a template input to ask for, and the engine of the
+// buffer declaring it.
+type pendingInput struct {
+	engine *engine.Engine
+	input  engine.Input
+}
+
+// askInputs queues prompts for the inputs the current buffer declares,
+// or only those with no value yet if missing is set.
+func (a *App) askInputs(missing bool) {
+	inputs := engine.Inputs(a.lines)
+	if missing {
+		inputs = a.engine.MissingInputs(a.lines)
+	}
+	for _, in := range inputs {
+		a.inputs = append(a.inputs, pendingInput{engine: a.engine, input: in})
+	}
+	a.cmdInput = ""
+}
+
+// inputPrompt returns what the status bar asks for the next input.
+func (a *App) inputPrompt() string {
+	in := a.inputs[0].input
+	if in.Prompt == "" {
+		return in.Name + ": "
+	}
+	return in.Prompt + " (" + in.Name + "): "
+}
+
+// handleInputKey handles a key while an input is being asked for. Enter
+// gives the input the value of what was typed; Esc skips it, leaving its
+// line an error.
+func (a *App) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a.message = ""
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+[":
+		a.nextInput()
+
+	case "enter":
+		p := a.inputs[0]
+		if v := p.engine.SetInputText(p.input.Name, a.cmdInput); v.IsError() {
+			a.setError(v.ErrorMessage())
+			return a, nil
+		}
+		a.nextInput()
+
+	case "backspace":
+		if r := []rune(a.cmdInput); len(r) > 0 {
+			a.cmdInput = string(r[:len(r)-1])
+		}
+
+	default:
+		if len(msg.Runes) > 0 {
+			a.cmdInput += string(msg.Runes)
+		} else if msg.String() == "space" || msg.String() == " " {
+			a.cmdInput += " "
+		}
+	}
+
+	return a, nil
+}
+
+// nextInput moves on to the next input to ask for.
+func (a *App) nextInput() {
+	a.inputs = a.inputs[1:]
+	a.cmdInput = ""
+}
+
+// inputCommand asks again for the inputs the document declares (:input),
+// or for the one named.
+func (a *App) inputCommand(name string) {
+	if name == "" {
+		a.askInputs(false)
+		if len(a.inputs) == 0 {
+			a.setError("No inputs in this document")
+		}
+		return
+	}
+	for _, in := range engine.Inputs(a.lines) {
+		if in.Name == name {
+			a.inputs = append(a.inputs, pendingInput{engine: a.engine, input: in})
+			a.cmdInput = ""
+			return
+		}
+	}
+	a.setError("No input " + name)
+}
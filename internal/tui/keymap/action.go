@@ -14,6 +14,7 @@ const (
 	ActionInsertMode Action = "insert_mode"
 	ActionAppendMode Action = "append_mode"
 	ActionVisualMode Action = "visual_mode"
+	ActionVisualLine Action = "visual_line_mode"
 
 	// Cursor movement
 	ActionMoveUp        Action = "move_up"
@@ -29,6 +30,11 @@ const (
 	ActionPageUp        Action = "page_up"
 	ActionPageDown      Action = "page_down"
 
+	// Scrolling (viewport only)
+	ActionScrollDown   Action = "scroll_down"
+	ActionScrollUp     Action = "scroll_up"
+	ActionScrollCenter Action = "scroll_center"
+
 	// Editing - Normal mode
 	ActionDeleteChar     Action = "delete_char"
 	ActionDeleteCharBack Action = "delete_char_back"
@@ -36,6 +42,8 @@ const (
 	ActionDeleteToEnd    Action = "delete_to_end"
 	ActionYankLine       Action = "yank_line"
 	ActionYank           Action = "yank"
+	ActionYankResult     Action = "yank_result"
+	ActionYankResults    Action = "yank_results"
 	ActionPaste          Action = "paste"
 	ActionPasteAbove     Action = "paste_above"
 	ActionUndo           Action = "undo"
@@ -65,10 +73,21 @@ const (
 	ActionSaveQuit    Action = "save_quit"
 	ActionToggleHelp  Action = "toggle_help"
 	ActionRefreshRate Action = "refresh_rate"
+	ActionCommandLine Action = "command_line"
+	ActionExplain     Action = "explain"
+
+	// Buffers
+	ActionNextBuffer Action = "next_buffer"
+	ActionPrevBuffer Action = "prev_buffer"
+
+	// Windows
+	ActionNextWindow Action = "next_window"
 
 	// UI toggles
 	ActionToggleLineNumbers Action = "toggle_line_numbers"
 	ActionToggleWrap        Action = "toggle_wrap"
+	ActionTogglePanel       Action = "toggle_panel"
+	ActionToggleRunning     Action = "toggle_running"
 )
 
 // ActionMetadata contains information about an action.
@@ -87,6 +106,7 @@ var actionRegistry = map[Action]ActionMetadata{
 	ActionInsertMode: {"Insert Mode", "Switch to insert mode", false, false, false},
 	ActionAppendMode: {"Append Mode", "Insert after cursor", false, false, false},
 	ActionVisualMode: {"Visual Mode", "Switch to visual mode", false, false, false},
+	ActionVisualLine: {"Visual Line", "Switch to line-wise visual mode", false, false, false},
 
 	// Cursor movement (all are motions)
 	ActionMoveUp:        {"Move Up", "Move cursor up", true, false, false},
@@ -102,6 +122,11 @@ var actionRegistry = map[Action]ActionMetadata{
 	ActionPageUp:        {"Page Up", "Move page up", true, false, false},
 	ActionPageDown:      {"Page Down", "Move page down", true, false, false},
 
+	// Scrolling
+	ActionScrollDown:   {"Scroll Down", "Scroll the view down a line", false, false, false},
+	ActionScrollUp:     {"Scroll Up", "Scroll the view up a line", false, false, false},
+	ActionScrollCenter: {"Scroll Center", "Center the view on the cursor", false, false, false},
+
 	// Editing - Normal mode
 	ActionDeleteChar:     {"Delete Char", "Delete character under cursor", false, false, true},
 	ActionDeleteCharBack: {"Delete Back", "Delete character before cursor", false, false, true},
@@ -109,6 +134,8 @@ var actionRegistry = map[Action]ActionMetadata{
 	ActionDeleteToEnd:    {"Delete to End", "Delete to end of line", false, false, true},
 	ActionYankLine:       {"Yank Line", "Copy current line", false, false, false},
 	ActionYank:           {"Yank", "Copy selection", false, false, false},
+	ActionYankResult:     {"Yank Result", "Copy the line's result to the clipboard", false, false, false},
+	ActionYankResults:    {"Yank Results", "Copy all results to the clipboard", false, false, false},
 	ActionPaste:          {"Paste", "Paste after cursor", false, false, true},
 	ActionPasteAbove:     {"Paste Above", "Paste before cursor", false, false, true},
 	ActionUndo:           {"Undo", "Undo last change", false, false, false},
@@ -138,10 +165,21 @@ var actionRegistry = map[Action]ActionMetadata{
 	ActionSaveQuit:    {"Save & Quit", "Save and quit", false, false, false},
 	ActionToggleHelp:  {"Toggle Help", "Show/hide help", false, false, false},
 	ActionRefreshRate: {"Refresh Rates", "Refresh currency rates", false, false, false},
+	ActionCommandLine: {"Command Line", "Enter a : command", false, false, false},
+	ActionExplain:     {"Explain", "Show how the line's result was worked out", false, false, false},
+
+	// Buffers
+	ActionNextBuffer: {"Next Buffer", "Switch to the next buffer", false, false, false},
+	ActionPrevBuffer: {"Previous Buffer", "Switch to the previous buffer", false, false, false},
+
+	// Windows
+	ActionNextWindow: {"Next Window", "Move to the next split window", false, false, false},
 
 	// UI toggles
 	ActionToggleLineNumbers: {"Toggle Line Numbers", "Show/hide line numbers", false, false, false},
 	ActionToggleWrap:        {"Toggle Wrap", "Toggle line wrapping", false, false, false},
+	ActionTogglePanel:       {"Toggle Panel", "Show/hide totals and variables panel", false, false, false},
+	ActionToggleRunning:     {"Toggle Running Total", "Show/hide the running total column", false, false, false},
 }
 
 // Metadata returns the metadata for an action.
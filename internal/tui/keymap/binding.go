@@ -12,6 +12,7 @@ const (
 	ModeInsert
 	ModeVisual
 	ModeOperatorPending // Waiting for motion after operator (d, y, c)
+	ModeVisualLine      // Line-wise visual (V)
 )
 
 // String returns the mode name.
@@ -25,11 +26,18 @@ func (m Mode) String() string {
 		return "VISUAL"
 	case ModeOperatorPending:
 		return "OPERATOR"
+	case ModeVisualLine:
+		return "V-LINE"
 	default:
 		return "UNKNOWN"
 	}
 }
 
+// IsVisual reports whether the mode is character- or line-wise visual.
+func (m Mode) IsVisual() bool {
+	return m == ModeVisual || m == ModeVisualLine
+}
+
 // ParseMode converts a string to a Mode.
 func ParseMode(s string) Mode {
 	switch strings.ToLower(s) {
@@ -41,6 +49,8 @@ func ParseMode(s string) Mode {
 		return ModeVisual
 	case "operator", "operator_pending":
 		return ModeOperatorPending
+	case "visual_line", "v-line":
+		return ModeVisualLine
 	default:
 		return ModeNormal
 	}
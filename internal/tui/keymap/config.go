@@ -78,20 +78,23 @@ func SaveConfig(path string, config *Config) error {
 #   "dd" = "delete_line"
 #
 # Available actions:
-#   Mode switching: normal_mode, insert_mode, append_mode, visual_mode
+#   Mode switching: normal_mode, insert_mode, append_mode, visual_mode,
+#                   visual_line_mode
 #   Movement: move_up, move_down, move_left, move_right
 #            move_word_next, move_word_prev
 #            goto_line_start, goto_line_end, goto_top, goto_bottom
 #            page_up, page_down
+#   Scrolling: scroll_down, scroll_up, scroll_center
 #   Editing: delete_char, delete_line, delete_to_end
 #           yank_line, yank, paste, paste_above
+#           yank_result, yank_results
 #           undo, redo, join_lines
 #           open_below, open_above
 #   Insert: insert_char, insert_newline, backspace, delete, insert_tab
 #   Operators: operator_delete, operator_yank, operator_change
 #   General: quit, force_quit, save, save_quit
 #           toggle_help, refresh_rate
-#           toggle_line_numbers, toggle_wrap
+#           toggle_line_numbers, toggle_wrap, toggle_panel
 
 `
 	if _, err := file.WriteString(header); err != nil {
@@ -51,6 +51,7 @@ func (km *KeyMap) loadNormalDefaults() {
 	n.Bind("i", ActionInsertMode)
 	n.Bind("a", ActionAppendMode)
 	n.Bind("v", ActionVisualMode)
+	n.Bind("V", ActionVisualLine)
 
 	// Cursor movement
 	n.Bind("h", ActionMoveLeft)
@@ -81,6 +82,11 @@ func (km *KeyMap) loadNormalDefaults() {
 	n.Bind("pgup", ActionPageUp)
 	n.Bind("pgdown", ActionPageDown)
 
+	// Scrolling
+	n.Bind("ctrl+e", ActionScrollDown)
+	n.Bind("ctrl+y", ActionScrollUp)
+	n.Bind("zz", ActionScrollCenter)
+
 	// Editing
 	n.Bind("x", ActionDeleteChar)
 	n.Bind("X", ActionDeleteCharBack)
@@ -110,11 +116,23 @@ func (km *KeyMap) loadNormalDefaults() {
 	n.Bind("ctrl+s", ActionSave)
 	n.Bind("ZZ", ActionSaveQuit)
 	n.Bind("ZQ", ActionForceQuit)
+	n.Bind(":", ActionCommandLine)
+
+	// Buffers
+	n.Bind("gt", ActionNextBuffer)
+	n.Bind("gT", ActionPrevBuffer)
+
+	// Windows
+	n.Bind("ctrl+w", ActionNextWindow)
 
 	// Help & UI
 	n.Bind("?", ActionToggleHelp)
 	n.Bind("f1", ActionToggleHelp)
 	n.Bind("ctrl+l", ActionToggleLineNumbers)
+	n.Bind("T", ActionTogglePanel)
+	n.Bind("R", ActionToggleRunning)
+	n.Bind("K", ActionExplain)
+	n.Bind("gr", ActionRefreshRate)
 }
 
 func (km *KeyMap) loadInsertDefaults() {
@@ -146,10 +164,11 @@ func (km *KeyMap) loadInsertDefaults() {
 func (km *KeyMap) loadVisualDefaults() {
 	v := km.Visual
 
-	// Exit visual mode
+	// Exit visual mode, or switch between character- and line-wise
 	v.Bind("esc", ActionNormalMode)
 	v.Bind("ctrl+c", ActionNormalMode)
-	v.Bind("v", ActionNormalMode)
+	v.Bind("v", ActionVisualMode)
+	v.Bind("V", ActionVisualLine)
 
 	// Movement
 	v.Bind("h", ActionMoveLeft)
@@ -166,12 +185,17 @@ func (km *KeyMap) loadVisualDefaults() {
 	v.Bind("$", ActionGotoLineEnd)
 	v.Bind("gg", ActionGotoTop)
 	v.Bind("G", ActionGotoBottom)
+	v.Bind("ctrl+e", ActionScrollDown)
+	v.Bind("ctrl+y", ActionScrollUp)
+	v.Bind("zz", ActionScrollCenter)
 
 	// Operations on selection
 	v.Bind("d", ActionOperatorDelete)
 	v.Bind("y", ActionOperatorYank)
 	v.Bind("c", ActionOperatorChange)
 	v.Bind("x", ActionOperatorDelete)
+	v.Bind("p", ActionPaste)
+	v.Bind("P", ActionPaste)
 }
 
 func (km *KeyMap) loadOperatorDefaults() {
@@ -197,6 +221,10 @@ func (km *KeyMap) loadOperatorDefaults() {
 	o.Bind("d", ActionDeleteLine) // dd
 	o.Bind("y", ActionYankLine)   // yy
 	o.Bind("c", ActionDeleteLine) // cc (delete line, enter insert)
+
+	// Results to the system clipboard (yr, yR)
+	o.Bind("r", ActionYankResult)
+	o.Bind("R", ActionYankResults)
 }
 
 // GetBindingMap returns the binding map for a mode.
@@ -206,7 +234,7 @@ func (km *KeyMap) GetBindingMap(mode Mode) *BindingMap {
 		return km.Normal
 	case ModeInsert:
 		return km.Insert
-	case ModeVisual:
+	case ModeVisual, ModeVisualLine:
 		return km.Visual
 	case ModeOperatorPending:
 		return km.Operator
@@ -254,9 +282,7 @@ func (km *KeyMap) ProcessKey(key string) (Command, bool) {
 	switch result.Status {
 	case LookupFound:
 		// Complete match - execute command
-		cmd := km.buildCommand(result.Action)
-		km.State.Reset()
-		return cmd, true
+		return km.complete(result.Action)
 
 	case LookupPending:
 		// Could be complete or could continue
@@ -265,9 +291,7 @@ func (km *KeyMap) ProcessKey(key string) (Command, bool) {
 		if result.Action != ActionNone {
 			// Has a valid action but could continue
 			// We'll execute immediately for simplicity
-			cmd := km.buildCommand(result.Action)
-			km.State.Reset()
-			return cmd, true
+			return km.complete(result.Action)
 		}
 		return Command{}, false
 
@@ -294,10 +318,26 @@ func (km *KeyMap) lookupCurrentSequence() LookupResult {
 	return km.GetBindingMap(mode).Lookup(km.State.KeyBuffer)
 }
 
+// complete builds the command for a resolved action. An operator only
+// starts operator-pending mode, keeping its state for the motion.
+func (km *KeyMap) complete(action Action) (Command, bool) {
+	cmd := km.buildCommand(action)
+	if cmd.Action == ActionNone {
+		return cmd, false
+	}
+	km.State.Reset()
+	return cmd, true
+}
+
 // buildCommand builds a command from the current state and action.
 func (km *KeyMap) buildCommand(action Action) Command {
 	count := km.State.GetCount()
 
+	// In visual mode operators apply to the selection
+	if action.IsOperator() && km.CurrentMode.IsVisual() {
+		return NewCommand(action, count)
+	}
+
 	if action.IsOperator() && !km.State.HasPendingOperator() {
 		// Starting an operator - enter operator-pending mode
 		km.State.SetOperator(action)
@@ -306,6 +346,10 @@ func (km *KeyMap) buildCommand(action Action) Command {
 	}
 
 	if km.State.HasPendingOperator() {
+		// Line commands (dd, yy) and the like replace the operator
+		if !action.IsMotion() {
+			return NewCommand(action, count)
+		}
 		// Completing an operator with a motion
 		return NewOperatorCommand(km.State.PendingOperator, count, action, 1)
 	}
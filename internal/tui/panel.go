@@ -0,0 +1,90 @@
+// internal/tui/panel.go
+
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// panelMaxWidth is the width of the totals and variables sidebar.
+const panelMaxWidth = 30
+
+// ════════════════════════════════════════════════════════════════
+// TOTALS PANEL
+// ════════════════════════════════════════════════════════════════
+
+// panelWidth returns the sidebar width, or 0 if it is hidden or the
+// windows would become too narrow.
+func (a *App) panelWidth() int {
+	if !a.showPanel || a.width-panelMaxWidth-1 < minWindowWidth {
+		return 0
+	}
+	return panelMaxWidth
+}
+
+// renderPanel renders the sidebar: the running total, totals grouped by
+// currency and unit, and the defined variables. It reads the engine state
+// from the last evaluation, so it must run after the document is evaluated.
+func (a *App) renderPanel(width, height int) []string {
+	var lines []string
+	section := func(title string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, a.st.panelTitle.Render(fitWidth(" "+title, width)))
+	}
+	entry := func(text string, style lipgloss.Style) {
+		lines = append(lines, style.Render(fitWidth("  "+text, width)))
+	}
+
+	section("Total")
+	if total := a.engine.Total(); !total.IsEmpty() {
+		entry(a.engine.Format(total), a.st.result)
+	} else {
+		entry("—", a.st.lineNum)
+	}
+
+	if groups := a.engine.GroupedTotals(); len(groups) > 1 {
+		section("By unit")
+		for _, g := range groups {
+			entry(a.engine.Format(g), a.st.result)
+		}
+	}
+
+	vars := a.engine.Variables()
+	if len(vars) > 0 {
+		section("Variables")
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			entry(name+" = "+a.engine.Format(vars[name]), lipgloss.NewStyle())
+			if d := a.engine.Description(name); d != "" {
+				entry("  "+d, a.st.lineNum)
+			}
+		}
+	}
+
+	// Pad or cut to the panel height
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines[:height]
+}
+
+// fitWidth pads or truncates s to exactly width cells.
+func fitWidth(s string, width int) string {
+	if w := lipgloss.Width(s); w <= width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,175 @@
+// internal/tui/refresh.go
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/0xsj/numio/pkg/cache"
+)
+
+// spinnerFrames animate the status bar while rates are fetched.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner moves.
+const spinnerInterval = 100 * time.Millisecond
+
+// rateRefresh is a fetch of fresh rates in progress, shown in the status
+// bar as each provider is asked.
+type rateRefresh struct {
+	events  <-chan cache.RefreshEvent
+	cancel  context.CancelFunc
+	classes []classStatus
+	frame   int
+}
+
+// classStatus is how fetching one asset class's rates is going.
+type classStatus struct {
+	class    cache.AssetClass
+	provider string // Being asked, while not done
+	done     bool
+	err      error
+	fallback string
+}
+
+// refreshEventMsg carries the next step of a refresh.
+type refreshEventMsg struct {
+	event cache.RefreshEvent
+	ok    bool // False once the refresh's events are over
+}
+
+// refreshTickMsg moves the spinner.
+type refreshTickMsg struct{}
+
+// ════════════════════════════════════════════════════════════════
+// COMMANDS
+// ════════════════════════════════════════════════════════════════
+
+// refreshRates starts fetching fresh rates into the current buffer's
+// engine. The other buffers pick them up from the rate cache file when
+// next evaluated.
+func (a *App) refreshRates() tea.Cmd {
+	if a.refresh != nil {
+		a.setMessage("Already fetching rates")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cache.DefaultRefreshTimeout)
+	r := &rateRefresh{
+		events: a.engine.RefreshRatesStream(ctx),
+		cancel: cancel,
+	}
+	for _, class := range cache.Fetchable(cache.AssetClasses...) {
+		r.classes = append(r.classes, classStatus{class: class})
+	}
+	a.refresh = r
+	return tea.Batch(r.wait(), spinnerTick())
+}
+
+// wait returns a command waiting for the refresh's next event.
+func (r *rateRefresh) wait() tea.Cmd {
+	return func() tea.Msg {
+		e, ok := <-r.events
+		return refreshEventMsg{event: e, ok: ok}
+	}
+}
+
+// spinnerTick returns a command moving the spinner after a moment.
+func spinnerTick() tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg { return refreshTickMsg{} })
+}
+
+// handleRefreshEvent applies a step of the refresh, waiting for the next
+// until it is over.
+func (a *App) handleRefreshEvent(msg refreshEventMsg) tea.Cmd {
+	r := a.refresh
+	if r == nil {
+		return nil
+	}
+	if !msg.ok {
+		r.cancel()
+		a.refresh = nil
+		return nil
+	}
+
+	e := msg.event
+	switch e.Kind {
+	case cache.RefreshProviderStarted:
+		if s := r.class(e.Class); s != nil {
+			s.provider = e.Provider
+		}
+
+	case cache.RefreshClassDone:
+		if s := r.class(e.Class); s != nil {
+			s.done, s.err, s.fallback = true, e.Err, e.Fallback
+		}
+
+	case cache.RefreshDone:
+		r.cancel()
+		a.refresh = nil
+		switch {
+		case e.Result == nil || len(e.Result.Classes) == 0:
+			a.setError("Cannot fetch rates: " + e.Err.Error())
+		case e.Err != nil:
+			a.setError("Cannot fetch rates: " + r.summary())
+		default:
+			a.setMessage(fmt.Sprintf("Fetched %d rates: %s", e.Result.Count, r.summary()))
+		}
+		return nil
+	}
+	return r.wait()
+}
+
+// handleRefreshTick moves the spinner while the refresh lasts.
+func (a *App) handleRefreshTick() tea.Cmd {
+	if a.refresh == nil {
+		return nil
+	}
+	a.refresh.frame++
+	return spinnerTick()
+}
+
+// ════════════════════════════════════════════════════════════════
+// STATUS
+// ════════════════════════════════════════════════════════════════
+
+// class returns the status of an asset class being fetched.
+func (r *rateRefresh) class(class cache.AssetClass) *classStatus {
+	for i := range r.classes {
+		if r.classes[i].class == class {
+			return &r.classes[i]
+		}
+	}
+	return nil
+}
+
+// status returns the refresh's progress for the status bar:
+// "⠹ fiat ✓  crypto (coingecko)…  metal ✗ (using cache)".
+func (r *rateRefresh) status() string {
+	return spinnerFrames[r.frame%len(spinnerFrames)] + " " + r.summary()
+}
+
+// summary returns how each asset class is going.
+func (r *rateRefresh) summary() string {
+	parts := make([]string, len(r.classes))
+	for i, s := range r.classes {
+		switch {
+		case !s.done && s.provider != "":
+			parts[i] = fmt.Sprintf("%s (%s)…", s.class, s.provider)
+		case !s.done:
+			parts[i] = s.class.String() + "…"
+		case s.err != nil && s.fallback == cache.FallbackCached:
+			parts[i] = s.class.String() + " ✗ (using cache)"
+		case s.err != nil:
+			parts[i] = s.class.String() + " ✗ (using built-in rates)"
+		default:
+			parts[i] = s.class.String() + " ✓"
+		}
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,324 @@
+// internal/tui/results.go
+
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0xsj/numio/pkg/engine"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+const (
+	// resultMinWidth is the narrowest the result column gets.
+	resultMinWidth = 8
+
+	// editorMinWidth is the narrowest the editor gets to make room for
+	// results.
+	editorMinWidth = 20
+
+	// plotRows is how tall a chart is in the result column, not counting
+	// its X axis.
+	plotRows = 6
+
+	// runningGap is the space around the running total column's separator.
+	runningGap = 3
+)
+
+// lineResult is the formatted result of one line.
+type lineResult struct {
+	text    string
+	isError bool
+	plot    *types.Plot // Drawn as a chart instead of text
+	running string      // Running total after this line, if shown
+}
+
+// ════════════════════════════════════════════════════════════════
+// EVALUATION
+// ════════════════════════════════════════════════════════════════
+
+// evaluate evaluates the whole document from a clean engine and returns
+// each line's result. Front matter lines only show an error. A scratch
+// buffer first evaluates the document it links to, keeping its variables
+// but not its lines. Rates another process has fetched since are picked
+// up first.
+func (a *App) evaluate() []lineResult {
+	settingsLines, settings := a.resetEngine()
+
+	results := make([]lineResult, len(a.lines))
+	for i, line := range a.lines {
+		if i < settingsLines {
+			if settings[i].IsError() {
+				results[i] = lineResult{text: "err", isError: true}
+			}
+			continue
+		}
+		results[i] = a.evaluateLine(line)
+	}
+
+	// Shares of the total are known once every line has counted
+	for n, share := range a.engine.Shares() {
+		if n < 1 || n > len(results) {
+			continue
+		}
+		r := &results[n-1]
+		r.text, r.isError = a.engine.Format(share), false
+		if share.IsError() {
+			r.text, r.isError = "err", true
+		}
+	}
+	return results
+}
+
+// resetEngine clears the engine, then loads the linked buffer and the
+// document's front matter. It returns the number of front matter lines
+// and the result of each.
+func (a *App) resetEngine() (int, []types.Value) {
+	a.engine.ReloadRatesIfChanged()
+	a.engine.Clear()
+
+	// Imports resolve against the document's directory
+	dir := "."
+	if a.filename != "" {
+		dir = filepath.Dir(a.filename)
+	}
+	a.engine.SetImportDir(dir)
+	if a.link != nil {
+		a.engine.EvalFile(strings.Join(a.link.lines, "\n"))
+		a.engine.ClearLines()
+	}
+
+	settingsLines := engine.FrontMatterLen(a.lines)
+	settings := a.engine.ApplyFrontMatter(a.lines[:settingsLines])
+	a.highlighter.SetLocale(a.engine.InputLocale())
+	a.highlighter.SetUnits(a.engine.Units())
+	return settingsLines, settings
+}
+
+// evaluateLine evaluates a single line. Blank lines and comments have no
+// result, but still go through the engine so line numbers stay in step.
+func (a *App) evaluateLine(line string) lineResult {
+	result := a.engine.Eval(line)
+
+	if result.IsEmpty() {
+		return lineResult{}
+	}
+
+	if result.IsError() {
+		return lineResult{text: "err", isError: true}
+	}
+
+	r := lineResult{text: a.engine.Format(result)}
+	if result.IsPlot() {
+		r.plot = result.Plot
+	}
+	if a.showRunning && result.IsNumeric() {
+		// Lines consumed by a continuation drop out of the total, like
+		// the totals panel
+		r.running = a.engine.Format(a.engine.Total())
+	}
+	return r
+}
+
+// ════════════════════════════════════════════════════════════════
+// RESULT COLUMN
+// ════════════════════════════════════════════════════════════════
+
+// resultColumn is the laid out result column, and the running total
+// column if it is shown.
+type resultColumn struct {
+	width int
+	cells [][]string // Each line's result, wrapped to width
+
+	runningWidth int
+	running      [][]string // Each line's running total, or nil if hidden
+}
+
+// withRunning adds the running total column, at most maxWidth wide.
+func (c resultColumn) withRunning(results []lineResult, maxWidth int) resultColumn {
+	totals := make([]lineResult, len(results))
+	for i, r := range results {
+		totals[i] = lineResult{text: r.running}
+	}
+	run := layoutResults(totals, maxWidth)
+	c.runningWidth, c.running = run.width, run.cells
+	return c
+}
+
+// runningSpace returns the screen width the running total column takes.
+func (c resultColumn) runningSpace() int {
+	if c.running == nil {
+		return 0
+	}
+	return c.runningWidth + runningGap
+}
+
+// layoutResults sizes the result column to the widest result, at most
+// maxWidth, and pads results so numbers line up on their decimal point.
+// Results wider than the column wrap.
+func layoutResults(results []lineResult, maxWidth int) resultColumn {
+	maxWidth = max(maxWidth, resultMinWidth)
+
+	// Charts are as wide as the column allows, up to their default
+	chartWidth, charts := min(types.PlotWidth, maxWidth), 0
+	for _, r := range results {
+		if r.plot != nil {
+			charts = chartWidth
+		}
+	}
+
+	// Split each number at its decimal point; the part from there on is
+	// the tail, and results are padded to the widest tail
+	tails := make([]int, len(results))
+	maxTail, widest := 0, charts
+	for i, r := range results {
+		if r.text == "" || r.isError || r.plot != nil {
+			continue
+		}
+		tails[i] = decimalTail(r.text)
+		maxTail = max(maxTail, tails[i])
+	}
+	for i, r := range results {
+		if r.text != "" && !r.isError && r.plot == nil {
+			widest = max(widest, lipgloss.Width(r.text)-tails[i]+maxTail)
+		}
+	}
+
+	// Aligning isn't worth wrapping results that would otherwise fit
+	align := widest <= maxWidth
+	if !align {
+		widest = charts
+		for _, r := range results {
+			if r.plot == nil {
+				widest = max(widest, lipgloss.Width(r.text))
+			}
+		}
+	}
+
+	col := resultColumn{
+		width: max(resultMinWidth, min(widest, maxWidth)),
+		cells: make([][]string, len(results)),
+	}
+	for i, r := range results {
+		if r.plot != nil {
+			col.cells[i] = r.plot.ASCII(chartWidth, plotRows)
+			continue
+		}
+		text := r.text
+		if align && text != "" && !r.isError {
+			text += strings.Repeat(" ", maxTail-tails[i])
+		}
+		col.cells[i] = wrapResult(text, col.width)
+	}
+	return col
+}
+
+// decimalTail returns the width of s from the decimal point of its first
+// number onwards, or from the end of the number if it has no fraction.
+// A result without a number has no tail.
+func decimalTail(s string) int {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return 0
+	}
+
+	end, point := start, -1
+	for end < len(s) {
+		c := s[end]
+		switch {
+		case c >= '0' && c <= '9':
+		case (c == '.' || c == ',') && end+1 < len(s) && s[end+1] >= '0' && s[end+1] <= '9':
+			if c == '.' {
+				point = end
+			}
+		default:
+			return lipgloss.Width(s[tailStart(end, point):])
+		}
+		end++
+	}
+	return lipgloss.Width(s[tailStart(end, point):])
+}
+
+// tailStart returns where the tail starts: the decimal point if there is
+// one, otherwise the end of the number.
+func tailStart(end, point int) int {
+	if point >= 0 {
+		return point
+	}
+	return end
+}
+
+// wrapResult wraps a result to width, preferring to break at spaces.
+func wrapResult(text string, width int) []string {
+	if text == "" {
+		return nil
+	}
+
+	var lines []string
+	runes := []rune(text)
+	for len(runes) > width {
+		cut := width
+		for j := width; j > 0; j-- {
+			if runes[j] == ' ' {
+				cut = j
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:cut]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	if len(runes) > 0 || len(lines) == 0 {
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+// height returns the number of screen rows line i takes up.
+func (c resultColumn) height(i int) int {
+	if c.running != nil {
+		return max(1, len(c.cells[i]), len(c.running[i]))
+	}
+	return max(1, len(c.cells[i]))
+}
+
+// renderResult renders row n of line i's result, right-aligned in the column.
+func (a *App) renderResult(c resultColumn, r lineResult, i, n int) string {
+	if n >= len(c.cells[i]) {
+		return strings.Repeat(" ", c.width)
+	}
+	text := c.cells[i][n]
+	style := a.st.result
+	if r.isError {
+		style = a.st.error
+	}
+	return strings.Repeat(" ", max(0, c.width-lipgloss.Width(text))) + style.Render(text)
+}
+
+// renderRunning renders row n of line i's running total, after a
+// separator. Rows past the end of the document are blank.
+func (a *App) renderRunning(c resultColumn, i, n int) string {
+	text := ""
+	if i < len(c.running) && n < len(c.running[i]) {
+		text = c.running[i][n]
+	}
+	pad := strings.Repeat(" ", max(0, c.runningWidth-lipgloss.Width(text)))
+	return "│ " + pad + a.st.hint.Render(text) + " "
+}
+
+// fitCursor scrolls down until the cursor line's rows fit on screen when
+// results above it wrap.
+func (a *App) fitCursor(c resultColumn, contentHeight int) {
+	for a.scroll < a.row {
+		rows := 0
+		for i := a.scroll; i <= a.row; i++ {
+			rows += c.height(i)
+		}
+		if rows <= contentHeight {
+			return
+		}
+		a.scroll++
+	}
+}
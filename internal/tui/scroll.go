@@ -0,0 +1,66 @@
+// internal/tui/scroll.go
+
+package tui
+
+// scrollOff is the number of lines kept visible above and below the cursor.
+const scrollOff = 3
+
+// ════════════════════════════════════════════════════════════════
+// VIEWPORT
+// ════════════════════════════════════════════════════════════════
+
+// contentHeight returns the number of editor lines in the focused window.
+func (a *App) contentHeight() int {
+	h := a.windowSizes()[a.win].height
+	if h < 1 {
+		h = 20
+	}
+	return h
+}
+
+// margin returns the scroll-off margin, reduced for small windows.
+func (a *App) margin() int {
+	return min(scrollOff, (a.contentHeight()-1)/2)
+}
+
+// scrollToCursor scrolls the view so the cursor is visible with a margin
+// of scrollOff lines.
+func (a *App) scrollToCursor() {
+	h, off := a.contentHeight(), a.margin()
+
+	if a.row < a.scroll+off {
+		a.scroll = a.row - off
+	}
+	if a.row > a.scroll+h-1-off {
+		// The margin doesn't apply past the end of the document
+		a.scroll = min(a.row-h+1+off, max(a.row-h+1, len(a.lines)-h))
+	}
+	a.clampScroll()
+}
+
+// scrollLines scrolls the view by n lines (negative scrolls up), moving the
+// cursor only as far as needed to keep it on screen.
+func (a *App) scrollLines(n int) {
+	a.scroll += n
+	a.clampScroll()
+
+	h, off := a.contentHeight(), a.margin()
+	switch {
+	case a.row < a.scroll+off:
+		a.row = min(a.scroll+off, len(a.lines)-1)
+	case a.row > a.scroll+h-1-off:
+		a.row = max(a.scroll+h-1-off, 0)
+	}
+	a.clampCol()
+}
+
+// scrollCenter scrolls the view so the cursor line is centered.
+func (a *App) scrollCenter() {
+	a.scroll = a.row - a.contentHeight()/2
+	a.clampScroll()
+}
+
+// clampScroll keeps the first visible line within the document.
+func (a *App) clampScroll() {
+	a.scroll = max(0, min(a.scroll, len(a.lines)-1))
+}
@@ -0,0 +1,156 @@
+// internal/tui/session.go
+
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// sessionVersion is the format version of the session file.
+const sessionVersion = 1
+
+// session is the editor state saved on quit: the open files and where the
+// cursor was in each.
+type session struct {
+	Version int             `json:"version"`
+	Current int             `json:"current"`
+	Buffers []sessionBuffer `json:"buffers"`
+}
+
+// sessionBuffer is a file's saved position.
+type sessionBuffer struct {
+	File   string `json:"file"`
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Scroll int    `json:"scroll"`
+}
+
+// stateDir returns the directory editor state is kept in:
+// $XDG_STATE_HOME/numio or ~/.local/state/numio.
+func stateDir() string {
+	if xdgState := os.Getenv("XDG_STATE_HOME"); xdgState != "" {
+		return filepath.Join(xdgState, "numio")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "numio")
+}
+
+// SessionPath returns the path of the session file.
+func SessionPath() string {
+	dir := stateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "session.json")
+}
+
+// ════════════════════════════════════════════════════════════════
+// SAVING
+// ════════════════════════════════════════════════════════════════
+
+// saveSession saves the open files and their positions. Unnamed buffers
+// aren't saved.
+func (a *App) saveSession() error {
+	path := SessionPath()
+	if path == "" {
+		return nil
+	}
+
+	s := session{Version: sessionVersion}
+	for i, b := range a.buffers {
+		if b.filename == "" {
+			continue
+		}
+		file, err := filepath.Abs(b.filename)
+		if err != nil {
+			file = b.filename
+		}
+		if i == a.current {
+			s.Current = len(s.Buffers)
+		}
+		s.Buffers = append(s.Buffers, sessionBuffer{
+			File: file, Row: b.row, Col: b.col, Scroll: b.scroll,
+		})
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// ════════════════════════════════════════════════════════════════
+// RESTORING
+// ════════════════════════════════════════════════════════════════
+
+// loadSession reads the session file. A missing or unreadable session is
+// empty.
+func loadSession() session {
+	var s session
+	data, err := os.ReadFile(SessionPath())
+	if err != nil || json.Unmarshal(data, &s) != nil || s.Version != sessionVersion {
+		return session{}
+	}
+	return s
+}
+
+// restoreSession reopens the files of the last session that still exist.
+func (a *App) restoreSession(s session) {
+	current := -1
+	for i, sb := range s.Buffers {
+		if _, err := os.Stat(sb.File); err != nil {
+			continue
+		}
+		if !a.isScratch() {
+			a.addBuffer(newBuffer(a.newEngine()))
+		}
+		if err := a.open(sb.File); err != nil {
+			continue
+		}
+		a.restorePosition(sb)
+		if i <= s.Current {
+			current = a.current
+		}
+	}
+
+	if current >= 0 {
+		a.switchBuffer(current)
+		a.setMessage(fmt.Sprintf("Restored %d files (%s)", len(a.buffers), a.bufferInfo()))
+	}
+}
+
+// restorePositions moves the cursor of each open buffer to where it was
+// in the last session.
+func (a *App) restorePositions(s session) {
+	current := a.current
+	for i, b := range a.buffers {
+		for _, sb := range s.Buffers {
+			if b.filename != "" && samePath(b.filename, sb.File) {
+				a.switchBuffer(i)
+				a.restorePosition(sb)
+			}
+		}
+	}
+	a.switchBuffer(current)
+}
+
+// restorePosition moves the cursor and scroll position of the current
+// buffer, keeping them within the document.
+func (a *App) restorePosition(sb sessionBuffer) {
+	a.row = max(0, min(sb.Row, len(a.lines)-1))
+	a.col = sb.Col
+	a.clampCol()
+	a.scroll = sb.Scroll
+	a.clampScroll()
+}
@@ -0,0 +1,84 @@
+// internal/tui/styles.go
+
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0xsj/numio/internal/highlight"
+	"github.com/0xsj/numio/internal/tui/keymap"
+)
+
+// styles are the editor styles derived from a theme's UI colors.
+type styles struct {
+	lineNum    lipgloss.Style
+	result     lipgloss.Style
+	error      lipgloss.Style
+	cursor     lipgloss.Style
+	nonText    lipgloss.Style
+	pending    lipgloss.Style
+	hint       lipgloss.Style
+	selection  lipgloss.Style
+	statusBar  lipgloss.Style
+	panelTitle lipgloss.Style
+	tab        lipgloss.Style
+	tabActive  lipgloss.Style
+
+	completion         lipgloss.Style
+	completionSelected lipgloss.Style
+
+	helpBorder  lipgloss.Style
+	helpTitle   lipgloss.Style
+	helpSection lipgloss.Style
+	helpKey     lipgloss.Style
+	helpDesc    lipgloss.Style
+	helpFooter  lipgloss.Style
+
+	modes map[keymap.Mode]lipgloss.Style
+}
+
+// newStyles builds the editor styles for a theme.
+func newStyles(t *highlight.Theme) *styles {
+	ui := t.UI
+	fg := func(c highlight.Color) lipgloss.Style { return c.Style() }
+	mode := func(bg highlight.Color) lipgloss.Style {
+		return lipgloss.NewStyle().Bold(true).Foreground(ui.ModeText.Lipgloss()).
+			Background(bg.Lipgloss()).Padding(0, 1)
+	}
+
+	return &styles{
+		lineNum:    fg(ui.LineNumber),
+		result:     fg(ui.Result),
+		error:      fg(ui.Error),
+		cursor:     lipgloss.NewStyle().Reverse(true),
+		nonText:    fg(ui.NonText),
+		pending:    fg(ui.Pending),
+		hint:       fg(ui.LineNumber),
+		selection:  lipgloss.NewStyle().Background(ui.Selection.Lipgloss()),
+		statusBar:  lipgloss.NewStyle().Background(ui.StatusBar.Lipgloss()),
+		panelTitle: fg(ui.Heading).Bold(true),
+		tab:        lipgloss.NewStyle().Foreground(ui.Muted.Lipgloss()).Background(ui.StatusBar.Lipgloss()),
+		tabActive:  mode(ui.Accent).Padding(0),
+
+		completion: lipgloss.NewStyle().Foreground(ui.PopupText.Lipgloss()).
+			Background(ui.PopupBg.Lipgloss()),
+		completionSelected: lipgloss.NewStyle().Foreground(ui.ModeText.Lipgloss()).
+			Background(ui.Accent.Lipgloss()),
+
+		helpBorder: lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).
+			BorderForeground(ui.Accent.Lipgloss()).Padding(1, 2),
+		helpTitle:   fg(ui.Accent).Bold(true),
+		helpSection: fg(ui.Heading).Bold(true).MarginTop(1),
+		helpKey:     fg(ui.Accent).Width(14),
+		helpDesc:    fg(ui.Muted),
+		helpFooter:  fg(ui.LineNumber).Italic(true).MarginTop(1),
+
+		modes: map[keymap.Mode]lipgloss.Style{
+			keymap.ModeNormal:          mode(ui.ModeNormal),
+			keymap.ModeInsert:          mode(ui.ModeInsert),
+			keymap.ModeVisual:          mode(ui.ModeVisual),
+			keymap.ModeVisualLine:      mode(ui.ModeVisual),
+			keymap.ModeOperatorPending: mode(ui.ModeOperator),
+		},
+	}
+}
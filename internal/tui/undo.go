@@ -0,0 +1,137 @@
+// internal/tui/undo.go
+
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xsj/numio/internal/tui/keymap"
+)
+
+// undoFileVersion is the format version of saved undo history.
+const undoFileVersion = 1
+
+// ════════════════════════════════════════════════════════════════
+// COALESCING
+// ════════════════════════════════════════════════════════════════
+
+// startInsert enters insert mode. When joined is set, the edits typed join
+// the undo step just saved, so "o" or "cw" and the text typed after them
+// undo together.
+func (a *App) startInsert(joined bool) {
+	a.keymap.SetMode(keymap.ModeInsert)
+	a.undoJoin = joined
+}
+
+// saveInsertUndo saves an undo state for an insert mode edit. The edits of
+// one insert session share a single undo step, broken by moving the
+// cursor.
+func (a *App) saveInsertUndo() {
+	if a.undoJoin {
+		a.modified = true
+		a.redoStack = nil
+		return
+	}
+	a.saveUndo()
+	a.undoJoin = true
+}
+
+// ════════════════════════════════════════════════════════════════
+// PERSISTENT UNDO
+// ════════════════════════════════════════════════════════════════
+
+// undoHistory is undo history saved for a file. It only applies while the
+// file's content still matches the hash.
+type undoHistory struct {
+	Version int          `json:"version"`
+	Hash    string       `json:"hash"`
+	Undo    []undoRecord `json:"undo"`
+	Redo    []undoRecord `json:"redo,omitempty"`
+}
+
+// undoRecord is a saved editorState.
+type undoRecord struct {
+	Lines []string `json:"lines"`
+	Row   int      `json:"row"`
+	Col   int      `json:"col"`
+}
+
+// undoPath returns the undo file for path in the state directory, named
+// after its absolute path with separators replaced by "%", as vim does.
+func undoPath(path string) string {
+	dir := stateDir()
+	abs, err := filepath.Abs(path)
+	if dir == "" || err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "undo", strings.ReplaceAll(abs, string(filepath.Separator), "%")+".json")
+}
+
+// contentHash returns the hash undo history is matched against.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeUndo saves the buffer's undo history for its file, whose content
+// is content.
+func (a *App) writeUndo(content string) error {
+	path := undoPath(a.filename)
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(undoHistory{
+		Version: undoFileVersion,
+		Hash:    contentHash(content),
+		Undo:    toRecords(a.undoStack),
+		Redo:    toRecords(a.redoStack),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// readUndo restores undo history saved for the buffer's file, if its
+// content is unchanged since. A file with saved history keeps saving it.
+func (a *App) readUndo(content string) {
+	data, err := os.ReadFile(undoPath(a.filename))
+	if err != nil {
+		return
+	}
+
+	var f undoHistory
+	if json.Unmarshal(data, &f) != nil || f.Version != undoFileVersion || f.Hash != contentHash(content) {
+		return
+	}
+	a.undoStack = fromRecords(f.Undo)
+	a.redoStack = fromRecords(f.Redo)
+	a.undoFile = true
+}
+
+// toRecords converts editor states for saving.
+func toRecords(states []editorState) []undoRecord {
+	records := make([]undoRecord, len(states))
+	for i, s := range states {
+		records[i] = undoRecord{Lines: s.lines, Row: s.row, Col: s.col}
+	}
+	return records
+}
+
+// fromRecords converts saved records to editor states.
+func fromRecords(records []undoRecord) []editorState {
+	states := make([]editorState, len(records))
+	for i, r := range records {
+		states[i] = editorState{lines: r.Lines, row: r.Row, col: r.Col}
+	}
+	return states
+}
@@ -0,0 +1,280 @@
+// internal/tui/visual.go
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/0xsj/numio/internal/tui/keymap"
+)
+
+// ════════════════════════════════════════════════════════════════
+// SELECTION
+// ════════════════════════════════════════════════════════════════
+
+// enterVisual switches to a visual mode. Entering from normal mode anchors
+// the selection at the cursor; the same mode again returns to normal mode;
+// the other visual mode keeps the anchor.
+func (a *App) enterVisual(mode keymap.Mode) {
+	current := a.keymap.CurrentMode
+	switch {
+	case current == mode:
+		a.keymap.SetMode(keymap.ModeNormal)
+		return
+	case !current.IsVisual():
+		a.visualRow, a.visualCol = a.row, a.col
+	}
+	a.keymap.SetMode(mode)
+}
+
+// linewise reports whether the selection is line-wise.
+func (a *App) linewise() bool {
+	return a.keymap.CurrentMode == keymap.ModeVisualLine
+}
+
+// selection returns the selected range, start inclusive and end exclusive.
+// Character-wise selections include the character under the cursor.
+func (a *App) selection() (startRow, startCol, endRow, endCol int) {
+	startRow, startCol = a.visualRow, a.visualCol
+	endRow, endCol = a.row, a.col
+	if endRow < startRow || (endRow == startRow && endCol < startCol) {
+		startRow, endRow = endRow, startRow
+		startCol, endCol = endCol, startCol
+	}
+
+	if a.linewise() {
+		return startRow, 0, endRow, len(a.lines[endRow])
+	}
+
+	endCol++
+	if endCol > len(a.lines[endRow]) {
+		endCol = len(a.lines[endRow])
+	}
+	if startCol > len(a.lines[startRow]) {
+		startCol = len(a.lines[startRow])
+	}
+	return startRow, startCol, endRow, endCol
+}
+
+// selectedCols returns the selected columns of a line, end exclusive.
+func (a *App) selectedCols(row int) (start, end int, ok bool) {
+	if !a.keymap.CurrentMode.IsVisual() {
+		return 0, 0, false
+	}
+
+	startRow, startCol, endRow, endCol := a.selection()
+	if row < startRow || row > endRow {
+		return 0, 0, false
+	}
+
+	start, end = 0, len(a.lines[row])
+	if row == startRow {
+		start = startCol
+	}
+	if row == endRow {
+		end = endCol
+	}
+	return start, end, true
+}
+
+// selectionText returns the selected text. Line-wise text ends in a newline.
+func (a *App) selectionText() string {
+	startRow, startCol, endRow, endCol := a.selection()
+
+	if a.linewise() {
+		return strings.Join(a.lines[startRow:endRow+1], "\n") + "\n"
+	}
+	if startRow == endRow {
+		return a.lines[startRow][startCol:endCol]
+	}
+
+	var text strings.Builder
+	text.WriteString(a.lines[startRow][startCol:])
+	for i := startRow + 1; i < endRow; i++ {
+		text.WriteString("\n" + a.lines[i])
+	}
+	text.WriteString("\n" + a.lines[endRow][:endCol])
+	return text.String()
+}
+
+// ════════════════════════════════════════════════════════════════
+// SELECTION OPERATORS
+// ════════════════════════════════════════════════════════════════
+
+// yankSelection copies the selection and returns to normal mode.
+func (a *App) yankSelection() {
+	startRow, startCol, _, _ := a.selection()
+	a.yankBuffer = a.selectionText()
+	a.keymap.SetMode(keymap.ModeNormal)
+	a.row, a.col = startRow, startCol
+	a.clampCol()
+}
+
+// deleteSelection cuts the selection and returns to normal mode.
+func (a *App) deleteSelection() {
+	a.removeSelection()
+	a.keymap.SetMode(keymap.ModeNormal)
+	a.clampCol()
+}
+
+// changeSelection cuts the selection and enters insert mode. A line-wise
+// change leaves an empty line to type into.
+func (a *App) changeSelection() {
+	linewise := a.linewise()
+	startRow, _, _, endRow := a.selection()
+	wholeBuffer := startRow == 0 && endRow == len(a.lines)-1
+
+	a.removeSelection()
+	if linewise && !wholeBuffer {
+		a.insertLines(startRow, []string{""})
+		a.row, a.col = startRow, 0
+	}
+	a.startInsert(true)
+}
+
+// pasteSelection replaces the selection with the yank buffer, which then
+// holds the replaced text.
+func (a *App) pasteSelection() {
+	text := a.yankBuffer
+	linewise := a.linewise()
+	startRow, _, _, endRow := a.selection()
+	wholeBuffer := linewise && startRow == 0 && endRow == len(a.lines)-1
+
+	a.removeSelection()
+
+	switch {
+	case text == "":
+	case linewise:
+		lines := yankedLines(text)
+		if wholeBuffer {
+			a.lines = lines
+		} else {
+			a.insertLines(startRow, lines)
+		}
+		a.row, a.col = startRow, 0
+	case strings.HasSuffix(text, "\n"):
+		a.insertText("\n" + text)
+		a.row, a.col = startRow+1, 0
+	default:
+		a.insertText(text)
+	}
+
+	a.keymap.SetMode(keymap.ModeNormal)
+	a.clampCol()
+}
+
+// removeSelection deletes the selection into the yank buffer and moves the
+// cursor to where it started.
+func (a *App) removeSelection() {
+	startRow, startCol, endRow, endCol := a.selection()
+	a.yankBuffer = a.selectionText()
+
+	if a.linewise() {
+		a.lines = append(a.lines[:startRow], a.lines[endRow+1:]...)
+		if len(a.lines) == 0 {
+			a.lines = []string{""}
+		}
+		if startRow >= len(a.lines) {
+			startRow = len(a.lines) - 1
+		}
+		a.row, a.col = startRow, 0
+		return
+	}
+
+	a.lines[startRow] = a.lines[startRow][:startCol] + a.lines[endRow][endCol:]
+	a.lines = append(a.lines[:startRow+1], a.lines[endRow+1:]...)
+	a.row, a.col = startRow, startCol
+}
+
+// ════════════════════════════════════════════════════════════════
+// MULTI-LINE INSERTION
+// ════════════════════════════════════════════════════════════════
+
+// insertText inserts text, which may span lines, at the cursor. The cursor
+// ends on the last inserted character.
+func (a *App) insertText(text string) {
+	line := a.lines[a.row]
+	col := a.col
+	if col > len(line) {
+		col = len(line)
+	}
+	before, after := line[:col], line[col:]
+
+	parts := strings.Split(text, "\n")
+	parts[0] = before + parts[0]
+	last := len(parts) - 1
+	endCol := len(parts[last]) - 1
+	parts[last] += after
+
+	a.lines[a.row] = parts[0]
+	a.insertLines(a.row+1, parts[1:])
+	a.row += last
+	a.col = endCol
+	if a.col < 0 {
+		a.col = 0
+	}
+}
+
+// insertLines inserts lines before row at.
+func (a *App) insertLines(at int, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	newLines := make([]string, 0, len(a.lines)+len(lines))
+	newLines = append(newLines, a.lines[:at]...)
+	newLines = append(newLines, lines...)
+	newLines = append(newLines, a.lines[at:]...)
+	a.lines = newLines
+}
+
+// ════════════════════════════════════════════════════════════════
+// RENDERING
+// ════════════════════════════════════════════════════════════════
+
+// renderSelectedLine renders a line with its selected part highlighted,
+// and the cursor if it is on the line.
+func (a *App) renderSelectedLine(row int, line string, start, end int) string {
+	cursor := -1
+	if row == a.row {
+		cursor = a.col
+	}
+
+	// style: 0 plain, 1 selected, 2 cursor
+	styleAt := func(i int) int {
+		switch {
+		case i == cursor:
+			return 2
+		case i >= start && i < end:
+			return 1
+		default:
+			return 0
+		}
+	}
+	render := func(style int, text string) string {
+		switch style {
+		case 2:
+			return a.st.cursor.Render(text)
+		case 1:
+			return a.st.selection.Render(text)
+		default:
+			return text
+		}
+	}
+
+	var b strings.Builder
+	runStart := 0
+	for i := 1; i <= len(line); i++ {
+		if i == len(line) || styleAt(i) != styleAt(runStart) {
+			b.WriteString(render(styleAt(runStart), line[runStart:i]))
+			runStart = i
+		}
+	}
+
+	switch {
+	case cursor >= len(line):
+		b.WriteString(a.st.cursor.Render(" "))
+	case line == "":
+		b.WriteString(a.st.selection.Render(" "))
+	}
+	return b.String()
+}
@@ -0,0 +1,263 @@
+// internal/tui/window.go
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/0xsj/numio/internal/tui/keymap"
+)
+
+const (
+	// minWindowWidth fits line numbers, the narrowest editor and results.
+	minWindowWidth = 5 + editorMinWidth + 3 + resultMinWidth
+
+	// minWindowHeight is the fewest rows a split leaves a window.
+	minWindowHeight = 3
+)
+
+// window is a view onto a buffer with its own cursor and scroll position.
+// Windows onto the same buffer share it, edits and engine included.
+//
+// The current window's position lives in its buffer while it has focus;
+// the window only holds it while another window has focus.
+type window struct {
+	buf    *buffer
+	row    int
+	col    int
+	scroll int
+}
+
+// size is the width and height of a window's content.
+type size struct {
+	width  int
+	height int
+}
+
+// ════════════════════════════════════════════════════════════════
+// LAYOUT
+// ════════════════════════════════════════════════════════════════
+
+// windowArea returns the size of the area the windows tile: the screen
+// less the tabline, status bar and panel.
+func (a *App) windowArea() size {
+	width := a.width
+	if p := a.panelWidth(); p > 0 {
+		width -= p + 1
+	}
+	return size{width: width, height: a.height - 2 - a.tablineHeight()}
+}
+
+// windowSizes splits the window area between the windows, side by side
+// when split vertically and stacked otherwise. Stacked windows have a bar
+// below all but the last; side by side ones a separator column.
+func (a *App) windowSizes() []size {
+	area := a.windowArea()
+	n := len(a.windows)
+	sizes := make([]size, n)
+
+	total := area.height
+	if a.vertical {
+		total = area.width
+	}
+	each, extra := (total-(n-1))/n, (total-(n-1))%n
+
+	for i := range sizes {
+		length := each
+		if i < extra {
+			length++
+		}
+		if a.vertical {
+			sizes[i] = size{width: length, height: area.height}
+		} else {
+			sizes[i] = size{width: area.width, height: length}
+		}
+	}
+	return sizes
+}
+
+// canSplit reports whether there is room for another window.
+func (a *App) canSplit(vertical bool) bool {
+	area := a.windowArea()
+	n := len(a.windows) + 1
+	if vertical {
+		return (area.width-(n-1))/n >= minWindowWidth
+	}
+	return (area.height-(n-1))/n >= minWindowHeight
+}
+
+// ════════════════════════════════════════════════════════════════
+// WINDOWS
+// ════════════════════════════════════════════════════════════════
+
+// split opens a new window onto the current buffer and focuses it. All
+// windows are laid out in the direction of the last split.
+func (a *App) split(vertical bool) bool {
+	if !a.canSplit(vertical) {
+		a.setError("Not enough room")
+		return false
+	}
+
+	a.storeWindow()
+	w := &window{buf: a.buffer, row: a.row, col: a.col, scroll: a.scroll}
+	at := a.win + 1
+	a.windows = append(a.windows[:at], append([]*window{w}, a.windows[at:]...)...)
+	a.vertical = vertical
+	a.focusWindow(at)
+	return true
+}
+
+// focusWindow moves focus to window i.
+func (a *App) focusWindow(i int) {
+	a.storeWindow()
+	a.win = i
+	a.loadWindow()
+}
+
+// loadWindow makes the focused window's buffer and position current.
+func (a *App) loadWindow() {
+	w := a.windows[a.win]
+	a.buffer = w.buf
+	for n, b := range a.buffers {
+		if b == w.buf {
+			a.current = n
+		}
+	}
+	a.row, a.col, a.scroll = w.row, w.col, w.scroll
+	a.row = max(0, min(a.row, len(a.lines)-1))
+	a.clampCol()
+	a.clampScroll()
+
+	a.keymap.SetMode(keymap.ModeNormal)
+	a.clearCompletions()
+}
+
+// cycleWindow moves focus n windows forward (negative moves back),
+// wrapping around.
+func (a *App) cycleWindow(n int) {
+	count := len(a.windows)
+	a.focusWindow(((a.win+n)%count + count) % count)
+}
+
+// storeWindow saves the cursor and scroll position into the focused
+// window.
+func (a *App) storeWindow() {
+	w := a.windows[a.win]
+	w.buf = a.buffer
+	w.row, w.col, w.scroll = a.row, a.col, a.scroll
+}
+
+// closeWindow closes the focused window. The last window can't be closed.
+func (a *App) closeWindow() bool {
+	if len(a.windows) == 1 {
+		a.setError("Cannot close last window")
+		return false
+	}
+	a.windows = append(a.windows[:a.win], a.windows[a.win+1:]...)
+	a.win = min(a.win, len(a.windows)-1)
+	a.loadWindow()
+	return true
+}
+
+// indexOfWindow returns the index of w, or -1.
+func (a *App) indexOfWindow(w *window) int {
+	for i, o := range a.windows {
+		if o == w {
+			return i
+		}
+	}
+	return -1
+}
+
+// onlyWindow closes all windows but the focused one.
+func (a *App) onlyWindow() {
+	a.storeWindow()
+	a.windows = []*window{a.windows[a.win]}
+	a.win = 0
+}
+
+// closeWindowsOnto closes the unfocused windows onto b.
+func (a *App) closeWindowsOnto(b *buffer) {
+	focused := a.windows[a.win]
+	windows := a.windows[:0]
+	for _, w := range a.windows {
+		if w == focused || w.buf != b {
+			windows = append(windows, w)
+		}
+	}
+	a.windows = windows
+	a.win = a.indexOfWindow(focused)
+}
+
+// ════════════════════════════════════════════════════════════════
+// RENDERING
+// ════════════════════════════════════════════════════════════════
+
+// renderWindows renders the windows into rows of the window area. The
+// focused window renders last, so its buffer's evaluation is the engine
+// state left for the panel and status bar.
+func (a *App) renderWindows() []string {
+	sizes := a.windowSizes()
+	rendered := make([][]string, len(a.windows))
+	for i, w := range a.windows {
+		if i != a.win {
+			rendered[i] = a.renderInactive(w, sizes[i])
+		}
+	}
+	rendered[a.win] = a.renderWindow(sizes[a.win], true)
+
+	if a.vertical {
+		rows := make([]string, a.windowArea().height)
+		for n := range rows {
+			parts := make([]string, len(rendered))
+			for i := range rendered {
+				parts[i] = rendered[i][n]
+			}
+			rows[n] = strings.Join(parts, a.st.lineNum.Render("│"))
+		}
+		return rows
+	}
+
+	var rows []string
+	for i, r := range rendered {
+		rows = append(rows, r...)
+		if i < len(rendered)-1 {
+			rows = append(rows, a.renderWindowBar(i, sizes[i].width))
+		}
+	}
+	return rows
+}
+
+// renderInactive renders an unfocused window by briefly making its
+// position the buffer's.
+func (a *App) renderInactive(w *window, sz size) []string {
+	focused := a.buffer
+	row, col, scroll := a.row, a.col, a.scroll
+
+	a.buffer = w.buf
+	a.row = max(0, min(w.row, len(a.lines)-1))
+	a.col, a.scroll = w.col, w.scroll
+	a.clampScroll()
+
+	rows := a.renderWindow(sz, false)
+	w.scroll = a.scroll
+
+	a.buffer = focused
+	a.row, a.col, a.scroll = row, col, scroll
+	return rows
+}
+
+// renderWindowBar renders the bar below a stacked window, naming its
+// buffer.
+func (a *App) renderWindowBar(i, width int) string {
+	b := a.windows[i].buf
+	name := " " + b.name()
+	if b.modified {
+		name += " [+]"
+	}
+	style := a.st.tab
+	if i == a.win {
+		style = a.st.tabActive
+	}
+	return style.Render(fitWidth(name, width))
+}
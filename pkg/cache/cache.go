@@ -1,17 +1,23 @@
 // pkg/cache/cache.go
 
-// Package cache provides exchange rate caching with BFS path-finding.
+// Package cache provides exchange rate caching with path-finding through
+// a conversion graph.
 package cache
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/0xsj/numio/internal/atomicfile"
 	"github.com/0xsj/numio/internal/fetch"
 	"github.com/0xsj/numio/pkg/types"
 )
@@ -22,7 +28,9 @@ const (
 	DefaultMemoryTTL      = 5 * time.Minute
 	DefaultCacheDir       = ".numio/cache"
 	DefaultRatesFile      = "rates.json"
+	DefaultPinnedFile     = "pinned.json"
 	DefaultRefreshTimeout = 30 * time.Second
+	DefaultClassTimeout   = 20 * time.Second // Of each asset class within a refresh
 )
 
 // RateCache stores exchange rates with multiple cache layers.
@@ -32,16 +40,59 @@ type RateCache struct {
 	// Direct rates: (from, to) -> rate
 	rates map[ratePair]float64
 
-	// Raw rates from API (for persistence)
+	// Conversion graph, built from rates as needed (see graph.go)
+	graphMu  sync.Mutex
+	adjacent map[string][]string
+	trees    map[string]map[string]string
+
+	// When each direct rate was fetched (zero for built-in and hand-set rates)
+	times map[ratePair]time.Time
+
+	// Raw rates from API (for persistence), and the provider each was
+	// fetched from, where known
 	rawRates map[string]float64
+	sources  map[string]string
+
+	// Rates set explicitly with SetRate (for session persistence)
+	pinned map[ratePair]float64
 
 	// Timestamps
 	lastUpdate time.Time
-	ttl        time.Duration
+	ttl        time.Duration // For asset classes without their own TTL
+
+	// Per asset class and per provider TTLs, and when each class was fetched
+	classTTLs    map[AssetClass]time.Duration
+	providerTTLs map[string]time.Duration
+	fetched      map[AssetClass]fetchStamp
+
+	// Append-only record of fetches, replacing the JSON file (nil = off)
+	history *History
+
+	// Called after each refresh from the network
+	onRefresh []func(RefreshResult)
 
-	// File cache path
-	cacheDir  string
-	cacheFile string
+	// How long each asset class may take to fetch (see SetClassTimeout)
+	classTimeouts map[AssetClass]time.Duration
+
+	// Where refreshes and file errors are logged (see SetLogger)
+	logger *slog.Logger
+
+	// Past rates per US dollar, by day (YYYY-MM-DD) then code, loaded
+	// from their file once needed, and recent failures to fetch them, by
+	// day and code (see dated.go)
+	dated       map[string]map[string]float64
+	datedLoaded bool
+	datedFailed map[string]datedFailure
+
+	// Never touch the filesystem or the network (see NewSandboxed), or
+	// never fetch past rates (see SetOffline)
+	sandboxed bool
+	offline   bool
+
+	// File cache path, and the version of the file last read or written
+	cacheDir    string
+	cacheFile   string
+	fileVersion fileVersion
 }
 
 // ratePair represents a currency pair for rate lookup.
@@ -53,19 +104,29 @@ type ratePair struct {
 // CachedRates represents the JSON structure for file persistence.
 type CachedRates struct {
 	Timestamp    int64              `json:"timestamp"`
+	Fetched      map[string]int64   `json:"fetched,omitempty"` // Per asset class in Unix milliseconds, overriding Timestamp
 	Rates        map[string]float64 `json:"rates"`
+	Sources      map[string]string  `json:"sources,omitempty"` // Provider each rate was fetched from
 	BaseCurrency string             `json:"base_currency"`
 }
 
-// New creates a new RateCache with default settings.
+// New creates a new RateCache with the default TTL of each asset class.
 func New() *RateCache {
-	c := &RateCache{
-		rates:     make(map[ratePair]float64),
-		rawRates:  make(map[string]float64),
-		ttl:       DefaultTTL,
-		cacheDir:  getCacheDir(),
-		cacheFile: DefaultRatesFile,
-	}
+	return NewWithClassTTLs(DefaultTTL, DefaultClassTTLs())
+}
+
+// NewWithTTL creates a RateCache with one TTL for every asset class. The
+// file cache is only loaded if it is younger than ttl.
+func NewWithTTL(ttl time.Duration) *RateCache {
+	return NewWithClassTTLs(ttl, nil)
+}
+
+// NewWithClassTTLs creates a RateCache with a TTL per asset class, and ttl
+// for those not given. Each class is loaded from the file cache only if it
+// is younger than its TTL.
+func NewWithClassTTLs(ttl time.Duration, classTTLs map[AssetClass]time.Duration) *RateCache {
+	c := newRateCache(ttl, classTTLs)
+	c.cacheDir = getCacheDir()
 
 	// Load defaults first
 	c.loadDefaults()
@@ -73,16 +134,58 @@ func New() *RateCache {
 	// Try to load from file cache
 	c.LoadFromFile()
 
+	// Rates pinned with SavePinned override fetched ones
+	c.loadPinned()
+
 	return c
 }
 
-// NewWithTTL creates a RateCache with custom TTL.
-func NewWithTTL(ttl time.Duration) *RateCache {
-	c := New()
-	c.ttl = ttl
+// NewSandboxed creates a RateCache that never touches the filesystem or
+// the network, for restricted environments such as WASM or serverless
+// functions. It holds the built-in rates and those set with SetRate or
+// ApplyRawRates; saving and loading files does nothing, and refreshing
+// fails with ErrSandboxed.
+func NewSandboxed() *RateCache {
+	c := newRateCache(DefaultTTL, DefaultClassTTLs())
+	c.sandboxed = true
+	c.loadDefaults()
 	return c
 }
 
+// ErrSandboxed is the error of fetching rates or opening the rate history
+// with a cache made by NewSandboxed.
+var ErrSandboxed = errors.New("rate cache is sandboxed: no network or file access")
+
+// newRateCache creates an empty RateCache, without a cache directory.
+func newRateCache(ttl time.Duration, classTTLs map[AssetClass]time.Duration) *RateCache {
+	c := &RateCache{
+		rates:        make(map[ratePair]float64),
+		times:        make(map[ratePair]time.Time),
+		rawRates:     make(map[string]float64),
+		sources:      make(map[string]string),
+		pinned:       make(map[ratePair]float64),
+		ttl:          ttl,
+		classTTLs:    make(map[AssetClass]time.Duration),
+		providerTTLs: make(map[string]time.Duration),
+		fetched:      make(map[AssetClass]fetchStamp),
+		cacheFile:    DefaultRatesFile,
+		logger:       discardLogger,
+
+		classTimeouts: make(map[AssetClass]time.Duration),
+	}
+	for class, classTTL := range classTTLs {
+		if classTTL > 0 {
+			c.classTTLs[class] = classTTL
+		}
+	}
+	return c
+}
+
+// IsSandboxed reports whether the cache was made by NewSandboxed.
+func (c *RateCache) IsSandboxed() bool {
+	return c.sandboxed
+}
+
 // ════════════════════════════════════════════════════════════════
 // RATE OPERATIONS
 // ════════════════════════════════════════════════════════════════
@@ -95,17 +198,57 @@ func (c *RateCache) SetRate(from, to string, rate float64) {
 	from = strings.ToUpper(from)
 	to = strings.ToUpper(to)
 
-	c.rates[ratePair{From: from, To: to}] = rate
+	c.storeRate(from, to, rate, time.Time{})
+	c.pinned[ratePair{From: from, To: to}] = rate
+}
 
-	// Also store inverse rate
+// storeRate stores a direct rate, and its inverse, as of time at.
+// Callers must hold the write lock.
+func (c *RateCache) storeRate(from, to string, rate float64, at time.Time) {
+	c.invalidateGraph()
+	c.rates[ratePair{From: from, To: to}] = rate
+	c.times[ratePair{From: from, To: to}] = at
 	if rate != 0 {
 		c.rates[ratePair{From: to, To: from}] = 1.0 / rate
+		c.times[ratePair{From: to, To: from}] = at
+	}
+}
+
+// RateTime returns when the rate between two currencies was fetched: for
+// a rate found through other currencies, the oldest along the way. It is
+// zero if any step is a built-in or hand-set rate, or there is no rate.
+func (c *RateCache) RateTime(from, to string) time.Time {
+	path, _, ok := c.RatePath(from, to)
+	if !ok {
+		return time.Time{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var oldest time.Time
+	for i := 1; i < len(path); i++ {
+		t := c.times[ratePair{From: path[i-1], To: path[i]}]
+		if t.IsZero() {
+			return time.Time{}
+		}
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
 	}
+	return oldest
 }
 
 // GetRate gets the exchange rate between two currencies.
-// Uses BFS to find conversion path if direct rate not available.
+// Converts through other currencies if there is no direct rate.
 func (c *RateCache) GetRate(from, to string) (float64, bool) {
+	_, rate, ok := c.RatePath(from, to)
+	return rate, ok
+}
+
+// RatePath returns the currencies a conversion goes through, from and to
+// included, and the rate along them. A direct rate is a two-currency path.
+func (c *RateCache) RatePath(from, to string) ([]string, float64, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -114,57 +257,16 @@ func (c *RateCache) GetRate(from, to string) (float64, bool) {
 
 	// Same currency
 	if from == to {
-		return 1.0, true
+		return []string{from}, 1.0, true
 	}
 
 	// Try direct rate
 	if rate, ok := c.rates[ratePair{From: from, To: to}]; ok {
-		return rate, true
+		return []string{from, to}, rate, true
 	}
 
-	// Try BFS to find conversion path
-	return c.findRateBFS(from, to)
-}
-
-// findRateBFS uses breadth-first search to find a conversion path.
-func (c *RateCache) findRateBFS(from, to string) (float64, bool) {
-	// Queue entries: (currency, accumulated rate)
-	type queueEntry struct {
-		currency string
-		rate     float64
-	}
-
-	visited := make(map[string]bool)
-	queue := []queueEntry{{currency: from, rate: 1.0}}
-	visited[from] = true
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		// Find all currencies we can reach from current
-		for pair, rate := range c.rates {
-			if pair.From != current.currency {
-				continue
-			}
-
-			nextCurrency := pair.To
-			nextRate := current.rate * rate
-
-			// Found target
-			if nextCurrency == to {
-				return nextRate, true
-			}
-
-			// Add to queue if not visited
-			if !visited[nextCurrency] {
-				visited[nextCurrency] = true
-				queue = append(queue, queueEntry{currency: nextCurrency, rate: nextRate})
-			}
-		}
-	}
-
-	return 0, false
+	// Find a conversion path through other currencies
+	return c.findPath(from, to)
 }
 
 // HasRate checks if a rate exists (direct or via path).
@@ -179,54 +281,91 @@ func (c *RateCache) Clear() {
 	defer c.mu.Unlock()
 
 	c.rates = make(map[ratePair]float64)
+	c.times = make(map[ratePair]time.Time)
+	c.invalidateGraph()
 	c.rawRates = make(map[string]float64)
+	c.sources = make(map[string]string)
+	c.pinned = make(map[ratePair]float64)
+	c.fetched = make(map[AssetClass]fetchStamp)
 	c.lastUpdate = time.Time{}
+	c.dated, c.datedLoaded, c.datedFailed = nil, false, nil
+}
+
+// PinnedRate is a rate set explicitly with SetRate.
+type PinnedRate struct {
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+// PinnedRates returns all rates set explicitly with SetRate.
+func (c *RateCache) PinnedRates() []PinnedRate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]PinnedRate, 0, len(c.pinned))
+	for p, rate := range c.pinned {
+		result = append(result, PinnedRate{From: p.From, To: p.To, Rate: rate})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		return result[i].To < result[j].To
+	})
+	return result
 }
 
 // ════════════════════════════════════════════════════════════════
 // BULK OPERATIONS
 // ════════════════════════════════════════════════════════════════
 
-// ApplyRawRates applies rates from an API response.
+// ApplyRawRates applies rates from an API response, marking their asset
+// classes as fetched now.
 // Fiat rates: "1 USD = X currency"
 // Crypto rates: "1 TOKEN = X USD"
 func (c *RateCache) ApplyRawRates(rates map[string]float64) {
+	now := time.Now()
+	c.applyRawRates(rates, now)
+
+	if history := c.History(); history != nil {
+		c.record(history, now, "", perUSD(rates))
+	}
+}
+
+// applyRawRates applies raw rates fetched at time at, marking their asset
+// classes as fetched then.
+func (c *RateCache) applyRawRates(rates map[string]float64, at time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Store raw rates for persistence
-	c.rawRates = make(map[string]float64)
+	// Store raw rates for persistence; where they came from isn't known
 	for k, v := range rates {
 		c.rawRates[k] = v
+		delete(c.sources, k)
 	}
 
 	// Process rates
 	for code, rate := range rates {
 		code = strings.ToUpper(code)
+		c.fetched[assetClassOf(code)] = fetchStamp{at: at}
 
 		// Check if it's a crypto (rate is in USD)
 		if types.IsCrypto(code) {
 			// Crypto: 1 TOKEN = rate USD
-			c.rates[ratePair{From: code, To: "USD"}] = rate
-			if rate != 0 {
-				c.rates[ratePair{From: "USD", To: code}] = 1.0 / rate
-			}
+			c.storeRate(code, "USD", rate, at)
 		} else if types.IsMetal(code) {
 			// Metal: 1 oz = rate USD
-			c.rates[ratePair{From: code, To: "USD"}] = rate
-			if rate != 0 {
-				c.rates[ratePair{From: "USD", To: code}] = 1.0 / rate
-			}
+			c.storeRate(code, "USD", rate, at)
 		} else {
 			// Fiat: 1 USD = rate CURRENCY
-			c.rates[ratePair{From: "USD", To: code}] = rate
-			if rate != 0 {
-				c.rates[ratePair{From: code, To: "USD"}] = 1.0 / rate
-			}
+			c.storeRate("USD", code, rate, at)
 		}
 	}
 
-	c.lastUpdate = time.Now()
+	if at.After(c.lastUpdate) {
+		c.lastUpdate = at
+	}
 }
 
 // RawRates returns the raw rates map (for persistence).
@@ -241,19 +380,66 @@ func (c *RateCache) RawRates() map[string]float64 {
 	return result
 }
 
+// RateSource returns where the rate between code and USD came from:
+// "pinned" if it was set with SetRate, the provider it was fetched from,
+// "cached" if it was loaded from a cache file or the rate history that
+// doesn't say, or "built-in".
+func (c *RateCache) RateSource(code string) string {
+	code = strings.ToUpper(code)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, toCode := c.pinned[ratePair{From: "USD", To: code}]
+	_, fromCode := c.pinned[ratePair{From: code, To: "USD"}]
+	if toCode || fromCode {
+		return "pinned"
+	}
+	if source := c.sources[code]; source != "" {
+		return source
+	}
+	if _, ok := c.rawRates[code]; ok {
+		return "cached"
+	}
+	return "built-in"
+}
+
 // ════════════════════════════════════════════════════════════════
 // CACHE VALIDITY
 // ════════════════════════════════════════════════════════════════
 
-// IsExpired returns true if the cache has expired.
-func (c *RateCache) IsExpired() bool {
+// DirectRates returns the rates held directly from base (1 base = rate
+// code), without path-finding.
+func (c *RateCache) DirectRates(base string) map[string]float64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if c.lastUpdate.IsZero() {
-		return true
+	base = strings.ToUpper(base)
+	result := make(map[string]float64)
+	for pair, rate := range c.rates {
+		if pair.From == base {
+			result[pair.To] = rate
+		}
 	}
-	return time.Since(c.lastUpdate) > c.ttl
+	return result
+}
+
+// IsPinned reports whether the rate between two currencies, either way
+// round, was set explicitly with SetRate.
+func (c *RateCache) IsPinned(from, to string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+	_, ok := c.pinned[ratePair{From: from, To: to}]
+	_, inverse := c.pinned[ratePair{From: to, To: from}]
+	return ok || inverse
+}
+
+// IsExpired returns true if the rates of any asset class have expired.
+func (c *RateCache) IsExpired() bool {
+	return len(c.Expired()) > 0
 }
 
 // IsValid returns true if the cache is valid (not expired).
@@ -279,7 +465,7 @@ func (c *RateCache) Age() time.Duration {
 	return time.Since(c.lastUpdate)
 }
 
-// TTL returns the cache TTL.
+// TTL returns the cache TTL, used by asset classes without their own.
 func (c *RateCache) TTL() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -299,40 +485,85 @@ func (c *RateCache) SetTTL(ttl time.Duration) {
 // FILE PERSISTENCE
 // ════════════════════════════════════════════════════════════════
 
-// LoadFromFile loads rates from the file cache.
+// LoadFromFile loads the latest unexpired fetches from the rate history if
+// it is open and has any, or else rates from the file cache. Asset classes
+// this cache has fetched since the file was written are kept.
 func (c *RateCache) LoadFromFile() bool {
+	if history := c.History(); history != nil && c.loadHistory(history) {
+		return true
+	}
+
 	path := c.getCachePath()
 	if path == "" {
 		return false
 	}
 
-	data, err := os.ReadFile(path)
+	var cached CachedRates
+	err := withFileLock(path, false, func() error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &cached); err != nil {
+			return err
+		}
+		c.noteFileVersion(path)
+		return nil
+	})
 	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.log().Warn("cannot read rate cache", "path", path, "err", err)
+		}
 		return false
 	}
 
-	var cached CachedRates
-	if err := json.Unmarshal(data, &cached); err != nil {
-		return false
-	}
+	return c.applyCached(cached)
+}
 
-	// Check if expired
-	timestamp := time.Unix(cached.Timestamp, 0)
-	if time.Since(timestamp) > c.ttl {
-		return false
-	}
+// applyCached applies each asset class in cached that hasn't expired and
+// wasn't fetched before this cache's own rates of that class.
+func (c *RateCache) applyCached(cached CachedRates) bool {
+	loaded := false
+	for class, rates := range splitByClass(cached.Rates) {
+		timestamp := time.Unix(cached.Timestamp, 0)
+		if at, ok := cached.Fetched[class.String()]; ok {
+			timestamp = time.UnixMilli(at)
+		}
+		if time.Since(timestamp) > c.ClassTTL(class) {
+			continue
+		}
 
-	// Apply rates
-	c.ApplyRawRates(cached.Rates)
-	c.mu.Lock()
-	c.lastUpdate = timestamp
-	c.mu.Unlock()
+		// The file keeps whole milliseconds, so a fetch in the same
+		// millisecond counts as the same one
+		c.mu.RLock()
+		stamp, ok := c.fetched[class]
+		c.mu.RUnlock()
+		if ok && stamp.at.UnixMilli() > timestamp.UnixMilli() {
+			continue
+		}
 
-	return true
+		c.applyRawRates(rates, timestamp)
+		c.mu.Lock()
+		for code := range rates {
+			if source := cached.Sources[code]; source != "" {
+				c.sources[code] = source
+			}
+		}
+		c.mu.Unlock()
+		loaded = true
+	}
+	return loaded
 }
 
-// SaveToFile saves rates to the file cache.
+// SaveToFile saves rates to the file cache. Asset classes another process
+// has saved since they were fetched here are loaded first rather than
+// overwritten. With the rate history open it does nothing, as fetches are
+// recorded as they arrive.
 func (c *RateCache) SaveToFile() error {
+	if c.History() != nil {
+		return nil
+	}
+
 	path := c.getCachePath()
 	if path == "" {
 		return nil
@@ -344,23 +575,190 @@ func (c *RateCache) SaveToFile() error {
 		return err
 	}
 
+	return withFileLock(path, true, func() error {
+		if data, err := os.ReadFile(path); err == nil {
+			var onDisk CachedRates
+			if json.Unmarshal(data, &onDisk) == nil {
+				c.applyCached(onDisk)
+			}
+		}
+
+		c.mu.RLock()
+		cached := CachedRates{
+			Timestamp:    c.lastUpdate.Unix(),
+			Fetched:      make(map[string]int64, len(c.fetched)),
+			Rates:        c.rawRates,
+			Sources:      c.sources,
+			BaseCurrency: "USD",
+		}
+		for class, stamp := range c.fetched {
+			cached.Fetched[class.String()] = stamp.at.UnixMilli()
+		}
+		data, err := json.MarshalIndent(cached, "", "  ")
+		c.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+
+		if err := atomicfile.Write(path, data, 0644); err != nil {
+			return err
+		}
+		c.noteFileVersion(path)
+		return nil
+	})
+}
+
+// ════════════════════════════════════════════════════════════════
+// RATE HISTORY
+// ════════════════════════════════════════════════════════════════
+
+// OpenHistory keeps fetched rates in the SQLite database at path instead
+// of the JSON cache file, and loads its latest unexpired fetches.
+func (c *RateCache) OpenHistory(path string) error {
+	if c.sandboxed {
+		return ErrSandboxed
+	}
+	history, err := OpenHistory(path)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	old := c.history
+	c.history = history
+	c.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+
+	c.loadHistory(history)
+	return nil
+}
+
+// CloseHistory closes the rate history, if open, going back to the JSON
+// cache file.
+func (c *RateCache) CloseHistory() error {
+	c.mu.Lock()
+	history := c.history
+	c.history = nil
+	c.mu.Unlock()
+
+	if history == nil {
+		return nil
+	}
+	return history.Close()
+}
+
+// History returns the rate history, or nil if it is not open.
+func (c *RateCache) History() *History {
 	c.mu.RLock()
-	cached := CachedRates{
-		Timestamp:    c.lastUpdate.Unix(),
-		Rates:        c.rawRates,
-		BaseCurrency: "USD",
+	defer c.mu.RUnlock()
+	return c.history
+}
+
+// Series returns the recorded rate from one currency to another since the
+// given time, oldest first.
+func (c *RateCache) Series(from, to string, since time.Time) ([]RatePoint, error) {
+	history := c.History()
+	if history == nil {
+		return nil, fmt.Errorf("no rate history (set rate_history = true in the config)")
 	}
+	return history.Series(from, to, since)
+}
+
+// loadHistory applies the latest fetches in history that have not expired.
+func (c *RateCache) loadHistory(history *History) bool {
+	c.mu.RLock()
+	longest := c.maxTTL()
 	c.mu.RUnlock()
 
-	data, err := json.MarshalIndent(cached, "", "  ")
+	latest, at, err := history.Latest(time.Now().Add(-longest))
+	if err != nil || len(latest) == 0 {
+		return false
+	}
+
+	loaded := false
+	for class, rates := range splitByClass(rawRates(latest)) {
+		if time.Since(at) <= c.ClassTTL(class) {
+			c.applyRawRates(rates, at)
+			loaded = true
+		}
+	}
+	return loaded
+}
+
+// getCachePath returns the full path to the cache file.
+// SavePinned saves the rates set with SetRate, which New loads back over
+// the fetched rates whether or not those have expired.
+func (c *RateCache) SavePinned() error {
+	path := c.getPinnedPath()
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c.PinnedRates(), "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return withFileLock(path, true, func() error {
+		return atomicfile.Write(path, data, 0644)
+	})
+}
+
+// loadPinned loads the rates saved with SavePinned.
+func (c *RateCache) loadPinned() {
+	path := c.getPinnedPath()
+	if path == "" {
+		return
+	}
+
+	var pinned []PinnedRate
+	err := withFileLock(path, false, func() error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, &pinned)
+	})
+	if err != nil {
+		return
+	}
+	for _, p := range pinned {
+		c.SetRate(p.From, p.To, p.Rate)
+	}
+}
+
+// ClearFiles clears the cache and removes its files, pinned and past
+// rates included, along with the providers' cached HTTP responses.
+func (c *RateCache) ClearFiles() error {
+	c.Clear()
+
+	for _, path := range []string{c.getCachePath(), c.getPinnedPath(), c.getDatedPath()} {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if hc := fetch.DefaultHTTPCache(); hc != nil && !c.IsSandboxed() {
+		return hc.Clear()
+	}
+	return nil
+}
+
+func (c *RateCache) getPinnedPath() string {
+	if c.cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(c.cacheDir, DefaultPinnedFile)
 }
 
-// getCachePath returns the full path to the cache file.
 func (c *RateCache) getCachePath() string {
 	if c.cacheDir == "" {
 		return ""
@@ -418,124 +816,313 @@ func (c *RateCache) isCacheFileValid() bool {
 // REFRESH FROM NETWORK
 // ════════════════════════════════════════════════════════════════
 
+// RefreshResult is the outcome of a refresh from the network.
+type RefreshResult struct {
+	Count   int            // Rates fetched
+	Err     error          // Why no rates could be fetched, or nil
+	Classes []ClassRefresh // Each asset class's outcome, when classes were refreshed together
+}
+
+// ClassRefresh is the outcome of fetching one asset class's rates.
+type ClassRefresh struct {
+	Class    AssetClass
+	Provider string // Where the rates came from, "" if nowhere
+	Count    int    // Rates fetched
+	Duration time.Duration
+	Err      error  // Why none were fetched, or nil
+	Fallback string // With Err, the rates used instead: FallbackCached or FallbackBuiltIn
+}
+
+// Fetched returns how many of the classes refreshed were fetched.
+func (r RefreshResult) Fetched() int {
+	n := 0
+	for _, cr := range r.Classes {
+		if cr.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns how many of the classes refreshed could not be fetched.
+func (r RefreshResult) Failed() int {
+	return len(r.Classes) - r.Fetched()
+}
+
+// OnRefresh registers a function called after every refresh from the
+// network, successful or not, from the goroutine that refreshed. A
+// refresh of nothing, as when RefreshIfExpired finds no rates expired, is
+// not reported.
+func (c *RateCache) OnRefresh(fn func(RefreshResult)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onRefresh = append(c.onRefresh, fn)
+}
+
+// refreshed reports a refresh to the OnRefresh functions, and returns its
+// outcome.
+func (c *RateCache) refreshed(count int, err error) (int, error) {
+	r := c.notify(RefreshResult{Count: count, Err: err})
+	return r.Count, r.Err
+}
+
+// notify reports a refresh to the OnRefresh functions, and returns it.
+func (c *RateCache) notify(r RefreshResult) RefreshResult {
+	c.logRefresh(r.Count, r.Err)
+
+	c.mu.RLock()
+	hooks := c.onRefresh
+	c.mu.RUnlock()
+
+	for _, fn := range hooks {
+		fn(r)
+	}
+	return r
+}
+
+// SetClassTimeout sets how long fetching an asset class's rates may take
+// within a refresh of several, DefaultClassTimeout if not positive, so a
+// slow provider of one class doesn't hold up the others.
+func (c *RateCache) SetClassTimeout(class AssetClass, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d > 0 {
+		c.classTimeouts[class] = d
+	} else {
+		delete(c.classTimeouts, class)
+	}
+}
+
+// ClassTimeout returns how long fetching an asset class's rates may take.
+func (c *RateCache) ClassTimeout(class AssetClass) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if d, ok := c.classTimeouts[class]; ok {
+		return d
+	}
+	return DefaultClassTimeout
+}
+
 // Refresh fetches fresh rates from the network and updates the cache.
 // Returns the number of rates fetched, or an error.
 func (c *RateCache) Refresh(ctx context.Context) (int, error) {
-	result, err := fetch.FetchAllRates(ctx)
-	if err != nil {
-		return 0, err
-	}
+	r := c.RefreshClasses(ctx, Fetchable(AssetClasses...)...)
+	return r.Count, r.Err
+}
 
-	if result.IsEmpty() {
+// RefreshIfExpired fetches fresh rates for the asset classes that have
+// expired. Returns the number of rates fetched (0 if none had), or an
+// error.
+func (c *RateCache) RefreshIfExpired(ctx context.Context) (int, error) {
+	expired := c.Expired()
+	if len(expired) == 0 {
 		return 0, nil
 	}
+	r := c.RefreshClasses(ctx, expired...)
+	return r.Count, r.Err
+}
 
-	// Apply the fetched rates
-	c.ApplyRawRates(result.Rates)
+// RefreshClasses fetches the rates of the given asset classes at the
+// same time, each within its timeout (see SetClassTimeout), keeping those
+// fetched, and reports how each class went. Err is set only if none could
+// be fetched.
+func (c *RateCache) RefreshClasses(ctx context.Context, classes ...AssetClass) RefreshResult {
+	return c.refreshClasses(ctx, nil, classes)
+}
+
+// refreshClasses is RefreshClasses, reporting its progress to emit if
+// not nil.
+func (c *RateCache) refreshClasses(ctx context.Context, emit func(RefreshEvent), classes []AssetClass) RefreshResult {
+	if c.sandboxed {
+		return c.notify(RefreshResult{Err: ErrSandboxed})
+	}
 
-	// Save to file cache
-	_ = c.SaveToFile()
+	r := RefreshResult{Classes: make([]ClassRefresh, len(classes))}
+	var wg sync.WaitGroup
+	for i, class := range classes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Classes[i] = c.refreshClass(ctx, emit, class)
+		}()
+	}
+	wg.Wait()
 
-	return result.Count(), nil
+	for _, cr := range r.Classes {
+		r.Count += cr.Count
+		if cr.Err != nil {
+			r.Err = cr.Err
+		}
+	}
+	if r.Fetched() > 0 {
+		r.Err = nil
+	}
+	if r.Count > 0 {
+		c.save()
+	}
+	return c.notify(r)
 }
 
-// RefreshIfExpired fetches fresh rates only if the cache is expired.
-// Returns the number of rates fetched (0 if cache was valid), or an error.
-func (c *RateCache) RefreshIfExpired(ctx context.Context) (int, error) {
-	if c.IsValid() {
-		return 0, nil
+// refreshClass fetches an asset class's rates within its timeout,
+// reporting its progress to emit if not nil.
+func (c *RateCache) refreshClass(ctx context.Context, emit func(RefreshEvent), class AssetClass) ClassRefresh {
+	ctx, cancel := context.WithTimeout(ctx, c.ClassTimeout(class))
+	defer cancel()
+	if emit != nil {
+		ctx = fetch.WithProgress(ctx, classProgress(class, emit))
+	}
+
+	start := time.Now()
+	result, err := fetch.Default().Fetch(ctx, class.providerType())
+	cr := ClassRefresh{Class: class, Duration: time.Since(start), Err: err}
+	if err != nil {
+		cr.Fallback = c.fellBack(class, err)
+	} else {
+		cr.Provider = strings.ToLower(result.Provider)
+		if !result.IsEmpty() {
+			c.applyRatesResult(result)
+			cr.Count = result.Count()
+		}
 	}
-	return c.Refresh(ctx)
+	if emit != nil {
+		emit(RefreshEvent{Kind: RefreshClassDone, Class: class, Provider: cr.Provider,
+			Count: cr.Count, Err: cr.Err, Fallback: cr.Fallback})
+	}
+	return cr
 }
 
 // RefreshFiat fetches only fiat currency rates.
 func (c *RateCache) RefreshFiat(ctx context.Context) (int, error) {
+	if c.sandboxed {
+		return c.refreshed(0, ErrSandboxed)
+	}
 	result, err := fetch.FetchFiatRates(ctx)
 	if err != nil {
-		return 0, err
-	}
-
-	if result.IsEmpty() {
-		return 0, nil
+		c.fellBack(AssetFiat, err)
 	}
-
-	c.applyRatesResult(result)
-	_ = c.SaveToFile()
-
-	return result.Count(), nil
+	return c.applyFetched(result, err, true)
 }
 
 // RefreshCrypto fetches only cryptocurrency rates.
 func (c *RateCache) RefreshCrypto(ctx context.Context) (int, error) {
+	if c.sandboxed {
+		return c.refreshed(0, ErrSandboxed)
+	}
 	result, err := fetch.FetchCryptoRates(ctx)
 	if err != nil {
-		return 0, err
+		c.fellBack(AssetCrypto, err)
 	}
+	return c.applyFetched(result, err, true)
+}
 
-	if result.IsEmpty() {
-		return 0, nil
+// RefreshMetals fetches only precious metal rates.
+func (c *RateCache) RefreshMetals(ctx context.Context) (int, error) {
+	if c.sandboxed {
+		return c.refreshed(0, ErrSandboxed)
+	}
+	result, err := fetch.FetchMetalRates(ctx)
+	if err != nil {
+		c.fellBack(AssetMetal, err)
 	}
+	return c.applyFetched(result, err, true)
+}
 
-	c.applyRatesResult(result)
-	_ = c.SaveToFile()
+// RefreshCommodities fetches only commodity rates: oil, gas and base
+// metals.
+func (c *RateCache) RefreshCommodities(ctx context.Context) (int, error) {
+	if c.sandboxed {
+		return c.refreshed(0, ErrSandboxed)
+	}
+	result, err := fetch.FetchCommodityRates(ctx)
+	if err != nil {
+		c.fellBack(AssetCommodity, err)
+	}
+	return c.applyFetched(result, err, true)
+}
+
+// RefreshFrom fetches rates from the named provider. With a non-zero date
+// it fetches the fiat rates of that day from a provider that keeps history
+// (any such provider when name is empty); past rates aren't saved to the
+// file cache.
+func (c *RateCache) RefreshFrom(ctx context.Context, name string, date time.Time) (int, error) {
+	if c.sandboxed {
+		return c.refreshed(0, ErrSandboxed)
+	}
 
-	return result.Count(), nil
+	var result *fetch.RatesResult
+	var err error
+	if date.IsZero() {
+		result, err = fetch.Default().FetchWithProvider(ctx, name)
+	} else {
+		result, err = fetch.Default().FetchAt(ctx, name, date)
+	}
+	return c.applyFetched(result, err, date.IsZero())
 }
 
-// RefreshMetals fetches only precious metal rates.
-func (c *RateCache) RefreshMetals(ctx context.Context) (int, error) {
-	result, err := fetch.FetchMetalRates(ctx)
+// applyFetched applies the result of a single fetch, saving the file cache
+// if save is set, and reports the refresh.
+func (c *RateCache) applyFetched(result *fetch.RatesResult, err error, save bool) (int, error) {
 	if err != nil {
-		return 0, err
+		return c.refreshed(0, err)
 	}
 
 	if result.IsEmpty() {
-		return 0, nil
+		return c.refreshed(0, nil)
 	}
 
 	c.applyRatesResult(result)
-	_ = c.SaveToFile()
+	if save {
+		c.save()
+	}
 
-	return result.Count(), nil
+	return c.refreshed(result.Count(), nil)
 }
 
 // applyRatesResult applies a fetch.RatesResult to the cache.
-// This handles the different rate semantics for fiat vs crypto vs metals.
+// This handles the different rate semantics for fiat vs crypto, metals
+// and commodities.
 func (c *RateCache) applyRatesResult(result *fetch.RatesResult) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 
+	at := result.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	perUSD := map[string]float64{"USD": 1}
 	for code, rate := range result.Rates {
 		code = strings.ToUpper(code)
 
 		// Store in rawRates for persistence
 		c.rawRates[code] = rate
+		c.sources[code] = strings.ToLower(result.Provider)
 
 		switch result.Type {
 		case fetch.ProviderTypeFiat:
 			// Fiat: 1 USD = rate CURRENCY
-			c.rates[ratePair{From: "USD", To: code}] = rate
-			if rate != 0 {
-				c.rates[ratePair{From: code, To: "USD"}] = 1.0 / rate
-			}
+			c.storeRate("USD", code, rate, at)
+			perUSD[code] = rate
 
-		case fetch.ProviderTypeCrypto:
-			// Crypto: 1 TOKEN = rate USD
-			c.rates[ratePair{From: code, To: "USD"}] = rate
+		case fetch.ProviderTypeCrypto, fetch.ProviderTypeMetal, fetch.ProviderTypeCommodity:
+			// Crypto: 1 TOKEN = rate USD; metal: 1 oz = rate USD;
+			// commodity: 1 unit (barrel, MMBtu, lb) = rate USD
+			c.storeRate(code, "USD", rate, at)
 			if rate != 0 {
-				c.rates[ratePair{From: "USD", To: code}] = 1.0 / rate
-			}
-
-		case fetch.ProviderTypeMetal:
-			// Metal: 1 oz = rate USD
-			c.rates[ratePair{From: code, To: "USD"}] = rate
-			if rate != 0 {
-				c.rates[ratePair{From: "USD", To: code}] = 1.0 / rate
+				perUSD[code] = 1 / rate
 			}
 		}
 	}
 
 	c.lastUpdate = time.Now()
+	if class, ok := assetClassFor(result.Type); ok {
+		c.fetched[class] = fetchStamp{at: c.lastUpdate, provider: strings.ToLower(result.Provider)}
+	}
+	history := c.history
+	c.mu.Unlock()
+
+	if history != nil {
+		c.record(history, at, result.Provider, perUSD)
+	}
 }
 
 // RefreshAsync starts a background refresh and returns immediately.
@@ -555,6 +1142,82 @@ func (c *RateCache) RefreshAsync(done chan<- error) {
 	}()
 }
 
+// ════════════════════════════════════════════════════════════════
+// REFRESH PROGRESS
+// ════════════════════════════════════════════════════════════════
+
+// RefreshEventKind is what a RefreshEvent reports.
+type RefreshEventKind int
+
+const (
+	RefreshProviderStarted RefreshEventKind = iota // A provider is being asked for a class's rates
+	RefreshProviderFetched                         // It returned them
+	RefreshProviderFailed                          // It failed; the class's next provider, if any, is asked
+	RefreshClassDone                               // A class's fetch is over, rates fetched or not
+	RefreshDone                                    // The refresh is over: the last event
+)
+
+// String returns the event kind's name.
+func (k RefreshEventKind) String() string {
+	switch k {
+	case RefreshProviderStarted:
+		return "provider started"
+	case RefreshProviderFetched:
+		return "provider fetched"
+	case RefreshProviderFailed:
+		return "provider failed"
+	case RefreshClassDone:
+		return "class done"
+	case RefreshDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// RefreshEvent is a step of a refresh, as RefreshStream reports it.
+type RefreshEvent struct {
+	Kind     RefreshEventKind
+	Class    AssetClass
+	Provider string         // Provider events; RefreshClassDone: where the rates came from, if fetched
+	Count    int            // RefreshClassDone: rates fetched
+	Err      error          // Why the provider failed, or the class could not be fetched
+	Fallback string         // RefreshClassDone with Err: FallbackCached or FallbackBuiltIn
+	Result   *RefreshResult // RefreshDone: the refresh's outcome
+}
+
+// RefreshStream refreshes the given asset classes like RefreshClasses,
+// in the background, sending each step to the returned channel as it
+// happens: each provider asked for rates, how it went, each class's
+// outcome and lastly the refresh's. The channel is closed after the
+// RefreshDone event; it must be read until then.
+func (c *RateCache) RefreshStream(ctx context.Context, classes ...AssetClass) <-chan RefreshEvent {
+	events := make(chan RefreshEvent, 4*len(classes)+1)
+	go func() {
+		defer close(events)
+		emit := func(e RefreshEvent) { events <- e }
+		r := c.refreshClasses(ctx, emit, classes)
+		emit(RefreshEvent{Kind: RefreshDone, Err: r.Err, Result: &r})
+	}()
+	return events
+}
+
+// classProgress returns the fetch progress of class, as refresh events.
+func classProgress(class AssetClass, emit func(RefreshEvent)) fetch.Progress {
+	return fetch.Progress{
+		Start: func(provider string, _ fetch.ProviderType) {
+			emit(RefreshEvent{Kind: RefreshProviderStarted, Class: class, Provider: provider})
+		},
+		Done: func(a fetch.FetchAttempt) {
+			kind := RefreshProviderFetched
+			if a.Err != nil {
+				kind = RefreshProviderFailed
+			}
+			emit(RefreshEvent{Kind: kind, Class: class, Provider: a.Provider, Err: a.Err})
+		},
+	}
+}
+
 // ════════════════════════════════════════════════════════════════
 // DEFAULT RATES (Fallback for offline mode)
 // ════════════════════════════════════════════════════════════════
@@ -605,6 +1268,7 @@ func (c *RateCache) loadDefaults() {
 		"KES": 155.0,
 		"QAR": 3.64,
 		"KWD": 0.31,
+		"BHD": 0.376,
 		"RON": 4.57,
 		"NZD": 1.64,
 	}
@@ -653,6 +1317,15 @@ func (c *RateCache) loadDefaults() {
 		"XPD": 1100.0, // Palladium
 	}
 
+	// Commodity rates (USD per barrel, MMBtu or pound)
+	commodityDefaults := map[string]float64{
+		"WTI":    75.0, // WTI crude oil, per barrel
+		"BRENT":  79.0, // Brent crude oil, per barrel
+		"NATGAS": 3.10, // Natural gas, per MMBtu
+		"XCU":    4.30, // Copper, per pound
+		"XAL":    1.15, // Aluminum, per pound
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -682,6 +1355,14 @@ func (c *RateCache) loadDefaults() {
 			c.rates[ratePair{From: "USD", To: code}] = 1.0 / rate
 		}
 	}
+
+	// Commodities: 1 unit = X USD
+	for code, rate := range commodityDefaults {
+		c.rates[ratePair{From: code, To: "USD"}] = rate
+		if rate != 0 {
+			c.rates[ratePair{From: "USD", To: code}] = 1.0 / rate
+		}
+	}
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -694,6 +1375,7 @@ type Stats struct {
 	LastUpdate   time.Time
 	Age          time.Duration
 	IsExpired    bool
+	Expired      []AssetClass // The asset classes that have expired
 	CacheFile    string
 	HasFileCache bool
 }
@@ -705,12 +1387,14 @@ func (c *RateCache) Stats() Stats {
 
 	path := c.getCachePath()
 	_, err := os.Stat(path)
+	expired := c.expired()
 
 	return Stats{
 		DirectRates:  len(c.rates),
 		LastUpdate:   c.lastUpdate,
 		Age:          time.Since(c.lastUpdate),
-		IsExpired:    c.lastUpdate.IsZero() || time.Since(c.lastUpdate) > c.ttl,
+		IsExpired:    len(expired) > 0,
+		Expired:      expired,
 		CacheFile:    path,
 		HasFileCache: err == nil,
 	}
@@ -731,21 +1415,43 @@ func (c *RateCache) Convert(amount float64, from, to string) (float64, bool) {
 
 // ConvertValue converts a types.Value to a target currency/unit.
 func (c *RateCache) ConvertValue(v types.Value, target string) (types.Value, bool) {
+	return convertValue(v, target, c.GetRate)
+}
+
+// convertValue converts a currency, crypto or metal amount to target,
+// using rate to look up the rate from one code to another.
+func convertValue(v types.Value, target string, rate func(from, to string) (float64, bool)) (types.Value, bool) {
 	if v.IsError() || v.IsEmpty() {
 		return v, false
 	}
 
+	// Sub-units convert as their crypto, shown in the sub-unit: "$10 in sats"
+	if unit := types.ParseCryptoUnit(target); unit != nil {
+		converted, ok := convertValue(v, unit.Crypto, rate)
+		if !ok || !converted.IsCrypto() {
+			return v, false
+		}
+		return converted.WithCryptoUnit(unit), true
+	}
+
 	target = strings.ToUpper(target)
+	convert := func(code string) (float64, bool) {
+		r, ok := rate(code, target)
+		return v.Num * r, ok
+	}
 
 	switch v.Kind {
 	case types.ValueCurrency:
 		if v.Curr == nil {
 			return v, false
 		}
-		converted, ok := c.Convert(v.Num, v.Curr.Code, target)
+		converted, ok := convert(v.Curr.Code)
 		if !ok {
 			return v, false
 		}
+		if targetCrypto := types.ParseCrypto(target); targetCrypto != nil && types.ParseCurrency(target) == nil {
+			return types.CryptoValue(converted, targetCrypto), true
+		}
 		targetCurr := types.ParseCurrency(target)
 		if targetCurr == nil {
 			targetCurr = types.CurrencyFromCode(target)
@@ -756,7 +1462,7 @@ func (c *RateCache) ConvertValue(v types.Value, target string) (types.Value, boo
 		if v.Crypto == nil {
 			return v, false
 		}
-		converted, ok := c.Convert(v.Num, v.Crypto.Code, target)
+		converted, ok := convert(v.Crypto.Code)
 		if !ok {
 			return v, false
 		}
@@ -773,7 +1479,13 @@ func (c *RateCache) ConvertValue(v types.Value, target string) (types.Value, boo
 		if v.Metal == nil {
 			return v, false
 		}
-		converted, ok := c.Convert(v.Num, v.Metal.Code, target)
+		var converted float64
+		var ok bool
+		if v.Karat > 0 {
+			converted, ok = convertKarat(v, target, rate)
+		} else {
+			converted, ok = convert(v.Metal.Code)
+		}
 		if !ok {
 			return v, false
 		}
@@ -790,3 +1502,15 @@ func (c *RateCache) ConvertValue(v types.Value, target string) (types.Value, boo
 		return v, false
 	}
 }
+
+// convertKarat converts an amount of gold of some purity, in troy ounces,
+// to target: at the price of a gram of that purity, where a provider gives
+// one, or else at its share of pure gold's price.
+func convertKarat(v types.Value, target string, rate func(from, to string) (float64, bool)) (float64, bool) {
+	if r, ok := rate(types.KaratCode(v.Karat), target); ok {
+		grams, _ := types.ConvertUnit(v.Num, "ozt", "g")
+		return grams * r, true
+	}
+	r, ok := rate(v.Metal.Code, target)
+	return v.Num * r * float64(v.Karat) / types.PureKarat, ok
+}
@@ -0,0 +1,275 @@
+// pkg/cache/dated.go
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/internal/atomicfile"
+	"github.com/0xsj/numio/internal/fetch"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Past rates convert amounts as of a date: "0.5 BTC on 2021-11-10 in USD".
+// Rates once published don't change, so each is fetched once, as it is
+// first needed, and kept in a file beside the rate cache. Fiat rates come
+// a day at a time; crypto prices a coin at a time, which free API tiers
+// allow only so many of.
+
+// Past rate settings.
+const (
+	DefaultDatedFile = "dated.json"
+	DatedRetryAfter  = 5 * time.Minute // A failed fetch isn't tried again sooner
+)
+
+// ErrOffline is the error of converting at a past date's rates not yet
+// fetched, with a cache set offline.
+var ErrOffline = errors.New("past rates not fetched: network access is off")
+
+// datedFailure is a failed fetch of a past rate, remembered so evaluating
+// the same line again doesn't wait on the network each time.
+type datedFailure struct {
+	at  time.Time
+	err error
+}
+
+// SetOffline keeps the cache from fetching past rates as it converts
+// amounts at them; only those fetched before are used.
+func (c *RateCache) SetOffline(offline bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offline = offline
+}
+
+// RateAt returns the rate from one code to another on date: fiat rates as
+// published that day, or on the last working day before it, and crypto
+// prices as of the start of that day (UTC). Dates from today on use the
+// current rates.
+func (c *RateCache) RateAt(from, to string, date time.Time) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if !isPast(date) {
+		if rate, ok := c.GetRate(from, to); ok {
+			return rate, nil
+		}
+		return 0, fmt.Errorf("no rate available for %s/%s", from, to)
+	}
+	if from == to {
+		return 1, nil
+	}
+
+	fromUSD, err := c.perUSDAt(from, date)
+	if err != nil {
+		return 0, err
+	}
+	toUSD, err := c.perUSDAt(to, date)
+	if err != nil {
+		return 0, err
+	}
+	return toUSD / fromUSD, nil
+}
+
+// ConvertValueAt converts a currency, crypto or metal amount to target at
+// date's rates (see RateAt).
+func (c *RateCache) ConvertValueAt(v types.Value, target string, date time.Time) (types.Value, error) {
+	var rateErr error
+	converted, ok := convertValue(v, target, func(from, to string) (float64, bool) {
+		rate, err := c.RateAt(from, to, date)
+		rateErr = err
+		return rate, err == nil
+	})
+	switch {
+	case ok:
+		return converted.WithAsOf(date), nil
+	case rateErr != nil:
+		return v, rateErr
+	default:
+		return v, fmt.Errorf("cannot convert to %s", target)
+	}
+}
+
+// isPast reports whether date is before today.
+func isPast(date time.Time) bool {
+	y, m, d := time.Now().Date()
+	return date.Before(time.Date(y, m, d, 0, 0, 0, 0, date.Location()))
+}
+
+// perUSDAt returns units of code per US dollar on date, fetching them if
+// they aren't kept yet.
+func (c *RateCache) perUSDAt(code string, date time.Time) (float64, error) {
+	if code == "USD" {
+		return 1, nil
+	}
+	day := date.Format(time.DateOnly)
+	c.loadDated()
+
+	c.mu.RLock()
+	rate, ok := c.dated[day][code]
+	failed, hasFailed := c.datedFailed[day+" "+code]
+	offline, sandboxed := c.offline, c.sandboxed
+	c.mu.RUnlock()
+
+	switch {
+	case ok:
+		return rate, nil
+	case sandboxed:
+		return 0, ErrSandboxed
+	case offline:
+		return 0, ErrOffline
+	case hasFailed && time.Since(failed.at) < DatedRetryAfter:
+		return 0, failed.err
+	}
+
+	rates, err := c.fetchDated(code, date)
+	if err == nil {
+		if rate, ok = rates[code]; !ok {
+			err = fmt.Errorf("no %s rate on %s", code, day)
+		}
+	}
+
+	c.mu.Lock()
+	if err != nil {
+		if c.datedFailed == nil {
+			c.datedFailed = make(map[string]datedFailure)
+		}
+		c.datedFailed[day+" "+code] = datedFailure{at: time.Now(), err: err}
+		c.mu.Unlock()
+		return 0, err
+	}
+	if c.dated == nil {
+		c.dated = make(map[string]map[string]float64)
+	}
+	if c.dated[day] == nil {
+		c.dated[day] = make(map[string]float64)
+	}
+	for k, v := range rates {
+		c.dated[day][k] = v
+	}
+	c.mu.Unlock()
+
+	c.saveDated(day, rates)
+	return rate, nil
+}
+
+// fetchDated fetches the rates on date needed for code, per US dollar:
+// every fiat rate of the day for a currency, or the one price of a coin
+// or metal.
+func (c *RateCache) fetchDated(code string, date time.Time) (map[string]float64, error) {
+	class := assetClassOf(code)
+	ctx, cancel := context.WithTimeout(context.Background(), c.ClassTimeout(class))
+	defer cancel()
+
+	day := date.Format(time.DateOnly)
+	var result *fetch.RatesResult
+	var err error
+	if class == AssetFiat {
+		result, err = fetch.Default().FetchAt(ctx, "", date)
+	} else {
+		result, err = fetch.Default().FetchPriceAt(ctx, class.providerType(), code, date)
+	}
+	if err != nil {
+		c.log().Warn("cannot fetch past rates", "code", code, "date", day, "err", err)
+		return nil, fmt.Errorf("cannot fetch %s rates on %s: %w", code, day, err)
+	}
+	c.log().Info("fetched past rates", "code", code, "date", day, "provider", result.Provider, "count", result.Count())
+
+	perUSD := make(map[string]float64, len(result.Rates))
+	for k, rate := range result.Rates {
+		if rate <= 0 {
+			continue
+		}
+		if result.Type == fetch.ProviderTypeFiat {
+			perUSD[strings.ToUpper(k)] = rate // 1 USD = rate CURRENCY
+		} else {
+			perUSD[strings.ToUpper(k)] = 1 / rate // 1 TOKEN = rate USD
+		}
+	}
+	return perUSD, nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// FILE
+// ════════════════════════════════════════════════════════════════
+
+// loadDated loads the past rates file, the first time it is needed.
+func (c *RateCache) loadDated() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.datedLoaded {
+		return
+	}
+	c.datedLoaded = true
+
+	path := c.getDatedPath()
+	if path == "" {
+		return
+	}
+	_ = withFileLock(path, false, func() error {
+		dated, err := readDated(path)
+		if err == nil {
+			c.dated = dated
+		}
+		return err
+	})
+}
+
+// saveDated adds a day's rates to the past rates file, keeping those
+// another process has added since this one read it.
+func (c *RateCache) saveDated(day string, rates map[string]float64) {
+	path := c.getDatedPath()
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.log().Warn("cannot save past rates", "path", path, "err", err)
+		return
+	}
+
+	err := withFileLock(path, true, func() error {
+		dated, err := readDated(path)
+		if err != nil {
+			dated = make(map[string]map[string]float64)
+		}
+		if dated[day] == nil {
+			dated[day] = make(map[string]float64)
+		}
+		for code, rate := range rates {
+			dated[day][code] = rate
+		}
+		data, err := json.Marshal(dated)
+		if err != nil {
+			return err
+		}
+		return atomicfile.Write(path, data, 0644)
+	})
+	if err != nil {
+		c.log().Warn("cannot save past rates", "path", path, "err", err)
+	}
+}
+
+// readDated reads a past rates file: units of each code per US dollar, by
+// day (YYYY-MM-DD).
+func readDated(path string) (map[string]map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var dated map[string]map[string]float64
+	if err := json.Unmarshal(data, &dated); err != nil {
+		return nil, err
+	}
+	return dated, nil
+}
+
+func (c *RateCache) getDatedPath() string {
+	if c.cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(c.cacheDir, DefaultDatedFile)
+}
@@ -0,0 +1,247 @@
+// pkg/cache/expiry.go
+
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/internal/fetch"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Default TTLs of each asset class, used by New. Crypto prices move by
+// the minute, metal prices by the hour, and fiat rates are published daily.
+// Commodity prices are published daily at most, and their providers' free
+// tiers allow few requests.
+const (
+	DefaultFiatTTL      = 24 * time.Hour
+	DefaultCryptoTTL    = 5 * time.Minute
+	DefaultMetalTTL     = 1 * time.Hour
+	DefaultCommodityTTL = 12 * time.Hour
+)
+
+// AssetClass is a kind of asset whose rates are fetched, and go stale,
+// together.
+type AssetClass int
+
+const (
+	AssetFiat AssetClass = iota
+	AssetCrypto
+	AssetMetal
+	AssetCommodity // Oil, gas and base metals
+)
+
+// AssetClasses lists every asset class.
+var AssetClasses = []AssetClass{AssetFiat, AssetCrypto, AssetMetal, AssetCommodity}
+
+// String returns the asset class name.
+func (a AssetClass) String() string {
+	switch a {
+	case AssetFiat:
+		return "fiat"
+	case AssetCrypto:
+		return "crypto"
+	case AssetMetal:
+		return "metal"
+	case AssetCommodity:
+		return "commodity"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAssetClass parses an asset class name.
+func ParseAssetClass(s string) (AssetClass, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "fiat", "currency", "currencies":
+		return AssetFiat, true
+	case "crypto", "cryptocurrency", "cryptocurrencies":
+		return AssetCrypto, true
+	case "metal", "metals":
+		return AssetMetal, true
+	case "commodity", "commodities":
+		return AssetCommodity, true
+	}
+	return 0, false
+}
+
+// DefaultClassTTLs returns the default TTL of each asset class.
+func DefaultClassTTLs() map[AssetClass]time.Duration {
+	return map[AssetClass]time.Duration{
+		AssetFiat:      DefaultFiatTTL,
+		AssetCrypto:    DefaultCryptoTTL,
+		AssetMetal:     DefaultMetalTTL,
+		AssetCommodity: DefaultCommodityTTL,
+	}
+}
+
+// assetClassOf returns the asset class of a currency, crypto, metal or
+// commodity code.
+func assetClassOf(code string) AssetClass {
+	switch {
+	case types.IsCrypto(code):
+		return AssetCrypto
+	case types.IsCommodity(code):
+		return AssetCommodity
+	case types.IsMetal(code):
+		return AssetMetal
+	default:
+		return AssetFiat
+	}
+}
+
+// assetClassFor returns the asset class a provider type fetches.
+func assetClassFor(typ fetch.ProviderType) (AssetClass, bool) {
+	switch typ {
+	case fetch.ProviderTypeFiat:
+		return AssetFiat, true
+	case fetch.ProviderTypeCrypto:
+		return AssetCrypto, true
+	case fetch.ProviderTypeMetal:
+		return AssetMetal, true
+	case fetch.ProviderTypeCommodity:
+		return AssetCommodity, true
+	}
+	return 0, false
+}
+
+// providerType returns the type of provider that fetches the asset class.
+func (a AssetClass) providerType() fetch.ProviderType {
+	switch a {
+	case AssetCrypto:
+		return fetch.ProviderTypeCrypto
+	case AssetMetal:
+		return fetch.ProviderTypeMetal
+	case AssetCommodity:
+		return fetch.ProviderTypeCommodity
+	default:
+		return fetch.ProviderTypeFiat
+	}
+}
+
+// Fetchable returns the asset classes of classes that a rate provider is
+// registered for. Commodities have none without an API key, and keep their
+// built-in prices rather than fail every refresh.
+func Fetchable(classes ...AssetClass) []AssetClass {
+	result := make([]AssetClass, 0, len(classes))
+	for _, class := range classes {
+		if len(fetch.Default().Providers(class.providerType())) > 0 {
+			result = append(result, class)
+		}
+	}
+	return result
+}
+
+// splitByClass splits raw rates by asset class.
+func splitByClass(rates map[string]float64) map[AssetClass]map[string]float64 {
+	result := make(map[AssetClass]map[string]float64)
+	for code, rate := range rates {
+		class := assetClassOf(strings.ToUpper(code))
+		if result[class] == nil {
+			result[class] = make(map[string]float64)
+		}
+		result[class][code] = rate
+	}
+	return result
+}
+
+// fetchStamp records when an asset class was last fetched, and from where.
+type fetchStamp struct {
+	at       time.Time
+	provider string // Empty when not known, e.g. loaded from the cache file
+}
+
+// ════════════════════════════════════════════════════════════════
+// TTLS
+// ════════════════════════════════════════════════════════════════
+
+// ClassTTL returns how long rates of an asset class stay fresh.
+func (c *RateCache) ClassTTL(class AssetClass) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.classTTL(class)
+}
+
+// SetClassTTL sets how long rates of an asset class stay fresh. A ttl of
+// zero goes back to the cache TTL.
+func (c *RateCache) SetClassTTL(class AssetClass, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl > 0 {
+		c.classTTLs[class] = ttl
+	} else {
+		delete(c.classTTLs, class)
+	}
+}
+
+// ProviderTTL returns how long rates fetched from the named provider stay
+// fresh, or zero if that is up to their asset class.
+func (c *RateCache) ProviderTTL(name string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.providerTTLs[strings.ToLower(name)]
+}
+
+// SetProviderTTL sets how long rates fetched from the named provider stay
+// fresh, overriding their asset class. A ttl of zero clears it.
+func (c *RateCache) SetProviderTTL(name string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name = strings.ToLower(name)
+	if ttl > 0 {
+		c.providerTTLs[name] = ttl
+	} else {
+		delete(c.providerTTLs, name)
+	}
+}
+
+// Expired returns the asset classes whose rates have expired or were never
+// fetched, of those a provider is registered for.
+func (c *RateCache) Expired() []AssetClass {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.expired()
+}
+
+// expired returns the expired asset classes. Callers must hold the lock.
+func (c *RateCache) expired() []AssetClass {
+	var result []AssetClass
+	for _, class := range Fetchable(AssetClasses...) {
+		stamp, ok := c.fetched[class]
+		if !ok || time.Since(stamp.at) > c.stampTTL(class, stamp) {
+			result = append(result, class)
+		}
+	}
+	return result
+}
+
+// classTTL returns the TTL of an asset class. Callers must hold the lock.
+func (c *RateCache) classTTL(class AssetClass) time.Duration {
+	if ttl, ok := c.classTTLs[class]; ok {
+		return ttl
+	}
+	return c.ttl
+}
+
+// stampTTL returns the TTL of an asset class's rates from the fetch
+// recorded in stamp. Callers must hold the lock.
+func (c *RateCache) stampTTL(class AssetClass, stamp fetchStamp) time.Duration {
+	if ttl, ok := c.providerTTLs[stamp.provider]; ok {
+		return ttl
+	}
+	return c.classTTL(class)
+}
+
+// maxTTL returns the longest TTL of any asset class or provider. Callers
+// must hold the lock.
+func (c *RateCache) maxTTL() time.Duration {
+	var longest time.Duration
+	for _, class := range AssetClasses {
+		longest = max(longest, c.classTTL(class))
+	}
+	for _, ttl := range c.providerTTLs {
+		longest = max(longest, ttl)
+	}
+	return longest
+}
@@ -0,0 +1,84 @@
+// pkg/cache/file.go
+
+package cache
+
+import (
+	"os"
+	"time"
+)
+
+// The CLI, the TUI and the server may share one cache directory. Files
+// there are written whole to a temporary file that is then renamed over
+// the old one, so a reader sees either version but never half of one, and
+// writers take an exclusive lock on a lock file beside it, so one doesn't
+// overwrite what another just fetched.
+
+// withFileLock runs fn holding a lock on path's lock file: shared to read
+// path, or exclusive to write it. If the lock file can't be opened, e.g.
+// because the directory doesn't exist yet, fn runs unlocked.
+func withFileLock(path string, exclusive bool, fn func() error) error {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fn()
+	}
+	defer f.Close()
+
+	if err := lockFile(f, exclusive); err != nil {
+		return fn()
+	}
+	defer unlockFile(f)
+
+	return fn()
+}
+
+// fileVersion identifies a version of a file by its size and modification
+// time.
+type fileVersion struct {
+	size    int64
+	modTime time.Time
+}
+
+// versionOf returns the version of the file at path, or false if it
+// doesn't exist.
+func versionOf(path string) (fileVersion, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileVersion{}, false
+	}
+	return fileVersion{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// noteFileVersion records the version of the cache file this cache has
+// just read or written.
+func (c *RateCache) noteFileVersion(path string) {
+	version, _ := versionOf(path)
+	c.mu.Lock()
+	c.fileVersion = version
+	c.mu.Unlock()
+}
+
+// ReloadIfChanged loads the file cache again if another process has
+// written it since this cache last read or wrote it, taking the asset
+// classes that process fetched more recently. It reports whether any
+// rates were loaded.
+func (c *RateCache) ReloadIfChanged() bool {
+	if c.History() != nil {
+		return false
+	}
+
+	path := c.getCachePath()
+	if path == "" {
+		return false
+	}
+	version, ok := versionOf(path)
+	if !ok {
+		return false
+	}
+	c.mu.RLock()
+	seen := c.fileVersion
+	c.mu.RUnlock()
+	if version.size == seen.size && version.modTime.Equal(seen.modTime) {
+		return false
+	}
+	return c.LoadFromFile()
+}
@@ -0,0 +1,93 @@
+// pkg/cache/graph.go
+
+package cache
+
+import (
+	"slices"
+)
+
+// The conversion graph links each currency to those it has a direct rate
+// to. A conversion without a direct rate takes the shortest path through
+// it, found by a breadth-first search from the source currency. Each
+// search builds the tree of shortest paths to every reachable currency,
+// which is kept until the rates change, so a document converting from
+// one currency to many searches once.
+//
+// The graph is guarded by mu: writers hold the write lock, and readers the
+// read lock plus graphMu, as looking up a route may build it.
+
+// findPath finds the shortest conversion path between two currencies and
+// the rate along it. Callers must hold the read lock.
+func (c *RateCache) findPath(from, to string) ([]string, float64, bool) {
+	tree := c.routes(from)
+	if _, ok := tree[to]; !ok {
+		return nil, 0, false
+	}
+
+	var path []string
+	for cur := to; cur != ""; cur = tree[cur] {
+		path = append(path, cur)
+	}
+	slices.Reverse(path)
+
+	rate := 1.0
+	for i := 1; i < len(path); i++ {
+		rate *= c.rates[ratePair{From: path[i-1], To: path[i]}]
+	}
+	return path, rate, true
+}
+
+// routes returns the shortest-path tree from a currency: each currency it
+// reaches, mapped to the one before it on the way ("" for from itself).
+// Callers must hold the read lock.
+func (c *RateCache) routes(from string) map[string]string {
+	c.graphMu.Lock()
+	defer c.graphMu.Unlock()
+
+	if tree, ok := c.trees[from]; ok {
+		return tree
+	}
+	if c.adjacent == nil {
+		c.adjacent = c.buildAdjacency()
+		c.trees = make(map[string]map[string]string)
+	}
+
+	tree := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range c.adjacent[cur] {
+			if _, ok := tree[next]; !ok {
+				tree[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	c.trees[from] = tree
+	return tree
+}
+
+// buildAdjacency lists the currencies each currency has a direct rate to,
+// sorted so that ties between equally short paths break the same way
+// every time.
+func (c *RateCache) buildAdjacency() map[string][]string {
+	adjacent := make(map[string][]string)
+	for pair := range c.rates {
+		if pair.From != pair.To {
+			adjacent[pair.From] = append(adjacent[pair.From], pair.To)
+		}
+	}
+	for _, next := range adjacent {
+		slices.Sort(next)
+	}
+	return adjacent
+}
+
+// invalidateGraph drops the conversion graph after the rates change.
+// Callers must hold the write lock.
+func (c *RateCache) invalidateGraph() {
+	c.adjacent = nil
+	c.trees = nil
+}
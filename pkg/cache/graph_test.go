@@ -0,0 +1,53 @@
+// pkg/cache/graph_test.go
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// conversionPairs are the pairs a document with many conversions looks
+// up: every currency to a handful of others, none of them direct.
+func conversionPairs(c *RateCache) [][2]string {
+	var pairs [][2]string
+	for _, cur := range types.AllCurrencies() {
+		for _, to := range []string{"EUR", "JPY", "GBP", "CHF"} {
+			if _, direct := c.rates[ratePair{From: cur.Code, To: to}]; !direct && cur.Code != to && c.HasRate(cur.Code, to) {
+				pairs = append(pairs, [2]string{cur.Code, to})
+			}
+		}
+	}
+	return pairs
+}
+
+func BenchmarkGetRate(b *testing.B) {
+	c := NewSandboxed()
+	pairs := conversionPairs(c)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, p := range pairs {
+			c.GetRate(p[0], p[1])
+		}
+	}
+	b.ReportMetric(float64(len(pairs)), "lookups/op")
+}
+
+// BenchmarkGetRateUncached drops the graph before each lookup, so every
+// lookup searches, as before the graph was kept: the gain from keeping it
+// is the difference from BenchmarkGetRate.
+func BenchmarkGetRateUncached(b *testing.B) {
+	c := NewSandboxed()
+	pairs := conversionPairs(c)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, p := range pairs {
+			c.mu.Lock()
+			c.invalidateGraph()
+			c.mu.Unlock()
+			c.GetRate(p[0], p[1])
+		}
+	}
+	b.ReportMetric(float64(len(pairs)), "lookups/op")
+}
@@ -0,0 +1,195 @@
+// pkg/cache/history.go
+
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// DefaultHistoryFile is the rate history database in the cache directory.
+const DefaultHistoryFile = "rates.db"
+
+// historySchema creates the history table. Rows are only ever added: each
+// is one currency's rate in a fetch, as units per US dollar, so any two
+// currencies fetched at the same time give a cross rate.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS rates (
+	code       TEXT    NOT NULL,
+	per_usd    REAL    NOT NULL,
+	fetched_at INTEGER NOT NULL,
+	provider   TEXT    NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS rates_code_time ON rates (code, fetched_at);`
+
+// History is an append-only SQLite record of fetched rates. Unlike the
+// JSON cache file, which keeps only the latest fetch, it answers what a
+// rate was at a past time and how it moved. Several processes may share
+// one database.
+type History struct {
+	db *sql.DB
+}
+
+// RatePoint is a rate at a point in time.
+type RatePoint struct {
+	Time time.Time
+	Rate float64
+}
+
+// OpenHistory opens the history database at path, creating it if needed.
+func OpenHistory(path string) (*History, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	// WAL lets readers work while another process records a fetch, and
+	// the busy timeout waits out its write lock instead of failing
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("rate history %s: %w", path, err)
+	}
+	return &History{db: db}, nil
+}
+
+// DefaultHistoryPath returns the path of the rate history database in the
+// cache directory, or "" if there is no home directory.
+func DefaultHistoryPath() string {
+	dir := getCacheDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, DefaultHistoryFile)
+}
+
+// Close closes the database.
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+// Record appends the rates of one fetch, given as units per US dollar.
+func (h *History) Record(at time.Time, provider string, perUSD map[string]float64) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO rates (code, per_usd, fetched_at, provider) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for code, rate := range perUSD {
+		if rate <= 0 {
+			continue
+		}
+		if _, err := stmt.Exec(strings.ToUpper(code), rate, at.Unix(), provider); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Latest returns each currency's most recent rate, as units per US
+// dollar, among those fetched since the given time, and when the newest
+// was fetched. It is empty if nothing was fetched since.
+func (h *History) Latest(since time.Time) (map[string]float64, time.Time, error) {
+	rows, err := h.db.Query(`
+		SELECT r.code, r.per_usd, r.fetched_at FROM rates r
+		JOIN (SELECT code, MAX(fetched_at) AS at FROM rates WHERE fetched_at >= ? GROUP BY code) latest
+		ON r.code = latest.code AND r.fetched_at = latest.at`, since.Unix())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	perUSD := make(map[string]float64)
+	var newest int64
+	for rows.Next() {
+		var code string
+		var rate float64
+		var at int64
+		if err := rows.Scan(&code, &rate, &at); err != nil {
+			return nil, time.Time{}, err
+		}
+		perUSD[code] = rate
+		newest = max(newest, at)
+	}
+	if len(perUSD) == 0 {
+		return perUSD, time.Time{}, rows.Err()
+	}
+	return perUSD, time.Unix(newest, 0), rows.Err()
+}
+
+// Series returns the rate from one currency to another each time either
+// was fetched since the given time, oldest first. Each point pairs the
+// latest rates of both currencies at that time.
+func (h *History) Series(from, to string, since time.Time) ([]RatePoint, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	rows, err := h.db.Query(`
+		SELECT t.at,
+			(SELECT per_usd FROM rates WHERE code = ? AND fetched_at <= t.at ORDER BY fetched_at DESC LIMIT 1),
+			(SELECT per_usd FROM rates WHERE code = ? AND fetched_at <= t.at ORDER BY fetched_at DESC LIMIT 1)
+		FROM (SELECT DISTINCT fetched_at AS at FROM rates WHERE code IN (?, ?) AND fetched_at >= ?) t
+		ORDER BY t.at`, from, to, from, to, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []RatePoint
+	for rows.Next() {
+		var at int64
+		var fromUSD, toUSD sql.NullFloat64
+		if err := rows.Scan(&at, &fromUSD, &toUSD); err != nil {
+			return nil, err
+		}
+		if !fromUSD.Valid || !toUSD.Valid || fromUSD.Float64 == 0 {
+			continue
+		}
+		points = append(points, RatePoint{Time: time.Unix(at, 0), Rate: toUSD.Float64 / fromUSD.Float64})
+	}
+	return points, rows.Err()
+}
+
+// perUSD converts raw rates to units per US dollar. Fiat rates already
+// are; crypto and metal rates are prices in dollars.
+func perUSD(raw map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(raw)+1)
+	result["USD"] = 1
+	for code, rate := range raw {
+		code = strings.ToUpper(code)
+		if (types.IsCrypto(code) || types.IsMetal(code)) && rate != 0 {
+			rate = 1 / rate
+		}
+		result[code] = rate
+	}
+	return result
+}
+
+// rawRates converts rates per US dollar back to raw rates.
+func rawRates(perUSD map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(perUSD))
+	for code, rate := range perUSD {
+		if code == "USD" {
+			continue
+		}
+		if (types.IsCrypto(code) || types.IsMetal(code)) && rate != 0 {
+			rate = 1 / rate
+		}
+		result[code] = rate
+	}
+	return result
+}
@@ -0,0 +1,18 @@
+// pkg/cache/lock_other.go
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package cache
+
+import "os"
+
+// lockFile does nothing where file locks aren't supported. Writes are
+// still atomic, so readers never see half a file.
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// unlockFile does nothing where file locks aren't supported.
+func unlockFile(f *os.File) error {
+	return nil
+}
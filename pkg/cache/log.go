@@ -0,0 +1,91 @@
+// pkg/cache/log.go
+
+package cache
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/0xsj/numio/internal/fetch"
+)
+
+// discardLogger is the logger of caches given none.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// SetLogger logs refreshes to l: rates fetched, refreshes that fail, and
+// the cached or built-in rates kept in their place. Failures to read or
+// write the cache file and rate history, which don't stop the cache
+// working, are logged too. nil turns logging off.
+func (c *RateCache) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = discardLogger
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = l
+}
+
+// log returns the cache's logger.
+func (c *RateCache) log() *slog.Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logger
+}
+
+// SetProviderLogger logs each rate provider's fetches, and falling back
+// from one provider to the next, to l. The providers are shared by every
+// cache in the process. nil turns logging off.
+func SetProviderLogger(l *slog.Logger) {
+	fetch.Default().SetLogger(l)
+}
+
+// logRefresh logs the outcome of a refresh.
+func (c *RateCache) logRefresh(count int, err error) {
+	switch {
+	case errors.Is(err, ErrSandboxed):
+		c.log().Debug("rate refresh skipped", "err", err)
+	case err != nil:
+		c.log().Warn("rate refresh failed", "err", err)
+	default:
+		c.log().Info("rates refreshed", "count", count)
+	}
+}
+
+// Fallbacks: the rates a cache goes on using for an asset class it could
+// not fetch.
+const (
+	FallbackCached  = "cached"   // Those fetched before
+	FallbackBuiltIn = "built-in" // The built-in ones, none having been fetched
+)
+
+// fellBack logs that class could not be fetched, and returns which rates
+// of it the cache goes on using (FallbackCached or FallbackBuiltIn).
+func (c *RateCache) fellBack(class AssetClass, err error) string {
+	c.mu.RLock()
+	stamp, ok := c.fetched[class]
+	logger := c.logger
+	c.mu.RUnlock()
+
+	if !ok {
+		logger.Warn("using built-in rates", "class", class.String(), "err", err)
+		return FallbackBuiltIn
+	}
+	logger.Warn("using cached rates", "class", class.String(),
+		"age", time.Since(stamp.at).Round(time.Second), "err", err)
+	return FallbackCached
+}
+
+// save saves the file cache, logging a failure.
+func (c *RateCache) save() {
+	if err := c.SaveToFile(); err != nil {
+		c.log().Warn("cannot save rate cache", "path", c.getCachePath(), "err", err)
+	}
+}
+
+// record records fetched rates in history, logging a failure.
+func (c *RateCache) record(history *History, at time.Time, provider string, perUSD map[string]float64) {
+	if err := history.Record(at, provider, perUSD); err != nil {
+		c.log().Warn("cannot record rate history", "provider", provider, "err", err)
+	}
+}
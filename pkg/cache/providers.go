@@ -0,0 +1,66 @@
+// pkg/cache/providers.go
+
+package cache
+
+import (
+	"time"
+
+	"github.com/0xsj/numio/internal/fetch"
+)
+
+// ProviderStatus is the health of a rate provider. A provider failing
+// several times in a row is skipped for a while, then tried again; the
+// wait grows while it keeps failing.
+type ProviderStatus struct {
+	Name        string
+	Type        string // fiat, crypto or metal
+	Available   bool   // Has the API key it needs, if any
+	Disabled    bool   // Never called (see the disabled_providers setting)
+	Source      bool   // The only provider used for its type (see the fiat_source setting)
+	State       string // closed (in use), open (being skipped) or half-open (tried on the next fetch)
+	Successes   int
+	Failures    int
+	Streak      int       // Failures in a row
+	LastSuccess time.Time // Zero if none
+	LastFailure time.Time // Zero if none
+	LastError   string    // Of the latest failure, "" if none
+	RetryAt     time.Time // When a skipped provider is tried again
+}
+
+// Skipped reports whether the provider is being skipped.
+func (s ProviderStatus) Skipped() bool {
+	return s.State == fetch.CircuitOpen.String()
+}
+
+// ProviderStatuses returns the health of every rate provider, which all
+// caches in the process share, in priority order within each type.
+func ProviderStatuses() []ProviderStatus {
+	var statuses []ProviderStatus
+	for _, s := range fetch.Default().Status() {
+		status := ProviderStatus{
+			Name:        s.Name,
+			Type:        s.Type.String(),
+			Available:   s.Available,
+			Disabled:    s.Disabled,
+			Source:      s.Source,
+			State:       s.State.String(),
+			Successes:   s.Successes,
+			Failures:    s.Failures,
+			Streak:      s.Streak,
+			LastSuccess: s.LastSuccess,
+			LastFailure: s.LastFailure,
+			RetryAt:     s.RetryAt,
+		}
+		if s.LastError != nil {
+			status.LastError = s.LastError.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// ResetProviderHealth forgets the named provider's failures, so it is no
+// longer skipped, or every provider's if name is empty.
+func ResetProviderHealth(name string) {
+	fetch.Default().ResetHealth(name)
+}
@@ -0,0 +1,9 @@
+// pkg/cache/sqlite.go
+
+//go:build !(js && wasm)
+
+package cache
+
+// The SQLite driver doesn't build for WebAssembly, where OpenHistory
+// fails for want of it.
+import _ "modernc.org/sqlite" // SQLite driver
@@ -0,0 +1,81 @@
+// pkg/engine/bench_test.go
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// mixedLines are conversions, assignments and function calls, as a
+// document evaluates them.
+var mixedLines = []string{
+	"price = $120", "price * 1.2", "5 km in mi", "sqrt(16) + 2^3",
+	"20% of price", "3 ft 2 in to cm", "max(4, 9, 2)",
+}
+
+func BenchmarkEvalArithmetic(b *testing.B) {
+	e := NewSandboxed()
+	b.ReportAllocs()
+	for b.Loop() {
+		e.Eval("2 + 3 * 4")
+	}
+}
+
+func BenchmarkEvalMixed(b *testing.B) {
+	e := NewSandboxed()
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, line := range mixedLines {
+			e.Eval(line)
+		}
+	}
+}
+
+// conversionDocument returns a document of n lines, each converting the
+// line before it to another currency, so each depends on the last.
+func conversionDocument(n int) string {
+	currencies := []string{"EUR", "JPY", "GBP", "CHF", "INR", "BRL", "KRW", "MXN", "ZAR", "SEK"}
+	lines := []string{"v0 = 100 USD"}
+	for i := 1; i < n; i++ {
+		lines = append(lines, fmt.Sprintf("v%d = v%d in %s + 1 %s", i, i-1,
+			currencies[i%len(currencies)], currencies[(i+3)%len(currencies)]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func BenchmarkEvalConversionDocument(b *testing.B) {
+	e := NewSandboxed()
+	doc := conversionDocument(500)
+	for i, v := range e.EvalFile(doc) {
+		if v.IsError() {
+			b.Fatalf("line %d: %s", i+1, v.ErrorMessage())
+		}
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		e.Clear()
+		e.EvalFile(doc)
+	}
+}
+
+// TestEvalAllocations guards the allocations per Eval the benchmarks
+// measure. Growing the line history and the parser's node chunks, each
+// shared by many lines, account for what remains.
+func TestEvalAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	e := NewSandboxed()
+	if n := testing.AllocsPerRun(1000, func() { e.Eval("2 + 3 * 4") }); n > 1 {
+		t.Errorf("2 + 3 * 4: %v allocations per Eval, want at most 1", n)
+	}
+	if n := testing.AllocsPerRun(200, func() {
+		for _, line := range mixedLines {
+			e.Eval(line)
+		}
+	}); n > 48 {
+		t.Errorf("mixed lines: %v allocations, want at most 48", n)
+	}
+}
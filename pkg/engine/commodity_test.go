@@ -0,0 +1,24 @@
+// pkg/engine/commodity_test.go
+
+package engine
+
+import "testing"
+
+func TestOilNamesWTI(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"3 oil", "3 WTI"},
+		{"3 crude oil", "3 WTI"},
+		{"100 bbl oil", "100 WTI"},
+		{"100 barrels of crude oil", "100 WTI"},
+		// Oil in a kitchen measure is the ingredient
+		{"2 cups oil", "2 cup oil"},
+		{"1 tbsp oil in g", "13.6 g oil"},
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// pkg/engine/completion.go
+
+package engine
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/0xsj/numio/internal/eval"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// CompletionKind identifies what a completion refers to.
+type CompletionKind string
+
+const (
+	CompleteVariable CompletionKind = "variable"
+	CompleteFunction CompletionKind = "function"
+	CompleteCurrency CompletionKind = "currency"
+	CompleteCrypto   CompletionKind = "crypto"
+	CompleteMetal    CompletionKind = "metal"
+	CompleteUnit     CompletionKind = "unit"
+	CompleteConstant CompletionKind = "constant"
+)
+
+// Completion is a single completion candidate.
+type Completion struct {
+	Label  string         `json:"label"`
+	Kind   CompletionKind `json:"kind"`
+	Detail string         `json:"detail,omitempty"` // A variable's description
+}
+
+// Complete returns completion candidates for the word ending at the end of
+// input: variables, functions, constants, currencies, cryptos, metals, and units (codes
+// and plural names) whose names start with it (case-insensitive). Variables
+// come first, then candidates are sorted by label.
+func (e *Engine) Complete(input string) []Completion {
+	prefix := strings.ToLower(CompletionPrefix(input))
+	if prefix == "" {
+		return nil
+	}
+
+	var out []Completion
+	seen := make(map[string]bool)
+	add := func(label string, kind CompletionKind) {
+		if seen[label] || !strings.HasPrefix(strings.ToLower(label), prefix) {
+			return
+		}
+		seen[label] = true
+		out = append(out, Completion{Label: label, Kind: kind})
+	}
+
+	for _, name := range e.VariableNames() {
+		add(name, CompleteVariable)
+	}
+	vars := len(out)
+	for i := range out {
+		out[i].Detail = e.Description(out[i].Label)
+	}
+
+	for _, name := range eval.FunctionNames() {
+		add(name, CompleteFunction)
+	}
+	for _, name := range types.ConstantNames() {
+		add(name, CompleteConstant)
+	}
+	for _, code := range types.CurrencyCodes() {
+		add(code, CompleteCurrency)
+	}
+	for _, code := range types.CryptoCodes() {
+		add(code, CompleteCrypto)
+	}
+	for _, code := range types.MetalCodes() {
+		add(code, CompleteMetal)
+	}
+	for _, code := range types.UnitCodes() {
+		add(code, CompleteUnit)
+	}
+	for _, u := range types.AllUnits() {
+		if u.Plural != "" && !strings.Contains(u.Plural, " ") {
+			add(u.Plural, CompleteUnit)
+		}
+	}
+	for _, u := range e.Units().Units() {
+		add(u.Code, CompleteUnit)
+		if u.Plural != "" && !strings.Contains(u.Plural, " ") {
+			add(u.Plural, CompleteUnit)
+		}
+	}
+
+	sort.SliceStable(out[:vars], func(i, j int) bool { return out[i].Label < out[j].Label })
+	rest := out[vars:]
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].Label < rest[j].Label })
+	return out
+}
+
+// CompletionPrefix returns the partial word at the end of input.
+// Leading digits are skipped, so "5k" completes units starting with "k".
+func CompletionPrefix(input string) string {
+	end := len(input)
+	start := end
+	for start > 0 {
+		r := rune(input[start-1])
+		if r >= 0x80 || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			break
+		}
+		start--
+	}
+	for start < end && input[start] >= '0' && input[start] <= '9' {
+		start++
+	}
+	return input[start:end]
+}
@@ -0,0 +1,61 @@
+// pkg/engine/constant_test.go
+
+package engine
+
+import "testing"
+
+func TestAstronomicalUnits(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"1 au in km", "149597870.7 km"},
+		{"2 astronomical units in m", "299195741400 m"},
+		{"1 lightyear in km", "9460730472580.8 km"},
+		{"1 light year in au", "63241.08 au"},
+		{"4.2 ly in km", "39735067984839.36 km"},
+		{"au in km", "149597870.7 km"},
+		{"lightyear / c", "365.25 d"},
+		{"1 xau", "1 XAU"}, // Gold by its code, not "au"
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestConstantsKeepUnits(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"G", "6.6743e-11 m³/(kg·s²)"},
+		{"avogadro", "6.022141e23 1/mol"},
+		{"boltzmann", "1.380649e-23 J/K"},
+		{"atm", "101325 Pa"},
+		{"3 m * g", "29.42 m²/s²"},
+		{"3 kg * g", "29.42 N"},
+		{"3 m * 2 m", "6 sqm"},
+		{"G * 5.972e24 kg / (6371 km * 6371 km)", "9.82 m/s²"},
+		{"10 J / 2 K", "5 kg·m²/(s²·K)"},
+		{"6 m / 3 m", "2"},
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestShortNamesAreNotConstants(t *testing.T) {
+	e := NewSandboxed()
+
+	for _, line := range []string{"R = 2", "me = 3", "mp = 4"} {
+		e.Eval(line)
+	}
+	if got := e.Format(e.Eval("R * me * mp")); got != "24" {
+		t.Errorf("R * me * mp = %q, want %q", got, "24")
+	}
+	if got := e.Format(e.Eval("gas_constant")); got != "8.31 J/(mol·K)" {
+		t.Errorf("gas_constant = %q, want %q", got, "8.31 J/(mol·K)")
+	}
+}
@@ -0,0 +1,80 @@
+// pkg/engine/conversion.go
+
+package engine
+
+import (
+	"time"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Conversion describes a conversion made by an evaluated line.
+type Conversion struct {
+	From types.Value // Value converted
+	To   types.Value // Converted value
+
+	// Exchange rate details, for currency, crypto and metal conversions
+	Rate   float64       // Rate from From to To
+	Path   []string      // Codes the rate was found through, From and To included
+	Pinned bool          // A rate on the path was set explicitly
+	Age    time.Duration // Age of the oldest rate on the path, zero for built-in ones
+}
+
+// HasRate reports whether the conversion used an exchange rate.
+func (c Conversion) HasRate() bool {
+	return len(c.Path) > 1
+}
+
+// LastConversion returns the conversion made by the last evaluated line,
+// if that line was one ("$100 in EUR", "in EUR", "5 km to mi").
+func (e *Engine) LastConversion() (Conversion, bool) {
+	lines := e.Lines()
+	if len(lines) == 0 {
+		return Conversion{}, false
+	}
+	lr := lines[len(lines)-1]
+	if lr.Converted.IsEmpty() || lr.Value.IsError() {
+		return Conversion{}, false
+	}
+	return e.conversion(lr.Converted, lr.Value), true
+}
+
+// conversion describes converting from to to, with the rate the cache
+// used when both are priced in rates.
+func (e *Engine) conversion(from, to types.Value) Conversion {
+	c := Conversion{From: from, To: to}
+
+	fromCode, toCode := rateCode(from), rateCode(to)
+	if fromCode == "" || toCode == "" || fromCode == toCode {
+		return c
+	}
+	path, rate, ok := e.rateCache.RatePath(fromCode, toCode)
+	if !ok {
+		return c
+	}
+
+	c.Rate, c.Path = rate, path
+	for i := 1; i < len(path); i++ {
+		if e.rateCache.IsPinned(path[i-1], path[i]) {
+			c.Pinned = true
+		}
+	}
+	if at := e.rateCache.RateTime(fromCode, toCode); !at.IsZero() {
+		c.Age = time.Since(at)
+	}
+	return c
+}
+
+// rateCode returns the code a value is priced under in the rate cache,
+// or "" for values without rates.
+func rateCode(v types.Value) string {
+	switch {
+	case v.IsCurrency() && v.Curr != nil:
+		return v.Curr.Code
+	case v.IsCrypto() && v.Crypto != nil:
+		return v.Crypto.Code
+	case v.IsMetal() && v.Metal != nil:
+		return v.Metal.Code
+	}
+	return ""
+}
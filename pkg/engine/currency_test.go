@@ -0,0 +1,51 @@
+// pkg/engine/currency_test.go
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+func TestSharedSymbolHasOneOwner(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"1000 JPY", "¥1000"},
+		{"1000 CNY", "1000.00 CNY"},
+		{"¥1000", "¥1000"},
+		{"100 SEK", "100.00 SEK"},
+		{"100 NOK", "100.00 NOK"},
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormattedCurrencyReadsBack(t *testing.T) {
+	e := NewSandboxed()
+
+	for _, c := range types.AllCurrencies() {
+		v := types.CurrencyValue(1234, types.ParseCurrency(c.Code))
+		s := e.Format(v)
+		back := e.Eval(s)
+		if back.Curr == nil || back.Curr.Code != c.Code || back.Num != v.Num {
+			t.Errorf("%s: %q reads back as %q", c.Code, s, e.Format(back))
+		}
+	}
+}
+
+func TestLetterSymbolKeepsIdentifiers(t *testing.T) {
+	e := NewSandboxed()
+
+	// ZAR's symbol is "R"
+	if got := e.Format(e.Eval("Rate = 5")); got != "5" {
+		t.Errorf("Rate = 5 gives %q", got)
+	}
+	if got := e.Format(e.Eval("Rate * 2")); got != "10" {
+		t.Errorf("Rate * 2 = %q, want 10", got)
+	}
+}
@@ -0,0 +1,22 @@
+// pkg/engine/date_test.go
+
+package engine
+
+import "testing"
+
+func TestBusinessDaysBetweenDatesWithYear(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"business days between Mar 1, 2026 and Apr 15, 2026", "33 bd"},
+		{"business days between Mar 1, 2025 and Apr 15, 2025", "32 bd"},
+		{"business days between Mar 1 2025 and Apr 15 2025", "32 bd"},
+		{"business days between 2025-03-01 and 2025-04-15", "32 bd"},
+		{"Mar 1, 2025 + 3 days", "Tue Mar 4, 2025"},
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// pkg/engine/diff.go
+
+package engine
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/internal/parser"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Snapshot is a document's results as evaluated at one point, to compare
+// with another using Diff.
+type Snapshot struct {
+	Lines []SnapshotLine
+	Total types.Value
+}
+
+// SnapshotLine is a line of a snapshot and its result.
+type SnapshotLine struct {
+	Line  int // Line number, from 1
+	Input string
+	Value types.Value // Empty for blank lines, comments and settings
+	Name  string      // Variable the line assigns, if any
+
+	key string // What Diff matches the line by
+}
+
+// Snapshot evaluates a document as EvalFile would on a cleared engine and
+// returns its results. The engine is left as it was; the snapshot is
+// taken on a copy with the same settings, rates and inputs.
+func (e *Engine) Snapshot(content string) Snapshot {
+	clone := e.Clone()
+	clone.Clear()
+	results := clone.EvalFile(content)
+
+	locale := clone.InputLocale()
+	s := Snapshot{Total: clone.Total()}
+	for i, input := range strings.Split(content, "\n") {
+		l := SnapshotLine{Line: i + 1, Input: input, Value: results[i]}
+		l.Name, l.key = lineKey(input, locale)
+		s.Lines = append(s.Lines, l)
+	}
+	return s
+}
+
+// lineKey returns the variable a line assigns, if any, and what Diff
+// matches it by: the variable, or else the line formatted without its
+// comment, so respacing a line or editing its comment keeps it matched.
+func lineKey(input string, locale types.InputLocale) (name, key string) {
+	if in, ok := ParseInput(input); ok {
+		return in.Name, "=" + in.Name
+	}
+	line, errs := parser.ParseLineWithLocale(input, locale)
+	if len(errs) > 0 {
+		return "", strings.Join(strings.Fields(input), " ")
+	}
+	if a, ok := line.Stmt.(*ast.AssignStmt); ok {
+		return a.Name, "=" + a.Name
+	}
+	line.Comment = ""
+	return "", parser.Format(line, parser.FormatOptions{Locale: locale})
+}
+
+// ChangeKind is how a line's result differs between two snapshots.
+type ChangeKind int
+
+const (
+	LineUnchanged ChangeKind = iota
+	LineChanged              // The line is in both, with different results
+	LineAdded                // The line is only in the later snapshot
+	LineRemoved              // The line is only in the earlier snapshot
+)
+
+// String returns the change kind name.
+func (k ChangeKind) String() string {
+	switch k {
+	case LineUnchanged:
+		return "unchanged"
+	case LineChanged:
+		return "changed"
+	case LineAdded:
+		return "added"
+	case LineRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a line whose result differs between two snapshots. Before is
+// the zero SnapshotLine for added lines, as After is for removed ones.
+type Change struct {
+	Kind          ChangeKind
+	Before, After SnapshotLine
+}
+
+// Delta returns how much the result went up or down: after - before. It
+// is only known for amounts of the same kind and unit, currency, metal
+// or crypto, as $100 and $120.
+func (c Change) Delta() (types.Value, bool) {
+	a, b := c.Before.Value, c.After.Value
+	if !a.IsNumeric() || !b.IsNumeric() || a.Kind != b.Kind || a.Big != nil || b.Big != nil ||
+		a.Curr != b.Curr || a.Unit != b.Unit || a.Metal != b.Metal || a.Karat != b.Karat || a.Crypto != b.Crypto {
+		return types.Value{}, false
+	}
+	return b.Nominal().WithAmount(b.Num - a.Num), true
+}
+
+// Percent returns the delta as a percentage of the earlier result: +8%.
+// It is unknown when the delta is unknown or the earlier result was zero.
+func (c Change) Percent() (types.Value, bool) {
+	delta, ok := c.Delta()
+	if !ok || c.Before.Value.Num == 0 {
+		return types.Value{}, false
+	}
+	before := c.Before.Value.Num
+	if before < 0 {
+		before = -before
+	}
+	return types.Percentage(delta.Num / before), true
+}
+
+// Difference is how a document's results changed between two snapshots.
+type Difference struct {
+	Changes []Change // Lines changed, added or removed, in document order
+	Total   Change   // The document total; LineUnchanged if it stayed the same
+}
+
+// Diff compares two snapshots of a document, which may have been edited
+// between them. Lines are matched by the variable they assign or, if
+// they assign none, by their text; lines left over between matches are
+// paired in order, and the rest were added or removed. Lines without a
+// result are skipped.
+func Diff(before, after Snapshot) Difference {
+	a, b := resultLines(before), resultLines(after)
+
+	var d Difference
+	i, j := 0, 0
+	for _, m := range matchLines(a, b) {
+		d.pair(a[i:m[0]], b[j:m[1]])
+		d.compare(a[m[0]], b[m[1]])
+		i, j = m[0]+1, m[1]+1
+	}
+	d.pair(a[i:], b[j:])
+
+	d.Total = Change{Before: SnapshotLine{Value: before.Total}, After: SnapshotLine{Value: after.Total}}
+	if !sameValue(before.Total, after.Total) {
+		d.Total.Kind = LineChanged
+	}
+	return d
+}
+
+// compare records a change if two matched lines' results differ.
+func (d *Difference) compare(before, after SnapshotLine) {
+	if !sameValue(before.Value, after.Value) {
+		d.Changes = append(d.Changes, Change{Kind: LineChanged, Before: before, After: after})
+	}
+}
+
+// pair compares unmatched lines in order, one from each side, recording
+// those left over as removed or added.
+func (d *Difference) pair(before, after []SnapshotLine) {
+	n := min(len(before), len(after))
+	for k := range n {
+		d.compare(before[k], after[k])
+	}
+	for _, l := range before[n:] {
+		d.Changes = append(d.Changes, Change{Kind: LineRemoved, Before: l})
+	}
+	for _, l := range after[n:] {
+		d.Changes = append(d.Changes, Change{Kind: LineAdded, After: l})
+	}
+}
+
+// resultLines returns the lines of a snapshot that have a result.
+func resultLines(s Snapshot) []SnapshotLine {
+	var lines []SnapshotLine
+	for _, l := range s.Lines {
+		if !l.Value.IsEmpty() {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// keys returns what each line is matched by. Lines not from Snapshot
+// are matched by the variable they assign or their text.
+func keys(lines []SnapshotLine) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		switch {
+		case l.key != "":
+			out[i] = l.key
+		case l.Name != "":
+			out[i] = "=" + l.Name
+		default:
+			out[i] = strings.Join(strings.Fields(l.Input), " ")
+		}
+	}
+	return out
+}
+
+// matchLines returns the longest run of lines of a and b, in order, with
+// the same keys, as pairs of indexes.
+func matchLines(a, b []SnapshotLine) [][2]int {
+	ka, kb := keys(a), keys(b)
+
+	// lcs[i][j] is the length of the longest match of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if ka[i] == kb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var matches [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case ka[i] == kb[j]:
+			matches = append(matches, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// sameValue reports whether two results are the same.
+func sameValue(a, b types.Value) bool {
+	if a.IsError() || b.IsError() {
+		return a.IsError() && b.IsError() && a.ErrorMessage() == b.ErrorMessage()
+	}
+	return reflect.DeepEqual(a, b)
+}
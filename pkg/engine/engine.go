@@ -5,7 +5,14 @@ package engine
 
 import (
 	"context"
+	"io"
+	"log/slog"
+	"maps"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/0xsj/numio/internal/ast"
 	"github.com/0xsj/numio/internal/eval"
@@ -16,9 +23,30 @@ import (
 )
 
 // Engine is the main entry point for numio calculations.
+//
+// An Engine is safe for concurrent use. Evaluations run one at a time,
+// as each line is numbered and can use the ones before it; settings,
+// variables and rates may be read and changed meanwhile. Lines evaluated
+// from several goroutines are interleaved in no particular order, so a
+// document should be evaluated from one, with EvalMultiple or, to spread
+// its independent lines across CPUs, EvalParallel.
 type Engine struct {
+	mu        sync.Mutex // Held while evaluating
 	evaluator *eval.Evaluator
 	rateCache *cache.RateCache
+
+	importDir string   // Where imports resolve; "" turns them off
+	importing []string // Files being imported, outermost first
+
+	inputs map[string]types.Value // Values of input lines, by name
+
+	limitsMu    sync.Mutex    // Guards evalTimeout and stepLimit
+	evalTimeout time.Duration // Longest a line may take; 0 = no limit
+	stepLimit   int           // Most expressions a line may evaluate; 0 = no limit
+
+	hooksMu sync.Mutex   // Guards hooks and logger
+	hooks   hooks        // Functions called as lines evaluate and variables change
+	logger  *slog.Logger // Where limited lines are logged; nil = nowhere
 }
 
 // New creates a new Engine with default settings.
@@ -47,6 +75,21 @@ func NewWithCache(rc *cache.RateCache) *Engine {
 	}
 }
 
+// NewSandboxed creates an Engine that never touches the filesystem or the
+// network, for restricted environments such as WASM or serverless
+// functions. Its rate cache (see cache.NewSandboxed) holds the built-in
+// rates and those set with SetRate or ApplyRawRates, refreshing rates
+// fails, and imports stay off.
+func NewSandboxed() *Engine {
+	return NewWithCache(cache.NewSandboxed())
+}
+
+// IsSandboxed reports whether the engine's rate cache is sandboxed, which
+// keeps the engine from touching the filesystem or the network.
+func (e *Engine) IsSandboxed() bool {
+	return e.rateCache.IsSandboxed()
+}
+
 // rateCacheAdapter adapts pkg/cache.RateCache to the interface expected by eval.
 type rateCacheAdapter struct {
 	rc *cache.RateCache
@@ -64,26 +107,87 @@ func (a *rateCacheAdapter) ConvertValue(v types.Value, target string) (types.Val
 	return a.rc.ConvertValue(v, target)
 }
 
+func (a *rateCacheAdapter) RateTime(from, to string) time.Time {
+	return a.rc.RateTime(from, to)
+}
+
+func (a *rateCacheAdapter) ConvertValueAt(v types.Value, target string, date time.Time) (types.Value, error) {
+	return a.rc.ConvertValueAt(v, target, date)
+}
+
+func (a *rateCacheAdapter) RateSeries(from, to string, since time.Time) ([]float64, error) {
+	points, err := a.rc.Series(from, to, since)
+	if err != nil {
+		return nil, err
+	}
+	rates := make([]float64, len(points))
+	for i, p := range points {
+		rates[i] = p.Rate
+	}
+	return rates, nil
+}
+
 // ════════════════════════════════════════════════════════════════
 // CORE EVALUATION
 // ════════════════════════════════════════════════════════════════
 
 // Eval evaluates a single line of input and returns the result.
 func (e *Engine) Eval(input string) types.Value {
+	return e.EvalContext(context.Background(), input)
+}
+
+// EvalContext evaluates a line like Eval, but stops once ctx is done,
+// with an error result. Lines are also stopped when they run over the
+// engine's EvalTimeout or StepLimit.
+func (e *Engine) EvalContext(ctx context.Context, input string) types.Value {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	results := []types.Value{e.eval(ctx, input)}
+	e.applyShares(results)
+	return results[0]
+}
+
+// eval evaluates a line within its budget. Callers must hold mu.
+func (e *Engine) eval(bctx context.Context, input string) (v types.Value) {
+	defer e.startBudget(bctx, e.evaluator).stop()
+	defer func() {
+		if v.Limited {
+			e.logLimited(v, input)
+		}
+	}()
+
+	ctx := e.evaluator.Context()
+	if errVal, ok := ctx.CheckInput(input); ok {
+		ctx.SkipLine()
+		return errVal
+	}
+
 	// Skip empty lines
 	trimmed := strings.TrimSpace(input)
 	if trimmed == "" {
+		ctx.SkipLine()
 		return types.Empty()
 	}
 
 	// Skip comment-only lines
 	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		ctx.SkipLine()
 		return types.Empty()
 	}
 
+	if path, ok := ImportPath(trimmed); ok {
+		ctx.SkipLine()
+		return e.importFile(path)
+	}
+	if in, ok := ParseInput(trimmed); ok {
+		return e.evalInput(in, input)
+	}
+
 	// Parse and evaluate
-	line, errs := parser.ParseLine(input)
+	line, errs := parser.ParseLineWithUnits(input, ctx.InputLocale(), ctx.Units())
 	if len(errs) > 0 {
+		ctx.SkipLine()
 		return types.Error(errs[0].Message)
 	}
 
@@ -93,17 +197,95 @@ func (e *Engine) Eval(input string) types.Value {
 
 // EvalMultiple evaluates multiple lines and returns all results.
 func (e *Engine) EvalMultiple(lines []string) []types.Value {
+	return e.EvalMultipleContext(context.Background(), lines)
+}
+
+// EvalMultipleContext evaluates lines like EvalMultiple, each within the
+// engine's budget. Once ctx is done, the line being evaluated and those
+// after it evaluate to errors.
+func (e *Engine) EvalMultipleContext(ctx context.Context, lines []string) []types.Value {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	results := make([]types.Value, len(lines))
 	for i, line := range lines {
-		results[i] = e.Eval(line)
+		results[i] = e.eval(ctx, line)
 	}
+	e.applyShares(results)
 	return results
 }
 
+// startBudget limits ev to the engine's budget for a line evaluated under
+// ctx, and returns what lifts the limits again.
+func (e *Engine) startBudget(ctx context.Context, ev *eval.Evaluator) budgetStop {
+	e.limitsMu.Lock()
+	timeout, steps := e.evalTimeout, e.stepLimit
+	e.limitsMu.Unlock()
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	budget := eval.Budget{MaxSteps: steps}
+	if ctx.Done() != nil {
+		// Contexts that can't be cancelled aren't worth checking
+		budget.Ctx = ctx
+	}
+	ev.SetBudget(budget)
+	return budgetStop{ev: ev, cancel: cancel}
+}
+
+// budgetStop lifts the limits startBudget put on an evaluator. It is a
+// value rather than a closure so a line's evaluation doesn't allocate.
+type budgetStop struct {
+	ev     *eval.Evaluator
+	cancel context.CancelFunc
+}
+
+// stop lifts the limits.
+func (s budgetStop) stop() {
+	s.ev.SetBudget(eval.Budget{})
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// Shares returns the share of the total that each line ending "% of
+// total" comes to, by line number. Eval and EvalMultiple return shares
+// as of the lines evaluated so far; after evaluating a document line by
+// line, Shares gives each line's share of the whole document.
+func (e *Engine) Shares() map[int]types.Value {
+	return e.evaluator.Context().Shares(1)
+}
+
+// applyShares replaces the results of lines ending "% of total" with
+// their share of the total, once every line in results has counted
+// towards it. results are of the last lines evaluated. Callers must hold
+// mu.
+func (e *Engine) applyShares(results []types.Value) {
+	ctx := e.evaluator.Context()
+	last := ctx.LineNumber()
+	first := last - len(results) + 1
+	for n, share := range ctx.Shares(first) {
+		results[n-first] = share
+	}
+}
+
 // EvalFile evaluates a multi-line string (like a file contents).
+// A leading settings block ("precision: 4", "base currency: EUR", ...)
+// is applied before the remaining lines are evaluated; its lines
+// produce empty results, or errors for invalid settings.
 func (e *Engine) EvalFile(content string) []types.Value {
+	return e.EvalFileContext(context.Background(), content)
+}
+
+// EvalFileContext evaluates a file like EvalFile, stopping as
+// EvalMultipleContext does once ctx is done.
+func (e *Engine) EvalFileContext(ctx context.Context, content string) []types.Value {
 	lines := strings.Split(content, "\n")
-	return e.EvalMultiple(lines)
+	n := FrontMatterLen(lines)
+	results := e.ApplyFrontMatter(lines[:n])
+	return append(results, e.EvalMultipleContext(ctx, lines[n:])...)
 }
 
 // EvalPreview evaluates an expression without affecting state.
@@ -114,12 +296,17 @@ func (e *Engine) EvalPreview(input string) types.Value {
 	ctx.SetRateCacheAdapter(&rateCacheAdapter{rc: e.rateCache})
 	tempEval := eval.NewWithContext(ctx)
 
+	defer e.startBudget(context.Background(), tempEval).stop()
+
+	if errVal, ok := ctx.CheckInput(input); ok {
+		return errVal
+	}
 	trimmed := strings.TrimSpace(input)
 	if trimmed == "" {
 		return types.Empty()
 	}
 
-	line, errs := parser.ParseLine(input)
+	line, errs := parser.ParseLineWithUnits(input, ctx.InputLocale(), ctx.Units())
 	if len(errs) > 0 {
 		return types.Error(errs[0].Message)
 	}
@@ -157,6 +344,27 @@ func (e *Engine) Variables() map[string]types.Value {
 	return e.evaluator.Context().Variables()
 }
 
+// Description returns a variable's description, from the comment on the
+// line that set it, or "".
+func (e *Engine) Description(name string) string {
+	return e.evaluator.Context().Description(name)
+}
+
+// Descriptions returns the variables' descriptions, by name.
+func (e *Engine) Descriptions() map[string]string {
+	return e.evaluator.Context().Descriptions()
+}
+
+// Block is a run of lines between begin and end.
+type Block = eval.Block
+
+// Blocks returns the begin ... end blocks evaluated since the engine was
+// cleared, with the variables set in each, which Variables no longer
+// has once a block ends.
+func (e *Engine) Blocks() []Block {
+	return e.evaluator.Context().Blocks()
+}
+
 // VariableNames returns all variable names.
 func (e *Engine) VariableNames() []string {
 	return e.evaluator.Context().VariableNames()
@@ -167,6 +375,88 @@ func (e *Engine) HasVariable(name string) bool {
 	return e.evaluator.Context().HasVariable(name)
 }
 
+// WithOverrides returns a copy of the engine where the given variables
+// keep the given values, whatever the document assigns them: a what-if
+// scenario. Re-evaluating the document on the copy recomputes every line
+// that depends on them, leaving this engine untouched.
+func (e *Engine) WithOverrides(overrides map[string]types.Value) *Engine {
+	clone := e.Clone()
+	clone.SetOverrides(overrides)
+	return clone
+}
+
+// SetOverrides sets the scenario values that replace variables. nil
+// clears them.
+func (e *Engine) SetOverrides(overrides map[string]types.Value) {
+	e.evaluator.Context().SetOverrides(overrides)
+}
+
+// Overrides returns the scenario values that replace variables.
+func (e *Engine) Overrides() map[string]types.Value {
+	return e.evaluator.Context().Overrides()
+}
+
+// ════════════════════════════════════════════════════════════════
+// CALENDAR
+// ════════════════════════════════════════════════════════════════
+
+// SetWeekend sets the non-working weekdays for business day arithmetic.
+// The default is Saturday and Sunday.
+func (e *Engine) SetWeekend(days ...time.Weekday) {
+	e.evaluator.Context().Calendar().SetWeekend(days...)
+}
+
+// Weekend returns the non-working weekdays.
+func (e *Engine) Weekend() []time.Weekday {
+	return e.evaluator.Context().Calendar().Weekend()
+}
+
+// AddHoliday marks a date as a non-working day.
+func (e *Engine) AddHoliday(t time.Time) {
+	e.evaluator.Context().Calendar().AddHoliday(t)
+}
+
+// LoadHolidays reads holidays (one YYYY-MM-DD date per line) from r.
+func (e *Engine) LoadHolidays(r io.Reader) error {
+	return e.evaluator.Context().Calendar().ReadHolidays(r)
+}
+
+// ════════════════════════════════════════════════════════════════
+// CUSTOM UNITS
+// ════════════════════════════════════════════════════════════════
+
+// DefineUnit registers a custom unit in terms of an existing one,
+// e.g. DefineUnit("sprint", "2 weeks"). An empty definition creates a
+// base unit for a new dimension (e.g. DefineUnit("point", "")).
+// This is equivalent to the statement "unit sprint = 2 weeks", but the
+// unit outlives Clear. Units are the engine's own: other engines, and
+// clones made before, don't see them.
+func (e *Engine) DefineUnit(name, definition string, aliases ...string) error {
+	stmt := &ast.UnitDefStmt{Name: name, Aliases: aliases}
+	if strings.TrimSpace(definition) != "" {
+		expr, errs := e.ParseExpr(definition)
+		if len(errs) > 0 {
+			return errs[0]
+		}
+		stmt.Expr = expr
+	}
+
+	e.mu.Lock()
+	v := e.evaluator.EvalStmt(stmt)
+	e.mu.Unlock()
+	if v.IsError() {
+		return errors.EvalError(v.ErrorMessage())
+	}
+	e.evaluator.Context().KeepUnit(name)
+	return nil
+}
+
+// Units returns the units defined in the engine, by DefineUnit or by
+// "unit" lines.
+func (e *Engine) Units() *types.UnitSet {
+	return e.evaluator.Context().Units()
+}
+
 // ════════════════════════════════════════════════════════════════
 // PREVIOUS RESULT
 // ════════════════════════════════════════════════════════════════
@@ -195,6 +485,29 @@ func (e *Engine) GroupedTotals() []types.Value {
 	return e.evaluator.Context().GroupedTotals()
 }
 
+// SetBaseCurrency sets the currency that totals and mixed-currency sums
+// are converted to (e.g. SetBaseCurrency("EUR")). "" or "none" goes back
+// to following the last currency used.
+func (e *Engine) SetBaseCurrency(code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" || strings.EqualFold(code, "none") {
+		e.evaluator.Context().SetBaseCurrency(nil)
+		return nil
+	}
+	curr := types.ParseCurrency(code)
+	if curr == nil {
+		return errors.ParseErrorf("unknown currency: %s", code)
+	}
+	e.evaluator.Context().SetBaseCurrency(curr)
+	return nil
+}
+
+// BaseCurrency returns the base currency, or nil if totals follow the
+// last currency used.
+func (e *Engine) BaseCurrency() *types.Currency {
+	return e.evaluator.Context().BaseCurrency()
+}
+
 // ════════════════════════════════════════════════════════════════
 // LINE HISTORY
 // ════════════════════════════════════════════════════════════════
@@ -251,7 +564,8 @@ func (e *Engine) IsRateCacheValid() bool {
 	return e.rateCache.IsValid()
 }
 
-// IsRateCacheExpired returns true if the rate cache has expired.
+// IsRateCacheExpired returns true if the rates of any asset class have
+// expired.
 func (e *Engine) IsRateCacheExpired() bool {
 	return e.rateCache.IsExpired()
 }
@@ -267,12 +581,34 @@ func (e *Engine) RefreshRates(ctx context.Context) (int, error) {
 	return e.rateCache.Refresh(ctx)
 }
 
-// RefreshRatesIfExpired fetches fresh rates only if the cache is expired.
-// Returns the number of rates fetched (0 if cache was valid), or an error.
+// RefreshRatesSummary fetches fresh rates from the network like
+// RefreshRates, fetching each asset class at the same time, and reports
+// how each class went.
+func (e *Engine) RefreshRatesSummary(ctx context.Context) cache.RefreshResult {
+	return e.rateCache.RefreshClasses(ctx, cache.Fetchable(cache.AssetClasses...)...)
+}
+
+// RefreshRatesStream fetches fresh rates like RefreshRatesSummary, in the
+// background, sending each provider's start, success or failure and each
+// asset class's outcome to the returned channel as they happen. The
+// channel is closed after the final cache.RefreshDone event.
+func (e *Engine) RefreshRatesStream(ctx context.Context) <-chan cache.RefreshEvent {
+	return e.rateCache.RefreshStream(ctx, cache.Fetchable(cache.AssetClasses...)...)
+}
+
+// RefreshRatesIfExpired fetches fresh rates for the asset classes whose
+// rates have expired. Returns the number of rates fetched (0 if none had),
+// or an error.
 func (e *Engine) RefreshRatesIfExpired(ctx context.Context) (int, error) {
 	return e.rateCache.RefreshIfExpired(ctx)
 }
 
+// ReloadRatesIfChanged loads the rate cache file again if another process
+// has written it since, and reports whether it loaded any rates.
+func (e *Engine) ReloadRatesIfChanged() bool {
+	return e.rateCache.ReloadIfChanged()
+}
+
 // RefreshRatesAsync starts a background refresh and returns immediately.
 // The done channel receives the error (or nil) when the refresh completes.
 // If done is nil, no notification is sent.
@@ -295,11 +631,33 @@ func (e *Engine) RefreshMetalRates(ctx context.Context) (int, error) {
 	return e.rateCache.RefreshMetals(ctx)
 }
 
+// RefreshCommodityRates fetches only commodity rates: oil, gas and base
+// metals.
+func (e *Engine) RefreshCommodityRates(ctx context.Context) (int, error) {
+	return e.rateCache.RefreshCommodities(ctx)
+}
+
+// RefreshRatesFrom fetches rates from the named provider, or with a
+// non-zero date the fiat rates of that day. Past rates aren't saved.
+func (e *Engine) RefreshRatesFrom(ctx context.Context, provider string, date time.Time) (int, error) {
+	return e.rateCache.RefreshFrom(ctx, provider, date)
+}
+
 // RateCacheStats returns statistics about the rate cache.
 func (e *Engine) RateCacheStats() cache.Stats {
 	return e.rateCache.Stats()
 }
 
+// ProviderStatus returns the health of each rate provider: how its
+// fetches have gone, and whether it is being skipped after failing
+// repeatedly. A sandboxed engine fetches nothing and reports none.
+func (e *Engine) ProviderStatus() []cache.ProviderStatus {
+	if e.IsSandboxed() {
+		return nil
+	}
+	return cache.ProviderStatuses()
+}
+
 // ════════════════════════════════════════════════════════════════
 // SETTINGS
 // ════════════════════════════════════════════════════════════════
@@ -309,11 +667,140 @@ func (e *Engine) Precision() int {
 	return e.evaluator.Context().Precision()
 }
 
-// SetPrecision sets the display precision.
+// SetPrecision sets the display precision: the most decimal places shown
+// for numbers and units, or types.AutoPrecision to choose by magnitude.
 func (e *Engine) SetPrecision(p int) {
 	e.evaluator.Context().SetPrecision(p)
 }
 
+// SigFigs returns the significant figures shown, or 0 if precision is
+// used instead.
+func (e *Engine) SigFigs() int {
+	return e.evaluator.Context().SigFigs()
+}
+
+// SetSigFigs shows numbers and units to n significant figures (1-15)
+// instead of a number of decimal places; 0 goes back to precision.
+func (e *Engine) SetSigFigs(n int) {
+	e.evaluator.Context().SetSigFigs(n)
+}
+
+// Fractions returns when values are displayed as fractions.
+func (e *Engine) Fractions() types.FractionDisplay {
+	return e.evaluator.Context().Fractions()
+}
+
+// SetFractions sets when values are displayed as fractions: never, for
+// imperial and cooking units ("3/4 in", "1 1/2 cup"), or always.
+func (e *Engine) SetFractions(d types.FractionDisplay) {
+	e.evaluator.Context().SetFractions(d)
+}
+
+// InputLocale returns how numbers are written in input.
+func (e *Engine) InputLocale() types.InputLocale {
+	return e.evaluator.Context().InputLocale()
+}
+
+// SetInputLocale sets how numbers are written in input: 1,234.56 with
+// LocaleDecimalPoint (the default), or 1.234,56 with LocaleDecimalComma,
+// where function arguments are separated by ";" (sum(1,5; 2)).
+func (e *Engine) SetInputLocale(l types.InputLocale) {
+	e.evaluator.Context().SetInputLocale(l)
+}
+
+// CurrencyDisplay returns whether currencies show symbols or codes.
+func (e *Engine) CurrencyDisplay() types.CurrencyDisplay {
+	return e.evaluator.Context().CurrencyDisplay()
+}
+
+// SetCurrencyDisplay sets whether currencies show symbols ("$100.00") or
+// codes ("100.00 USD"), which tell USD, CAD and AUD apart. A line ending
+// in "as code" or "as symbol" overrides it.
+func (e *Engine) SetCurrencyDisplay(d types.CurrencyDisplay) {
+	e.evaluator.Context().SetCurrencyDisplay(d)
+}
+
+// AngleMode returns how trig functions read plain numbers.
+func (e *Engine) AngleMode() types.AngleMode {
+	return e.evaluator.Context().AngleMode()
+}
+
+// SetAngleMode sets how trig functions read plain numbers: sin(90) is
+// sin(90 rad) with AngleRadians (the default) and sin(90 deg) with
+// AngleDegrees, and asin, acos and atan return degrees. Angles with a
+// unit are read the same either way.
+func (e *Engine) SetAngleMode(m types.AngleMode) {
+	e.evaluator.Context().SetAngleMode(m)
+}
+
+// Rounding returns the rounding mode.
+func (e *Engine) Rounding() types.RoundingMode {
+	return e.evaluator.Context().Rounding()
+}
+
+// SetRounding sets how displayed values and round() results are rounded.
+func (e *Engine) SetRounding(m types.RoundingMode) {
+	e.evaluator.Context().SetRounding(m)
+}
+
+// EvalTimeout returns the longest a line may take to evaluate, or 0 if
+// there is no limit.
+func (e *Engine) EvalTimeout() time.Duration {
+	e.limitsMu.Lock()
+	defer e.limitsMu.Unlock()
+	return e.evalTimeout
+}
+
+// SetEvalTimeout sets the longest a line may take to evaluate before it
+// is stopped with an error; 0 removes the limit.
+func (e *Engine) SetEvalTimeout(d time.Duration) {
+	e.limitsMu.Lock()
+	defer e.limitsMu.Unlock()
+	e.evalTimeout = max(d, 0)
+}
+
+// StepLimit returns the most expressions a line may evaluate, or 0 if
+// there is no limit.
+func (e *Engine) StepLimit() int {
+	e.limitsMu.Lock()
+	defer e.limitsMu.Unlock()
+	return e.stepLimit
+}
+
+// SetStepLimit sets the most expressions, counting each call of a
+// user-defined function's body, a line may evaluate before it is stopped
+// with an error; 0 removes the limit. Unlike EvalTimeout, it stops a line
+// at the same point however fast the machine.
+func (e *Engine) SetStepLimit(n int) {
+	e.limitsMu.Lock()
+	defer e.limitsMu.Unlock()
+	e.stepLimit = max(n, 0)
+}
+
+// FormatOptions returns the display options from the engine's settings.
+func (e *Engine) FormatOptions() types.FormatOptions {
+	return types.FormatOptions{
+		Precision:  e.Precision(),
+		SigFigs:    e.SigFigs(),
+		Rounding:   e.Rounding(),
+		Fractions:  e.Fractions(),
+		Currencies: e.CurrencyDisplay(),
+		AsOf:       e.ShowAsOf(),
+	}
+}
+
+// Seed returns the seed of the random number generator behind rand(),
+// randint(), sample() and dice rolls.
+func (e *Engine) Seed() uint64 {
+	return e.evaluator.Context().Seed()
+}
+
+// SetSeed restarts the random number generator from seed, so a document
+// gives the same random numbers every time it is evaluated.
+func (e *Engine) SetSeed(seed uint64) {
+	e.evaluator.Context().SetSeed(seed)
+}
+
 // IsStrict returns whether strict mode is enabled.
 func (e *Engine) IsStrict() bool {
 	return e.evaluator.Context().IsStrict()
@@ -325,6 +812,75 @@ func (e *Engine) SetStrict(strict bool) {
 	e.evaluator.Context().SetStrict(strict)
 }
 
+// ShowAsOf returns whether converted amounts show when their rates were
+// fetched.
+func (e *Engine) ShowAsOf() bool {
+	return e.evaluator.Context().ShowAsOf()
+}
+
+// SetShowAsOf sets whether converted amounts show when their rates were
+// fetched: €92.00 (as of 2026-10-16 14:05 UTC).
+func (e *Engine) SetShowAsOf(on bool) {
+	e.evaluator.Context().SetShowAsOf(on)
+}
+
+// Region returns the region used for ambiguous units (gallon, pint, ...).
+func (e *Engine) Region() types.Region {
+	return e.evaluator.Context().Region()
+}
+
+// SetRegion sets the region used for ambiguous units.
+// In types.RegionUK, "gallon" means an imperial gallon and stones are
+// displayed as stones-and-pounds.
+func (e *Engine) SetRegion(r types.Region) {
+	e.evaluator.Context().SetRegion(r)
+}
+
+// DataUnits returns whether KB, MB, GB, ... are binary or decimal.
+func (e *Engine) DataUnits() types.DataUnits {
+	return e.evaluator.Context().DataUnits()
+}
+
+// SetDataUnits sets whether KB, MB, GB, ... mean 1024 (types.DataBinary)
+// or 1000 (types.DataDecimal) based sizes. KiB, MiB, ... are always binary.
+func (e *Engine) SetDataUnits(d types.DataUnits) {
+	e.evaluator.Context().SetDataUnits(d)
+}
+
+// LengthDisplay returns the display mode for lengths.
+func (e *Engine) LengthDisplay() types.LengthDisplay {
+	return e.evaluator.Context().LengthDisplay()
+}
+
+// SetLengthDisplay sets how Format displays lengths
+// (e.g., "1.88 m" with types.LengthMetric, "6 ft 2 in" with types.LengthFeetInches).
+func (e *Engine) SetLengthDisplay(d types.LengthDisplay) {
+	e.evaluator.Context().SetLengthDisplay(d)
+}
+
+// ════════════════════════════════════════════════════════════════
+// FORMATTING
+// ════════════════════════════════════════════════════════════════
+
+// Format returns the display form of a value using the engine's settings.
+// Unlike Value.String, this applies the length display mode, unless the
+// value was converted to its unit ("in ft"), and regional compound
+// formatting (e.g., "12 st 4 lb" in types.RegionUK).
+func (e *Engine) Format(v types.Value) string {
+	if v.Uncertainty != 0 {
+		return e.Format(v.Nominal()) + " ± " + e.Format(v.Nominal().WithAmount(v.Uncertainty))
+	}
+	if v.IsUnit() {
+		if s, ok := types.FormatLength(v.Num, v.Unit, e.LengthDisplay()); ok && !v.KeepUnit {
+			return s
+		}
+		if parts := types.CompoundPartsFor(v.Unit, e.Region()); parts != nil {
+			return types.FormatCompound(v.Num, v.Unit, parts...)
+		}
+	}
+	return v.Format(e.FormatOptions())
+}
+
 // ════════════════════════════════════════════════════════════════
 // STATE MANAGEMENT
 // ════════════════════════════════════════════════════════════════
@@ -346,6 +902,18 @@ func (e *Engine) ClearLines() {
 	e.evaluator.Context().ClearLines()
 }
 
+// Pins returns the values frozen with !pin, by statement. Clear keeps
+// them, so re-evaluating a document does not change its pinned lines.
+func (e *Engine) Pins() map[string]types.Value {
+	return e.evaluator.Context().PinnedValues()
+}
+
+// ClearPins forgets the values frozen with !pin; pinned lines take the
+// value they next evaluate to.
+func (e *Engine) ClearPins() {
+	e.evaluator.Context().ClearPins()
+}
+
 // Reset is an alias for Clear.
 func (e *Engine) Reset() {
 	e.Clear()
@@ -356,9 +924,20 @@ func (e *Engine) Clone() *Engine {
 	ctx := e.evaluator.Context().Clone()
 	ctx.SetRateCacheAdapter(&rateCacheAdapter{rc: e.rateCache})
 
+	e.hooksMu.Lock()
+	logger := e.logger
+	e.hooksMu.Unlock()
+
+	e.limitsMu.Lock()
+	defer e.limitsMu.Unlock()
 	return &Engine{
-		evaluator: eval.NewWithContext(ctx),
-		rateCache: e.rateCache,
+		evaluator:   eval.NewWithContext(ctx),
+		rateCache:   e.rateCache,
+		importDir:   e.importDir,
+		inputs:      maps.Clone(e.inputs),
+		evalTimeout: e.evalTimeout,
+		stepLimit:   e.stepLimit,
+		logger:      logger,
 	}
 }
 
@@ -368,17 +947,65 @@ func (e *Engine) Clone() *Engine {
 
 // Parse parses input without evaluating.
 func (e *Engine) Parse(input string) (*ast.Line, []*errors.Error) {
-	return parser.ParseLine(input)
+	return parser.ParseLineWithUnits(input, e.InputLocale(), e.Units())
 }
 
 // ParseExpr parses an expression without evaluating.
 func (e *Engine) ParseExpr(input string) (ast.Expr, []*errors.Error) {
-	return parser.ParseExpr(input)
+	return parser.ParseExprWithUnits(input, e.InputLocale(), e.Units())
+}
+
+// Spans returns the tokens of input, each with where it was written and
+// the role the parser gave it, for highlighting.
+func (e *Engine) Spans(input string) []parser.Span {
+	return parser.SpansWithUnits(input, e.InputLocale(), e.Units())
+}
+
+// FormatInput rewrites a line canonically: spaced operators, numbers in
+// digits, unit codes, and currency amounts shown as the engine's currency
+// display setting chooses ($100 or 100 USD). Blank and comment lines, and
+// lines that don't parse, come back as they are.
+func (e *Engine) FormatInput(input string) string {
+	opts := parser.FormatOptions{Locale: e.InputLocale(), Currencies: e.CurrencyDisplay()}
+	line, errs := parser.ParseLineWithUnits(input, opts.Locale, e.Units())
+	if len(errs) > 0 || line.Stmt == nil {
+		return input
+	}
+	if _, ok := line.Stmt.(*ast.EmptyStmt); ok {
+		return input
+	}
+	// A label is kept as written, which is only safe when a space sets it
+	// apart: "$250 rent", not "Total:" or "0xff"
+	if label := line.Label; label != "" {
+		if r, _ := utf8.DecodeRuneInString(label); !unicode.IsLetter(r) ||
+			!strings.Contains(input, " "+label) && !strings.Contains(input, "\t"+label) {
+			return input
+		}
+	}
+
+	// Formatting again must change nothing, or the line may read back
+	// differently
+	out := parser.Format(line, opts)
+	again, errs := parser.ParseLineWithUnits(out, opts.Locale, e.Units())
+	if len(errs) > 0 || parser.Format(again, opts) != out {
+		return input
+	}
+	return out
+}
+
+// FormatDocument formats each line of a document with FormatInput,
+// leaving its settings block as it is.
+func (e *Engine) FormatDocument(content string) string {
+	lines := strings.Split(content, "\n")
+	for i := FrontMatterLen(lines); i < len(lines); i++ {
+		lines[i] = e.FormatInput(lines[i])
+	}
+	return strings.Join(lines, "\n")
 }
 
 // IsValidExpression checks if an input is a valid expression.
 func (e *Engine) IsValidExpression(input string) bool {
-	_, errs := parser.ParseLine(input)
+	_, errs := e.Parse(input)
 	return len(errs) == 0
 }
 
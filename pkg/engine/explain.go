@@ -0,0 +1,166 @@
+// pkg/engine/explain.go
+
+package engine
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/internal/eval"
+	"github.com/0xsj/numio/internal/parser"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Explanation describes how a line evaluates: how it was parsed, what
+// each part of it came to, and the rates and variables it used.
+type Explanation struct {
+	Input  string
+	Tree   string      // Parse tree, one node per line; "" if it didn't parse
+	Steps  []Step      // Value of each part, innermost first
+	Rates  []RateUse   // Exchange rates used, in the order first used
+	Reads  []string    // Variables read, in the order first read
+	Result types.Value // What Eval would return
+
+	// Descriptions of the variables read that have one
+	Descriptions map[string]string
+}
+
+// Step is what one part of a line evaluated to.
+type Step struct {
+	Expr    string // The part, formatted as FormatInput would
+	Value   types.Value
+	Depth   int  // Parts it is inside; 0 for the whole expression
+	Literal bool // A number or amount as written, with no parts
+}
+
+// RateUse is an exchange rate a line converted with.
+type RateUse struct {
+	From, To string // Codes
+	Rate     float64
+	Fetched  time.Time // Zero for built-in rates
+}
+
+// Explain evaluates a line as Eval would at this point, without changing
+// the engine, and describes how it got its result.
+func (e *Engine) Explain(input string) Explanation {
+	x := Explanation{Input: input, Result: types.Empty()}
+
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		return x
+	}
+
+	ctx := e.evaluator.Context().Clone()
+	rates := &rateRecorder{RateCacheAdapter: &rateCacheAdapter{rc: e.rateCache}}
+	ctx.SetRateCacheAdapter(rates)
+
+	line, errs := parser.ParseLineWithUnits(input, ctx.InputLocale(), ctx.Units())
+	if len(errs) > 0 {
+		x.Result = types.Error(errs[0].Message)
+		return x
+	}
+	line.Raw = input
+	if line.Stmt != nil {
+		x.Tree = ast.Tree(line.Stmt)
+	}
+
+	// Variables are looked up in the engine's context, which the line,
+	// run on a copy, can't assign
+	vars := e.evaluator.Context()
+	ev := eval.NewWithContext(ctx)
+	ev.SetTrace(func(s eval.Step) {
+		x.Steps = append(x.Steps, traceStep(ctx, s))
+		if id, ok := s.Expr.(*ast.Identifier); ok && !slices.Contains(x.Reads, id.Name) {
+			if _, ok := vars.GetVariable(id.Name); ok {
+				x.Reads = append(x.Reads, id.Name)
+				if d := vars.Description(id.Name); d != "" {
+					if x.Descriptions == nil {
+						x.Descriptions = make(map[string]string)
+					}
+					x.Descriptions[id.Name] = d
+				}
+			}
+		}
+	})
+	defer e.startBudget(context.Background(), ev).stop()
+	x.Result = ev.EvalLine(line)
+	x.Rates = rates.uses
+	return x
+}
+
+// SetTrace sets a function called with each part of every line Eval
+// evaluates and its value, innermost first, as Explain lists them. nil
+// stops tracing.
+func (e *Engine) SetTrace(trace func(Step)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if trace == nil {
+		e.evaluator.SetTrace(nil)
+		return
+	}
+	ctx := e.evaluator.Context()
+	e.evaluator.SetTrace(func(s eval.Step) {
+		trace(traceStep(ctx, s))
+	})
+}
+
+// traceStep describes an evaluator step, writing its expression in the
+// context's locale and currency display.
+func traceStep(ctx *eval.Context, s eval.Step) Step {
+	opts := parser.FormatOptions{Locale: ctx.InputLocale(), Currencies: ctx.CurrencyDisplay()}
+	return Step{
+		Expr:    parser.FormatExpr(s.Expr, opts),
+		Value:   s.Value,
+		Depth:   s.Depth,
+		Literal: ast.IsLiteral(s.Expr),
+	}
+}
+
+// rateRecorder notes the exchange rates conversions use.
+type rateRecorder struct {
+	eval.RateCacheAdapter
+	uses []RateUse
+}
+
+func (r *rateRecorder) GetRate(from, to string) (float64, bool) {
+	rate, ok := r.RateCacheAdapter.GetRate(from, to)
+	if ok {
+		r.note(from, to)
+	}
+	return rate, ok
+}
+
+func (r *rateRecorder) Convert(amount float64, from, to string) (float64, bool) {
+	converted, ok := r.RateCacheAdapter.Convert(amount, from, to)
+	if ok {
+		r.note(from, to)
+	}
+	return converted, ok
+}
+
+func (r *rateRecorder) ConvertValue(v types.Value, target string) (types.Value, bool) {
+	converted, ok := r.RateCacheAdapter.ConvertValue(v, target)
+	if ok {
+		r.note(valueCode(v), valueCode(converted))
+	}
+	return converted, ok
+}
+
+// note records the rate between two codes, once.
+func (r *rateRecorder) note(from, to string) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == "" || to == "" || from == to {
+		return
+	}
+	for _, u := range r.uses {
+		if u.From == from && u.To == to {
+			return
+		}
+	}
+	rate, _ := r.RateCacheAdapter.GetRate(from, to)
+	r.uses = append(r.uses, RateUse{From: from, To: to, Rate: rate, Fetched: r.RateTime(from, to)})
+}
@@ -0,0 +1,30 @@
+// pkg/engine/explain_test.go
+
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestTraceSkipsParentheses(t *testing.T) {
+	e := NewSandboxed()
+
+	var steps []string
+	e.SetTrace(func(s Step) {
+		steps = append(steps, fmt.Sprintf("%d %s = %s", s.Depth, s.Expr, e.Format(s.Value)))
+	})
+	e.Eval("(1 + 2) * 3")
+
+	want := []string{
+		"2 1 = 1",
+		"2 2 = 2",
+		"1 1 + 2 = 3",
+		"1 3 = 3",
+		"0 (1 + 2) * 3 = 9",
+	}
+	if !slices.Equal(steps, want) {
+		t.Errorf("steps = %q, want %q", steps, want)
+	}
+}
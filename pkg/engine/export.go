@@ -0,0 +1,231 @@
+// pkg/engine/export.go
+
+package engine
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/errors"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// ExportFormat is a tabular export format.
+type ExportFormat int
+
+const (
+	ExportCSV ExportFormat = iota // Comma-separated values
+	ExportTSV                     // Tab-separated values
+)
+
+// String returns the format name.
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportCSV:
+		return "csv"
+	case ExportTSV:
+		return "tsv"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseExportFormat parses an export format name.
+func ParseExportFormat(s string) (ExportFormat, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "csv":
+		return ExportCSV, true
+	case "tsv", "tab":
+		return ExportTSV, true
+	default:
+		return 0, false
+	}
+}
+
+// exportHeader lists the exported columns.
+var exportHeader = []string{"input", "result", "amount", "kind", "unit", "as_of"}
+
+// Export writes the line history as a table in the given format, one row
+// per evaluated line: input, formatted result, numeric amount, value kind,
+// the currency, unit, metal, or crypto code, and when the rates behind a
+// converted amount were fetched.
+func (e *Engine) Export(w io.Writer, format ExportFormat) error {
+	cw := csv.NewWriter(w)
+	switch format {
+	case ExportCSV:
+		cw.Comma = ','
+	case ExportTSV:
+		cw.Comma = '\t'
+	default:
+		return errors.EvalErrorf("unknown export format: %s", format)
+	}
+
+	if err := cw.Write(exportHeader); err != nil {
+		return err
+	}
+	for _, lr := range e.Lines() {
+		if err := cw.Write(e.exportRow(lr)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportRow returns the table row for a line result.
+func (e *Engine) exportRow(lr LineResult) []string {
+	v := lr.Value
+	row := []string{strings.TrimSpace(lr.Input), "", "", v.Kind.String(), valueCode(v), ""}
+	if !v.AsOf.IsZero() {
+		row[5] = v.AsOf.UTC().Format(time.RFC3339)
+	}
+
+	switch {
+	case v.IsError():
+		row[1] = v.ErrorMessage()
+	case v.Kind == types.ValueDate:
+		row[1] = e.Format(v)
+		row[2] = v.Time.Format("2006-01-02")
+	case !v.IsEmpty():
+		row[1] = e.Format(v)
+		row[2] = strconv.FormatFloat(v.Num, 'f', -1, 64)
+	}
+
+	return row
+}
+
+// valueCode returns the currency, unit, metal, or crypto code of a value.
+func valueCode(v types.Value) string {
+	switch {
+	case v.Curr != nil:
+		return v.Curr.Code
+	case v.Unit != nil:
+		return v.Unit.Code
+	case v.Metal != nil:
+		return v.Metal.Code
+	case v.Crypto != nil:
+		return v.Crypto.Code
+	default:
+		return ""
+	}
+}
+
+// ════════════════════════════════════════════════════════════════
+// MARKDOWN
+// ════════════════════════════════════════════════════════════════
+
+// markdownRow is one expression line of a Markdown report.
+type markdownRow struct {
+	expr, result, comment string
+}
+
+// ExportMarkdown evaluates a document and writes it to w as a Markdown
+// report: one table (expression | result | comment) per section, each
+// followed by the section's totals. Comment-only lines start a new
+// section and become its heading. A leading settings block is applied
+// but not rendered. Converted amounts say when their rates were fetched.
+func (e *Engine) ExportMarkdown(w io.Writer, content string) error {
+	lines := strings.Split(content, "\n")
+	n := FrontMatterLen(lines)
+	e.ApplyFrontMatter(lines[:n])
+
+	var sb strings.Builder
+	var rows []markdownRow
+	start := len(e.Lines())
+
+	// Reports always say which rates produced their figures
+	reportOpts := e.FormatOptions()
+	reportOpts.AsOf = true
+
+	flush := func() {
+		if len(rows) == 0 {
+			return
+		}
+		totals := e.evaluator.Context().GroupedTotalsOf(e.Lines()[start:])
+		writeMarkdownTable(&sb, rows, e.formatTotals(totals))
+		rows = nil
+		start = len(e.Lines())
+	}
+
+	for _, line := range lines[n:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if isCommentLine(trimmed) {
+			flush()
+			text := commentText(trimmed)
+			if text != "" {
+				sb.WriteString("### " + text + "\n\n")
+			}
+			continue
+		}
+
+		v := e.Eval(line)
+		row := markdownRow{expr: trimmed}
+		if parsed, errs := e.Parse(line); len(errs) == 0 && parsed.Comment != "" {
+			row.expr = strings.TrimSpace(strings.TrimSuffix(trimmed, parsed.Comment))
+			row.comment = commentText(parsed.Comment)
+		}
+		switch {
+		case v.IsError():
+			row.result = "Error: " + v.ErrorMessage()
+		case !v.IsEmpty():
+			row.result = v.Format(reportOpts)
+		}
+		rows = append(rows, row)
+	}
+	flush()
+
+	_, err := io.WriteString(w, strings.TrimRight(sb.String(), "\n")+"\n")
+	return err
+}
+
+// formatTotals joins grouped totals for display ("$1500.00, 12 km").
+func (e *Engine) formatTotals(totals []types.Value) string {
+	parts := make([]string, 0, len(totals))
+	for _, t := range totals {
+		parts = append(parts, e.Format(t))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeMarkdownTable writes a section table with an optional totals row.
+func writeMarkdownTable(sb *strings.Builder, rows []markdownRow, total string) {
+	sb.WriteString("| Expression | Result | Comment |\n")
+	sb.WriteString("| --- | ---: | --- |\n")
+	for _, r := range rows {
+		sb.WriteString("| " + markdownCell(r.expr) + " | " + markdownCell(r.result) +
+			" | " + markdownCell(r.comment) + " |\n")
+	}
+	if total != "" && len(rows) > 1 {
+		sb.WriteString("| **Total** | **" + markdownCell(total) + "** | |\n")
+	}
+	sb.WriteString("\n")
+}
+
+// markdownCell escapes text for use in a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// isCommentLine reports whether a trimmed line is a comment.
+func isCommentLine(s string) bool {
+	return strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//")
+}
+
+// commentText strips comment markers ("#", "//") from a comment.
+func commentText(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "//") {
+		s = s[2:]
+	} else {
+		s = strings.TrimLeft(s, "#")
+	}
+	return strings.TrimSpace(s)
+}
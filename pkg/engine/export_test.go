@@ -0,0 +1,51 @@
+// pkg/engine/export_test.go
+
+package engine
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestExportMarkdownLeavesOutTotalLines(t *testing.T) {
+	doc := "# Costs\n$100\n$200\ntotal\n\n# Trip\n5 km\n3 km\n"
+	var sb strings.Builder
+	if err := NewSandboxed().ExportMarkdown(&sb, doc); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{"| **Total** | **$300.00** | |", "| **Total** | **8 km** | |"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report has no %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTotalLeavesOutTotalLines(t *testing.T) {
+	e := NewSandboxed()
+	for _, line := range []string{"$100", "$200", "total", "$5"} {
+		e.Eval(line)
+	}
+	if got := e.Format(e.Eval("total")); got != "$305.00" {
+		t.Errorf("total = %q, want $305.00", got)
+	}
+}
+
+func TestTotalConvertsMixedCurrencies(t *testing.T) {
+	e := NewSandboxed()
+	results := e.EvalFile("$250 % of total\n€750\ntotal")
+
+	ref := NewSandboxed()
+	dollars := ref.Eval("$250 in EUR").Num
+	want := ref.Format(ref.Eval("€750").WithAmount(750 + dollars))
+	if got := e.Format(results[2]); got != want {
+		t.Errorf("total = %q, want %q", got, want)
+	}
+	if grouped := e.GroupedTotals(); len(grouped) != 1 || e.Format(grouped[0]) != want {
+		t.Errorf("grouped totals = %v, want %s", grouped, want)
+	}
+	if got, want := results[0].Num, dollars/(750+dollars); math.Abs(got-want) > 1e-9 {
+		t.Errorf("$250 %% of total = %v, want %v", got, want)
+	}
+}
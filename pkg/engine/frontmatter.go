@@ -0,0 +1,258 @@
+// pkg/engine/frontmatter.go
+
+package engine
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/0xsj/numio/pkg/errors"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// A .calc file may start with a settings block, one "key: value" per line,
+// optionally fenced by "---" lines:
+//
+//	precision: 4
+//	rounding: half-even
+//	base currency: EUR
+//	strict: on
+//	---
+//	rent = 1200 EUR
+
+// frontMatterFence separates the settings block from the document.
+const frontMatterFence = "---"
+
+// ════════════════════════════════════════════════════════════════
+// SETTINGS
+// ════════════════════════════════════════════════════════════════
+
+// ApplySetting applies a named setting, as written in a file's settings
+// block (e.g. ApplySetting("base currency", "EUR")).
+func (e *Engine) ApplySetting(name, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch normalizeSettingName(name) {
+	case "precision":
+		if strings.EqualFold(value, "auto") {
+			e.SetPrecision(types.AutoPrecision)
+			break
+		}
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 0 || p > 15 {
+			return errors.ParseErrorf("precision must be 0-15 or auto, got %q", value)
+		}
+		e.SetPrecision(p)
+
+	case "sigfig", "sigfigs", "significant figures":
+		if on, ok := parseSwitch(value); ok && !on {
+			e.SetSigFigs(0)
+			break
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 15 {
+			return errors.ParseErrorf("sigfig must be 1-15 or off, got %q", value)
+		}
+		e.SetSigFigs(n)
+
+	case "fractions":
+		d, ok := types.ParseFractionDisplay(value)
+		if !ok {
+			return errors.ParseErrorf("fractions must be off, imperial or on, got %q", value)
+		}
+		e.SetFractions(d)
+
+	case "input locale", "locale":
+		l, ok := types.ParseInputLocale(value)
+		if !ok {
+			return errors.ParseErrorf("input locale must be en (1,234.56) or eu (1.234,56), got %q", value)
+		}
+		e.SetInputLocale(l)
+
+	case "currency display", "currencies":
+		d, ok := types.ParseCurrencyDisplay(value)
+		if !ok {
+			return errors.ParseErrorf("currency display must be symbol or code, got %q", value)
+		}
+		e.SetCurrencyDisplay(d)
+
+	case "angle", "angles", "angle mode":
+		m, ok := types.ParseAngleMode(value)
+		if !ok {
+			return errors.ParseErrorf("angle must be deg or rad, got %q", value)
+		}
+		e.SetAngleMode(m)
+
+	case "seed":
+		seed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return errors.ParseErrorf("seed must be a whole number, got %q", value)
+		}
+		e.SetSeed(seed)
+
+	case "rounding":
+		m, ok := types.ParseRoundingMode(value)
+		if !ok {
+			return errors.ParseErrorf("unknown rounding mode: %s", value)
+		}
+		e.SetRounding(m)
+
+	case "strict":
+		on, ok := parseSwitch(value)
+		if !ok {
+			return errors.ParseErrorf("strict must be on or off, got %q", value)
+		}
+		e.SetStrict(on)
+
+	case "as of", "asof":
+		on, ok := parseSwitch(value)
+		if !ok {
+			return errors.ParseErrorf("as of must be on or off, got %q", value)
+		}
+		e.SetShowAsOf(on)
+
+	case "region":
+		r, ok := types.ParseRegion(value)
+		if !ok {
+			return errors.ParseErrorf("unknown region: %s", value)
+		}
+		e.SetRegion(r)
+
+	case "length", "lengths":
+		d, ok := types.ParseLengthDisplay(value)
+		if !ok {
+			return errors.ParseErrorf("unknown length display: %s", value)
+		}
+		e.SetLengthDisplay(d)
+
+	case "data", "data units":
+		d, ok := types.ParseDataUnits(value)
+		if !ok {
+			return errors.ParseErrorf("unknown data units: %s", value)
+		}
+		e.SetDataUnits(d)
+
+	case "weekend":
+		days, ok := types.ParseWeekdays(value)
+		if !ok {
+			return errors.ParseErrorf("invalid weekend: %s", value)
+		}
+		e.SetWeekend(days...)
+
+	case "base currency", "currency":
+		if err := e.SetBaseCurrency(value); err != nil {
+			return err
+		}
+
+	default:
+		return errors.ParseErrorf("unknown setting: %s", name)
+	}
+
+	return nil
+}
+
+// isSetting reports whether name is a setting ApplySetting understands.
+func isSetting(name string) bool {
+	switch normalizeSettingName(name) {
+	case "precision", "sigfig", "sigfigs", "significant figures", "rounding", "fractions", "input locale", "locale", "currency display", "currencies",
+		"angle", "angles", "angle mode", "seed", "strict", "as of", "asof", "region", "length", "lengths",
+		"data", "data units", "weekend", "base currency", "currency":
+		return true
+	}
+	return false
+}
+
+// normalizeSettingName lowercases a setting name and collapses
+// separators, so "Base Currency", "base_currency" and "base-currency" match.
+func normalizeSettingName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// parseSwitch parses an on/off value.
+func parseSwitch(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "on", "true", "yes", "1":
+		return true, true
+	case "off", "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// ════════════════════════════════════════════════════════════════
+// FRONT MATTER
+// ════════════════════════════════════════════════════════════════
+
+// FrontMatterLen returns the number of leading lines that form the
+// settings block, including a closing fence. A block opened with a fence
+// runs until the closing fence; otherwise it runs while lines are
+// "key: value" pairs with known keys.
+func FrontMatterLen(lines []string) int {
+	if len(lines) == 0 {
+		return 0
+	}
+
+	if strings.TrimSpace(lines[0]) == frontMatterFence {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == frontMatterFence {
+				return i + 1
+			}
+		}
+		return 0 // Unclosed fence: not a settings block
+	}
+
+	n := 0
+	for n < len(lines) {
+		name, _, ok := splitSetting(lines[n])
+		if !ok || !isSetting(name) {
+			break
+		}
+		n++
+	}
+	if n > 0 && n < len(lines) && strings.TrimSpace(lines[n]) == frontMatterFence {
+		n++
+	}
+	return n
+}
+
+// splitSetting splits a "key: value" line.
+func splitSetting(line string) (name, value string, ok bool) {
+	name, value, ok = strings.Cut(line, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return "", "", false
+	}
+	return strings.TrimSpace(name), strings.TrimSpace(value), true
+}
+
+// ApplyFrontMatter applies the settings in a settings block.
+// Returns one result per line: empty for settings, fences, blank lines
+// and comments, or an error for invalid settings.
+func (e *Engine) ApplyFrontMatter(lines []string) []types.Value {
+	results := make([]types.Value, len(lines))
+	for i, line := range lines {
+		results[i] = types.Empty()
+		e.evaluator.Context().SkipLine()
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == frontMatterFence ||
+			strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+
+		name, value, ok := splitSetting(trimmed)
+		if !ok {
+			results[i] = types.Error("invalid setting: " + trimmed)
+			continue
+		}
+		if err := e.ApplySetting(name, value); err != nil {
+			if ee, ok := err.(*errors.Error); ok {
+				results[i] = types.Error(ee.Message)
+			} else {
+				results[i] = types.Error(err.Error())
+			}
+		}
+	}
+	return results
+}
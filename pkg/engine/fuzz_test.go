@@ -0,0 +1,76 @@
+// pkg/engine/fuzz_test.go
+
+package engine
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// fuzzRates is the rate cache the fuzz target's engines share, holding
+// the built-in rates.
+var fuzzRates = cache.New()
+
+// readsBack evaluates each line in turn on one engine, and checks that
+// an amount it returns reads back as itself: evaluating its text on a
+// new engine must show the same text.
+func readsBack(t *testing.T, input string) {
+	t.Helper()
+	e := NewWithCache(fuzzRates)
+	for _, line := range strings.Split(input, "\n") {
+		v := e.Eval(line)
+		if !roundTrips(v) {
+			continue
+		}
+		text := v.String()
+		if again := NewWithCache(fuzzRates).Eval(text).String(); again != text {
+			t.Errorf("%q gives %q, which reads back as %q", line, text, again)
+		}
+	}
+}
+
+// roundTrips reports whether a value's text should evaluate back to it:
+// a finite amount, even as a percentage, shown the default way, in a
+// unit that can be typed (not a compound unit like m²/s²).
+func roundTrips(v types.Value) bool {
+	switch v.Kind {
+	case types.ValueNumber, types.ValuePercentage, types.ValueCurrency,
+		types.ValueWithUnit, types.ValueMetal, types.ValueCrypto:
+	default:
+		return false
+	}
+	finite := func(f float64) bool { return !math.IsInf(f, 0) && !math.IsNaN(f) }
+	typed := v.Unit == nil || types.ParseUnit(v.Unit.Code) != nil
+	return finite(v.Num*100) && finite(v.Uncertainty*100) && v.Big == nil && v.CurrencyDisplay == nil && typed
+}
+
+// roundTripSeeds are the amounts the round trip is checked on, and the
+// fuzz target's seed corpus.
+var roundTripSeeds = []string{
+	"1000 JPY", "1000 CNY", "5 CAD", "$10.50", "€3", "100₽", "5 ZAR",
+	"10 g 18k gold", "3 oz gold", "2 oz of 22k gold", "100 bbl brent",
+	"1 BTC", "50000 sats", "21 gwei", "0.1 ETH",
+	"5 km", "3 ft 2 in", "2 cups flour", "60 mph", "20%", "1/3",
+	"1e-320", "5 million", "x = 5\nx * 2", "-$10",
+}
+
+func TestFormattedValueReadsBack(t *testing.T) {
+	for _, input := range roundTripSeeds {
+		readsBack(t, input)
+	}
+}
+
+// FuzzEval checks that evaluation never panics, and that an amount it
+// returns reads back as itself.
+func FuzzEval(f *testing.F) {
+	for _, seed := range roundTripSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		readsBack(t, input)
+	})
+}
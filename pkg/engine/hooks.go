@@ -0,0 +1,71 @@
+// pkg/engine/hooks.go
+
+package engine
+
+import (
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// hooks are the functions registered with OnEval and OnVariableSet.
+type hooks struct {
+	onEval []func(LineResult)
+	onSet  []func(name string, value types.Value)
+}
+
+// OnEval registers a function called with each line added to the line
+// history as it is evaluated, in order. Blank lines, comments and lines
+// that fail to parse have no result and aren't reported; a line ending
+// "% of total" is reported with its own value, as Lines has it.
+//
+// Functions are called from the goroutine evaluating, while the engine
+// is evaluating: they may read its variables and settings, but must not
+// evaluate. Copies made with Clone or WithOverrides don't call them.
+func (e *Engine) OnEval(fn func(LineResult)) {
+	e.hooksMu.Lock()
+	defer e.hooksMu.Unlock()
+	e.hooks.onEval = append(e.hooks.onEval, fn)
+	e.installHooks()
+}
+
+// OnVariableSet registers a function called whenever a variable is set,
+// by an assignment, an import, an input line or SetVariable. As with
+// OnEval, functions must not evaluate; EvalParallel may call them from
+// several goroutines at once.
+func (e *Engine) OnVariableSet(fn func(name string, value types.Value)) {
+	e.hooksMu.Lock()
+	defer e.hooksMu.Unlock()
+	e.hooks.onSet = append(e.hooks.onSet, fn)
+	e.installHooks()
+}
+
+// OnRateRefresh registers a function called after each refresh of rates
+// from the network, with the number of rates fetched or why none could
+// be. It is registered with the rate cache, so it also hears of
+// refreshes made through other engines sharing it.
+func (e *Engine) OnRateRefresh(fn func(cache.RefreshResult)) {
+	e.rateCache.OnRefresh(fn)
+}
+
+// installHooks has the context report to the registered functions.
+// Callers must hold hooksMu.
+func (e *Engine) installHooks() {
+	h := e.hooks
+	var onLine func(LineResult)
+	if len(h.onEval) > 0 {
+		onLine = func(lr LineResult) {
+			for _, fn := range h.onEval {
+				fn(lr)
+			}
+		}
+	}
+	var onSet func(string, types.Value)
+	if len(h.onSet) > 0 {
+		onSet = func(name string, value types.Value) {
+			for _, fn := range h.onSet {
+				fn(name, value)
+			}
+		}
+	}
+	e.evaluator.Context().SetHooks(onLine, onSet)
+}
@@ -0,0 +1,134 @@
+// pkg/engine/import.go
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/0xsj/numio/internal/eval"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// SetImportDir turns on import "file" and include "file" lines, which
+// bring the variables, functions and units a .calc file defines into the
+// document. Paths are resolved against dir, or, in an imported file,
+// against that file's directory, and must stay inside dir. Imports are
+// off until this is called, so an engine evaluating untrusted input
+// can't read files; "" turns them off again. A sandboxed engine keeps
+// them off.
+func (e *Engine) SetImportDir(dir string) {
+	if e.IsSandboxed() {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.importDir = dir
+}
+
+// ImportPath returns the file an import or include line names: import
+// "rates.calc", include shared/fx.calc. A trailing comment is allowed.
+func ImportPath(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	word, rest, ok := strings.Cut(line, " ")
+	if !ok || !strings.EqualFold(word, "import") && !strings.EqualFold(word, "include") {
+		return "", false
+	}
+
+	rest = strings.TrimSpace(rest)
+	if quoted, ok := strings.CutPrefix(rest, `"`); ok {
+		path, after, ok := strings.Cut(quoted, `"`)
+		if !ok || path == "" || !isLineEnd(after) {
+			return "", false
+		}
+		return path, true
+	}
+	path, after, _ := strings.Cut(rest, " ")
+	if path == "" || !isLineEnd(after) {
+		return "", false
+	}
+	return path, true
+}
+
+// isLineEnd reports whether s is blank or a comment.
+func isLineEnd(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//")
+}
+
+// importFile evaluates a .calc file on a copy of the context and takes
+// the variables and functions it defines; its lines don't join the
+// document's. The file's first error is returned, with its line number.
+// Callers must hold mu.
+func (e *Engine) importFile(path string) types.Value {
+	if e.importDir == "" {
+		return types.Error("imports are off")
+	}
+
+	if filepath.IsAbs(path) {
+		return types.Errorf("cannot import %s: absolute paths are not allowed", path)
+	}
+	root, err := filepath.Abs(e.importDir)
+	if err != nil {
+		return types.Errorf("cannot import %s: %v", path, err)
+	}
+	dir := root
+	if n := len(e.importing); n > 0 {
+		dir = filepath.Dir(e.importing[n-1])
+	}
+	name := path
+	path = filepath.Join(dir, path)
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return types.Errorf("cannot import %s: outside the import directory", name)
+	}
+	if i := slices.Index(e.importing, path); i >= 0 {
+		cycle := append(slices.Clone(e.importing[i:]), path)
+		for j, p := range cycle {
+			cycle[j] = filepath.Base(p)
+		}
+		return types.Errorf("import cycle: %s", strings.Join(cycle, " → "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return types.Errorf("cannot import %s: %v", filepath.Base(path), errorText(err))
+	}
+
+	ctx := e.evaluator.Context()
+	child := &Engine{
+		evaluator: eval.NewWithContext(ctx.Clone()),
+		rateCache: e.rateCache,
+		importDir: e.importDir,
+		importing: append(slices.Clone(e.importing), path),
+	}
+	child.evaluator.Context().SetRateCacheAdapter(&rateCacheAdapter{rc: e.rateCache})
+
+	for i, result := range child.EvalFile(string(data)) {
+		if result.IsError() {
+			return types.Errorf("%s:%d: %s", filepath.Base(path), i+1, result.ErrorMessage())
+		}
+	}
+
+	imported := child.evaluator.Context()
+	for _, fn := range imported.Functions() {
+		ctx.DefineFunction(fn)
+	}
+	descriptions := imported.Descriptions()
+	for name, v := range imported.Variables() {
+		ctx.SetVariable(name, v)
+		if d := descriptions[name]; d != "" {
+			ctx.SetDescription(name, d)
+		}
+	}
+	return types.Empty()
+}
+
+// errorText returns an error's message without the path os puts first.
+func errorText(err error) string {
+	if pe, ok := err.(*os.PathError); ok {
+		return pe.Err.Error()
+	}
+	return err.Error()
+}
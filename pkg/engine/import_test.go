@@ -0,0 +1,53 @@
+// pkg/engine/import_test.go
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xsj/numio/pkg/cache"
+)
+
+func TestImportStaysInDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "docs")
+	for path, content := range map[string]string{
+		"docs/rates.calc":      "fee = 5",
+		"docs/shared/fx.calc":  "import \"../../secret.calc\"",
+		"docs/shared/tax.calc": "import \"../rates.calc\"\ntax = fee * 2",
+		"secret.calc":          "secret = 42",
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		line string
+		err  string // The error, if refused
+	}{
+		{`import "rates.calc"`, ""},
+		{`import "shared/tax.calc"`, ""},
+		{`import "../secret.calc"`, "outside the import directory"},
+		{`import "shared/fx.calc"`, "outside the import directory"},
+		{`import "` + filepath.Join(root, "secret.calc") + `"`, "absolute paths"},
+	}
+	for _, tt := range tests {
+		e := NewWithCache(cache.New())
+		e.SetImportDir(dir)
+		v := e.Eval(tt.line)
+		switch {
+		case tt.err == "" && v.IsError():
+			t.Errorf("%s: %s", tt.line, v.ErrorMessage())
+		case tt.err != "" && !strings.Contains(v.ErrorMessage(), tt.err):
+			t.Errorf("%s gave %q, want %q", tt.line, v.ErrorMessage(), tt.err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// pkg/engine/input.go
+
+package engine
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/0xsj/numio/internal/eval"
+	"github.com/0xsj/numio/internal/token"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Input is a value a template document asks for when it is opened,
+// declared by a line like input salary "Monthly salary". The line sets
+// the variable to the value given with SetInput.
+type Input struct {
+	Name   string
+	Prompt string // What to ask; "" if the line gives none
+	Line   int    // Line number, from 1
+}
+
+// ParseInput returns the input a line declares: input salary, or input
+// salary "Monthly salary". A trailing comment is allowed.
+func ParseInput(line string) (Input, bool) {
+	line = strings.TrimSpace(line)
+	word, rest, ok := strings.Cut(line, " ")
+	if !ok || !strings.EqualFold(word, "input") {
+		return Input{}, false
+	}
+
+	name, rest, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	if !isInputName(name) {
+		return Input{}, false
+	}
+	in := Input{Name: name}
+
+	rest = strings.TrimSpace(rest)
+	if quoted, ok := strings.CutPrefix(rest, `"`); ok {
+		prompt, after, ok := strings.Cut(quoted, `"`)
+		if !ok {
+			return Input{}, false
+		}
+		in.Prompt, rest = prompt, after
+	}
+	if !isLineEnd(rest) {
+		return Input{}, false
+	}
+	return in, true
+}
+
+// isInputName reports whether s can name an input's variable.
+func isInputName(s string) bool {
+	if s == "" || token.LookupIdentifier(strings.ToLower(s)) != token.IDENTIFIER {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Inputs returns the inputs a document's lines declare, in order. An
+// input declared twice is listed once, at its first line.
+func Inputs(lines []string) []Input {
+	var inputs []Input
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		in, ok := ParseInput(line)
+		if !ok || seen[in.Name] {
+			continue
+		}
+		seen[in.Name] = true
+		in.Line = i + 1
+		inputs = append(inputs, in)
+	}
+	return inputs
+}
+
+// SetInput gives an input its value. Clear keeps the values, so a
+// document re-evaluated after an edit doesn't ask again.
+func (e *Engine) SetInput(name string, v types.Value) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.inputs == nil {
+		e.inputs = make(map[string]types.Value)
+	}
+	e.inputs[name] = v
+}
+
+// SetInputText gives an input the value of an expression, as typed at a
+// prompt: "$4,500", "12 * 350". It returns the value, or the error the
+// expression evaluated to.
+func (e *Engine) SetInputText(name, text string) types.Value {
+	v := e.EvalPreview(text)
+	if v.IsEmpty() {
+		return types.Error("no value given")
+	}
+	if !v.IsError() {
+		e.SetInput(name, v)
+	}
+	return v
+}
+
+// MissingInputs returns the inputs a document's lines declare that have
+// no value yet, which are the ones to ask for.
+func (e *Engine) MissingInputs(lines []string) []Input {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var missing []Input
+	for _, in := range Inputs(lines) {
+		if _, ok := e.inputs[in.Name]; !ok {
+			missing = append(missing, in)
+		}
+	}
+	return missing
+}
+
+// evalInput sets an input's variable to its value, described by its
+// prompt, as an assignment would. Callers must hold mu.
+func (e *Engine) evalInput(in Input, input string) types.Value {
+	ctx := e.evaluator.Context()
+	v, ok := e.inputs[in.Name]
+	if !ok {
+		ctx.SkipLine()
+		return types.Errorf("no value given for input %s", in.Name)
+	}
+
+	ctx.SetVariable(in.Name, v)
+	if in.Prompt != "" {
+		ctx.SetDescription(in.Name, in.Prompt)
+	}
+	e.evaluator.RecordLine(eval.LineResult{Input: input, Value: v, AssignedVar: in.Name})
+	return v
+}
+
+// isDirective reports whether a line is an import or an input, which the
+// engine handles before parsing.
+func isDirective(line string) bool {
+	if _, ok := ImportPath(line); ok {
+		return true
+	}
+	_, ok := ParseInput(line)
+	return ok
+}
@@ -0,0 +1,28 @@
+// pkg/engine/length_test.go
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/0xsj/numio/pkg/types"
+)
+
+func TestExplicitTargetBeatsLengthDisplay(t *testing.T) {
+	e := NewSandboxed()
+	e.SetLengthDisplay(types.LengthFeetInches)
+	e.SetRegion(types.RegionUK)
+
+	tests := []struct{ input, want string }{
+		{"2 m in ft", "6.56 ft"},
+		{"2 m in inches", "78.74 in"},
+		{"2 m", "6 ft 6.74 in"},
+		{"(2 m in ft) + 1 m", "9.84 ft"},
+		{"80 kg in st", "12 st 8.37 lb"}, // Stones are still split
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
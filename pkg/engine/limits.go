@@ -0,0 +1,45 @@
+// pkg/engine/limits.go
+
+package engine
+
+import (
+	"github.com/0xsj/numio/internal/eval"
+	"github.com/0xsj/numio/pkg/errors"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// Limits bound what evaluating a line may build: the elements of a list
+// or matrix, of a range, the bytes of the input and how deeply functions
+// may call each other. A zero field means no limit.
+type Limits = eval.Limits
+
+// DefaultLimits returns the limits of a new engine.
+func DefaultLimits() Limits {
+	return eval.DefaultLimits()
+}
+
+// Limits returns the limits on what a line may build.
+func (e *Engine) Limits() Limits {
+	return e.evaluator.Context().Limits()
+}
+
+// SetLimits sets the limits on what a line may build. A line running
+// over one evaluates to an error value whose IsLimitError is true, as do
+// lines stopped by EvalTimeout, StepLimit or a cancelled context.
+func (e *Engine) SetLimits(l Limits) {
+	e.evaluator.Context().SetLimits(l)
+}
+
+// ResultError returns the error a result holds, or nil if it isn't an
+// error. Errors from running over a limit are of kind
+// errors.KindResourceLimit, and match errors.ErrResourceLimit.
+func ResultError(v types.Value) error {
+	switch {
+	case !v.IsError():
+		return nil
+	case v.IsLimitError():
+		return errors.ResourceLimit(v.ErrorMessage())
+	default:
+		return errors.EvalError(v.ErrorMessage())
+	}
+}
@@ -0,0 +1,46 @@
+// pkg/engine/log.go
+
+package engine
+
+import (
+	"log/slog"
+
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// discardLogger is the logger of engines given none.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// SetLogger logs to l what the engine would otherwise do silently: lines
+// stopped by a timeout or limit, and, through its rate cache, rate
+// refreshes that fail and the cached or built-in rates used instead.
+// Unless the engine is sandboxed, l also becomes the logger of the rate
+// providers, which every engine in the process shares. Copies made with
+// Clone keep the logger. nil turns logging off.
+func (e *Engine) SetLogger(l *slog.Logger) {
+	e.hooksMu.Lock()
+	e.logger = l
+	e.hooksMu.Unlock()
+
+	e.rateCache.SetLogger(l)
+	if !e.IsSandboxed() {
+		cache.SetProviderLogger(l)
+	}
+}
+
+// log returns the engine's logger.
+func (e *Engine) log() *slog.Logger {
+	e.hooksMu.Lock()
+	defer e.hooksMu.Unlock()
+	if e.logger == nil {
+		return discardLogger
+	}
+	return e.logger
+}
+
+// logLimited logs a line stopped by a resource limit. The line itself
+// isn't logged, as it may be private.
+func (e *Engine) logLimited(v types.Value, input string) {
+	e.log().Warn("evaluation stopped", "reason", v.ErrorMessage(), "input_bytes", len(input))
+}
@@ -0,0 +1,213 @@
+// pkg/engine/parallel.go
+
+package engine
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/internal/eval"
+	"github.com/0xsj/numio/internal/parser"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// EvalParallel evaluates lines like EvalMultiple, with the same results
+// and leaving the engine in the same state, but evaluates lines that
+// don't depend on each other at the same time, across up to GOMAXPROCS
+// goroutines.
+//
+// A line waits for the lines before it that set the variables it uses,
+// and a line setting a variable waits for those before it that use or set
+// it. Lines that use the line history (_, total, "+ 10"), random numbers
+// or user-defined functions, define functions or units, or are pinned,
+// run on their own, after every line before them, as do imports and
+// inputs.
+func (e *Engine) EvalParallel(lines []string) []types.Value {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	results := make([]types.Value, len(lines))
+	var batch []plannedLine
+	for i, input := range lines {
+		// Lines are parsed only once those before them that may define
+		// units have run
+		if isDirective(input) {
+			e.evalBatch(batch, results)
+			batch = batch[:0]
+			results[i] = e.eval(context.Background(), input)
+			continue
+		}
+		p := e.planLine(i, input)
+		if p.line == nil || !p.deps.Ordered {
+			batch = append(batch, p)
+			continue
+		}
+
+		e.evalBatch(batch, results)
+		batch = batch[:0]
+		lr := e.evalBudgeted(e.evaluator, p.line)
+		e.evaluator.RecordLine(lr)
+		results[i] = lr.Value
+	}
+	e.evalBatch(batch, results)
+	e.applyShares(results)
+	return results
+}
+
+// plannedLine is a parsed line and what it needs from the lines around it.
+type plannedLine struct {
+	index int
+	line  *ast.Line   // nil for lines without a statement
+	value types.Value // Result of a line without a statement
+	skip  bool        // A line without a statement still takes a line number
+	deps  eval.Deps
+}
+
+// planLine parses a line for EvalParallel. Blank lines, comments and lines
+// that fail to parse have no statement, and just take up a line number,
+// as in Eval.
+func (e *Engine) planLine(index int, input string) plannedLine {
+	p := plannedLine{index: index, value: types.Empty(), skip: true}
+
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		return p
+	}
+
+	ctx := e.evaluator.Context()
+	if errVal, ok := ctx.CheckInput(input); ok {
+		p.value = errVal
+		return p
+	}
+	line, errs := parser.ParseLineWithUnits(input, ctx.InputLocale(), ctx.Units())
+	if len(errs) > 0 {
+		p.value = types.Error(errs[0].Message)
+		return p
+	}
+	p.skip = false
+	if line.Stmt == nil {
+		return p
+	}
+
+	line.Raw = input
+	p.line = line
+	p.deps = ctx.LineDeps(line)
+	return p
+}
+
+// evalBatch evaluates a run of lines, none of them ordered, then records
+// them in order. Lines are evaluated in waves: each wave holds the lines
+// whose dependencies ran in earlier waves, and is shared out among a pool
+// of workers.
+func (e *Engine) evalBatch(batch []plannedLine, results []types.Value) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var waves [][]int // Positions in batch
+	for i, wave := range batchWaves(batch) {
+		for len(waves) <= wave {
+			waves = append(waves, nil)
+		}
+		waves[wave] = append(waves[wave], i)
+	}
+
+	ctx := e.evaluator.Context()
+	evaluated := make([]eval.LineResult, len(batch))
+	workers := runtime.GOMAXPROCS(0)
+	for _, wave := range waves {
+		if workers == 1 || len(wave) == 1 {
+			for _, i := range wave {
+				if batch[i].line != nil {
+					evaluated[i] = e.evalBudgeted(e.evaluator, batch[i].line)
+				}
+			}
+			continue
+		}
+
+		jobs := make(chan int, len(wave))
+		var wg sync.WaitGroup
+		for range min(workers, len(wave)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Each worker has its own evaluator, sharing the context
+				ev := eval.NewWithContext(ctx)
+				for i := range jobs {
+					evaluated[i] = e.evalBudgeted(ev, batch[i].line)
+				}
+			}()
+		}
+		for _, i := range wave {
+			if batch[i].line != nil {
+				jobs <- i
+			}
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	for i, p := range batch {
+		if p.line == nil {
+			if p.skip {
+				ctx.SkipLine()
+			}
+			results[p.index] = p.value
+			continue
+		}
+		e.evaluator.RecordLine(evaluated[i])
+		results[p.index] = evaluated[i].Value
+	}
+}
+
+// evalBudgeted evaluates a line with ev within the engine's budget.
+func (e *Engine) evalBudgeted(ev *eval.Evaluator, line *ast.Line) eval.LineResult {
+	defer e.startBudget(context.Background(), ev).stop()
+	lr := ev.EvalLineResult(line)
+	if lr.Value.Limited {
+		e.logLimited(lr.Value, line.Raw)
+	}
+	return lr
+}
+
+// batchWaves returns the wave each line of a batch runs in: one after the
+// latest wave of the lines it depends on.
+func batchWaves(batch []plannedLine) []int {
+	waves := make([]int, len(batch))
+	lastSet := make(map[string]int)  // Wave of the last line setting each variable
+	lastUsed := make(map[string]int) // Latest wave of the lines using it since
+
+	for i, p := range batch {
+		if p.line == nil {
+			continue
+		}
+
+		wave := 0
+		for _, name := range p.deps.Reads {
+			if w, ok := lastSet[name]; ok {
+				wave = max(wave, w+1)
+			}
+		}
+		if name := p.deps.Assigns; name != "" {
+			if w, ok := lastSet[name]; ok {
+				wave = max(wave, w+1)
+			}
+			if w, ok := lastUsed[name]; ok {
+				wave = max(wave, w+1)
+			}
+		}
+		waves[i] = wave
+
+		for _, name := range p.deps.Reads {
+			lastUsed[name] = max(lastUsed[name], wave)
+		}
+		if name := p.deps.Assigns; name != "" {
+			lastSet[name] = wave
+			delete(lastUsed, name)
+		}
+	}
+	return waves
+}
@@ -0,0 +1,9 @@
+// pkg/engine/race_test.go
+
+//go:build race
+
+package engine
+
+// raceEnabled is whether the race detector, which allocates as it
+// instruments, is on.
+const raceEnabled = true
@@ -0,0 +1,29 @@
+// pkg/engine/speed_test.go
+
+package engine
+
+import "testing"
+
+func TestSpeedAndAcceleration(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"c", "299792458 m/s"},
+		{"c * 2 s", "599584916 m"},
+		{"g", "9.81 m/s²"},
+		{"g * 3 s", "29.42 m/s"},
+		{"lightyear / c", "365.25 d"},
+		{"100 km / 2 h", "50 km/h"},
+		{"100 km / 50 km/h", "2 h"},
+		{"60 mph * 2 h", "120 mi"},
+		{"60 mph in km/h", "96.56 km/h"},
+		{"10 m/s / 2 s", "5 m/s²"},
+		{"9.8 m/s^2 in m/s²", "9.8 m/s²"},
+		{"700 GB / 50 Mbit/s", "33.41 h"},
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
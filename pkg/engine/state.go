@@ -0,0 +1,237 @@
+// pkg/engine/state.go
+
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/0xsj/numio/internal/ast"
+	"github.com/0xsj/numio/internal/parser"
+	"github.com/0xsj/numio/pkg/cache"
+	"github.com/0xsj/numio/pkg/errors"
+	"github.com/0xsj/numio/pkg/types"
+)
+
+// stateVersion is the current session file format version.
+const stateVersion = 1
+
+// State is the serialized form of an engine session.
+type State struct {
+	Version      int                       `json:"version"`
+	Variables    map[string]map[string]any `json:"variables"`
+	Descriptions map[string]string         `json:"descriptions,omitempty"`
+	Functions    []string                  `json:"functions,omitempty"`
+	Pins         map[string]map[string]any `json:"pins,omitempty"`
+	Lines        []StateLine               `json:"lines"`
+	Settings     StateSettings             `json:"settings"`
+	Rates        []cache.PinnedRate        `json:"rates,omitempty"`
+}
+
+// StateLine is a serialized line result.
+type StateLine struct {
+	Input          string         `json:"input"`
+	Value          map[string]any `json:"value"`
+	IsConsumed     bool           `json:"consumed,omitempty"`
+	IsContinuation bool           `json:"continuation,omitempty"`
+	IsTotal        bool           `json:"total,omitempty"`
+	AssignedVar    string         `json:"assigned,omitempty"`
+	Share          bool           `json:"share,omitempty"`
+}
+
+// StateSettings holds the serialized engine settings.
+type StateSettings struct {
+	Precision int      `json:"precision"`
+	SigFigs   int      `json:"sigfig,omitempty"`
+	Rounding  string   `json:"rounding,omitempty"`
+	Fractions string   `json:"fractions,omitempty"`
+	Currency  string   `json:"currency_display,omitempty"`
+	Locale    string   `json:"locale,omitempty"`
+	Angle     string   `json:"angle,omitempty"`
+	Seed      uint64   `json:"seed,omitempty"`
+	Strict    bool     `json:"strict"`
+	AsOf      bool     `json:"as_of,omitempty"`
+	Region    string   `json:"region"`
+	Lengths   string   `json:"lengths"`
+	DataUnits string   `json:"data_units"`
+	Weekend   []string `json:"weekend"`
+	Holidays  []string `json:"holidays,omitempty"`
+}
+
+// ════════════════════════════════════════════════════════════════
+// SAVE / LOAD
+// ════════════════════════════════════════════════════════════════
+
+// State returns a snapshot of the session: variables, line history,
+// settings, and pinned rates (those set with SetRate).
+func (e *Engine) State() *State {
+	ctx := e.evaluator.Context()
+
+	st := &State{
+		Version:   stateVersion,
+		Variables: make(map[string]map[string]any),
+		Settings: StateSettings{
+			Precision: ctx.Precision(),
+			SigFigs:   ctx.SigFigs(),
+			Rounding:  ctx.Rounding().String(),
+			Fractions: ctx.Fractions().String(),
+			Currency:  ctx.CurrencyDisplay().String(),
+			Locale:    ctx.InputLocale().String(),
+			Angle:     ctx.AngleMode().String(),
+			Seed:      ctx.Seed(),
+			Strict:    ctx.IsStrict(),
+			AsOf:      ctx.ShowAsOf(),
+			Region:    ctx.Region().String(),
+			Lengths:   ctx.LengthDisplay().String(),
+			DataUnits: ctx.DataUnits().String(),
+			Holidays:  ctx.Calendar().Holidays(),
+		},
+		Rates: e.rateCache.PinnedRates(),
+	}
+
+	for name, v := range ctx.Variables() {
+		st.Variables[name] = v.ToMap()
+	}
+	if d := ctx.Descriptions(); len(d) > 0 {
+		st.Descriptions = d
+	}
+	for _, fn := range ctx.Functions() {
+		st.Functions = append(st.Functions, fn.String())
+	}
+	for stmt, v := range ctx.PinnedValues() {
+		if st.Pins == nil {
+			st.Pins = make(map[string]map[string]any)
+		}
+		st.Pins[stmt] = v.ToMap()
+	}
+	sort.Strings(st.Functions)
+	for _, lr := range ctx.Lines() {
+		st.Lines = append(st.Lines, StateLine{
+			Input:          lr.Input,
+			Value:          lr.Value.ToMap(),
+			IsConsumed:     lr.IsConsumed,
+			IsContinuation: lr.IsContinuation,
+			IsTotal:        lr.IsTotal,
+			AssignedVar:    lr.AssignedVar,
+			Share:          lr.Share,
+		})
+	}
+	for _, d := range ctx.Calendar().Weekend() {
+		st.Settings.Weekend = append(st.Settings.Weekend, strings.ToLower(d.String()[:3]))
+	}
+
+	return st
+}
+
+// SaveState writes the session to w as JSON.
+func (e *Engine) SaveState(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e.State())
+}
+
+// LoadState replaces the session with one written by SaveState.
+func (e *Engine) LoadState(r io.Reader) error {
+	var st State
+	if err := json.NewDecoder(r).Decode(&st); err != nil {
+		return errors.ParseErrorf("invalid session: %v", err)
+	}
+	return e.RestoreState(&st)
+}
+
+// RestoreState replaces the session with a snapshot from State.
+func (e *Engine) RestoreState(st *State) error {
+	if st.Version > stateVersion {
+		return errors.ParseErrorf("unsupported session version: %d", st.Version)
+	}
+
+	ctx := e.evaluator.Context()
+	ctx.Clear()
+
+	// Settings
+	ctx.SetPrecision(st.Settings.Precision)
+	ctx.SetSigFigs(st.Settings.SigFigs)
+	if m, ok := types.ParseRoundingMode(st.Settings.Rounding); ok {
+		ctx.SetRounding(m)
+	}
+	if d, ok := types.ParseFractionDisplay(st.Settings.Fractions); ok {
+		ctx.SetFractions(d)
+	}
+	if l, ok := types.ParseInputLocale(st.Settings.Locale); ok {
+		ctx.SetInputLocale(l)
+	}
+	if d, ok := types.ParseCurrencyDisplay(st.Settings.Currency); ok {
+		ctx.SetCurrencyDisplay(d)
+	}
+	if m, ok := types.ParseAngleMode(st.Settings.Angle); ok {
+		ctx.SetAngleMode(m)
+	}
+	if st.Settings.Seed != 0 {
+		ctx.SetSeed(st.Settings.Seed)
+	}
+	ctx.SetStrict(st.Settings.Strict)
+	ctx.SetShowAsOf(st.Settings.AsOf)
+	if r, ok := types.ParseRegion(st.Settings.Region); ok {
+		ctx.SetRegion(r)
+	}
+	if d, ok := types.ParseLengthDisplay(st.Settings.Lengths); ok {
+		ctx.SetLengthDisplay(d)
+	}
+	if d, ok := types.ParseDataUnits(st.Settings.DataUnits); ok {
+		ctx.SetDataUnits(d)
+	}
+	if days, ok := types.ParseWeekdays(strings.Join(st.Settings.Weekend, ",")); ok {
+		ctx.Calendar().SetWeekend(days...)
+	}
+	ctx.Calendar().ClearHolidays()
+	for _, h := range st.Settings.Holidays {
+		if t, ok := types.ParseDate(h); ok {
+			ctx.Calendar().AddHoliday(t)
+		}
+	}
+
+	// Rates first, so restored values can be converted
+	for _, r := range st.Rates {
+		e.rateCache.SetRate(r.From, r.To, r.Rate)
+	}
+
+	// Functions, then variables and history
+	for _, src := range st.Functions {
+		line, errs := parser.ParseLine(src)
+		if len(errs) > 0 {
+			return errors.ParseErrorf("invalid function in session: %s", src)
+		}
+		fn, ok := line.Stmt.(*ast.FuncDefStmt)
+		if !ok {
+			return errors.ParseErrorf("invalid function in session: %s", src)
+		}
+		ctx.DefineFunction(fn)
+	}
+	ctx.ClearPins()
+	for stmt, m := range st.Pins {
+		ctx.Pin(stmt, types.ValueFromMap(m))
+	}
+	for name, m := range st.Variables {
+		ctx.SetVariable(name, types.ValueFromMap(m))
+	}
+	for name, d := range st.Descriptions {
+		ctx.SetDescription(name, d)
+	}
+	for _, sl := range st.Lines {
+		v := types.ValueFromMap(sl.Value)
+		ctx.AddLineResult(LineResult{
+			Input:          sl.Input,
+			Value:          v,
+			IsConsumed:     sl.IsConsumed,
+			IsContinuation: sl.IsContinuation,
+			IsTotal:        sl.IsTotal,
+			AssignedVar:    sl.AssignedVar,
+			Share:          sl.Share,
+		})
+		ctx.SetPrevious(v)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// pkg/engine/units_test.go
+
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCustomUnitsArePerEngine(t *testing.T) {
+	a, b := NewSandboxed(), NewSandboxed()
+
+	if v := a.Eval("unit sprint = 2 weeks"); v.IsError() {
+		t.Fatalf("define: %s", v.ErrorMessage())
+	}
+	if got := a.Format(a.Eval("3 sprints in days")); got != "42 d" {
+		t.Errorf("3 sprints in days = %q, want 42 d", got)
+	}
+	if v := b.Eval("3 sprints in days"); v.IsUnit() {
+		t.Errorf("other engine read sprint: %s", b.Format(v))
+	}
+}
+
+func TestRedefinedUnitKeepsEarlierValues(t *testing.T) {
+	e := NewSandboxed()
+	e.Eval("unit sprint = 2 weeks")
+	before := e.Eval("1 sprint")
+	e.Eval("unit sprint = 3 weeks")
+
+	if got := e.Format(e.Eval("1 sprint in days")); got != "21 d" {
+		t.Errorf("1 sprint in days = %q, want 21 d", got)
+	}
+	if days, _ := before.Unit.ConvertTo(before.Num, e.Eval("1 day").Unit); days != 14 {
+		t.Errorf("earlier sprint changed: %v days, want 14", days)
+	}
+}
+
+func TestClearKeepsDefinedUnits(t *testing.T) {
+	e := NewSandboxed()
+	if err := e.DefineUnit("point", ""); err != nil {
+		t.Fatal(err)
+	}
+	e.Eval("unit sprint = 2 weeks")
+	e.Clear()
+
+	if v := e.Eval("5 points"); v.IsError() || v.Unit == nil {
+		t.Errorf("5 points after Clear = %s", e.Format(v))
+	}
+	if v := e.Eval("1 sprint in days"); v.IsUnit() {
+		t.Errorf("document unit outlived Clear: %s", e.Format(v))
+	}
+
+	clone := e.Clone()
+	e.Eval("unit sprint = 2 weeks")
+	if v := clone.Eval("1 sprint in days"); v.IsUnit() {
+		t.Errorf("clone read a unit defined after it: %s", clone.Format(v))
+	}
+}
+
+// Run with -race: engines define and read units at once.
+func TestCustomUnitsConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e := NewSandboxed()
+			for j := range 50 {
+				e.Eval(fmt.Sprintf("unit block = %d MB", i+j+1))
+				e.Eval("2 blocks/s in MB/s")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAbbreviatedVolumeUnits(t *testing.T) {
+	e := NewSandboxed()
+
+	tests := []struct{ input, want string }{
+		{"1 fl oz in mL", "29.57 mL"},
+		{"1 us fl oz in mL", "29.57 mL"},
+		{"1 uk fl oz in mL", "28.41 mL"},
+		{"1 uk gal in L", "4.55 L"},
+		{"1 imperial gal in L", "4.55 L"},
+		{"1 imperial gallon in L", "4.55 L"},
+		{"1 us gal in L", "3.79 L"},
+		{"1 uk pt in mL", "568.26 mL"},
+		{"1 gal in fl oz", "128 floz"},
+	}
+	for _, tt := range tests {
+		if got := e.Format(e.Eval(tt.input)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -9,14 +9,15 @@ import "fmt"
 type Kind int
 
 const (
-	KindUnknown    Kind = iota // Unknown or uncategorized error
-	KindParse                  // Parsing/lexing error
-	KindEval                   // Evaluation error
-	KindConversion             // Unit/currency conversion error
-	KindDivision               // Division by zero
-	KindVariable               // Undefined variable
-	KindFunction               // Unknown function or bad arguments
-	KindType                   // Type mismatch
+	KindUnknown       Kind = iota // Unknown or uncategorized error
+	KindParse                     // Parsing/lexing error
+	KindEval                      // Evaluation error
+	KindConversion                // Unit/currency conversion error
+	KindDivision                  // Division by zero
+	KindVariable                  // Undefined variable
+	KindFunction                  // Unknown function or bad arguments
+	KindType                      // Type mismatch
+	KindResourceLimit             // Evaluation ran over a time, size or depth limit
 )
 
 func (k Kind) String() string {
@@ -35,6 +36,8 @@ func (k Kind) String() string {
 		return "function error"
 	case KindType:
 		return "type error"
+	case KindResourceLimit:
+		return "resource limit"
 	default:
 		return "unknown error"
 	}
@@ -99,6 +102,16 @@ func (e *Error) WithLine(line int) *Error {
 	}
 }
 
+// ErrResourceLimit matches, with the standard errors.Is, every error of
+// KindResourceLimit.
+var ErrResourceLimit = New(KindResourceLimit, "resource limit exceeded")
+
+// Is reports whether target is ErrResourceLimit and e is of its kind, so
+// errors.Is(err, ErrResourceLimit) holds for any resource limit error.
+func (e *Error) Is(target error) bool {
+	return target == ErrResourceLimit && e.Kind == KindResourceLimit
+}
+
 // Is checks if the error is of a specific kind.
 func Is(err error, kind Kind) bool {
 	if e, ok := err.(*Error); ok {
@@ -154,6 +167,11 @@ func UnknownFunction(name string) *Error {
 	return Newf(KindFunction, "unknown function: %s", name)
 }
 
+// ResourceLimit creates a resource limit error.
+func ResourceLimit(message string) *Error {
+	return New(KindResourceLimit, message)
+}
+
 // TypeError creates a type mismatch error.
 func TypeError(message string) *Error {
 	return New(KindType, message)
@@ -0,0 +1,616 @@
+// pkg/numiopb/numio.proto
+//
+// The numio gRPC service, served by "numio serve --grpc <addr>". The Go
+// code beside this file is generated from it with "make proto".
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: pkg/numiopb/numio.proto
+
+package numiopb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EvalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvalRequest) Reset() {
+	*x = EvalRequest{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvalRequest) ProtoMessage() {}
+
+func (x *EvalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvalRequest.ProtoReflect.Descriptor instead.
+func (*EvalRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EvalRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+type EvalDocumentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The document as lines, or as one string; content wins if both are
+	// set. A leading settings block in content is applied.
+	Lines         []string `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	Content       string   `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvalDocumentRequest) Reset() {
+	*x = EvalDocumentRequest{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvalDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvalDocumentRequest) ProtoMessage() {}
+
+func (x *EvalDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvalDocumentRequest.ProtoReflect.Descriptor instead.
+func (*EvalDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EvalDocumentRequest) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *EvalDocumentRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type EvalDocumentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*Value               `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvalDocumentResponse) Reset() {
+	*x = EvalDocumentResponse{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvalDocumentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvalDocumentResponse) ProtoMessage() {}
+
+func (x *EvalDocumentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvalDocumentResponse.ProtoReflect.Descriptor instead.
+func (*EvalDocumentResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EvalDocumentResponse) GetResults() []*Value {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ConvertRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// An expression ("5 km"), or an amount and the code it is in
+	Input  string   `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Amount *float64 `protobuf:"fixed64,2,opt,name=amount,proto3,oneof" json:"amount,omitempty"`
+	From   string   `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	// Code to convert to: "mi", "EUR", "BTC"
+	To            string `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertRequest) Reset() {
+	*x = ConvertRequest{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertRequest) ProtoMessage() {}
+
+func (x *ConvertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertRequest.ProtoReflect.Descriptor instead.
+func (*ConvertRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ConvertRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetAmount() float64 {
+	if x != nil && x.Amount != nil {
+		return *x.Amount
+	}
+	return 0
+}
+
+func (x *ConvertRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+// Value is a result. Which fields are set depends on its kind.
+type Value struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// empty, number, percentage, currency, unit, metal, crypto, date,
+	// vector, matrix, plot, sparkline or error
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// The value as numio displays it
+	Display string `protobuf:"bytes,2,opt,name=display,proto3" json:"display,omitempty"`
+	// The number, or the amount of a currency, unit, metal or crypto; a
+	// percentage as a fraction (0.2 for 20%)
+	Amount float64 `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Currency, unit, metal or crypto code
+	Code string `protobuf:"bytes,4,opt,name=code,proto3" json:"code,omitempty"`
+	// A whole number too large for amount, in decimal
+	Big string `protobuf:"bytes,5,opt,name=big,proto3" json:"big,omitempty"`
+	// A date, as YYYY-MM-DD
+	Date string `protobuf:"bytes,6,opt,name=date,proto3" json:"date,omitempty"`
+	// A vector's elements, or a matrix's row by row
+	Values  []float64 `protobuf:"fixed64,7,rep,packed,name=values,proto3" json:"values,omitempty"`
+	Columns int32     `protobuf:"varint,8,opt,name=columns,proto3" json:"columns,omitempty"`
+	// A plot's or sparkline's points
+	X []float64 `protobuf:"fixed64,9,rep,packed,name=x,proto3" json:"x,omitempty"`
+	Y []float64 `protobuf:"fixed64,10,rep,packed,name=y,proto3" json:"y,omitempty"`
+	// An error's message, and whether it is from running over a resource
+	// limit such as the evaluation timeout
+	Error         string `protobuf:"bytes,11,opt,name=error,proto3" json:"error,omitempty"`
+	Limit         bool   `protobuf:"varint,12,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Value) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Value) GetDisplay() string {
+	if x != nil {
+		return x.Display
+	}
+	return ""
+}
+
+func (x *Value) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Value) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Value) GetBig() string {
+	if x != nil {
+		return x.Big
+	}
+	return ""
+}
+
+func (x *Value) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *Value) GetValues() []float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Value) GetColumns() int32 {
+	if x != nil {
+		return x.Columns
+	}
+	return 0
+}
+
+func (x *Value) GetX() []float64 {
+	if x != nil {
+		return x.X
+	}
+	return nil
+}
+
+func (x *Value) GetY() []float64 {
+	if x != nil {
+		return x.Y
+	}
+	return nil
+}
+
+func (x *Value) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Value) GetLimit() bool {
+	if x != nil {
+		return x.Limit
+	}
+	return false
+}
+
+type StreamRatesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Currency rates are given against, default USD
+	Base string `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	// Codes to send; empty sends all
+	Codes         []string `protobuf:"bytes,2,rep,name=codes,proto3" json:"codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRatesRequest) Reset() {
+	*x = StreamRatesRequest{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRatesRequest) ProtoMessage() {}
+
+func (x *StreamRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamRatesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamRatesRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *StreamRatesRequest) GetCodes() []string {
+	if x != nil {
+		return x.Codes
+	}
+	return nil
+}
+
+type RatesUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Base  string                 `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	// Units of each code per one of base
+	Rates map[string]float64 `protobuf:"bytes,2,rep,name=rates,proto3" json:"rates,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	// When the rates were last refreshed, in Unix milliseconds; 0 if they
+	// are the built-in fallback rates
+	UpdatedUnixMs int64 `protobuf:"varint,3,opt,name=updated_unix_ms,json=updatedUnixMs,proto3" json:"updated_unix_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RatesUpdate) Reset() {
+	*x = RatesUpdate{}
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RatesUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatesUpdate) ProtoMessage() {}
+
+func (x *RatesUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_numiopb_numio_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatesUpdate.ProtoReflect.Descriptor instead.
+func (*RatesUpdate) Descriptor() ([]byte, []int) {
+	return file_pkg_numiopb_numio_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RatesUpdate) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *RatesUpdate) GetRates() map[string]float64 {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+func (x *RatesUpdate) GetUpdatedUnixMs() int64 {
+	if x != nil {
+		return x.UpdatedUnixMs
+	}
+	return 0
+}
+
+var File_pkg_numiopb_numio_proto protoreflect.FileDescriptor
+
+const file_pkg_numiopb_numio_proto_rawDesc = "" +
+	"\n" +
+	"\x17pkg/numiopb/numio.proto\x12\bnumio.v1\"#\n" +
+	"\vEvalRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\"E\n" +
+	"\x13EvalDocumentRequest\x12\x14\n" +
+	"\x05lines\x18\x01 \x03(\tR\x05lines\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"A\n" +
+	"\x14EvalDocumentResponse\x12)\n" +
+	"\aresults\x18\x01 \x03(\v2\x0f.numio.v1.ValueR\aresults\"r\n" +
+	"\x0eConvertRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12\x1b\n" +
+	"\x06amount\x18\x02 \x01(\x01H\x00R\x06amount\x88\x01\x01\x12\x12\n" +
+	"\x04from\x18\x03 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x04 \x01(\tR\x02toB\t\n" +
+	"\a_amount\"\x81\x02\n" +
+	"\x05Value\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x18\n" +
+	"\adisplay\x18\x02 \x01(\tR\adisplay\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x12\n" +
+	"\x04code\x18\x04 \x01(\tR\x04code\x12\x10\n" +
+	"\x03big\x18\x05 \x01(\tR\x03big\x12\x12\n" +
+	"\x04date\x18\x06 \x01(\tR\x04date\x12\x16\n" +
+	"\x06values\x18\a \x03(\x01R\x06values\x12\x18\n" +
+	"\acolumns\x18\b \x01(\x05R\acolumns\x12\f\n" +
+	"\x01x\x18\t \x03(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\n" +
+	" \x03(\x01R\x01y\x12\x14\n" +
+	"\x05error\x18\v \x01(\tR\x05error\x12\x14\n" +
+	"\x05limit\x18\f \x01(\bR\x05limit\">\n" +
+	"\x12StreamRatesRequest\x12\x12\n" +
+	"\x04base\x18\x01 \x01(\tR\x04base\x12\x14\n" +
+	"\x05codes\x18\x02 \x03(\tR\x05codes\"\xbb\x01\n" +
+	"\vRatesUpdate\x12\x12\n" +
+	"\x04base\x18\x01 \x01(\tR\x04base\x126\n" +
+	"\x05rates\x18\x02 \x03(\v2 .numio.v1.RatesUpdate.RatesEntryR\x05rates\x12&\n" +
+	"\x0fupdated_unix_ms\x18\x03 \x01(\x03R\rupdatedUnixMs\x1a8\n" +
+	"\n" +
+	"RatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x012\x82\x02\n" +
+	"\x05Numio\x12.\n" +
+	"\x04Eval\x12\x15.numio.v1.EvalRequest\x1a\x0f.numio.v1.Value\x12M\n" +
+	"\fEvalDocument\x12\x1d.numio.v1.EvalDocumentRequest\x1a\x1e.numio.v1.EvalDocumentResponse\x124\n" +
+	"\aConvert\x12\x18.numio.v1.ConvertRequest\x1a\x0f.numio.v1.Value\x12D\n" +
+	"\vStreamRates\x12\x1c.numio.v1.StreamRatesRequest\x1a\x15.numio.v1.RatesUpdate0\x01B#Z!github.com/0xsj/numio/pkg/numiopbb\x06proto3"
+
+var (
+	file_pkg_numiopb_numio_proto_rawDescOnce sync.Once
+	file_pkg_numiopb_numio_proto_rawDescData []byte
+)
+
+func file_pkg_numiopb_numio_proto_rawDescGZIP() []byte {
+	file_pkg_numiopb_numio_proto_rawDescOnce.Do(func() {
+		file_pkg_numiopb_numio_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_numiopb_numio_proto_rawDesc), len(file_pkg_numiopb_numio_proto_rawDesc)))
+	})
+	return file_pkg_numiopb_numio_proto_rawDescData
+}
+
+var file_pkg_numiopb_numio_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_pkg_numiopb_numio_proto_goTypes = []any{
+	(*EvalRequest)(nil),          // 0: numio.v1.EvalRequest
+	(*EvalDocumentRequest)(nil),  // 1: numio.v1.EvalDocumentRequest
+	(*EvalDocumentResponse)(nil), // 2: numio.v1.EvalDocumentResponse
+	(*ConvertRequest)(nil),       // 3: numio.v1.ConvertRequest
+	(*Value)(nil),                // 4: numio.v1.Value
+	(*StreamRatesRequest)(nil),   // 5: numio.v1.StreamRatesRequest
+	(*RatesUpdate)(nil),          // 6: numio.v1.RatesUpdate
+	nil,                          // 7: numio.v1.RatesUpdate.RatesEntry
+}
+var file_pkg_numiopb_numio_proto_depIdxs = []int32{
+	4, // 0: numio.v1.EvalDocumentResponse.results:type_name -> numio.v1.Value
+	7, // 1: numio.v1.RatesUpdate.rates:type_name -> numio.v1.RatesUpdate.RatesEntry
+	0, // 2: numio.v1.Numio.Eval:input_type -> numio.v1.EvalRequest
+	1, // 3: numio.v1.Numio.EvalDocument:input_type -> numio.v1.EvalDocumentRequest
+	3, // 4: numio.v1.Numio.Convert:input_type -> numio.v1.ConvertRequest
+	5, // 5: numio.v1.Numio.StreamRates:input_type -> numio.v1.StreamRatesRequest
+	4, // 6: numio.v1.Numio.Eval:output_type -> numio.v1.Value
+	2, // 7: numio.v1.Numio.EvalDocument:output_type -> numio.v1.EvalDocumentResponse
+	4, // 8: numio.v1.Numio.Convert:output_type -> numio.v1.Value
+	6, // 9: numio.v1.Numio.StreamRates:output_type -> numio.v1.RatesUpdate
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_numiopb_numio_proto_init() }
+func file_pkg_numiopb_numio_proto_init() {
+	if File_pkg_numiopb_numio_proto != nil {
+		return
+	}
+	file_pkg_numiopb_numio_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_numiopb_numio_proto_rawDesc), len(file_pkg_numiopb_numio_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_numiopb_numio_proto_goTypes,
+		DependencyIndexes: file_pkg_numiopb_numio_proto_depIdxs,
+		MessageInfos:      file_pkg_numiopb_numio_proto_msgTypes,
+	}.Build()
+	File_pkg_numiopb_numio_proto = out.File
+	file_pkg_numiopb_numio_proto_goTypes = nil
+	file_pkg_numiopb_numio_proto_depIdxs = nil
+}
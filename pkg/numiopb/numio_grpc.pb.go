@@ -0,0 +1,264 @@
+// pkg/numiopb/numio.proto
+//
+// The numio gRPC service, served by "numio serve --grpc <addr>". The Go
+// code beside this file is generated from it with "make proto".
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/numiopb/numio.proto
+
+package numiopb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Numio_Eval_FullMethodName         = "/numio.v1.Numio/Eval"
+	Numio_EvalDocument_FullMethodName = "/numio.v1.Numio/EvalDocument"
+	Numio_Convert_FullMethodName      = "/numio.v1.Numio/Convert"
+	Numio_StreamRates_FullMethodName  = "/numio.v1.Numio/StreamRates"
+)
+
+// NumioClient is the client API for Numio service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Numio evaluates numio expressions and documents. Each call gets a clean
+// engine: variables don't carry over from one call to the next. All
+// engines share one rate cache.
+type NumioClient interface {
+	// Eval evaluates a single line.
+	Eval(ctx context.Context, in *EvalRequest, opts ...grpc.CallOption) (*Value, error)
+	// EvalDocument evaluates lines in order, each able to use the ones
+	// before it, and returns a result per line.
+	EvalDocument(ctx context.Context, in *EvalDocumentRequest, opts ...grpc.CallOption) (*EvalDocumentResponse, error)
+	// Convert converts an amount without evaluating anything else.
+	Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*Value, error)
+	// StreamRates sends the current exchange rates, then again each time
+	// they are refreshed, until the call is cancelled.
+	StreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RatesUpdate], error)
+}
+
+type numioClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNumioClient(cc grpc.ClientConnInterface) NumioClient {
+	return &numioClient{cc}
+}
+
+func (c *numioClient) Eval(ctx context.Context, in *EvalRequest, opts ...grpc.CallOption) (*Value, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Value)
+	err := c.cc.Invoke(ctx, Numio_Eval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numioClient) EvalDocument(ctx context.Context, in *EvalDocumentRequest, opts ...grpc.CallOption) (*EvalDocumentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EvalDocumentResponse)
+	err := c.cc.Invoke(ctx, Numio_EvalDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numioClient) Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*Value, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Value)
+	err := c.cc.Invoke(ctx, Numio_Convert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numioClient) StreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RatesUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Numio_ServiceDesc.Streams[0], Numio_StreamRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRatesRequest, RatesUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Numio_StreamRatesClient = grpc.ServerStreamingClient[RatesUpdate]
+
+// NumioServer is the server API for Numio service.
+// All implementations must embed UnimplementedNumioServer
+// for forward compatibility.
+//
+// Numio evaluates numio expressions and documents. Each call gets a clean
+// engine: variables don't carry over from one call to the next. All
+// engines share one rate cache.
+type NumioServer interface {
+	// Eval evaluates a single line.
+	Eval(context.Context, *EvalRequest) (*Value, error)
+	// EvalDocument evaluates lines in order, each able to use the ones
+	// before it, and returns a result per line.
+	EvalDocument(context.Context, *EvalDocumentRequest) (*EvalDocumentResponse, error)
+	// Convert converts an amount without evaluating anything else.
+	Convert(context.Context, *ConvertRequest) (*Value, error)
+	// StreamRates sends the current exchange rates, then again each time
+	// they are refreshed, until the call is cancelled.
+	StreamRates(*StreamRatesRequest, grpc.ServerStreamingServer[RatesUpdate]) error
+	mustEmbedUnimplementedNumioServer()
+}
+
+// UnimplementedNumioServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNumioServer struct{}
+
+func (UnimplementedNumioServer) Eval(context.Context, *EvalRequest) (*Value, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Eval not implemented")
+}
+func (UnimplementedNumioServer) EvalDocument(context.Context, *EvalDocumentRequest) (*EvalDocumentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvalDocument not implemented")
+}
+func (UnimplementedNumioServer) Convert(context.Context, *ConvertRequest) (*Value, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedNumioServer) StreamRates(*StreamRatesRequest, grpc.ServerStreamingServer[RatesUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamRates not implemented")
+}
+func (UnimplementedNumioServer) mustEmbedUnimplementedNumioServer() {}
+func (UnimplementedNumioServer) testEmbeddedByValue()               {}
+
+// UnsafeNumioServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NumioServer will
+// result in compilation errors.
+type UnsafeNumioServer interface {
+	mustEmbedUnimplementedNumioServer()
+}
+
+func RegisterNumioServer(s grpc.ServiceRegistrar, srv NumioServer) {
+	// If the following call pancis, it indicates UnimplementedNumioServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Numio_ServiceDesc, srv)
+}
+
+func _Numio_Eval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumioServer).Eval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Numio_Eval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumioServer).Eval(ctx, req.(*EvalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numio_EvalDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvalDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumioServer).EvalDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Numio_EvalDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumioServer).EvalDocument(ctx, req.(*EvalDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numio_Convert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumioServer).Convert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Numio_Convert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumioServer).Convert(ctx, req.(*ConvertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numio_StreamRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NumioServer).StreamRates(m, &grpc.GenericServerStream[StreamRatesRequest, RatesUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Numio_StreamRatesServer = grpc.ServerStreamingServer[RatesUpdate]
+
+// Numio_ServiceDesc is the grpc.ServiceDesc for Numio service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Numio_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "numio.v1.Numio",
+	HandlerType: (*NumioServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Eval",
+			Handler:    _Numio_Eval_Handler,
+		},
+		{
+			MethodName: "EvalDocument",
+			Handler:    _Numio_EvalDocument_Handler,
+		},
+		{
+			MethodName: "Convert",
+			Handler:    _Numio_Convert_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamRates",
+			Handler:       _Numio_StreamRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/numiopb/numio.proto",
+}
@@ -0,0 +1,67 @@
+// pkg/types/angle.go
+
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// AngleMode selects how trig functions read plain numbers: sin(90) is
+// sin(90 rad) in AngleRadians and sin(90 deg) in AngleDegrees. Angles
+// with a unit ("90 deg", "1.5 rad") are read the same in both.
+type AngleMode int
+
+const (
+	AngleRadians AngleMode = iota // Plain numbers are radians (default)
+	AngleDegrees                  // Plain numbers are degrees
+)
+
+// String returns the angle mode name.
+func (m AngleMode) String() string {
+	switch m {
+	case AngleRadians:
+		return "rad"
+	case AngleDegrees:
+		return "deg"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAngleMode parses an angle mode name ("rad", "deg", ...).
+// Returns false if the name is not recognized.
+func ParseAngleMode(s string) (AngleMode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "rad", "radian", "radians":
+		return AngleRadians, true
+	case "deg", "degree", "degrees":
+		return AngleDegrees, true
+	default:
+		return AngleRadians, false
+	}
+}
+
+// Radians returns v as an angle in radians: converted from its angle unit,
+// or a plain number read in mode.
+func Radians(v Value, mode AngleMode) (float64, bool) {
+	switch {
+	case v.IsUnit() && v.Unit != nil && v.Unit.Type == UnitTypeAngle:
+		return v.Num * v.Unit.ToBase, true
+	case v.IsNumber():
+		if mode == AngleDegrees {
+			return v.Num * math.Pi / 180, true
+		}
+		return v.Num, true
+	}
+	return 0, false
+}
+
+// AngleValue returns an angle in radians as a value for mode: degrees in
+// AngleDegrees, else a plain number of radians.
+func AngleValue(rad float64, mode AngleMode) Value {
+	if mode == AngleDegrees {
+		return UnitValue(rad*180/math.Pi, ParseUnit("deg"))
+	}
+	return Number(rad)
+}
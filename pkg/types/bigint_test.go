@@ -0,0 +1,50 @@
+// pkg/types/bigint_test.go
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigIntegerKeepsLargeValues(t *testing.T) {
+	tests := []struct {
+		digits string
+		exact  bool
+	}{
+		{"9007199254740992", false},          // 2^53
+		{"9007199254740993", true},           // 2^53 + 1, which a float64 rounds
+		{"2432902008176640000", true},        // 20!, above 2^53
+		{"-9007199254740993", true},          // Negative as well
+		{"15511210043330985984000000", true}, // 25!
+	}
+	for _, tt := range tests {
+		b, _ := new(big.Int).SetString(tt.digits, 10)
+		v := BigInteger(b)
+		if got := v.Big != nil; got != tt.exact {
+			t.Errorf("BigInteger(%s) keeps the big.Int: %v, want %v", tt.digits, got, tt.exact)
+		}
+		if v.Big != nil && v.Big.String() != tt.digits {
+			t.Errorf("BigInteger(%s) keeps %s", tt.digits, v.Big)
+		}
+	}
+}
+
+func TestBigIntegerShowsDigits(t *testing.T) {
+	tests := []struct {
+		digits string
+		opts   FormatOptions
+		want   string
+	}{
+		{"15511210043330985984000001", DefaultFormat, "15,511,210,043,330,985,984,000,001"}, // 25! + 1
+		{"-9007199254740993", DefaultFormat, "-9,007,199,254,740,993"},
+		{"265252859812191058636308480000000", DefaultFormat, "2.652529e32"}, // 30!, too long
+		{"15511210043330985984000001", FormatOptions{SigFigs: 3}, "1.55e25"},
+	}
+	for _, tt := range tests {
+		b, _ := new(big.Int).SetString(tt.digits, 10)
+		if got := BigInteger(b).Format(tt.opts); got != tt.want {
+			t.Errorf("%s shows as %q, want %q", tt.digits, got, tt.want)
+		}
+	}
+}
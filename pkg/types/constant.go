@@ -0,0 +1,136 @@
+// pkg/types/constant.go
+
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// Constant is a named mathematical or physical constant.
+type Constant struct {
+	Name    string   // Name as written: "pi", "c", "avogadro"
+	Aliases []string // Other names: "π", "speed_of_light"
+	Value   float64  // Value in SI units
+	Unit    string   // Unit code: "kg", "m/s", or compound like "J/K"
+	Desc    string   // Description: "Speed of light in vacuum"
+}
+
+// ToValue returns the constant as a value, with its unit if it has one.
+func (c *Constant) ToValue() Value {
+	if c.Unit == "" {
+		return Number(c.Value)
+	}
+	u := ParseUnit(c.Unit)
+	if u == nil {
+		u = CompoundUnit(c.Unit)
+	}
+	if u == nil {
+		return Number(c.Value)
+	}
+	// Value is in SI units
+	if _, size, ok := UnitDims(u); ok {
+		return UnitValue(c.Value/size, u)
+	}
+	return UnitValue(c.Value, u)
+}
+
+// ConstantRegistry holds all known constants.
+type ConstantRegistry struct {
+	byName  map[string]*Constant // Exact names: "g" and "G" differ
+	byLower map[string]*Constant // Names of two or more letters, lowercased
+}
+
+// Global constant registry.
+var constants = newConstantRegistry()
+
+// newConstantRegistry creates and populates the constant registry.
+func newConstantRegistry() *ConstantRegistry {
+	r := &ConstantRegistry{
+		byName:  make(map[string]*Constant),
+		byLower: make(map[string]*Constant),
+	}
+
+	for i := range curatedConstants {
+		r.register(&curatedConstants[i])
+	}
+
+	return r
+}
+
+// register adds a constant to the registry.
+func (r *ConstantRegistry) register(c *Constant) {
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		r.byName[name] = c
+		if len([]rune(name)) > 1 {
+			r.byLower[strings.ToLower(name)] = c
+		}
+	}
+}
+
+// Lookup finds a constant by name. One-letter names are case-sensitive.
+func (r *ConstantRegistry) Lookup(name string) *Constant {
+	if c, ok := r.byName[name]; ok {
+		return c
+	}
+	return r.byLower[strings.ToLower(name)]
+}
+
+// curatedConstants contains the built-in constants (CODATA 2018 values).
+var curatedConstants = []Constant{
+	// ════════════════════════════════════════════════════════════
+	// MATHEMATICAL
+	// ════════════════════════════════════════════════════════════
+	{Name: "pi", Aliases: []string{"π"}, Value: math.Pi, Desc: "Ratio of a circle's circumference to its diameter"},
+	{Name: "tau", Aliases: []string{"τ"}, Value: 2 * math.Pi, Desc: "Full turn in radians (2π)"},
+	{Name: "e", Aliases: []string{"euler"}, Value: math.E, Desc: "Base of the natural logarithm"},
+	{Name: "phi", Aliases: []string{"φ", "golden_ratio"}, Value: math.Phi, Desc: "Golden ratio"},
+	{Name: "sqrt2", Value: math.Sqrt2, Desc: "Square root of 2"},
+
+	// ════════════════════════════════════════════════════════════
+	// PHYSICAL
+	// ════════════════════════════════════════════════════════════
+	{Name: "c", Aliases: []string{"speed_of_light"}, Value: 299792458, Unit: "m/s", Desc: "Speed of light in vacuum"},
+	{Name: "g", Aliases: []string{"gravity"}, Value: 9.80665, Unit: "m/s²", Desc: "Standard acceleration of gravity"},
+	{Name: "G", Aliases: []string{"gravitational_constant"}, Value: 6.67430e-11, Unit: "m³/(kg·s²)", Desc: "Newtonian constant of gravitation"},
+	{Name: "avogadro", Aliases: []string{"N_A"}, Value: 6.02214076e23, Unit: "1/mol", Desc: "Avogadro constant"},
+	{Name: "boltzmann", Aliases: []string{"k_B"}, Value: 1.380649e-23, Unit: "J/K", Desc: "Boltzmann constant"},
+	{Name: "planck", Aliases: []string{"h_planck"}, Value: 6.62607015e-34, Unit: "J·s", Desc: "Planck constant"},
+	{Name: "elementary_charge", Value: 1.602176634e-19, Unit: "A·s", Desc: "Elementary charge"},
+	{Name: "gas_constant", Value: 8.314462618, Unit: "J/(mol·K)", Desc: "Molar gas constant"},
+	{Name: "electron_mass", Value: 9.1093837015e-31, Unit: "kg", Desc: "Electron mass"},
+	{Name: "proton_mass", Value: 1.67262192369e-27, Unit: "kg", Desc: "Proton mass"},
+	{Name: "atm", Value: 101325, Unit: "Pa", Desc: "Standard atmosphere"},
+
+	// ════════════════════════════════════════════════════════════
+	// ASTRONOMICAL
+	// ════════════════════════════════════════════════════════════
+	{Name: "au", Aliases: []string{"astronomical_unit"}, Value: 149597870700, Unit: "au", Desc: "Astronomical unit"},
+	{Name: "lightyear", Aliases: []string{"light_year"}, Value: 9460730472580800, Unit: "ly", Desc: "Distance light travels in a Julian year"},
+	{Name: "earth_radius", Value: 6371000, Unit: "m", Desc: "Mean radius of the Earth"},
+	{Name: "earth_mass", Value: 5.9722e24, Unit: "kg", Desc: "Mass of the Earth"},
+}
+
+// ════════════════════════════════════════════════════════════════
+// PUBLIC API
+// ════════════════════════════════════════════════════════════════
+
+// LookupConstant finds a constant by name or alias.
+// Returns nil if not found.
+func LookupConstant(name string) *Constant {
+	return constants.Lookup(strings.TrimSpace(name))
+}
+
+// AllConstants returns all constants.
+func AllConstants() []Constant {
+	return curatedConstants
+}
+
+// ConstantNames returns the names of all constants.
+func ConstantNames() []string {
+	names := make([]string, len(curatedConstants))
+	for i, c := range curatedConstants {
+		names[i] = c.Name
+	}
+	return names
+}
@@ -13,7 +13,7 @@ type Crypto struct {
 	Name        string   // Full name: "Bitcoin", "Ethereum"
 	Aliases     []string // Natural language aliases
 	CoingeckoID string   // CoinGecko API identifier
-	Decimals    int      // Typical decimal places for display
+	MinorUnits  int      // Decimal places shown: 8 for BTC (one satoshi)
 }
 
 // String returns the crypto code.
@@ -97,9 +97,9 @@ var curatedCryptos = []Crypto{
 		Code:        "BTC",
 		Symbol:      "₿",
 		Name:        "Bitcoin",
-		Aliases:     []string{"bitcoin", "btc", "xbt", "satoshi", "sats"},
+		Aliases:     []string{"bitcoin", "btc", "xbt"},
 		CoingeckoID: "bitcoin",
-		Decimals:    8,
+		MinorUnits:  8,
 	},
 	{
 		Code:        "ETH",
@@ -107,7 +107,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Ethereum",
 		Aliases:     []string{"ethereum", "eth", "ether"},
 		CoingeckoID: "ethereum",
-		Decimals:    6,
+		MinorUnits:  6,
 	},
 
 	// ════════════════════════════════════════════════════════════
@@ -119,7 +119,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Tether",
 		Aliases:     []string{"tether", "usdt"},
 		CoingeckoID: "tether",
-		Decimals:    2,
+		MinorUnits:  2,
 	},
 	{
 		Code:        "USDC",
@@ -127,7 +127,7 @@ var curatedCryptos = []Crypto{
 		Name:        "USD Coin",
 		Aliases:     []string{"usd coin", "usdc"},
 		CoingeckoID: "usd-coin",
-		Decimals:    2,
+		MinorUnits:  2,
 	},
 	{
 		Code:        "DAI",
@@ -135,7 +135,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Dai",
 		Aliases:     []string{"dai", "makerdao"},
 		CoingeckoID: "dai",
-		Decimals:    2,
+		MinorUnits:  2,
 	},
 	{
 		Code:        "BUSD",
@@ -143,7 +143,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Binance USD",
 		Aliases:     []string{"binance usd", "busd"},
 		CoingeckoID: "binance-usd",
-		Decimals:    2,
+		MinorUnits:  2,
 	},
 
 	// ════════════════════════════════════════════════════════════
@@ -155,7 +155,7 @@ var curatedCryptos = []Crypto{
 		Name:        "BNB",
 		Aliases:     []string{"bnb", "binance coin", "binance"},
 		CoingeckoID: "binancecoin",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "SOL",
@@ -163,7 +163,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Solana",
 		Aliases:     []string{"solana", "sol"},
 		CoingeckoID: "solana",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "XRP",
@@ -171,7 +171,7 @@ var curatedCryptos = []Crypto{
 		Name:        "XRP",
 		Aliases:     []string{"xrp", "ripple"},
 		CoingeckoID: "ripple",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "ADA",
@@ -179,7 +179,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Cardano",
 		Aliases:     []string{"cardano", "ada"},
 		CoingeckoID: "cardano",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "DOGE",
@@ -187,7 +187,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Dogecoin",
 		Aliases:     []string{"dogecoin", "doge"},
 		CoingeckoID: "dogecoin",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "DOT",
@@ -195,7 +195,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Polkadot",
 		Aliases:     []string{"polkadot", "dot"},
 		CoingeckoID: "polkadot",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "MATIC",
@@ -203,7 +203,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Polygon",
 		Aliases:     []string{"polygon", "matic"},
 		CoingeckoID: "matic-network",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "AVAX",
@@ -211,7 +211,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Avalanche",
 		Aliases:     []string{"avalanche", "avax"},
 		CoingeckoID: "avalanche-2",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "LTC",
@@ -219,7 +219,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Litecoin",
 		Aliases:     []string{"litecoin", "ltc"},
 		CoingeckoID: "litecoin",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "LINK",
@@ -227,7 +227,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Chainlink",
 		Aliases:     []string{"chainlink", "link"},
 		CoingeckoID: "chainlink",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "ATOM",
@@ -235,7 +235,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Cosmos",
 		Aliases:     []string{"cosmos", "atom"},
 		CoingeckoID: "cosmos",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "UNI",
@@ -243,7 +243,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Uniswap",
 		Aliases:     []string{"uniswap", "uni"},
 		CoingeckoID: "uniswap",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "XLM",
@@ -251,7 +251,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Stellar",
 		Aliases:     []string{"stellar", "xlm", "lumens"},
 		CoingeckoID: "stellar",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "ALGO",
@@ -259,7 +259,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Algorand",
 		Aliases:     []string{"algorand", "algo"},
 		CoingeckoID: "algorand",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "TON",
@@ -267,7 +267,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Toncoin",
 		Aliases:     []string{"toncoin", "ton", "telegram"},
 		CoingeckoID: "the-open-network",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 
 	// ════════════════════════════════════════════════════════════
@@ -279,7 +279,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Aave",
 		Aliases:     []string{"aave"},
 		CoingeckoID: "aave",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "MKR",
@@ -287,7 +287,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Maker",
 		Aliases:     []string{"maker", "mkr"},
 		CoingeckoID: "maker",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "CRV",
@@ -295,7 +295,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Curve",
 		Aliases:     []string{"curve", "crv"},
 		CoingeckoID: "curve-dao-token",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "NEAR",
@@ -303,7 +303,7 @@ var curatedCryptos = []Crypto{
 		Name:        "NEAR Protocol",
 		Aliases:     []string{"near", "near protocol"},
 		CoingeckoID: "near",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "APT",
@@ -311,7 +311,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Aptos",
 		Aliases:     []string{"aptos", "apt"},
 		CoingeckoID: "aptos",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "ARB",
@@ -319,7 +319,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Arbitrum",
 		Aliases:     []string{"arbitrum", "arb"},
 		CoingeckoID: "arbitrum",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "OP",
@@ -327,7 +327,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Optimism",
 		Aliases:     []string{"optimism", "op"},
 		CoingeckoID: "optimism",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 
 	// ════════════════════════════════════════════════════════════
@@ -339,7 +339,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Shiba Inu",
 		Aliases:     []string{"shiba", "shib", "shiba inu"},
 		CoingeckoID: "shiba-inu",
-		Decimals:    8,
+		MinorUnits:  8,
 	},
 	{
 		Code:        "PEPE",
@@ -347,7 +347,7 @@ var curatedCryptos = []Crypto{
 		Name:        "Pepe",
 		Aliases:     []string{"pepe"},
 		CoingeckoID: "pepe",
-		Decimals:    8,
+		MinorUnits:  8,
 	},
 	{
 		Code:        "WIF",
@@ -355,7 +355,7 @@ var curatedCryptos = []Crypto{
 		Name:        "dogwifhat",
 		Aliases:     []string{"dogwifhat", "wif"},
 		CoingeckoID: "dogwifcoin",
-		Decimals:    4,
+		MinorUnits:  4,
 	},
 	{
 		Code:        "BONK",
@@ -363,10 +363,61 @@ var curatedCryptos = []Crypto{
 		Name:        "Bonk",
 		Aliases:     []string{"bonk"},
 		CoingeckoID: "bonk",
-		Decimals:    8,
+		MinorUnits:  8,
 	},
 }
 
+// ════════════════════════════════════════════════════════════════
+// SUB-UNITS
+// ════════════════════════════════════════════════════════════════
+
+// CryptoUnit is a named fraction of a cryptocurrency, as satoshis are of
+// bitcoin: "50000 sats", "21 gwei".
+type CryptoUnit struct {
+	Code    string   // Singular name: "sat", "gwei"
+	Plural  string   // Name of other amounts than one: "sats"
+	Aliases []string // Natural language aliases
+	Crypto  string   // Code of the crypto divided: "BTC"
+	Factor  float64  // Amount of the crypto in one: 1e-8
+}
+
+// Of returns the crypto the unit divides.
+func (u *CryptoUnit) Of() *Crypto {
+	return cryptos.byCode[u.Crypto]
+}
+
+// Name returns the unit's name for an amount: "1 sat", "2 sats".
+func (u *CryptoUnit) Name(amount float64) string {
+	if amount == 1 || u.Plural == "" {
+		return u.Code
+	}
+	return u.Plural
+}
+
+// curatedCryptoUnits contains the sub-units in common use.
+var curatedCryptoUnits = []CryptoUnit{
+	{Code: "sat", Plural: "sats", Aliases: []string{"sat", "sats", "satoshi", "satoshis"}, Crypto: "BTC", Factor: 1e-8},
+	{Code: "gwei", Aliases: []string{"gwei", "shannon"}, Crypto: "ETH", Factor: 1e-9},
+	{Code: "wei", Aliases: []string{"wei"}, Crypto: "ETH", Factor: 1e-18},
+}
+
+// cryptoUnits indexes the sub-units by alias.
+var cryptoUnits = func() map[string]*CryptoUnit {
+	m := make(map[string]*CryptoUnit)
+	for i := range curatedCryptoUnits {
+		for _, alias := range curatedCryptoUnits[i].Aliases {
+			m[alias] = &curatedCryptoUnits[i]
+		}
+	}
+	return m
+}()
+
+// ParseCryptoUnit parses the name of a crypto sub-unit ("sats", "gwei"),
+// ignoring case. Returns nil if not found.
+func ParseCryptoUnit(s string) *CryptoUnit {
+	return cryptoUnits[strings.ToLower(strings.TrimSpace(s))]
+}
+
 // ════════════════════════════════════════════════════════════════
 // PUBLIC API
 // ════════════════════════════════════════════════════════════════
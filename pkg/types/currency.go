@@ -5,6 +5,8 @@ package types
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Currency represents a fiat currency.
@@ -14,6 +16,7 @@ type Currency struct {
 	Name        string   // Full name: "US Dollar", "Euro", "Turkish Lira"
 	Aliases     []string // Natural language aliases: "dollars", "bucks"
 	SymbolAfter bool     // true if symbol comes after amount (100₺ vs $100)
+	MinorUnits  int      // Decimal places of the minor unit: 2 (cents), 0 for JPY, 3 for BHD
 }
 
 // String returns the currency code.
@@ -151,8 +154,9 @@ func (r *CurrencyRegistry) register(c *Currency) {
 	r.byCode[strings.ToUpper(c.Code)] = c
 	r.byCode[strings.ToLower(c.Code)] = c
 
-	// By symbol
-	if c.Symbol != "" {
+	// By symbol. A symbol several currencies share belongs to the first
+	// listed: ¥ to JPY, not CNY; kr to SEK
+	if _, taken := r.bySymbol[c.Symbol]; c.Symbol != "" && !taken {
 		r.bySymbol[c.Symbol] = c
 	}
 
@@ -188,74 +192,85 @@ var curatedCurrencies = []Currency{
 	// MAJOR CURRENCIES
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "USD",
-		Symbol:  "$",
-		Name:    "US Dollar",
-		Aliases: []string{"dollar", "dollars", "usd", "bucks", "buck"},
+		Code:       "USD",
+		Symbol:     "$",
+		Name:       "US Dollar",
+		Aliases:    []string{"dollar", "dollars", "usd", "bucks", "buck"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "EUR",
-		Symbol:  "€",
-		Name:    "Euro",
-		Aliases: []string{"euro", "euros", "eur"},
+		Code:       "EUR",
+		Symbol:     "€",
+		Name:       "Euro",
+		Aliases:    []string{"euro", "euros", "eur"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "GBP",
-		Symbol:  "£",
-		Name:    "British Pound",
-		Aliases: []string{"pound", "pounds", "gbp", "quid", "sterling"},
+		Code:       "GBP",
+		Symbol:     "£",
+		Name:       "British Pound",
+		Aliases:    []string{"pound", "pounds", "gbp", "quid", "sterling"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "JPY",
-		Symbol:  "¥",
-		Name:    "Japanese Yen",
-		Aliases: []string{"yen", "jpy"},
+		Code:       "JPY",
+		Symbol:     "¥",
+		Name:       "Japanese Yen",
+		Aliases:    []string{"yen", "jpy"},
+		MinorUnits: 0,
 	},
 	{
-		Code:    "CHF",
-		Symbol:  "CHF",
-		Name:    "Swiss Franc",
-		Aliases: []string{"franc", "francs", "chf", "swiss franc", "swiss francs"},
+		Code:       "CHF",
+		Symbol:     "CHF",
+		Name:       "Swiss Franc",
+		Aliases:    []string{"franc", "francs", "chf", "swiss franc", "swiss francs"},
+		MinorUnits: 2,
 	},
 
 	// ════════════════════════════════════════════════════════════
 	// AMERICAS
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "CAD",
-		Symbol:  "C$",
-		Name:    "Canadian Dollar",
-		Aliases: []string{"cad", "canadian dollar", "canadian dollars", "loonie"},
+		Code:       "CAD",
+		Symbol:     "C$",
+		Name:       "Canadian Dollar",
+		Aliases:    []string{"cad", "canadian dollar", "canadian dollars", "loonie"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "MXN",
-		Symbol:  "MX$",
-		Name:    "Mexican Peso",
-		Aliases: []string{"mxn", "peso", "pesos", "mexican peso"},
+		Code:       "MXN",
+		Symbol:     "MX$",
+		Name:       "Mexican Peso",
+		Aliases:    []string{"mxn", "peso", "pesos", "mexican peso"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "BRL",
-		Symbol:  "R$",
-		Name:    "Brazilian Real",
-		Aliases: []string{"brl", "real", "reais", "brazilian real"},
+		Code:       "BRL",
+		Symbol:     "R$",
+		Name:       "Brazilian Real",
+		Aliases:    []string{"brl", "real", "reais", "brazilian real"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "ARS",
-		Symbol:  "AR$",
-		Name:    "Argentine Peso",
-		Aliases: []string{"ars", "argentine peso"},
+		Code:       "ARS",
+		Symbol:     "AR$",
+		Name:       "Argentine Peso",
+		Aliases:    []string{"ars", "argentine peso"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "CLP",
-		Symbol:  "CL$",
-		Name:    "Chilean Peso",
-		Aliases: []string{"clp", "chilean peso"},
+		Code:       "CLP",
+		Symbol:     "CL$",
+		Name:       "Chilean Peso",
+		Aliases:    []string{"clp", "chilean peso"},
+		MinorUnits: 0,
 	},
 	{
-		Code:    "COP",
-		Symbol:  "CO$",
-		Name:    "Colombian Peso",
-		Aliases: []string{"cop", "colombian peso"},
+		Code:       "COP",
+		Symbol:     "CO$",
+		Name:       "Colombian Peso",
+		Aliases:    []string{"cop", "colombian peso"},
+		MinorUnits: 2,
 	},
 
 	// ════════════════════════════════════════════════════════════
@@ -267,6 +282,7 @@ var curatedCurrencies = []Currency{
 		Name:        "Russian Ruble",
 		Aliases:     []string{"rub", "ruble", "rubles", "rouble", "roubles"},
 		SymbolAfter: true,
+		MinorUnits:  2,
 	},
 	{
 		Code:        "UAH",
@@ -274,6 +290,7 @@ var curatedCurrencies = []Currency{
 		Name:        "Ukrainian Hryvnia",
 		Aliases:     []string{"uah", "hryvnia", "hryvnias"},
 		SymbolAfter: true,
+		MinorUnits:  2,
 	},
 	{
 		Code:        "PLN",
@@ -281,42 +298,49 @@ var curatedCurrencies = []Currency{
 		Name:        "Polish Zloty",
 		Aliases:     []string{"pln", "zloty", "zlotys", "złoty"},
 		SymbolAfter: true,
+		MinorUnits:  2,
 	},
 	{
-		Code:    "CZK",
-		Symbol:  "Kč",
-		Name:    "Czech Koruna",
-		Aliases: []string{"czk", "koruna", "korunas", "czech koruna"},
+		Code:       "CZK",
+		Symbol:     "Kč",
+		Name:       "Czech Koruna",
+		Aliases:    []string{"czk", "koruna", "korunas", "czech koruna"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "SEK",
-		Symbol:  "kr",
-		Name:    "Swedish Krona",
-		Aliases: []string{"sek", "swedish krona", "swedish kronor"},
+		Code:       "SEK",
+		Symbol:     "kr",
+		Name:       "Swedish Krona",
+		Aliases:    []string{"sek", "swedish krona", "swedish kronor"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "NOK",
-		Symbol:  "kr",
-		Name:    "Norwegian Krone",
-		Aliases: []string{"nok", "norwegian krone", "norwegian kroner"},
+		Code:       "NOK",
+		Symbol:     "kr",
+		Name:       "Norwegian Krone",
+		Aliases:    []string{"nok", "norwegian krone", "norwegian kroner"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "DKK",
-		Symbol:  "kr",
-		Name:    "Danish Krone",
-		Aliases: []string{"dkk", "danish krone", "danish kroner"},
+		Code:       "DKK",
+		Symbol:     "kr",
+		Name:       "Danish Krone",
+		Aliases:    []string{"dkk", "danish krone", "danish kroner"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "HUF",
-		Symbol:  "Ft",
-		Name:    "Hungarian Forint",
-		Aliases: []string{"huf", "forint", "forints"},
+		Code:       "HUF",
+		Symbol:     "Ft",
+		Name:       "Hungarian Forint",
+		Aliases:    []string{"huf", "forint", "forints"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "RON",
-		Symbol:  "lei",
-		Name:    "Romanian Leu",
-		Aliases: []string{"ron", "leu", "lei"},
+		Code:       "RON",
+		Symbol:     "lei",
+		Name:       "Romanian Leu",
+		Aliases:    []string{"ron", "leu", "lei"},
+		MinorUnits: 2,
 	},
 
 	// ════════════════════════════════════════════════════════════
@@ -328,162 +352,194 @@ var curatedCurrencies = []Currency{
 		Name:        "Turkish Lira",
 		Aliases:     []string{"try", "tl", "lira", "liras", "turkish lira", "turk lirasi"},
 		SymbolAfter: true,
+		MinorUnits:  2,
 	},
 	{
-		Code:    "ILS",
-		Symbol:  "₪",
-		Name:    "Israeli Shekel",
-		Aliases: []string{"ils", "shekel", "shekels", "nis"},
+		Code:       "ILS",
+		Symbol:     "₪",
+		Name:       "Israeli Shekel",
+		Aliases:    []string{"ils", "shekel", "shekels", "nis"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "AED",
-		Symbol:  "د.إ",
-		Name:    "UAE Dirham",
-		Aliases: []string{"aed", "dirham", "dirhams", "emirati dirham"},
+		Code:       "AED",
+		Symbol:     "د.إ",
+		Name:       "UAE Dirham",
+		Aliases:    []string{"aed", "dirham", "dirhams", "emirati dirham"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "SAR",
-		Symbol:  "﷼",
-		Name:    "Saudi Riyal",
-		Aliases: []string{"sar", "riyal", "riyals", "saudi riyal"},
+		Code:       "SAR",
+		Symbol:     "﷼",
+		Name:       "Saudi Riyal",
+		Aliases:    []string{"sar", "riyal", "riyals", "saudi riyal"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "QAR",
-		Symbol:  "﷼",
-		Name:    "Qatari Riyal",
-		Aliases: []string{"qar", "qatari riyal"},
+		Code:       "QAR",
+		Symbol:     "﷼",
+		Name:       "Qatari Riyal",
+		Aliases:    []string{"qar", "qatari riyal"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "KWD",
-		Symbol:  "د.ك",
-		Name:    "Kuwaiti Dinar",
-		Aliases: []string{"kwd", "kuwaiti dinar"},
+		Code:       "KWD",
+		Symbol:     "د.ك",
+		Name:       "Kuwaiti Dinar",
+		Aliases:    []string{"kwd", "kuwaiti dinar"},
+		MinorUnits: 3,
 	},
 	{
-		Code:    "EGP",
-		Symbol:  "E£",
-		Name:    "Egyptian Pound",
-		Aliases: []string{"egp", "egyptian pound"},
+		Code:       "BHD",
+		Symbol:     "د.ب",
+		Name:       "Bahraini Dinar",
+		Aliases:    []string{"bhd", "bahraini dinar"},
+		MinorUnits: 3,
+	},
+	{
+		Code:       "EGP",
+		Symbol:     "E£",
+		Name:       "Egyptian Pound",
+		Aliases:    []string{"egp", "egyptian pound"},
+		MinorUnits: 2,
 	},
 
 	// ════════════════════════════════════════════════════════════
 	// ASIA PACIFIC
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "CNY",
-		Symbol:  "¥",
-		Name:    "Chinese Yuan",
-		Aliases: []string{"cny", "yuan", "rmb", "renminbi", "chinese yuan"},
+		Code:       "CNY",
+		Symbol:     "¥",
+		Name:       "Chinese Yuan",
+		Aliases:    []string{"cny", "yuan", "rmb", "renminbi", "chinese yuan"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "HKD",
-		Symbol:  "HK$",
-		Name:    "Hong Kong Dollar",
-		Aliases: []string{"hkd", "hong kong dollar"},
+		Code:       "HKD",
+		Symbol:     "HK$",
+		Name:       "Hong Kong Dollar",
+		Aliases:    []string{"hkd", "hong kong dollar"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "TWD",
-		Symbol:  "NT$",
-		Name:    "Taiwan Dollar",
-		Aliases: []string{"twd", "taiwan dollar", "nt dollar"},
+		Code:       "TWD",
+		Symbol:     "NT$",
+		Name:       "Taiwan Dollar",
+		Aliases:    []string{"twd", "taiwan dollar", "nt dollar"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "KRW",
-		Symbol:  "₩",
-		Name:    "South Korean Won",
-		Aliases: []string{"krw", "won", "korean won"},
+		Code:       "KRW",
+		Symbol:     "₩",
+		Name:       "South Korean Won",
+		Aliases:    []string{"krw", "won", "korean won"},
+		MinorUnits: 0,
 	},
 	{
-		Code:    "INR",
-		Symbol:  "₹",
-		Name:    "Indian Rupee",
-		Aliases: []string{"inr", "rupee", "rupees", "indian rupee"},
+		Code:       "INR",
+		Symbol:     "₹",
+		Name:       "Indian Rupee",
+		Aliases:    []string{"inr", "rupee", "rupees", "indian rupee"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "PKR",
-		Symbol:  "₨",
-		Name:    "Pakistani Rupee",
-		Aliases: []string{"pkr", "pakistani rupee"},
+		Code:       "PKR",
+		Symbol:     "₨",
+		Name:       "Pakistani Rupee",
+		Aliases:    []string{"pkr", "pakistani rupee"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "BDT",
-		Symbol:  "৳",
-		Name:    "Bangladeshi Taka",
-		Aliases: []string{"bdt", "taka", "bangladeshi taka"},
+		Code:       "BDT",
+		Symbol:     "৳",
+		Name:       "Bangladeshi Taka",
+		Aliases:    []string{"bdt", "taka", "bangladeshi taka"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "SGD",
-		Symbol:  "S$",
-		Name:    "Singapore Dollar",
-		Aliases: []string{"sgd", "singapore dollar"},
+		Code:       "SGD",
+		Symbol:     "S$",
+		Name:       "Singapore Dollar",
+		Aliases:    []string{"sgd", "singapore dollar"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "MYR",
-		Symbol:  "RM",
-		Name:    "Malaysian Ringgit",
-		Aliases: []string{"myr", "ringgit", "malaysian ringgit"},
+		Code:       "MYR",
+		Symbol:     "RM",
+		Name:       "Malaysian Ringgit",
+		Aliases:    []string{"myr", "ringgit", "malaysian ringgit"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "THB",
-		Symbol:  "฿",
-		Name:    "Thai Baht",
-		Aliases: []string{"thb", "baht", "thai baht"},
+		Code:       "THB",
+		Symbol:     "฿",
+		Name:       "Thai Baht",
+		Aliases:    []string{"thb", "baht", "thai baht"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "IDR",
-		Symbol:  "Rp",
-		Name:    "Indonesian Rupiah",
-		Aliases: []string{"idr", "rupiah", "indonesian rupiah"},
+		Code:       "IDR",
+		Symbol:     "Rp",
+		Name:       "Indonesian Rupiah",
+		Aliases:    []string{"idr", "rupiah", "indonesian rupiah"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "VND",
-		Symbol:  "₫",
-		Name:    "Vietnamese Dong",
-		Aliases: []string{"vnd", "dong", "vietnamese dong"},
+		Code:       "VND",
+		Symbol:     "₫",
+		Name:       "Vietnamese Dong",
+		Aliases:    []string{"vnd", "dong", "vietnamese dong"},
+		MinorUnits: 0,
 	},
 	{
-		Code:    "PHP",
-		Symbol:  "₱",
-		Name:    "Philippine Peso",
-		Aliases: []string{"php", "philippine peso"},
+		Code:       "PHP",
+		Symbol:     "₱",
+		Name:       "Philippine Peso",
+		Aliases:    []string{"php", "philippine peso"},
+		MinorUnits: 2,
 	},
 
 	// ════════════════════════════════════════════════════════════
 	// OCEANIA
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "AUD",
-		Symbol:  "A$",
-		Name:    "Australian Dollar",
-		Aliases: []string{"aud", "australian dollar", "australian dollars", "aussie dollar"},
+		Code:       "AUD",
+		Symbol:     "A$",
+		Name:       "Australian Dollar",
+		Aliases:    []string{"aud", "australian dollar", "australian dollars", "aussie dollar"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "NZD",
-		Symbol:  "NZ$",
-		Name:    "New Zealand Dollar",
-		Aliases: []string{"nzd", "new zealand dollar", "kiwi dollar"},
+		Code:       "NZD",
+		Symbol:     "NZ$",
+		Name:       "New Zealand Dollar",
+		Aliases:    []string{"nzd", "new zealand dollar", "kiwi dollar"},
+		MinorUnits: 2,
 	},
 
 	// ════════════════════════════════════════════════════════════
 	// AFRICA
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "ZAR",
-		Symbol:  "R",
-		Name:    "South African Rand",
-		Aliases: []string{"zar", "rand", "south african rand"},
+		Code:       "ZAR",
+		Symbol:     "R",
+		Name:       "South African Rand",
+		Aliases:    []string{"zar", "rand", "south african rand"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "NGN",
-		Symbol:  "₦",
-		Name:    "Nigerian Naira",
-		Aliases: []string{"ngn", "naira", "nigerian naira"},
+		Code:       "NGN",
+		Symbol:     "₦",
+		Name:       "Nigerian Naira",
+		Aliases:    []string{"ngn", "naira", "nigerian naira"},
+		MinorUnits: 2,
 	},
 	{
-		Code:    "KES",
-		Symbol:  "KSh",
-		Name:    "Kenyan Shilling",
-		Aliases: []string{"kes", "kenyan shilling"},
+		Code:       "KES",
+		Symbol:     "KSh",
+		Name:       "Kenyan Shilling",
+		Aliases:    []string{"kes", "kenyan shilling"},
+		MinorUnits: 2,
 	},
 }
 
@@ -519,12 +575,38 @@ func CurrencyFromCode(code string) *Currency {
 
 	// Create dynamic currency (no symbol, just code)
 	return &Currency{
-		Code:   code,
-		Symbol: code,
-		Name:   code,
+		Code:       code,
+		Symbol:     code,
+		Name:       code,
+		MinorUnits: minorUnits(code),
 	}
 }
 
+// minorUnits returns the ISO 4217 minor unit digits of a currency code
+// not in the curated list.
+func minorUnits(code string) int {
+	switch code {
+	case "BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG",
+		"RWF", "UGX", "UYI", "VND", "VUV", "XAF", "XOF", "XPF":
+		return 0
+	case "BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND":
+		return 3
+	case "CLF", "UYW":
+		return 4
+	}
+	return 2
+}
+
+// ShowsSymbol reports whether amounts are written with the symbol rather
+// than the code. Only a one-rune, non-letter symbol the currency owns
+// reads back as it: ¥ is JPY's, so CNY shows its code, and "C$5" would
+// be C, $5.
+func (c *Currency) ShowsSymbol() bool {
+	r, size := utf8.DecodeRuneInString(c.Symbol)
+	return size > 0 && size == len(c.Symbol) && !unicode.IsLetter(r) &&
+		currencies.bySymbol[c.Symbol] == c
+}
+
 // LookupCurrencyBySymbol finds a currency by its symbol.
 func LookupCurrencyBySymbol(symbol string) *Currency {
 	return currencies.bySymbol[symbol]
@@ -553,7 +635,7 @@ func CurrencyCodes() []string {
 func CurrencySymbols() map[string]string {
 	symbols := make(map[string]string)
 	for _, c := range curatedCurrencies {
-		if c.Symbol != "" && c.Symbol != c.Code {
+		if _, taken := symbols[c.Symbol]; c.Symbol != "" && c.Symbol != c.Code && !taken {
 			symbols[c.Symbol] = c.Code
 		}
 	}
@@ -0,0 +1,84 @@
+// internal/types/data.go
+
+package types
+
+import "strings"
+
+// DataUnits selects whether KB, MB, GB, ... are binary (1024-based)
+// or decimal (1000-based). IEC units (KiB, MiB, ...) are always binary,
+// and bits (Kbit, Mbit, Gbit) always decimal, as link speeds are.
+type DataUnits int
+
+const (
+	DataBinary  DataUnits = iota // 1 KB = 1024 B (default)
+	DataDecimal                  // 1 KB = 1000 B
+)
+
+// String returns the data units name.
+func (d DataUnits) String() string {
+	switch d {
+	case DataBinary:
+		return "binary"
+	case DataDecimal:
+		return "decimal"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseDataUnits parses a data units name ("binary", "decimal", ...).
+// Returns false if the name is not recognized.
+func ParseDataUnits(s string) (DataUnits, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "binary", "iec", "1024":
+		return DataBinary, true
+	case "decimal", "si", "1000":
+		return DataDecimal, true
+	default:
+		return DataBinary, false
+	}
+}
+
+// decimalSizes maps ambiguous data unit codes to their decimal size in bytes.
+var decimalSizes = map[string]float64{
+	"KB": 1e3,
+	"MB": 1e6,
+	"GB": 1e9,
+	"TB": 1e12,
+	"PB": 1e15,
+}
+
+// decimalDataUnits holds the decimal variants of the ambiguous data units.
+var decimalDataUnits = newDecimalDataUnits()
+
+// newDecimalDataUnits derives decimal variants from the curated units.
+func newDecimalDataUnits() map[string]*Unit {
+	m := make(map[string]*Unit, len(decimalSizes))
+	for _, u := range curatedUnits {
+		if size, ok := decimalSizes[u.Code]; ok {
+			dec := u
+			dec.Aliases = nil
+			dec.ToBase = size
+			m[u.Code] = &dec
+		}
+	}
+	return m
+}
+
+// DataUnit returns the variant of u used with the given data units.
+// Units other than KB, MB, GB, TB and PB (alone or in a data rate such as
+// MB/s) are returned unchanged.
+func DataUnit(u *Unit, d DataUnits) *Unit {
+	if u == nil || d != DataDecimal {
+		return u
+	}
+	if dec, ok := decimalDataUnits[u.Code]; ok {
+		return dec
+	}
+	if data, per, ok := RateParts(u); ok {
+		if dec, ok := decimalDataUnits[data.Code]; ok {
+			return RateUnit(dec, per)
+		}
+	}
+	return u
+}
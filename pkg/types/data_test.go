@@ -0,0 +1,37 @@
+// pkg/types/data_test.go
+
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBitsAreAlwaysDecimal(t *testing.T) {
+	tests := []struct {
+		code  string
+		bytes float64
+	}{
+		{"Kbit", 125},
+		{"Mbit", 125000},
+		{"Gbit", 125000000},
+		{"Mbit/s", 125000},
+	}
+	for _, tt := range tests {
+		for _, d := range []DataUnits{DataBinary, DataDecimal} {
+			u := DataUnit(ParseUnit(tt.code), d)
+			if u == nil || math.Abs(u.ToBase-tt.bytes) > 1e-9 {
+				t.Errorf("%s (%s) is %v bytes, want %g", tt.code, d, u, tt.bytes)
+			}
+		}
+	}
+}
+
+func TestBytesFollowDataUnits(t *testing.T) {
+	if got := DataUnit(ParseUnit("MB"), DataBinary).ToBase; got != 1<<20 {
+		t.Errorf("binary MB = %g bytes", got)
+	}
+	if got := DataUnit(ParseUnit("MB"), DataDecimal).ToBase; got != 1e6 {
+		t.Errorf("decimal MB = %g bytes", got)
+	}
+}
@@ -0,0 +1,203 @@
+// internal/types/date.go
+
+package types
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xsj/numio/pkg/errors"
+)
+
+// ════════════════════════════════════════════════════════════════
+// DATE PARSING
+// ════════════════════════════════════════════════════════════════
+
+// months maps month names and abbreviations to months.
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January,
+	"feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"may": time.May,
+	"jun": time.June, "june": time.June,
+	"jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November,
+	"dec": time.December, "december": time.December,
+}
+
+// ParseMonth parses a month name or abbreviation ("Mar", "march").
+func ParseMonth(s string) (time.Month, bool) {
+	m, ok := months[strings.ToLower(strings.TrimSpace(s))]
+	return m, ok
+}
+
+// weekdays maps weekday names and abbreviations to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseWeekdays parses a comma-separated weekday list ("sat,sun").
+// Returns false if any name is not recognized.
+func ParseWeekdays(s string) ([]time.Weekday, bool) {
+	var days []time.Weekday
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		d, ok := weekdays[name]
+		if !ok {
+			return nil, false
+		}
+		days = append(days, d)
+	}
+	return days, true
+}
+
+// ParseDate parses an ISO date ("2026-03-01") in local time.
+func ParseDate(s string) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s), time.Local)
+	return t, err == nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// CALENDAR (business days)
+// ════════════════════════════════════════════════════════════════
+
+// Calendar defines which days are business days.
+type Calendar struct {
+	weekend  [7]bool
+	holidays map[string]bool // "2006-01-02" keys
+}
+
+// NewCalendar returns a calendar with a Saturday/Sunday weekend and no holidays.
+func NewCalendar() *Calendar {
+	c := &Calendar{holidays: make(map[string]bool)}
+	c.SetWeekend(time.Saturday, time.Sunday)
+	return c
+}
+
+// Clone returns a copy of the calendar.
+func (c *Calendar) Clone() *Calendar {
+	clone := &Calendar{
+		weekend:  c.weekend,
+		holidays: make(map[string]bool, len(c.holidays)),
+	}
+	for k := range c.holidays {
+		clone.holidays[k] = true
+	}
+	return clone
+}
+
+// SetWeekend sets the non-working weekdays.
+func (c *Calendar) SetWeekend(days ...time.Weekday) {
+	c.weekend = [7]bool{}
+	for _, d := range days {
+		c.weekend[d] = true
+	}
+}
+
+// Weekend returns the non-working weekdays.
+func (c *Calendar) Weekend() []time.Weekday {
+	var days []time.Weekday
+	for d, off := range c.weekend {
+		if off {
+			days = append(days, time.Weekday(d))
+		}
+	}
+	return days
+}
+
+// AddHoliday marks a date as a non-working day.
+func (c *Calendar) AddHoliday(t time.Time) {
+	c.holidays[t.Format("2006-01-02")] = true
+}
+
+// Holidays returns all holidays as sorted YYYY-MM-DD strings.
+func (c *Calendar) Holidays() []string {
+	days := make([]string, 0, len(c.holidays))
+	for d := range c.holidays {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// ClearHolidays removes all holidays.
+func (c *Calendar) ClearHolidays() {
+	c.holidays = make(map[string]bool)
+}
+
+// ReadHolidays reads holidays from r, one ISO date per line.
+// Blank lines and text after '#' are ignored.
+func (c *Calendar) ReadHolidays(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		t, ok := ParseDate(text)
+		if !ok {
+			return errors.ParseErrorf("invalid holiday date: %s", text).WithLine(line)
+		}
+		c.AddHoliday(t)
+	}
+	return scanner.Err()
+}
+
+// IsBusinessDay returns true if t is neither a weekend day nor a holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return !c.weekend[t.Weekday()] && !c.holidays[t.Format("2006-01-02")]
+}
+
+// AddBusinessDays returns the date n business days after t
+// (before t if n is negative).
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	if len(c.Weekend()) == 7 {
+		return t // no business days at all
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// BusinessDaysBetween counts business days from one date to another,
+// including both ends. The count is negative if to is before from.
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	count := 0
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(d) {
+			count++
+		}
+	}
+	return sign * count
+}
@@ -0,0 +1,301 @@
+// pkg/types/dimension.go
+
+package types
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dims holds the powers of the SI base units in a unit: m³/(kg·s²) is
+// mass -1, length 3, time -2.
+type Dims [6]int8
+
+// Positions in Dims, in the order their units are written.
+const (
+	dimMass = iota
+	dimLength
+	dimTime
+	dimCurrent
+	dimTemperature
+	dimAmount
+)
+
+// dimSymbols are the SI base units, by position in Dims.
+var dimSymbols = [len(Dims{})]string{"kg", "m", "s", "A", "K", "mol"}
+
+// siType describes a built-in unit type in SI terms.
+type siType struct {
+	dims  Dims
+	scale float64 // Size of the type's base unit in SI units
+	code  string  // Unit shown for a result of this type
+}
+
+// siTypes are the built-in unit types that have SI dimensions. Weight
+// and volume are based on the gram and liter, not the kilogram and m³.
+var siTypes = map[UnitType]siType{
+	UnitTypeLength:       {Dims{dimLength: 1}, 1, "m"},
+	UnitTypeWeight:       {Dims{dimMass: 1}, 0.001, "kg"},
+	UnitTypeTime:         {Dims{dimTime: 1}, 1, "s"},
+	UnitTypeTemperature:  {Dims{dimTemperature: 1}, 1, "K"},
+	UnitTypeArea:         {Dims{dimLength: 2}, 1, "sqm"},
+	UnitTypeVolume:       {Dims{dimLength: 3}, 0.001, "m3"},
+	UnitTypeSpeed:        {Dims{dimLength: 1, dimTime: -1}, 1, "m/s"},
+	UnitTypeAcceleration: {Dims{dimLength: 1, dimTime: -2}, 1, "m/s²"},
+	UnitTypeEnergy:       {Dims{dimMass: 1, dimLength: 2, dimTime: -2}, 1, "J"},
+	UnitTypePower:        {Dims{dimMass: 1, dimLength: 2, dimTime: -3}, 1, "W"},
+}
+
+// derivedUnits are named SI units without a unit type of their own.
+// They are written in compound units ("N·m") and shown for results.
+var derivedUnits = map[string]Dims{
+	"N":  {dimMass: 1, dimLength: 1, dimTime: -2},
+	"Pa": {dimMass: 1, dimLength: -1, dimTime: -2},
+}
+
+// compoundUnits holds the units made by CompoundUnit and SIUnit, by code,
+// and the dimensions of the unit types made for them.
+var (
+	compoundMu    sync.RWMutex
+	compoundUnits = map[string]*Unit{}
+	compoundDims  = map[UnitType]Dims{}
+)
+
+// UnitDims returns the SI dimensions of a unit, and the size of one of
+// it in SI units. Returns false for units without SI dimensions (data,
+// angles) and for temperatures with an offset (°C, °F).
+func UnitDims(u *Unit) (Dims, float64, bool) {
+	if u == nil {
+		return Dims{}, 0, false
+	}
+	if t, ok := siTypes[u.Type]; ok {
+		if u.Type == UnitTypeTemperature && u.Code != "K" {
+			return Dims{}, 0, false
+		}
+		return t.dims, u.ToBase * t.scale, true
+	}
+	compoundMu.RLock()
+	defer compoundMu.RUnlock()
+	d, ok := compoundDims[u.Type]
+	return d, u.ToBase, ok
+}
+
+// MultiplyUnits multiplies (or divides) two unit values in SI units:
+// 3 m * 2 m is 6 m², 10 J / 2 K is 5 J/K. A result without dimensions
+// is a plain number. Returns false if either unit has no SI dimensions.
+func MultiplyUnits(left, right Value, divide bool) (Value, bool) {
+	ld, lsi, lok := UnitDims(left.Unit)
+	rd, rsi, rok := UnitDims(right.Unit)
+	if !lok || !rok {
+		return Value{}, false
+	}
+
+	l, r := left.Num*lsi, right.Num*rsi
+	var d Dims
+	for i := range d {
+		if divide {
+			d[i] = ld[i] - rd[i]
+		} else {
+			d[i] = ld[i] + rd[i]
+		}
+	}
+	amount := l * r
+	if divide {
+		if r == 0 {
+			return Error("division by zero"), true
+		}
+		amount = l / r
+	}
+
+	u := SIUnit(d)
+	if u == nil {
+		return Number(amount), true
+	}
+	_, size, _ := UnitDims(u)
+	return UnitValue(amount/size, u), true
+}
+
+// SIUnit returns the SI unit for dimensions: the built-in unit where one
+// fits (m², J), a derived unit (N), or a compound unit (m²/s²). Returns
+// nil for no dimensions.
+func SIUnit(d Dims) *Unit {
+	if d == (Dims{}) {
+		return nil
+	}
+	for _, t := range siTypes {
+		if t.dims == d {
+			return ParseUnit(t.code)
+		}
+	}
+	for code, dims := range derivedUnits {
+		if dims == d {
+			return CompoundUnit(code)
+		}
+	}
+	return CompoundUnit(formatDims(d))
+}
+
+// CompoundUnit parses a unit made of SI units and the units numio has:
+// "J/K", "1/mol", "m³/(kg·s²)", "J·s", "N". Returns nil if it is not one.
+// Products are written with "·" or "*", powers as "²", "³" or "^4".
+func CompoundUnit(code string) *Unit {
+	compoundMu.RLock()
+	u, ok := compoundUnits[code]
+	compoundMu.RUnlock()
+	if ok {
+		return u
+	}
+
+	d, size, ok := parseDims(code)
+	if !ok || d == (Dims{}) {
+		return nil
+	}
+
+	compoundMu.Lock()
+	defer compoundMu.Unlock()
+	if u, ok := compoundUnits[code]; ok {
+		return u
+	}
+	u = &Unit{Code: code, Symbol: code, Name: code, Plural: code, ToBase: size}
+	typ := UnitType(-1)
+	for t, info := range siTypes {
+		if info.dims == d {
+			typ, u.ToBase = t, size/info.scale
+			break
+		}
+	}
+	if typ < 0 {
+		typ = RegisterUnitType(formatDims(d))
+		compoundDims[typ] = d
+	}
+	u.Type = typ
+	compoundUnits[code] = u
+	return u
+}
+
+// parseDims reads a compound unit code into its dimensions and the size
+// of one of it in SI units.
+func parseDims(code string) (Dims, float64, bool) {
+	num, den, divided := strings.Cut(code, "/")
+	if strings.Contains(den, "/") {
+		return Dims{}, 0, false
+	}
+	d, size, ok := parseProduct(num)
+	if !ok {
+		return Dims{}, 0, false
+	}
+	if divided {
+		dd, dsize, ok := parseProduct(strings.TrimSuffix(strings.TrimPrefix(den, "("), ")"))
+		if !ok {
+			return Dims{}, 0, false
+		}
+		for i := range d {
+			d[i] -= dd[i]
+		}
+		size /= dsize
+	}
+	return d, size, true
+}
+
+// parseProduct reads "kg·m²" or "1" into dimensions and a size in SI
+// units.
+func parseProduct(s string) (Dims, float64, bool) {
+	if strings.TrimSpace(s) == "1" {
+		return Dims{}, 1, true
+	}
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '·' || r == '*' })
+	if len(parts) == 0 {
+		return Dims{}, 0, false
+	}
+	var d Dims
+	size := 1.0
+	for _, part := range parts {
+		name, power := splitPower(strings.TrimSpace(part))
+		pd, psize, ok := atomDims(name)
+		if !ok || power == 0 {
+			return Dims{}, 0, false
+		}
+		for i := range d {
+			d[i] += pd[i] * int8(power)
+		}
+		size *= math.Pow(psize, float64(power))
+	}
+	return d, size, true
+}
+
+// atomDims finds the dimensions of one unit in a compound unit: an SI
+// base unit, a derived unit or a unit with SI dimensions.
+func atomDims(name string) (Dims, float64, bool) {
+	for i, sym := range dimSymbols {
+		if name == sym {
+			var d Dims
+			d[i] = 1
+			return d, 1, true
+		}
+	}
+	if d, ok := derivedUnits[name]; ok {
+		return d, 1, true
+	}
+	return UnitDims(ParseUnit(name))
+}
+
+// superscripts are the digits written as powers.
+const superscripts = "⁰¹²³⁴⁵⁶⁷⁸⁹"
+
+// splitPower splits "m²" or "s^4" into the unit and its power.
+func splitPower(s string) (string, int) {
+	if name, p, ok := strings.Cut(s, "^"); ok {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return s, 0
+		}
+		return name, n
+	}
+	r := []rune(s)
+	if len(r) > 1 {
+		if i := strings.IndexRune(superscripts, r[len(r)-1]); i >= 0 {
+			return string(r[:len(r)-1]), len([]rune(superscripts[:i]))
+		}
+	}
+	return s, 1
+}
+
+// formatDims writes dimensions as a unit code: "m²/s²", "m³/(kg·s²)",
+// "1/mol".
+func formatDims(d Dims) string {
+	var num, den []string
+	for i, p := range d {
+		switch {
+		case p > 0:
+			num = append(num, dimSymbols[i]+powerText(int(p)))
+		case p < 0:
+			den = append(den, dimSymbols[i]+powerText(int(-p)))
+		}
+	}
+	code := strings.Join(num, "·")
+	if code == "" {
+		code = "1"
+	}
+	switch len(den) {
+	case 0:
+		return code
+	case 1:
+		return code + "/" + den[0]
+	default:
+		return code + "/(" + strings.Join(den, "·") + ")"
+	}
+}
+
+// powerText writes a power as a superscript: "", "²", "³".
+func powerText(p int) string {
+	if p == 1 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range strconv.Itoa(p) {
+		sb.WriteString(string([]rune(superscripts)[c-'0']))
+	}
+	return sb.String()
+}
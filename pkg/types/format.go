@@ -0,0 +1,499 @@
+// pkg/types/format.go
+
+package types
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ════════════════════════════════════════════════════════════════
+// ROUNDING
+// ════════════════════════════════════════════════════════════════
+
+// RoundingMode selects how values are rounded to a number of places.
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // Ties away from zero: 2.5 → 3, -2.5 → -3
+	RoundHalfEven                     // Ties to even (banker's): 2.5 → 2, 3.5 → 4
+	RoundFloor                        // Toward negative infinity
+	RoundCeiling                      // Toward positive infinity
+)
+
+// String returns the mode name.
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfEven:
+		return "half-even"
+	case RoundFloor:
+		return "floor"
+	case RoundCeiling:
+		return "ceiling"
+	default:
+		return "half-up"
+	}
+}
+
+// ParseRoundingMode parses a rounding mode name: "half-up", "half-even"
+// (or "bankers"), "floor" or "ceiling".
+func ParseRoundingMode(s string) (RoundingMode, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("_", "-", " ", "-").Replace(s)
+	switch s {
+	case "half-up", "halfup", "up":
+		return RoundHalfUp, true
+	case "half-even", "halfeven", "even", "bankers", "banker's", "banker":
+		return RoundHalfEven, true
+	case "floor", "down":
+		return RoundFloor, true
+	case "ceiling", "ceil":
+		return RoundCeiling, true
+	}
+	return RoundHalfUp, false
+}
+
+// Round rounds x to places decimal places (negative places round to tens,
+// hundreds, ...). Values within float error of a tie or a whole step are
+// treated as exact, so 2.675 rounds half-up to 2.68 and 0.1+0.2 rounds
+// up to 0.3.
+func Round(x float64, places int, mode RoundingMode) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	shift := math.Pow(10, float64(places))
+	y := x * shift
+	if math.IsInf(y, 0) || math.Abs(y) >= 1<<53 {
+		return x // Already exact at this many places
+	}
+
+	// Snap float noise to the nearest whole step or tie
+	eps := 1e-9 * math.Max(1, math.Abs(y))
+	if r := math.Round(y); math.Abs(y-r) < eps {
+		y = r
+	} else if t := math.Floor(y) + 0.5; math.Abs(y-t) < eps {
+		y = t
+	}
+
+	switch mode {
+	case RoundHalfEven:
+		y = math.RoundToEven(y)
+	case RoundFloor:
+		y = math.Floor(y)
+	case RoundCeiling:
+		y = math.Ceil(y)
+	default:
+		y = math.Round(y)
+	}
+	return y / shift
+}
+
+// ════════════════════════════════════════════════════════════════
+// FRACTIONS
+// ════════════════════════════════════════════════════════════════
+
+// FractionDisplay selects when values are shown as fractions.
+type FractionDisplay int
+
+const (
+	FractionsOff      FractionDisplay = iota // Decimals only (default)
+	FractionsImperial                        // Imperial and cooking units: 3/4 in, 1 1/2 cups
+	FractionsOn                              // All numbers and units: 1/3
+)
+
+// String returns the fraction display name.
+func (d FractionDisplay) String() string {
+	switch d {
+	case FractionsImperial:
+		return "imperial"
+	case FractionsOn:
+		return "on"
+	default:
+		return "off"
+	}
+}
+
+// ParseFractionDisplay parses a fraction display name ("off", "imperial",
+// "on"). Returns false if the name is not recognized.
+func ParseFractionDisplay(s string) (FractionDisplay, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "off", "no", "false", "none":
+		return FractionsOff, true
+	case "imperial", "cooking", "units":
+		return FractionsImperial, true
+	case "on", "yes", "true", "all":
+		return FractionsOn, true
+	}
+	return FractionsOff, false
+}
+
+// CurrencyDisplay selects how currency amounts are labelled.
+type CurrencyDisplay int
+
+const (
+	ShowCurrencySymbols CurrencyDisplay = iota // $100.00 (default)
+	ShowCurrencyCodes                          // 100.00 USD, unambiguous across dollars
+)
+
+// String returns the currency display name.
+func (d CurrencyDisplay) String() string {
+	if d == ShowCurrencyCodes {
+		return "code"
+	}
+	return "symbol"
+}
+
+// ParseCurrencyDisplay parses a currency display name ("symbol", "code").
+// Returns false if the name is not recognized.
+func ParseCurrencyDisplay(s string) (CurrencyDisplay, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "symbol", "symbols":
+		return ShowCurrencySymbols, true
+	case "code", "codes", "iso":
+		return ShowCurrencyCodes, true
+	}
+	return ShowCurrencySymbols, false
+}
+
+// InputLocale selects how numbers are written in input.
+type InputLocale int
+
+const (
+	LocaleDecimalPoint InputLocale = iota // 1,234.56 (default)
+	LocaleDecimalComma                    // 1.234,56; function arguments are split by ";"
+)
+
+// String returns the input locale name.
+func (l InputLocale) String() string {
+	if l == LocaleDecimalComma {
+		return "eu"
+	}
+	return "en"
+}
+
+// ParseInputLocale parses an input locale name: "en" (or "us", "uk",
+// "point") for 1,234.56 and "eu" (or "de", "fr", "es", "it", "nl",
+// "comma") for 1.234,56. Returns false if the name is not recognized.
+func ParseInputLocale(s string) (InputLocale, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "en", "us", "uk", "point", "1,234.56":
+		return LocaleDecimalPoint, true
+	case "eu", "de", "fr", "es", "it", "nl", "comma", "1.234,56":
+		return LocaleDecimalComma, true
+	}
+	return LocaleDecimalPoint, false
+}
+
+// maxDenominator is the largest denominator shown in a fraction.
+const maxDenominator = 64
+
+// imperialUnits are the units FractionsImperial shows as fractions.
+var imperialUnits = map[string]bool{
+	"in": true, "ft": true, "yd": true, "mi": true,
+	"lb": true, "oz": true, "st": true, "ozt": true,
+	"sqft": true, "sqmi": true, "acre": true,
+	"gal": true, "qt": true, "pt": true, "cup": true, "floz": true, "tbsp": true, "tsp": true,
+	"usgal": true, "usqt": true, "uspt": true, "usfloz": true,
+	"impgal": true, "impqt": true, "imppt": true, "impfloz": true,
+}
+
+// formatFraction formats n as a whole or mixed number ("3/4", "1 1/2",
+// "-2 1/3") if it is one with a small denominator.
+func formatFraction(n float64) (string, bool) {
+	if math.IsNaN(n) || math.IsInf(n, 0) || math.Abs(n) >= 1<<53 {
+		return "", false
+	}
+
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	whole, frac := math.Modf(n)
+	eps := 1e-9 * math.Max(1, n)
+
+	for den := 1; den <= maxDenominator; den++ {
+		num := math.Round(frac * float64(den))
+		if math.Abs(frac-num/float64(den)) >= eps {
+			continue
+		}
+		if num == float64(den) { // frac rounds up to a whole
+			whole, num = whole+1, 0
+		}
+		w := strconv.FormatFloat(whole, 'f', 0, 64)
+		f := strconv.FormatFloat(num, 'f', 0, 64) + "/" + strconv.Itoa(den)
+		switch {
+		case num == 0:
+			if whole == 0 {
+				return "0", true
+			}
+			return sign + w, true
+		case whole == 0:
+			return sign + f, true
+		default:
+			return sign + w + " " + f, true
+		}
+	}
+	return "", false
+}
+
+// ════════════════════════════════════════════════════════════════
+// COMPACT NUMBERS
+// ════════════════════════════════════════════════════════════════
+
+// compactScales are the suffixes compact numbers are shown with, largest
+// first: $1.2M, 5k.
+var compactScales = []struct {
+	suffix string
+	factor float64
+}{
+	{"tn", 1e12},
+	{"bn", 1e9},
+	{"M", 1e6},
+	{"k", 1e3},
+}
+
+// NumberScale returns the multiplier of a number suffix ("k", "M", "bn")
+// or scale word ("dozen", "thousand", "million"). Returns false if s is
+// neither.
+func NumberScale(s string) (float64, bool) {
+	switch s {
+	case "k", "K":
+		return 1e3, true
+	case "M":
+		return 1e6, true
+	case "bn":
+		return 1e9, true
+	case "tn":
+		return 1e12, true
+	}
+	switch strings.ToLower(s) {
+	case "dozen", "dozens":
+		return 12, true
+	case "hundred", "hundreds":
+		return 100, true
+	case "thousand", "thousands":
+		return 1e3, true
+	case "million", "millions":
+		return 1e6, true
+	case "billion", "billions":
+		return 1e9, true
+	case "trillion", "trillions":
+		return 1e12, true
+	}
+	return 0, false
+}
+
+// compactDecimals is the most decimal places of a compact number.
+const compactDecimals = 2
+
+// compact formats n with a scale suffix ("1.2M"), or returns false if n
+// is under a thousand or not finite.
+func (o FormatOptions) compact(n float64) (string, bool) {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return "", false
+	}
+	a := math.Abs(n)
+	for i, s := range compactScales {
+		if a < s.factor {
+			continue
+		}
+		m := Round(n/s.factor, compactDecimals, o.Rounding)
+		// Rounding may carry into the next scale (999.999k → 1M)
+		if math.Abs(m) >= 1000 && i > 0 {
+			s = compactScales[i-1]
+			m = Round(n/s.factor, compactDecimals, o.Rounding)
+		}
+		return o.fixed(m, compactDecimals, true) + s.suffix, true
+	}
+	return "", false
+}
+
+// asOfLayout is how rate timestamps are shown, always in UTC so reports
+// read the same everywhere.
+const asOfLayout = "2006-01-02 15:04 UTC"
+
+// FormatAsOf formats when a rate was fetched: 2026-10-16 14:05 UTC.
+func FormatAsOf(t time.Time) string {
+	return t.UTC().Format(asOfLayout)
+}
+
+// ════════════════════════════════════════════════════════════════
+// FORMAT OPTIONS
+// ════════════════════════════════════════════════════════════════
+
+// AutoPrecision picks the decimal places for a number by its magnitude.
+const AutoPrecision = -1
+
+// FormatOptions controls how values are displayed.
+type FormatOptions struct {
+	Precision  int             // Max decimal places for numbers and units, or AutoPrecision
+	SigFigs    int             // Significant figures for numbers and units; 0 uses Precision
+	Rounding   RoundingMode    // How shown values are rounded
+	Fractions  FractionDisplay // When numbers and units are shown as fractions
+	Currencies CurrencyDisplay // Whether currencies are shown with symbols or codes
+	Compact    bool            // Show large numbers and currencies with scale suffixes: $1.2M
+	AsOf       bool            // Show when converted amounts' rates were fetched
+}
+
+// DefaultFormat is the format used by Value.String.
+var DefaultFormat = FormatOptions{Precision: AutoPrecision, Rounding: RoundHalfUp}
+
+// number formats a plain number, trimming trailing zeros.
+func (o FormatOptions) number(n float64) string {
+	if o.Compact {
+		if s, ok := o.compact(n); ok {
+			return s
+		}
+	}
+	if o.Fractions == FractionsOn {
+		if s, ok := formatFraction(n); ok {
+			return s
+		}
+	}
+	return o.decimal(n)
+}
+
+// amount formats the amount of a value in unit u.
+func (o FormatOptions) amount(n float64, u *Unit) string {
+	if o.Fractions == FractionsImperial && imperialUnits[u.Code] {
+		if s, ok := formatFraction(n); ok {
+			return s
+		}
+	}
+	return o.number(n)
+}
+
+// decimal formats a number in decimals or significant figures.
+func (o FormatOptions) decimal(n float64) string {
+	if o.SigFigs > 0 {
+		return o.significant(n)
+	}
+	// Too large for decimals, at any precision: past a float's digits
+	// they would be binary noise (6.022141e23, not 602214075999999987023872)
+	if a := math.Abs(n); a >= 1e15 && !math.IsInf(n, 0) {
+		return FormatOptions{SigFigs: autoSigFigs, Rounding: o.Rounding}.significant(n)
+	}
+	decimals := o.Precision
+	if decimals == AutoPrecision {
+		// Too small for decimals: 9.109384e-31
+		if a := math.Abs(n); n != 0 && a < 1e-6 {
+			return FormatOptions{SigFigs: autoSigFigs, Rounding: o.Rounding}.significant(n)
+		}
+		decimals = autoDecimals(absFloat(n))
+	}
+	return o.fixed(n, decimals, true)
+}
+
+// autoSigFigs is the significant figures shown for numbers too small or
+// large for AutoPrecision's decimals.
+const autoSigFigs = 7
+
+// fixed formats n with decimals places, rounded by the options' mode,
+// optionally trimming trailing zeros.
+func (o FormatOptions) fixed(n float64, decimals int, trim bool) string {
+	// Round before taking the sign, so floor and ceiling see it
+	n = Round(n, decimals, o.Rounding)
+
+	s := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	if trim && strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		// Past a float's digits, show its shortest form, not binary noise
+		if short := strconv.FormatFloat(math.Abs(n), 'f', -1, 64); len(short) < len(s) {
+			s = short
+		}
+	}
+	if n < 0 && strings.Trim(s, "0.") != "" {
+		s = "-" + s
+	}
+	return s
+}
+
+// significant formats n to SigFigs significant figures, switching to
+// scientific notation ("1.234e-5") for very small or large numbers, like
+// %g.
+func (o FormatOptions) significant(n float64) string {
+	if n == 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return strconv.FormatFloat(n, 'g', -1, 64)
+	}
+
+	// Split n into mantissa and exponent by formatting it, as scaling by
+	// a power of ten overflows for the smallest numbers (1e-320)
+	mantissa, e, _ := strings.Cut(strconv.FormatFloat(n, 'e', -1, 64), "e")
+	m, _ := strconv.ParseFloat(mantissa, 64)
+	exp, _ := strconv.Atoi(e)
+
+	m = Round(m, o.SigFigs-1, o.Rounding)
+	// Rounding may carry into the next power of ten (9.9996 → 10.00)
+	if math.Abs(m) >= 10 {
+		m /= 10
+		exp++
+	}
+
+	if exp < -4 || exp >= o.SigFigs {
+		return o.fixed(m, o.SigFigs-1, true) + "e" + strconv.Itoa(exp)
+	}
+	places := o.SigFigs - 1 - exp
+	return o.fixed(Round(n, places, o.Rounding), max(0, places), true)
+}
+
+// maxBigDigits is the most digits a whole number too large for a
+// float64 is shown with in full; longer ones are shown in scientific
+// notation.
+const maxBigDigits = 30
+
+// bigInteger formats a whole number too large for a float64: in full,
+// with its digits grouped in threes (15,511,210,043,330,985,984,000,001),
+// or in scientific notation if it is longer than maxBigDigits or
+// significant figures are asked for (4.023872e2567).
+func (o FormatOptions) bigInteger(b *big.Int) string {
+	digits := b.String()
+	sign := ""
+	if rest, ok := strings.CutPrefix(digits, "-"); ok {
+		sign, digits = "-", rest
+	}
+	if o.SigFigs <= 0 && len(digits) <= maxBigDigits {
+		return sign + groupDigits(digits)
+	}
+
+	sig := o.SigFigs
+	if sig <= 0 {
+		sig = autoSigFigs
+	}
+	s := new(big.Float).SetInt(b).Text('e', sig-1)
+	mantissa, exp, _ := strings.Cut(s, "e")
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+	}
+	return mantissa + "e" + strings.TrimPrefix(exp, "+")
+}
+
+// groupDigits puts a comma between each group of three digits, from the
+// right: 1234567 → 1,234,567.
+func groupDigits(digits string) string {
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	return sb.String()
+}
+
+// autoDecimals returns the decimal places shown for n by its magnitude.
+func autoDecimals(n float64) int {
+	switch {
+	case n == float64(int64(n)):
+		return 0
+	case n >= 1:
+		return 2
+	case n >= 0.01:
+		return 4
+	default:
+		return 6
+	}
+}
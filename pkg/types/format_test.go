@@ -0,0 +1,28 @@
+// pkg/types/format_test.go
+
+package types
+
+import "testing"
+
+func TestFixedPrecisionLargeNumbers(t *testing.T) {
+	tests := []struct {
+		n         float64
+		precision int
+		want      string
+	}{
+		{1e30, 2, "1e30"},
+		{9.33262154439441e157, 2, "9.332622e157"},
+		{123456789012345678, 0, "1.234568e17"},
+		{1e15, 4, "1e15"},
+		{-2.5e20, 2, "-2.5e20"},
+		{999999999999999.5, 2, "999999999999999.5"},
+		{1234.5678, 2, "1234.57"},
+		{1e30, AutoPrecision, "1e30"},
+	}
+	for _, tt := range tests {
+		opts := FormatOptions{Precision: tt.precision, Rounding: RoundHalfUp}
+		if got := Number(tt.n).Format(opts); got != tt.want {
+			t.Errorf("%g at precision %d = %q, want %q", tt.n, tt.precision, got, tt.want)
+		}
+	}
+}
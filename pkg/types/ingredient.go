@@ -0,0 +1,160 @@
+// internal/types/ingredient.go
+
+package types
+
+import (
+	"strings"
+	"sync"
+)
+
+// Ingredient is a cooking ingredient with a known density,
+// used to convert between volume and weight ("2 cups flour in grams").
+type Ingredient struct {
+	Name    string   // Canonical name: "flour", "sugar"
+	Aliases []string // Alternative names
+	Density float64  // Grams per milliliter
+}
+
+// String returns the ingredient name.
+func (i Ingredient) String() string {
+	return i.Name
+}
+
+// Convert converts an amount of this ingredient between a volume unit
+// and a weight unit (in either direction).
+// Returns false unless one unit is a volume and the other a weight.
+func (i Ingredient) Convert(amount float64, from, to *Unit) (float64, bool) {
+	if from == nil || to == nil || i.Density <= 0 {
+		return 0, false
+	}
+
+	// Volume base is liters, weight base is grams
+	switch {
+	case from.Type == UnitTypeVolume && to.Type == UnitTypeWeight:
+		ml := amount * from.ToBase * 1000
+		return ml * i.Density / to.ToBase, true
+	case from.Type == UnitTypeWeight && to.Type == UnitTypeVolume:
+		grams := amount * from.ToBase
+		return grams / i.Density / 1000 / to.ToBase, true
+	default:
+		return 0, false
+	}
+}
+
+// IngredientRegistry holds all known ingredients.
+type IngredientRegistry struct {
+	mu     sync.RWMutex
+	byName map[string]*Ingredient
+}
+
+// Global ingredient registry.
+var ingredients = newIngredientRegistry()
+
+// newIngredientRegistry creates and populates the ingredient registry.
+func newIngredientRegistry() *IngredientRegistry {
+	r := &IngredientRegistry{
+		byName: make(map[string]*Ingredient),
+	}
+
+	for i := range curatedIngredients {
+		r.register(&curatedIngredients[i])
+	}
+
+	return r
+}
+
+// register adds an ingredient to the registry.
+// Caller must hold the write lock (or be the constructor).
+func (r *IngredientRegistry) register(in *Ingredient) {
+	r.byName[strings.ToLower(in.Name)] = in
+	for _, alias := range in.Aliases {
+		r.byName[strings.ToLower(alias)] = in
+	}
+}
+
+// Lookup finds an ingredient by name or alias (case-insensitive).
+func (r *IngredientRegistry) Lookup(s string) *Ingredient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byName[strings.ToLower(s)]
+}
+
+// curatedIngredients contains common cooking ingredients.
+// Densities are typical values for spooned-and-leveled measures.
+var curatedIngredients = []Ingredient{
+	// Liquids
+	{Name: "water", Density: 1.0},
+	{Name: "milk", Density: 1.03},
+	{Name: "cream", Aliases: []string{"heavy cream"}, Density: 1.01},
+	{Name: "oil", Aliases: []string{"vegetable oil", "olive oil"}, Density: 0.92},
+	{Name: "honey", Density: 1.42},
+	{Name: "maple syrup", Aliases: []string{"syrup"}, Density: 1.32},
+
+	// Flours and starches
+	{Name: "flour", Aliases: []string{"plain flour"}, Density: 0.53},
+	{Name: "bread flour", Density: 0.55},
+	{Name: "whole wheat flour", Density: 0.51},
+	{Name: "cornstarch", Aliases: []string{"corn starch"}, Density: 0.54},
+	{Name: "cocoa", Aliases: []string{"cocoa powder"}, Density: 0.42},
+
+	// Sugars
+	{Name: "sugar", Aliases: []string{"white sugar", "granulated sugar"}, Density: 0.85},
+	{Name: "brown sugar", Density: 0.93},
+	{Name: "powdered sugar", Aliases: []string{"icing sugar"}, Density: 0.51},
+
+	// Fats and dairy
+	{Name: "butter", Density: 0.96},
+	{Name: "yogurt", Aliases: []string{"yoghurt"}, Density: 1.03},
+
+	// Grains and other
+	{Name: "rice", Density: 0.79},
+	{Name: "oats", Aliases: []string{"rolled oats"}, Density: 0.38},
+	{Name: "salt", Aliases: []string{"table salt"}, Density: 1.22},
+	{Name: "baking soda", Density: 0.92},
+	{Name: "baking powder", Density: 0.81},
+}
+
+// ════════════════════════════════════════════════════════════════
+// PUBLIC API
+// ════════════════════════════════════════════════════════════════
+
+// ParseIngredient parses a string into an ingredient.
+// Returns nil if not found.
+func ParseIngredient(s string) *Ingredient {
+	return ingredients.Lookup(strings.TrimSpace(s))
+}
+
+// IsIngredient checks if a string refers to a known ingredient.
+func IsIngredient(s string) bool {
+	return ParseIngredient(s) != nil
+}
+
+// RegisterIngredient adds or replaces an ingredient in the global registry.
+// Density is in grams per milliliter and must be positive.
+// Returns false if the ingredient is invalid.
+func RegisterIngredient(in Ingredient) bool {
+	if strings.TrimSpace(in.Name) == "" || in.Density <= 0 {
+		return false
+	}
+
+	ingredients.mu.Lock()
+	defer ingredients.mu.Unlock()
+	ingredients.register(&in)
+	return true
+}
+
+// AllIngredients returns all registered ingredients.
+func AllIngredients() []Ingredient {
+	ingredients.mu.RLock()
+	defer ingredients.mu.RUnlock()
+
+	seen := make(map[*Ingredient]bool, len(ingredients.byName))
+	all := make([]Ingredient, 0, len(ingredients.byName))
+	for _, in := range ingredients.byName {
+		if !seen[in] {
+			seen[in] = true
+			all = append(all, *in)
+		}
+	}
+	return all
+}
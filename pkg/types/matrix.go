@@ -0,0 +1,353 @@
+// pkg/types/matrix.go
+
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// Matrix is a small dense matrix of plain numbers, stored row by row.
+type Matrix struct {
+	Rows int
+	Cols int
+	Data []float64 // Rows*Cols elements; element (i, j) is Data[i*Cols+j]
+}
+
+// NewMatrix creates a matrix from its rows, which must all have the same
+// non-zero length. Returns nil otherwise.
+func NewMatrix(rows [][]float64) *Matrix {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil
+	}
+
+	m := &Matrix{Rows: len(rows), Cols: len(rows[0])}
+	m.Data = make([]float64, 0, m.Rows*m.Cols)
+	for _, row := range rows {
+		if len(row) != m.Cols {
+			return nil
+		}
+		m.Data = append(m.Data, row...)
+	}
+	return m
+}
+
+// RowMatrix returns v as a one-row matrix.
+func RowMatrix(v []float64) *Matrix {
+	return &Matrix{Rows: 1, Cols: len(v), Data: v}
+}
+
+// Identity returns the n×n identity matrix.
+func Identity(n int) *Matrix {
+	m := zeroMatrix(n, n)
+	for i := range n {
+		m.Data[i*n+i] = 1
+	}
+	return m
+}
+
+// zeroMatrix returns a rows×cols matrix of zeros.
+func zeroMatrix(rows, cols int) *Matrix {
+	return &Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+// At returns element (i, j).
+func (m *Matrix) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// Row returns row i.
+func (m *Matrix) Row(i int) []float64 {
+	return m.Data[i*m.Cols : (i+1)*m.Cols]
+}
+
+// IsSquare reports whether m has as many rows as columns.
+func (m *Matrix) IsSquare() bool {
+	return m.Rows == m.Cols
+}
+
+// Shape returns m's size as "rows×cols".
+func (m *Matrix) Shape() string {
+	return formatNumber(float64(m.Rows)) + "×" + formatNumber(float64(m.Cols))
+}
+
+// ════════════════════════════════════════════════════════════════
+// ARITHMETIC
+// ════════════════════════════════════════════════════════════════
+
+// Add returns m + sign·o. Returns false if the shapes differ.
+func (m *Matrix) Add(o *Matrix, sign float64) (*Matrix, bool) {
+	if m.Rows != o.Rows || m.Cols != o.Cols {
+		return nil, false
+	}
+	r := zeroMatrix(m.Rows, m.Cols)
+	for i := range m.Data {
+		r.Data[i] = m.Data[i] + sign*o.Data[i]
+	}
+	return r, true
+}
+
+// Scale returns k·m.
+func (m *Matrix) Scale(k float64) *Matrix {
+	r := zeroMatrix(m.Rows, m.Cols)
+	for i, x := range m.Data {
+		r.Data[i] = k * x
+	}
+	return r
+}
+
+// Mul returns the matrix product m·o. Returns false if m's columns do
+// not match o's rows.
+func (m *Matrix) Mul(o *Matrix) (*Matrix, bool) {
+	if m.Cols != o.Rows {
+		return nil, false
+	}
+	r := zeroMatrix(m.Rows, o.Cols)
+	for i := range m.Rows {
+		for j := range o.Cols {
+			var sum float64
+			for k := range m.Cols {
+				sum += m.At(i, k) * o.At(k, j)
+			}
+			r.Data[i*r.Cols+j] = sum
+		}
+	}
+	return r, true
+}
+
+// MulVec returns m·v for a column vector v. Returns false if m's columns
+// do not match v's length.
+func (m *Matrix) MulVec(v []float64) ([]float64, bool) {
+	if m.Cols != len(v) {
+		return nil, false
+	}
+	r := make([]float64, m.Rows)
+	for i := range m.Rows {
+		r[i], _ = Dot(m.Row(i), v)
+	}
+	return r, true
+}
+
+// Transpose returns the transpose of m.
+func (m *Matrix) Transpose() *Matrix {
+	r := zeroMatrix(m.Cols, m.Rows)
+	for i := range m.Rows {
+		for j := range m.Cols {
+			r.Data[j*r.Cols+i] = m.At(i, j)
+		}
+	}
+	return r
+}
+
+// Pow returns m raised to a whole power; negative powers use the
+// inverse. Returns false if m is not square or a negative power is taken
+// of a singular matrix.
+func (m *Matrix) Pow(n int) (*Matrix, bool) {
+	if !m.IsSquare() {
+		return nil, false
+	}
+	base := m
+	if n < 0 {
+		inv, ok := m.Inverse()
+		if !ok {
+			return nil, false
+		}
+		base, n = inv, -n
+	}
+
+	// Square and multiply
+	r := Identity(m.Rows)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			r, _ = r.Mul(base)
+		}
+		base, _ = base.Mul(base)
+	}
+	return r, true
+}
+
+// ════════════════════════════════════════════════════════════════
+// DETERMINANT AND INVERSE
+// ════════════════════════════════════════════════════════════════
+
+// singularTolerance is the pivot size below which a matrix is treated as
+// singular, relative to its largest element.
+const singularTolerance = 1e-12
+
+// Det returns the determinant of m, by elimination with partial
+// pivoting. Returns false if m is not square.
+func (m *Matrix) Det() (float64, bool) {
+	if !m.IsSquare() {
+		return 0, false
+	}
+
+	a := m.Scale(1)
+	n := a.Rows
+	det := 1.0
+	for col := range n {
+		pivot := a.pivotRow(col)
+		if a.At(pivot, col) == 0 {
+			return 0, true
+		}
+		if pivot != col {
+			a.swapRows(pivot, col)
+			det = -det
+		}
+		p := a.At(col, col)
+		det *= p
+		for i := col + 1; i < n; i++ {
+			a.addRow(i, col, -a.At(i, col)/p)
+		}
+	}
+	return det, true
+}
+
+// Inverse returns the inverse of m, by Gauss-Jordan elimination with
+// partial pivoting. Returns false if m is not square or is singular.
+func (m *Matrix) Inverse() (*Matrix, bool) {
+	if !m.IsSquare() {
+		return nil, false
+	}
+
+	a := m.Scale(1)
+	inv := Identity(m.Rows)
+	n := a.Rows
+	tol := singularTolerance * a.maxAbs()
+	for col := range n {
+		pivot := a.pivotRow(col)
+		if math.Abs(a.At(pivot, col)) <= tol {
+			return nil, false
+		}
+		a.swapRows(pivot, col)
+		inv.swapRows(pivot, col)
+
+		p := a.At(col, col)
+		a.scaleRow(col, 1/p)
+		inv.scaleRow(col, 1/p)
+		for i := range n {
+			if f := a.At(i, col); i != col && f != 0 {
+				a.addRow(i, col, -f)
+				inv.addRow(i, col, -f)
+			}
+		}
+	}
+	return inv, true
+}
+
+// pivotRow returns the row at or below col with the largest element in
+// column col.
+func (m *Matrix) pivotRow(col int) int {
+	best := col
+	for i := col + 1; i < m.Rows; i++ {
+		if math.Abs(m.At(i, col)) > math.Abs(m.At(best, col)) {
+			best = i
+		}
+	}
+	return best
+}
+
+// swapRows swaps rows i and j in place.
+func (m *Matrix) swapRows(i, j int) {
+	if i == j {
+		return
+	}
+	ri, rj := m.Row(i), m.Row(j)
+	for k := range ri {
+		ri[k], rj[k] = rj[k], ri[k]
+	}
+}
+
+// scaleRow multiplies row i by k in place.
+func (m *Matrix) scaleRow(i int, k float64) {
+	row := m.Row(i)
+	for j := range row {
+		row[j] *= k
+	}
+}
+
+// addRow adds k times row src to row dst in place.
+func (m *Matrix) addRow(dst, src int, k float64) {
+	d, s := m.Row(dst), m.Row(src)
+	for j := range d {
+		d[j] += k * s[j]
+	}
+}
+
+// maxAbs returns the largest absolute element of m.
+func (m *Matrix) maxAbs() float64 {
+	var largest float64
+	for _, x := range m.Data {
+		largest = math.Max(largest, math.Abs(x))
+	}
+	return largest
+}
+
+// ════════════════════════════════════════════════════════════════
+// VECTORS
+// ════════════════════════════════════════════════════════════════
+
+// Dot returns the dot product of a and b. Returns false if their lengths
+// differ.
+func Dot(a, b []float64) (float64, bool) {
+	if len(a) != len(b) {
+		return 0, false
+	}
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum, true
+}
+
+// Cross returns the cross product of two 3-vectors. Returns false if
+// either is not 3 long.
+func Cross(a, b []float64) ([]float64, bool) {
+	if len(a) != 3 || len(b) != 3 {
+		return nil, false
+	}
+	return []float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}, true
+}
+
+// ScaleVector returns k·v.
+func ScaleVector(v []float64, k float64) []float64 {
+	r := make([]float64, len(v))
+	for i, x := range v {
+		r[i] = k * x
+	}
+	return r
+}
+
+// Norm returns the Euclidean length of v.
+func Norm(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
+
+// ════════════════════════════════════════════════════════════════
+// FORMATTING
+// ════════════════════════════════════════════════════════════════
+
+// formatVector formats v as "[1, 2, 3]".
+func formatVector(v []float64, opts FormatOptions) string {
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = opts.number(x)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatMatrix formats m as "[[1, 2], [3, 4]]".
+func formatMatrix(m *Matrix, opts FormatOptions) string {
+	rows := make([]string, m.Rows)
+	for i := range m.Rows {
+		rows[i] = formatVector(m.Row(i), opts)
+	}
+	return "[" + strings.Join(rows, ", ") + "]"
+}
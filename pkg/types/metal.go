@@ -3,17 +3,21 @@
 package types
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 )
 
-// Metal represents a precious metal.
+// Metal represents a metal or other commodity, priced in USD per unit:
+// precious metals per troy ounce, base metals per pound, and oil and gas
+// per barrel and per MMBtu.
 type Metal struct {
-	Code      string   // ISO 4217 code: "XAU", "XAG"
+	Code      string   // ISO 4217 code: "XAU", "XAG"; or a trading code: "WTI"
 	Symbol    string   // Display symbol
 	Name      string   // Full name: "Gold", "Silver"
 	Aliases   []string // Natural language aliases
-	UnitName  string   // Standard unit: "oz", "gram"
-	UnitLabel string   // Display label: "per troy oz", "per gram"
+	UnitName  string   // Code of the unit priced: "ozt", "lb", "bbl", "MMBtu"
+	UnitLabel string   // Display label: "per troy oz", "per barrel"
 }
 
 // String returns the metal code.
@@ -21,6 +25,44 @@ func (m Metal) String() string {
 	return m.Code
 }
 
+// Unit returns the unit the metal is priced per, amounts of it being
+// counted in that unit.
+func (m Metal) Unit() *Unit {
+	return ParseUnit(m.UnitName)
+}
+
+// ════════════════════════════════════════════════════════════════
+// GOLD PURITY
+// ════════════════════════════════════════════════════════════════
+
+// PureKarat is the purity of pure gold: 18k gold is 18 parts in 24 gold.
+const PureKarat = 24
+
+// GoldKarats lists the purities gold is commonly sold in, priced per gram
+// by some providers.
+var GoldKarats = []int{24, 22, 21, 20, 18, 16, 14, 10}
+
+// KaratCode returns the rate code of gold of a purity, priced in USD per
+// gram: "XAU18K".
+func KaratCode(karat int) string {
+	return fmt.Sprintf("XAU%dK", karat)
+}
+
+// isKaratCode reports whether s is a KaratCode.
+func isKaratCode(s string) bool {
+	s = strings.ToUpper(s)
+	rest, ok := strings.CutPrefix(s, "XAU")
+	if !ok {
+		return false
+	}
+	digits, ok := strings.CutSuffix(rest, "K")
+	if !ok {
+		return false
+	}
+	k, err := strconv.Atoi(digits)
+	return err == nil && k >= 1 && k <= PureKarat
+}
+
 // MetalRegistry holds all known metals.
 type MetalRegistry struct {
 	byCode  map[string]*Metal
@@ -71,8 +113,8 @@ func (r *MetalRegistry) Lookup(s string) *Metal {
 	return nil
 }
 
-// curatedMetals contains precious metals with ISO 4217 codes.
-// These codes are used by financial APIs for metal prices.
+// curatedMetals contains precious metals with ISO 4217 codes, used by
+// financial APIs for metal prices, and the commodities quoted beside them.
 var curatedMetals = []Metal{
 	// ════════════════════════════════════════════════════════════
 	// PRECIOUS METALS (ISO 4217)
@@ -81,8 +123,8 @@ var curatedMetals = []Metal{
 		Code:      "XAU",
 		Symbol:    "Au",
 		Name:      "Gold",
-		Aliases:   []string{"gold", "au", "xau"},
-		UnitName:  "oz",
+		Aliases:   []string{"gold", "xau"}, // Not "au": that's an astronomical unit
+		UnitName:  "ozt",
 		UnitLabel: "per troy oz",
 	},
 	{
@@ -90,7 +132,7 @@ var curatedMetals = []Metal{
 		Symbol:    "Ag",
 		Name:      "Silver",
 		Aliases:   []string{"silver", "ag", "xag"},
-		UnitName:  "oz",
+		UnitName:  "ozt",
 		UnitLabel: "per troy oz",
 	},
 	{
@@ -98,7 +140,7 @@ var curatedMetals = []Metal{
 		Symbol:    "Pt",
 		Name:      "Platinum",
 		Aliases:   []string{"platinum", "pt", "xpt"},
-		UnitName:  "oz",
+		UnitName:  "ozt",
 		UnitLabel: "per troy oz",
 	},
 	{
@@ -106,7 +148,7 @@ var curatedMetals = []Metal{
 		Symbol:    "Pd",
 		Name:      "Palladium",
 		Aliases:   []string{"palladium", "pd", "xpd"},
-		UnitName:  "oz",
+		UnitName:  "ozt",
 		UnitLabel: "per troy oz",
 	},
 
@@ -161,6 +203,34 @@ var curatedMetals = []Metal{
 		UnitName:  "lb",
 		UnitLabel: "per pound",
 	},
+
+	// ════════════════════════════════════════════════════════════
+	// ENERGY
+	// ════════════════════════════════════════════════════════════
+	{
+		Code:      "WTI",
+		Symbol:    "WTI",
+		Name:      "WTI Crude Oil",
+		Aliases:   []string{"wti", "crude", "crude oil", "oil"},
+		UnitName:  "bbl",
+		UnitLabel: "per barrel",
+	},
+	{
+		Code:      "BRENT",
+		Symbol:    "Brent",
+		Name:      "Brent Crude Oil",
+		Aliases:   []string{"brent"},
+		UnitName:  "bbl",
+		UnitLabel: "per barrel",
+	},
+	{
+		Code:      "NATGAS",
+		Symbol:    "NG",
+		Name:      "Natural Gas",
+		Aliases:   []string{"natgas"}, // Not "ng": that's a nanogram
+		UnitName:  "MMBtu",
+		UnitLabel: "per MMBtu",
+	},
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -180,9 +250,10 @@ func ParseMetal(s string) *Metal {
 	return metals.Lookup(strings.TrimSpace(s))
 }
 
-// IsMetal checks if a string refers to a known metal.
+// IsMetal checks if a string refers to a known metal, or is the rate
+// code of a purity of gold (see KaratCode).
 func IsMetal(s string) bool {
-	return metals.Lookup(s) != nil
+	return metals.Lookup(s) != nil || isKaratCode(s)
 }
 
 // IsMetalCode checks if a string is a metal ISO code.
@@ -208,10 +279,36 @@ func MetalCodes() []string {
 func PreciousMetals() []Metal {
 	precious := make([]Metal, 0, 4)
 	for _, m := range curatedMetals {
-		switch m.Code {
-		case "XAU", "XAG", "XPT", "XPD":
+		if isPrecious(m.Code) {
 			precious = append(precious, m)
 		}
 	}
 	return precious
 }
+
+// Commodities returns the metals that aren't precious, and energy.
+func Commodities() []Metal {
+	commodities := make([]Metal, 0, len(curatedMetals)-4)
+	for _, m := range curatedMetals {
+		if !isPrecious(m.Code) {
+			commodities = append(commodities, m)
+		}
+	}
+	return commodities
+}
+
+// IsCommodity checks if a string refers to a known metal that isn't
+// precious, or to energy: those priced by commodity providers.
+func IsCommodity(s string) bool {
+	m := metals.Lookup(s)
+	return m != nil && !isPrecious(m.Code)
+}
+
+// isPrecious reports whether code is a precious metal's.
+func isPrecious(code string) bool {
+	switch code {
+	case "XAU", "XAG", "XPT", "XPD":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+// pkg/types/plot.go
+
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// Plot is a series of points to chart: a function sampled over a range,
+// or the values of a run of lines.
+type Plot struct {
+	X []float64 // Ascending
+	Y []float64
+}
+
+// Default chart size, in terminal cells.
+const (
+	PlotWidth  = 48
+	PlotHeight = 8
+)
+
+// sparkBlocks are the bar heights of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// yRange returns the lowest and highest Y. A flat series is widened so
+// it charts across the middle.
+func (p *Plot) yRange() (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, y := range p.Y {
+		lo, hi = math.Min(lo, y), math.Max(hi, y)
+	}
+	if lo == hi {
+		pad := math.Max(1, math.Abs(lo)/2)
+		lo, hi = lo-pad, hi+pad
+	}
+	return lo, hi
+}
+
+// sample returns n values of the series at evenly spaced X, linearly
+// interpolating between points.
+func (p *Plot) sample(n int) []float64 {
+	ys := make([]float64, n)
+	last := len(p.X) - 1
+	if last == 0 || n == 1 {
+		for i := range ys {
+			ys[i] = p.Y[0]
+		}
+		return ys
+	}
+
+	x0, x1 := p.X[0], p.X[last]
+	j := 0
+	for i := range ys {
+		x := x0 + (x1-x0)*float64(i)/float64(n-1)
+		for j < last-1 && p.X[j+1] < x {
+			j++
+		}
+		t := 0.0
+		if dx := p.X[j+1] - p.X[j]; dx > 0 {
+			t = (x - p.X[j]) / dx
+		}
+		ys[i] = p.Y[j] + t*(p.Y[j+1]-p.Y[j])
+	}
+	return ys
+}
+
+// level scales y in [lo, hi] to a step from 0 to steps-1.
+func level(y, lo, hi float64, steps int) int {
+	return int(math.Round((y - lo) / (hi - lo) * float64(steps-1)))
+}
+
+// Sparkline draws the series as a row of bars, at most width wide.
+func (p *Plot) Sparkline(width int) string {
+	ys := p.Y
+	if len(ys) > width {
+		ys = p.sample(width)
+	}
+
+	lo, hi := p.yRange()
+	var sb strings.Builder
+	for _, y := range ys {
+		sb.WriteRune(sparkBlocks[level(y, lo, hi, len(sparkBlocks))])
+	}
+	return sb.String()
+}
+
+// ASCII draws the series as a chart of width × height characters, plus
+// two rows for the X axis.
+func (p *Plot) ASCII(width, height int) []string {
+	lo, hi := p.yRange()
+	f := p.frame(width, lo, hi, chartASCII)
+	height = max(2, height)
+
+	grid := make([][]rune, height)
+	for r := range grid {
+		grid[r] = []rune(strings.Repeat(" ", f.cols))
+	}
+
+	prev := -1
+	for c, y := range p.sample(f.cols) {
+		row := height - 1 - level(y, lo, hi, height)
+		for _, r := range span(prev, row) {
+			grid[r][c] = '*'
+		}
+		prev = row
+	}
+
+	rows := make([]string, height)
+	for r := range grid {
+		rows[r] = string(grid[r])
+	}
+	return f.draw(rows)
+}
+
+// Braille draws the series as a chart of width × height characters,
+// plus two rows for the X axis. Each character holds 2 × 4 dots.
+func (p *Plot) Braille(width, height int) []string {
+	lo, hi := p.yRange()
+	f := p.frame(width, lo, hi, chartBraille)
+	height = max(2, height)
+
+	cells := make([][]rune, height)
+	for r := range cells {
+		cells[r] = []rune(strings.Repeat(string(rune(0x2800)), f.cols))
+	}
+
+	dotRows := 4 * height
+	prev := -1
+	for x, y := range p.sample(2 * f.cols) {
+		row := dotRows - 1 - level(y, lo, hi, dotRows)
+		for _, r := range span(prev, row) {
+			cells[r/4][x/2] |= brailleDot(x%2, r%4)
+		}
+		prev = row
+	}
+
+	rows := make([]string, height)
+	for r := range cells {
+		rows[r] = string(cells[r])
+	}
+	return f.draw(rows)
+}
+
+// brailleDot returns the bit for the dot at column dx and row dy of a
+// braille character.
+func brailleDot(dx, dy int) rune {
+	if dy == 3 {
+		return rune(0x40 << dx)
+	}
+	return rune(1 << (dy + 3*dx))
+}
+
+// span returns the rows from just past prev through row, so consecutive
+// points join up. With no previous point it is just row.
+func span(prev, row int) []int {
+	if prev < 0 || prev == row {
+		return []int{row}
+	}
+	step := 1
+	if row < prev {
+		step = -1
+	}
+	var rows []int
+	for r := prev + step; r != row+step; r += step {
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// ════════════════════════════════════════════════════════════════
+// AXES
+// ════════════════════════════════════════════════════════════════
+
+// chartStyle is the characters a chart's axes are drawn with.
+type chartStyle struct {
+	axis, corner, rule string
+}
+
+var (
+	chartASCII   = chartStyle{axis: "|", corner: "+", rule: "-"}
+	chartBraille = chartStyle{axis: "│", corner: "└", rule: "─"}
+)
+
+// chartFrame lays out the axes and labels around a chart.
+type chartFrame struct {
+	style       chartStyle
+	top, bottom string // Y axis labels
+	left, right string // X axis labels
+	labelWidth  int
+	cols        int // Width of the plotting area
+}
+
+// frame lays out axes for a chart width characters wide.
+func (p *Plot) frame(width int, lo, hi float64, style chartStyle) chartFrame {
+	f := chartFrame{
+		style:  style,
+		top:    formatNumber(hi),
+		bottom: formatNumber(lo),
+		left:   formatNumber(p.X[0]),
+		right:  formatNumber(p.X[len(p.X)-1]),
+	}
+	f.labelWidth = max(len(f.top), len(f.bottom))
+	f.cols = max(len(f.left)+len(f.right)+1, width-f.labelWidth-1)
+	return f
+}
+
+// draw adds the axes and labels to the chart's rows.
+func (f chartFrame) draw(rows []string) []string {
+	pad := strings.Repeat(" ", f.labelWidth)
+	out := make([]string, 0, len(rows)+2)
+	for i, row := range rows {
+		label := pad
+		switch i {
+		case 0:
+			label = padLeft(f.top, f.labelWidth)
+		case len(rows) - 1:
+			label = padLeft(f.bottom, f.labelWidth)
+		}
+		out = append(out, label+f.style.axis+row)
+	}
+
+	gap := f.cols - len(f.left) - len(f.right)
+	out = append(out,
+		pad+f.style.corner+strings.Repeat(f.style.rule, f.cols),
+		pad+" "+f.left+strings.Repeat(" ", gap)+f.right)
+	return out
+}
+
+// padLeft right-aligns s in width characters.
+func padLeft(s string, width int) string {
+	return strings.Repeat(" ", max(0, width-len(s))) + s
+}
@@ -0,0 +1,117 @@
+// internal/types/rate.go
+
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// rateAliases maps common rate abbreviations to "amount/time" codes.
+var rateAliases = map[string]string{
+	"bps":   "bit/s",
+	"kbps":  "Kbit/s",
+	"mbps":  "Mbit/s",
+	"gbps":  "Gbit/s",
+	"mph":   "mi/h",
+	"kph":   "km/h",
+	"kmh":   "km/h",
+	"kn":    "nmi/h",
+	"knot":  "nmi/h",
+	"knots": "nmi/h",
+}
+
+// rateTypes maps the types that have a rate to the rate's type.
+var rateTypes = map[UnitType]UnitType{
+	UnitTypeData:   UnitTypeDataRate,
+	UnitTypeLength: UnitTypeSpeed,
+	UnitTypeSpeed:  UnitTypeAcceleration,
+}
+
+// RateUnit returns the unit of an amount per time: a data rate (Mbit/s),
+// a speed (km/h) or, per time again, an acceleration (m/s²). Returns nil
+// unless per is a time unit and amount has a rate.
+func RateUnit(amount, per *Unit) *Unit {
+	if amount == nil || per == nil || per.Type != UnitTypeTime {
+		return nil
+	}
+	typ, ok := rateTypes[amount.Type]
+	if !ok {
+		return nil
+	}
+	u := &Unit{
+		Code:   amount.Code + "/" + per.Code,
+		Symbol: amount.Symbol + "/" + per.Symbol,
+		Name:   amount.Name + " per " + per.Name,
+		Plural: amount.Plural + " per " + per.Name,
+		Type:   typ,
+		ToBase: amount.ToBase / per.ToBase,
+	}
+	// Per the same time twice is squared: m/s/s is m/s²
+	if _, first, ok := RateParts(amount); ok && first.Code == per.Code {
+		u.Code, u.Symbol = amount.Code+"²", amount.Symbol+"²"
+		u.Name, u.Plural = amount.Name+" squared", amount.Plural+" squared"
+	}
+	return u
+}
+
+// RateParts splits a rate unit into its amount and time units: Mbit/s
+// into Mbit and s, m/s² into m/s and s. Returns false if u is not a rate.
+func RateParts(u *Unit) (amount, per *Unit, ok bool) {
+	if u == nil || (u.Type != UnitTypeDataRate && u.Type != UnitTypeSpeed && u.Type != UnitTypeAcceleration) {
+		return nil, nil, false
+	}
+	code := u.Code
+	if squared, ok := strings.CutSuffix(code, "²"); ok {
+		code = squared + squared[strings.LastIndex(squared, "/"):]
+	}
+	i := strings.LastIndex(code, "/")
+	if i < 0 {
+		return nil, nil, false
+	}
+	amount, per = ParseUnit(code[:i]), ParseUnit(code[i+1:])
+	if amount == nil || per == nil {
+		return nil, nil, false
+	}
+	return amount, per, true
+}
+
+// lookupRate parses "amount/time" codes, "m/s²" and rate aliases.
+// Caller must hold the read lock.
+func (r *UnitRegistry) lookupRate(s string) *Unit {
+	if code, ok := rateAliases[strings.ToLower(s)]; ok {
+		s = code
+	}
+	s, squared := strings.CutSuffix(s, "²")
+	i := strings.LastIndex(s, "/")
+	if i < 0 {
+		return nil
+	}
+	num, den := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	amount := r.lookup(num)
+	if amount == nil {
+		amount = r.lookupRate(num)
+	}
+	per := r.lookup(den)
+	u := RateUnit(amount, per)
+	if squared {
+		u = RateUnit(u, per)
+	}
+	return u
+}
+
+// Duration returns a time value for a number of seconds, expressed in
+// the most readable unit (s, min, h or d).
+func Duration(seconds float64) Value {
+	code := "s"
+	switch abs := math.Abs(seconds); {
+	case abs >= 48*3600:
+		code = "d"
+	case abs >= 3600:
+		code = "h"
+	case abs >= 60:
+		code = "min"
+	}
+	u := units.code(code)
+	return UnitValue(seconds/u.ToBase, u)
+}
@@ -0,0 +1,199 @@
+// internal/types/region.go
+
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// Region selects regional variants of ambiguous units.
+// For example, "gallon" means a US gallon in RegionUS and an
+// imperial gallon in RegionUK.
+type Region int
+
+const (
+	RegionUS Region = iota // US customary units (default)
+	RegionUK               // British imperial units
+)
+
+// String returns the region name.
+func (r Region) String() string {
+	switch r {
+	case RegionUS:
+		return "us"
+	case RegionUK:
+		return "uk"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRegion parses a region name ("us", "uk", "imperial", ...).
+// Returns false if the name is not recognized.
+func ParseRegion(s string) (Region, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "us", "usa", "en-us", "customary":
+		return RegionUS, true
+	case "uk", "gb", "en-gb", "imperial", "british":
+		return RegionUK, true
+	default:
+		return RegionUS, false
+	}
+}
+
+// RegionalUnit returns the variant of u used in the given region.
+// Units without a regional variant are returned unchanged.
+func RegionalUnit(u *Unit, r Region) *Unit {
+	if u == nil || u.Variants == nil {
+		return u
+	}
+	code, ok := u.Variants[r]
+	if !ok {
+		return u
+	}
+	if variant := units.code(code); variant != nil {
+		return variant
+	}
+	return u
+}
+
+// ════════════════════════════════════════════════════════════════
+// COMPOUND FORMATTING
+// ════════════════════════════════════════════════════════════════
+
+// compoundParts maps a unit code to the units it is split into
+// for compound display in a given region (e.g. "12 st 4 lb").
+var compoundParts = map[Region]map[string][]string{
+	RegionUK: {
+		"st": {"st", "lb"},
+	},
+}
+
+// CompoundPartsFor returns the compound display units for a unit in a region,
+// or nil if the unit is displayed as a single quantity.
+func CompoundPartsFor(u *Unit, r Region) []*Unit {
+	if u == nil {
+		return nil
+	}
+	codes, ok := compoundParts[r][u.Code]
+	if !ok {
+		return nil
+	}
+	parts := make([]*Unit, 0, len(codes))
+	for _, code := range codes {
+		p := units.code(code)
+		if p == nil || p.Type != u.Type {
+			return nil
+		}
+		parts = append(parts, p)
+	}
+	return parts
+}
+
+// FormatCompound formats an amount of unit u split across the given parts,
+// largest first. The last part keeps a fractional remainder and zero parts
+// are omitted.
+// e.g. FormatCompound(12.2857, st, st, lb) → "12 st 4 lb"
+func FormatCompound(amount float64, u *Unit, parts ...*Unit) string {
+	if u == nil || len(parts) == 0 {
+		return formatNumber(amount)
+	}
+
+	// Work in the smallest part so rounding only happens once.
+	smallest := parts[len(parts)-1]
+	rest := roundTo(math.Abs(amount)*u.ToBase/smallest.ToBase, 2)
+
+	var out []string
+	for _, p := range parts[:len(parts)-1] {
+		ratio := p.ToBase / smallest.ToBase
+		whole := math.Floor(rest/ratio + 1e-9)
+		rest = roundTo(rest-whole*ratio, 2)
+		if whole != 0 {
+			out = append(out, formatNumber(whole)+" "+p.Code)
+		}
+	}
+	if rest != 0 || len(out) == 0 {
+		out = append(out, formatNumber(rest)+" "+smallest.Code)
+	}
+
+	s := strings.Join(out, " ")
+	if amount < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// roundTo rounds a float to the given number of decimal places.
+func roundTo(n float64, decimals int) float64 {
+	shift := math.Pow(10, float64(decimals))
+	return math.Round(n*shift) / shift
+}
+
+// ════════════════════════════════════════════════════════════════
+// LENGTH DISPLAY
+// ════════════════════════════════════════════════════════════════
+
+// LengthDisplay selects how length results are displayed.
+type LengthDisplay int
+
+const (
+	LengthAsIs       LengthDisplay = iota // Keep the computed unit (default)
+	LengthMetric                          // Convert to m/cm/km (e.g. "1.88 m")
+	LengthFeetInches                      // Split into feet and inches (e.g. "6 ft 2 in")
+)
+
+// String returns the length display name.
+func (d LengthDisplay) String() string {
+	switch d {
+	case LengthAsIs:
+		return "auto"
+	case LengthMetric:
+		return "metric"
+	case LengthFeetInches:
+		return "imperial"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLengthDisplay parses a length display name ("auto", "metric", "imperial").
+// Returns false if the name is not recognized.
+func ParseLengthDisplay(s string) (LengthDisplay, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "auto", "off", "none":
+		return LengthAsIs, true
+	case "metric", "m", "si":
+		return LengthMetric, true
+	case "imperial", "ft", "feet", "ft-in", "feet-inches":
+		return LengthFeetInches, true
+	default:
+		return LengthAsIs, false
+	}
+}
+
+// FormatLength formats a length in the given display mode.
+// Returns false if u is not a length unit or d is LengthAsIs.
+func FormatLength(amount float64, u *Unit, d LengthDisplay) (string, bool) {
+	if u == nil || u.Type != UnitTypeLength {
+		return "", false
+	}
+
+	switch d {
+	case LengthMetric:
+		meters := amount * u.ToBase
+		code := "m"
+		switch abs := math.Abs(meters); {
+		case abs >= 1000:
+			code = "km"
+		case abs > 0 && abs < 1:
+			code = "cm"
+		}
+		target := units.code(code)
+		return formatNumber(meters/target.ToBase) + " " + target.Code, true
+
+	case LengthFeetInches:
+		return FormatCompound(amount, u, units.code("ft"), units.code("in")), true
+	}
+	return "", false
+}
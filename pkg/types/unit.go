@@ -3,7 +3,13 @@
 package types
 
 import (
+	"maps"
+	"math"
+	"slices"
 	"strings"
+	"sync"
+
+	"github.com/0xsj/numio/pkg/errors"
 )
 
 // UnitType represents a category of units.
@@ -17,7 +23,13 @@ const (
 	UnitTypeData
 	UnitTypeArea
 	UnitTypeVolume
-	UnitTypeSpeed // Future: compound units
+	UnitTypeSpeed // Length per time: km/h (base: meter per second)
+	UnitTypePower
+	UnitTypeEnergy
+	UnitTypeDataRate // Data per time: Mbit/s (base: byte per second)
+	UnitTypeBusinessDay
+	UnitTypeAngle        // base: radian
+	UnitTypeAcceleration // Speed per time: m/s² (base: meter per second squared)
 )
 
 // String returns the unit type name.
@@ -39,7 +51,22 @@ func (t UnitType) String() string {
 		return "volume"
 	case UnitTypeSpeed:
 		return "speed"
+	case UnitTypePower:
+		return "power"
+	case UnitTypeEnergy:
+		return "energy"
+	case UnitTypeDataRate:
+		return "data rate"
+	case UnitTypeBusinessDay:
+		return "business days"
+	case UnitTypeAngle:
+		return "angle"
+	case UnitTypeAcceleration:
+		return "acceleration"
 	default:
+		if name, ok := customUnitTypeName(t); ok {
+			return name
+		}
 		return "unknown"
 	}
 }
@@ -55,6 +82,11 @@ type Unit struct {
 	ToBase      float64  // Multiplier to convert to base unit
 	FromBaseAdd float64  // Additive offset from base (for temperature)
 	IsBase      bool     // True if this is the base unit for its type
+	SIPrefix    bool     // Accepts SI prefixes: "µs", "GW", "ML"
+
+	// Variants maps a region to the code of the unit this one means there
+	// (e.g. "gal" is an imperial gallon in RegionUK). See RegionalUnit.
+	Variants map[Region]string
 }
 
 // String returns the unit code.
@@ -111,6 +143,8 @@ func convertTemperature(value float64, from, to *Unit) float64 {
 
 // UnitRegistry holds all known units.
 type UnitRegistry struct {
+	mu      sync.RWMutex
+	exact   map[string]*Unit // Canonical codes only (case-sensitive)
 	byCode  map[string]*Unit
 	byAlias map[string]*Unit
 	byType  map[UnitType][]*Unit
@@ -122,6 +156,7 @@ var units = newUnitRegistry()
 // newUnitRegistry creates and populates the unit registry.
 func newUnitRegistry() *UnitRegistry {
 	r := &UnitRegistry{
+		exact:   make(map[string]*Unit),
 		byCode:  make(map[string]*Unit),
 		byAlias: make(map[string]*Unit),
 		byType:  make(map[UnitType][]*Unit),
@@ -137,6 +172,7 @@ func newUnitRegistry() *UnitRegistry {
 // register adds a unit to the registry.
 func (r *UnitRegistry) register(u *Unit) {
 	// By code (case-insensitive for most, but preserve case for symbols)
+	r.exact[u.Code] = u
 	r.byCode[u.Code] = u
 	r.byCode[strings.ToLower(u.Code)] = u
 	r.byCode[strings.ToUpper(u.Code)] = u
@@ -151,13 +187,34 @@ func (r *UnitRegistry) register(u *Unit) {
 }
 
 // Lookup finds a unit by code or alias.
+// Units marked SIPrefix also match with an SI prefix ("µs", "GW", "kilowatt").
 func (r *UnitRegistry) Lookup(s string) *Unit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Rates: "Mbit/s", "MB/h", "Mbps", "km/h", "m/s²"
+	if u := r.lookupRate(s); u != nil {
+		return u
+	}
+	return r.lookup(s)
+}
+
+// lookup finds a unit by code or alias. Caller must hold the read lock.
+func (r *UnitRegistry) lookup(s string) *Unit {
 	// Try exact code match first
-	if u, ok := r.byCode[s]; ok {
+	if u, ok := r.exact[s]; ok {
+		return u
+	}
+
+	// Try SI prefix on an exact code ("ML" is megaliter, not mL)
+	if u := r.lookupPrefixed(s); u != nil {
 		return u
 	}
 
 	// Try case-insensitive code
+	if u, ok := r.byCode[s]; ok {
+		return u
+	}
 	if u, ok := r.byCode[strings.ToLower(s)]; ok {
 		return u
 	}
@@ -167,9 +224,81 @@ func (r *UnitRegistry) Lookup(s string) *Unit {
 		return u
 	}
 
+	// Try long-form SI prefix on an alias ("kilowatts")
+	return r.lookupPrefixedAlias(strings.ToLower(s))
+}
+
+// code finds a unit by code without prefix or alias matching.
+func (r *UnitRegistry) code(c string) *Unit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byCode[c]
+}
+
+// ════════════════════════════════════════════════════════════════
+// SI PREFIXES
+// ════════════════════════════════════════════════════════════════
+
+// siPrefix is a metric prefix applied to units marked SIPrefix.
+type siPrefix struct {
+	Symbol string
+	Name   string
+	Factor float64
+}
+
+// siPrefixes lists the supported SI prefixes.
+var siPrefixes = []siPrefix{
+	{"n", "nano", 1e-9},
+	{"µ", "micro", 1e-6},
+	{"μ", "micro", 1e-6}, // Greek mu
+	{"m", "milli", 1e-3},
+	{"c", "centi", 1e-2},
+	{"k", "kilo", 1e3},
+	{"M", "mega", 1e6},
+	{"G", "giga", 1e9},
+	{"T", "tera", 1e12},
+}
+
+// lookupPrefixed matches "<prefix symbol><code>", e.g. "GW".
+// Caller must hold the read lock.
+func (r *UnitRegistry) lookupPrefixed(s string) *Unit {
+	for _, p := range siPrefixes {
+		if !strings.HasPrefix(s, p.Symbol) {
+			continue
+		}
+		if base := r.exact[s[len(p.Symbol):]]; base != nil && base.SIPrefix {
+			return prefixedUnit(base, p)
+		}
+	}
+	return nil
+}
+
+// lookupPrefixedAlias matches "<prefix name><alias>", e.g. "kilowatts".
+// Caller must hold the read lock.
+func (r *UnitRegistry) lookupPrefixedAlias(s string) *Unit {
+	for _, p := range siPrefixes {
+		if !strings.HasPrefix(s, p.Name) {
+			continue
+		}
+		if base := r.byAlias[s[len(p.Name):]]; base != nil && base.SIPrefix {
+			return prefixedUnit(base, p)
+		}
+	}
 	return nil
 }
 
+// prefixedUnit derives a unit from base scaled by an SI prefix.
+func prefixedUnit(base *Unit, p siPrefix) *Unit {
+	return &Unit{
+		Code:   p.Symbol + base.Code,
+		Symbol: p.Symbol + base.Symbol,
+		Name:   p.Name + base.Name,
+		Plural: p.Name + base.Plural,
+		Type:   base.Type,
+		ToBase: base.ToBase * p.Factor,
+	}
+}
+
 // curatedUnits contains all supported units.
 // Base units have ToBase = 1.0
 var curatedUnits = []Unit{
@@ -177,14 +306,15 @@ var curatedUnits = []Unit{
 	// LENGTH (base: meter)
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "m",
-		Symbol:  "m",
-		Name:    "meter",
-		Plural:  "meters",
-		Type:    UnitTypeLength,
-		Aliases: []string{"meter", "meters", "metre", "metres"},
-		ToBase:  1.0,
-		IsBase:  true,
+		Code:     "m",
+		Symbol:   "m",
+		Name:     "meter",
+		Plural:   "meters",
+		Type:     UnitTypeLength,
+		Aliases:  []string{"meter", "meters", "metre", "metres"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
 	},
 	{
 		Code:    "km",
@@ -237,7 +367,7 @@ var curatedUnits = []Unit{
 		Name:    "foot",
 		Plural:  "feet",
 		Type:    UnitTypeLength,
-		Aliases: []string{"foot", "feet"},
+		Aliases: []string{"foot", "feet", "'", "′"},
 		ToBase:  0.3048,
 	},
 	{
@@ -246,7 +376,7 @@ var curatedUnits = []Unit{
 		Name:    "inch",
 		Plural:  "inches",
 		Type:    UnitTypeLength,
-		Aliases: []string{"inch", "inches"},
+		Aliases: []string{"inch", "inches", "\"", "″"},
 		ToBase:  0.0254,
 	},
 	{
@@ -258,19 +388,38 @@ var curatedUnits = []Unit{
 		Aliases: []string{"nautical mile", "nautical miles", "nmi"},
 		ToBase:  1852.0,
 	},
+	{
+		Code:    "au",
+		Symbol:  "au",
+		Name:    "astronomical unit",
+		Plural:  "astronomical units",
+		Type:    UnitTypeLength,
+		Aliases: []string{"astronomical unit", "astronomical units", "astronomical_unit"},
+		ToBase:  149597870700.0,
+	},
+	{
+		Code:    "ly",
+		Symbol:  "ly",
+		Name:    "light-year",
+		Plural:  "light-years",
+		Type:    UnitTypeLength,
+		Aliases: []string{"lightyear", "lightyears", "light year", "light years", "light-year", "light-years", "light_year"},
+		ToBase:  9460730472580800.0, // Julian year of 365.25 days
+	},
 
 	// ════════════════════════════════════════════════════════════
 	// WEIGHT / MASS (base: gram)
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "g",
-		Symbol:  "g",
-		Name:    "gram",
-		Plural:  "grams",
-		Type:    UnitTypeWeight,
-		Aliases: []string{"gram", "grams"},
-		ToBase:  1.0,
-		IsBase:  true,
+		Code:     "g",
+		Symbol:   "g",
+		Name:     "gram",
+		Plural:   "grams",
+		Type:     UnitTypeWeight,
+		Aliases:  []string{"gram", "grams"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
 	},
 	{
 		Code:    "kg",
@@ -340,14 +489,15 @@ var curatedUnits = []Unit{
 	// TIME (base: second)
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "s",
-		Symbol:  "s",
-		Name:    "second",
-		Plural:  "seconds",
-		Type:    UnitTypeTime,
-		Aliases: []string{"second", "seconds", "sec", "secs"},
-		ToBase:  1.0,
-		IsBase:  true,
+		Code:     "s",
+		Symbol:   "s",
+		Name:     "second",
+		Plural:   "seconds",
+		Type:     UnitTypeTime,
+		Aliases:  []string{"second", "seconds", "sec", "secs"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
 	},
 	{
 		Code:    "ms",
@@ -413,6 +563,19 @@ var curatedUnits = []Unit{
 		ToBase:  31556952.0, // Average year (365.2425 days)
 	},
 
+	// Business days depend on the calendar, so they are their own type
+	// and only combine with dates (see Calendar).
+	{
+		Code:    "bd",
+		Symbol:  "bd",
+		Name:    "business day",
+		Plural:  "business days",
+		Type:    UnitTypeBusinessDay,
+		Aliases: []string{"business day", "business days", "workday", "workdays"},
+		ToBase:  1.0,
+		IsBase:  true,
+	},
+
 	// ════════════════════════════════════════════════════════════
 	// TEMPERATURE (base: Kelvin, but special conversion logic)
 	// ════════════════════════════════════════════════════════════
@@ -449,14 +612,15 @@ var curatedUnits = []Unit{
 	// DATA (base: byte)
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "B",
-		Symbol:  "B",
-		Name:    "byte",
-		Plural:  "bytes",
-		Type:    UnitTypeData,
-		Aliases: []string{"byte", "bytes"},
-		ToBase:  1.0,
-		IsBase:  true,
+		Code:     "B",
+		Symbol:   "B",
+		Name:     "byte",
+		Plural:   "bytes",
+		Type:     UnitTypeData,
+		Aliases:  []string{"byte", "bytes"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
 	},
 	{
 		Code:    "KB",
@@ -504,13 +668,14 @@ var curatedUnits = []Unit{
 		ToBase:  1125899906842624.0, // 1024^5
 	},
 	{
-		Code:    "bit",
-		Symbol:  "bit",
-		Name:    "bit",
-		Plural:  "bits",
-		Type:    UnitTypeData,
-		Aliases: []string{"bits"},
-		ToBase:  0.125, // 1/8 byte
+		Code:     "bit",
+		Symbol:   "bit",
+		Name:     "bit",
+		Plural:   "bits",
+		Type:     UnitTypeData,
+		Aliases:  []string{"bits"},
+		ToBase:   0.125, // 1/8 byte
+		SIPrefix: true,
 	},
 	{
 		Code:    "Kbit",
@@ -519,7 +684,7 @@ var curatedUnits = []Unit{
 		Plural:  "kilobits",
 		Type:    UnitTypeData,
 		Aliases: []string{"kilobit", "kilobits", "kbit"},
-		ToBase:  128.0, // 1024 bits = 128 bytes
+		ToBase:  125.0, // 1000 bits; bit rates are always decimal
 	},
 	{
 		Code:    "Mbit",
@@ -528,7 +693,7 @@ var curatedUnits = []Unit{
 		Plural:  "megabits",
 		Type:    UnitTypeData,
 		Aliases: []string{"megabit", "megabits", "mbit"},
-		ToBase:  131072.0, // 1024^2 bits
+		ToBase:  125000.0, // 1000^2 bits
 	},
 	{
 		Code:    "Gbit",
@@ -537,6 +702,82 @@ var curatedUnits = []Unit{
 		Plural:  "gigabits",
 		Type:    UnitTypeData,
 		Aliases: []string{"gigabit", "gigabits", "gbit"},
+		ToBase:  125000000.0, // 1000^3 bits
+	},
+
+	// ════════════════════════════════════════════════════════════
+	// DATA - IEC (always binary, see DataUnits for KB/MB/...)
+	// ════════════════════════════════════════════════════════════
+	{
+		Code:    "KiB",
+		Symbol:  "KiB",
+		Name:    "kibibyte",
+		Plural:  "kibibytes",
+		Type:    UnitTypeData,
+		Aliases: []string{"kibibyte", "kibibytes"},
+		ToBase:  1024.0,
+	},
+	{
+		Code:    "MiB",
+		Symbol:  "MiB",
+		Name:    "mebibyte",
+		Plural:  "mebibytes",
+		Type:    UnitTypeData,
+		Aliases: []string{"mebibyte", "mebibytes"},
+		ToBase:  1048576.0, // 1024^2
+	},
+	{
+		Code:    "GiB",
+		Symbol:  "GiB",
+		Name:    "gibibyte",
+		Plural:  "gibibytes",
+		Type:    UnitTypeData,
+		Aliases: []string{"gibibyte", "gibibytes"},
+		ToBase:  1073741824.0, // 1024^3
+	},
+	{
+		Code:    "TiB",
+		Symbol:  "TiB",
+		Name:    "tebibyte",
+		Plural:  "tebibytes",
+		Type:    UnitTypeData,
+		Aliases: []string{"tebibyte", "tebibytes"},
+		ToBase:  1099511627776.0, // 1024^4
+	},
+	{
+		Code:    "PiB",
+		Symbol:  "PiB",
+		Name:    "pebibyte",
+		Plural:  "pebibytes",
+		Type:    UnitTypeData,
+		Aliases: []string{"pebibyte", "pebibytes"},
+		ToBase:  1125899906842624.0, // 1024^5
+	},
+	{
+		Code:    "Kibit",
+		Symbol:  "Kibit",
+		Name:    "kibibit",
+		Plural:  "kibibits",
+		Type:    UnitTypeData,
+		Aliases: []string{"kibibit", "kibibits"},
+		ToBase:  128.0, // 1024 bits
+	},
+	{
+		Code:    "Mibit",
+		Symbol:  "Mibit",
+		Name:    "mebibit",
+		Plural:  "mebibits",
+		Type:    UnitTypeData,
+		Aliases: []string{"mebibit", "mebibits"},
+		ToBase:  131072.0, // 1024^2 bits
+	},
+	{
+		Code:    "Gibit",
+		Symbol:  "Gibit",
+		Name:    "gibibit",
+		Plural:  "gibibits",
+		Type:    UnitTypeData,
+		Aliases: []string{"gibibit", "gibibits"},
 		ToBase:  134217728.0, // 1024^3 bits
 	},
 
@@ -549,7 +790,7 @@ var curatedUnits = []Unit{
 		Name:    "square meter",
 		Plural:  "square meters",
 		Type:    UnitTypeArea,
-		Aliases: []string{"square meter", "square meters", "sq m", "m2"},
+		Aliases: []string{"square meter", "square meters", "sq m", "m2", "m²"},
 		ToBase:  1.0,
 		IsBase:  true,
 	},
@@ -559,7 +800,7 @@ var curatedUnits = []Unit{
 		Name:    "square kilometer",
 		Plural:  "square kilometers",
 		Type:    UnitTypeArea,
-		Aliases: []string{"square kilometer", "square kilometers", "sq km", "km2"},
+		Aliases: []string{"square kilometer", "square kilometers", "sq km", "km2", "km²"},
 		ToBase:  1000000.0,
 	},
 	{
@@ -568,7 +809,7 @@ var curatedUnits = []Unit{
 		Name:    "square foot",
 		Plural:  "square feet",
 		Type:    UnitTypeArea,
-		Aliases: []string{"square foot", "square feet", "sq ft", "ft2"},
+		Aliases: []string{"square foot", "square feet", "sq ft", "ft2", "ft²"},
 		ToBase:  0.092903,
 	},
 	{
@@ -577,7 +818,7 @@ var curatedUnits = []Unit{
 		Name:    "square mile",
 		Plural:  "square miles",
 		Type:    UnitTypeArea,
-		Aliases: []string{"square mile", "square miles", "sq mi", "mi2"},
+		Aliases: []string{"square mile", "square miles", "sq mi", "mi2", "mi²"},
 		ToBase:  2589988.0,
 	},
 	{
@@ -603,14 +844,15 @@ var curatedUnits = []Unit{
 	// VOLUME (base: liter)
 	// ════════════════════════════════════════════════════════════
 	{
-		Code:    "L",
-		Symbol:  "L",
-		Name:    "liter",
-		Plural:  "liters",
-		Type:    UnitTypeVolume,
-		Aliases: []string{"liter", "liters", "litre", "litres", "l"},
-		ToBase:  1.0,
-		IsBase:  true,
+		Code:     "L",
+		Symbol:   "L",
+		Name:     "liter",
+		Plural:   "liters",
+		Type:     UnitTypeVolume,
+		Aliases:  []string{"liter", "liters", "litre", "litres", "l"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
 	},
 	{
 		Code:    "mL",
@@ -622,31 +864,34 @@ var curatedUnits = []Unit{
 		ToBase:  0.001,
 	},
 	{
-		Code:    "gal",
-		Symbol:  "gal",
-		Name:    "gallon",
-		Plural:  "gallons",
-		Type:    UnitTypeVolume,
-		Aliases: []string{"gallon", "gallons"},
-		ToBase:  3.78541, // US gallon
+		Code:     "gal",
+		Symbol:   "gal",
+		Name:     "gallon",
+		Plural:   "gallons",
+		Type:     UnitTypeVolume,
+		Aliases:  []string{"gallon", "gallons"},
+		ToBase:   3.78541, // US gallon
+		Variants: map[Region]string{RegionUK: "impgal"},
 	},
 	{
-		Code:    "qt",
-		Symbol:  "qt",
-		Name:    "quart",
-		Plural:  "quarts",
-		Type:    UnitTypeVolume,
-		Aliases: []string{"quart", "quarts"},
-		ToBase:  0.946353,
+		Code:     "qt",
+		Symbol:   "qt",
+		Name:     "quart",
+		Plural:   "quarts",
+		Type:     UnitTypeVolume,
+		Aliases:  []string{"quart", "quarts"},
+		ToBase:   0.946353,
+		Variants: map[Region]string{RegionUK: "impqt"},
 	},
 	{
-		Code:    "pt",
-		Symbol:  "pt",
-		Name:    "pint",
-		Plural:  "pints",
-		Type:    UnitTypeVolume,
-		Aliases: []string{"pint", "pints"},
-		ToBase:  0.473176,
+		Code:     "pt",
+		Symbol:   "pt",
+		Name:     "pint",
+		Plural:   "pints",
+		Type:     UnitTypeVolume,
+		Aliases:  []string{"pint", "pints"},
+		ToBase:   0.473176,
+		Variants: map[Region]string{RegionUK: "imppt"},
 	},
 	{
 		Code:    "cup",
@@ -658,13 +903,14 @@ var curatedUnits = []Unit{
 		ToBase:  0.236588,
 	},
 	{
-		Code:    "floz",
-		Symbol:  "fl oz",
-		Name:    "fluid ounce",
-		Plural:  "fluid ounces",
-		Type:    UnitTypeVolume,
-		Aliases: []string{"fluid ounce", "fluid ounces", "fl oz"},
-		ToBase:  0.0295735,
+		Code:     "floz",
+		Symbol:   "fl oz",
+		Name:     "fluid ounce",
+		Plural:   "fluid ounces",
+		Type:     UnitTypeVolume,
+		Aliases:  []string{"fluid ounce", "fluid ounces", "fl oz"},
+		ToBase:   0.0295735,
+		Variants: map[Region]string{RegionUK: "impfloz"},
 	},
 	{
 		Code:    "tbsp",
@@ -690,9 +936,238 @@ var curatedUnits = []Unit{
 		Name:    "cubic meter",
 		Plural:  "cubic meters",
 		Type:    UnitTypeVolume,
-		Aliases: []string{"cubic meter", "cubic meters", "cubic metre", "cubic metres"},
+		Aliases: []string{"cubic meter", "cubic meters", "cubic metre", "cubic metres", "m³"},
 		ToBase:  1000.0,
 	},
+	{
+		Code:    "bbl",
+		Symbol:  "bbl",
+		Name:    "barrel",
+		Plural:  "barrels",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"barrel", "barrels"},
+		ToBase:  158.987294928, // Oil barrel: 42 US gallons
+	},
+
+	// ════════════════════════════════════════════════════════════
+	// POWER (base: watt)
+	// ════════════════════════════════════════════════════════════
+	{
+		Code:     "W",
+		Symbol:   "W",
+		Name:     "watt",
+		Plural:   "watts",
+		Type:     UnitTypePower,
+		Aliases:  []string{"watt", "watts"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
+	},
+	{
+		Code:    "hp",
+		Symbol:  "hp",
+		Name:    "horsepower",
+		Plural:  "horsepower",
+		Type:    UnitTypePower,
+		Aliases: []string{"horsepower"},
+		ToBase:  745.7,
+	},
+
+	// ════════════════════════════════════════════════════════════
+	// ENERGY (base: joule)
+	// ════════════════════════════════════════════════════════════
+	{
+		Code:     "J",
+		Symbol:   "J",
+		Name:     "joule",
+		Plural:   "joules",
+		Type:     UnitTypeEnergy,
+		Aliases:  []string{"joule", "joules"},
+		ToBase:   1.0,
+		IsBase:   true,
+		SIPrefix: true,
+	},
+	{
+		Code:     "Wh",
+		Symbol:   "Wh",
+		Name:     "watt-hour",
+		Plural:   "watt-hours",
+		Type:     UnitTypeEnergy,
+		Aliases:  []string{"watt-hour", "watt-hours"},
+		ToBase:   3600.0,
+		SIPrefix: true,
+	},
+	{
+		Code:     "cal",
+		Symbol:   "cal",
+		Name:     "calorie",
+		Plural:   "calories",
+		Type:     UnitTypeEnergy,
+		Aliases:  []string{"calorie", "calories"},
+		ToBase:   4.184,
+		SIPrefix: true,
+	},
+	{
+		Code:    "BTU",
+		Symbol:  "BTU",
+		Name:    "British thermal unit",
+		Plural:  "British thermal units",
+		Type:    UnitTypeEnergy,
+		Aliases: []string{"btus"},
+		ToBase:  1055.05585262,
+	},
+	{
+		Code:   "MMBtu",
+		Symbol: "MMBtu",
+		Name:   "million BTU",
+		Plural: "million BTU",
+		Type:   UnitTypeEnergy,
+		ToBase: 1055.05585262e6,
+	},
+	{
+		Code:    "thm",
+		Symbol:  "thm",
+		Name:    "therm",
+		Plural:  "therms",
+		Type:    UnitTypeEnergy,
+		Aliases: []string{"therm", "therms"},
+		ToBase:  105.505585262e6,
+	},
+
+	// ════════════════════════════════════════════════════════════
+	// ANGLE (base: radian)
+	// ════════════════════════════════════════════════════════════
+	{
+		Code:    "rad",
+		Symbol:  "rad",
+		Name:    "radian",
+		Plural:  "radians",
+		Type:    UnitTypeAngle,
+		Aliases: []string{"radian", "radians"},
+		ToBase:  1.0,
+		IsBase:  true,
+	},
+	{
+		Code:    "deg",
+		Symbol:  "°",
+		Name:    "degree",
+		Plural:  "degrees",
+		Type:    UnitTypeAngle,
+		Aliases: []string{"degree", "degrees", "°"},
+		ToBase:  math.Pi / 180,
+	},
+	{
+		Code:    "grad",
+		Symbol:  "grad",
+		Name:    "gradian",
+		Plural:  "gradians",
+		Type:    UnitTypeAngle,
+		Aliases: []string{"gradian", "gradians", "gon"},
+		ToBase:  math.Pi / 200,
+	},
+	{
+		Code:    "turn",
+		Symbol:  "turn",
+		Name:    "turn",
+		Plural:  "turns",
+		Type:    UnitTypeAngle,
+		Aliases: []string{"turns", "revolution", "revolutions", "rev"},
+		ToBase:  2 * math.Pi,
+	},
+	{
+		Code:    "arcmin",
+		Symbol:  "′",
+		Name:    "arcminute",
+		Plural:  "arcminutes",
+		Type:    UnitTypeAngle,
+		Aliases: []string{"arcminute", "arcminutes"},
+		ToBase:  math.Pi / 10800,
+	},
+	{
+		Code:    "arcsec",
+		Symbol:  "″",
+		Name:    "arcsecond",
+		Plural:  "arcseconds",
+		Type:    UnitTypeAngle,
+		Aliases: []string{"arcsecond", "arcseconds"},
+		ToBase:  math.Pi / 648000,
+	},
+
+	// ════════════════════════════════════════════════════════════
+	// VOLUME - REGIONAL (explicit US customary / UK imperial)
+	// ════════════════════════════════════════════════════════════
+	{
+		Code:    "usgal",
+		Symbol:  "gal",
+		Name:    "US gallon",
+		Plural:  "US gallons",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"us gallon", "us gallons", "us gal"},
+		ToBase:  3.78541,
+	},
+	{
+		Code:    "usqt",
+		Symbol:  "qt",
+		Name:    "US quart",
+		Plural:  "US quarts",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"us quart", "us quarts", "us qt"},
+		ToBase:  0.946353,
+	},
+	{
+		Code:    "uspt",
+		Symbol:  "pt",
+		Name:    "US pint",
+		Plural:  "US pints",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"us pint", "us pints", "us pt"},
+		ToBase:  0.473176,
+	},
+	{
+		Code:    "usfloz",
+		Symbol:  "fl oz",
+		Name:    "US fluid ounce",
+		Plural:  "US fluid ounces",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"us fluid ounce", "us fluid ounces", "us fl oz"},
+		ToBase:  0.0295735,
+	},
+	{
+		Code:    "impgal",
+		Symbol:  "gal",
+		Name:    "imperial gallon",
+		Plural:  "imperial gallons",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"imperial gallon", "imperial gallons", "uk gallon", "uk gallons", "imperial gal", "uk gal"},
+		ToBase:  4.54609,
+	},
+	{
+		Code:    "impqt",
+		Symbol:  "qt",
+		Name:    "imperial quart",
+		Plural:  "imperial quarts",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"imperial quart", "imperial quarts", "uk quart", "uk quarts", "imperial qt", "uk qt"},
+		ToBase:  1.1365225,
+	},
+	{
+		Code:    "imppt",
+		Symbol:  "pt",
+		Name:    "imperial pint",
+		Plural:  "imperial pints",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"imperial pint", "imperial pints", "uk pint", "uk pints", "imperial pt", "uk pt"},
+		ToBase:  0.56826125,
+	},
+	{
+		Code:    "impfloz",
+		Symbol:  "fl oz",
+		Name:    "imperial fluid ounce",
+		Plural:  "imperial fluid ounces",
+		Type:    UnitTypeVolume,
+		Aliases: []string{"imperial fluid ounce", "imperial fluid ounces", "uk fluid ounce", "uk fluid ounces", "imperial fl oz", "uk fl oz"},
+		ToBase:  0.0284130625,
+	},
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -719,23 +1194,34 @@ func IsUnit(s string) bool {
 
 // IsUnitCode checks if a string is a unit code.
 func IsUnitCode(code string) bool {
-	return units.byCode[code] != nil || units.byCode[strings.ToLower(code)] != nil
+	return units.code(code) != nil || units.code(strings.ToLower(code)) != nil
 }
 
-// AllUnits returns all curated units.
+// AllUnits returns all curated and user-registered units.
 func AllUnits() []Unit {
-	return curatedUnits
+	units.mu.RLock()
+	defer units.mu.RUnlock()
+
+	all := make([]Unit, 0, len(curatedUnits)+len(customUnits))
+	all = append(all, curatedUnits...)
+	for _, u := range customUnits {
+		all = append(all, *u)
+	}
+	return all
 }
 
 // UnitsByType returns all units of a given type.
 func UnitsByType(t UnitType) []*Unit {
-	return units.byType[t]
+	units.mu.RLock()
+	defer units.mu.RUnlock()
+	return append([]*Unit(nil), units.byType[t]...)
 }
 
 // UnitCodes returns all unit codes.
 func UnitCodes() []string {
-	codes := make([]string, len(curatedUnits))
-	for i, u := range curatedUnits {
+	all := AllUnits()
+	codes := make([]string, len(all))
+	for i, u := range all {
 		codes[i] = u.Code
 	}
 	return codes
@@ -743,7 +1229,7 @@ func UnitCodes() []string {
 
 // BaseUnit returns the base unit for a given unit type.
 func BaseUnit(t UnitType) *Unit {
-	for _, u := range units.byType[t] {
+	for _, u := range UnitsByType(t) {
 		if u.IsBase {
 			return u
 		}
@@ -775,3 +1261,263 @@ func CompatibleUnits(a, b string) bool {
 
 	return unitA.Type == unitB.Type
 }
+
+// ════════════════════════════════════════════════════════════════
+// CUSTOM UNITS
+// ════════════════════════════════════════════════════════════════
+
+// customUnits holds units added with RegisterUnit, by code.
+// Guarded by units.mu.
+var customUnits = map[string]*Unit{}
+
+// customUnitTypes holds unit types added with RegisterUnitType.
+var (
+	customTypesMu   sync.RWMutex
+	customTypeNames = map[UnitType]string{}
+	customTypeIDs   = map[string]UnitType{}
+)
+
+// firstCustomUnitType is the first UnitType handed out by RegisterUnitType.
+const firstCustomUnitType UnitType = 1000
+
+// RegisterUnitType returns a new unit type for a user-defined dimension
+// (e.g., "story points"). Registering the same name again returns the
+// existing type.
+func RegisterUnitType(name string) UnitType {
+	key := strings.ToLower(strings.TrimSpace(name))
+
+	customTypesMu.Lock()
+	defer customTypesMu.Unlock()
+
+	if t, ok := customTypeIDs[key]; ok {
+		return t
+	}
+	t := firstCustomUnitType + UnitType(len(customTypeIDs))
+	customTypeIDs[key] = t
+	customTypeNames[t] = key
+	return t
+}
+
+// customUnitTypeName returns the name of a type added with RegisterUnitType.
+func customUnitTypeName(t UnitType) (string, bool) {
+	customTypesMu.RLock()
+	defer customTypesMu.RUnlock()
+	name, ok := customTypeNames[t]
+	return name, ok
+}
+
+// RegisterUnit adds a user-defined unit to the global registry.
+// ToBase is relative to the base unit of u.Type; use RegisterUnitType
+// for a new dimension and set IsBase on its first unit.
+// Registering a code again replaces the earlier custom unit, so
+// definitions can be re-evaluated. Built-in units cannot be replaced.
+// Units are never changed once registered: values holding the earlier
+// unit keep it. Engines keep their own units in a UnitSet instead.
+func RegisterUnit(u Unit) (*Unit, error) {
+	if err := checkCustomUnit(&u); err != nil {
+		return nil, err
+	}
+
+	units.mu.Lock()
+	defer units.mu.Unlock()
+
+	// The plural becomes an alias when it is not taken
+	if u.Plural != "" && !slices.Contains(u.Aliases, u.Plural) {
+		if taken := units.byAlias[strings.ToLower(u.Plural)]; taken == nil || taken.Code == u.Code {
+			u.Aliases = append(u.Aliases, u.Plural)
+		}
+	}
+
+	names := append([]string{u.Code}, u.Aliases...)
+	for _, name := range names {
+		existing := units.byCode[name]
+		if existing == nil {
+			existing = units.byAlias[strings.ToLower(name)]
+		}
+		if existing != nil && customUnits[existing.Code] != existing {
+			return nil, errors.TypeErrorf("%s is already a built-in unit", name)
+		}
+	}
+
+	if old, ok := customUnits[u.Code]; ok {
+		units.unregister(old)
+	}
+	nu := &u
+	customUnits[u.Code] = nu
+	units.register(nu)
+	return nu, nil
+}
+
+// checkCustomUnit checks a user-defined unit and fills in its symbol and
+// name. Its aliases are copied, so adding to them leaves the caller's.
+func checkCustomUnit(u *Unit) error {
+	u.Code = strings.TrimSpace(u.Code)
+	if u.Code == "" {
+		return errors.TypeError("unit code is required")
+	}
+	if u.ToBase <= 0 {
+		return errors.TypeErrorf("unit %s must have a positive size", u.Code)
+	}
+	if u.Type == UnitTypeTemperature {
+		return errors.TypeError("custom temperature units are not supported")
+	}
+	if u.Symbol == "" {
+		u.Symbol = u.Code
+	}
+	if u.Name == "" {
+		u.Name = u.Code
+	}
+	u.Aliases = slices.Clone(u.Aliases)
+	return nil
+}
+
+// isRegisteredUnit reports whether name is the code or an alias of a unit
+// in the global registry.
+func isRegisteredUnit(name string) bool {
+	units.mu.RLock()
+	defer units.mu.RUnlock()
+	return units.byCode[name] != nil || units.byAlias[strings.ToLower(name)] != nil
+}
+
+// ════════════════════════════════════════════════════════════════
+// UNIT SETS
+// ════════════════════════════════════════════════════════════════
+
+// UnitSet holds the units a user defines, apart from the built-in ones,
+// so each engine keeps its own. A unit defined again is replaced by a new
+// one: units handed out never change.
+type UnitSet struct {
+	mu     sync.RWMutex
+	byCode map[string]*Unit
+	byName map[string]*Unit // Lower-case codes and aliases
+}
+
+// NewUnitSet returns an empty unit set.
+func NewUnitSet() *UnitSet {
+	return &UnitSet{
+		byCode: make(map[string]*Unit),
+		byName: make(map[string]*Unit),
+	}
+}
+
+// Clone returns a copy of the set. The units themselves are shared, as
+// they never change.
+func (s *UnitSet) Clone() *UnitSet {
+	clone := NewUnitSet()
+	if s == nil {
+		return clone
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	maps.Copy(clone.byCode, s.byCode)
+	maps.Copy(clone.byName, s.byName)
+	return clone
+}
+
+// Define adds a unit to the set, as RegisterUnit does to the global
+// registry: replacing a unit of the same code, but no built-in unit.
+func (s *UnitSet) Define(u Unit) (*Unit, error) {
+	if err := checkCustomUnit(&u); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.byCode[u.Code]
+
+	// The plural becomes an alias when it is not taken
+	if u.Plural != "" && !slices.Contains(u.Aliases, u.Plural) && !isRegisteredUnit(u.Plural) {
+		if taken := s.byName[strings.ToLower(u.Plural)]; taken == nil || taken == old {
+			u.Aliases = append(u.Aliases, u.Plural)
+		}
+	}
+
+	for _, name := range append([]string{u.Code}, u.Aliases...) {
+		if isRegisteredUnit(name) {
+			return nil, errors.TypeErrorf("%s is already a built-in unit", name)
+		}
+		if taken := s.byName[strings.ToLower(name)]; taken != nil && taken != old {
+			return nil, errors.TypeErrorf("%s is already unit %s", name, taken.Code)
+		}
+	}
+
+	if old != nil {
+		delete(s.byCode, old.Code)
+		for name, x := range s.byName {
+			if x == old {
+				delete(s.byName, name)
+			}
+		}
+	}
+	nu := &u
+	s.byCode[nu.Code] = nu
+	s.byName[strings.ToLower(nu.Code)] = nu
+	for _, alias := range nu.Aliases {
+		s.byName[strings.ToLower(alias)] = nu
+	}
+	return nu, nil
+}
+
+// Lookup finds a unit of the set by code or alias, or returns nil. A nil
+// set has no units.
+func (s *UnitSet) Lookup(name string) *Unit {
+	if s == nil {
+		return nil
+	}
+	name = strings.TrimSpace(name)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if u, ok := s.byCode[name]; ok {
+		return u
+	}
+	return s.byName[strings.ToLower(name)]
+}
+
+// Parse parses a unit as ParseUnit does, trying the set's units first.
+func (s *UnitSet) Parse(name string) *Unit {
+	if u := s.Lookup(name); u != nil {
+		return u
+	}
+	// Rates of the set's data units: "blocks/s"
+	if num, den, ok := strings.Cut(name, "/"); ok && (s.Lookup(num) != nil || s.Lookup(den) != nil) {
+		return RateUnit(s.Parse(num), s.Parse(den))
+	}
+	return ParseUnit(name)
+}
+
+// Units returns the set's units, by code.
+func (s *UnitSet) Units() []*Unit {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := slices.Collect(maps.Values(s.byCode))
+	slices.SortFunc(list, func(a, b *Unit) int { return strings.Compare(a.Code, b.Code) })
+	return list
+}
+
+// unregister removes a unit's code, aliases and type entry.
+// Caller must hold the write lock.
+func (r *UnitRegistry) unregister(u *Unit) {
+	if r.exact[u.Code] == u {
+		delete(r.exact, u.Code)
+	}
+	for _, code := range []string{u.Code, strings.ToLower(u.Code), strings.ToUpper(u.Code)} {
+		if r.byCode[code] == u {
+			delete(r.byCode, code)
+		}
+	}
+	for _, alias := range u.Aliases {
+		if r.byAlias[strings.ToLower(alias)] == u {
+			delete(r.byAlias, strings.ToLower(alias))
+		}
+	}
+	list := r.byType[u.Type]
+	for i, x := range list {
+		if x == u {
+			r.byType[u.Type] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+}
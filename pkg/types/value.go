@@ -3,7 +3,10 @@
 package types
 
 import (
+	"math/big"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ValueKind represents the type of a Value.
@@ -18,6 +21,11 @@ const (
 	ValueMetal                       // Precious metal: 1 oz gold
 	ValueCrypto                      // Cryptocurrency: 0.5 BTC
 	ValueError                       // Error during evaluation
+	ValueDate                        // Calendar date: Mar 1, today
+	ValueVector                      // Vector of numbers: [1, 2, 3]
+	ValueMatrix                      // Matrix of numbers: [[1, 2], [3, 4]]
+	ValuePlot                        // Chart of points: plot(f, 0..10)
+	ValueSparkline                   // Inline chart of values: spark([3, 5, 9])
 )
 
 // String returns the kind name.
@@ -37,6 +45,16 @@ func (k ValueKind) String() string {
 		return "metal"
 	case ValueCrypto:
 		return "crypto"
+	case ValueDate:
+		return "date"
+	case ValueVector:
+		return "vector"
+	case ValueMatrix:
+		return "matrix"
+	case ValuePlot:
+		return "plot"
+	case ValueSparkline:
+		return "sparkline"
 	case ValueError:
 		return "error"
 	default:
@@ -52,14 +70,62 @@ type Value struct {
 	// Numeric value (used by all numeric kinds)
 	Num float64
 
+	// Exact whole number too large for Num, which is then ±Inf (for
+	// ValueNumber): 1000!
+	Big *big.Int
+
+	// Uncertainty of Num (standard uncertainty, in the same unit): 100 ± 5
+	Uncertainty float64
+
 	// Type-specific data
 	Curr   *Currency // For ValueCurrency
 	Unit   *Unit     // For ValueWithUnit
 	Metal  *Metal    // For ValueMetal
 	Crypto *Crypto   // For ValueCrypto
 
+	// Purity of gold in karats (for ValueMetal): 18 for 18k gold; 0 if
+	// not given, taken as pure
+	Karat int
+
+	// Sub-unit a crypto amount is shown in (for ValueCrypto): sats for
+	// "50000 sats"; Num stays in whole coins. Nil shows whole coins
+	CryptoUnit *CryptoUnit
+
+	// Optional ingredient for ValueWithUnit (enables volume ↔ weight)
+	Ingredient *Ingredient
+
+	// Calendar date (for ValueDate), at midnight local time
+	Time time.Time
+
+	// Elements (for ValueVector and ValueMatrix)
+	Vec []float64
+	Mat *Matrix
+
+	// Points to chart (for ValuePlot and ValueSparkline)
+	Plot *Plot
+
+	// How this value shows currencies, overriding the display setting
+	// ("as code"); nil uses the setting
+	CurrencyDisplay *CurrencyDisplay
+
+	// Shown with a scale suffix (for ValueNumber and ValueCurrency),
+	// having been written with one: 5k, $1.2M
+	Compact bool
+
+	// Shown in its own unit whatever the length display, having been
+	// converted to it: 2 m in ft (for ValueWithUnit)
+	KeepUnit bool
+
+	// When the exchange rate behind a converted amount was fetched: for
+	// several conversions, the oldest. Zero if no fetched rate was used.
+	AsOf time.Time
+
 	// Error message (for ValueError)
 	Err string
+
+	// The error is from running over a resource limit: a timeout, or a
+	// list, range, input or call depth too large (for ValueError)
+	Limited bool
 }
 
 // ════════════════════════════════════════════════════════════════
@@ -132,6 +198,65 @@ func CryptoValue(amount float64, crypto *Crypto) Value {
 	}
 }
 
+// DateValue creates a calendar date value. The time of day is dropped.
+func DateValue(t time.Time) Value {
+	y, m, d := t.Date()
+	return Value{
+		Kind: ValueDate,
+		Time: time.Date(y, m, d, 0, 0, 0, 0, t.Location()),
+	}
+}
+
+// maxExactFloat is the largest whole number every float64 up to holds
+// exactly: 2^53.
+var maxExactFloat = big.NewInt(1 << 53)
+
+// BigInteger creates a plain number from a whole number of any size,
+// keeping it exactly when a float64 would round it: above 2^53.
+func BigInteger(b *big.Int) Value {
+	f, _ := new(big.Float).SetInt(b).Float64()
+	if b.CmpAbs(maxExactFloat) <= 0 {
+		return Number(f)
+	}
+	return Value{
+		Kind: ValueNumber,
+		Num:  f,
+		Big:  b,
+	}
+}
+
+// VectorValue creates a vector value.
+func VectorValue(v []float64) Value {
+	return Value{
+		Kind: ValueVector,
+		Vec:  v,
+	}
+}
+
+// MatrixValue creates a matrix value.
+func MatrixValue(m *Matrix) Value {
+	return Value{
+		Kind: ValueMatrix,
+		Mat:  m,
+	}
+}
+
+// PlotValue creates a plot value.
+func PlotValue(p *Plot) Value {
+	return Value{
+		Kind: ValuePlot,
+		Plot: p,
+	}
+}
+
+// SparklineValue creates a sparkline value.
+func SparklineValue(p *Plot) Value {
+	return Value{
+		Kind: ValueSparkline,
+		Plot: p,
+	}
+}
+
 // Error creates an error value.
 func Error(message string) Value {
 	return Value{
@@ -173,6 +298,21 @@ func Errorf(format string, args ...any) Value {
 	}
 }
 
+// LimitError creates an error value for an evaluation that ran over a
+// resource limit.
+func LimitError(message string) Value {
+	v := Error(message)
+	v.Limited = true
+	return v
+}
+
+// LimitErrorf creates a resource limit error with formatted message.
+func LimitErrorf(format string, args ...any) Value {
+	v := Errorf(format, args...)
+	v.Limited = true
+	return v
+}
+
 // ════════════════════════════════════════════════════════════════
 // PREDICATES
 // ════════════════════════════════════════════════════════════════
@@ -187,6 +327,12 @@ func (v Value) IsError() bool {
 	return v.Kind == ValueError
 }
 
+// IsLimitError returns true if the value is an error from running over a
+// resource limit.
+func (v Value) IsLimitError() bool {
+	return v.Kind == ValueError && v.Limited
+}
+
 // IsNumeric returns true if the value has a numeric component.
 func (v Value) IsNumeric() bool {
 	switch v.Kind {
@@ -227,6 +373,31 @@ func (v Value) IsCrypto() bool {
 	return v.Kind == ValueCrypto
 }
 
+// IsVector returns true if the value is a vector.
+func (v Value) IsVector() bool {
+	return v.Kind == ValueVector
+}
+
+// IsMatrix returns true if the value is a matrix.
+func (v Value) IsMatrix() bool {
+	return v.Kind == ValueMatrix
+}
+
+// IsPlot returns true if the value is a plot.
+func (v Value) IsPlot() bool {
+	return v.Kind == ValuePlot
+}
+
+// IsSparkline returns true if the value is a sparkline.
+func (v Value) IsSparkline() bool {
+	return v.Kind == ValueSparkline
+}
+
+// IsDate returns true if the value is a calendar date.
+func (v Value) IsDate() bool {
+	return v.Kind == ValueDate
+}
+
 // ════════════════════════════════════════════════════════════════
 // ACCESSORS
 // ════════════════════════════════════════════════════════════════
@@ -267,13 +438,90 @@ func (v Value) UnitType() (UnitType, bool) {
 func (v Value) WithAmount(amount float64) Value {
 	result := v
 	result.Num = amount
+	result.Big = nil
+	return result
+}
+
+// WithIngredient returns a new value tagged with an ingredient.
+func (v Value) WithIngredient(in *Ingredient) Value {
+	result := v
+	result.Ingredient = in
+	return result
+}
+
+// WithKarat returns a new value of gold of the given purity.
+func (v Value) WithKarat(karat int) Value {
+	result := v
+	result.Karat = karat
+	return result
+}
+
+// WithCryptoUnit returns a new value shown in a crypto's sub-unit.
+func (v Value) WithCryptoUnit(unit *CryptoUnit) Value {
+	result := v
+	result.CryptoUnit = unit
+	return result
+}
+
+// WithCurrencyDisplay returns a new value shown with currency display d,
+// whatever the display setting.
+func (v Value) WithCurrencyDisplay(d CurrencyDisplay) Value {
+	result := v
+	result.CurrencyDisplay = &d
+	return result
+}
+
+// WithKeptUnit returns a new value shown in its own unit, whatever the
+// length display.
+func (v Value) WithKeptUnit() Value {
+	result := v
+	result.KeepUnit = true
+	return result
+}
+
+// WithCompact returns a new value shown with a scale suffix when large:
+// $1.2M.
+func (v Value) WithCompact() Value {
+	result := v
+	result.Compact = true
+	return result
+}
+
+// WithAsOf returns a new value computed with a rate fetched at t.
+func (v Value) WithAsOf(t time.Time) Value {
+	result := v
+	result.AsOf = t
+	return result
+}
+
+// WithUncertainty returns a new value with uncertainty u (taken as
+// positive).
+func (v Value) WithUncertainty(u float64) Value {
+	result := v
+	result.Uncertainty = absFloat(u)
+	return result
+}
+
+// Nominal returns the value without its uncertainty.
+func (v Value) Nominal() Value {
+	result := v
+	result.Uncertainty = 0
 	return result
 }
 
 // Negate returns the negated value.
 func (v Value) Negate() Value {
-	if v.IsError() || v.IsEmpty() {
+	switch {
+	case v.IsError() || v.IsEmpty():
 		return v
+	case v.IsVector():
+		return VectorValue(ScaleVector(v.Vec, -1))
+	case v.IsMatrix():
+		return MatrixValue(v.Mat.Scale(-1))
+	case v.IsPlot() || v.IsSparkline():
+		return Errorf("cannot negate a %s", v.Kind.String())
+	case v.Big != nil:
+		return BigInteger(new(big.Int).Neg(v.Big))
 	}
 	return v.WithAmount(-v.Num)
 }
@@ -282,41 +530,93 @@ func (v Value) Negate() Value {
 // FORMATTING
 // ════════════════════════════════════════════════════════════════
 
-// String returns a human-readable representation of the value.
+// String formats the value with the default options.
 func (v Value) String() string {
+	return v.Format(DefaultFormat)
+}
+
+// Format formats the value with the given options.
+func (v Value) Format(opts FormatOptions) string {
+	if opts.AsOf && !v.AsOf.IsZero() {
+		opts.AsOf = false
+		return v.Format(opts) + " (as of " + FormatAsOf(v.AsOf) + ")"
+	}
+	if v.CurrencyDisplay != nil {
+		opts.Currencies = *v.CurrencyDisplay
+	}
+	if v.Compact {
+		opts.Compact = true
+	}
+
+	// Uncertain values show both amounts in full: "100 kg ± 5 kg"
+	if v.Uncertainty != 0 && v.IsNumeric() {
+		return v.Nominal().Format(opts) + " ± " + v.Nominal().WithAmount(v.Uncertainty).Format(opts)
+	}
+
 	switch v.Kind {
 	case ValueEmpty:
 		return ""
 
 	case ValueNumber:
-		return formatNumber(v.Num)
+		if v.Big != nil {
+			return opts.bigInteger(v.Big)
+		}
+		return opts.number(v.Num)
 
 	case ValuePercentage:
-		return formatNumber(v.Num*100) + "%"
+		return opts.decimal(v.Num*100) + "%"
 
 	case ValueCurrency:
 		if v.Curr != nil {
-			return formatCurrency(v.Num, v.Curr)
+			return formatCurrency(v.Num, v.Curr, opts)
 		}
-		return formatNumber(v.Num)
+		return opts.number(v.Num)
 
 	case ValueWithUnit:
+		if v.Unit != nil && v.Ingredient != nil {
+			return opts.amount(v.Num, v.Unit) + " " + v.Unit.Code + " " + v.Ingredient.Name
+		}
 		if v.Unit != nil {
-			return formatNumber(v.Num) + " " + v.Unit.Code
+			return opts.amount(v.Num, v.Unit) + " " + v.Unit.Code
 		}
-		return formatNumber(v.Num)
+		return opts.number(v.Num)
 
 	case ValueMetal:
+		if v.Metal != nil && v.Karat > 0 {
+			return opts.number(v.Num) + " " + strconv.Itoa(v.Karat) + "k " + v.Metal.Code
+		}
 		if v.Metal != nil {
-			return formatNumber(v.Num) + " " + v.Metal.Code
+			return opts.number(v.Num) + " " + v.Metal.Code
 		}
-		return formatNumber(v.Num)
+		return opts.number(v.Num)
 
 	case ValueCrypto:
+		if v.CryptoUnit != nil {
+			amount := v.Num / v.CryptoUnit.Factor
+			return opts.number(amount) + " " + v.CryptoUnit.Name(amount)
+		}
 		if v.Crypto != nil {
-			return formatCrypto(v.Num, v.Crypto)
+			return formatCrypto(v.Num, v.Crypto, opts)
+		}
+		return opts.number(v.Num)
+
+	case ValueDate:
+		return v.Time.Format("Mon Jan 2, 2006")
+
+	case ValueVector:
+		return formatVector(v.Vec, opts)
+
+	case ValueMatrix:
+		if v.Mat != nil {
+			return formatMatrix(v.Mat, opts)
+		}
+		return "[]"
+
+	case ValuePlot, ValueSparkline:
+		if v.Plot != nil {
+			return v.Plot.Sparkline(PlotWidth)
 		}
-		return formatNumber(v.Num)
+		return ""
 
 	case ValueError:
 		return "Error: " + v.Err
@@ -328,48 +628,24 @@ func (v Value) String() string {
 
 // formatNumber formats a number with appropriate precision.
 func formatNumber(n float64) string {
-	// Handle negative
-	if n < 0 {
-		return "-" + formatNumber(-n)
-	}
-
-	// Determine precision based on magnitude
-	var decimals int
-	if n == float64(int64(n)) {
-		decimals = 0
-	} else if n >= 100 {
-		decimals = 2
-	} else if n >= 1 {
-		decimals = 2
-	} else if n >= 0.01 {
-		decimals = 4
-	} else {
-		decimals = 6
-	}
-
-	return formatFloatTrimmed(n, decimals)
+	return DefaultFormat.number(n)
 }
 
-// formatFloatTrimmed formats a float and trims trailing zeros.
-func formatFloatTrimmed(n float64, maxDecimals int) string {
-	str := formatFloat(n, maxDecimals)
-
-	// Trim trailing zeros after decimal point
-	if strings.Contains(str, ".") {
-		str = strings.TrimRight(str, "0")
-		str = strings.TrimRight(str, ".")
+// formatCurrency formats a currency value to its minor unit: $1.50,
+// ¥1000, BD1.250.
+func formatCurrency(amount float64, curr *Currency, opts FormatOptions) string {
+	amount = Round(amount, curr.MinorUnits, opts.Rounding)
+	numStr := opts.fixed(absFloat(amount), curr.MinorUnits, false)
+	if opts.Compact {
+		if s, ok := opts.compact(absFloat(amount)); ok {
+			numStr = s
+		}
 	}
 
-	return str
-}
-
-// formatCurrency formats a currency value.
-func formatCurrency(amount float64, curr *Currency) string {
-	// Format with 2 decimal places for currency
-	numStr := formatFloat(absFloat(amount), 2)
-
 	var result string
-	if curr.SymbolAfter {
+	if opts.Currencies == ShowCurrencyCodes || !curr.ShowsSymbol() {
+		result = numStr + " " + curr.Code
+	} else if curr.SymbolAfter {
 		result = numStr + curr.Symbol
 	} else {
 		result = curr.Symbol + numStr
@@ -383,14 +659,15 @@ func formatCurrency(amount float64, curr *Currency) string {
 }
 
 // formatCrypto formats a cryptocurrency value.
-func formatCrypto(amount float64, crypto *Crypto) string {
+func formatCrypto(amount float64, crypto *Crypto, opts FormatOptions) string {
 	// Use crypto's preferred decimal places
-	decimals := crypto.Decimals
+	decimals := crypto.MinorUnits
 	if decimals == 0 {
 		decimals = 4
 	}
 
-	numStr := formatFloatTrimmed(absFloat(amount), decimals)
+	amount = Round(amount, decimals, opts.Rounding)
+	numStr := opts.fixed(absFloat(amount), decimals, true)
 
 	// Use symbol if available, otherwise code
 	symbol := crypto.Code
@@ -399,8 +676,12 @@ func formatCrypto(amount float64, crypto *Crypto) string {
 	}
 
 	var result string
-	// Crypto symbols typically come before the amount
-	result = symbol + numStr
+	if opts.Currencies == ShowCurrencyCodes {
+		result = numStr + " " + crypto.Code
+	} else {
+		// Crypto symbols typically come before the amount
+		result = symbol + numStr
+	}
 
 	if amount < 0 {
 		result = "-" + result
@@ -496,7 +777,11 @@ func (v Value) ToMap() map[string]any {
 		// Nothing extra
 
 	case ValueNumber:
-		m["value"] = v.Num
+		if v.Big != nil {
+			m["big"] = v.Big.String()
+		} else {
+			m["value"] = v.Num
+		}
 
 	case ValuePercentage:
 		m["value"] = v.Num
@@ -515,6 +800,9 @@ func (v Value) ToMap() map[string]any {
 			m["unit"] = v.Unit.Code
 			m["unitType"] = v.Unit.Type.String()
 		}
+		if v.Ingredient != nil {
+			m["ingredient"] = v.Ingredient.Name
+		}
 
 	case ValueMetal:
 		m["amount"] = v.Num
@@ -522,6 +810,9 @@ func (v Value) ToMap() map[string]any {
 			m["metal"] = v.Metal.Code
 			m["name"] = v.Metal.Name
 		}
+		if v.Karat > 0 {
+			m["karat"] = v.Karat
+		}
 
 	case ValueCrypto:
 		m["amount"] = v.Num
@@ -529,12 +820,202 @@ func (v Value) ToMap() map[string]any {
 			m["crypto"] = v.Crypto.Code
 			m["name"] = v.Crypto.Name
 		}
+		if v.CryptoUnit != nil {
+			m["crypto_unit"] = v.CryptoUnit.Code
+		}
+
+	case ValueDate:
+		m["date"] = v.Time.Format("2006-01-02")
+
+	case ValueVector:
+		m["values"] = v.Vec
+
+	case ValueMatrix:
+		if v.Mat != nil {
+			rows := make([][]float64, v.Mat.Rows)
+			for i := range rows {
+				rows[i] = v.Mat.Row(i)
+			}
+			m["rows"] = rows
+		}
+
+	case ValuePlot, ValueSparkline:
+		if v.Plot != nil {
+			m["x"] = v.Plot.X
+			m["y"] = v.Plot.Y
+		}
 
 	case ValueError:
 		m["error"] = v.Err
+		if v.Limited {
+			m["limit"] = true
+		}
 	}
 
+	if v.Uncertainty != 0 {
+		m["uncertainty"] = v.Uncertainty
+	}
+	if v.CurrencyDisplay != nil {
+		m["currencyDisplay"] = v.CurrencyDisplay.String()
+	}
+	if v.Compact {
+		m["compact"] = true
+	}
+	if v.KeepUnit {
+		m["keepUnit"] = true
+	}
+	if !v.AsOf.IsZero() {
+		m["asOf"] = v.AsOf.UTC().Format(time.RFC3339)
+	}
 	m["display"] = v.String()
 
 	return m
 }
+
+// ValueFromMap rebuilds a value from its ToMap representation.
+// Unknown currencies, units, or kinds produce an error value.
+func ValueFromMap(m map[string]any) Value {
+	v := valueFromMap(m)
+	if u, ok := m["uncertainty"].(float64); ok && v.IsNumeric() {
+		v = v.WithUncertainty(u)
+	}
+	if s, ok := m["currencyDisplay"].(string); ok {
+		if d, ok := ParseCurrencyDisplay(s); ok {
+			v = v.WithCurrencyDisplay(d)
+		}
+	}
+	if compact, _ := m["compact"].(bool); compact {
+		v = v.WithCompact()
+	}
+	if keep, _ := m["keepUnit"].(bool); keep && v.IsUnit() {
+		v = v.WithKeptUnit()
+	}
+	if s, ok := m["asOf"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			v = v.WithAsOf(t)
+		}
+	}
+	return v
+}
+
+// valueFromMap rebuilds a value without its uncertainty or display
+// overrides.
+func valueFromMap(m map[string]any) Value {
+	str := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	num := func(key string) float64 {
+		n, _ := m[key].(float64)
+		return n
+	}
+
+	switch str("kind") {
+	case "", "empty":
+		return Empty()
+
+	case "number":
+		if b, ok := new(big.Int).SetString(str("big"), 10); ok {
+			return BigInteger(b)
+		}
+		return Number(num("value"))
+
+	case "percentage":
+		return Percentage(num("value"))
+
+	case "currency":
+		if c := CurrencyFromCode(str("currency")); c != nil {
+			return CurrencyValue(num("amount"), c)
+		}
+		return Errorf("unknown currency: %s", str("currency"))
+
+	case "unit":
+		u := ParseUnit(str("unit"))
+		if u == nil {
+			return Errorf("unknown unit: %s", str("unit"))
+		}
+		v := UnitValue(num("amount"), u)
+		if name := str("ingredient"); name != "" {
+			v = v.WithIngredient(ParseIngredient(name))
+		}
+		return v
+
+	case "metal":
+		if metal := ParseMetal(str("metal")); metal != nil {
+			return MetalValue(num("amount"), metal).WithKarat(int(num("karat")))
+		}
+		return Errorf("unknown metal: %s", str("metal"))
+
+	case "crypto":
+		if c := ParseCrypto(str("crypto")); c != nil {
+			return CryptoValue(num("amount"), c).WithCryptoUnit(ParseCryptoUnit(str("crypto_unit")))
+		}
+		return Errorf("unknown crypto: %s", str("crypto"))
+
+	case "date":
+		if t, ok := ParseDate(str("date")); ok {
+			return DateValue(t)
+		}
+		return Errorf("invalid date: %s", str("date"))
+
+	case "vector":
+		if vec, ok := floats(m["values"]); ok {
+			return VectorValue(vec)
+		}
+		return Error("invalid vector")
+
+	case "matrix":
+		rows, _ := m["rows"].([]any)
+		data := make([][]float64, len(rows))
+		for i, row := range rows {
+			r, ok := floats(row)
+			if !ok {
+				return Error("invalid matrix")
+			}
+			data[i] = r
+		}
+		if mat := NewMatrix(data); mat != nil {
+			return MatrixValue(mat)
+		}
+		return Error("invalid matrix")
+
+	case "plot", "sparkline":
+		x, okX := floats(m["x"])
+		y, okY := floats(m["y"])
+		if !okX || !okY || len(x) == 0 || len(x) != len(y) {
+			return Errorf("invalid %s", str("kind"))
+		}
+		if str("kind") == "sparkline" {
+			return SparklineValue(&Plot{X: x, Y: y})
+		}
+		return PlotValue(&Plot{X: x, Y: y})
+
+	case "error":
+		if limited, _ := m["limit"].(bool); limited {
+			return LimitError(str("error"))
+		}
+		return Error(str("error"))
+
+	default:
+		return Errorf("unknown value kind: %s", str("kind"))
+	}
+}
+
+// floats converts a decoded JSON array of numbers to a slice.
+func floats(x any) ([]float64, bool) {
+	switch xs := x.(type) {
+	case []float64:
+		return xs, true
+	case []any:
+		out := make([]float64, len(xs))
+		for i, n := range xs {
+			f, ok := n.(float64)
+			if !ok {
+				return nil, false
+			}
+			out[i] = f
+		}
+		return out, true
+	}
+	return nil, false
+}